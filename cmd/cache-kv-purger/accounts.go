@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/auth"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// accountRun is the outcome of running a command in one account of an accounts file
+type accountRun struct {
+	AccountID string        `json:"account_id"`
+	Name      string        `json:"name,omitempty"`
+	OK        bool          `json:"ok"`
+	Error     string        `json:"error,omitempty"`
+	Output    interface{}   `json:"output,omitempty"` // JSON output of the command in quiet mode, else its text
+	Duration  time.Duration `json:"-"`
+}
+
+// runAcrossAccounts runs the command once per account in the accounts file, each in its
+// own process with the account ID and token of the tenant in its environment, and prints a
+// per-account summary. It returns the exit code for the whole run.
+func runAcrossAccounts(cmd *cobra.Command, path string) int {
+	fail := func(err error) int {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if parts := strings.Fields(cmd.CommandPath()); len(parts) < 3 || (parts[1] != "kv" && parts[1] != "cache") {
+		return fail(fmt.Errorf("--accounts-file only works with kv and cache commands"))
+	}
+	if f := cmd.Flags().Lookup("account-id"); f != nil && f.Changed {
+		return fail(fmt.Errorf("--account-id cannot be combined with --accounts-file, the account comes from the file"))
+	}
+
+	tenants, err := config.LoadTenants(path)
+	if err != nil {
+		return fail(err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fail(fmt.Errorf("failed to find the executable for per-account runs: %w", err))
+	}
+
+	concurrency, _ := cmd.Flags().GetInt("account-concurrency")
+	if concurrency <= 0 {
+		concurrency = 3
+	}
+	args := accountRunArgs(os.Args[1:])
+	quiet := common.IsQuietMode()
+
+	// Per-account runs cannot prompt, so commands that would ask are confirmed once for all accounts
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, err := cmd.Flags().GetBool("force")
+	prompts := err == nil
+	if prompts && !dryRun && !force && !common.ConfirmAction(fmt.Sprintf("\nRun '%s' in %d accounts without further confirmation?",
+		strings.Join(append([]string{cmd.Root().Name()}, args...), " "), len(tenants))) {
+		fmt.Println("Operation cancelled.")
+		return 0
+	}
+
+	runs := make([]accountRun, len(tenants))
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, tenant := range tenants {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, tenant config.Tenant) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			child := exec.Command(executable, args...)
+			child.Env = append(os.Environ(), config.EnvAccountID+"="+tenant.AccountID, config.EnvAssumeYes+"=1")
+			if token := tenant.Token(); token != "" {
+				child.Env = append(child.Env, auth.EnvAPIToken+"="+token)
+			}
+
+			// In quiet mode stdout is kept apart so the JSON output stays parseable
+			var stdout, stderr bytes.Buffer
+			child.Stdout = &stdout
+			child.Stderr = &stderr
+			if !quiet {
+				child.Stderr = &stdout
+			}
+
+			start := time.Now()
+			runErr := child.Run()
+			run := accountRun{AccountID: tenant.AccountID, Name: tenant.Name, OK: runErr == nil, Duration: time.Since(start)}
+			if runErr != nil {
+				run.Error = errorLine(stderr.String() + stdout.String())
+				if run.Error == "" {
+					run.Error = runErr.Error()
+				}
+			}
+			if quiet && json.Valid(stdout.Bytes()) {
+				run.Output = json.RawMessage(stdout.Bytes())
+			} else if stdout.Len() > 0 {
+				run.Output = stdout.String()
+			}
+
+			mu.Lock()
+			runs[i] = run
+			if !quiet {
+				header := tenant.AccountID
+				if tenant.Name != "" {
+					header = fmt.Sprintf("%s (%s)", tenant.Name, tenant.AccountID)
+				}
+				fmt.Printf("=== %s ===\n%s\n", header, strings.TrimRight(stdout.String(), "\n"))
+			}
+			mu.Unlock()
+		}(i, tenant)
+	}
+	wg.Wait()
+
+	failed := 0
+	rows := make([][]string, len(runs))
+	for i, run := range runs {
+		status := "OK"
+		if !run.OK {
+			status = "FAILED"
+			failed++
+		}
+		rows[i] = []string{run.AccountID, run.Name, status, run.Duration.Round(time.Millisecond).String(), run.Error}
+	}
+
+	if quiet {
+		if err := common.OutputJSON(runs); err != nil {
+			return fail(err)
+		}
+	} else {
+		fmt.Printf("\nSummary: %d of %d accounts succeeded\n", len(runs)-failed, len(runs))
+		common.FormatTable([]string{"Account ID", "Name", "Status", "Duration", "Error"}, rows)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "command failed in %d of %d accounts\n", failed, len(runs))
+		return 1
+	}
+	return 0
+}
+
+// accountRunArgs returns the command line for a per-account run: the original arguments
+// without --accounts-file and --account-concurrency
+func accountRunArgs(args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		if name == "--accounts-file" || name == "--account-concurrency" {
+			if !hasValue {
+				i++ // Skip the flag's value
+			}
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// errorLine returns the error a failed command printed: the line cobra prefixes with
+// "Error: ", or the last line of output when errors are printed bare, as in quiet mode
+func errorLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines {
+		if msg, ok := strings.CutPrefix(line, "Error: "); ok {
+			return strings.TrimSpace(msg)
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
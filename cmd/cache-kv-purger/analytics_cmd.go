@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"cache-kv-purger/internal/analytics"
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// createAnalyticsCmd creates a command to show how well a zone is cached
+func createAnalyticsCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var since string
+	var top int
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Show the cache hit ratio, bandwidth and top cached URLs of a zone",
+		Long: `Show a snapshot of how well a zone was cached over a recent period, from Cloudflare's
+GraphQL analytics API: the share of requests and bandwidth served from cache, the traffic
+of each cache status, and the most requested cached URLs. Use it to decide what to purge
+or warm.
+
+Responses with the status hit, stale, updating or revalidated count as served from cache.
+The API token needs the Zone Analytics Read permission. How far back the analytics reach
+depends on the zone's plan.`,
+		Example: `  # Cache hit ratio of the last 24 hours
+  cache-kv-purger cache analytics --zone example.com
+
+  # The top 25 cached URLs of the last 6 hours
+  cache-kv-purger cache analytics --zone example.com --since 6h --top 25
+
+  # Since a fixed time, as JSON
+  cache-kv-purger cache analytics --zone example.com --since 2024-06-01T00:00:00Z --json`,
+		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
+			start, err := parseAnalyticsSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+
+			// Create API client
+			client, err := api.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to create API client: %w", err)
+			}
+
+			// The zone comes from --zone or the default zone in config
+			cfg, err := config.LoadFromFile("")
+			if err != nil {
+				cfg = config.New()
+			}
+			zone, _ := cmd.Flags().GetString("zone")
+			zoneID, err := cmdutil.ResolveZoneID(client, cfg, cmdutil.ResolveAccountID(cmd, cfg), zone)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := analytics.GetCacheSnapshot(client, zoneID, analytics.Options{Since: start, TopURLs: top})
+			if err != nil {
+				return err
+			}
+
+			if outputJSON {
+				return common.OutputJSON(snapshot)
+			}
+			printCacheSnapshot(zone, snapshot)
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&since, "since", "24h", "Start of the period: a duration back from now (e.g. 6h, 7d) or a time (RFC 3339, YYYY-MM-DD)")
+	cmd.Flags().IntVar(&top, "top", analytics.DefaultTopURLs, "Number of top cached URLs to show")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output the snapshot as JSON")
+
+	return cmd
+}
+
+// parseAnalyticsSince parses --since as a duration back from now, or as a time
+func parseAnalyticsSince(since string, now time.Time) (time.Time, error) {
+	if ago, err := common.ParseTTL(since); err == nil {
+		return now.Add(-ago), nil
+	}
+	start, err := common.ParseTimestamp(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since '%s': use a duration like 24h or 7d, or a time", since)
+	}
+	return start, nil
+}
+
+// printCacheSnapshot prints a cache snapshot as tables
+func printCacheSnapshot(zone string, snapshot *analytics.CacheSnapshot) {
+	fmt.Println(render.Bold(fmt.Sprintf("Cache analytics for %s", zone)))
+	fmt.Println(render.Dim(fmt.Sprintf("%s to %s", snapshot.Since.Local().Format(time.DateTime), snapshot.Until.Local().Format(time.DateTime))))
+
+	summary := render.NewTable("", "Total", "From cache", "Hit ratio")
+	summary.AddRow("Requests", strconv.FormatInt(snapshot.Requests, 10), strconv.FormatInt(snapshot.CachedRequests, 10),
+		formatRatio(snapshot.HitRatio))
+	summary.AddRow("Bandwidth", analytics.FormatBytes(snapshot.Bytes), analytics.FormatBytes(snapshot.CachedBytes),
+		formatRatio(snapshot.BandwidthHitRatio))
+	summary.Print()
+
+	if len(snapshot.ByStatus) > 0 {
+		fmt.Println(render.Bold("\nBy cache status:"))
+		statuses := render.NewTable("Status", "Requests", "Share", "Bandwidth")
+		for _, status := range snapshot.ByStatus {
+			share := 0.0
+			if snapshot.Requests > 0 {
+				share = float64(status.Requests) / float64(snapshot.Requests)
+			}
+			statuses.AddRow(status.Status, strconv.FormatInt(status.Requests, 10), formatRatio(share), analytics.FormatBytes(status.Bytes))
+		}
+		statuses.Print()
+	}
+
+	if len(snapshot.TopCachedURLs) > 0 {
+		fmt.Println(render.Bold("\nTop cached URLs:"))
+		urls := render.NewTable("URL", "Requests", "Bandwidth")
+		for _, url := range snapshot.TopCachedURLs {
+			urls.AddRow(url.URL, strconv.FormatInt(url.Requests, 10), analytics.FormatBytes(url.Bytes))
+		}
+		urls.Print()
+	}
+}
+
+// formatRatio formats a ratio as a percentage
+func formatRatio(ratio float64) string {
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
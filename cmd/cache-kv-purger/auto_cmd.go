@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/zones"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// autoItem is a line of a mixed purge list after classification
+type autoItem struct {
+	kind  cache.PurgeItemKind
+	host  string
+	value string
+}
+
+// autoZone is the files, prefixes and hosts of a mixed purge list that belong to one zone
+type autoZone struct {
+	zoneID     string
+	name       string
+	files      []string
+	prefixes   []string
+	hosts      []string
+	purged     int // Items purged
+	purgedURLs int // URLs purged, which count against the daily file budget
+	errors     []error
+}
+
+// total returns the number of items to purge in the zone
+func (z *autoZone) total() int {
+	return len(z.files) + len(z.prefixes) + len(z.hosts)
+}
+
+// createPurgeAutoCmd creates a command to purge a list mixing URLs, prefixes and hosts
+func createPurgeAutoCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var input string
+	var batchSize int
+
+	cmd := &cobra.Command{
+		Use:   "auto",
+		Short: "Purge a mixed list of URLs, prefixes and hosts",
+		Long: `Read a purge list that mixes full URLs, path prefixes and hostnames, one per line,
+and purge each with the matching purge type.
+
+A line with an http:// or https:// scheme is purged by file, a host followed by a
+path (such as example.com/blog/) by prefix, and a bare hostname by host. Blank
+lines and lines starting with # are skipped, and duplicates are removed.
+
+Without --zone, each item's zone is detected from its host and the zones in the
+account. Zones whose plan lacks prefix or host purges fail the plan check, or use
+--fallback-files. URLs are purged in batches of --batch-size, prefixes and hosts in
+batches of 100, followed by a per-zone summary. Use --dry-run to preview how the
+list was classified.`,
+		Example: `  # Preview how a purge list is classified and grouped by zone
+  cache-kv-purger cache purge auto --input list.txt --dry-run
+
+  # Purge the list without prompting
+  cache-kv-purger cache purge auto --input list.txt --force
+
+  # Purge a list from stdin into one zone
+  cat list.txt | cache-kv-purger cache purge auto --input - --zone example.com`,
+		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
+			if input == "" {
+				return fmt.Errorf("--input is required, use - to read the list from stdin")
+			}
+			if batchSize <= 0 || batchSize > 500 {
+				return fmt.Errorf("--batch-size must be between 1 and 500")
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			items, err := readAutoPurgeList(input)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return fmt.Errorf("purge list %s has no items", input)
+			}
+
+			// Create API client
+			client, err := api.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to create API client: %w", err)
+			}
+
+			// Get account ID for resolving zone names
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
+
+			zoneList, err := groupAutoPurgeItems(cmd, client, cfg, accountID, items, verbose)
+			if err != nil {
+				return err
+			}
+
+			zoneIDs := make([]string, len(zoneList))
+			for i, zone := range zoneList {
+				zoneIDs[i] = zone.zoneID
+			}
+
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, zoneIDs); err != nil {
+				return err
+			}
+
+			files, prefixes, hosts := 0, 0, 0
+			pending := zoneList[:0]
+			for _, zone := range zoneList {
+				if details, err := zones.GetZoneDetails(client, zone.zoneID); err == nil && details.Result.Name != "" {
+					zone.name = details.Result.Name
+				}
+
+				// Make sure the zone's plan can purge by prefix and host, or fall back to URLs
+				if len(zone.prefixes) > 0 {
+					handled, err := checkPurgePlan(client, zone.zoneID, "prefixes", zone.prefixes, dryRun, verbose)
+					if err != nil {
+						return err
+					}
+					if handled {
+						zone.prefixes = nil
+					}
+				}
+				if len(zone.hosts) > 0 {
+					handled, err := checkPurgePlan(client, zone.zoneID, "hosts", zone.hosts, dryRun, verbose)
+					if err != nil {
+						return err
+					}
+					if handled {
+						zone.hosts = nil
+					}
+				}
+
+				if zone.total() == 0 {
+					continue
+				}
+				files += len(zone.files)
+				prefixes += len(zone.prefixes)
+				hosts += len(zone.hosts)
+				pending = append(pending, zone)
+			}
+			zoneList = pending
+
+			if len(zoneList) == 0 {
+				return nil
+			}
+
+			if dryRun {
+				rows := make([][]string, len(zoneList))
+				for i, zone := range zoneList {
+					rows[i] = []string{zone.name, strconv.Itoa(len(zone.files)), strconv.Itoa(len(zone.prefixes)),
+						strconv.Itoa(len(zone.hosts)), strconv.Itoa(autoRequests(zone, batchSize))}
+				}
+				fmt.Printf("DRY RUN: Would purge %d URLs, %d prefixes and %d hosts across %d zones\n",
+					files, prefixes, hosts, len(zoneList))
+				common.FormatTable([]string{"Zone", "URLs", "Prefixes", "Hosts", "Requests"}, rows)
+				if verbose {
+					for _, zone := range zoneList {
+						for _, host := range zone.hosts {
+							fmt.Printf("  %s: %s (host)\n", zone.name, host)
+						}
+						for _, prefix := range zone.prefixes {
+							fmt.Printf("  %s: %s (prefix)\n", zone.name, prefix)
+						}
+						for _, file := range zone.files {
+							fmt.Printf("  %s: %s\n", zone.name, file)
+						}
+					}
+				}
+				return nil
+			}
+
+			if !common.ConfirmBatchOperation(files+prefixes+hosts, "URLs, prefixes and hosts", "purge", purgeFlagsVars.force) {
+				fmt.Println("Operation cancelled.")
+				return nil
+			}
+
+			// Check the daily purge budget, which counts URLs purged by file
+			budget, err := cache.LoadFileBudget("")
+			if err != nil {
+				fmt.Printf("Warning: daily purge count unavailable: %s\n", err)
+			}
+
+			concurrency := purgeFlagsVars.cacheConcurrency
+			if concurrency <= 0 && cfg != nil {
+				concurrency = cfg.GetCacheConcurrency()
+			}
+
+			for _, zone := range zoneList {
+				if verbose {
+					fmt.Printf("Purging %d URLs, %d prefixes and %d hosts in zone %s...\n",
+						len(zone.files), len(zone.prefixes), len(zone.hosts), zone.name)
+				}
+				purgeAutoZone(client, zone, batchSize, concurrency, verbose)
+
+				if budget != nil && len(zone.files) > 0 {
+					if err := budget.Record(zone.zoneID, zone.purgedURLs); err != nil {
+						fmt.Printf("Warning: %s\n", err)
+					}
+				}
+			}
+
+			// Per-zone summary
+			purged, failedZones := 0, 0
+			rows := make([][]string, len(zoneList))
+			for i, zone := range zoneList {
+				status := "ok"
+				if len(zone.errors) > 0 {
+					failedZones++
+					status = "failed"
+					if zone.purged > 0 {
+						status = "partial"
+					}
+				}
+				purged += zone.purged
+				rows[i] = []string{zone.name, strconv.Itoa(len(zone.files)), strconv.Itoa(len(zone.prefixes)),
+					strconv.Itoa(len(zone.hosts)), strconv.Itoa(zone.purged), strconv.Itoa(len(zone.errors)), status}
+			}
+			fmt.Println()
+			common.FormatTable([]string{"Zone", "URLs", "Prefixes", "Hosts", "Purged", "Failed Batches", "Status"}, rows)
+
+			for _, zone := range zoneList {
+				for i, err := range zone.errors {
+					if i == 3 { // Show at most 3 errors per zone
+						fmt.Printf("  - %s: ... and %d more errors\n", zone.name, len(zone.errors)-3)
+						break
+					}
+					fmt.Printf("  - %s: %s\n", zone.name, err)
+				}
+			}
+
+			fmt.Printf("Completed: Successfully purged %d of %d items across %d zones\n",
+				purged, files+prefixes+hosts, len(zoneList))
+			if failedZones > 0 {
+				return fmt.Errorf("%d of %d zones had purge errors", failedZones, len(zoneList))
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&input, "input", "", "Text file with one URL, prefix or host per line (- for stdin)")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 30, "Maximum number of URLs to purge in a single API request (max 500)")
+	cmd.Flags().BoolVar(&purgeFlagsVars.force, "force", false, "Skip confirmation prompt")
+
+	return cmd
+}
+
+// readAutoPurgeList reads and classifies a mixed purge list, skipping blank lines,
+// comments and duplicates. Errors name the line that could not be classified.
+func readAutoPurgeList(input string) ([]autoItem, error) {
+	var reader io.Reader = os.Stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read purge list: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var items []autoItem
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, host, value, err := cache.ClassifyPurgeItem(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if seen[string(kind)+" "+value] {
+			continue
+		}
+		seen[string(kind)+" "+value] = true
+		items = append(items, autoItem{kind: kind, host: host, value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read purge list: %w", err)
+	}
+	return items, nil
+}
+
+// groupAutoPurgeItems assigns classified items to zones: all of them to the zone given
+// with --zone, or each to the zone of its host detected from the account's zones
+func groupAutoPurgeItems(cmd *cobra.Command, client *api.Client, cfg *config.Config, accountID string,
+	items []autoItem, verbose bool) ([]*autoZone, error) {
+
+	zoneID := purgeFlagsVars.zoneID
+	if zoneID == "" {
+		zoneID, _ = cmd.Flags().GetString("zone")
+	}
+
+	hostZones := make(map[string]string)
+	if zoneID != "" {
+		resolved, err := cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			hostZones[item.host] = resolved
+		}
+	} else {
+		if accountID == "" {
+			return nil, fmt.Errorf("account ID is required to auto-detect zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config, or pass --zone")
+		}
+
+		hosts := make([]string, 0, len(items))
+		for _, item := range items {
+			hosts = append(hosts, item.host)
+		}
+		hosts = common.RemoveDuplicates(hosts)
+		sort.Strings(hosts)
+
+		if verbose {
+			fmt.Printf("Auto-detecting zones for %d hosts...\n", len(hosts))
+		}
+
+		detected, unknownHosts, err := zones.DetectZonesFromHosts(client, accountID, hosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect zones: %w", err)
+		}
+		if len(unknownHosts) > 0 {
+			return nil, fmt.Errorf("%d hosts don't belong to any zone in the account: %s",
+				len(unknownHosts), strings.Join(unknownHosts, ", "))
+		}
+		hostZones = detected
+	}
+
+	byZone := make(map[string]*autoZone)
+	var zoneList []*autoZone
+	for _, item := range items {
+		zone, ok := byZone[hostZones[item.host]]
+		if !ok {
+			zone = &autoZone{zoneID: hostZones[item.host], name: hostZones[item.host]}
+			byZone[zone.zoneID] = zone
+			zoneList = append(zoneList, zone)
+		}
+		switch item.kind {
+		case cache.PurgeItemFile:
+			zone.files = append(zone.files, item.value)
+		case cache.PurgeItemPrefix:
+			zone.prefixes = append(zone.prefixes, item.value)
+		case cache.PurgeItemHost:
+			zone.hosts = append(zone.hosts, item.value)
+		}
+	}
+	sort.Slice(zoneList, func(i, j int) bool { return zoneList[i].zoneID < zoneList[j].zoneID })
+	return zoneList, nil
+}
+
+// purgeAutoZone purges a zone's hosts, prefixes and URLs in batches, recording the
+// number of purged items and the errors of failed batches
+func purgeAutoZone(client *api.Client, zone *autoZone, batchSize, concurrency int, verbose bool) {
+	progress := func(kind string) func(completed, total, successful int) {
+		if !verbose {
+			return nil
+		}
+		return func(completed, total, successful int) {
+			fmt.Printf("Zone %s: processed %d/%d %s batches, %d %s purged\n", zone.name, completed, total, kind, successful, kind)
+		}
+	}
+
+	if len(zone.hosts) > 0 {
+		successful, errors := cache.PurgeHostsInBatches(client, zone.zoneID, zone.hosts, cache.NewPurgeBatchOptions(
+			cache.WithConcurrency(concurrency),
+			cache.WithProgress(progress("hosts")),
+		))
+		zone.purged += len(successful)
+		zone.errors = append(zone.errors, errors...)
+	}
+
+	if len(zone.prefixes) > 0 {
+		successful, errors := cache.PurgePrefixesInBatches(client, zone.zoneID, zone.prefixes, cache.NewPurgeBatchOptions(
+			cache.WithConcurrency(concurrency),
+			cache.WithProgress(progress("prefixes")),
+		))
+		zone.purged += len(successful)
+		zone.errors = append(zone.errors, errors...)
+	}
+
+	if len(zone.files) > 0 {
+		processor := common.NewBatchProcessor().
+			WithBatchSize(batchSize).
+			WithConcurrency(concurrency).
+			WithProgressCallback(func(completed, total, successful int) {
+				if verbose {
+					fmt.Printf("Zone %s: processed %d/%d URL batches, %d URLs purged\n", zone.name, completed, total, successful)
+				}
+			})
+		successful, errors := processor.ProcessStrings(zone.files, func(batch []string) ([]string, error) {
+			if _, err := cache.PurgeFiles(client, zone.zoneID, batch); err != nil {
+				return nil, err
+			}
+			return batch, nil
+		})
+		zone.purged += len(successful)
+		zone.purgedURLs = len(successful)
+		zone.errors = append(zone.errors, errors...)
+	}
+}
+
+// autoRequests returns the number of purge requests a zone's items take
+func autoRequests(zone *autoZone, batchSize int) int {
+	return len(common.SplitIntoBatches(zone.files, batchSize)) +
+		len(common.SplitIntoBatches(zone.prefixes, cache.MaxPurgeBatchSize)) +
+		len(common.SplitIntoBatches(zone.hosts, cache.MaxPurgeBatchSize))
+}
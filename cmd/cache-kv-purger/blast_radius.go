@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/analytics"
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/zones"
+)
+
+// maxBlastRadiusQueries caps the analytics queries made for one confirmation prompt
+const maxBlastRadiusQueries = 10
+
+// zonesBlastRadius estimates the cached traffic that purging everything from each zone
+// would send to the origin, one line per zone. Zones whose analytics can't be read get
+// a line saying so instead.
+func zonesBlastRadius(client *api.Client, zoneIDs []string) []string {
+	var lines []string
+	for i, zoneID := range zoneIDs {
+		if i == maxBlastRadiusQueries {
+			lines = append(lines, fmt.Sprintf("... and %d more zones", len(zoneIDs)-i))
+			break
+		}
+
+		zoneName := zoneID
+		if details, err := zones.GetZoneDetails(client, zoneID); err == nil && details.Result.Name != "" {
+			zoneName = details.Result.Name
+		}
+		radius, err := analytics.EstimateZoneBlastRadius(client, zoneID, zoneName)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: no estimate (%v)", zoneName, err))
+			continue
+		}
+		lines = append(lines, radius.String())
+	}
+	return lines
+}
+
+// prefixesBlastRadius estimates the cached traffic under each broad prefix, one line per
+// prefix. Narrow prefixes are left out since their purge is unlikely to load the origin.
+func prefixesBlastRadius(client *api.Client, zoneID string, prefixes []string) []string {
+	var broad []string
+	for _, prefix := range prefixes {
+		if analytics.IsBroadPrefix(prefix) {
+			broad = append(broad, prefix)
+		}
+	}
+
+	var lines []string
+	for i, prefix := range broad {
+		if i == maxBlastRadiusQueries {
+			lines = append(lines, fmt.Sprintf("... and %d more broad prefixes", len(broad)-i))
+			break
+		}
+		radius, err := analytics.EstimatePrefixBlastRadius(client, zoneID, prefix)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: no estimate (%v)", prefix, err))
+			continue
+		}
+		lines = append(lines, radius.String())
+	}
+	return lines
+}
+
+// printBlastRadius prints blast radius estimates
+func printBlastRadius(lines []string) {
+	fmt.Println("\nEstimated blast radius (cached traffic over the last 24 hours):")
+	for _, line := range lines {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println("Purged content is fetched from the origin again until the cache refills.")
+}
+
+// confirmBlastRadius prints blast radius estimates and asks whether to go ahead with a purge.
+// A declined prompt, or one that can't be answered because stdin is not a terminal, is an
+// error, so an unattended run doesn't exit successfully having purged nothing.
+func confirmBlastRadius(lines []string, question string) error {
+	if !common.AssumeYes() && !common.StdinIsTerminal() {
+		return fmt.Errorf("the purge needs confirmation but stdin is not a terminal; pass --force, --yes or --quiet (or set %s) to purge without the prompt", config.EnvAssumeYes)
+	}
+
+	printBlastRadius(lines)
+	if !common.ConfirmAction("\n" + question) {
+		return fmt.Errorf("purge cancelled")
+	}
+	return nil
+}
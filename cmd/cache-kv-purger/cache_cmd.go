@@ -28,14 +28,19 @@ var purgeFlagsVars struct {
 	tags                 []string
 	hosts                []string
 	prefixes             []string
-	cacheConcurrency     int  // Concurrency for cache operations
-	multiZoneConcurrency int  // Concurrency for multi-zone operations
-	force                bool // Skip confirmation prompt
+	cacheConcurrency     int    // Concurrency for cache operations
+	multiZoneConcurrency int    // Concurrency for multi-zone operations
+	force                bool   // Skip confirmation prompt
+	skipPlanCheck        bool   // Don't check the zone plan before tag, prefix and host purges
+	fallbackFiles        string // JSON map of tags, prefixes or hosts to URLs purged when the plan lacks the purge type
 }
 
 func init() {
 	// Add purge command to cache command
 	cacheCmd.AddCommand(purgeCmd)
+	cacheCmd.AddCommand(createWarmCmd())
+	cacheCmd.AddCommand(createHistoryCmd())
+	cacheCmd.AddCommand(createAnalyticsCmd())
 
 	// Add purge subcommands to purge command
 	purgeCmd.AddCommand(createPurgeEverythingCmd())
@@ -43,10 +48,15 @@ func init() {
 	purgeCmd.AddCommand(createPurgeTagsCmd())
 	purgeCmd.AddCommand(createPurgePrefixesCmd())
 	purgeCmd.AddCommand(createPurgeHostsCmd())
+	purgeCmd.AddCommand(createPurgeFromSitemapCmd())
+	purgeCmd.AddCommand(createPurgeAutoCmd())
 
 	// Add cache command to root command
 	rootCmd.AddCommand(cacheCmd)
 
+	// The account used to resolve zone names, overriding CLOUDFLARE_ACCOUNT_ID and the config
+	cacheCmd.PersistentFlags().String("account-id", "", "Cloudflare Account ID used to resolve zone names (overrides the environment and config)")
+
 	// Add global flags to purge command
 	purgeCmd.PersistentFlags().StringVar(&purgeFlagsVars.zoneID, "zone", "", "Zone ID or name to purge content from")
 	purgeCmd.PersistentFlags().StringArrayVar(&purgeFlagsVars.zones, "zones", []string{}, "Zone IDs or names to purge content from (can be specified multiple times)")
@@ -56,4 +66,6 @@ func init() {
 	purgeCmd.PersistentFlags().IntVar(&purgeFlagsVars.cacheConcurrency, "concurrency", 10, "Number of concurrent cache operations (default 10, max 20)")
 	purgeCmd.PersistentFlags().IntVar(&purgeFlagsVars.multiZoneConcurrency, "zone-concurrency", 3, "Number of zones to process concurrently (default 3)")
 	purgeCmd.PersistentFlags().Bool("dry-run", false, "Show what would be purged without actually purging")
+	purgeCmd.PersistentFlags().BoolVar(&purgeFlagsVars.skipPlanCheck, "skip-plan-check", false, "Don't check that the zone's plan supports tag, prefix and host purges")
+	purgeCmd.PersistentFlags().StringVar(&purgeFlagsVars.fallbackFiles, "fallback-files", "", "JSON file mapping tags, prefixes or hosts to URLs, purged instead when the zone's plan lacks the purge type")
 }
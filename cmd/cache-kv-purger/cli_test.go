@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// fakeAPI is a Cloudflare API stand-in that records the requests of a test run
+type fakeAPI struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string // "METHOD /path body"
+}
+
+// newFakeAPI starts a fake API answering every request with respond, or with an empty
+// successful result when respond returns an empty body
+func newFakeAPI(t *testing.T, respond func(method, path, body string) (int, string)) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		api.mu.Lock()
+		api.requests = append(api.requests, r.Method+" "+r.URL.Path+" "+string(data))
+		api.mu.Unlock()
+
+		status, body := http.StatusOK, ""
+		if respond != nil {
+			status, body = respond(r.Method, r.URL.Path, string(data))
+		}
+		if body == "" {
+			body = `{"success": true, "result": {}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// Requests returns the recorded requests whose method and path contain match
+func (a *fakeAPI) Requests(match string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var matched []string
+	for _, request := range a.requests {
+		if strings.Contains(request, match) {
+			matched = append(matched, request)
+		}
+	}
+	return matched
+}
+
+// runCLI runs the command line against the fake API with a scratch home directory, so no
+// config, history or journal of the machine is read or written
+func runCLI(t *testing.T, api *fakeAPI, args ...string) error {
+	t.Helper()
+	return runCLIWithConfig(t, api, "", args...)
+}
+
+// runCLIWithConfig runs the command line like runCLI, with configJSON as the config file of
+// the scratch home directory when it isn't empty
+func runCLIWithConfig(t *testing.T, api *fakeAPI, configJSON string, args ...string) error {
+	t.Helper()
+	home := t.TempDir()
+	if configJSON != "" {
+		if err := os.WriteFile(filepath.Join(home, config.DefaultFileName), []byte(configJSON), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("CLOUDFLARE_API_TOKEN", "test-token")
+	t.Setenv("CLOUDFLARE_API_ENDPOINT", api.URL)
+	t.Setenv("CLOUDFLARE_ACCOUNT_ID", "test-account")
+
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(append([]string{"--retries", "0"}, args...))
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	return rootCmd.Execute()
+}
+
+// resetFlags restores every flag of the command tree to its default, since the commands
+// are package variables shared by the runs of a test binary
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
@@ -1,16 +1,21 @@
 package main
 
 import (
+	"bufio"
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/cache"
 	"cache-kv-purger/internal/cmdutil"
 	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
 	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/kv"
 	"cache-kv-purger/internal/zones"
 	"fmt"
 	"github.com/spf13/cobra"
+	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 // combinedCmd represents the combined command
@@ -30,8 +35,51 @@ var syncPurgeCmd = &cobra.Command{
 This powerful command combines the KV search capabilities with cache purging to:
 1. Find and delete KV keys matching specific criteria
 2. Purge associated cache tags in the same operation
+
+For the common case, --tag is used both as the Cloudflare cache tag and as the
+value of the KV metadata field (--tag-field, default "cache-tag"). The namespace
+and zone fall back to the configured defaults, and you are asked to confirm once.
+
+With --extract-tags, cache tags are read from the metadata of the matching keys.
+By default the cache-tag, cache-tags, cacheTags, tag and tags fields are read.
+Custom metadata schemas can set tag_extract_rules in the config file or pass
+--tag-extract-rule, for example "field=labels,delimiter=semicolon",
+"path=.cdn.tags" or "field=sku,template=product-{value}".
+
+Cache tags can be purged from several zones at once: repeat --zone, pass a comma
+separated list to --zones, or use --all-zones for every zone of the account. Zones
+are purged concurrently (--zone-concurrency) and the purge ID of each zone is shown
+in the summary table.
+
+The affected keys don't have to live in KV. With --source they are read from stdin
+("-"), an http(s) endpoint (for example a Worker in front of D1 or R2) or a CSV file,
+and go through the same tag extraction and purge; nothing is deleted from KV. Stdin
+and endpoints return one key per line, or a JSON array of keys or of objects with
+"key" and "metadata". CSV files need a header row: the key column is "key" (or
+--source-key-column), and the other columns are metadata. If no cache tags are found
+in the metadata, the keys themselves are purged as cache tags.
+
+With --from-export, the search runs against a file written by 'kv export' instead
+of the namespace, and the exact keys that would be deleted and the cache tags that
+would be purged are printed. It is always a dry run: no API requests are made, so
+zones must be named with --zone or --zones rather than resolved with --all-zones.
 `,
-	Example: `  # Purge KV keys with a specific search value and auto-extract matching cache tags
+	Example: `  # Purge everything tagged "products" from KV and the cache, using configured defaults
+  cache-kv-purger sync purge --tag products
+
+  # Same, across several zones
+  cache-kv-purger sync purge --tag products --zone example.com --zone example.org
+  cache-kv-purger sync purge --tag products --zones example.com,example.org
+
+  # Same, across every zone of the account
+  cache-kv-purger sync purge --tag products --all-zones
+
+  # Purge the cache tags of keys listed by another system, without touching KV
+  cat changed-products.txt | cache-kv-purger sync purge --source - --zone example.com
+  cache-kv-purger sync purge --source https://changes.example.com/since-last-deploy --zone example.com
+  cache-kv-purger sync purge --source exports/products.csv --source-key-column sku --tag-extract-rule "field=tags,delimiter=semicolon" --zone example.com
+
+  # Purge KV keys with a specific search value and auto-extract matching cache tags
   cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --search "product-123" --zone example.com
 
   # Purge KV keys with a search value and common derived cache tags 
@@ -43,11 +91,17 @@ This powerful command combines the KV search capabilities with cache purging to:
   # Use metadata field-specific search and purge cache
   cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --tag-field "type" --tag-value "temp" --zone example.com --cache-tag temp-data
   
+  # Read cache tags from a nested metadata field
+  cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --search "product-123" --zone example.com --tag-extract-rule "path=.cdn.tags"
+
   # Show detailed output with debug verbosity
   cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --search "product-123" --zone example.com --verbosity debug
   
   # Dry run to preview without making changes
-  cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --search "product-123" --zone example.com --dry-run`,
+  cache-kv-purger sync purge --namespace-id YOUR_NAMESPACE_ID --search "product-123" --zone example.com --dry-run
+
+  # Review offline which keys and cache tags a purge would affect, using a backup made with 'kv export'
+  cache-kv-purger sync purge --tag products --zone example.com --from-export backup.json`,
 	RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
 		// Get flags
 		accountID, _ := cmd.Flags().GetString("account-id")
@@ -56,61 +110,181 @@ This powerful command combines the KV search capabilities with cache purging to:
 		searchValue, _ := cmd.Flags().GetString("search")
 		tagField, _ := cmd.Flags().GetString("tag-field")
 		tagValue, _ := cmd.Flags().GetString("tag-value")
-		zone, _ := cmd.Flags().GetString("zone")
+		zoneFlags, _ := cmd.Flags().GetStringArray("zone")
 		cacheTags, _ := cmd.Flags().GetStringSlice("cache-tag")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		batchSize, _ := cmd.Flags().GetInt("batch-size")
 		concurrency, _ := cmd.Flags().GetInt("concurrency")
 		derivedTags, _ := cmd.Flags().GetBool("derived-tags")
 		extractTags, _ := cmd.Flags().GetBool("extract-tags")
+		extractRuleSpecs, _ := cmd.Flags().GetStringArray("tag-extract-rule")
+		tag, _ := cmd.Flags().GetString("tag")
+		zoneList, _ := cmd.Flags().GetStringSlice("zones")
+		allZones, _ := cmd.Flags().GetBool("all-zones")
+		zoneConcurrency, _ := cmd.Flags().GetInt("zone-concurrency")
+		force, _ := cmd.Flags().GetBool("force")
+		sourceSpec, _ := cmd.Flags().GetString("source")
+		sourceKeyColumn, _ := cmd.Flags().GetString("source-key-column")
+		fromExport, _ := cmd.Flags().GetString("from-export")
 
 		// Middleware now handles verbosity flags
 
-		// Validate inputs
-		if (searchValue == "" && tagField == "") || (namespaceID == "" && namespace == "") {
-			return fmt.Errorf("either search or tag-field, and either namespace-id or namespace are required")
+		// A single tag stands for both the KV metadata value and the cache tag
+		if tag != "" {
+			if searchValue != "" {
+				return fmt.Errorf("--tag cannot be combined with --search")
+			}
+			if tagValue != "" && tagValue != tag {
+				return fmt.Errorf("--tag and --tag-value must match when both are given")
+			}
+			if tagField == "" {
+				tagField = "cache-tag"
+			}
+			tagValue = tag
+			if len(cacheTags) == 0 {
+				cacheTags = []string{tag}
+			}
 		}
 
 		// Load config and fallback values
 		cfg, _ := config.LoadFromFile("")
 
-		// Load account ID if not provided
-		if accountID == "" && cfg != nil {
-			accountID = cfg.GetAccountID()
+		// Rules for finding cache tags in key metadata
+		extractRules, err := common.ResolveTagExtractRules(extractRuleSpecs, cfg)
+		if err != nil {
+			return err
 		}
 
-		// Create API client
-		client, err := api.NewClient()
-		if err != nil {
-			return fmt.Errorf("failed to create API client: %w", err)
+		// Load account ID and namespace ID if not provided
+		accountID = cmdutil.ResolveAccountID(cmd, cfg, accountID)
+		if cfg != nil {
+			if namespaceID == "" && namespace == "" {
+				namespaceID = cfg.GetNamespaceID()
+			}
 		}
 
-		// Create KV service
-		kvService := kv.NewKVService(client)
+		// Keys come from KV unless another source is given
+		var source cache.KeySource
+		if sourceSpec != "" {
+			if searchValue != "" || tagField != "" || tag != "" {
+				return fmt.Errorf("--source cannot be combined with --search, --tag-field or --tag")
+			}
+			if source, err = cache.NewKeySource(sourceSpec, os.Stdin); err != nil {
+				return err
+			}
+			if csvSource, ok := source.(*cache.CSVKeySource); ok {
+				csvSource.KeyColumn = sourceKeyColumn
+			}
+		}
 
-		// Resolve namespace if name is provided
-		if namespace != "" && namespaceID == "" {
-			nsID, err := kvService.ResolveNamespaceID(cmd.Context(), accountID, namespace)
-			if err != nil {
-				return fmt.Errorf("failed to resolve namespace: %w", err)
+		// An export is searched offline, so nothing can be changed
+		var exportItems []kv.BulkWriteItem
+		if fromExport != "" {
+			if source != nil {
+				return fmt.Errorf("--from-export cannot be combined with --source")
 			}
-			namespaceID = nsID
+			if allZones {
+				return fmt.Errorf("--all-zones needs the API to list zones; name the zones with --zone or --zones when using --from-export")
+			}
+			if exportItems, err = kv.ReadExportFile(fromExport); err != nil {
+				return err
+			}
+			dryRun = true
 		}
 
-		fmt.Println("Step 1: Searching for matching KV keys...")
+		// Validate inputs
+		if source == nil && ((searchValue == "" && tagField == "") || (namespaceID == "" && namespace == "" && fromExport == "")) {
+			return fmt.Errorf("either search, tag-field, tag, or source, and either namespace-id, namespace, or a default namespace in config are required")
+		}
 
-		// Search for keys
-		searchOptions := kv.SearchOptions{
-			SearchValue: searchValue,
-			TagField:    tagField,
-			TagValue:    tagValue,
-			BatchSize:   batchSize,
-			Concurrency: concurrency,
+		// Collect target zones, falling back to the default zone
+		zoneList = append(zoneFlags, zoneList...)
+		if allZones && len(zoneList) > 0 {
+			return fmt.Errorf("--all-zones cannot be combined with --zone or --zones")
+		}
+		if !allZones && len(zoneList) == 0 && cfg != nil && cfg.GetZoneID() != "" {
+			zoneList = []string{cfg.GetZoneID()}
+		}
+		if !allZones && len(zoneList) == 0 {
+			return fmt.Errorf("zone is required, specify it with --zone, --zones or --all-zones, CLOUDFLARE_ZONE_ID environment variable, or set a default zone in config")
+		}
+		if zoneConcurrency <= 0 && cfg != nil {
+			zoneConcurrency = cfg.GetMultiZoneConcurrency()
 		}
 
-		matchingKeys, err := kvService.Search(cmd.Context(), accountID, namespaceID, searchOptions)
-		if err != nil {
-			return fmt.Errorf("search failed: %w", err)
+		var client *api.Client
+		var kvService kv.KVService
+		var zoneIDs []string
+		if fromExport == "" {
+			// Create API client
+			client, err = api.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to create API client: %w", err)
+			}
+
+			// Create KV service
+			kvService = kv.NewKVService(client)
+
+			// Resolve namespace if name is provided
+			if source == nil && namespace != "" && namespaceID == "" {
+				nsID, err := kvService.ResolveNamespaceID(cmd.Context(), accountID, namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				namespaceID = nsID
+			}
+
+			// Resolve zones up front so protected resources are refused before anything is deleted
+			zoneList, zoneIDs, err = resolveSyncZones(client, accountID, zoneList, allZones)
+			if err != nil {
+				return err
+			}
+			if err := checkZonesProtection(cmd, client, zoneIDs); err != nil {
+				return err
+			}
+			if source == nil {
+				if err := cmdutil.CheckNamespaceProtection(cmd.Context(), cmd, cfg, kvService, accountID, namespaceID); err != nil {
+					return err
+				}
+			}
+		}
+
+		var matchingKeys []kv.KeyValuePair
+		if fromExport != "" {
+			fmt.Printf("Step 1: Searching for matching keys in export %s...\n", fromExport)
+
+			matchingKeys, err = kv.SearchExport(exportItems, kv.SearchOptions{
+				SearchValue: searchValue,
+				TagField:    tagField,
+				TagValue:    tagValue,
+			})
+			if err != nil {
+				return err
+			}
+		} else if source != nil {
+			fmt.Printf("Step 1: Reading keys from %s...\n", source.Name())
+
+			sourceKeys, err := source.Keys(cmd.Context())
+			if err != nil {
+				return err
+			}
+			matchingKeys = sourceKeyPairs(sourceKeys)
+		} else {
+			fmt.Println("Step 1: Searching for matching KV keys...")
+
+			// Search for keys
+			searchOptions := kv.SearchOptions{
+				SearchValue: searchValue,
+				TagField:    tagField,
+				TagValue:    tagValue,
+				BatchSize:   batchSize,
+				Concurrency: concurrency,
+			}
+
+			matchingKeys, err = kvService.Search(cmd.Context(), accountID, namespaceID, searchOptions)
+			if err != nil {
+				return fmt.Errorf("search failed: %w", err)
+			}
 		}
 
 		// Extract key names
@@ -119,7 +293,11 @@ This powerful command combines the KV search capabilities with cache purging to:
 			keyNames[i] = key.Key
 		}
 
-		fmt.Printf("Found %d matching KV keys\n", len(keyNames))
+		if source != nil {
+			fmt.Printf("Read %d keys from %s\n", len(keyNames), source.Name())
+		} else {
+			fmt.Printf("Found %d matching KV keys\n", len(keyNames))
+		}
 
 		// If verbose and keys found, show a sample
 		if verbose && len(keyNames) > 0 {
@@ -129,12 +307,18 @@ This powerful command combines the KV search capabilities with cache purging to:
 			}
 
 			fmt.Println("Sample matching keys:")
-			for i := 0; i < maxDisplay; i++ {
-				fmt.Printf("  %s\n", keyNames[i])
+			table := render.NewTable("Key", "Expiration")
+			for _, key := range matchingKeys[:maxDisplay] {
+				expiration := ""
+				if key.Expiration > 0 {
+					expiration = time.Unix(key.Expiration, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+				}
+				table.AddRow(key.Key, expiration)
 			}
+			table.Print()
 
 			if len(keyNames) > maxDisplay {
-				fmt.Printf("  ...and %d more\n", len(keyNames)-maxDisplay)
+				fmt.Println(render.Dim(fmt.Sprintf("...and %d more", len(keyNames)-maxDisplay)))
 			}
 		}
 
@@ -150,89 +334,13 @@ This powerful command combines the KV search capabilities with cache purging to:
 			if extractTags && len(matchingKeys) > 0 {
 				tagMap := make(map[string]bool)
 
-				// Look for cache tags in the metadata
+				// Look for cache tags in the metadata using the extraction rules
 				for _, key := range matchingKeys {
-					if key.Metadata != nil {
-						// Check for cache-tag field in metadata
-						if cacheTag, ok := (*key.Metadata)["cache-tag"]; ok {
-							// If it's a string, add it directly
-							if tagStr, isString := cacheTag.(string); isString {
-								tagMap[tagStr] = true
-							}
-						}
-
-						// Some implementations store as cache-tags (plural)
-						if cacheTags, ok := (*key.Metadata)["cache-tags"]; ok {
-							// If it's a string, split by commas (common format)
-							if tagsStr, isString := cacheTags.(string); isString {
-								for _, tag := range strings.Split(tagsStr, ",") {
-									trimmed := strings.TrimSpace(tag)
-									if trimmed != "" {
-										tagMap[trimmed] = true
-									}
-								}
-							}
-						}
-
-						// Add support for cacheTags (camelCase) field name
-						if cacheTags, ok := (*key.Metadata)["cacheTags"]; ok {
-							// If it's an array, process each element
-							if tagsArray, isArray := cacheTags.([]interface{}); isArray {
-								for _, tag := range tagsArray {
-									if tagStr, isString := tag.(string); isString {
-										tagMap[tagStr] = true
-									}
-								}
-							} else if tagsStr, isString := cacheTags.(string); isString {
-								// If it's a string, split by commas
-								for _, tag := range strings.Split(tagsStr, ",") {
-									trimmed := strings.TrimSpace(tag)
-									if trimmed != "" {
-										tagMap[trimmed] = true
-									}
-								}
-							}
-						}
-
-						// Some store it as "tag" (singular)
-						if tag, ok := (*key.Metadata)["tag"]; ok {
-							// If it's a string, split by commas
-							if tagStr, isString := tag.(string); isString {
-								for _, t := range strings.Split(tagStr, ",") {
-									trimmed := strings.TrimSpace(t)
-									if trimmed != "" {
-										tagMap[trimmed] = true
-									}
-								}
-							} else if tagArray, isArray := tag.([]interface{}); isArray {
-								// If it's an array, convert each element
-								for _, t := range tagArray {
-									if tStr, isString := t.(string); isString {
-										tagMap[tStr] = true
-									}
-								}
-							}
-						}
-
-						// Some store it as an array of tags
-						if tags, ok := (*key.Metadata)["tags"]; ok {
-							// If it's a string, split by commas
-							if tagsStr, isString := tags.(string); isString {
-								for _, tag := range strings.Split(tagsStr, ",") {
-									trimmed := strings.TrimSpace(tag)
-									if trimmed != "" {
-										tagMap[trimmed] = true
-									}
-								}
-							} else if tagsArray, isArray := tags.([]interface{}); isArray {
-								// If it's an array, convert each element
-								for _, tag := range tagsArray {
-									if tagStr, isString := tag.(string); isString {
-										tagMap[tagStr] = true
-									}
-								}
-							}
-						}
+					if key.Metadata == nil {
+						continue
+					}
+					for _, tag := range common.ExtractTagsFromMetadata(*key.Metadata, extractRules) {
+						tagMap[tag] = true
 					}
 				}
 
@@ -241,7 +349,7 @@ This powerful command combines the KV search capabilities with cache purging to:
 					for tag := range tagMap {
 						cacheTags = append(cacheTags, tag)
 					}
-					fmt.Printf("Extracted %d actual cache tags from KV metadata: %s\n",
+					fmt.Printf("Extracted %d actual cache tags from key metadata: %s\n",
 						len(cacheTags), strings.Join(cacheTags, ", "))
 				} else if verbose {
 					fmt.Println("No cache tags found in KV metadata")
@@ -272,9 +380,21 @@ This powerful command combines the KV search capabilities with cache purging to:
 				}
 			}
 
-			// Fallback to using exact search/tag value if no other tags specified
+			// Fallback to using exact search/tag value, or the keys of a source, if no other tags specified
 			if len(cacheTags) == 0 {
-				if searchValue != "" {
+				if source != nil && len(keyNames) > 0 {
+					seen := make(map[string]bool)
+					for _, key := range keyNames {
+						if !seen[key] {
+							seen[key] = true
+							cacheTags = append(cacheTags, key)
+						}
+					}
+					fmt.Printf("Using the %d keys from %s as cache tags\n", len(cacheTags), source.Name())
+				} else if source != nil {
+					fmt.Printf("No keys read from %s, nothing to purge\n", source.Name())
+					return nil
+				} else if searchValue != "" {
 					cacheTags = []string{searchValue}
 					fmt.Printf("Using search value '%s' as cache tag\n", searchValue)
 				} else if tagValue != "" {
@@ -286,10 +406,36 @@ This powerful command combines the KV search capabilities with cache purging to:
 			}
 		}
 
+		// With --tag or --source, confirm everything once before changing anything
+		if (tag != "" || source != nil) && !dryRun && !force {
+			if source != nil {
+				fmt.Printf("\nAbout to purge %d cache tags [%s] in %d zones (%s).\n",
+					len(cacheTags), strings.Join(cacheTags, ", "), len(zoneList), strings.Join(zoneList, ", "))
+			} else {
+				fmt.Printf("\nAbout to delete %d KV keys and purge cache tags [%s] in %d zones (%s).\n",
+					len(keyNames), strings.Join(cacheTags, ", "), len(zoneList), strings.Join(zoneList, ", "))
+			}
+			fmt.Print("Are you sure? (y/N): ")
+
+			reader := bufio.NewReader(os.Stdin)
+			confirmation, _ := reader.ReadString('\n')
+			confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+
+			if confirmation != "y" && confirmation != "yes" {
+				fmt.Println("Operation cancelled.")
+				return nil
+			}
+		}
+
 		// Step 2: Delete the keys
-		fmt.Println("\nStep 2: Deleting matching KV keys...")
+		if source != nil {
+			fmt.Printf("\nStep 2: Keys come from %s, skipping KV deletion\n", source.Name())
+		} else if fromExport != "" {
+			fmt.Println("\nStep 2: Planning KV deletion against the export...")
+			cmdutil.PrintExportPlan(fromExport, len(exportItems), "deleted", keyNames)
+		} else if len(keyNames) > 0 {
+			fmt.Println("\nStep 2: Deleting matching KV keys...")
 
-		if len(keyNames) > 0 {
 			if dryRun {
 				fmt.Printf("DRY RUN: Would delete %d KV keys\n", len(keyNames))
 			} else {
@@ -322,22 +468,12 @@ This powerful command combines the KV search capabilities with cache purging to:
 					return fmt.Errorf("KV deletion failed: %w", err)
 				}
 
-				// Show detailed debug information if requested
-				if debug {
-					fmt.Printf("[DEBUG] DeleteMultipleValues called with %d keys\n", len(keyNames))
-					fmt.Printf("[VERBOSE] Sending bulk delete request to /accounts/%s/storage/kv/namespaces/%s/bulk/delete with %d keys\n",
-						accountID, namespaceID, len(keyNames))
-					fmt.Printf("[DEBUG] API response: success=true, errors=0\n")
-					fmt.Printf("[INFO] Bulk delete of %d keys completed successfully\n", count)
-				}
-
 				// Format KV deletion results with key-value table
-				kvData := make(map[string]string)
-				kvData["Operation"] = "KV Deletion"
-				kvData["Keys Deleted"] = fmt.Sprintf("%d/%d", count, len(keyNames))
-				kvData["Status"] = "Success"
-
-				common.FormatKeyValueTable(kvData)
+				render.PrintKeyValues([]render.Pair{
+					{Key: "Operation", Value: "KV Deletion"},
+					{Key: "Keys Deleted", Value: fmt.Sprintf("%d/%d", count, len(keyNames))},
+					{Key: "Status", Value: "Success"},
+				})
 			}
 		} else {
 			fmt.Println("\nStep 2: No KV keys to delete, skipping deletion step")
@@ -345,49 +481,149 @@ This powerful command combines the KV search capabilities with cache purging to:
 
 		// Step 3: Purge cache tags
 		fmt.Println("\nStep 3: Purging cache tags...")
+		var purgeErr error
 		if dryRun {
-			fmt.Printf("DRY RUN: Would purge %d cache tags: %s\n", len(cacheTags), strings.Join(cacheTags, ", "))
+			fmt.Printf("DRY RUN: Would purge %d cache tags in %d zones: %s\n", len(cacheTags), len(zoneList), strings.Join(cacheTags, ", "))
 		} else {
-			// Resolve zone ID if needed
-			zoneID, err := zones.ResolveZoneIdentifier(client, accountID, zone)
-			if err != nil {
-				return fmt.Errorf("failed to resolve zone: %w", err)
-			}
-
-			// Purge cache tags
-			resp, err := cache.PurgeTags(client, zoneID, cacheTags)
-			if err != nil {
-				return fmt.Errorf("cache purge failed: %w", err)
-			}
-
-			// Format cache purge results with key-value table
-			cacheData := make(map[string]string)
-			cacheData["Operation"] = "Cache Tag Purge"
-			cacheData["Zone"] = zone
-			cacheData["Tags Purged"] = strings.Join(cacheTags, ", ")
-			cacheData["Purge ID"] = resp.Result.ID
-			cacheData["Status"] = "Success"
-
-			common.FormatKeyValueTable(cacheData)
+			fmt.Printf("Purging %d cache tags in %d zones: %s\n", len(cacheTags), len(zoneList), strings.Join(cacheTags, ", "))
+			purgeErr = printSyncZonePurges(zoneList, purgeSyncZones(client, zoneIDs, cacheTags, zoneConcurrency))
 		}
 
 		// Format final success message
-		resultData := make(map[string]string)
-		resultData["Operation"] = "Sync Purge"
+		status := "Successfully Completed"
 		if dryRun {
-			resultData["Status"] = "DRY RUN Completed"
-		} else {
-			resultData["Status"] = "Successfully Completed"
+			status = "DRY RUN Completed"
+		} else if purgeErr != nil {
+			status = "Completed With Errors"
+		}
+
+		keysFoundLabel := "KV Keys Found"
+		if source != nil {
+			keysFoundLabel = "Source Keys"
 		}
-		resultData["KV Keys Found"] = fmt.Sprintf("%d", len(keyNames))
-		resultData["Cache Tags"] = fmt.Sprintf("%d", len(cacheTags))
 
 		fmt.Println()
-		common.FormatKeyValueTable(resultData)
-		return nil
+		render.PrintKeyValues([]render.Pair{
+			{Key: "Operation", Value: "Sync Purge"},
+			{Key: "Status", Value: status},
+			{Key: keysFoundLabel, Value: fmt.Sprintf("%d", len(keyNames))},
+			{Key: "Cache Tags", Value: fmt.Sprintf("%d", len(cacheTags))},
+			{Key: "Zones", Value: fmt.Sprintf("%d", len(zoneList))},
+		})
+		return purgeErr
 	}),
 }
 
+// sourceKeyPairs converts the keys of a key source so they go through the same tag
+// extraction as KV keys
+func sourceKeyPairs(sourceKeys []cache.SourceKey) []kv.KeyValuePair {
+	pairs := make([]kv.KeyValuePair, len(sourceKeys))
+	for i, sourceKey := range sourceKeys {
+		pairs[i].Key = sourceKey.Key
+		if sourceKey.Metadata != nil {
+			metadata := kv.KeyValueMetadata(sourceKey.Metadata)
+			pairs[i].Metadata = &metadata
+		}
+	}
+	return pairs
+}
+
+// syncZonePurge is the outcome of purging the cache tags of one zone
+type syncZonePurge struct {
+	purgeID string
+	err     error
+}
+
+// resolveSyncZones resolves zone names or IDs, or lists every zone of the account with
+// allZones, and returns the display names and IDs of the distinct zones
+func resolveSyncZones(client *api.Client, accountID string, zoneList []string, allZones bool) ([]string, []string, error) {
+	if allZones {
+		if accountID == "" {
+			return nil, nil, fmt.Errorf("account ID is required for --all-zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config")
+		}
+		zoneResp, err := zones.ListZones(client, accountID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list zones: %w", err)
+		}
+		if len(zoneResp.Result) == 0 {
+			return nil, nil, fmt.Errorf("no zones found for the account")
+		}
+		names := make([]string, len(zoneResp.Result))
+		ids := make([]string, len(zoneResp.Result))
+		for i, zone := range zoneResp.Result {
+			names[i], ids[i] = zone.Name, zone.ID
+		}
+		return names, ids, nil
+	}
+
+	var names, ids []string
+	seen := make(map[string]bool)
+	for _, zone := range zoneList {
+		zoneID, err := zones.ResolveZoneIdentifier(client, accountID, zone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve zone: %w", err)
+		}
+		if seen[zoneID] {
+			continue
+		}
+		seen[zoneID] = true
+		names = append(names, zone)
+		ids = append(ids, zoneID)
+	}
+	return names, ids, nil
+}
+
+// purgeSyncZones purges the cache tags from every zone, concurrency zones at a time
+func purgeSyncZones(client *api.Client, zoneIDs []string, cacheTags []string, concurrency int) []syncZonePurge {
+	if concurrency <= 0 {
+		concurrency = 3
+	}
+
+	results := make([]syncZonePurge, len(zoneIDs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, zoneID := range zoneIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, zoneID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := cache.PurgeTags(client, zoneID, cacheTags)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].purgeID = resp.Result.ID
+		}(i, zoneID)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// printSyncZonePurges prints the purge ID or error of each zone and returns an error if
+// any zone failed
+func printSyncZonePurges(zoneList []string, results []syncZonePurge) error {
+	table := render.NewTable("Zone", "Purge ID", "Status")
+	failed := 0
+	for i, result := range results {
+		if result.err != nil {
+			failed++
+			table.AddRow(zoneList[i], "", render.Red("Failed: "+result.err.Error()))
+			continue
+		}
+		table.AddRow(zoneList[i], result.purgeID, render.Green("Success"))
+	}
+	table.Print()
+
+	if failed > 0 {
+		return fmt.Errorf("cache purge failed for %d of %d zones", failed, len(results))
+	}
+	return nil
+}
+
 func init() {
 	// Add combined/sync command to root
 	rootCmd.AddCommand(combinedCmd)
@@ -395,6 +631,9 @@ func init() {
 	// Add purge command to sync
 	combinedCmd.AddCommand(syncPurgeCmd)
 
+	// Add manifest command to sync
+	combinedCmd.AddCommand(createSyncManifestCmd())
+
 	// Add flags to purge command
 	syncPurgeCmd.Flags().String("account-id", "", "Cloudflare Account ID")
 	syncPurgeCmd.Flags().String("namespace-id", "", "KV Namespace ID")
@@ -402,22 +641,27 @@ func init() {
 	syncPurgeCmd.Flags().String("search", "", "Search for keys containing this value")
 	syncPurgeCmd.Flags().String("tag-field", "", "Search for keys with this metadata field")
 	syncPurgeCmd.Flags().String("tag-value", "", "Value to match in the tag field")
-	syncPurgeCmd.Flags().String("zone", "", "Zone ID or name to purge content from")
+	syncPurgeCmd.Flags().String("tag", "", "Tag used as both the KV metadata value and the cache tag to purge")
+	syncPurgeCmd.Flags().StringArray("zone", []string{}, "Zone ID or name to purge content from (can be repeated, defaults to the configured zone)")
+	syncPurgeCmd.Flags().StringSlice("zones", []string{}, "Additional zones to purge cache tags from (comma separated, can specify multiple times)")
+	syncPurgeCmd.Flags().Bool("all-zones", false, "Purge cache tags from every zone in the account")
+	syncPurgeCmd.Flags().Int("zone-concurrency", 0, "Number of zones to purge concurrently (defaults to the multi-zone concurrency in config)")
 	syncPurgeCmd.Flags().StringSlice("cache-tag", []string{}, "Cache tags to purge (can specify multiple times, optional if search/tag-value is provided)")
+	syncPurgeCmd.Flags().String("source", "", "Read affected keys from '-' (stdin), an http(s) URL or a CSV file instead of searching KV")
+	syncPurgeCmd.Flags().String("source-key-column", "", "CSV column holding the key (default \"key\", or the first column)")
+	syncPurgeCmd.Flags().String("from-export", "", cmdutil.FromExportUsage+", along with the cache tags")
 
 	// Cache tag generation options
 	syncPurgeCmd.Flags().Bool("derived-tags", false, "Generate common cache tag patterns from search/tag values")
 	syncPurgeCmd.Flags().Bool("extract-tags", true, "Extract cache tags from matching key metadata")
+	syncPurgeCmd.Flags().StringArray("tag-extract-rule", []string{}, "Where to find cache tags in key metadata, e.g. \"field=labels,delimiter=semicolon\" or \"path=.cdn.tags\" (can be repeated, overrides config)")
 
 	// Operation options
 	syncPurgeCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	syncPurgeCmd.Flags().Bool("force", false, "Skip the confirmation prompt when using --tag")
 	syncPurgeCmd.Flags().Int("batch-size", 0, "Batch size for KV operations")
 	syncPurgeCmd.Flags().Int("concurrency", 0, "Number of concurrent operations")
 	syncPurgeCmd.Flags().Bool("verbose", false, "Enable verbose output")
 
-	// Mark required flags
-	if err := syncPurgeCmd.MarkFlagRequired("zone"); err != nil {
-		fmt.Printf("Warning: could not mark zone flag as required: %v\n", err)
-	}
-	// Cache tag is conditionally required - validation is handled in RunE
+	// Zone and cache tag are conditionally required - validation is handled in RunE
 }
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +21,7 @@ var configCmd = &cobra.Command{
 var configDefaultsCmd = &cobra.Command{
 	Use:   "set-defaults",
 	Short: "Set default values",
-	Long:  `Set default values for zone ID, account ID, and API endpoint.`,
+	Long:  `Set default values for zone ID, account ID, KV namespace ID, and API endpoint.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load existing config
 		cfg, err := config.LoadFromFile("")
@@ -31,6 +33,10 @@ var configDefaultsCmd = &cobra.Command{
 		zoneID, _ := cmd.Flags().GetString("zone")
 		accountID, _ := cmd.Flags().GetString("account-id")
 		apiEndpoint, _ := cmd.Flags().GetString("api-endpoint")
+		namespaceID, _ := cmd.Flags().GetString("namespace-id")
+		protectNamespaces, _ := cmd.Flags().GetStringSlice("protect-namespace")
+		protectZones, _ := cmd.Flags().GetStringSlice("protect-zone")
+		tagExtractRules, _ := cmd.Flags().GetStringArray("tag-extract-rule")
 
 		// Update config
 		changed := false
@@ -42,8 +48,32 @@ var configDefaultsCmd = &cobra.Command{
 			cfg.AccountID = accountID
 			changed = true
 		}
+		if namespaceID != "" {
+			cfg.DefaultNamespace = namespaceID
+			changed = true
+		}
+		if len(protectNamespaces) > 0 {
+			cfg.ProtectedNamespaces = appendUnique(cfg.ProtectedNamespaces, protectNamespaces...)
+			changed = true
+		}
+		if len(protectZones) > 0 {
+			cfg.ProtectedZones = appendUnique(cfg.ProtectedZones, protectZones...)
+			changed = true
+		}
+		if len(tagExtractRules) > 0 {
+			rules, err := common.ResolveTagExtractRules(tagExtractRules, cfg)
+			if err != nil {
+				return err
+			}
+			cfg.TagExtractRules = rules
+			changed = true
+		}
 		if apiEndpoint != "" {
-			cfg.APIEndpoint = apiEndpoint
+			validated, err := config.ValidateAPIEndpoint(apiEndpoint)
+			if err != nil {
+				return err
+			}
+			cfg.APIEndpoint = validated
 			changed = true
 		}
 
@@ -75,7 +105,11 @@ var configShowCmd = &cobra.Command{
 
 		// Display config
 		fmt.Println("Current configuration:")
-		fmt.Printf("  API Endpoint: %s\n", cfg.APIEndpoint)
+		if os.Getenv(config.EnvAPIEndpoint) != "" {
+			fmt.Printf("  API Endpoint: %s (from environment variable)\n", cfg.GetAPIEndpoint())
+		} else {
+			fmt.Printf("  API Endpoint: %s\n", cfg.GetAPIEndpoint())
+		}
 
 		// Zone ID (may come from env var, config, or neither)
 		zoneID := cfg.GetZoneID()
@@ -101,17 +135,255 @@ var configShowCmd = &cobra.Command{
 			fmt.Printf("  Default Account ID: (not set)\n")
 		}
 
+		// Namespace ID (may come from env var, config, or neither)
+		namespaceID := cfg.GetNamespaceID()
+		if namespaceID != "" {
+			if os.Getenv(config.EnvNamespaceID) != "" {
+				fmt.Printf("  Default Namespace ID: %s (from environment variable)\n", namespaceID)
+			} else {
+				fmt.Printf("  Default Namespace ID: %s\n", namespaceID)
+			}
+		} else {
+			fmt.Printf("  Default Namespace ID: (not set)\n")
+		}
+
+		// Protected resources
+		if len(cfg.ProtectedNamespaces) > 0 {
+			fmt.Printf("  Protected Namespaces: %s\n", strings.Join(cfg.ProtectedNamespaces, ", "))
+		}
+		if len(cfg.ProtectedZones) > 0 {
+			fmt.Printf("  Protected Zones: %s\n", strings.Join(cfg.ProtectedZones, ", "))
+		}
+
+		// Per-namespace defaults
+		if len(cfg.Namespaces) > 0 {
+			fmt.Println("  Namespace Defaults:")
+			ids, titles := cfg.NamespaceDefaultKeys()
+			for _, key := range append(ids, titles...) {
+				fmt.Printf("    - %s: %s\n", key, cfg.Namespaces[key])
+			}
+		}
+
+		// Cache tag extraction rules
+		if len(cfg.TagExtractRules) > 0 {
+			fmt.Println("  Tag Extract Rules:")
+			for _, rule := range cfg.TagExtractRules {
+				fmt.Printf("    - %s\n", rule)
+			}
+		}
+
 		return nil
 	},
 }
 
+// configValidateCmd is the command for checking the config file
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for mistakes",
+	Long: `Check the config file and configuration environment variables.
+
+Reports invalid JSON with its line and column, unknown fields (with the closest known
+field name), values of the wrong type, malformed zone, account and namespace IDs,
+invalid API endpoints, out-of-range concurrency settings, and invalid tag extraction
+rules. Exits with an error when any problem is found.`,
+	Example: `  # Check the config file in the home directory
+  cache-kv-purger config validate
+
+  # Check another file
+  cache-kv-purger config validate --file ./ci-config.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("file")
+		outputJSON, _ := cmd.Flags().GetBool("json")
+
+		if path == "" {
+			var err error
+			if path, err = config.DefaultPath(); err != nil {
+				return err
+			}
+		}
+
+		var issues []config.ValidationIssue
+		data, err := os.ReadFile(path)
+		exists := err == nil
+		switch {
+		case os.IsNotExist(err):
+			if !outputJSON {
+				fmt.Printf("No config file at %s; built-in defaults are used\n", path)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to read config file: %w", err)
+		default:
+			cfg, fileIssues := config.ValidateData(data)
+			issues = append(issues, fileIssues...)
+			if cfg != nil {
+				for i, rule := range cfg.TagExtractRules {
+					if err := common.ValidateTagExtractRule(rule); err != nil {
+						issues = append(issues, config.ValidationIssue{
+							Field:   fmt.Sprintf("tag_extract_rules[%d]", i),
+							Message: err.Error(),
+						})
+					}
+				}
+			}
+		}
+		issues = append(issues, config.ValidateEnvironment()...)
+
+		if outputJSON {
+			if err := common.OutputJSON(map[string]interface{}{
+				"file":   path,
+				"valid":  len(issues) == 0,
+				"issues": issues,
+			}); err != nil {
+				return err
+			}
+		} else if len(issues) == 0 {
+			if exists {
+				fmt.Printf("Configuration is valid (%s)\n", path)
+			}
+		} else {
+			fmt.Printf("Found %d problems in the configuration (%s):\n", len(issues), path)
+			for _, issue := range issues {
+				fmt.Printf("  - %s\n", issue)
+			}
+		}
+
+		if len(issues) > 0 {
+			return fmt.Errorf("configuration has %d problems", len(issues))
+		}
+		return nil
+	},
+}
+
+// configGetCmd is the command for reading one config file field
+var configGetCmd = &cobra.Command{
+	Use:   "get FIELD",
+	Short: "Print the value of a config file field",
+	Long: `Print the value of a config file field as stored in the file, without environment
+variable overrides. List fields are printed comma-separated; tag extraction rules and
+redact patterns are printed one per line.
+
+Fields: ` + strings.Join(config.FieldNames(), ", "),
+	Example: `  cache-kv-purger config get account_id`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFileOnly()
+		if err != nil {
+			return err
+		}
+
+		value, err := cfg.GetField(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// configSetCmd is the command for setting one config file field
+var configSetCmd = &cobra.Command{
+	Use:   "set FIELD VALUE...",
+	Short: "Set a config file field",
+	Long: `Set a config file field, checking the value before the file is written.
+
+Single-value fields take one value, and an empty value ("") clears the field. List
+fields (protected_namespaces, protected_zones) replace the list with the given values.
+tag_extract_rules replaces the rules, each given in the --tag-extract-rule syntax.
+redact_patterns replaces the patterns, one regular expression per value.
+
+Fields: ` + strings.Join(config.FieldNames(), ", "),
+	Example: `  # Set the default account
+  cache-kv-purger config set account_id 01a7362d577a6c3019a474fd6f485823
+
+  # Protect two zones
+  cache-kv-purger config set protected_zones example.com example.org
+
+  # Clear the default zone
+  cache-kv-purger config set default_zone ""`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfigFileOnly()
+		if err != nil {
+			return err
+		}
+
+		field, values := args[0], args[1:]
+		if field == "tag_extract_rules" {
+			rules, err := common.ResolveTagExtractRules(values, cfg)
+			if err != nil {
+				return err
+			}
+			cfg.TagExtractRules = rules
+		} else if err := cfg.SetField(field, values...); err != nil {
+			return err
+		}
+
+		if err := cfg.SaveToFile(""); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("Set %s.\n", field)
+		return nil
+	},
+}
+
+// loadConfigFileOnly loads the config file in the home directory without applying
+// environment variables, so that editing it doesn't persist environment overrides
+func loadConfigFileOnly() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, issues := config.ValidateData(data)
+	if cfg == nil {
+		return nil, fmt.Errorf("config file %s cannot be read: %s (run 'config validate' for details)", path, issues[0])
+	}
+	return cfg, nil
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configDefaultsCmd)
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+
+	// Add flags to validate command
+	configValidateCmd.Flags().String("file", "", "Config file to check (default ~/.cache-kv-purger.json)")
+	configValidateCmd.Flags().Bool("json", false, "Output the problems as JSON")
 
 	// Add flags to set-defaults command
 	configDefaultsCmd.Flags().String("zone", "", "Default zone ID")
 	configDefaultsCmd.Flags().String("account-id", "", "Default account ID")
+	configDefaultsCmd.Flags().String("namespace-id", "", "Default KV namespace ID")
 	configDefaultsCmd.Flags().String("api-endpoint", "", "API endpoint URL")
+	configDefaultsCmd.Flags().StringSlice("protect-namespace", nil, "Namespace ID or title that destructive commands must not touch (can be repeated)")
+	configDefaultsCmd.Flags().StringSlice("protect-zone", nil, "Zone ID or name that destructive commands must not touch (can be repeated)")
+	configDefaultsCmd.Flags().StringArray("tag-extract-rule", nil, "Rule for finding cache tags in KV metadata, replacing the configured rules (can be repeated)")
+}
+
+// appendUnique appends values that are not already in the list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
 }
@@ -4,18 +4,37 @@ import (
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/cache"
 	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/zones"
 	"fmt"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 // createPurgeEverythingCmd creates a command to purge everything
 func createPurgeEverythingCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var exceptHosts []string
+	var dryRun bool
+
 	cmd := &cobra.Command{
 		Use:   "everything",
 		Short: "Purge everything from cache",
-		Long:  `Purge all cached files for a zone from Cloudflare's edge servers.`,
+		Long: `Purge all cached files for a zone from Cloudflare's edge servers.
+
+With --except-host, no global purge is issued. Instead the zone's proxied hostnames
+are read from its DNS records and purged by host, leaving the cache of the listed
+hosts untouched. Use this for zones where a total flush is too destructive.
+
+Before purging, the cached traffic of each zone over the last 24 hours is read from
+the analytics API and shown with the confirmation prompt, e.g. "example.com served
+~4.2M cached requests/day", as an estimate of the load the purge moves to the
+origin. --dry-run shows the estimate and the zones without purging anything;
+--force skips both the estimate and the prompt, and --yes (or CACHE_KV_ASSUME_YES)
+shows the estimate and answers the prompt. Without a terminal to answer the prompt,
+for example in scripts and CI, the purge fails unless --force, --yes or --quiet is
+given.`,
 		Example: `  # Purge everything from a zone
   cache-kv-purger cache purge everything --zone example.com
 
@@ -23,7 +42,13 @@ func createPurgeEverythingCmd() *cobra.Command {
   cache-kv-purger cache purge everything --zone example.com --zone example.org
 
   # Purge everything from all zones in an account
-  cache-kv-purger cache purge everything --all-zones`,
+  cache-kv-purger cache purge everything --all-zones
+
+  # Purge every proxied hostname except the CDN
+  cache-kv-purger cache purge everything --zone example.com --except-host cdn.example.com
+
+  # Preview which hostnames would be purged
+  cache-kv-purger cache purge everything --zone example.com --except-host cdn.example.com --dry-run`,
 		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
 			// Create API client
 			client, err := api.NewClient()
@@ -32,11 +57,8 @@ func createPurgeEverythingCmd() *cobra.Command {
 			}
 
 			// Get account ID for resolving zone names
-			accountID := ""
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 			// Resolve zone identifiers (could be names or IDs)
 			resolvedZoneIDs, err := resolveZoneIdentifiers(cmd, client, accountID)
@@ -44,6 +66,24 @@ func createPurgeEverythingCmd() *cobra.Command {
 				return err
 			}
 
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, resolvedZoneIDs); err != nil {
+				return err
+			}
+
+			// Show what the purge would send to the origin and ask before going ahead
+			if dryRun {
+				printBlastRadius(zonesBlastRadius(client, resolvedZoneIDs))
+			} else if !purgeFlagsVars.force {
+				question := fmt.Sprintf("Purge everything from %d zones?", len(resolvedZoneIDs))
+				if len(exceptHosts) > 0 {
+					question = fmt.Sprintf("Purge every hostname except %s from %d zones?", strings.Join(exceptHosts, ", "), len(resolvedZoneIDs))
+				}
+				if err := confirmBlastRadius(zonesBlastRadius(client, resolvedZoneIDs), question); err != nil {
+					return err
+				}
+			}
+
 			// Track successes
 			successCount := 0
 
@@ -65,6 +105,7 @@ func createPurgeEverythingCmd() *cobra.Command {
 				zoneName   string
 				successful bool
 				purgeID    string
+				hostCount  int
 				err        error
 			}
 
@@ -92,6 +133,30 @@ func createPurgeEverythingCmd() *cobra.Command {
 						fmt.Printf("Purging everything from zone %s...\n", zoneName)
 					}
 
+					// Purge by host, leaving the excluded hosts cached
+					if len(exceptHosts) > 0 {
+						count, err := purgeZoneExceptHosts(client, zID, zoneName, exceptHosts, purgeFlagsVars.cacheConcurrency, dryRun, verbose)
+						resultChan <- zoneResult{
+							zoneID:     zID,
+							zoneName:   zoneName,
+							successful: err == nil,
+							hostCount:  count,
+							err:        err,
+						}
+						return
+					}
+
+					// A dry run sends no purge, so nothing is recorded in the purge history either
+					if dryRun {
+						fmt.Printf("DRY RUN: Would purge everything from zone %s\n", zoneName)
+						resultChan <- zoneResult{
+							zoneID:     zID,
+							zoneName:   zoneName,
+							successful: true,
+						}
+						return
+					}
+
 					// Make the API call to purge everything
 					resp, err := cache.PurgeEverything(client, zID)
 					if err != nil {
@@ -121,7 +186,11 @@ func createPurgeEverythingCmd() *cobra.Command {
 				if result.err != nil {
 					fmt.Printf("Error purging zone %s: %s\n", result.zoneID, result.err)
 				} else {
-					if verbose {
+					if len(exceptHosts) > 0 {
+						if !dryRun {
+							fmt.Printf("Purged %d hostnames in zone %s\n", result.hostCount, result.zoneName)
+						}
+					} else if verbose {
 						fmt.Printf("Successfully purged everything from zone %s. Purge ID: %s\n", result.zoneName, result.purgeID)
 					}
 					successCount++
@@ -129,10 +198,63 @@ func createPurgeEverythingCmd() *cobra.Command {
 			}
 
 			// Final summary
+			if dryRun {
+				fmt.Printf("DRY RUN: Would purge content from %d zones\n", successCount)
+				return nil
+			}
 			fmt.Printf("Successfully purged content from %d/%d zones\n", successCount, len(resolvedZoneIDs))
 			return nil
 		}),
 	}
 
+	cmd.Flags().StringSliceVar(&exceptHosts, "except-host", []string{}, "Purge every proxied hostname except these instead of purging everything (can be repeated)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without actually purging")
+	cmd.Flags().BoolVar(&purgeFlagsVars.force, "force", false, "Skip the blast radius estimate and confirmation prompt")
+
 	return cmd
 }
+
+// purgeZoneExceptHosts purges a zone's proxied hostnames by host, skipping the excluded ones.
+// It returns the number of hostnames purged (or that would be purged in a dry run).
+func purgeZoneExceptHosts(client *api.Client, zoneID, zoneName string, exceptHosts []string,
+	concurrency int, dryRun, verbose bool) (int, error) {
+
+	// Purging by host is not available on every plan
+	if !purgeFlagsVars.skipPlanCheck {
+		if details, err := zones.GetZoneDetails(client, zoneID); err == nil {
+			if err := zones.CheckPurgeSupported(details.Result, "hosts"); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	hostnames, err := zones.ListProxiedHostnames(client, zoneID)
+	if err != nil {
+		return 0, err
+	}
+
+	hosts, excluded := zones.ExcludeHostnames(hostnames, exceptHosts)
+	if verbose {
+		fmt.Printf("Zone %s has %d proxied hostnames, excluding %d\n", zoneName, len(hostnames), len(excluded))
+	}
+
+	if dryRun {
+		fmt.Printf("DRY RUN: Would purge %d hostnames in zone %s (keeping %d cached)\n", len(hosts), zoneName, len(excluded))
+		for _, host := range hosts {
+			fmt.Printf("  %s\n", host)
+		}
+		return len(hosts), nil
+	}
+
+	if len(hosts) == 0 {
+		return 0, fmt.Errorf("no hostnames left to purge after excluding %s", strings.Join(exceptHosts, ", "))
+	}
+
+	successful, errs := cache.PurgeHostsInBatches(client, zoneID, hosts, cache.NewPurgeBatchOptions(cache.WithConcurrency(concurrency)))
+	if len(errs) > 0 {
+		return len(successful), fmt.Errorf("purged %d of %d hostnames: %s",
+			len(successful), len(hosts), common.SummarizeBatchErrors(errs))
+	}
+
+	return len(successful), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"cache-kv-purger/internal/cache"
+)
+
+func TestPurgeEverythingDryRunSendsNoPurge(t *testing.T) {
+	api := newFakeAPI(t, nil)
+	zoneID := "0123456789abcdef0123456789abcdef"
+	before := len(cache.SessionPurges())
+
+	if err := runCLI(t, api, "cache", "purge", "everything", "--zone", zoneID, "--dry-run"); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	if purges := api.Requests("/purge_cache"); len(purges) > 0 {
+		t.Errorf("dry run sent purge requests: %v", purges)
+	}
+	if after := len(cache.SessionPurges()); after != before {
+		t.Errorf("dry run recorded %d purges in the history", after-before)
+	}
+}
+
+// pipeStdin replaces stdin with a closed pipe, standing in for the stdin of a script or CI job
+func pipeStdin(t *testing.T) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		reader.Close()
+	})
+}
+
+func TestPurgeEverythingWithoutTerminalNeedsForce(t *testing.T) {
+	api := newFakeAPI(t, nil)
+	pipeStdin(t)
+
+	err := runCLI(t, api, "cache", "purge", "everything", "--zone", "0123456789abcdef0123456789abcdef")
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("error = %v, want one asking for --force", err)
+	}
+	if purges := api.Requests("/purge_cache"); len(purges) > 0 {
+		t.Errorf("unconfirmed purge sent requests: %v", purges)
+	}
+}
+
+func TestPurgeEverythingYesAnswersPrompt(t *testing.T) {
+	api := newFakeAPI(t, nil)
+	pipeStdin(t)
+
+	if err := runCLI(t, api, "cache", "purge", "everything", "--zone", "0123456789abcdef0123456789abcdef", "--yes"); err != nil {
+		t.Fatalf("purge with --yes failed: %v", err)
+	}
+	if purges := api.Requests("/purge_cache"); len(purges) != 1 {
+		t.Errorf("sent %d purge requests, want 1", len(purges))
+	}
+}
+
+func TestPurgeEverythingFailsClosedOnProtection(t *testing.T) {
+	zoneID := "0123456789abcdef0123456789abcdef"
+	api := newFakeAPI(t, func(method, path, body string) (int, string) {
+		if method == "GET" && strings.HasSuffix(path, "/zones/"+zoneID) {
+			return http.StatusInternalServerError, `{"success": false, "errors": [{"code": 1000, "message": "unavailable"}]}`
+		}
+		return http.StatusOK, ""
+	})
+
+	// The zone may be protected by name, so a failed lookup must not let the purge through
+	err := runCLIWithConfig(t, api, `{"protected_zones": ["example.com"]}`,
+		"cache", "purge", "everything", "--zone", zoneID, "--force")
+	if err == nil || !strings.Contains(err.Error(), "protected zones") {
+		t.Errorf("error = %v, want a failed protection check", err)
+	}
+
+	// An unreadable config must not turn protection off either
+	err = runCLIWithConfig(t, api, `{"protected_zones": [`, "cache", "purge", "everything", "--zone", zoneID, "--force")
+	if err == nil {
+		t.Error("expected an unreadable config to refuse the purge")
+	}
+
+	if purges := api.Requests("/purge_cache"); len(purges) > 0 {
+		t.Errorf("purge sent requests without a protection check: %v", purges)
+	}
+}
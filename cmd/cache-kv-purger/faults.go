@@ -0,0 +1,39 @@
+//go:build faultinject
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"cache-kv-purger/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// Fault injection flags exist only in builds with the faultinject tag, for load testing
+// retries, checkpointing and adaptive concurrency against a slow or failing API
+func init() {
+	rootCmd.PersistentFlags().Duration("inject-latency", 0, "Add this latency, with jitter, to every API request")
+	rootCmd.PersistentFlags().Float64("inject-error-rate", 0, "Fail this share of API requests (0 to 1) with a simulated 429 or 500")
+	_ = rootCmd.PersistentFlags().MarkHidden("inject-latency")
+	_ = rootCmd.PersistentFlags().MarkHidden("inject-error-rate")
+
+	cobra.OnInitialize(initializeFaultInjection)
+}
+
+// initializeFaultInjection makes clients simulate the latency and failures set by the
+// --inject-latency and --inject-error-rate flags
+func initializeFaultInjection() {
+	latency, _ := rootCmd.PersistentFlags().GetDuration("inject-latency")
+	errorRate, _ := rootCmd.PersistentFlags().GetFloat64("inject-error-rate")
+	faults := api.FaultInjection{Latency: latency, ErrorRate: errorRate}
+
+	if err := api.SetFaultInjection(faults); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if faults.Enabled() {
+		fmt.Fprintf(os.Stderr, "Fault injection: %s latency, %.0f%% of requests failing\n", latency, errorRate*100)
+	}
+}
@@ -3,9 +3,9 @@ package main
 import (
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
 	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
-	"cache-kv-purger/internal/zones"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
@@ -19,6 +19,10 @@ func createPurgeFilesCmd() *cobra.Command {
 	var files []string
 	var batchSize int
 	var concurrency int
+	var budgetLimit int
+	var deviceTypes []string
+	var countries []string
+	var plan cmdutil.PlanFlags
 
 	cmd := &cobra.Command{
 		Use:   "files",
@@ -26,7 +30,25 @@ func createPurgeFilesCmd() *cobra.Command {
 		Long: `Purge specific files from Cloudflare's cache.
 
 Files must be provided as full URLs including the protocol (http:// or https://). 
-The Cloudflare API requires complete URLs for cache purging.`,
+The Cloudflare API requires complete URLs for cache purging. Before purging, URLs
+are normalized (lowercase host, fragments removed) and deduplicated. Entries that
+are not valid URLs are listed with their line number in --files-list and skipped,
+instead of making the API reject the whole batch they would be sent in.
+
+Some plans cap how many single files can be purged per zone per day. URLs purged
+by this command are counted locally per zone and UTC day, and a warning is shown
+when the count nears the limit (30,000 unless --budget is given). With --budget,
+files beyond the remaining daily budget are not purged. Purges made elsewhere,
+e.g. from the dashboard or another machine, are not counted.
+
+Zones that cache separate variants per device type or visitor country need each
+variant purged. With --device-types and --countries every URL is purged once per
+combination, with the CF-Device-Type and CF-IPCountry headers set, and each
+combination counts as one URL against the daily budget.
+
+Save the URLs a dry run would purge with --plan-out and pass the file to the real
+run with --plan-in to abort when the URLs, e.g. from a regenerated list, differ
+from the preview by more than --plan-threshold percent.`,
 		Example: `  # Purge a single file
   cache-kv-purger cache purge files --zone example.com --file https://example.com/css/styles.css
 
@@ -37,7 +59,17 @@ The Cloudflare API requires complete URLs for cache purging.`,
   cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt
   
   # Purge many files with batch processing
-  cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt --batch-size 500 --concurrency 10`,
+  cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt --batch-size 500 --concurrency 10
+
+  # Stop before purging more than 10,000 URLs today
+  cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt --budget 10000
+
+  # Preview a purge, then purge only if the list still has the same URLs
+  cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt --dry-run --plan-out plan.json
+  cache-kv-purger cache purge files --zone example.com --files-list myfiles.txt --plan-in plan.json
+
+  # Purge the mobile and desktop variants cached for visitors from the US and Germany
+  cache-kv-purger cache purge files --zone example.com --file https://example.com/ --device-types mobile,desktop --countries US,DE`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Get flags
 			var opts struct {
@@ -49,6 +81,7 @@ The Cloudflare API requires complete URLs for cache purging.`,
 				verbose     bool
 				batchSize   int
 				concurrency int
+				budget      int
 			}
 
 			// Extract flags once at the beginning
@@ -65,6 +98,10 @@ The Cloudflare API requires complete URLs for cache purging.`,
 
 			opts.batchSize = batchSize
 			opts.concurrency = concurrency
+			opts.budget = budgetLimit
+			if err := plan.Validate(opts.dryRun); err != nil {
+				return err
+			}
 
 			// Load config
 			cfg, err := config.LoadFromFile("")
@@ -79,11 +116,13 @@ The Cloudflare API requires complete URLs for cache purging.`,
 				return fmt.Errorf("failed to create API client: %w", err)
 			}
 
-			// Collect all files to purge
-			var allFiles []string
+			// Collect all files to purge, remembering where each came from
+			var entries []common.PurgeURLEntry
 
 			// Add files from command line flags
-			allFiles = append(allFiles, opts.files...)
+			for _, file := range opts.files {
+				entries = append(entries, common.PurgeURLEntry{URL: file, Source: "--file"})
+			}
 
 			// Add files from file list if provided
 			if opts.filesList != "" {
@@ -96,34 +135,53 @@ The Cloudflare API requires complete URLs for cache purging.`,
 
 				// Split the file content by lines
 				lines := strings.Split(string(fileData), "\n")
-				for _, line := range lines {
+				listed := 0
+				for i, line := range lines {
 					line = strings.TrimSpace(line)
 					if line != "" && !strings.HasPrefix(line, "#") {
-						allFiles = append(allFiles, line)
+						entries = append(entries, common.PurgeURLEntry{URL: line, Source: filesListPath, Line: i + 1})
+						listed++
 					}
 				}
 
 				if opts.verbose {
-					fmt.Printf("Extracted %d files from %s\n", len(allFiles)-len(purgeFlagsVars.files), filesListPath)
+					fmt.Printf("Extracted %d files from %s\n", listed, filesListPath)
 				}
 			}
 
 			// Check if we have any files
-			if len(allFiles) == 0 {
+			if len(entries) == 0 {
 				return fmt.Errorf("at least one file is required, specify with --file, --files, or --files-list")
 			}
 
-			// Remove duplicates
-			allFiles = common.RemoveDuplicates(allFiles)
-
-			// Verify all files are valid URLs
-			// Check if all files have a valid URL scheme
+			// Validate, normalize and dedupe the URLs so a bad entry doesn't fail a whole batch.
 			// Cloudflare API requires full URLs with protocol
-			for _, file := range allFiles {
-				if !strings.HasPrefix(file, "http://") && !strings.HasPrefix(file, "https://") {
-					return fmt.Errorf("file URLs must include http:// or https:// prefix: %s", file)
+			prepared := common.PreparePurgeURLs(entries)
+			if len(prepared.Invalid) > 0 {
+				fmt.Printf("Skipping %d invalid file URLs:\n", len(prepared.Invalid))
+				for _, issue := range prepared.Invalid {
+					fmt.Printf("  %s\n", issue)
 				}
 			}
+			if prepared.Duplicates > 0 && opts.verbose {
+				fmt.Printf("Removed %d duplicate file URLs\n", prepared.Duplicates)
+			}
+			if len(prepared.URLs) == 0 {
+				return fmt.Errorf("no valid file URLs to purge, file URLs must include http:// or https:// and a host")
+			}
+			allFiles := prepared.URLs
+
+			// Each URL is purged once per device type and country variant
+			variantDevices, err := cache.NormalizeDeviceTypes(deviceTypes)
+			if err != nil {
+				return err
+			}
+			variantCountries, err := cache.NormalizeCountries(countries)
+			if err != nil {
+				return err
+			}
+			useVariants := len(variantDevices) > 0 || len(variantCountries) > 0
+			perURL := cache.VariantCount(variantDevices, variantCountries)
 
 			// Process one zone at a time
 			zoneID := opts.zoneID
@@ -137,32 +195,76 @@ The Cloudflare API requires complete URLs for cache purging.`,
 				zoneID = opts.zones[0] // Just use the first one for now
 			}
 
-			if zoneID == "" && cfg != nil {
-				zoneID = cfg.GetZoneID()
+			// Resolve zone (could be name or ID), falling back to the default zone
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
+			zoneID, err = cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+			if err != nil {
+				return err
 			}
 
-			if zoneID == "" {
-				return fmt.Errorf("zone ID is required, specify it with --zone flag, CLOUDFLARE_ZONE_ID environment variable, or set a default zone in config")
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, []string{zoneID}); err != nil {
+				return err
 			}
 
-			// Get account ID for zone resolver
-			accountID := ""
-			if cfg != nil {
-				accountID = cfg.GetAccountID()
-			}
+			// Now purge the files
+			validFiles := allFiles
 
-			// Resolve zone (could be name or ID)
-			zoneID, err = zones.ResolveZoneIdentifier(client, accountID, zoneID)
+			// Check the daily purge budget
+			budget, err := cache.LoadFileBudget("")
 			if err != nil {
-				return fmt.Errorf("failed to resolve zone: %w", err)
+				if opts.budget > 0 {
+					return err
+				}
+				fmt.Printf("Warning: daily purge count unavailable: %s\n", err)
 			}
 
-			// Now purge the files
-			validFiles := allFiles
+			limit := opts.budget
+			if limit <= 0 {
+				limit = cache.DefaultDailyFileLimit
+			}
+
+			skippedFiles := 0
+			if budget != nil {
+				remaining := budget.Remaining(zoneID, limit) / perURL
+				if len(validFiles) > remaining {
+					if opts.budget > 0 {
+						if remaining == 0 {
+							return fmt.Errorf("daily purge budget of %d URLs for zone %s is used up (%d purged today)",
+								limit, zoneID, budget.Used(zoneID))
+						}
+						fmt.Printf("Warning: purging only %d of %d files to stay within the daily budget of %d URLs (%d purged today)\n",
+							remaining, len(validFiles), limit, budget.Used(zoneID))
+						skippedFiles = len(validFiles) - remaining
+						validFiles = validFiles[:remaining]
+					} else {
+						fmt.Printf("Warning: purging %d files would exceed the estimated daily limit of %d URLs (%d purged today); use --budget to stop before exceeding it\n",
+							len(validFiles)*perURL, limit, budget.Used(zoneID))
+					}
+				}
+			}
+
+			// Save or check the plan of URLs to purge
+			if plan.Enabled() {
+				if err := plan.Apply("cache purge files", zoneID, validFiles); err != nil {
+					return err
+				}
+			}
 
 			// Handle dry run mode
 			if opts.dryRun {
 				fmt.Printf("DRY RUN: Would purge %d files from zone %s\n", len(validFiles), zoneID)
+				if useVariants {
+					fmt.Printf("Each file is purged in %d device type and country variants, %d purge entries in total\n",
+						perURL, len(validFiles)*perURL)
+				}
+				if len(prepared.Invalid) > 0 || prepared.Duplicates > 0 {
+					fmt.Printf("Skipped %d invalid and %d duplicate entries\n", len(prepared.Invalid), prepared.Duplicates)
+				}
+				if budget != nil {
+					fmt.Printf("Daily budget: %d of %d URLs used today, %d remaining\n",
+						budget.Used(zoneID), limit, budget.Remaining(zoneID, limit))
+				}
 				if opts.verbose {
 					for i, file := range validFiles {
 						fmt.Printf("  %d. %s\n", i+1, file)
@@ -184,7 +286,41 @@ The Cloudflare API requires complete URLs for cache purging.`,
 			// Check if we need to use batch processing
 			useBatchProcessing := len(validFiles) > 100 || opts.batchSize > 0 || opts.concurrency > 0
 
-			if !useBatchProcessing {
+			if useVariants {
+				// Purge every device type and country variant of each file
+				variants := cache.ExpandFileVariants(validFiles, variantDevices, variantCountries)
+				if opts.verbose {
+					fmt.Printf("Purging %d variants of each file, %d purge entries\n", perURL, len(variants))
+				}
+
+				successful, errors := cache.PurgeFilesWithHeadersInBatches(client, zoneID, variants, cache.NewPurgeBatchOptions(
+					cache.WithBatchSize(opts.batchSize),
+					cache.WithConcurrency(opts.concurrency),
+					cache.WithProgress(func(completed, total, successful int) {
+						if opts.verbose {
+							fmt.Printf("Progress: %d/%d batches completed, %d entries purged\n", completed, total, successful)
+						}
+					}),
+				))
+
+				// Report errors if any
+				for _, err := range errors {
+					fmt.Printf("Error during batch processing: %s\n", err)
+				}
+
+				data := make(map[string]string)
+				data["Operation"] = "Purge Files (Variants)"
+				data["Zone"] = zoneID
+				data["Files"] = fmt.Sprintf("%d", len(validFiles))
+				data["Variants per File"] = fmt.Sprintf("%d", perURL)
+				data["Entries Purged"] = fmt.Sprintf("%d", len(successful))
+				data["Failed Batches"] = fmt.Sprintf("%d", len(errors))
+				data["Status"] = "Complete"
+				recordFileBudget(budget, zoneID, len(successful), limit, skippedFiles, data)
+				recordSkippedURLs(prepared, data)
+
+				common.FormatKeyValueTable(data)
+			} else if !useBatchProcessing {
 				// For small numbers of files, use the direct API call
 				resp, err := cache.PurgeFiles(client, zoneID, validFiles)
 				if err != nil {
@@ -199,6 +335,8 @@ The Cloudflare API requires complete URLs for cache purging.`,
 				data["Files Purged"] = fmt.Sprintf("%d", len(validFiles))
 				data["Purge ID"] = resp.Result.ID
 				data["Status"] = "Success"
+				recordFileBudget(budget, zoneID, len(validFiles), limit, skippedFiles, data)
+				recordSkippedURLs(prepared, data)
 
 				common.FormatKeyValueTable(data)
 			} else {
@@ -215,6 +353,9 @@ The Cloudflare API requires complete URLs for cache purging.`,
 						if opts.verbose {
 							fmt.Printf("Progress: %d/%d batches completed, %d files purged\n",
 								completed, total, successful)
+							if quota, ok := common.CurrentRateLimitStatus(); ok {
+								fmt.Printf("API quota: %s\n", quota)
+							}
 						}
 					})
 
@@ -242,10 +383,22 @@ The Cloudflare API requires complete URLs for cache purging.`,
 				data["Batches"] = fmt.Sprintf("%d", (len(validFiles)+opts.batchSize-1)/opts.batchSize)
 				data["Failed Batches"] = fmt.Sprintf("%d", len(errors))
 				data["Status"] = "Complete"
+				recordFileBudget(budget, zoneID, len(successful), limit, skippedFiles, data)
+				recordSkippedURLs(prepared, data)
 
 				common.FormatKeyValueTable(data)
 			}
 
+			if opts.verbose {
+				if quota, ok := common.CurrentRateLimitStatus(); ok {
+					fmt.Printf("API quota: %s\n", quota)
+				}
+			}
+
+			if budget != nil && budget.NearLimit(zoneID, limit) {
+				fmt.Printf("Warning: %d of %d daily file purges used for zone %s\n", budget.Used(zoneID), limit, zoneID)
+			}
+
 			return nil
 		},
 	}
@@ -255,8 +408,41 @@ The Cloudflare API requires complete URLs for cache purging.`,
 	cmd.Flags().StringVar(&filesList, "files-list", "", "Path to a file containing a list of files to purge (one URL per line)")
 	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "Maximum number of files to purge in a single API request (max 500)")
 	cmd.Flags().IntVar(&concurrency, "concurrency", 10, "Maximum number of concurrent API requests (1-50)")
+	cmd.Flags().IntVar(&budgetLimit, "budget", 0, "Maximum URLs to purge per zone per day; files beyond it are not purged")
+	cmd.Flags().StringSliceVar(&deviceTypes, "device-types", nil, "Purge these device type variants of each file (mobile, tablet, desktop), sent as CF-Device-Type")
+	cmd.Flags().StringSliceVar(&countries, "countries", nil, "Purge these country variants of each file (two-letter codes such as US,DE), sent as CF-IPCountry")
+	cmd.Flags().StringVar(&plan.Out, "plan-out", "", "Save the URLs a dry run would purge to this plan file (with --dry-run)")
+	cmd.Flags().StringVar(&plan.In, "plan-in", "", "Check the URLs to purge against this plan file from a dry run before purging")
+	cmd.Flags().Float64Var(&plan.Threshold, "plan-threshold", 0, "Abort when the URLs differ from the --plan-in plan by more than this percentage")
 
 	// No need to update global variables - we use local variables directly
 
 	return cmd
 }
+
+// recordFileBudget adds purged URLs to the daily purge count and adds the budget to the summary
+func recordFileBudget(budget *cache.FileBudget, zoneID string, purged, limit, skipped int, data map[string]string) {
+	if budget == nil {
+		return
+	}
+
+	if err := budget.Record(zoneID, purged); err != nil {
+		fmt.Printf("Warning: %s\n", err)
+	}
+
+	data["Purged Today"] = fmt.Sprintf("%d", budget.Used(zoneID))
+	data["Remaining Today (est.)"] = fmt.Sprintf("%d of %d", budget.Remaining(zoneID, limit), limit)
+	if skipped > 0 {
+		data["Skipped (Budget)"] = fmt.Sprintf("%d", skipped)
+	}
+}
+
+// recordSkippedURLs adds the invalid and duplicate entries left out of the purge to the summary
+func recordSkippedURLs(prepared common.PreparedPurgeURLs, data map[string]string) {
+	if len(prepared.Invalid) > 0 {
+		data["Skipped (Invalid)"] = fmt.Sprintf("%d", len(prepared.Invalid))
+	}
+	if prepared.Duplicates > 0 {
+		data["Skipped (Duplicate)"] = fmt.Sprintf("%d", prepared.Duplicates)
+	}
+}
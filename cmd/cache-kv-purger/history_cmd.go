@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+
+	"github.com/spf13/cobra"
+)
+
+// zoneIDPattern matches a Cloudflare zone ID
+var zoneIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// createHistoryCmd creates a command to list the purge IDs of recent purges
+func createHistoryCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var limit int
+	var outputJSON bool
+	var clear bool
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List recent purges and their purge IDs",
+		Long: `List the purge requests made from this machine, newest first, with the purge ID
+Cloudflare returned for each. Quote the purge ID in Cloudflare support tickets about a purge.
+
+The history is kept in ~/.cache-kv-purger-history.json and holds the last 500 purge
+requests. Purges made from other machines or the dashboard are not included.`,
+		Example: `  # Show the last 20 purges
+  cache-kv-purger cache history
+
+  # Show the purges of one zone as JSON
+  cache-kv-purger cache history --zone 023e105f4ecef8ad9ca31a8372d0c353 --limit 100 --json
+
+  # Clear the history
+  cache-kv-purger cache history --clear`,
+		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
+			if clear {
+				if err := cache.ClearHistory(""); err != nil {
+					return err
+				}
+				fmt.Println("Purge history cleared.")
+				return nil
+			}
+
+			records, err := cache.LoadHistory("")
+			if err != nil {
+				return err
+			}
+
+			// Filter by zone ID if given
+			zoneID, _ := cmd.Flags().GetString("zone")
+			if zoneID != "" {
+				if !zoneIDPattern.MatchString(zoneID) {
+					return fmt.Errorf("--zone must be a zone ID for history, the zone name is not recorded")
+				}
+				filtered := records[:0]
+				for _, record := range records {
+					if record.ZoneID == zoneID {
+						filtered = append(filtered, record)
+					}
+				}
+				records = filtered
+			}
+
+			// Newest first
+			sort.SliceStable(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+			if limit > 0 && len(records) > limit {
+				records = records[:limit]
+			}
+
+			if outputJSON {
+				if records == nil {
+					records = []cache.PurgeRecord{}
+				}
+				return common.OutputJSON(records)
+			}
+
+			if len(records) == 0 {
+				fmt.Println("No purges recorded.")
+				return nil
+			}
+			printPurgeRecords(records)
+			return nil
+		}),
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of purges to show (0 for all)")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output the history as JSON")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Delete the purge history")
+	cmd.Flags().Bool("verbose", false, "Enable verbose output")
+
+	return cmd
+}
+
+// printPurgeRecords prints purge requests as a table
+func printPurgeRecords(records []cache.PurgeRecord) {
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		items := strconv.Itoa(record.Items)
+		if record.Type == "everything" {
+			items = "-"
+		}
+		rows[i] = []string{record.Time.Local().Format(time.DateTime), record.ZoneID, record.Type, items, record.ID}
+	}
+	common.FormatTable([]string{"Time", "Zone ID", "Type", "Items", "Purge ID"}, rows)
+}
+
+// savePurgeHistory adds the purges of this run to the history file. When the run made
+// several purge requests, as batched and multi-zone purges do, their purge IDs are listed
+// by zone, since the command output only summarizes them.
+func savePurgeHistory() {
+	records := cache.SessionPurges()
+	if len(records) == 0 {
+		return
+	}
+
+	if len(records) > 1 {
+		byZone := make([]cache.PurgeRecord, len(records))
+		copy(byZone, records)
+		sort.SliceStable(byZone, func(i, j int) bool { return byZone[i].ZoneID < byZone[j].ZoneID })
+		fmt.Printf("Purge IDs (%d requests):\n", len(byZone))
+		printPurgeRecords(byZone)
+	}
+
+	// Replayed purge IDs are not real, so mock runs are not recorded
+	if api.MockDir() != "" {
+		return
+	}
+	if err := cache.AppendHistory("", records); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
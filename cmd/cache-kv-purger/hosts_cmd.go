@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,11 +23,21 @@ func createPurgeHostsCmd() *cobra.Command {
 	var autoZoneDetect bool
 	var batchSize int
 	var dryRun bool
+	var skipHostCheck bool
 
 	cmd := &cobra.Command{
 		Use:   "hosts",
 		Short: "Purge cached content by hostname",
-		Long:  `Purge cached content from Cloudflare's edge servers based on hostname.`,
+		Long: `Purge cached content from Cloudflare's edge servers based on hostname.
+
+Without --zone, each host's zone is detected from the zones in the account (the
+longest matching zone name wins), the hosts are grouped by zone, and the zones are
+purged concurrently (--zone-concurrency) in batches of up to 100 hosts. A summary
+lists the result of every zone, and the command fails if any zone had errors.
+
+With --zone, every host must be the zone's name or one of its subdomains. Hosts
+outside the zone are listed and nothing is purged, since the API would reject the
+batches they are in. Use --skip-host-check to send them anyway.`,
 		Example: `  # Purge a single host
   cache-kv-purger cache purge hosts --zone example.com --host images.example.com
 
@@ -40,6 +52,9 @@ func createPurgeHostsCmd() *cobra.Command {
   
   # Auto-detect zones based on hostnames (no need to specify zone)
   cache-kv-purger cache purge hosts --host images.example.com --host api.example2.com
+
+  # Purge hosts of many zones from a file, 5 zones at a time, with a per-zone summary
+  cache-kv-purger cache purge hosts --hosts-file hosts.txt --zone-concurrency 5
   
   # Control batch size and concurrency
   cache-kv-purger cache purge hosts --zone example.com --hosts-file hosts.txt --batch-size 20 --concurrency 15 --zone-concurrency 5
@@ -56,11 +71,8 @@ func createPurgeHostsCmd() *cobra.Command {
 			}
 
 			// Get account ID for resolving zone names
-			accountID := ""
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 			// Collect all hosts from various input methods
 			allHosts := make([]string, 0)
@@ -129,10 +141,13 @@ func createPurgeHostsCmd() *cobra.Command {
 			}
 
 			// If no specific zone is provided, try auto-detection
-			if len(purgeFlagsVars.zones) == 0 && purgeFlagsVars.zoneID == "" && cmd.Flags().Lookup("zone").Value.String() == "" {
-				// No zone specified, so try to auto-detect zones from hosts
-				// Pass concurrency settings to the handler
-				return handleAutoZoneDetectionForHosts(client, accountID, allHosts, cmd, cacheConcurrency, multiZoneConcurrency)
+			zoneGiven := len(purgeFlagsVars.zones) > 0 || purgeFlagsVars.zoneID != "" || cmd.Flags().Lookup("zone").Value.String() != ""
+			if autoZoneDetect && zoneGiven {
+				return fmt.Errorf("--auto-zone cannot be used with --zone")
+			}
+			if !zoneGiven {
+				// No zone specified, so detect each host's zone and purge the zones concurrently
+				return purgeHostsAcrossZones(cmd, client, accountID, allHosts, cacheConcurrency, multiZoneConcurrency, dryRun, verbose)
 			}
 
 			// Get the zone ID from flag, config, or environment variable
@@ -142,21 +157,27 @@ func createPurgeHostsCmd() *cobra.Command {
 				zoneID, _ = cmd.Flags().GetString("zone")
 			}
 
-			if zoneID == "" {
-				// Try to get from config or environment variable
-				if cfg != nil {
-					zoneID = cfg.GetZoneID()
-				}
+			// Resolve zone (could be name or ID), falling back to the default zone
+			resolvedZoneID, err := cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+			if err != nil {
+				return err
 			}
 
-			if zoneID == "" {
-				return fmt.Errorf("zone ID is required, specify it with --zone flag, CLOUDFLARE_ZONE_ID environment variable, or set a default zone in config")
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, []string{resolvedZoneID}); err != nil {
+				return err
 			}
 
-			// Resolve zone (could be name or ID)
-			resolvedZoneID, err := zones.ResolveZoneIdentifier(client, accountID, zoneID)
-			if err != nil {
-				return fmt.Errorf("failed to resolve zone: %w", err)
+			// Catch hosts of other zones before they fail a batch
+			if !skipHostCheck {
+				if err := checkHostsInZone(client, resolvedZoneID, allHosts, verbose); err != nil {
+					return err
+				}
+			}
+
+			// Make sure the zone's plan can purge by hosts, or fall back to URLs
+			if handled, err := checkPurgePlan(client, resolvedZoneID, "hosts", allHosts, dryRun, verbose); handled || err != nil {
+				return err
 			}
 
 			// Default batch size if not specified or invalid
@@ -198,12 +219,8 @@ func createPurgeHostsCmd() *cobra.Command {
 			}
 
 			// For larger numbers, use batching with concurrency
-			// Cap concurrency for Enterprise tier
-			if cacheConcurrency <= 0 {
-				cacheConcurrency = 50 // Enterprise tier default
-			} else if cacheConcurrency > 50 {
-				cacheConcurrency = 50 // Enterprise tier allows 50 requests per second
-			}
+			// Default to the Enterprise tier's 50 requests per second, capped by the policy
+			cacheConcurrency = common.CurrentPolicy().Concurrency(cacheConcurrency, 50)
 
 			// Split hosts into batches (for preview in dry run mode)
 			batches := common.SplitIntoBatches(allHosts, batchSize)
@@ -239,7 +256,11 @@ func createPurgeHostsCmd() *cobra.Command {
 			}
 
 			// Process hosts with concurrent batching
-			successful, errors := cache.PurgeHostsInBatches(client, resolvedZoneID, allHosts, progressFn, cacheConcurrency)
+			successful, errors := cache.PurgeHostsInBatches(client, resolvedZoneID, allHosts, cache.NewPurgeBatchOptions(
+				cache.WithBatchSize(batchSize),
+				cache.WithConcurrency(cacheConcurrency),
+				cache.WithProgress(progressFn),
+			))
 
 			// Print a newline to clear the progress line
 			if !verbose {
@@ -270,7 +291,176 @@ func createPurgeHostsCmd() *cobra.Command {
 	cmd.Flags().StringVar(&hostsFile, "hosts-file", "", "Path to a text file containing hostnames to purge (one host per line)")
 	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "Maximum number of hosts to purge in each batch (API limit: 100 items per request)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without actually purging")
-	cmd.Flags().BoolVar(&autoZoneDetect, "auto-zone", false, "Auto-detect zones based on hostnames")
+	cmd.Flags().BoolVar(&autoZoneDetect, "auto-zone", false, "Auto-detect each host's zone (the default when --zone is not given)")
+	cmd.Flags().BoolVar(&skipHostCheck, "skip-host-check", false, "Purge hosts even if they are not in the zone given with --zone")
 
 	return cmd
 }
+
+// checkHostsInZone returns an error listing the hosts that are not the zone's name or
+// one of its subdomains. Zones whose details cannot be read are not checked.
+func checkHostsInZone(client *api.Client, zoneID string, hosts []string, verbose bool) error {
+	details, err := zones.GetZoneDetails(client, zoneID)
+	if err != nil || details.Result.Name == "" {
+		if verbose {
+			fmt.Printf("Warning: could not read the zone name, skipping the host check: %v\n", err)
+		}
+		return nil
+	}
+
+	outside := zones.HostsOutsideZone(details.Result.Name, hosts)
+	if len(outside) == 0 {
+		return nil
+	}
+
+	fmt.Printf("%d of %d hosts are not in zone %s:\n", len(outside), len(hosts), details.Result.Name)
+	for _, host := range outside {
+		fmt.Printf("  %s\n", host)
+	}
+	return fmt.Errorf("%d hosts don't belong to zone %s; purge them without --zone to detect their zones, or use --skip-host-check",
+		len(outside), details.Result.Name)
+}
+
+// purgeHostsAcrossZones detects the zone of each host, groups the hosts by zone and purges
+// the zones concurrently, ending with a per-zone summary
+func purgeHostsAcrossZones(cmd *cobra.Command, client *api.Client, accountID string, hosts []string,
+	cacheConcurrency, zoneConcurrency int, dryRun, verbose bool) error {
+
+	if accountID == "" {
+		return fmt.Errorf("account ID is required to auto-detect zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config, or pass --zone")
+	}
+
+	// Zone names are lowercase and DNS names may carry a trailing dot
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		normalized = append(normalized, strings.TrimSuffix(strings.ToLower(host), "."))
+	}
+	normalized = common.RemoveDuplicates(normalized)
+
+	if verbose {
+		fmt.Printf("Auto-detecting zones for %d hosts...\n", len(normalized))
+	}
+
+	hostZones, unknownHosts, err := zones.DetectZonesFromHosts(client, accountID, normalized)
+	if err != nil {
+		return fmt.Errorf("failed to detect zones: %w", err)
+	}
+	if len(unknownHosts) > 0 {
+		return fmt.Errorf("%d hosts don't belong to any zone in the account: %s",
+			len(unknownHosts), strings.Join(unknownHosts, ", "))
+	}
+
+	hostsByZone := make(map[string][]string)
+	for _, host := range normalized {
+		zoneID := hostZones[host]
+		hostsByZone[zoneID] = append(hostsByZone[zoneID], host)
+	}
+
+	zoneIDs := make([]string, 0, len(hostsByZone))
+	for zoneID := range hostsByZone {
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+	sort.Strings(zoneIDs)
+
+	// Refuse to purge protected zones
+	if err := checkZonesProtection(cmd, client, zoneIDs); err != nil {
+		return err
+	}
+
+	zoneNames := make(map[string]string, len(zoneIDs))
+	for _, zoneID := range zoneIDs {
+		zoneNames[zoneID] = zoneID
+		if details, err := zones.GetZoneDetails(client, zoneID); err == nil && details.Result.Name != "" {
+			zoneNames[zoneID] = details.Result.Name
+		}
+
+		// Make sure each zone's plan can purge by hosts, or fall back to URLs
+		handled, err := checkPurgePlan(client, zoneID, "hosts", hostsByZone[zoneID], dryRun, verbose)
+		if err != nil {
+			return err
+		}
+		if handled {
+			delete(hostsByZone, zoneID)
+		}
+	}
+
+	if len(hostsByZone) == 0 {
+		return nil
+	}
+
+	if dryRun {
+		total := 0
+		rows := make([][]string, 0, len(hostsByZone))
+		for _, zoneID := range zoneIDs {
+			zoneHosts, ok := hostsByZone[zoneID]
+			if !ok {
+				continue
+			}
+			total += len(zoneHosts)
+			rows = append(rows, []string{zoneNames[zoneID], strconv.Itoa(len(zoneHosts)),
+				strconv.Itoa(len(common.SplitIntoBatches(zoneHosts, 100)))})
+		}
+		fmt.Printf("DRY RUN: Would purge %d hosts across %d zones\n", total, len(hostsByZone))
+		common.FormatTable([]string{"Zone", "Hosts", "Batches"}, rows)
+		if verbose {
+			for _, zoneID := range zoneIDs {
+				for _, host := range hostsByZone[zoneID] {
+					fmt.Printf("  %s: %s\n", zoneNames[zoneID], host)
+				}
+			}
+		}
+		return nil
+	}
+
+	fmt.Printf("Purging hosts across %d zones...\n", len(hostsByZone))
+
+	var progressFn func(zoneID string, completed, total, successful int)
+	if verbose {
+		progressFn = func(zoneID string, completed, total, successful int) {
+			fmt.Printf("Zone %s: processed %d/%d batches, %d hosts purged\n",
+				zoneNames[zoneID], completed, total, successful)
+		}
+	}
+
+	results := cache.PurgeHostsByZone(client, hostsByZone, cache.NewPurgeBatchOptions(
+		cache.WithZoneConcurrency(zoneConcurrency),
+		cache.WithConcurrency(cacheConcurrency),
+		cache.WithZoneProgress(progressFn),
+	))
+
+	// Per-zone summary
+	purged, total, failedZones := 0, 0, 0
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		status := "ok"
+		if !result.OK() {
+			failedZones++
+			status = "failed"
+			if len(result.Purged) > 0 {
+				status = "partial"
+			}
+		}
+		purged += len(result.Purged)
+		total += len(result.Hosts)
+		rows = append(rows, []string{zoneNames[result.ZoneID], strconv.Itoa(len(result.Hosts)),
+			strconv.Itoa(len(result.Purged)), strconv.Itoa(len(result.Errors)), status})
+	}
+	fmt.Println()
+	common.FormatTable([]string{"Zone", "Hosts", "Purged", "Failed Batches", "Status"}, rows)
+
+	for _, result := range results {
+		for i, err := range result.Errors {
+			if i == 3 { // Show at most 3 errors per zone
+				fmt.Printf("  - %s: ... and %d more errors\n", zoneNames[result.ZoneID], len(result.Errors)-3)
+				break
+			}
+			fmt.Printf("  - %s: %s\n", zoneNames[result.ZoneID], err)
+		}
+	}
+
+	fmt.Printf("Completed: Successfully purged %d of %d hosts across %d zones\n", purged, total, len(results))
+	if failedZones > 0 {
+		return fmt.Errorf("%d of %d zones had purge errors", failedZones, len(results))
+	}
+	return nil
+}
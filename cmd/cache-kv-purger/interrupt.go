@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+)
+
+// checkpointFileName is the name of the file in the home directory that records what an
+// interrupted run completed
+const checkpointFileName = ".cache-kv-purger-interrupted.json"
+
+// interruptCheckpoint records what an interrupted run completed before it stopped
+type interruptCheckpoint struct {
+	Command       string                  `json:"command"`
+	InterruptedAt time.Time               `json:"interrupted_at"`
+	Completed     []common.CompletedCount `json:"completed"`
+	Purges        []cache.PurgeRecord     `json:"purges"`
+	Error         string                  `json:"error,omitempty"`
+}
+
+// reportInterrupted prints what an interrupted run completed and writes it to the
+// checkpoint file, so the rest of the work can be picked up from there
+func reportInterrupted(err error) {
+	checkpoint := interruptCheckpoint{
+		Command:       strings.Join(os.Args, " "),
+		InterruptedAt: time.Now().UTC(),
+		Completed:     common.CompletedWork(),
+		Purges:        cache.SessionPurges(),
+	}
+	if err != nil {
+		checkpoint.Error = err.Error()
+	}
+
+	// Purges are reported per type, like other completed work
+	purged := make(map[string]int)
+	for _, record := range checkpoint.Purges {
+		purged[record.Type] += max(record.Items, 1)
+	}
+	for purgeType, items := range purged {
+		what := purgeType + " purged"
+		if purgeType == "files" {
+			what = "URLs purged"
+		}
+		checkpoint.Completed = append(checkpoint.Completed, common.CompletedCount{What: what, Count: items})
+	}
+	sort.Slice(checkpoint.Completed, func(i, j int) bool { return checkpoint.Completed[i].What < checkpoint.Completed[j].What })
+
+	fmt.Fprintln(os.Stderr, "Interrupted. Completed before stopping:")
+	if len(checkpoint.Completed) == 0 {
+		fmt.Fprintln(os.Stderr, "  nothing")
+	}
+	for _, count := range checkpoint.Completed {
+		fmt.Fprintf(os.Stderr, "  %d %s\n", count.Count, count.What)
+	}
+
+	homeDir, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return
+	}
+	path := filepath.Join(homeDir, checkpointFileName)
+	data, marshalErr := json.MarshalIndent(checkpoint, "", "  ")
+	if marshalErr != nil {
+		return
+	}
+	if writeErr := os.WriteFile(path, data, 0600); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write checkpoint: %v\n", writeErr)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Checkpoint written to %s\n", path)
+}
@@ -46,6 +46,8 @@ var kvFlagsVars struct {
 	file          string
 	verbose       bool
 	includeValues bool
+	worker        string
+	binding       string
 }
 
 // addMissingValueValidation adds validation for flags that require values
@@ -112,10 +114,15 @@ func init() {
 	kvCmd.PersistentFlags().StringVar(&kvFlagsVars.file, "file", "", "Output or input file path")
 	kvCmd.PersistentFlags().StringVar(&kvFlagsVars.key, "key", "", "Key name")
 
+	// Namespaces can be referenced through a Worker's binding names
+	kvCmd.PersistentFlags().StringVar(&kvFlagsVars.worker, "worker", "", "Worker script whose KV bindings --binding refers to")
+	kvCmd.PersistentFlags().StringVar(&kvFlagsVars.binding, "binding", "", "KV binding name of the --worker script (alternative to namespace-id)")
+
 	// Add all KV subcommands directly in kv_cmd.go using the builder pattern
 	kvCmd.AddCommand(cmdutil.NewKVListCommand().Build())
 	kvCmd.AddCommand(cmdutil.NewKVGetCommand().Build())
 	kvCmd.AddCommand(cmdutil.NewKVPutCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVExistsCommand().Build())
 
 	// Instead of adding the original delete command, add the fixed version
 	// kvCmd.AddCommand(cmdutil.NewKVDeleteCommand().Build())
@@ -123,6 +130,26 @@ func init() {
 
 	kvCmd.AddCommand(cmdutil.NewKVCreateCommand().Build())
 	kvCmd.AddCommand(cmdutil.NewKVRenameCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVEnsureCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVCopyCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVExportCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVBackupCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVRestoreCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVTagsCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVBrowseCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVJSONCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVValidateKeysCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVExpireCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVUndoCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVReplaceCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVRetagCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVReprefixCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVApplyCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVSampleCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVSeedCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVLimitsCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVBindingsCommand().Build())
+	kvCmd.AddCommand(cmdutil.NewKVEnvCommand().Build())
 	kvCmd.AddCommand(cmdutil.NewKVConfigCommand().Build())
 
 	// Demo commands removed for production build
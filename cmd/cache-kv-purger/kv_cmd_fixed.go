@@ -3,6 +3,8 @@ package main
 import (
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/kv"
 	"context"
 	"fmt"
@@ -11,6 +13,20 @@ import (
 	"github.com/spf13/pflag"
 )
 
+// originalDeleteFlags are the kv delete flags the fixed tag-based path doesn't implement.
+// Runs using any of them take the original implementation, so no filter is ignored.
+var originalDeleteFlags = []string{"exclude-prefix", "exclude-pattern", "exclude-keys-file", "pattern", "plan-in", "plan-out", "plan-threshold", "estimate"}
+
+// anyFlagChanged returns true if any of the named flags was set on the command line
+func anyFlagChanged(flags *pflag.FlagSet, names []string) bool {
+	for _, name := range names {
+		if flags.Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
 // addFixedDeleteCommand adds the fixed kv delete command to a parent command
 func addFixedDeleteCommand(parentCmd *cobra.Command) {
 	// Get the original delete command
@@ -37,14 +53,24 @@ func addFixedDeleteCommand(parentCmd *cobra.Command) {
 			// key not used in this implementation
 			tagField, _ := cmd.Flags().GetString("tag-field")
 			tagValue, _ := cmd.Flags().GetString("tag-value")
+			prefix, _ := cmd.Flags().GetString("prefix")
 			bulk, _ := cmd.Flags().GetBool("bulk")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			batchSize, _ := cmd.Flags().GetInt("batch-size")
 			concurrency, _ := cmd.Flags().GetInt("concurrency")
 			verbosity, _ := cmd.Flags().GetString("verbosity")
 
-			// Check if this is a tag-based deletion where we need our fix
-			isTagBased := bulk && tagField != ""
+			// Check if this is a tag-based deletion where we need our fix. Journaled, archived,
+			// expiration-filtered, confirmed and --from-export deletions need the matching keys
+			// up front, which the original implementation finds.
+			archiveTo, _ := cmd.Flags().GetString("archive-to")
+			noExpiration, _ := cmd.Flags().GetBool("no-expiration")
+			hasExpiration, _ := cmd.Flags().GetBool("has-expiration")
+			force, _ := cmd.Flags().GetBool("force")
+			fromExport, _ := cmd.Flags().GetString("from-export")
+			isTagBased := bulk && tagField != "" && !cmdutil.JournalEnabled(cmd) && archiveTo == "" &&
+				!noExpiration && !hasExpiration && (force || dryRun) && fromExport == "" &&
+				!anyFlagChanged(cmd.Flags(), originalDeleteFlags)
 
 			if isTagBased {
 				// Get the client using the WithConfigAndClient middleware
@@ -56,16 +82,34 @@ func addFixedDeleteCommand(parentCmd *cobra.Command) {
 					return err
 				}
 
+				cfg, err := config.LoadFromFile("")
+				if err != nil {
+					cfg = config.New()
+				}
+
+				// Resolve --worker/--binding to a namespace ID
+				if err := cmdutil.ApplyWorkerBinding(cmd, cfg, client); err != nil {
+					return err
+				}
+				namespaceID, _ = cmd.Flags().GetString("namespace-id")
+
+				// Apply the namespace's configured defaults as the original implementation does.
+				// They only fill in --batch-size and --concurrency here, which don't decide the path.
+				cmdutil.ApplyNamespaceDefaults(cmd, cfg, client)
+				batchSize, _ = cmd.Flags().GetInt("batch-size")
+				concurrency, _ = cmd.Flags().GetInt("concurrency")
+
 				// Determine verbosity
 				debug := verbosity == "debug"
 				// verbose not used but would be:
 				// verbose := verbosity == "verbose" || debug
 
 				// Call our fixed implementation directly
-				fmt.Printf("[INFO] Using fixed implementation for tag-based deletion\n")
+				common.LogVerbose("Using fixed implementation for tag-based deletion")
 
-				if accountID == "" {
-					return fmt.Errorf("account-id is required")
+				accountID, err := cmdutil.RequireAccountID(cmd, cfg, accountID)
+				if err != nil {
+					return err
 				}
 
 				// Resolve namespace ID if needed
@@ -84,22 +128,21 @@ func addFixedDeleteCommand(parentCmd *cobra.Command) {
 					return fmt.Errorf("namespace-id or namespace is required")
 				}
 
+				// Refuse to touch protected namespaces
+				if err := cmdutil.CheckNamespaceProtection(context.Background(), cmd, cfg, kv.NewKVService(client), accountID, namespaceID); err != nil {
+					return err
+				}
+
 				// Set up a progress callback based on verbosity
-				progressCallback := func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
+				progressCallback := func(event kv.ProgressEvent) {
 					if debug {
-						fetchPercent := 0.0
-						procPercent := 0.0
-						if total > 0 {
-							fetchPercent = float64(keysFetched) / float64(total) * 100
-							procPercent = float64(keysProcessed) / float64(total) * 100
-						}
-						fmt.Printf("[DEBUG] Progress: %d/%d keys fetched (%.1f%%), %d/%d processed (%.1f%%), %d matched, %d deleted\n",
-							keysFetched, total, fetchPercent, keysProcessed, total, procPercent, keysMatched, keysDeleted)
+						common.LogDebug("Progress: %s (%d fetched, %d matched, %d deleted)",
+							event, event.Fetched, event.Matched, event.Deleted)
 					}
 				}
 
 				// Call our fixed implementation
-				count, err := kv.PurgeByMetadataOnlyFixed(client, accountID, namespaceID, tagField, tagValue,
+				count, err := kv.PurgeByMetadataOnlyFixed(client, accountID, namespaceID, prefix, tagField, tagValue,
 					batchSize, concurrency, dryRun, progressCallback)
 
 				if err != nil {
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testNamespaceID is a namespace ID that needs no lookup
+const testNamespaceID = "0123456789abcdef0123456789abcdef"
+
+// newTaggedKeysAPI serves a namespace whose keys all carry the cache-tag x
+func newTaggedKeysAPI(t *testing.T, keys ...string) *fakeAPI {
+	return newFakeAPI(t, func(method, path, body string) (int, string) {
+		switch {
+		case method == "GET" && strings.HasSuffix(path, "/keys"):
+			entries := make([]string, len(keys))
+			for i, key := range keys {
+				entries[i] = `{"name": "` + key + `", "metadata": {"cache-tag": "x"}}`
+			}
+			return 200, `{"success": true, "result": [` + strings.Join(entries, ",") + `], "result_info": {"count": ` +
+				strconv.Itoa(len(keys)) + `, "cursor": ""}}`
+		case strings.Contains(path, "/metadata/"):
+			return 200, `{"success": true, "result": {"cache-tag": "x"}}`
+		case strings.HasSuffix(path, "/bulk/delete"):
+			return 200, `{"success": true, "result": {"successful_key_count": 1}}`
+		}
+		return 200, ""
+	})
+}
+
+func TestTagDeleteKeepsExcludedKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		flag []string
+	}{
+		{name: "exclude prefix", flag: []string{"--exclude-prefix", "cache/critical/"}},
+		{name: "exclude pattern", flag: []string{"--exclude-pattern", "^cache/critical/"}},
+		{name: "pattern", flag: []string{"--pattern", "^cache/b$"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTaggedKeysAPI(t, "cache/b", "cache/critical/a")
+			args := append([]string{"kv", "delete", "--bulk", "--namespace-id", testNamespaceID,
+				"--tag-field", "cache-tag", "--tag-value", "x", "--force"}, tt.flag...)
+			if err := runCLI(t, api, args...); err != nil {
+				t.Fatalf("delete failed: %v", err)
+			}
+
+			deletes := api.Requests("/bulk/delete")
+			if len(deletes) == 0 {
+				t.Fatal("expected the matching key to be deleted")
+			}
+			for _, request := range deletes {
+				if strings.Contains(request, "cache/critical/a") {
+					t.Errorf("excluded key was deleted: %s", request)
+				}
+			}
+		})
+	}
+}
+
+func TestTagDeleteUsesPlans(t *testing.T) {
+	tagDelete := []string{"kv", "delete", "--bulk", "--namespace-id", testNamespaceID, "--tag-field", "cache-tag", "--tag-value", "x"}
+
+	api := newTaggedKeysAPI(t, "cache/a", "cache/b")
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if err := runCLI(t, api, append(tagDelete, "--plan-in", missing, "--force")...); err == nil {
+		t.Error("expected a missing --plan-in plan to fail the delete")
+	}
+	if deletes := api.Requests("/bulk/delete"); len(deletes) > 0 {
+		t.Errorf("keys were deleted without the plan: %v", deletes)
+	}
+
+	planOut := filepath.Join(t.TempDir(), "plan.json")
+	if err := runCLI(t, api, append(tagDelete, "--dry-run", "--plan-out", planOut)...); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if _, err := os.Stat(planOut); err != nil {
+		t.Errorf("dry run wrote no plan: %v", err)
+	}
+}
+
+func TestJSONGetRunsNestedPreRunHooks(t *testing.T) {
+	api := newFakeAPI(t, func(method, path, body string) (int, string) {
+		if strings.Contains(path, "/values/") {
+			return 200, `{"config": {"enabled": true}}`
+		}
+		return 200, ""
+	})
+
+	// kv json get is nested two builder commands deep, so its pre-run hooks run in a chain
+	if err := runCLI(t, api, "kv", "json", "get", "--namespace-id", testNamespaceID,
+		"--key", "settings", "--path", ".config.enabled"); err != nil {
+		t.Fatalf("json get failed: %v", err)
+	}
+}
+
+func TestWritesRefuseProtectedNamespaces(t *testing.T) {
+	const otherNamespaceID = "fedcba9876543210fedcba9876543210"
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "put", args: []string{"kv", "put", "--namespace-id", testNamespaceID, "--key", "a", "--value", "b"}},
+		{name: "copy", args: []string{"kv", "copy", "--source-namespace-id", otherNamespaceID,
+			"--dest-namespace-id", testNamespaceID, "--key", "a"}},
+		{name: "json set", args: []string{"kv", "json", "set", "--namespace-id", testNamespaceID,
+			"--key", "a", "--path", ".b", "--value", "1"}},
+		{name: "rename", args: []string{"kv", "rename", "--namespace-id", testNamespaceID, "--title", "renamed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t, nil)
+			err := runCLIWithConfig(t, api, `{"protected_namespaces": ["`+testNamespaceID+`"]}`, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), "protected") {
+				t.Errorf("error = %v, want a protected namespace refusal", err)
+			}
+			if requests := api.Requests(""); len(requests) > 0 {
+				t.Errorf("sent requests to a protected namespace: %v", requests)
+			}
+		})
+	}
+}
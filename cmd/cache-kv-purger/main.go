@@ -3,6 +3,15 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -43,6 +52,43 @@ func init() {
 	rootCmd.PersistentFlags().String("verbosity", "normal", "Verbosity level: quiet, normal, verbose, debug. Overrides command-specific --verbose flags")
 	rootCmd.PersistentFlags().StringP("zone", "z", "", "Cloudflare Zone ID or domain name (required for most commands)")
 	rootCmd.PersistentFlags().Bool("version", false, "Print version information")
+	rootCmd.PersistentFlags().String("api-endpoint", "", "Cloudflare API base URL (overrides CLOUDFLARE_API_ENDPOINT and config)")
+	rootCmd.PersistentFlags().Bool("override-protection", false, "Allow destructive commands to touch namespaces and zones protected in config")
+	rootCmd.PersistentFlags().Int("max-concurrency", 0, "Upper bound of in-flight API requests; concurrency adapts below it to 429s and latency (overrides CLOUDFLARE_MAX_CONCURRENCY and config)")
+	rootCmd.PersistentFlags().Int("retries", common.DefaultRetries, "Retries of failed API requests and purge batches (overrides CLOUDFLARE_RETRY_COUNT and config)")
+	rootCmd.PersistentFlags().Duration("backoff", common.DefaultBackoff, "Delay before the first retry, doubled for each further one (overrides CLOUDFLARE_BACKOFF_DELAY and config)")
+	rootCmd.PersistentFlags().Duration("api-timeout", common.DefaultTimeout, "Time allowed for one API request (overrides CLOUDFLARE_API_TIMEOUT and config)")
+	rootCmd.PersistentFlags().Int("rate-limit", common.DefaultRateLimit, "API requests per second across all workers (overrides CLOUDFLARE_RATE_LIMIT and config)")
+	rootCmd.PersistentFlags().String("mock", "", "Record API responses to this directory on first run and replay them afterwards (overrides CACHE_KV_MOCK)")
+	rootCmd.PersistentFlags().Bool("yes", false, "Answer confirmation prompts with yes (or set "+config.EnvAssumeYes+")")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Machine mode for scripts: implies --force, answers prompts with yes, and writes only JSON results (or nothing) to stdout; errors still go to stderr")
+	rootCmd.PersistentFlags().String("accounts-file", "", "Run a kv or cache command once per account listed in this file (JSON tenants or one account ID per line)")
+	rootCmd.PersistentFlags().Int("account-concurrency", 3, "Number of accounts processed concurrently with --accounts-file")
+	rootCmd.PersistentFlags().Bool("wide", false, "Show long table cells in full instead of truncating them")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output (or set NO_COLOR)")
+	rootCmd.PersistentFlags().Bool("mock-offline", false, "With --mock, fail requests that have no recorded response instead of sending them (or set CACHE_KV_MOCK_OFFLINE)")
+	rootCmd.PersistentFlags().Int("purge-rate", 0, "Purge calls allowed per minute per zone, shared by all concurrent batches; 0 for no pacing (overrides CLOUDFLARE_PURGE_RATE and config)")
+	rootCmd.PersistentFlags().Int("metadata-workers", 0, "Metadata requests in flight across all workers; 0 bounds them only by --max-concurrency (overrides CLOUDFLARE_METADATA_WORKERS and config)")
+	rootCmd.PersistentFlags().String("log-format", common.LogFormatText, "Log format: text (console lines) or json (JSON records, on stderr unless --log-file is set)")
+	rootCmd.PersistentFlags().Duration("namespace-cache", 0, "Also keep namespace title to ID resolutions on disk for this long, e.g. 10m, shared by later commands (overrides CACHE_KV_NAMESPACE_CACHE)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Resolve namespace titles with a fresh namespace listing instead of cached resolutions")
+	rootCmd.PersistentFlags().String("log-file", "", "Also write log records to this file, in --log-format, at least at the verbose level")
+	rootCmd.PersistentFlags().StringArray("redact-pattern", nil, "Regular expression whose matches are redacted from log output, on top of the built-in secret patterns and redact_patterns in config (can be repeated)")
+	rootCmd.PersistentFlags().String("ca-cert", "", "PEM bundle of CA certificates trusted for API connections in addition to the system roots, e.g. of a TLS-intercepting proxy (overrides CLOUDFLARE_CA_CERT)")
+	rootCmd.PersistentFlags().String("client-cert", "", "PEM client certificate presented to the API or proxy for mutual TLS (overrides CLOUDFLARE_CLIENT_CERT)")
+	rootCmd.PersistentFlags().String("client-key", "", "PEM key of --client-cert (overrides CLOUDFLARE_CLIENT_KEY)")
+	rootCmd.PersistentFlags().String("proxy", "", "HTTP(S) proxy for API requests (overrides HTTPS_PROXY and HTTP_PROXY)")
+	rootCmd.PersistentFlags().Bool("no-redact", false, "Don't redact secrets such as tokens and API keys from verbose and debug output")
+	rootCmd.PersistentFlags().String("transcript", "", "Record every step of the run, with timestamps, confirmation answers and purge IDs, to this Markdown file for change tickets")
+	rootCmd.PersistentFlags().String("notify-url", "", "POST a JSON summary of purge and delete runs (counts, duration, errors, purge IDs) to this webhook (overrides "+config.EnvNotifyURL+")")
+	rootCmd.PersistentFlags().String("notify-slack", "", "Post the summary of purge and delete runs to this Slack incoming webhook URL (overrides "+config.EnvNotifySlack+")")
+
+	// Apply quiet mode, the transcript, logging, redaction, table rendering, the API endpoint, TLS and proxy,
+	// the retry, timeout, rate and concurrency policy, purge rate, mock mode, the namespace cache and the
+	// notification webhooks once flags are parsed, before any client is created
+	cobra.OnInitialize(initializeQuiet, initializeTranscript, initializeLogging, initializeRedaction, initializeRender, initializeAPIEndpoint,
+		initializeTLS, initializePolicy, initializeMetadataWorkers, initializePurgeRate, initializeMock,
+		initializeNamespaceCache, initializeNotify)
 
 	// Initialize default rate limits
 	initializeRateLimits()
@@ -57,6 +103,269 @@ func initializeRateLimits() {
 	// Rate limits are initialized when first used
 }
 
+// initializeQuiet answers prompts with yes when --yes or CACHE_KV_ASSUME_YES is set, and
+// enables machine mode from the --quiet flag: only JSON results reach stdout, usage and
+// banners are suppressed, and errors are printed once to stderr
+func initializeQuiet() {
+	yes, _ := strconv.ParseBool(os.Getenv(config.EnvAssumeYes))
+	if yesFlag, _ := rootCmd.PersistentFlags().GetBool("yes"); yesFlag {
+		yes = true
+	}
+	common.SetAssumeYes(yes)
+
+	if quiet, _ := rootCmd.PersistentFlags().GetBool("quiet"); !quiet {
+		return
+	}
+
+	if err := common.EnableQuietMode(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	_ = rootCmd.PersistentFlags().Set("verbosity", "quiet")
+	rootCmd.SilenceUsage = true
+	rootCmd.SilenceErrors = true
+}
+
+// logFile is the --log-file, closed when the command finishes
+var logFile *os.File
+
+// initializeLogging sets up the logger from the --log-format, --log-file and --verbosity flags
+func initializeLogging() {
+	format, _ := rootCmd.PersistentFlags().GetString("log-format")
+	path, _ := rootCmd.PersistentFlags().GetString("log-file")
+	verbosity, _ := rootCmd.PersistentFlags().GetString("verbosity")
+
+	file, err := common.ConfigureLogging(format, path, common.ParseVerbosityLevel(verbosity))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logFile = file
+}
+
+// initializeRedaction sets up the redaction of secrets in log output from the --no-redact
+// and --redact-pattern flags and the redact_patterns config field
+func initializeRedaction() {
+	if noRedact, _ := rootCmd.PersistentFlags().GetBool("no-redact"); noRedact {
+		common.SetRedaction(false)
+		return
+	}
+
+	patterns, _ := rootCmd.PersistentFlags().GetStringArray("redact-pattern")
+	if cfg, err := config.LoadFromFile(""); err == nil {
+		patterns = append(cfg.RedactPatterns, patterns...)
+	}
+	if err := common.AddRedactPatterns(patterns); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// initializeRender applies the --wide and --no-color flags to table output
+func initializeRender() {
+	wide, _ := rootCmd.PersistentFlags().GetBool("wide")
+	render.SetWide(wide)
+	if noColor, _ := rootCmd.PersistentFlags().GetBool("no-color"); noColor {
+		render.SetColor(false)
+	}
+}
+
+// initializeAPIEndpoint sets the base URL for API clients from the --api-endpoint flag,
+// the CLOUDFLARE_API_ENDPOINT environment variable, or the config file, in that order
+func initializeAPIEndpoint() {
+	endpoint, _ := rootCmd.PersistentFlags().GetString("api-endpoint")
+	if endpoint == "" {
+		cfg, err := config.LoadFromFile("")
+		if err != nil {
+			// Don't let a broken config file be ignored silently
+			fmt.Fprintf(os.Stderr, "Warning: %v; using defaults\n", err)
+			cfg = config.New()
+		}
+		endpoint = cfg.GetAPIEndpoint()
+	}
+
+	if err := api.SetDefaultBaseURL(endpoint); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// initializeTLS configures the CA bundle, client certificate and proxy of API clients from
+// the --ca-cert, --client-cert, --client-key and --proxy flags or their environment variables
+func initializeTLS() {
+	flagOrEnv := func(flag, env string) string {
+		if value, _ := rootCmd.PersistentFlags().GetString(flag); value != "" {
+			return value
+		}
+		return os.Getenv(env)
+	}
+
+	caCert := flagOrEnv("ca-cert", config.EnvCACert)
+	clientCert := flagOrEnv("client-cert", config.EnvClientCert)
+	clientKey := flagOrEnv("client-key", config.EnvClientKey)
+	if err := api.SetTLSFiles(caCert, clientCert, clientKey); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	proxy, _ := rootCmd.PersistentFlags().GetString("proxy")
+	if err := api.SetProxyURL(proxy); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// initializePolicy builds the run's retry, timeout, rate and concurrency policy from the
+// --retries, --backoff, --api-timeout, --rate-limit and --max-concurrency flags, their
+// environment variables, or the config file, in that order, and installs it before any
+// client is created
+func initializePolicy() {
+	cfg, err := config.LoadFromFile("")
+	if err != nil {
+		cfg = config.New()
+	}
+	flags := rootCmd.PersistentFlags()
+
+	policy := common.DefaultPolicy()
+	if retries := cfg.GetRetries(); retries > 0 {
+		policy.Retries = retries
+	}
+	if backoff := cfg.GetBackoffMS(); backoff > 0 {
+		policy.Backoff = time.Duration(backoff) * time.Millisecond
+	}
+	if timeout := cfg.GetTimeoutSeconds(); timeout > 0 {
+		policy.Timeout = time.Duration(timeout) * time.Second
+	}
+	if rate := cfg.GetRateLimit(); rate > 0 {
+		policy.RateLimit = rate
+	}
+	if batchSize := cfg.GetBatchSize(); batchSize > 0 {
+		policy.BatchSize = batchSize
+	}
+	policy.MaxConcurrency = cfg.GetMaxConcurrency()
+
+	if flags.Changed("retries") {
+		policy.Retries, _ = flags.GetInt("retries")
+	}
+	if flags.Changed("backoff") {
+		policy.Backoff, _ = flags.GetDuration("backoff")
+	}
+	if flags.Changed("api-timeout") {
+		policy.Timeout, _ = flags.GetDuration("api-timeout")
+	}
+	if flags.Changed("rate-limit") {
+		policy.RateLimit, _ = flags.GetInt("rate-limit")
+	}
+	if maxConcurrency, _ := flags.GetInt("max-concurrency"); maxConcurrency > 0 {
+		policy.MaxConcurrency = maxConcurrency
+	}
+
+	if policy.Retries < 0 || policy.Backoff <= 0 || policy.Timeout <= 0 || policy.RateLimit <= 0 {
+		fmt.Fprintln(os.Stderr, "--retries cannot be negative, and --backoff, --api-timeout and --rate-limit must be positive")
+		os.Exit(1)
+	}
+	common.SetPolicy(policy)
+	common.LogDebug("Policy: %d retries from %s, %s timeout, %d requests/s, %d workers, batches of %d",
+		policy.Retries, policy.Backoff, policy.Timeout, policy.RateLimit, policy.MaxConcurrency, policy.BatchSize)
+}
+
+// initializeMetadataWorkers bounds the metadata requests in flight across the process from
+// the --metadata-workers flag, the CLOUDFLARE_METADATA_WORKERS environment variable or the
+// metadata_workers config field
+func initializeMetadataWorkers() {
+	workers, _ := rootCmd.PersistentFlags().GetInt("metadata-workers")
+	if workers <= 0 {
+		cfg, err := config.LoadFromFile("")
+		if err != nil {
+			cfg = config.New()
+		}
+		workers = cfg.GetMetadataWorkers()
+	}
+
+	kv.SetMetadataWorkers(workers)
+}
+
+// initializePurgeRate paces purge calls per zone from the --purge-rate flag, the
+// CLOUDFLARE_PURGE_RATE environment variable or the purge_rate config field
+func initializePurgeRate() {
+	rate, _ := rootCmd.PersistentFlags().GetInt("purge-rate")
+	if rate <= 0 {
+		cfg, err := config.LoadFromFile("")
+		if err != nil {
+			cfg = config.New()
+		}
+		rate = cfg.GetPurgeRate()
+	}
+
+	cache.SetPurgeRate(rate)
+}
+
+// reportPurgePacing prints how long the purge rate delayed the purge calls of each zone
+func reportPurgePacing() {
+	for _, pacing := range cache.PurgePacingStats() {
+		fmt.Printf("Purge pacing: %d calls to zone %s waited %s in total (%d calls per minute)\n",
+			pacing.Delayed, pacing.ZoneID, pacing.Waited.Round(time.Second), cache.PurgeRate())
+	}
+}
+
+// reportMetadataFetches logs how many metadata lookups the run made and how many were
+// coalesced, so the effect of --metadata-workers and --max-concurrency can be measured
+func reportMetadataFetches() {
+	stats := kv.CurrentMetadataFetchStats()
+	if stats.Requests == 0 {
+		return
+	}
+	common.LogVerbose("Metadata fetches: %s", stats)
+}
+
+// initializeNamespaceCache configures caching of namespace title resolutions from the
+// --no-cache and --namespace-cache flags or the CACHE_KV_NAMESPACE_CACHE environment variable
+func initializeNamespaceCache() {
+	noCache, _ := rootCmd.PersistentFlags().GetBool("no-cache")
+	diskTTL, _ := rootCmd.PersistentFlags().GetDuration("namespace-cache")
+	if !rootCmd.PersistentFlags().Changed("namespace-cache") {
+		if value := os.Getenv(config.EnvNamespaceCache); value != "" {
+			ttl, err := common.ParseTTL(value)
+			if err != nil {
+				fmt.Printf("Invalid %s '%s': %v\n", config.EnvNamespaceCache, value, err)
+				os.Exit(1)
+			}
+			diskTTL = ttl
+		}
+	}
+	if diskTTL < 0 {
+		fmt.Println("--namespace-cache must not be negative")
+		os.Exit(1)
+	}
+	kv.SetNamespaceCache(noCache, diskTTL)
+}
+
+// initializeMock turns on record/replay of API responses from the --mock and --mock-offline
+// flags or the CACHE_KV_MOCK and CACHE_KV_MOCK_OFFLINE environment variables
+func initializeMock() {
+	dir, _ := rootCmd.PersistentFlags().GetString("mock")
+	if dir == "" {
+		dir = os.Getenv(config.EnvMock)
+	}
+	if dir == "" {
+		return
+	}
+
+	offline, _ := rootCmd.PersistentFlags().GetBool("mock-offline")
+	if !offline {
+		offline, _ = strconv.ParseBool(os.Getenv(config.EnvMockOffline))
+	}
+
+	if err := api.SetMockDir(dir, offline); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if common.IsQuietMode() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Mock mode: replaying API responses from %s\n", dir)
+}
+
 // setupCommandValidation recursively adds help and flag validation to all commands
 func setupCommandValidation(cmd *cobra.Command) {
 	// Add special handling for help flag (-h/--help)
@@ -90,6 +399,18 @@ func setupCommandValidation(cmd *cobra.Command) {
 			}
 		}
 
+		// With --accounts-file, run the command once per account instead
+		if accountsFile, _ := cmd.Flags().GetString("accounts-file"); accountsFile != "" {
+			os.Exit(runAcrossAccounts(cmd, accountsFile))
+		}
+
+		// Quiet mode and per-account runs never wait for confirmation
+		if common.AssumeYes() {
+			if force := cmd.Flags().Lookup("force"); force != nil && force.Value.Type() == "bool" {
+				_ = force.Value.Set("true")
+			}
+		}
+
 		// Continue with original pre-run if it exists
 		if original != nil {
 			return original(cmd, args)
@@ -121,13 +442,51 @@ func main() {
 	// Apply validation to all commands
 	setupCommandValidation(rootCmd)
 
-	// Execute the root command
-	if err := rootCmd.Execute(); err != nil {
+	// Wind down instead of dying mid-batch on SIGINT or SIGTERM
+	common.CatchInterrupts()
+
+	// Execute the root command, then record any purges it made, even if it failed partway
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	savePurgeHistory()
+	reportPurgePacing()
+	reportMetadataFetches()
+	logCommandResult(cmd, time.Since(start), err)
+	if common.Interrupted() {
+		reportInterrupted(err)
+	}
+	sendNotifications(cmd, start, err)
+	finishTranscript(err)
+	if logFile != nil {
+		logFile.Close()
+	}
+	if common.Interrupted() {
+		os.Exit(common.ExitInterrupted)
+	}
+	if err != nil {
 		// Skip error output for --help requests
 		if err.Error() != "help requested" {
-			fmt.Println(err)
+			if common.IsQuietMode() {
+				// Stdout is reserved for results in quiet mode
+				fmt.Fprintln(os.Stderr, err)
+			} else {
+				fmt.Println(err)
+			}
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
 }
+
+// logCommandResult logs the outcome of the command, so log files and JSON logs record
+// how each run ended
+func logCommandResult(cmd *cobra.Command, duration time.Duration, err error) {
+	if cmd == nil {
+		return
+	}
+	if err != nil {
+		common.Logger().Info("command failed", "command", cmd.CommandPath(), "duration", duration.Round(time.Millisecond), "error", err.Error())
+		return
+	}
+	common.Logger().Info("command finished", "command", cmd.CommandPath(), "duration", duration.Round(time.Millisecond))
+}
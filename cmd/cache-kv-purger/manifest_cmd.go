@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+	"cache-kv-purger/internal/zones"
+
+	"github.com/spf13/cobra"
+)
+
+// manifestRunner runs the stages of a manifest against the resolved zones and namespaces
+type manifestRunner struct {
+	cmd         *cobra.Command
+	client      *api.Client
+	kvService   kv.KVService
+	accountID   string
+	zoneIDs     map[string]string // Zone name or ID from the manifest -> zone ID
+	namespaces  map[string]string // Namespace title or ID from the manifest -> namespace ID
+	concurrency int
+	batchSize   int
+	dryRun      bool
+	verbose     bool
+}
+
+// createSyncManifestCmd creates a command to run the ordered stages of a purge manifest
+func createSyncManifestCmd() *cobra.Command {
+	var accountID string
+	var dryRun, force bool
+	var concurrency, batchSize int
+
+	cmd := &cobra.Command{
+		Use:   "manifest <file>",
+		Short: "Run the ordered KV and cache stages of a purge manifest",
+		Long: `Run a release purge described in one JSON manifest. Stages run one at a time in
+the order of the file, for example deleting KV keys first, then purging the cache tags
+served from them, then warming the URLs again.
+
+Stage types are kv-delete, purge-tags, purge-files, purge-prefixes, purge-hosts,
+purge-everything and warm. Cache stages purge "items" (tags, URLs, prefixes or hosts)
+from their "zones", or from the manifest zones; kv-delete stages delete "keys", or the
+keys matching "prefix", "tag_field"/"tag_value" or "search", from their "namespace", or
+from the manifest namespace. Zones and the namespace fall back to the configured defaults.
+
+When a stage fails, its "on_failure" policy decides what happens next: "abort" (the
+default) skips every later stage, "continue" runs them. A stage listing earlier stages in
+"depends_on" runs only if all of them succeeded, and is skipped otherwise.
+
+Every zone and namespace is resolved and checked against the protection lists before the
+first stage runs, and the stages are confirmed once unless --force or --yes is given.
+
+  {
+    "version": 1,
+    "zones": ["example.com"],
+    "namespace": "content",
+    "stages": [
+      {"name": "kv", "type": "kv-delete", "tag_field": "cache-tag", "tag_value": "products"},
+      {"name": "tags", "type": "purge-tags", "items": ["products"], "depends_on": ["kv"]},
+      {"name": "warm", "type": "warm", "items": ["https://example.com/products"], "on_failure": "continue"}
+    ]
+  }`,
+		Example: `  # Preview the stages of a release purge
+  cache-kv-purger sync manifest release.json --dry-run
+
+  # Run them without the confirmation prompt
+  cache-kv-purger sync manifest release.json --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: cmdutil.WithConfigClientAndVerbosity(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client, verbosity *common.Verbosity) error {
+			manifest, err := common.LoadManifest(args[0])
+			if err != nil {
+				return err
+			}
+
+			// Fall back to the configured zone and namespace
+			for i := range manifest.Stages {
+				stage := &manifest.Stages[i]
+				switch stage.Type {
+				case common.StageKVDelete:
+					if stage.Namespace == "" {
+						stage.Namespace = cfg.GetNamespaceID()
+					}
+					if stage.Namespace == "" {
+						return fmt.Errorf("stage '%s': namespace is required, set it in the manifest or configure a default namespace", stage.Name)
+					}
+				case common.StageWarm:
+				default:
+					if len(stage.Zones) == 0 && cfg.GetZoneID() != "" {
+						stage.Zones = []string{cfg.GetZoneID()}
+					}
+					if len(stage.Zones) == 0 {
+						return fmt.Errorf("stage '%s': zones are required, set them in the manifest or configure a default zone", stage.Name)
+					}
+				}
+			}
+
+			runner := &manifestRunner{
+				cmd:         cmd,
+				client:      client,
+				kvService:   kv.NewKVService(client),
+				accountID:   cmdutil.ResolveAccountID(cmd, cfg, accountID),
+				zoneIDs:     make(map[string]string),
+				namespaces:  make(map[string]string),
+				concurrency: concurrency,
+				batchSize:   batchSize,
+				dryRun:      dryRun,
+				verbose:     verbosity.IsVerbose(),
+			}
+
+			// Resolve and check every target up front, so nothing runs if one is protected
+			if err := runner.resolveTargets(cfg, manifest.Stages); err != nil {
+				return err
+			}
+
+			printManifestStages(args[0], manifest.Stages)
+			if !dryRun && !force {
+				if !common.ConfirmAction(fmt.Sprintf("\nRun these %d stages?", len(manifest.Stages))) {
+					fmt.Println("Operation cancelled.")
+					return nil
+				}
+			}
+
+			results := common.RunManifestStages(manifest.Stages, func(stage common.ManifestStage) error {
+				fmt.Printf("\n%s %s (%s)\n", render.Bold("Stage:"), stage.Name, stage.Type)
+				err := runner.runStage(stage)
+				if err != nil {
+					fmt.Printf("%s %v\n", render.Red("Failed:"), err)
+				}
+				return err
+			})
+
+			return printManifestResults(results, dryRun)
+		}),
+	}
+
+	cmd.Flags().StringVar(&accountID, "account-id", "", "Cloudflare Account ID used to resolve zone and namespace names")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Resolve the targets and show what each stage would do without changing anything")
+	cmd.Flags().BoolVar(&force, "force", false, "Skip the confirmation prompt")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "Concurrent requests of KV deletes and cache purge batches (0 for the defaults)")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "Items per KV delete request (0 for the default)")
+	cmd.Flags().Bool("verbose", false, "Enable verbose output")
+
+	return cmd
+}
+
+// resolveTargets resolves the zones and namespaces of the stages to IDs and refuses
+// protected ones
+func (r *manifestRunner) resolveTargets(cfg *config.Config, stages []common.ManifestStage) error {
+	var zoneIDs []string
+	for _, stage := range stages {
+		for _, zone := range stage.Zones {
+			if _, ok := r.zoneIDs[zone]; ok {
+				continue
+			}
+			zoneID, err := zones.ResolveZoneIdentifier(r.client, r.accountID, zone)
+			if err != nil {
+				return fmt.Errorf("stage '%s': failed to resolve zone: %w", stage.Name, err)
+			}
+			r.zoneIDs[zone] = zoneID
+			zoneIDs = append(zoneIDs, zoneID)
+		}
+
+		if stage.Namespace == "" {
+			continue
+		}
+		if _, ok := r.namespaces[stage.Namespace]; ok {
+			continue
+		}
+		namespaceID, err := r.kvService.ResolveNamespaceID(r.cmd.Context(), r.accountID, stage.Namespace)
+		if err != nil {
+			return fmt.Errorf("stage '%s': failed to resolve namespace: %w", stage.Name, err)
+		}
+		if err := cmdutil.CheckNamespaceProtection(r.cmd.Context(), r.cmd, cfg, r.kvService, r.accountID, namespaceID); err != nil {
+			return err
+		}
+		r.namespaces[stage.Namespace] = namespaceID
+	}
+
+	return checkZonesProtection(r.cmd, r.client, common.RemoveDuplicates(zoneIDs))
+}
+
+// runStage runs one stage, or with --dry-run shows what it would do
+func (r *manifestRunner) runStage(stage common.ManifestStage) error {
+	switch stage.Type {
+	case common.StageKVDelete:
+		return r.deleteKeys(stage)
+
+	case common.StageWarm:
+		if r.dryRun {
+			fmt.Printf("DRY RUN: Would warm %d URLs\n", len(stage.Items))
+			return nil
+		}
+		result := cache.WarmURLs(stage.Items, cache.WarmOptions{Concurrency: r.concurrency}, nil)
+		printWarmSummary(result, r.verbose)
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("%d of %d requests failed", len(result.Errors), result.Requests)
+		}
+		return nil
+	}
+
+	options := cache.NewPurgeBatchOptions(cache.WithConcurrency(r.concurrency))
+	var purge func(zoneID string) []error
+	var what string
+	switch stage.Type {
+	case common.StagePurgeTags:
+		what = fmt.Sprintf("%d cache tags", len(stage.Items))
+		purge = func(zoneID string) []error {
+			_, errs := cache.PurgeTagsInBatches(r.client, zoneID, stage.Items, options)
+			return errs
+		}
+	case common.StagePurgeFiles:
+		files := make([]cache.FileWithHeaders, len(stage.Items))
+		for i, item := range stage.Items {
+			files[i].URL = item
+		}
+		what = fmt.Sprintf("%d URLs", len(stage.Items))
+		purge = func(zoneID string) []error {
+			_, errs := cache.PurgeFilesWithHeadersInBatches(r.client, zoneID, files, options)
+			return errs
+		}
+	case common.StagePurgePrefixes:
+		what = fmt.Sprintf("%d prefixes", len(stage.Items))
+		purge = func(zoneID string) []error {
+			_, errs := cache.PurgePrefixesInBatches(r.client, zoneID, stage.Items, options)
+			return errs
+		}
+	case common.StagePurgeHosts:
+		what = fmt.Sprintf("%d hosts", len(stage.Items))
+		purge = func(zoneID string) []error {
+			_, errs := cache.PurgeHostsInBatches(r.client, zoneID, stage.Items, options)
+			return errs
+		}
+	case common.StagePurgeEverything:
+		what = "everything"
+		purge = func(zoneID string) []error {
+			if _, err := cache.PurgeEverything(r.client, zoneID); err != nil {
+				return []error{err}
+			}
+			return nil
+		}
+	}
+
+	if r.dryRun {
+		fmt.Printf("DRY RUN: Would purge %s in %d zones (%s)\n", what, len(stage.Zones), strings.Join(stage.Zones, ", "))
+		return nil
+	}
+
+	// Zones are purged one at a time, so the stage order holds within a stage as well
+	fmt.Printf("Purging %s in %d zones...\n", what, len(stage.Zones))
+	table := render.NewTable("Zone", "Status")
+	failed := 0
+	var firstErr error
+	for _, zone := range stage.Zones {
+		errs := purge(r.zoneIDs[zone])
+		if len(errs) == 0 {
+			table.AddRow(zone, render.Green("Success"))
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = errs[0]
+		}
+		table.AddRow(zone, render.Red(fmt.Sprintf("Failed: %d errors, first: %v", len(errs), errs[0])))
+	}
+	table.Print()
+
+	if failed > 0 {
+		return fmt.Errorf("cache purge failed for %d of %d zones: %w", failed, len(stage.Zones), firstErr)
+	}
+	return nil
+}
+
+// deleteKeys deletes the keys of a kv-delete stage, searching for them unless the stage
+// lists them
+func (r *manifestRunner) deleteKeys(stage common.ManifestStage) error {
+	namespaceID := r.namespaces[stage.Namespace]
+
+	keyNames := stage.Keys
+	if len(keyNames) == 0 {
+		matches, err := r.kvService.Search(r.cmd.Context(), r.accountID, namespaceID, kv.SearchOptions{
+			Prefix:      stage.Prefix,
+			TagField:    stage.TagField,
+			TagValue:    stage.TagValue,
+			SearchValue: stage.Search,
+			BatchSize:   r.batchSize,
+			Concurrency: r.concurrency,
+		})
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		keyNames = make([]string, len(matches))
+		for i, match := range matches {
+			keyNames[i] = match.Key
+		}
+		fmt.Printf("Found %d matching KV keys\n", len(keyNames))
+	}
+
+	if len(keyNames) == 0 {
+		fmt.Println("No KV keys to delete")
+		return nil
+	}
+	if r.dryRun {
+		fmt.Printf("DRY RUN: Would delete %d KV keys from namespace %s\n", len(keyNames), stage.Namespace)
+		if r.verbose {
+			common.StringsDisplaySample(keyNames, true)
+		}
+		return nil
+	}
+
+	count, err := r.kvService.BulkDelete(r.cmd.Context(), r.accountID, namespaceID, keyNames, kv.BulkDeleteOptions{
+		BatchSize:   r.batchSize,
+		Concurrency: r.concurrency,
+		Force:       true, // Confirmed once for the whole manifest
+	})
+	fmt.Printf("Deleted %d/%d KV keys\n", count, len(keyNames))
+	if err != nil {
+		return fmt.Errorf("KV deletion failed: %w", err)
+	}
+	if count < len(keyNames) {
+		return fmt.Errorf("deleted only %d of %d KV keys", count, len(keyNames))
+	}
+	return nil
+}
+
+// printManifestStages prints the stages of a manifest in the order they run
+func printManifestStages(path string, stages []common.ManifestStage) {
+	fmt.Printf("Manifest %s: %d stages\n", path, len(stages))
+	table := render.NewTable("#", "Stage", "Type", "Target", "Depends On", "On Failure")
+	for i, stage := range stages {
+		target := strings.Join(stage.Zones, ", ")
+		switch stage.Type {
+		case common.StageKVDelete:
+			target = stage.Namespace
+		case common.StageWarm:
+			target = fmt.Sprintf("%d URLs", len(stage.Items))
+		}
+		table.AddRow(fmt.Sprintf("%d", i+1), stage.Name, stage.Type, target, strings.Join(stage.DependsOn, ", "), stage.OnFailure)
+	}
+	table.Print()
+}
+
+// printManifestResults prints the outcome of every stage and returns an error if any failed
+func printManifestResults(results []common.StageResult, dryRun bool) error {
+	fmt.Println()
+	table := render.NewTable("Stage", "Type", "Status", "Duration", "Detail")
+	failed := 0
+	for _, result := range results {
+		status := result.Status
+		if dryRun && status == common.StageSucceeded {
+			status = "dry run"
+		}
+		if result.Status == common.StageFailed {
+			failed++
+		}
+		detail := ""
+		if result.Err != nil {
+			detail = result.Err.Error()
+		}
+		duration := ""
+		if result.Status != common.StageSkipped {
+			duration = result.Duration.Round(time.Millisecond).String()
+		}
+		table.AddRow(result.Stage.Name, result.Stage.Type, render.Status(status), duration, detail)
+	}
+	table.Print()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d manifest stages failed", failed, len(results))
+	}
+	return nil
+}
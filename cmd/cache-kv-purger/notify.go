@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// notifySlackMaxIDs is how many purge IDs a Slack message lists before summarizing the rest
+const notifySlackMaxIDs = 10
+
+// notifyURL and notifySlack are the webhooks receiving the summary of the run, from
+// --notify-url and --notify-slack or their environment variables
+var notifyURL, notifySlack string
+
+// runNotification is the JSON summary of a purge or delete run POSTed to --notify-url
+type runNotification struct {
+	Operation  string                  `json:"operation"` // e.g. cache-kv-purger kv delete
+	Command    string                  `json:"command"`   // Full command line, without the webhooks
+	Status     string                  `json:"status"`    // succeeded, failed or interrupted
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt time.Time               `json:"finished_at"`
+	Duration   float64                 `json:"duration_seconds"`
+	Completed  []common.CompletedCount `json:"completed"` // e.g. keys deleted
+	Purges     []cache.PurgeRecord     `json:"purges"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// initializeNotify reads the notification webhooks and checks them before the run starts,
+// so a mistyped URL fails fast instead of after a long purge
+func initializeNotify() {
+	notifyURL, _ = rootCmd.PersistentFlags().GetString("notify-url")
+	if notifyURL == "" {
+		notifyURL = os.Getenv(config.EnvNotifyURL)
+	}
+	notifySlack, _ = rootCmd.PersistentFlags().GetString("notify-slack")
+	if notifySlack == "" {
+		notifySlack = os.Getenv(config.EnvNotifySlack)
+	}
+
+	for flag, webhook := range map[string]string{"--notify-url": notifyURL, "--notify-slack": notifySlack} {
+		if webhook == "" {
+			continue
+		}
+		if err := common.ValidateNotifyURL(webhook); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", flag, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// sendNotifications posts the summary of a purge or delete run to the notification
+// webhooks. A webhook that fails is reported on stderr without failing the run.
+func sendNotifications(cmd *cobra.Command, started time.Time, err error) {
+	if notifyURL == "" && notifySlack == "" {
+		return
+	}
+
+	finished := time.Now().UTC()
+	notification := runNotification{
+		Command:    common.Redact(strings.Join(withoutWebhookArgs(os.Args), " ")),
+		Status:     "succeeded",
+		StartedAt:  started.UTC(),
+		FinishedAt: finished,
+		Duration:   finished.Sub(started).Round(time.Millisecond).Seconds(),
+		Completed:  common.CompletedWork(),
+		Purges:     cache.SessionPurges(),
+	}
+	if !shouldNotify(cmd, err, notification) {
+		return
+	}
+	notification.Operation = cmd.CommandPath()
+	switch {
+	case common.Interrupted():
+		notification.Status = "interrupted"
+	case err != nil:
+		notification.Status = "failed"
+	}
+	if err != nil {
+		notification.Error = common.Redact(err.Error())
+	}
+
+	if notifyURL != "" {
+		if err := common.PostNotification(context.Background(), notifyURL, notification); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if notifySlack != "" {
+		message := map[string]string{"text": slackNotificationText(notification)}
+		if err := common.PostNotification(context.Background(), notifySlack, message); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// shouldNotify returns true for runs of purge and delete commands, and for any run that
+// purged the cache or deleted keys. Dry runs and help requests change nothing and are
+// not notified.
+func shouldNotify(cmd *cobra.Command, err error, notification runNotification) bool {
+	if cmd == nil || (err != nil && err.Error() == "help requested") {
+		return false
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return false
+	}
+	if fromExport, _ := cmd.Flags().GetString("from-export"); fromExport != "" {
+		return false
+	}
+
+	if len(notification.Purges) > 0 {
+		return true
+	}
+	for _, count := range notification.Completed {
+		if count.What == "keys deleted" {
+			return true
+		}
+	}
+	for _, name := range strings.Fields(cmd.CommandPath()) {
+		if strings.HasPrefix(name, "purge") || strings.HasPrefix(name, "delete") {
+			return true
+		}
+	}
+	return false
+}
+
+// slackNotificationText formats the summary of a run as a Slack message
+func slackNotificationText(notification runNotification) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*%s* %s in %s", notification.Operation, notification.Status,
+		time.Duration(notification.Duration*float64(time.Second)).Round(time.Millisecond))
+
+	for _, count := range notification.Completed {
+		fmt.Fprintf(&text, "\n• %s: %d", count.What, count.Count)
+	}
+
+	if len(notification.Purges) > 0 {
+		zones := make(map[string]bool)
+		ids := make([]string, 0, min(len(notification.Purges), notifySlackMaxIDs))
+		for _, record := range notification.Purges {
+			zones[record.ZoneID] = true
+			if len(ids) < notifySlackMaxIDs {
+				ids = append(ids, "`"+record.ID+"`")
+			}
+		}
+		fmt.Fprintf(&text, "\n• %d purge requests in %d zones: %s", len(notification.Purges), len(zones), strings.Join(ids, ", "))
+		if more := len(notification.Purges) - len(ids); more > 0 {
+			fmt.Fprintf(&text, " and %d more", more)
+		}
+	}
+
+	if notification.Error != "" {
+		fmt.Fprintf(&text, "\n• Error: %s", notification.Error)
+	}
+	fmt.Fprintf(&text, "\n`%s`", notification.Command)
+	return text.String()
+}
+
+// withoutWebhookArgs drops --notify-url and --notify-slack from command line arguments,
+// since webhook URLs usually embed a secret
+func withoutWebhookArgs(args []string) []string {
+	kept := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--notify-url" || arg == "--notify-slack":
+			i++ // Skip the value as well
+		case strings.HasPrefix(arg, "--notify-url=") || strings.HasPrefix(arg, "--notify-slack="):
+		default:
+			kept = append(kept, arg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/zones"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fallbackFilesBatchSize is the number of URLs per purge request on non-Enterprise plans
+const fallbackFilesBatchSize = 30
+
+// checkPurgePlan pre-flights a purge by tag, prefix or host against the zone's plan.
+// It returns an actionable error when the plan does not include the purge type, or
+// true when the items were purged by URL through the --fallback-files mapping instead
+// (or would be, in a dry run). Zones whose details cannot be read are not blocked.
+func checkPurgePlan(client *api.Client, zoneID, purgeType string, items []string, dryRun, verbose bool) (bool, error) {
+	if purgeFlagsVars.skipPlanCheck {
+		return false, nil
+	}
+
+	details, err := zones.GetZoneDetails(client, zoneID)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Warning: could not read the zone plan, skipping the plan check: %v\n", err)
+		}
+		return false, nil
+	}
+
+	planErr := zones.CheckPurgeSupported(details.Result, purgeType)
+	if planErr == nil {
+		return false, nil
+	}
+	if purgeFlagsVars.fallbackFiles == "" {
+		return false, planErr
+	}
+
+	urls, err := fallbackURLs(purgeFlagsVars.fallbackFiles, items)
+	if err != nil {
+		return false, err
+	}
+
+	zoneName := details.Result.Name
+	fmt.Printf("Zone %s cannot purge by %s on its plan; purging %d URLs mapped from %d %s instead\n",
+		zoneName, purgeType, len(urls), len(items), purgeType)
+
+	if dryRun {
+		fmt.Printf("DRY RUN: Would purge %d URLs\n", len(urls))
+		if verbose {
+			for _, url := range urls {
+				fmt.Printf("  %s\n", url)
+			}
+		}
+		return true, nil
+	}
+
+	if !common.ConfirmBatchOperation(len(urls), "URLs", "purge", purgeFlagsVars.force) {
+		return true, nil
+	}
+
+	purged := 0
+	for i, batch := range common.SplitIntoBatches(urls, fallbackFilesBatchSize) {
+		if _, err := cache.PurgeFiles(client, zoneID, batch); err != nil {
+			return true, fmt.Errorf("failed to purge URL batch %d after purging %d URLs: %w", i+1, purged, err)
+		}
+		purged += len(batch)
+		if verbose {
+			fmt.Printf("Progress: %d/%d URLs purged\n", purged, len(urls))
+		}
+	}
+
+	fmt.Printf("Successfully purged %d URLs in place of %d %s\n", purged, len(items), purgeType)
+	return true, nil
+}
+
+// fallbackURLs reads a JSON object mapping each tag, prefix or host to the URLs it
+// covers and returns the de-duplicated URLs for the items. Every item must be mapped.
+func fallbackURLs(path string, items []string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fallback files mapping: %w", err)
+	}
+
+	var mapping map[string][]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse fallback files mapping (must be a JSON object of arrays of URLs): %w", err)
+	}
+
+	var urls []string
+	var missing []string
+	for _, item := range items {
+		mapped, ok := mapping[item]
+		if !ok {
+			missing = append(missing, item)
+			continue
+		}
+		urls = append(urls, mapped...)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("fallback files mapping has no URLs for: %s", strings.Join(missing, ", "))
+	}
+
+	urls = common.RemoveDuplicates(urls)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("fallback files mapping has no URLs for the requested items")
+	}
+	return urls, nil
+}
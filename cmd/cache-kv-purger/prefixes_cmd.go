@@ -6,7 +6,6 @@ import (
 	"cache-kv-purger/internal/cmdutil"
 	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
-	"cache-kv-purger/internal/zones"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
@@ -24,7 +23,14 @@ func createPurgePrefixesCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "prefixes",
 		Short: "Purge cached content by URL prefix",
-		Long:  `Purge cached content from Cloudflare's edge servers based on URL prefixes.`,
+		Long: `Purge cached content from Cloudflare's edge servers based on URL prefixes.
+
+Broad prefixes, covering a whole host or a top-level directory such as
+example.com/ or example.com/blog/, can empty a large part of the cache at once.
+Before purging them, their cached traffic over the last 24 hours is read from the
+analytics API and shown with a confirmation prompt as an estimate of the load the
+purge moves to the origin. --dry-run shows the estimate without purging; --force
+skips both the estimate and the prompt.`,
 		Example: `  # Purge a single prefix
   cache-kv-purger cache purge prefixes --zone example.com --prefix https://example.com/blog/
 
@@ -49,11 +55,8 @@ func createPurgePrefixesCmd() *cobra.Command {
 			}
 
 			// Get account ID for resolving zone names
-			accountID := ""
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 			// Collect all prefixes from various input methods
 			allPrefixes := make([]string, 0)
@@ -115,21 +118,31 @@ func createPurgePrefixesCmd() *cobra.Command {
 				zoneID, _ = cmd.Flags().GetString("zone")
 			}
 
-			if zoneID == "" {
-				// Try to get from config or environment variable
-				if cfg != nil {
-					zoneID = cfg.GetZoneID()
-				}
+			// Resolve zone (could be name or ID), falling back to the default zone
+			resolvedZoneID, err := cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+			if err != nil {
+				return err
 			}
 
-			if zoneID == "" {
-				return fmt.Errorf("zone ID is required, specify it with --zone flag, CLOUDFLARE_ZONE_ID environment variable, or set a default zone in config")
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, []string{resolvedZoneID}); err != nil {
+				return err
 			}
 
-			// Resolve zone (could be name or ID)
-			resolvedZoneID, err := zones.ResolveZoneIdentifier(client, accountID, zoneID)
-			if err != nil {
-				return fmt.Errorf("failed to resolve zone: %w", err)
+			// Make sure the zone's plan can purge by prefixes, or fall back to URLs
+			if handled, err := checkPurgePlan(client, resolvedZoneID, "prefixes", allPrefixes, dryRun, verbose); handled || err != nil {
+				return err
+			}
+
+			// Ask before purging broad prefixes, showing what they would send to the origin
+			if dryRun || !purgeFlagsVars.force {
+				if lines := prefixesBlastRadius(client, resolvedZoneID, allPrefixes); len(lines) > 0 {
+					if dryRun {
+						printBlastRadius(lines)
+					} else if err := confirmBlastRadius(lines, fmt.Sprintf("Purge %d prefixes from zone %s?", len(allPrefixes), resolvedZoneID)); err != nil {
+						return err
+					}
+				}
 			}
 
 			// Default batch size if not specified or invalid
@@ -189,12 +202,8 @@ func createPurgePrefixesCmd() *cobra.Command {
 				concurrency = cfg.GetCacheConcurrency()
 			}
 
-			// Cap concurrency for Enterprise tier
-			if concurrency <= 0 {
-				concurrency = 50 // Enterprise tier default
-			} else if concurrency > 50 {
-				concurrency = 50 // Enterprise tier allows 50 requests per second
-			}
+			// Default to the Enterprise tier's 50 requests per second, capped by the policy
+			concurrency = common.CurrentPolicy().Concurrency(concurrency, 50)
 
 			// Split prefixes into batches (for preview in dry run mode)
 			batches := common.SplitIntoBatches(allPrefixes, batchSize)
@@ -230,7 +239,11 @@ func createPurgePrefixesCmd() *cobra.Command {
 			}
 
 			// Process prefixes with concurrent batching
-			successful, errors := cache.PurgePrefixesInBatches(client, resolvedZoneID, allPrefixes, progressFn, concurrency)
+			successful, errors := cache.PurgePrefixesInBatches(client, resolvedZoneID, allPrefixes, cache.NewPurgeBatchOptions(
+				cache.WithBatchSize(batchSize),
+				cache.WithConcurrency(concurrency),
+				cache.WithProgress(progressFn),
+			))
 
 			// Print a newline to clear the progress line
 			if !verbose {
@@ -261,6 +274,7 @@ func createPurgePrefixesCmd() *cobra.Command {
 	cmd.Flags().StringVar(&prefixesFile, "prefixes-file", "", "Path to a text file containing URL prefixes to purge (one prefix per line)")
 	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "Maximum number of prefixes to purge in each batch (API limit: 100 items per request)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without actually purging")
+	cmd.Flags().BoolVar(&purgeFlagsVars.force, "force", false, "Skip the blast radius estimate and confirmation prompt for broad prefixes")
 
 	return cmd
 }
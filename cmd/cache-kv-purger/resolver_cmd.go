@@ -2,7 +2,7 @@ package main
 
 import (
 	"cache-kv-purger/internal/api"
-	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/zones"
 	"fmt"
@@ -11,159 +11,6 @@ import (
 )
 
 
-// handleAutoZoneDetectionForHosts handles auto-detection of zones from hostnames
-func handleAutoZoneDetectionForHosts(client *api.Client, accountID string, hosts []string, cmd *cobra.Command,
-	cacheConcurrency, multiZoneConcurrency int) error {
-	verbose, _ := cmd.Flags().GetBool("verbose")
-
-	if verbose {
-		fmt.Printf("Auto-detecting zones for %d hosts...\n", len(hosts))
-	}
-
-	// Create a map where hosts map to themselves (we don't have files here)
-	hostMap := make(map[string][]string)
-	for _, host := range hosts {
-		hostMap[host] = []string{host}
-	}
-
-	// Detect zones for hosts
-	hostZones, unknownHosts, err := zones.DetectZonesFromHosts(client, accountID, hosts)
-	if err != nil {
-		return fmt.Errorf("failed to detect zones: %w", err)
-	}
-
-	if len(unknownHosts) > 0 {
-		return fmt.Errorf("%d hosts couldn't be mapped to zones: %v", len(unknownHosts), unknownHosts)
-	}
-
-	// Group hosts by zone
-	itemsByZone := zones.GroupItemsByZone(hostZones, hostMap)
-
-	// Now handle processing with the results
-	return handleItemsForZones(client, itemsByZone, cmd, cacheConcurrency, multiZoneConcurrency, "hosts")
-}
-
-// handleItemsForZones handles processing items (files or hosts) by zone
-func handleItemsForZones(client *api.Client, itemsByZone map[string][]string, cmd *cobra.Command,
-	cacheConcurrency, multiZoneConcurrency int, itemType string) error {
-
-	verbose, _ := cmd.Flags().GetBool("verbose")
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	batchSize, _ := cmd.Flags().GetInt("batch-size")
-
-	if batchSize <= 0 {
-		batchSize = 30 // Default batch size if not specified
-	}
-
-	// Cap concurrency to reasonable limits
-	if cacheConcurrency <= 0 {
-		cacheConcurrency = 10 // Default
-	} else if cacheConcurrency > 20 {
-		cacheConcurrency = 20 // Max
-	}
-
-	// Validate multi-zone concurrency
-	if multiZoneConcurrency <= 0 {
-		multiZoneConcurrency = 3 // Default
-	} else if multiZoneConcurrency > 5 {
-		multiZoneConcurrency = 5 // Max to avoid overwhelming API
-	}
-
-	// Define the handler function for processing items in each zone
-	handler := func(zoneID string, zoneName string, items []string) (bool, error) {
-		// Process items based on type (files or hosts)
-		switch itemType {
-		case "files":
-			if verbose {
-				fmt.Printf("Purging %d files for zone %s...\n", len(items), zoneName)
-			}
-
-			// Make the API call to purge files
-			resp, err := cache.PurgeFiles(client, zoneID, items)
-			if err != nil {
-				return false, fmt.Errorf("failed to purge files: %w", err)
-			}
-
-			fmt.Printf("Successfully purged %d files from zone %s. Purge ID: %s\n", len(items), zoneName, resp.Result.ID)
-			return true, nil
-
-		case "hosts":
-			if verbose {
-				fmt.Printf("Purging %d hosts for zone %s...\n", len(items), zoneName)
-			}
-
-			// For large number of hosts, use batching with concurrency
-			if len(items) > batchSize {
-				// Create progress function
-				progressFn := func(completed, total, successful int) {
-					if verbose {
-						fmt.Printf("Progress for zone %s: processed %d/%d batches, %d hosts purged\n",
-							zoneName, completed, total, successful)
-					} else {
-						fmt.Printf("Zone %s: processing batch %d/%d: %d hosts purged so far...  \r",
-							zoneName, completed, total, successful)
-					}
-				}
-
-				// Process hosts with concurrent batching
-				successful, errors := cache.PurgeHostsInBatches(client, zoneID, items, progressFn, cacheConcurrency)
-
-				// Print a newline to clear the progress line
-				if !verbose {
-					fmt.Println()
-				}
-
-				// Report errors if any
-				if len(errors) > 0 {
-					errMsg := fmt.Sprintf("Encountered %d errors during purging for zone %s:\n", len(errors), zoneName)
-					for i, err := range errors {
-						if i < 3 { // Show at most 3 errors
-							errMsg += fmt.Sprintf("  - %s\n", err)
-						} else {
-							errMsg += fmt.Sprintf("  - ... and %d more errors\n", len(errors)-3)
-							break
-						}
-					}
-					return false, fmt.Errorf("%s", errMsg)
-				}
-
-				fmt.Printf("Successfully purged %d hosts from zone %s\n", len(successful), zoneName)
-				return true, nil
-			} else {
-				// Small number of hosts, just use single API call
-				resp, err := cache.PurgeHosts(client, zoneID, items)
-				if err != nil {
-					return false, fmt.Errorf("failed to purge hosts: %w", err)
-				}
-				fmt.Printf("Successfully purged %d hosts from zone %s. Purge ID: %s\n", len(items), zoneName, resp.Result.ID)
-				return true, nil
-			}
-		default:
-			return false, fmt.Errorf("unknown item type: %s", itemType)
-		}
-	}
-
-	// Use ProcessMultiZoneItems for concurrent processing
-	totalItems, successCount, err := zones.ProcessMultiZoneItems(
-		client, 
-		itemsByZone, 
-		handler, 
-		verbose, 
-		dryRun, 
-		multiZoneConcurrency,
-	)
-	
-	if err != nil {
-		return fmt.Errorf("failed to process zones: %w", err)
-	}
-
-	// The summary is already printed by ProcessMultiZoneItems
-	_ = totalItems
-	_ = successCount
-
-	return nil
-}
-
 // resolveZoneIdentifiers resolves zone identifiers from various sources
 func resolveZoneIdentifiers(cmd *cobra.Command, client *api.Client, accountID string) ([]string, error) {
 	// Flag to indicate if --all-zones was used
@@ -173,7 +20,7 @@ func resolveZoneIdentifiers(cmd *cobra.Command, client *api.Client, accountID st
 	if allZones {
 		// Make sure we have an account ID
 		if accountID == "" {
-			return nil, fmt.Errorf("account ID is required for --all-zones, set it with CLOUDFLARE_ACCOUNT_ID or in config")
+			return nil, fmt.Errorf("account ID is required for --all-zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config")
 		}
 
 		// Fetch all zones
@@ -249,3 +96,34 @@ func resolveZoneIdentifiers(cmd *cobra.Command, client *api.Client, accountID st
 
 	return []string{resolvedZoneID}, nil
 }
+
+// checkZonesProtection refuses to operate on zones that are protected in config,
+// unless --override-protection is passed
+func checkZonesProtection(cmd *cobra.Command, client *api.Client, zoneIDs []string) error {
+	// Protection can't be checked without the config, so refuse rather than purge unprotected
+	cfg, err := config.LoadFromFile("")
+	if err != nil {
+		return fmt.Errorf("failed to load config to check protected zones: %w", err)
+	}
+	if len(cfg.ProtectedZones) == 0 {
+		return nil
+	}
+
+	for _, zoneID := range zoneIDs {
+		// Protected zones may be listed by name, so look it up
+		zoneName := ""
+		details, err := zones.GetZoneDetails(client, zoneID)
+		if err == nil {
+			zoneName = details.Result.Name
+		} else if !common.ProtectionOverridden(cmd) {
+			return fmt.Errorf("failed to look up zone %s to check it against the protected zones: %w (pass --%s to purge anyway)",
+				zoneID, err, common.OverrideProtectionFlag)
+		}
+
+		if err := common.CheckZoneProtection(cmd, cfg, zoneID, zoneName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+)
+
+// createSelfUpdateCmd creates a command that replaces the binary with a release from GitHub
+func createSelfUpdateCmd() *cobra.Command {
+	var targetVersion string
+	var checkOnly bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update cache-kv-purger to the latest release",
+		Long: `Update the running binary to the latest GitHub release, or to the release given with --tag.
+
+The release archive for this platform (e.g. cache-kv-purger_Linux_x86_64.tar.gz or
+cache-kv-purger_Darwin_arm64.tar.gz) is downloaded, its SHA-256 hash is checked
+against the release's checksums.txt, and the binary inside it replaces the running
+one in place, keeping its permissions. Releases are not signed, so the checksum
+protects against corrupted downloads but not against a compromised release.
+
+The current version is the one set at build time. Development builds, and updates
+that would not move to a newer version, need --force. Requests go through --proxy
+and trust --ca-cert like API requests.`,
+		Example: `  # Check whether a newer release is available
+  cache-kv-purger self-update --check
+
+  # Update to the latest release
+  cache-kv-purger self-update
+
+  # Install a specific release, even if it is older
+  cache-kv-purger self-update --tag v1.4.0 --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			updater := &selfupdate.Updater{
+				HTTPClient: &http.Client{Timeout: 5 * time.Minute, Transport: api.NewTransport()},
+			}
+
+			release, err := updater.Release(cmd.Context(), targetVersion)
+			if err != nil {
+				return err
+			}
+
+			// Only move forward unless forced
+			comparison, compareErr := selfupdate.CompareVersions(version, release.TagName)
+			upToDate := compareErr == nil && comparison >= 0
+
+			if checkOnly {
+				switch {
+				case compareErr != nil:
+					fmt.Printf("Current version %s cannot be compared; latest release is %s\n", version, release.TagName)
+				case upToDate:
+					fmt.Printf("cache-kv-purger %s is up to date (release %s)\n", version, release.TagName)
+				default:
+					fmt.Printf("Update available: %s -> %s\n", version, release.TagName)
+				}
+				return nil
+			}
+
+			if !force {
+				if compareErr != nil {
+					return fmt.Errorf("current version %s is a development build; use --force to replace it with %s", version, release.TagName)
+				}
+				if upToDate {
+					fmt.Printf("cache-kv-purger %s is up to date (release %s)\n", version, release.TagName)
+					return nil
+				}
+			}
+
+			// Find this platform's archive and the checksums
+			archiveName := selfupdate.ArchiveName(runtime.GOOS, runtime.GOARCH)
+			archiveAsset, ok := release.Asset(archiveName)
+			if !ok {
+				return fmt.Errorf("release %s has no archive for %s/%s (%s)", release.TagName, runtime.GOOS, runtime.GOARCH, archiveName)
+			}
+			checksumsAsset, ok := release.Asset(selfupdate.ChecksumsAsset)
+			if !ok {
+				return fmt.Errorf("release %s has no %s to verify the download", release.TagName, selfupdate.ChecksumsAsset)
+			}
+
+			exePath, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to find the running binary: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+				exePath = resolved
+			}
+
+			fmt.Printf("Downloading %s %s...\n", archiveName, release.TagName)
+			archive, err := updater.Download(cmd.Context(), archiveAsset)
+			if err != nil {
+				return err
+			}
+			checksums, err := updater.Download(cmd.Context(), checksumsAsset)
+			if err != nil {
+				return err
+			}
+			if err := selfupdate.VerifyChecksum(checksums, archiveName, archive); err != nil {
+				return err
+			}
+
+			binaryName := selfupdate.ProjectName
+			if runtime.GOOS == "windows" {
+				binaryName += ".exe"
+			}
+			binary, err := selfupdate.ExtractBinary(archiveName, archive, binaryName)
+			if err != nil {
+				return err
+			}
+
+			if err := selfupdate.ReplaceBinary(exePath, binary); err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated %s from %s to %s\n", exePath, version, release.TagName)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&targetVersion, "tag", "", "Release to install, e.g. v1.4.0 (default: the latest release)")
+	cmd.Flags().BoolVar(&checkOnly, "check", false, "Only report whether a newer release is available")
+	cmd.Flags().BoolVar(&force, "force", false, "Install even if the release is not newer or the current binary is a development build")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(createSelfUpdateCmd())
+}
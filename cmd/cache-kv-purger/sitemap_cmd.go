@@ -0,0 +1,344 @@
+package main
+
+import (
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/zones"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sitemapZone is the URLs and prefixes of a sitemap that belong to one zone
+type sitemapZone struct {
+	zoneID     string
+	name       string
+	files      []string
+	prefixes   []string
+	purged     int // Prefixes and URLs purged
+	purgedURLs int // URLs purged, which count against the daily file budget
+	errors     []error
+}
+
+// createPurgeFromSitemapCmd creates a command to purge the pages listed in a sitemap
+func createPurgeFromSitemapCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var mode string
+	var prefixDepth int
+	var batchSize int
+	var maxURLs int
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "from-sitemap <sitemap-url>",
+		Short: "Purge the pages listed in a sitemap",
+		Long: `Download a sitemap, follow any nested sitemap indexes, and purge the pages it lists.
+
+The sitemap may be a URL or a local file, and may be gzipped. Duplicate URLs are
+removed. With --mode files every page is purged by URL in batches of --batch-size.
+With --mode prefixes the pages are grouped into prefixes of the host and the first
+--prefix-depth path segments, which purges far fewer items on zones whose plan
+supports prefix purges; pages above that depth, such as the home page, are purged
+by URL.
+
+Without --zone, each page's zone is detected from the zones in the account and every
+zone is purged in turn, followed by a per-zone summary.`,
+		Example: `  # Purge every page in a sitemap, detecting the zones
+  cache-kv-purger cache purge from-sitemap https://example.com/sitemap.xml
+
+  # Preview the purge as top-level prefixes
+  cache-kv-purger cache purge from-sitemap https://example.com/sitemap.xml --mode prefixes --dry-run
+
+  # Purge a local sitemap into one zone without prompting
+  cache-kv-purger cache purge from-sitemap ./sitemap.xml.gz --zone example.com --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
+			if mode != "files" && mode != "prefixes" {
+				return fmt.Errorf("--mode must be files or prefixes")
+			}
+			if batchSize <= 0 || batchSize > 500 {
+				return fmt.Errorf("--batch-size must be between 1 and 500")
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			// Create API client
+			client, err := api.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to create API client: %w", err)
+			}
+
+			// Get account ID for resolving zone names
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
+
+			fmt.Printf("Reading sitemap %s...\n", args[0])
+			sitemap, err := cache.FetchSitemapURLs(args[0], cache.SitemapOptions{Timeout: timeout, MaxURLs: maxURLs})
+			if err != nil {
+				return err
+			}
+			if len(sitemap.URLs) == 0 {
+				return fmt.Errorf("sitemap %s lists no pages", args[0])
+			}
+			fmt.Printf("Found %d unique URLs in %d sitemaps\n", len(sitemap.URLs), sitemap.Sitemaps)
+
+			zoneList, err := groupSitemapURLs(cmd, client, cfg, accountID, sitemap.URLs, verbose)
+			if err != nil {
+				return err
+			}
+
+			zoneIDs := make([]string, len(zoneList))
+			for i, zone := range zoneList {
+				zoneIDs[i] = zone.zoneID
+			}
+
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, zoneIDs); err != nil {
+				return err
+			}
+
+			files, prefixes := 0, 0
+			pending := zoneList[:0]
+			for _, zone := range zoneList {
+				if details, err := zones.GetZoneDetails(client, zone.zoneID); err == nil && details.Result.Name != "" {
+					zone.name = details.Result.Name
+				}
+
+				if mode == "prefixes" {
+					zone.prefixes, zone.files = cache.SitemapPrefixes(zone.files, prefixDepth)
+
+					// Make sure the zone's plan can purge by prefix, or fall back to URLs
+					handled, err := checkPurgePlan(client, zone.zoneID, "prefixes", zone.prefixes, dryRun, verbose)
+					if err != nil {
+						return err
+					}
+					if handled {
+						zone.prefixes = nil
+					}
+				}
+
+				if len(zone.files)+len(zone.prefixes) == 0 {
+					continue
+				}
+				files += len(zone.files)
+				prefixes += len(zone.prefixes)
+				pending = append(pending, zone)
+			}
+			zoneList = pending
+
+			if len(zoneList) == 0 {
+				return nil
+			}
+
+			if dryRun {
+				rows := make([][]string, len(zoneList))
+				for i, zone := range zoneList {
+					rows[i] = []string{zone.name, strconv.Itoa(len(zone.files)), strconv.Itoa(len(zone.prefixes)),
+						strconv.Itoa(sitemapRequests(zone, batchSize))}
+				}
+				fmt.Printf("DRY RUN: Would purge %d URLs and %d prefixes across %d zones\n", files, prefixes, len(zoneList))
+				common.FormatTable([]string{"Zone", "URLs", "Prefixes", "Requests"}, rows)
+				if verbose {
+					for _, zone := range zoneList {
+						for _, prefix := range zone.prefixes {
+							fmt.Printf("  %s: %s (prefix)\n", zone.name, prefix)
+						}
+						for _, file := range zone.files {
+							fmt.Printf("  %s: %s\n", zone.name, file)
+						}
+					}
+				}
+				return nil
+			}
+
+			if !common.ConfirmBatchOperation(files+prefixes, "URLs and prefixes", "purge", purgeFlagsVars.force) {
+				fmt.Println("Operation cancelled.")
+				return nil
+			}
+
+			// Check the daily purge budget, which counts URLs purged by file
+			budget, err := cache.LoadFileBudget("")
+			if err != nil {
+				fmt.Printf("Warning: daily purge count unavailable: %s\n", err)
+			}
+
+			concurrency := purgeFlagsVars.cacheConcurrency
+			if concurrency <= 0 && cfg != nil {
+				concurrency = cfg.GetCacheConcurrency()
+			}
+
+			for _, zone := range zoneList {
+				if verbose {
+					fmt.Printf("Purging %d URLs and %d prefixes in zone %s...\n", len(zone.files), len(zone.prefixes), zone.name)
+				}
+				purgeSitemapZone(client, zone, batchSize, concurrency, verbose)
+
+				if budget != nil && len(zone.files) > 0 {
+					if err := budget.Record(zone.zoneID, zone.purgedURLs); err != nil {
+						fmt.Printf("Warning: %s\n", err)
+					}
+				}
+			}
+
+			// Per-zone summary
+			purged, failedZones := 0, 0
+			rows := make([][]string, len(zoneList))
+			for i, zone := range zoneList {
+				status := "ok"
+				if len(zone.errors) > 0 {
+					failedZones++
+					status = "failed"
+					if zone.purged > 0 {
+						status = "partial"
+					}
+				}
+				purged += zone.purged
+				rows[i] = []string{zone.name, strconv.Itoa(len(zone.files)), strconv.Itoa(len(zone.prefixes)),
+					strconv.Itoa(zone.purged), strconv.Itoa(len(zone.errors)), status}
+			}
+			fmt.Println()
+			common.FormatTable([]string{"Zone", "URLs", "Prefixes", "Purged", "Failed Batches", "Status"}, rows)
+
+			for _, zone := range zoneList {
+				for i, err := range zone.errors {
+					if i == 3 { // Show at most 3 errors per zone
+						fmt.Printf("  - %s: ... and %d more errors\n", zone.name, len(zone.errors)-3)
+						break
+					}
+					fmt.Printf("  - %s: %s\n", zone.name, err)
+				}
+			}
+
+			fmt.Printf("Completed: Successfully purged %d of %d items across %d zones\n", purged, files+prefixes, len(zoneList))
+			if failedZones > 0 {
+				return fmt.Errorf("%d of %d zones had purge errors", failedZones, len(zoneList))
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&mode, "mode", "files", "Purge the pages by URL (files) or by path prefix (prefixes)")
+	cmd.Flags().IntVar(&prefixDepth, "prefix-depth", 1, "Number of path segments in each prefix with --mode prefixes")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 30, "Maximum number of URLs to purge in a single API request (max 500)")
+	cmd.Flags().IntVar(&maxURLs, "max-urls", 100000, "Fail if the sitemap lists more URLs than this (0 for no limit)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for downloading each sitemap")
+	cmd.Flags().BoolVar(&purgeFlagsVars.force, "force", false, "Skip confirmation prompt")
+
+	return cmd
+}
+
+// groupSitemapURLs assigns sitemap URLs to zones: all of them to the zone given with
+// --zone, or each to the zone of its host detected from the account's zones
+func groupSitemapURLs(cmd *cobra.Command, client *api.Client, cfg *config.Config, accountID string,
+	urls []string, verbose bool) ([]*sitemapZone, error) {
+
+	zoneID := purgeFlagsVars.zoneID
+	if zoneID == "" {
+		zoneID, _ = cmd.Flags().GetString("zone")
+	}
+	if zoneID != "" {
+		resolved, err := cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+		if err != nil {
+			return nil, err
+		}
+		return []*sitemapZone{{zoneID: resolved, name: resolved, files: urls}}, nil
+	}
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required to auto-detect zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config, or pass --zone")
+	}
+
+	urlsByHost := make(map[string][]string)
+	for _, pageURL := range urls {
+		parsed, err := url.Parse(pageURL)
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("sitemap lists an invalid URL: %s", pageURL)
+		}
+		host := strings.ToLower(parsed.Hostname())
+		urlsByHost[host] = append(urlsByHost[host], pageURL)
+	}
+
+	hosts := make([]string, 0, len(urlsByHost))
+	for host := range urlsByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	if verbose {
+		fmt.Printf("Auto-detecting zones for %d hosts...\n", len(hosts))
+	}
+
+	hostZones, unknownHosts, err := zones.DetectZonesFromHosts(client, accountID, hosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect zones: %w", err)
+	}
+	if len(unknownHosts) > 0 {
+		return nil, fmt.Errorf("%d hosts don't belong to any zone in the account: %s",
+			len(unknownHosts), strings.Join(unknownHosts, ", "))
+	}
+
+	byZone := make(map[string]*sitemapZone)
+	var zoneList []*sitemapZone
+	for _, host := range hosts {
+		zone, ok := byZone[hostZones[host]]
+		if !ok {
+			zone = &sitemapZone{zoneID: hostZones[host], name: hostZones[host]}
+			byZone[zone.zoneID] = zone
+			zoneList = append(zoneList, zone)
+		}
+		zone.files = append(zone.files, urlsByHost[host]...)
+	}
+	sort.Slice(zoneList, func(i, j int) bool { return zoneList[i].zoneID < zoneList[j].zoneID })
+	return zoneList, nil
+}
+
+// purgeSitemapZone purges a zone's prefixes and URLs in batches, recording the number
+// of purged items and the errors of failed batches
+func purgeSitemapZone(client *api.Client, zone *sitemapZone, batchSize, concurrency int, verbose bool) {
+	if len(zone.prefixes) > 0 {
+		var progress func(completed, total, successful int)
+		if verbose {
+			progress = func(completed, total, successful int) {
+				fmt.Printf("Zone %s: processed %d/%d prefix batches, %d prefixes purged\n", zone.name, completed, total, successful)
+			}
+		}
+		successful, errors := cache.PurgePrefixesInBatches(client, zone.zoneID, zone.prefixes, cache.NewPurgeBatchOptions(
+			cache.WithBatchSize(batchSize),
+			cache.WithConcurrency(concurrency),
+			cache.WithProgress(progress),
+		))
+		zone.purged += len(successful)
+		zone.errors = append(zone.errors, errors...)
+	}
+
+	if len(zone.files) > 0 {
+		processor := common.NewBatchProcessor().
+			WithBatchSize(batchSize).
+			WithConcurrency(concurrency).
+			WithProgressCallback(func(completed, total, successful int) {
+				if verbose {
+					fmt.Printf("Zone %s: processed %d/%d URL batches, %d URLs purged\n", zone.name, completed, total, successful)
+				}
+			})
+		successful, errors := processor.ProcessStrings(zone.files, func(batch []string) ([]string, error) {
+			if _, err := cache.PurgeFiles(client, zone.zoneID, batch); err != nil {
+				return nil, err
+			}
+			return batch, nil
+		})
+		zone.purged += len(successful)
+		zone.purgedURLs = len(successful)
+		zone.errors = append(zone.errors, errors...)
+	}
+}
+
+// sitemapRequests returns the number of purge requests a zone's items take
+func sitemapRequests(zone *sitemapZone, batchSize int) int {
+	return len(common.SplitIntoBatches(zone.files, batchSize)) + len(common.SplitIntoBatches(zone.prefixes, 100))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+)
+
+// defaultPreviewTagField is the metadata field read for tag previews when neither the
+// flag nor the namespace defaults name one
+const defaultPreviewTagField = "cache-tag"
+
+// tagPreviewOptions configures the sample of keys shown before a tag purge
+type tagPreviewOptions struct {
+	namespace string // Namespace ID or title holding the content behind the tags
+	tagField  string // Metadata field holding the tags
+	limit     int    // Keys shown per tag
+}
+
+// previewTags prints a sample of the KV keys whose metadata carries each tag, so the
+// operator can check they are purging the right tag names. Cloudflare analytics don't
+// record cache tags, so the KV metadata index is the only place to find what a tag covers.
+func previewTags(client *api.Client, cfg *config.Config, accountID string, tags []string, options tagPreviewOptions) error {
+	namespace := options.namespace
+	if namespace == "" && cfg != nil {
+		namespace = cfg.GetNamespaceID()
+	}
+	if namespace == "" {
+		return fmt.Errorf("--preview needs --preview-namespace (or a default namespace) to find the keys behind the tags")
+	}
+	if accountID == "" {
+		return fmt.Errorf("--preview needs an account ID to read the namespace, specify it with --account-id")
+	}
+
+	namespaceID, err := kv.NewKVService(client).ResolveNamespaceID(context.Background(), accountID, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to resolve preview namespace: %w", err)
+	}
+
+	tagField := options.tagField
+	if tagField == "" && cfg != nil {
+		if defaults, ok := cfg.GetNamespaceDefaults(namespaceID, namespace); ok {
+			tagField = defaults.TagField
+		}
+	}
+	if tagField == "" {
+		tagField = defaultPreviewTagField
+	}
+
+	samples, scanned, err := kv.SampleTaggedKeys(client, accountID, namespaceID, tagField, tags, options.limit, 0)
+	if err != nil {
+		return fmt.Errorf("failed to preview tags: %w", err)
+	}
+
+	fmt.Printf("\nKeys tagged in namespace %s (metadata field '%s', %d keys scanned):\n", namespace, tagField, scanned)
+	untagged := 0
+	for _, sample := range samples {
+		if len(sample.Keys) == 0 {
+			untagged++
+			fmt.Printf("  %s: no keys found\n", sample.Tag)
+			continue
+		}
+		more := ""
+		if sample.HasMore {
+			more = ", more exist"
+		}
+		fmt.Printf("  %s (%d shown%s):\n", sample.Tag, len(sample.Keys), more)
+		for _, key := range sample.Keys {
+			fmt.Printf("    - %s\n", key)
+		}
+	}
+	if untagged > 0 {
+		fmt.Printf("%d of %d tags matched no keys; check the tag names before purging.\n", untagged, len(tags))
+	}
+	fmt.Println()
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"cache-kv-purger/internal/cmdutil"
 	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
-	"cache-kv-purger/internal/zones"
 	"fmt"
 	"github.com/spf13/cobra"
 	"strings"
@@ -19,6 +18,8 @@ func createPurgeTagsCmd() *cobra.Command {
 	var tagsFile string
 	var batchSize int
 	var dryRun bool
+	var preview bool
+	var previewOpts tagPreviewOptions
 
 	cmd := &cobra.Command{
 		Use:   "tags",
@@ -40,7 +41,10 @@ func createPurgeTagsCmd() *cobra.Command {
   cache-kv-purger cache purge tags --zone example.com --tags-file tags.csv 
   
   # Dry run (show what would be purged, but don't actually purge)
-  cache-kv-purger cache purge tags --zone example.com --tags-file tags.csv --dry-run`,
+  cache-kv-purger cache purge tags --zone example.com --tags-file tags.csv --dry-run
+
+  # Show keys tagged in a KV namespace before confirming the purge
+  cache-kv-purger cache purge tags --zone example.com --tag product-123 --preview --preview-namespace "Product Pages"`,
 		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
 			// Middleware now handles verbose flags
 
@@ -51,11 +55,8 @@ func createPurgeTagsCmd() *cobra.Command {
 			}
 
 			// Get account ID for resolving zone names
-			accountID := ""
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
+			cfg, _ := config.LoadFromFile("")
+			accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 			// Collect all tags from various input methods
 			allTags := make([]string, 0)
@@ -113,21 +114,27 @@ func createPurgeTagsCmd() *cobra.Command {
 				zoneID, _ = cmd.Flags().GetString("zone")
 			}
 
-			if zoneID == "" {
-				// Try to get from config or environment variable
-				if cfg != nil {
-					zoneID = cfg.GetZoneID()
-				}
+			// Resolve zone (could be name or ID), falling back to the default zone
+			resolvedZoneID, err := cmdutil.ResolveZoneID(client, cfg, accountID, zoneID)
+			if err != nil {
+				return err
 			}
 
-			if zoneID == "" {
-				return fmt.Errorf("zone ID is required, specify it with --zone flag, CLOUDFLARE_ZONE_ID environment variable, or set a default zone in config")
+			// Refuse to purge protected zones
+			if err := checkZonesProtection(cmd, client, []string{resolvedZoneID}); err != nil {
+				return err
 			}
 
-			// Resolve zone (could be name or ID)
-			resolvedZoneID, err := zones.ResolveZoneIdentifier(client, accountID, zoneID)
-			if err != nil {
-				return fmt.Errorf("failed to resolve zone: %w", err)
+			// Show what the tags cover before going further
+			if preview {
+				if err := previewTags(client, cfg, accountID, allTags, previewOpts); err != nil {
+					return err
+				}
+			}
+
+			// Make sure the zone's plan can purge by tags, or fall back to URLs
+			if handled, err := checkPurgePlan(client, resolvedZoneID, "tags", allTags, dryRun, verbose); handled || err != nil {
+				return err
 			}
 
 			// Default batch size if not specified or invalid
@@ -191,12 +198,8 @@ func createPurgeTagsCmd() *cobra.Command {
 				concurrency = cfg.GetCacheConcurrency()
 			}
 
-			// Cap concurrency for Enterprise tier
-			if concurrency <= 0 {
-				concurrency = 50 // Default
-			} else if concurrency > 50 {
-				concurrency = 50 // Enterprise tier allows 50 requests per second
-			}
+			// Default to the Enterprise tier's 50 requests per second, capped by the policy
+			concurrency = common.CurrentPolicy().Concurrency(concurrency, 50)
 
 			// Split tags into batches (for preview in dry run mode)
 			batches := common.SplitIntoBatches(allTags, batchSize)
@@ -240,7 +243,11 @@ func createPurgeTagsCmd() *cobra.Command {
 			}
 
 			// Process tags with concurrent batching
-			successful, errors := cache.PurgeTagsInBatches(client, resolvedZoneID, allTags, progressFn, concurrency)
+			successful, errors := cache.PurgeTagsInBatches(client, resolvedZoneID, allTags, cache.NewPurgeBatchOptions(
+				cache.WithBatchSize(batchSize),
+				cache.WithConcurrency(concurrency),
+				cache.WithProgress(progressFn),
+			))
 
 			// Print a newline to clear the progress line
 			if !verbose {
@@ -272,6 +279,10 @@ func createPurgeTagsCmd() *cobra.Command {
 	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "Maximum number of tags to purge in each batch (API limit: 100 tags per request)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without actually purging")
 	cmd.Flags().BoolVar(&purgeFlagsVars.force, "force", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&preview, "preview", false, "Before purging, list sample KV keys whose metadata carries each tag")
+	cmd.Flags().StringVar(&previewOpts.namespace, "preview-namespace", "", "Namespace ID or title to find tagged keys in (default: the default namespace)")
+	cmd.Flags().StringVar(&previewOpts.tagField, "preview-tag-field", "", "Metadata field holding the tags (default: the namespace's tag_field, or cache-tag)")
+	cmd.Flags().IntVar(&previewOpts.limit, "preview-limit", 5, "Number of keys to show per tag")
 
 	return cmd
 }
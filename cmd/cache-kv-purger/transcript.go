@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+)
+
+// initializeTranscript starts recording the run for the --transcript file
+func initializeTranscript() {
+	path, _ := rootCmd.PersistentFlags().GetString("transcript")
+	if path == "" {
+		return
+	}
+	if err := common.StartTranscript(path, os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// finishTranscript writes the --transcript file with the outcome of the run and the
+// purge IDs it received, which change tickets usually ask for
+func finishTranscript(err error) {
+	if !common.TranscriptActive() {
+		return
+	}
+
+	purges := common.TranscriptSection{
+		Title:  "Purge requests",
+		Header: []string{"Purge ID", "Zone", "Type", "Items", "Time"},
+	}
+	for _, record := range cache.SessionPurges() {
+		purges.Rows = append(purges.Rows, []string{
+			record.ID, record.ZoneID, record.Type, strconv.Itoa(record.Items), record.Time.Format(time.RFC3339),
+		})
+	}
+
+	if err := common.FinishTranscript(err, purges); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/cmdutil"
+	"cache-kv-purger/internal/common"
+
+	"github.com/spf13/cobra"
+)
+
+// createWarmCmd creates a command to re-populate the cache by requesting URLs
+func createWarmCmd() *cobra.Command {
+	// Define local variables for this command's flags
+	var urls []string
+	var urlsFile string
+	var concurrency int
+	var devices []string
+	var languages []string
+	var headers []string
+	var timeout time.Duration
+	var dryRun bool
+	var outputJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Pre-warm the cache by requesting URLs",
+		Long: `Request each URL through Cloudflare so the cache is populated again after a purge,
+before visitor traffic reaches the origin.
+
+With --device, each URL is requested once per device type (desktop, mobile, tablet) using a
+matching User-Agent, for zones that cache by device type. With --language, each URL is
+requested once per Accept-Language value. Both can be combined.
+
+At the end the CF-Cache-Status values of the responses are summarized: MISS and EXPIRED
+mean the request populated the cache, HIT means it was already cached, and DYNAMIC or
+BYPASS mean the URL is not cached at all.`,
+		Example: `  # Warm the URLs in a file, 20 at a time
+  cache-kv-purger cache warm --urls-file urls.txt --concurrency 20
+
+  # Warm mobile and desktop variants in two languages
+  cache-kv-purger cache warm --urls-file urls.txt --device desktop,mobile --language en-US,de-DE
+
+  # Purge, then warm the same URLs
+  cache-kv-purger cache purge files --zone example.com --files-list urls.txt
+  cache-kv-purger cache warm --urls-file urls.txt`,
+		RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
+			// Collect the URLs
+			allURLs := append([]string{}, urls...)
+			if urlsFile != "" {
+				fromFile, err := common.ReadItemsFromFile(urlsFile, "", nil)
+				if err != nil {
+					return fmt.Errorf("failed to read URLs file: %w", err)
+				}
+				allURLs = append(allURLs, fromFile...)
+			}
+			allURLs = common.RemoveDuplicates(allURLs)
+			if len(allURLs) == 0 {
+				return fmt.Errorf("at least one URL is required, specify with --url or --urls-file")
+			}
+
+			for _, raw := range allURLs {
+				u, err := url.Parse(raw)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid URL '%s': must be an absolute http or https URL", raw)
+				}
+			}
+
+			variants, err := cache.BuildWarmVariants(devices, languages)
+			if err != nil {
+				return err
+			}
+
+			extraHeaders := make(map[string]string, len(headers))
+			for _, header := range headers {
+				name, value, ok := strings.Cut(header, ":")
+				if !ok || strings.TrimSpace(name) == "" {
+					return fmt.Errorf("invalid header '%s': use 'Name: value'", header)
+				}
+				extraHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+
+			total := len(allURLs) * len(variants)
+			if dryRun {
+				fmt.Printf("DRY RUN: Would request %d URLs in %d variants (%d requests, concurrency %d)\n",
+					len(allURLs), len(variants), total, concurrency)
+				if verbose {
+					for _, u := range allURLs {
+						fmt.Printf("  %s\n", u)
+					}
+				}
+				return nil
+			}
+
+			if !outputJSON {
+				fmt.Printf("Warming %d URLs in %d variants (%d requests)...\n", len(allURLs), len(variants), total)
+			}
+
+			progressFn := func(completed, total int) {
+				if outputJSON {
+					return
+				}
+				if verbose {
+					fmt.Printf("Progress: %d/%d requests\n", completed, total)
+				} else if completed%100 == 0 || completed == total {
+					fmt.Printf("Progress: %d/%d requests...  \r", completed, total)
+				}
+			}
+
+			result := cache.WarmURLs(allURLs, cache.WarmOptions{
+				Concurrency: concurrency,
+				Timeout:     timeout,
+				Variants:    variants,
+				Headers:     extraHeaders,
+			}, progressFn)
+
+			if outputJSON {
+				return common.OutputJSON(map[string]interface{}{
+					"result":           result,
+					"duration_seconds": result.Duration.Seconds(),
+				})
+			}
+
+			if !verbose {
+				fmt.Println()
+			}
+			printWarmSummary(result, verbose)
+
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("%d of %d requests failed", len(result.Errors), result.Requests)
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().StringArrayVar(&urls, "url", []string{}, "URL to warm (can be specified multiple times)")
+	cmd.Flags().StringVar(&urlsFile, "urls-file", "", "File containing URLs to warm (text with one URL per line, CSV, or JSON array)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 20, "Number of concurrent requests")
+	cmd.Flags().StringSliceVar(&devices, "device", nil, "Device types to warm: desktop, mobile, tablet (comma-separated or repeated)")
+	cmd.Flags().StringSliceVar(&languages, "language", nil, "Accept-Language values to warm (comma-separated or repeated)")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, "Extra request header as 'Name: value' (can be specified multiple times)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout per request")
+	cmd.Flags().Bool("verbose", false, "Enable verbose output")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be requested without requesting it")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output the summary as JSON")
+
+	return cmd
+}
+
+// printWarmSummary prints the CF-Cache-Status distribution and failed requests
+func printWarmSummary(result *cache.WarmResult, verbose bool) {
+	statuses := make([]string, 0, len(result.CacheStatuses))
+	for status := range result.CacheStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return result.CacheStatuses[statuses[i]] > result.CacheStatuses[statuses[j]] ||
+			(result.CacheStatuses[statuses[i]] == result.CacheStatuses[statuses[j]] && statuses[i] < statuses[j])
+	})
+
+	rows := make([][]string, 0, len(statuses))
+	for _, status := range statuses {
+		count := result.CacheStatuses[status]
+		rows = append(rows, []string{status, strconv.Itoa(count),
+			fmt.Sprintf("%.1f%%", float64(count)*100/float64(result.Requests))})
+	}
+	if len(rows) > 0 {
+		common.FormatTable([]string{"CF-Cache-Status", "Requests", "Share"}, rows)
+	}
+
+	if verbose && len(result.HTTPStatuses) > 0 {
+		codes := make([]int, 0, len(result.HTTPStatuses))
+		for code := range result.HTTPStatuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		parts := make([]string, len(codes))
+		for i, code := range codes {
+			parts[i] = fmt.Sprintf("%d: %d", code, result.HTTPStatuses[code])
+		}
+		fmt.Printf("HTTP status codes: %s\n", strings.Join(parts, ", "))
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Printf("Failed requests: %d\n", len(result.Errors))
+		for i, warmErr := range result.Errors {
+			if i == 5 && !verbose { // Show at most 5 errors unless verbose
+				fmt.Printf("  - ... and %d more\n", len(result.Errors)-5)
+				break
+			}
+			if warmErr.Variant != "" {
+				fmt.Printf("  - %s (%s): %s\n", warmErr.URL, warmErr.Variant, warmErr.Error)
+			} else {
+				fmt.Printf("  - %s: %s\n", warmErr.URL, warmErr.Error)
+			}
+		}
+	}
+
+	fmt.Printf("Completed %d requests in %v\n", result.Requests, result.Duration.Round(time.Millisecond))
+}
@@ -23,15 +23,9 @@ var zonesListCmd = &cobra.Command{
 	Short: "List all zones",
 	Long:  `List all zones available for your account.`,
 	RunE: cmdutil.WithVerbose(func(cmd *cobra.Command, args []string, verbose, debug bool) error {
-		// Get account ID from flag, config, or environment variable
-		accountID, _ := cmd.Flags().GetString("account-id")
-		if accountID == "" {
-			// Try to get from config or environment variable
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
-		}
+		// Get account ID from flag, environment variable, or config
+		cfg, _ := config.LoadFromFile("")
+		accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 		// Create API client
 		client, err := api.NewClient()
@@ -78,15 +72,9 @@ var zonesGetCmd = &cobra.Command{
 		// Get domain name from arguments
 		domainName := args[0]
 
-		// Get account ID from flag, config, or environment variable
-		accountID, _ := cmd.Flags().GetString("account-id")
-		if accountID == "" {
-			// Try to get from config or environment variable
-			cfg, err := config.LoadFromFile("")
-			if err == nil {
-				accountID = cfg.GetAccountID()
-			}
-		}
+		// Get account ID from flag, environment variable, or config
+		cfg, _ := config.LoadFromFile("")
+		accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 		// Create API client
 		client, err := api.NewClient()
@@ -157,7 +145,7 @@ var zonesConfigCmd = &cobra.Command{
 				}
 
 				// Try to get account ID
-				accountID := cfg.GetAccountID()
+				accountID := cmdutil.ResolveAccountID(cmd, cfg)
 
 				// Try to resolve the zone
 				resolvedZoneID, err := zones.ResolveZoneIdentifier(client, accountID, zoneIdentifier)
@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// graphQLPath is the path of Cloudflare's GraphQL analytics API, relative to the API base URL
+const graphQLPath = "/graphql"
+
+// DefaultTopURLs is the number of top cached URLs fetched when no limit is given
+const DefaultTopURLs = 10
+
+// cachedStatuses are the cache statuses of responses served from cache
+var cachedStatuses = map[string]bool{
+	"hit":         true,
+	"stale":       true,
+	"updating":    true,
+	"revalidated": true,
+}
+
+// IsCachedStatus returns true if responses with the cache status were served from cache
+func IsCachedStatus(status string) bool {
+	return cachedStatuses[strings.ToLower(status)]
+}
+
+// Options selects the time range and detail of a cache snapshot
+type Options struct {
+	Since   time.Time // Start of the range
+	Until   time.Time // End of the range (default now)
+	TopURLs int       // Number of top cached URLs (default DefaultTopURLs)
+}
+
+// StatusCount is the traffic of one cache status
+type StatusCount struct {
+	Status   string `json:"status"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// URLCount is the cached traffic of one URL
+type URLCount struct {
+	URL      string `json:"url"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// CacheSnapshot summarizes how well a zone was cached over a time range
+type CacheSnapshot struct {
+	ZoneID            string        `json:"zone_id"`
+	Since             time.Time     `json:"since"`
+	Until             time.Time     `json:"until"`
+	Requests          int64         `json:"requests"`
+	CachedRequests    int64         `json:"cached_requests"`
+	Bytes             int64         `json:"bytes"`
+	CachedBytes       int64         `json:"cached_bytes"`
+	HitRatio          float64       `json:"hit_ratio"`           // Share of requests served from cache
+	BandwidthHitRatio float64       `json:"bandwidth_hit_ratio"` // Share of bytes served from cache
+	ByStatus          []StatusCount `json:"by_status"`
+	TopCachedURLs     []URLCount    `json:"top_cached_urls"`
+}
+
+// cacheSnapshotQuery reads the traffic of a zone by cache status and its most requested
+// cached URLs from the adaptive HTTP requests dataset
+const cacheSnapshotQuery = `query CacheSnapshot($zoneTag: string, $since: Time, $until: Time, $top: Int) {
+  viewer {
+    zones(filter: {zoneTag: $zoneTag}) {
+      byStatus: httpRequestsAdaptiveGroups(limit: 100, filter: {datetime_geq: $since, datetime_lt: $until}, orderBy: [count_DESC]) {
+        count
+        sum { edgeResponseBytes }
+        dimensions { cacheStatus }
+      }
+      topCached: httpRequestsAdaptiveGroups(limit: $top, filter: {datetime_geq: $since, datetime_lt: $until, cacheStatus_in: ["hit", "stale", "updating", "revalidated"]}, orderBy: [count_DESC]) {
+        count
+        sum { edgeResponseBytes }
+        dimensions { clientRequestHTTPHost clientRequestPath }
+      }
+    }
+  }
+}`
+
+// trafficGroup is one row of the adaptive HTTP requests dataset
+type trafficGroup struct {
+	Count int64 `json:"count"`
+	Sum   struct {
+		EdgeResponseBytes int64 `json:"edgeResponseBytes"`
+	} `json:"sum"`
+	Dimensions struct {
+		CacheStatus           string `json:"cacheStatus"`
+		ClientRequestHTTPHost string `json:"clientRequestHTTPHost"`
+		ClientRequestPath     string `json:"clientRequestPath"`
+	} `json:"dimensions"`
+}
+
+// cacheSnapshotResponse is the GraphQL response to cacheSnapshotQuery
+type cacheSnapshotResponse struct {
+	Data struct {
+		Viewer struct {
+			Zones []struct {
+				ByStatus  []trafficGroup `json:"byStatus"`
+				TopCached []trafficGroup `json:"topCached"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GetCacheSnapshot reads the cache hit ratio, bandwidth and top cached URLs of a zone from
+// the GraphQL analytics API. The token needs the Analytics Read permission, and the range
+// that can be queried depends on the zone's plan.
+func GetCacheSnapshot(client *api.Client, zoneID string, options Options) (*CacheSnapshot, error) {
+	if zoneID == "" {
+		return nil, fmt.Errorf("zone ID is required")
+	}
+	if options.Until.IsZero() {
+		options.Until = time.Now()
+	}
+	if !options.Since.Before(options.Until) {
+		return nil, fmt.Errorf("start of the range must be before its end")
+	}
+	if options.TopURLs <= 0 {
+		options.TopURLs = DefaultTopURLs
+	}
+
+	request := map[string]interface{}{
+		"query": cacheSnapshotQuery,
+		"variables": map[string]interface{}{
+			"zoneTag": zoneID,
+			"since":   options.Since.UTC().Format(time.RFC3339),
+			"until":   options.Until.UTC().Format(time.RFC3339),
+			"top":     options.TopURLs,
+		},
+	}
+
+	respBody, err := client.Request(http.MethodPost, graphQLPath, nil, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analytics: %w", err)
+	}
+
+	var resp cacheSnapshotResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse analytics response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("analytics query failed: %s", resp.Errors[0].Message)
+	}
+	if len(resp.Data.Viewer.Zones) == 0 {
+		return nil, fmt.Errorf("no analytics returned for zone %s", zoneID)
+	}
+
+	zone := resp.Data.Viewer.Zones[0]
+	snapshot := buildSnapshot(zone.ByStatus, zone.TopCached)
+	snapshot.ZoneID = zoneID
+	snapshot.Since = options.Since.UTC()
+	snapshot.Until = options.Until.UTC()
+	return snapshot, nil
+}
+
+// buildSnapshot totals traffic by cache status and lists the top cached URLs
+func buildSnapshot(byStatus, topCached []trafficGroup) *CacheSnapshot {
+	snapshot := &CacheSnapshot{ByStatus: []StatusCount{}, TopCachedURLs: []URLCount{}}
+
+	for _, group := range byStatus {
+		status := group.Dimensions.CacheStatus
+		if status == "" {
+			status = "unknown"
+		}
+		snapshot.ByStatus = append(snapshot.ByStatus, StatusCount{Status: status, Requests: group.Count, Bytes: group.Sum.EdgeResponseBytes})
+		snapshot.Requests += group.Count
+		snapshot.Bytes += group.Sum.EdgeResponseBytes
+		if IsCachedStatus(status) {
+			snapshot.CachedRequests += group.Count
+			snapshot.CachedBytes += group.Sum.EdgeResponseBytes
+		}
+	}
+	sort.SliceStable(snapshot.ByStatus, func(i, j int) bool { return snapshot.ByStatus[i].Requests > snapshot.ByStatus[j].Requests })
+
+	if snapshot.Requests > 0 {
+		snapshot.HitRatio = float64(snapshot.CachedRequests) / float64(snapshot.Requests)
+	}
+	if snapshot.Bytes > 0 {
+		snapshot.BandwidthHitRatio = float64(snapshot.CachedBytes) / float64(snapshot.Bytes)
+	}
+
+	// Groups are already ordered by requests
+	for _, group := range topCached {
+		snapshot.TopCachedURLs = append(snapshot.TopCachedURLs, URLCount{
+			URL:      "https://" + group.Dimensions.ClientRequestHTTPHost + group.Dimensions.ClientRequestPath,
+			Requests: group.Count,
+			Bytes:    group.Sum.EdgeResponseBytes,
+		})
+	}
+
+	return snapshot
+}
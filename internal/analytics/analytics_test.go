@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildSnapshot(t *testing.T) {
+	var resp cacheSnapshotResponse
+	body := `{"data":{"viewer":{"zones":[{
+		"byStatus":[
+			{"count":20,"sum":{"edgeResponseBytes":2000},"dimensions":{"cacheStatus":"miss"}},
+			{"count":70,"sum":{"edgeResponseBytes":6000},"dimensions":{"cacheStatus":"hit"}},
+			{"count":10,"sum":{"edgeResponseBytes":2000},"dimensions":{"cacheStatus":"revalidated"}}
+		],
+		"topCached":[
+			{"count":50,"sum":{"edgeResponseBytes":5000},"dimensions":{"clientRequestHTTPHost":"example.com","clientRequestPath":"/app.js"}}
+		]
+	}]}}}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	zone := resp.Data.Viewer.Zones[0]
+
+	snapshot := buildSnapshot(zone.ByStatus, zone.TopCached)
+	if snapshot.Requests != 100 || snapshot.CachedRequests != 80 {
+		t.Errorf("requests = %d cached %d, want 100 cached 80", snapshot.Requests, snapshot.CachedRequests)
+	}
+	if snapshot.HitRatio != 0.8 {
+		t.Errorf("HitRatio = %v, want 0.8", snapshot.HitRatio)
+	}
+	if snapshot.BandwidthHitRatio != 0.8 {
+		t.Errorf("BandwidthHitRatio = %v, want 0.8", snapshot.BandwidthHitRatio)
+	}
+	if snapshot.ByStatus[0].Status != "hit" {
+		t.Errorf("ByStatus[0] = %s, want hit first", snapshot.ByStatus[0].Status)
+	}
+	if len(snapshot.TopCachedURLs) != 1 || snapshot.TopCachedURLs[0].URL != "https://example.com/app.js" {
+		t.Errorf("TopCachedURLs = %v", snapshot.TopCachedURLs)
+	}
+}
+
+func TestBuildSnapshotNoTraffic(t *testing.T) {
+	snapshot := buildSnapshot(nil, nil)
+	if snapshot.HitRatio != 0 || snapshot.BandwidthHitRatio != 0 {
+		t.Errorf("ratios = %v %v, want 0 without traffic", snapshot.HitRatio, snapshot.BandwidthHitRatio)
+	}
+}
+
+func TestSplitPrefix(t *testing.T) {
+	tests := []struct {
+		prefix, host, path string
+	}{
+		{"https://Example.com/blog/", "example.com", "/blog/"},
+		{"example.com/blog", "example.com", "/blog"},
+		{"example.com", "example.com", "/"},
+		{"/blog/", "", "/blog/"},
+	}
+	for _, tt := range tests {
+		host, path := SplitPrefix(tt.prefix)
+		if host != tt.host || path != tt.path {
+			t.Errorf("SplitPrefix(%s) = %s, %s, want %s, %s", tt.prefix, host, path, tt.host, tt.path)
+		}
+	}
+}
+
+func TestIsBroadPrefix(t *testing.T) {
+	tests := map[string]bool{
+		"example.com/":                   true,
+		"https://example.com/blog/":      true,
+		"example.com/blog/2024/":         false,
+		"https://example.com/a/b/c.html": false,
+	}
+	for prefix, want := range tests {
+		if got := IsBroadPrefix(prefix); got != want {
+			t.Errorf("IsBroadPrefix(%s) = %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+func TestBlastRadiusString(t *testing.T) {
+	radius := BlastRadius{Scope: "example.com", CachedRequests: 4_200_000, CachedBytes: 3 * 1024 * 1024 * 1024}
+	want := "example.com served ~4.2M cached requests/day (3.0 GiB)"
+	if got := radius.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := FormatCount(980); got != "980" {
+		t.Errorf("FormatCount(980) = %s, want 980", got)
+	}
+}
@@ -0,0 +1,177 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// BlastRadiusPeriod is how far back the traffic behind a blast radius estimate is read
+const BlastRadiusPeriod = 24 * time.Hour
+
+// BlastRadius estimates the cached traffic a purge would send back to the origin, from the
+// requests served from cache over the last BlastRadiusPeriod
+type BlastRadius struct {
+	Scope          string `json:"scope"` // Zone name or URL prefix
+	Requests       int64  `json:"requests,omitempty"`
+	CachedRequests int64  `json:"cached_requests"`
+	CachedBytes    int64  `json:"cached_bytes"`
+}
+
+// String describes the blast radius, e.g. "example.com served ~4.2M cached requests/day (12.3 GiB)"
+func (b BlastRadius) String() string {
+	return fmt.Sprintf("%s served ~%s cached requests/day (%s)", b.Scope, FormatCount(b.CachedRequests), FormatBytes(b.CachedBytes))
+}
+
+// EstimateZoneBlastRadius reads the cached traffic of a whole zone, the traffic that
+// purging everything would send to the origin until the cache refills
+func EstimateZoneBlastRadius(client *api.Client, zoneID, zoneName string) (*BlastRadius, error) {
+	now := time.Now()
+	snapshot, err := GetCacheSnapshot(client, zoneID, Options{Since: now.Add(-BlastRadiusPeriod), Until: now, TopURLs: 1})
+	if err != nil {
+		return nil, err
+	}
+	if zoneName == "" {
+		zoneName = zoneID
+	}
+	return &BlastRadius{
+		Scope:          zoneName,
+		Requests:       snapshot.Requests,
+		CachedRequests: snapshot.CachedRequests,
+		CachedBytes:    snapshot.CachedBytes,
+	}, nil
+}
+
+// prefixTrafficQuery totals the cached traffic under a path prefix, of one host or of any
+// host when $host is %
+const prefixTrafficQuery = `query PrefixTraffic($zoneTag: string, $since: Time, $until: Time, $host: string, $path: string) {
+  viewer {
+    zones(filter: {zoneTag: $zoneTag}) {
+      cached: httpRequestsAdaptiveGroups(limit: 1, filter: {datetime_geq: $since, datetime_lt: $until, clientRequestHTTPHost_like: $host, clientRequestPath_like: $path, cacheStatus_in: ["hit", "stale", "updating", "revalidated"]}) {
+        count
+        sum { edgeResponseBytes }
+      }
+    }
+  }
+}`
+
+// prefixTrafficResponse is the GraphQL response to prefixTrafficQuery
+type prefixTrafficResponse struct {
+	Data struct {
+		Viewer struct {
+			Zones []struct {
+				Cached []trafficGroup `json:"cached"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// EstimatePrefixBlastRadius reads the cached traffic under a purge prefix such as
+// example.com/blog/ (a scheme is ignored). A prefix without a host, such as /blog/,
+// counts the traffic of every host in the zone.
+func EstimatePrefixBlastRadius(client *api.Client, zoneID, prefix string) (*BlastRadius, error) {
+	host, path := SplitPrefix(prefix)
+	hostFilter := host
+	if hostFilter == "" {
+		hostFilter = "%"
+	}
+
+	now := time.Now()
+	request := map[string]interface{}{
+		"query": prefixTrafficQuery,
+		"variables": map[string]interface{}{
+			"zoneTag": zoneID,
+			"since":   now.Add(-BlastRadiusPeriod).UTC().Format(time.RFC3339),
+			"until":   now.UTC().Format(time.RFC3339),
+			"host":    hostFilter,
+			"path":    path + "%",
+		},
+	}
+
+	respBody, err := client.Request(http.MethodPost, graphQLPath, nil, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analytics: %w", err)
+	}
+
+	var resp prefixTrafficResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse analytics response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("analytics query failed: %s", resp.Errors[0].Message)
+	}
+	if len(resp.Data.Viewer.Zones) == 0 {
+		return nil, fmt.Errorf("no analytics returned for zone %s", zoneID)
+	}
+
+	radius := &BlastRadius{Scope: host + path}
+	for _, group := range resp.Data.Viewer.Zones[0].Cached {
+		radius.CachedRequests += group.Count
+		radius.CachedBytes += group.Sum.EdgeResponseBytes
+	}
+	return radius, nil
+}
+
+// SplitPrefix splits a purge prefix into its hostname and path, which always starts with
+// a slash: "https://example.com/blog" gives "example.com" and "/blog", and "/blog" gives
+// no host
+func SplitPrefix(prefix string) (host, path string) {
+	rest := prefix
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	host, path, _ = strings.Cut(rest, "/")
+	return strings.ToLower(host), "/" + path
+}
+
+// IsBroadPrefix returns true for prefixes that cover a whole host or a top-level
+// directory, such as example.com/ or example.com/blog/, whose purge can reach a large
+// share of a zone's traffic
+func IsBroadPrefix(prefix string) bool {
+	_, path := SplitPrefix(prefix)
+	segments := 0
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments++
+		}
+	}
+	return segments <= 1
+}
+
+// FormatCount formats a count with a metric suffix, e.g. 4.2M or 980
+func FormatCount(n int64) string {
+	value := float64(n)
+	for _, suffix := range []string{"", "K", "M", "B"} {
+		if value < 1000 || suffix == "B" {
+			if suffix == "" {
+				return fmt.Sprintf("%d", n)
+			}
+			return fmt.Sprintf("%.1f%s", value, suffix)
+		}
+		value /= 1000
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// FormatBytes formats a byte count for display, e.g. 12.3 GiB
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	for _, suffix := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		value /= unit
+		if value < unit || suffix == "TiB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
@@ -6,15 +6,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"time"
 
 	"cache-kv-purger/internal/auth"
 	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
 )
 
+// defaultBaseURL is the base URL used by clients created without WithBaseURL
+var defaultBaseURL = config.DefaultAPIEndpoint
+
+// SetDefaultBaseURL sets the base URL used by clients created without WithBaseURL,
+// e.g. from the --api-endpoint flag, a mocked endpoint in tests, or a gateway proxy
+func SetDefaultBaseURL(baseURL string) error {
+	validated, err := config.ValidateAPIEndpoint(baseURL)
+	if err != nil {
+		return err
+	}
+	defaultBaseURL = validated
+	return nil
+}
+
+// wrapFaultTransport wraps the transport of new clients to inject faults. It is only set
+// in builds with the faultinject tag.
+var wrapFaultTransport func(http.RoundTripper) http.RoundTripper
+
+// DefaultBaseURL returns the base URL used by clients created without WithBaseURL
+func DefaultBaseURL() string {
+	return defaultBaseURL
+}
+
 // Client represents a Cloudflare API client
 type Client struct {
 	BaseURL    string
@@ -48,22 +74,32 @@ func WithCredentials(creds *auth.CredentialInfo) ClientOption {
 
 // NewClient creates a new Cloudflare API client
 func NewClient(options ...ClientOption) (*Client, error) {
-	// Create optimized transport with connection pooling
+	// Create optimized transport with connection pooling. Idle connections per host match
+	// the connection limit, so bursts of small requests like metadata fetches reuse
+	// connections instead of closing and redialing them.
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second, // TCP keep-alive probes for pooled connections
+	}
 	transport := &http.Transport{
-		MaxIdleConns:        500,              // Increased pool size
-		MaxIdleConnsPerHost: 100,              // More connections per host
-		MaxConnsPerHost:     100,              // Limit concurrent connections
-		IdleConnTimeout:     90 * time.Second, // Keep connections alive longer
-		TLSHandshakeTimeout: 10 * time.Second,
-		DisableCompression:  true, // API responses are already compressed
-		ForceAttemptHTTP2:   true, // Enable HTTP/2 for multiplexing
+		Proxy:                 proxyFunc,         // HTTPS_PROXY and friends, or --proxy
+		TLSClientConfig:       tlsConfig.Clone(), // --ca-cert and mutual TLS, nil for the defaults
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          500,              // Increased pool size
+		MaxIdleConnsPerHost:   100,              // More connections per host
+		MaxConnsPerHost:       100,              // Limit concurrent connections
+		IdleConnTimeout:       90 * time.Second, // Keep connections alive longer
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableCompression:    true, // API responses are already compressed
+		ForceAttemptHTTP2:     true, // Enable HTTP/2 for multiplexing; needed with a custom dialer
 	}
 
 	// Create client with default values
 	client := &Client{
-		BaseURL: "https://api.cloudflare.com/client/v4",
+		BaseURL: defaultBaseURL,
 		HTTPClient: &http.Client{
-			Timeout:   300 * time.Second, // Increased from 30s to 300s to handle large operations
+			Timeout:   common.CurrentPolicy().Timeout, // 300s unless the run's policy says otherwise
 			Transport: transport,
 		},
 	}
@@ -73,15 +109,40 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		option(client)
 	}
 
+	// Make sure the base URL is usable before any request is built from it
+	if _, err := config.ValidateAPIEndpoint(client.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// If no credentials are provided, try to get them from environment
+	replayOnly := mockOffline
 	if client.Creds == nil {
 		creds, err := auth.GetCredentials()
 		if err != nil {
-			return nil, err
+			if mockDir == "" {
+				return nil, err
+			}
+			// Recordings can be replayed without credentials, e.g. in CI
+			creds = mockCredentials
+			replayOnly = true
 		}
 		client.Creds = creds
 	}
 
+	// Record and replay API responses in mock mode
+	if mockDir != "" {
+		var next http.RoundTripper = client.HTTPClient.Transport
+		if replayOnly {
+			next = nil
+		}
+		client.HTTPClient.Transport = NewReplayTransport(mockDir, next)
+	}
+
+	// Simulate slow and failing responses in builds with the faultinject tag
+	if wrapFaultTransport != nil {
+		client.HTTPClient.Transport = wrapFaultTransport(client.HTTPClient.Transport)
+	}
+
 	return client, nil
 }
 
@@ -94,6 +155,23 @@ func (c *Client) GetTransportStats() (idleConns int, totalConns int) {
 	return 0, 0
 }
 
+// encodeBody returns the request body and its content type. Byte slices, such as KV values,
+// are sent as they are; anything else is encoded as JSON.
+func encodeBody(body interface{}) (io.Reader, string, error) {
+	switch body := body.(type) {
+	case nil:
+		return nil, "application/json", nil
+	case []byte:
+		return bytes.NewReader(body), "application/octet-stream", nil
+	default:
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewBuffer(jsonBody), "application/json", nil
+	}
+}
+
 // Request makes a request to the Cloudflare API
 func (c *Client) Request(method, path string, query url.Values, body interface{}) ([]byte, error) {
 	// Determine endpoint for rate limiting
@@ -117,13 +195,9 @@ func (c *Client) Request(method, path string, query url.Values, body interface{}
 	}
 
 	// Create request body if provided
-	var reqBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		reqBody = bytes.NewBuffer(jsonBody)
+	reqBody, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request
@@ -133,7 +207,7 @@ func (c *Client) Request(method, path string, query url.Values, body interface{}
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
 	// Set authentication
@@ -148,7 +222,7 @@ func (c *Client) Request(method, path string, query url.Values, body interface{}
 	}
 
 	// Make request
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +236,9 @@ func (c *Client) Request(method, path string, query url.Values, body interface{}
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
-	respBody := buf.Bytes()
+	// The buffer goes back to the pool on return, so callers get a copy; coalesced
+	// metadata requests share one body between several callers
+	respBody := bytes.Clone(buf.Bytes())
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
@@ -178,9 +254,48 @@ func (c *Client) Request(method, path string, query url.Values, body interface{}
 		return nil, fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, errorMsg)
 	}
 
+	recordCompletedWork(method, path, body)
 	return respBody, nil
 }
 
+// Do sends a prepared HTTP request, holding a slot of the shared adaptive concurrency
+// limit while it is in flight and pacing it when the reported API quota runs low. Use it
+// instead of HTTPClient.Do for requests that bypass Request, such as raw value reads.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	// Once the run is interrupted, requests in flight finish but no new ones are sent
+	if common.Interrupted() {
+		return nil, common.ErrInterrupted
+	}
+
+	// Slow down when the API reported that little quota remains
+	if err := common.PaceForQuota(req.Context()); err != nil {
+		return nil, err
+	}
+
+	if err := common.AcquireRequestSlot(req.Context()); err != nil {
+		return nil, err
+	}
+	if common.Interrupted() {
+		common.ReleaseRequestSlot(0, false)
+		return nil, common.ErrInterrupted
+	}
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	elapsed := time.Since(start)
+	common.ReleaseRequestSlot(elapsed, err == nil && resp.StatusCode == http.StatusTooManyRequests)
+
+	if err != nil {
+		common.Logger().Debug("api request failed", "method", req.Method, "path", req.URL.Path, "duration", elapsed, "error", err)
+		return resp, err
+	}
+
+	common.Logger().Debug("api request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", elapsed)
+	common.RecordRateLimitHeaders(resp.Header)
+
+	return resp, err
+}
+
 // RequestWithContext makes a request with context support
 func (c *Client) RequestWithContext(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
 	// Determine endpoint for rate limiting
@@ -203,13 +318,9 @@ func (c *Client) RequestWithContext(ctx context.Context, method, path string, qu
 	}
 
 	// Create request body if provided
-	var reqBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		reqBody = bytes.NewBuffer(jsonBody)
+	reqBody, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request with context
@@ -219,7 +330,7 @@ func (c *Client) RequestWithContext(ctx context.Context, method, path string, qu
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
 	// Set authentication
@@ -234,7 +345,7 @@ func (c *Client) RequestWithContext(ctx context.Context, method, path string, qu
 	}
 
 	// Make request
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +359,9 @@ func (c *Client) RequestWithContext(ctx context.Context, method, path string, qu
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
-	respBody := buf.Bytes()
+	// The buffer goes back to the pool on return, so callers get a copy; coalesced
+	// metadata requests share one body between several callers
+	respBody := bytes.Clone(buf.Bytes())
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
@@ -273,9 +386,35 @@ func (c *Client) RequestWithContext(ctx context.Context, method, path string, qu
 		return nil, fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, errorMsg)
 	}
 
+	recordCompletedWork(method, path, body)
 	return respBody, nil
 }
 
+// recordCompletedWork counts the keys a successful KV write or delete request changed, so an
+// interrupted run can report them. Purges are recorded by the cache package.
+func recordCompletedWork(method, path string, body interface{}) {
+	if !strings.Contains(path, "/storage/kv/namespaces/") {
+		return
+	}
+
+	items := 1
+	if value := reflect.ValueOf(body); value.Kind() == reflect.Slice {
+		items = value.Len()
+	}
+
+	switch {
+	case method == http.MethodPost && strings.HasSuffix(path, "/bulk/delete"),
+		method == http.MethodDelete && strings.HasSuffix(path, "/bulk"):
+		common.RecordCompleted("keys deleted", items)
+	case method == http.MethodPut && strings.HasSuffix(path, "/bulk"):
+		common.RecordCompleted("keys written", items)
+	case method == http.MethodDelete && strings.Contains(path, "/values/"):
+		common.RecordCompleted("keys deleted", 1)
+	case method == http.MethodPut && strings.Contains(path, "/values/"):
+		common.RecordCompleted("keys written", 1)
+	}
+}
+
 // determineEndpoint determines the rate limit endpoint from the request
 func determineEndpoint(method, path string) string {
 	// Normalize path
@@ -24,15 +24,9 @@ func (c *Client) RequestWithRetry(ctx context.Context, method, path string, quer
 		return nil
 	}
 
-	// Use custom retry policy for API requests
+	// Retry API requests as the run's policy says
 	policy := &APIRetryPolicy{
-		config: &common.RetryConfig{
-			MaxAttempts:  5,
-			InitialDelay: 1 * time.Second,
-			MaxDelay:     30 * time.Second,
-			Multiplier:   2.0,
-			Jitter:       0.2,
-		},
+		config: common.CurrentPolicy().RetryConfig(),
 	}
 
 	err := common.Retry(ctx, retryFunc, policy)
@@ -110,9 +104,7 @@ func (p *APIRetryPolicy) NextDelay(attempt int) time.Duration {
 
 // RequestBatchWithRetry makes multiple requests with retry and manages concurrency
 func (c *Client) RequestBatchWithRetry(ctx context.Context, requests []BatchRequest, concurrency int) ([]BatchResponse, error) {
-	if concurrency <= 0 {
-		concurrency = 10
-	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
 
 	responses := make([]BatchResponse, len(requests))
 	semaphore := make(chan struct{}, concurrency)
@@ -2,6 +2,9 @@ package api
 
 import (
 	"cache-kv-purger/internal/auth"
+	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -95,6 +98,37 @@ func TestRequest(t *testing.T) {
 	}
 }
 
+func TestRequestBodyEncoding(t *testing.T) {
+	var gotBody, gotType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody, gotType = string(body), r.Header.Get("Content-Type")
+		fmt.Fprint(w, `{"success": true, "result": {}}`)
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	tests := []struct {
+		name     string
+		body     interface{}
+		wantBody string
+		wantType string
+	}{
+		{"byte slices are sent as they are", []byte("\x1f\x8b raw value"), "\x1f\x8b raw value", "application/octet-stream"},
+		{"other bodies are JSON", map[string]string{"key": "value"}, `{"key":"value"}`, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.RequestWithContext(context.Background(), http.MethodPut, "/values/key", nil, tt.body); err != nil {
+				t.Fatal(err)
+			}
+			if gotBody != tt.wantBody || gotType != tt.wantType {
+				t.Errorf("sent %q as %s, want %q as %s", gotBody, gotType, tt.wantBody, tt.wantType)
+			}
+		})
+	}
+}
+
 func TestURLBuilding(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -223,3 +257,41 @@ func TestConnectionPooling(t *testing.T) {
 		t.Errorf("Expected total connections stat to be 100, got %d", totalConns)
 	}
 }
+
+func TestClientBaseURL(t *testing.T) {
+	creds := WithCredentials(&auth.CredentialInfo{
+		Type: auth.AuthTypeAPIToken,
+		Key:  "test-token",
+	})
+
+	// Plain http is allowed for local mocks
+	if _, err := NewClient(WithBaseURL("http://localhost:8787/client/v4"), creds); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Invalid endpoints are rejected
+	for _, baseURL := range []string{"", "ftp://example.com", "api.cloudflare.com/client/v4", "https://example.com/v4?x=1"} {
+		if _, err := NewClient(WithBaseURL(baseURL), creds); err == nil {
+			t.Errorf("Expected error for base URL %q", baseURL)
+		}
+	}
+
+	// The default base URL applies to clients created without WithBaseURL
+	original := DefaultBaseURL()
+	defer func() { _ = SetDefaultBaseURL(original) }()
+
+	if err := SetDefaultBaseURL("https://gateway.example.com/cloudflare/client/v4/"); err != nil {
+		t.Fatalf("Failed to set default base URL: %v", err)
+	}
+	client, err := NewClient(creds)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.BaseURL != "https://gateway.example.com/cloudflare/client/v4" {
+		t.Errorf("Expected default base URL to be used, got %q", client.BaseURL)
+	}
+
+	if err := SetDefaultBaseURL("not a url"); err == nil {
+		t.Error("Expected error when setting an invalid default base URL")
+	}
+}
@@ -0,0 +1,115 @@
+//go:build faultinject
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FaultInjection makes clients simulate a slow or unreliable API, to load test retries,
+// checkpointing and adaptive concurrency. Only available in builds with the faultinject tag.
+type FaultInjection struct {
+	Latency   time.Duration // Added to every request, with up to 50% jitter either way
+	ErrorRate float64       // Share of requests failed with a 429 or 500, from 0 to 1
+}
+
+// Enabled returns true if the injection changes any response
+func (f FaultInjection) Enabled() bool {
+	return f.Latency > 0 || f.ErrorRate > 0
+}
+
+// faultInjection is applied to clients created after SetFaultInjection
+var faultInjection FaultInjection
+
+// SetFaultInjection makes clients created afterwards simulate latency and failures
+func SetFaultInjection(f FaultInjection) error {
+	if f.Latency < 0 {
+		return fmt.Errorf("injected latency must not be negative")
+	}
+	if f.ErrorRate < 0 || f.ErrorRate > 1 {
+		return fmt.Errorf("injected error rate must be between 0 and 1")
+	}
+	faultInjection = f
+	return nil
+}
+
+func init() {
+	wrapFaultTransport = func(next http.RoundTripper) http.RoundTripper {
+		if !faultInjection.Enabled() {
+			return next
+		}
+		return NewFaultTransport(next, faultInjection)
+	}
+}
+
+// FaultTransport is an http.RoundTripper that delays requests and fails a share of them
+// with the rate limit and server errors the API returns under load. Failed requests never
+// reach Next, so they change nothing.
+type FaultTransport struct {
+	Next   http.RoundTripper
+	Faults FaultInjection
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewFaultTransport creates a transport injecting faults in front of next
+func NewFaultTransport(next http.RoundTripper, faults FaultInjection) *FaultTransport {
+	return &FaultTransport{Next: next, Faults: faults, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// RoundTrip waits out the injected latency, then fails the request or sends it through Next
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	jitter := t.rand.Float64() + 0.5 // 0.5x to 1.5x the latency
+	roll := t.rand.Float64()
+	rateLimit := t.rand.Intn(2) == 0
+	t.mu.Unlock()
+
+	if t.Faults.Latency > 0 {
+		select {
+		case <-time.After(time.Duration(float64(t.Faults.Latency) * jitter)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if roll >= t.Faults.ErrorRate {
+		return t.Next.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if rateLimit {
+		return faultResponse(req, http.StatusTooManyRequests, 971, "Please wait and consider throttling your request speed (injected)",
+			http.Header{"Retry-After": []string{"1"}}), nil
+	}
+	return faultResponse(req, http.StatusInternalServerError, 10001, "Internal server error (injected)", nil), nil
+}
+
+// faultResponse builds an API error response with the given status
+func faultResponse(req *http.Request, status, code int, message string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	body := fmt.Sprintf(`{"success":false,"errors":[{"code":%d,"message":%q}],"messages":[],"result":null}`, code, message)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
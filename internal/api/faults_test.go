@@ -0,0 +1,63 @@
+//go:build faultinject
+
+package api
+
+import (
+	"cache-kv-purger/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFaultTransport(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	creds := &auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "token"}
+
+	// Every request fails without reaching the server
+	if err := SetFaultInjection(FaultInjection{ErrorRate: 1}); err != nil {
+		t.Fatalf("SetFaultInjection() error = %v", err)
+	}
+	defer SetFaultInjection(FaultInjection{})
+
+	client, err := NewClient(WithBaseURL(server.URL), WithCredentials(creds))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		_, err := client.Request(http.MethodGet, "/zones", nil, nil)
+		if err == nil || !(strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "500")) {
+			t.Fatalf("Request() error = %v, want an injected 429 or 500", err)
+		}
+	}
+	if hits != 0 {
+		t.Errorf("server got %d requests, want none", hits)
+	}
+
+	// Without faults requests go through
+	SetFaultInjection(FaultInjection{})
+	client, err = NewClient(WithBaseURL(server.URL), WithCredentials(creds))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Request(http.MethodGet, "/zones", nil, nil); err != nil || hits != 1 {
+		t.Errorf("Request() error = %v, hits = %d, want one successful request", err, hits)
+	}
+}
+
+func TestSetFaultInjectionValidation(t *testing.T) {
+	defer SetFaultInjection(FaultInjection{})
+	if err := SetFaultInjection(FaultInjection{ErrorRate: 1.5}); err == nil {
+		t.Error("expected an error for an error rate above 1")
+	}
+	if err := SetFaultInjection(FaultInjection{Latency: -1}); err == nil {
+		t.Error("expected an error for negative latency")
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cache-kv-purger/internal/auth"
+)
+
+// mockDir is the directory API responses are recorded to and replayed from; empty disables mock mode
+var mockDir string
+
+// mockOffline makes mock mode fail requests that have no recording instead of sending them
+var mockOffline bool
+
+// SetMockDir enables record/replay of API responses in dir for clients created afterwards,
+// e.g. from the --mock flag or CACHE_KV_MOCK. With offline set, requests without a
+// recording fail instead of reaching the API. An empty dir disables mock mode.
+func SetMockDir(dir string, offline bool) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create mock directory: %w", err)
+		}
+	}
+	mockDir = dir
+	mockOffline = offline
+	return nil
+}
+
+// MockDir returns the directory used for recorded API responses, or "" when mock mode is off
+func MockDir() string {
+	return mockDir
+}
+
+// mockCredentials stand in for real credentials when replaying offline
+var mockCredentials = &auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "mock"}
+
+// recordedResponse is the on-disk form of a recorded API response. Only the request line
+// is kept, never its headers, so credentials do not end up in the recordings.
+type recordedResponse struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// ReplayTransport is an http.RoundTripper that replays recorded responses from Dir and
+// records the responses of requests it has not seen through Next. Identical requests
+// made several times in a run are recorded in sequence, so a list that changes after a
+// delete replays the same way; once a sequence runs out, its last response is reused.
+type ReplayTransport struct {
+	Dir string
+	// Next sends requests that have no recording; nil replays only
+	Next http.RoundTripper
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewReplayTransport creates a transport recording to and replaying from dir
+func NewReplayTransport(dir string, next http.RoundTripper) *ReplayTransport {
+	return &ReplayTransport{Dir: dir, Next: next, calls: make(map[string]int)}
+}
+
+// RoundTrip replays the recorded response for the request or records a new one
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	key := recordingKey(req.Method, req.URL.String(), body)
+	t.mu.Lock()
+	seq := t.calls[key]
+	t.calls[key] = seq + 1
+	t.mu.Unlock()
+
+	if recorded, err := t.load(key, seq); err == nil {
+		return recorded.response(req), nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if t.Next == nil {
+		return nil, fmt.Errorf("mock: no recorded response for %s %s in %s", req.Method, req.URL.Redacted(), t.Dir)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	recorded := &recordedResponse{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(respBody),
+	}
+	if err := t.save(key, seq, recorded); err != nil {
+		return nil, err
+	}
+	return recorded.response(req), nil
+}
+
+// load reads the recording for the seq-th identical request, falling back to the last
+// recording of a shorter sequence
+func (t *ReplayTransport) load(key string, seq int) (*recordedResponse, error) {
+	var data []byte
+	var err error
+	for i := seq; i >= 0; i-- {
+		data, err = os.ReadFile(t.recordingPath(key, i))
+		if err == nil || !os.IsNotExist(err) {
+			break
+		}
+		if t.Next != nil {
+			// When recording, a missing entry is recorded rather than substituted
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded recordedResponse
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to parse recorded response %s: %w", t.recordingPath(key, seq), err)
+	}
+	return &recorded, nil
+}
+
+// save writes a recording for the seq-th identical request
+func (t *ReplayTransport) save(key string, seq int, recorded *recordedResponse) error {
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.recordingPath(key, seq), data, 0600); err != nil {
+		return fmt.Errorf("failed to write recorded response: %w", err)
+	}
+	return nil
+}
+
+// recordingPath returns the file holding the seq-th recording for a request key
+func (t *ReplayTransport) recordingPath(key string, seq int) string {
+	return filepath.Join(t.Dir, fmt.Sprintf("%s-%d.json", key, seq))
+}
+
+// recordingKey identifies a request by its method, URL and body
+func recordingKey(method, rawURL string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(rawURL))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// response builds an *http.Response for req from the recording
+func (r *recordedResponse) response(req *http.Request) *http.Response {
+	header := r.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"cache-kv-purger/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplayTransport(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"result":{"call":` + string(rune('0'+hits)) + `}}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := SetMockDir(dir, false); err != nil {
+		t.Fatalf("SetMockDir() error = %v", err)
+	}
+	defer SetMockDir("", false)
+
+	creds := &auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "secret-token"}
+	client, err := NewClient(WithBaseURL(server.URL), WithCredentials(creds))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Record two responses for the same request
+	// Request returns a pooled buffer, so copy each response before the next call
+	body, err := client.Request(http.MethodGet, "/zones", nil, nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	first := string(body)
+	body, err = client.Request(http.MethodGet, "/zones", nil, nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	second := string(body)
+	if hits != 2 || first == second {
+		t.Fatalf("expected two distinct recorded calls, got %d hits", hits)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 recordings, got %d", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("recording %s contains credentials", file)
+		}
+	}
+
+	// Replay offline in the same order, reusing the last response once the sequence runs out
+	if err := SetMockDir(dir, true); err != nil {
+		t.Fatalf("SetMockDir() error = %v", err)
+	}
+	client, err = NewClient(WithBaseURL(server.URL), WithCredentials(creds))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	for i, want := range []string{first, second, second} {
+		got, err := client.Request(http.MethodGet, "/zones", nil, nil)
+		if err != nil {
+			t.Fatalf("replay %d error = %v", i, err)
+		}
+		if string(got) != want {
+			t.Errorf("replay %d = %s, want %s", i, got, want)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("expected no requests while replaying, got %d hits", hits)
+	}
+
+	if _, err := client.Request(http.MethodGet, "/accounts", nil, nil); err == nil {
+		t.Error("expected an error for a request without a recording")
+	}
+}
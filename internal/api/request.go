@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Response is a Cloudflare API response whose result decodes into T
+type Response[T any] struct {
+	APIResponse
+	Result     T              `json:"result"`
+	ResultInfo PaginationInfo `json:"result_info"`
+}
+
+// DecodeResponse parses a Cloudflare API response into a typed Response. A response that
+// reports failure is returned along with an error formatted by FormatAPIError.
+func DecodeResponse[T any](respBody []byte) (*Response[T], error) {
+	var resp Response[T]
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	if !resp.Success {
+		return &resp, FormatAPIError(&resp.APIResponse)
+	}
+	return &resp, nil
+}
+
+// RequestJSON makes an API request and returns its typed result. GET requests are retried
+// with the API retry policy; other methods are sent once, since they may not be safe to
+// repeat.
+func RequestJSON[T any](ctx context.Context, c *Client, method, path string, query url.Values, body interface{}) (T, error) {
+	resp, err := requestResponse[T](ctx, c, method, path, query, body)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return resp.Result, nil
+}
+
+// requestResponse makes an API request and decodes the whole response
+func requestResponse[T any](ctx context.Context, c *Client, method, path string, query url.Values, body interface{}) (*Response[T], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var respBody []byte
+	var err error
+	if method == http.MethodGet {
+		respBody, err = c.RequestWithRetry(ctx, method, path, query, body)
+	} else {
+		respBody, err = c.RequestWithContext(ctx, method, path, query, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeResponse[T](respBody)
+}
+
+// Paginate fetches every page of a list endpoint and returns the results of all pages.
+// It follows result_info.cursor when the endpoint returns one, and otherwise the page
+// number until total_pages is reached. perPage sets the page size; 0 leaves it to the API.
+func Paginate[T any](ctx context.Context, c *Client, path string, query url.Values, perPage int) ([]T, error) {
+	var all []T
+	err := PaginateEach(ctx, c, path, query, perPage, func(page []T) error {
+		all = append(all, page...)
+		return nil
+	})
+	return all, err
+}
+
+// PaginateEach is Paginate for callers that handle one page at a time. Returning an
+// error from fn stops the listing with that error.
+func PaginateEach[T any](ctx context.Context, c *Client, path string, query url.Values, perPage int, fn func(page []T) error) error {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = append([]string(nil), values...)
+	}
+	if perPage > 0 {
+		params.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	page := 1
+	for {
+		resp, err := requestResponse[[]T](ctx, c, http.MethodGet, path, params, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp.Result); err != nil {
+			return err
+		}
+
+		info := resp.ResultInfo
+		switch {
+		case info.Cursor != "" && len(resp.Result) > 0:
+			params.Set("cursor", info.Cursor)
+		case info.Cursor == "" && info.TotalPages > page:
+			page++
+			params.Set("page", strconv.Itoa(page))
+		default:
+			return nil
+		}
+	}
+}
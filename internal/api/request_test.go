@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, `{"success": true, "result": {"id": "123", "name": "test"}}`)
+		case "/fail":
+			fmt.Fprint(w, `{"success": false, "errors": [{"code": 1003, "message": "Invalid request"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL)
+
+	type item struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	t.Run("Success response", func(t *testing.T) {
+		result, err := RequestJSON[item](context.Background(), client, http.MethodPost, "/ok", nil, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result.ID != "123" || result.Name != "test" {
+			t.Errorf("Expected item 123/test, got: %+v", result)
+		}
+	})
+
+	t.Run("API failure", func(t *testing.T) {
+		_, err := RequestJSON[item](context.Background(), client, http.MethodPost, "/fail", nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "Invalid request") {
+			t.Errorf("Expected the API error message, got: %v", err)
+		}
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		_, err := RequestJSON[item](context.Background(), client, http.MethodPost, "/missing", nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+			t.Errorf("Expected an HTTP 404 error, got: %v", err)
+		}
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	t.Run("Page numbers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("per_page"); got != "2" {
+				t.Errorf("Expected per_page 2, got %q", got)
+			}
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				page = "1"
+			}
+			fmt.Fprintf(w, `{"success": true, "result": ["%s-a", "%s-b"], "result_info": {"page": %s, "total_pages": 3}}`, page, page, page)
+		}))
+		defer server.Close()
+
+		items, err := Paginate[string](context.Background(), newTestClient(t, server.URL), "/items", nil, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := "1-a,1-b,2-a,2-b,3-a,3-b"
+		if got := strings.Join(items, ","); got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("cursor") {
+			case "":
+				fmt.Fprint(w, `{"success": true, "result": [1, 2], "result_info": {"cursor": "next"}}`)
+			case "next":
+				fmt.Fprint(w, `{"success": true, "result": [3], "result_info": {"cursor": ""}}`)
+			default:
+				t.Errorf("Unexpected cursor %q", r.URL.Query().Get("cursor"))
+			}
+		}))
+		defer server.Close()
+
+		items, err := Paginate[int](context.Background(), newTestClient(t, server.URL), "/items", nil, 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(items) != 3 || items[2] != 3 {
+			t.Errorf("Expected [1 2 3], got %v", items)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// tlsConfig is the TLS configuration of new clients, nil for Go's defaults
+var tlsConfig *tls.Config
+
+// proxyFunc picks the proxy of new clients' requests. It defaults to the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY environment variables.
+var proxyFunc = http.ProxyFromEnvironment
+
+// SetTLSFiles configures the TLS connections of clients created afterwards, e.g. from the
+// --ca-cert, --client-cert and --client-key flags. caCert is a PEM bundle of certificates
+// trusted in addition to the system roots, such as the CA of a TLS-intercepting egress
+// proxy. clientCert and clientKey are a PEM certificate and key presented for mutual TLS,
+// and must be given together. Empty paths restore the defaults.
+func SetTLSFiles(caCert, clientCert, clientKey string) error {
+	if (clientCert == "") != (clientKey == "") {
+		return fmt.Errorf("--client-cert and --client-key must be given together")
+	}
+	if caCert == "" && clientCert == "" {
+		tlsConfig = nil
+		return nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in CA bundle %s", caCert)
+		}
+		config.RootCAs = pool
+	}
+
+	if clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	tlsConfig = config
+	return nil
+}
+
+// SetProxyURL routes the requests of clients created afterwards through an HTTP or HTTPS
+// proxy, e.g. from the --proxy flag. An empty URL restores the proxy environment variables.
+func SetProxyURL(proxyURL string) error {
+	if proxyURL == "" {
+		proxyFunc = http.ProxyFromEnvironment
+		return nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid proxy URL '%s', use http://host:port or https://host:port", proxyURL)
+	}
+	proxyFunc = http.ProxyURL(parsed)
+	return nil
+}
+
+// NewTransport returns an HTTP transport with the proxy and TLS configuration of API
+// clients, for requests to other hosts such as release downloads
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:               proxyFunc,
+		TLSClientConfig:     tlsConfig.Clone(),
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"cache-kv-purger/internal/auth"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// resetTransportSettings restores the default TLS and proxy settings after a test
+func resetTransportSettings(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SetTLSFiles("", "", "")
+		_ = SetProxyURL("")
+	})
+}
+
+// writePEM writes a PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestClient returns a client for baseURL with a test token
+func newTestClient(t *testing.T, baseURL string) *Client {
+	t.Helper()
+	client, err := NewClient(
+		WithBaseURL(baseURL),
+		WithCredentials(&auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "test-token"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"success": true}`))
+}
+
+func TestCustomCABundle(t *testing.T) {
+	resetTransportSettings(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	// The test server's certificate isn't trusted by default
+	if _, err := newTestClient(t, server.URL).Request("GET", "/zones", nil, nil); err == nil {
+		t.Fatal("expected a certificate error without the CA bundle")
+	}
+
+	caPath := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	if err := SetTLSFiles(caPath, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestClient(t, server.URL).Request("GET", "/zones", nil, nil); err != nil {
+		t.Fatalf("request with the CA bundle failed: %v", err)
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	resetTransportSettings(t)
+
+	var presented int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = len(r.TLS.PeerCertificates)
+		okHandler(w, r)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// Self-signed client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	caPath := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	certPath := writePEM(t, dir, "client.pem", "CERTIFICATE", certDER)
+	keyPath := writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+
+	if err := SetTLSFiles(caPath, certPath, keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestClient(t, server.URL).Request("GET", "/zones", nil, nil); err != nil {
+		t.Fatalf("request with a client certificate failed: %v", err)
+	}
+	if presented != 1 {
+		t.Errorf("expected the client certificate to be presented, got %d certificates", presented)
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	resetTransportSettings(t)
+
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		okHandler(w, r)
+	}))
+	defer proxy.Close()
+
+	if err := SetProxyURL(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestClient(t, "http://api.example.invalid/client/v4").Request("GET", "/zones", nil, nil); err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	if proxiedHost != "api.example.invalid" {
+		t.Errorf("expected the request for api.example.invalid to reach the proxy, got host %q", proxiedHost)
+	}
+}
+
+func TestTransportSettingErrors(t *testing.T) {
+	resetTransportSettings(t)
+
+	if err := SetTLSFiles("", "client.pem", ""); err == nil {
+		t.Error("expected an error for a client certificate without a key")
+	}
+	if err := SetTLSFiles(filepath.Join(t.TempDir(), "missing.pem"), "", ""); err == nil {
+		t.Error("expected an error for a missing CA bundle")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTLSFiles(empty, "", ""); err == nil {
+		t.Error("expected an error for a CA bundle without certificates")
+	}
+
+	for _, proxyURL := range []string{"socks5://proxy:1080", "proxy:8080", "http://"} {
+		if err := SetProxyURL(proxyURL); err == nil {
+			t.Errorf("expected an error for proxy URL %q", proxyURL)
+		}
+	}
+}
@@ -31,11 +31,19 @@ type PaginatedResponse struct {
 
 // Zone represents a Cloudflare zone
 type Zone struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Status      string   `json:"status"`
-	NameServers []string `json:"name_servers,omitempty"`
-	Type        string   `json:"type,omitempty"`
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	NameServers []string  `json:"name_servers,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Plan        *ZonePlan `json:"plan,omitempty"`
+}
+
+// ZonePlan is the plan a zone is subscribed to
+type ZonePlan struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`                // e.g. "Enterprise Website"
+	LegacyID string `json:"legacy_id,omitempty"` // e.g. "free", "pro", "business", "enterprise"
 }
 
 // ZonesResponse represents the response from a zones list request
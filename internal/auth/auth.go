@@ -11,6 +11,12 @@ const (
 	EnvAPIKey   = "CLOUDFLARE_API_KEY"
 	EnvAPIEmail = "CLOUDFLARE_EMAIL"
 	EnvAPIToken = "CLOUDFLARE_API_TOKEN"
+
+	// Environment variables for a second set of credentials, used as the
+	// destination of cross-account operations
+	EnvDestAPIKey   = "CLOUDFLARE_DEST_API_KEY"
+	EnvDestAPIEmail = "CLOUDFLARE_DEST_EMAIL"
+	EnvDestAPIToken = "CLOUDFLARE_DEST_API_TOKEN"
 )
 
 // Errors related to authentication
@@ -61,6 +67,32 @@ func GetCredentials() (*CredentialInfo, error) {
 	return nil, ErrNoCredentials
 }
 
+// GetDestinationCredentials extracts the destination credentials for cross-account operations.
+// Returns nil without an error if no destination credentials are configured, in which case
+// the primary credentials should be used.
+func GetDestinationCredentials() (*CredentialInfo, error) {
+	if token := os.Getenv(EnvDestAPIToken); token != "" {
+		return &CredentialInfo{
+			Type: AuthTypeAPIToken,
+			Key:  token,
+		}, nil
+	}
+
+	if key := os.Getenv(EnvDestAPIKey); key != "" {
+		email := os.Getenv(EnvDestAPIEmail)
+		if email == "" {
+			return nil, errors.New("destination API key authentication requires an email, set CLOUDFLARE_DEST_EMAIL environment variable")
+		}
+		return &CredentialInfo{
+			Type:  AuthTypeAPIKey,
+			Email: email,
+			Key:   key,
+		}, nil
+	}
+
+	return nil, nil
+}
+
 // CheckTokenScope validates if a token error might be due to insufficient permissions
 func CheckTokenScope(errorMsg string) string {
 	if strings.Contains(strings.ToLower(errorMsg), "token not authorized") {
@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/common"
+)
+
+const (
+	// MaxPurgeBatchSize is the most items the API accepts in one purge request
+	MaxPurgeBatchSize = 100
+
+	// DefaultPurgeConcurrency is the number of batches purged at once when none is set
+	DefaultPurgeConcurrency = 10
+
+	// MaxPurgeConcurrency caps batch concurrency at the enterprise tier's request rate
+	MaxPurgeConcurrency = 50
+
+	// DefaultZoneConcurrency is the number of zones purged at once when none is set
+	DefaultZoneConcurrency = 3
+)
+
+// PurgeBatchOptions configures how the batch purge functions split and send their work.
+// Zero values select the defaults, so callers only set what they need.
+type PurgeBatchOptions struct {
+	BatchSize       int           // Items per purge request, capped at MaxPurgeBatchSize
+	Concurrency     int           // Batches purged at once per zone (default DefaultPurgeConcurrency)
+	ZoneConcurrency int           // Zones purged at once by the multi-zone functions (default DefaultZoneConcurrency)
+	Retries         int           // Extra attempts for a batch whose purge request fails
+	Pacing          time.Duration // Minimum time between the batch starts of a zone, on top of the purge rate
+	DryRun          bool          // Count batches as purged without calling the API
+
+	// Progress is called as the batches of a zone complete
+	Progress func(completed, total, successful int)
+
+	// ZoneProgress is called by the multi-zone functions as the batches of each zone complete
+	ZoneProgress func(zoneID string, completed, total, successful int)
+}
+
+// PurgeBatchOption sets one field of PurgeBatchOptions
+type PurgeBatchOption func(*PurgeBatchOptions)
+
+// NewPurgeBatchOptions builds batch options from the defaults and the given options
+func NewPurgeBatchOptions(opts ...PurgeBatchOption) PurgeBatchOptions {
+	var options PurgeBatchOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithBatchSize sets the number of items per purge request
+func WithBatchSize(size int) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.BatchSize = size }
+}
+
+// WithConcurrency sets the number of batches purged at once per zone
+func WithConcurrency(concurrency int) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.Concurrency = concurrency }
+}
+
+// WithZoneConcurrency sets the number of zones purged at once by the multi-zone functions
+func WithZoneConcurrency(concurrency int) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.ZoneConcurrency = concurrency }
+}
+
+// WithRetries sets the extra attempts for a batch whose purge request fails
+func WithRetries(retries int) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.Retries = retries }
+}
+
+// WithPacing sets the minimum time between the batch starts of a zone
+func WithPacing(interval time.Duration) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.Pacing = interval }
+}
+
+// WithDryRun counts batches as purged without calling the API
+func WithDryRun(dryRun bool) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.DryRun = dryRun }
+}
+
+// WithProgress sets the callback for the batches of a zone completing
+func WithProgress(progress func(completed, total, successful int)) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.Progress = progress }
+}
+
+// WithZoneProgress sets the per-zone callback of the multi-zone functions
+func WithZoneProgress(progress func(zoneID string, completed, total, successful int)) PurgeBatchOption {
+	return func(o *PurgeBatchOptions) { o.ZoneProgress = progress }
+}
+
+// normalized returns the options with defaults filled in and limits applied
+func (o PurgeBatchOptions) normalized() PurgeBatchOptions {
+	if o.BatchSize <= 0 || o.BatchSize > MaxPurgeBatchSize {
+		o.BatchSize = MaxPurgeBatchSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultPurgeConcurrency
+	}
+	if o.Concurrency > MaxPurgeConcurrency {
+		o.Concurrency = MaxPurgeConcurrency
+	}
+	if o.ZoneConcurrency <= 0 {
+		o.ZoneConcurrency = DefaultZoneConcurrency
+	}
+	if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Progress == nil {
+		o.Progress = func(completed, total, successful int) {}
+	}
+	return o
+}
+
+// forZone returns the options for one zone of a multi-zone purge, reporting progress
+// through ZoneProgress
+func (o PurgeBatchOptions) forZone(zoneID string) PurgeBatchOptions {
+	o.Progress = nil
+	if o.ZoneProgress != nil {
+		zoneProgress := o.ZoneProgress
+		o.Progress = func(completed, total, successful int) {
+			zoneProgress(zoneID, completed, total, successful)
+		}
+	}
+	return o
+}
+
+// purgeInBatches splits items into batches and purges them concurrently with purge,
+// retrying failed batches. Returns the items of the batches that were purged and the
+// errors of those that were not.
+func purgeInBatches[T any](items []T, options PurgeBatchOptions, purge func(batch []T) error) ([]T, []error) {
+	options = options.normalized()
+
+	var batches [][]T
+	for i := 0; i < len(items); i += options.BatchSize {
+		batches = append(batches, items[i:min(i+options.BatchSize, len(items))])
+	}
+
+	type batchResult struct {
+		items []T
+		err   error
+	}
+	resultChan := make(chan batchResult, len(batches))
+
+	// A semaphore limits concurrent batches; a ticker spaces out their starts when paced
+	sem := make(chan struct{}, options.Concurrency)
+	var tick <-chan time.Time
+	if options.Pacing > 0 {
+		ticker := time.NewTicker(options.Pacing)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for i, batch := range batches {
+		if tick != nil && i > 0 {
+			<-tick
+		}
+		sem <- struct{}{}
+
+		go func(index int, batch []T) {
+			defer func() { <-sem }()
+
+			if options.DryRun {
+				resultChan <- batchResult{items: batch}
+				return
+			}
+
+			var err error
+			for attempt := 0; attempt <= options.Retries; attempt++ {
+				if attempt > 0 {
+					common.LogVerbose("Retrying batch %d (attempt %d of %d): %v", index+1, attempt+1, options.Retries+1, err)
+					time.Sleep(common.CurrentPolicy().RetryDelay(attempt))
+				}
+				if err = purge(batch); err == nil {
+					break
+				}
+			}
+			if err != nil {
+				resultChan <- batchResult{err: fmt.Errorf("batch %d failed: %w", index+1, err)}
+				return
+			}
+			resultChan <- batchResult{items: batch}
+		}(i, batch)
+	}
+
+	successful := make([]T, 0, len(items))
+	var errors []error
+	for completed := 1; completed <= len(batches); completed++ {
+		result := <-resultChan
+		if result.err != nil {
+			errors = append(errors, result.err)
+		} else {
+			successful = append(successful, result.items...)
+		}
+		options.Progress(completed, len(batches), len(successful))
+	}
+
+	return successful, errors
+}
+
+// zoneBatchResult is the outcome of purging the items of one zone
+type zoneBatchResult[T any] struct {
+	zoneID     string
+	successful []T
+	errors     []error
+}
+
+// purgeZonesInBatches runs purgeZone for each zone, working on up to ZoneConcurrency
+// zones at once. Results are returned in zone ID order.
+func purgeZonesInBatches[T any](zoneIDs []string, options PurgeBatchOptions,
+	purgeZone func(zoneID string, options PurgeBatchOptions) ([]T, []error)) []zoneBatchResult[T] {
+
+	options = options.normalized()
+
+	sorted := append([]string(nil), zoneIDs...)
+	sort.Strings(sorted)
+
+	results := make([]zoneBatchResult[T], len(sorted))
+	sem := make(chan struct{}, options.ZoneConcurrency)
+	var wg sync.WaitGroup
+
+	for i, zoneID := range sorted {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, zoneID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			successful, errors := purgeZone(zoneID, options.forZone(zoneID))
+			results[i] = zoneBatchResult[T]{zoneID: zoneID, successful: successful, errors: errors}
+		}(i, zoneID)
+	}
+	wg.Wait()
+
+	return results
+}
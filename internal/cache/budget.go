@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultDailyFileLimit is the daily single-file purge limit assumed when no
+// --budget is given; it is only used to warn, never to stop a purge
+const DefaultDailyFileLimit = 30000
+
+// budgetWarningRatio is the share of the daily limit at which a warning is shown
+const budgetWarningRatio = 0.8
+
+// budgetFileName is the name of the local purge counter file in the home directory
+const budgetFileName = ".cache-kv-purger-purge-budget.json"
+
+// FileBudget tracks how many URLs were purged per zone on the current UTC day.
+// The counter is local, so purges made from other machines or the dashboard are not seen.
+type FileBudget struct {
+	Date  string         `json:"date"`  // UTC day the counts belong to, as YYYY-MM-DD
+	Zones map[string]int `json:"zones"` // URLs purged per zone ID
+
+	path string
+}
+
+// LoadFileBudget loads today's purge counter, starting a fresh one on a new day
+func LoadFileBudget(path string) (*FileBudget, error) {
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.New("cannot determine home directory for purge budget file")
+		}
+		path = filepath.Join(homeDir, budgetFileName)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	budget := &FileBudget{Date: today, Zones: make(map[string]int), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return budget, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read purge budget file: %w", err)
+	}
+
+	var stored FileBudget
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse purge budget file: %w", err)
+	}
+
+	// Counts from a previous day no longer apply
+	if stored.Date == today && stored.Zones != nil {
+		budget.Zones = stored.Zones
+	}
+
+	return budget, nil
+}
+
+// Used returns the number of URLs purged today for a zone
+func (b *FileBudget) Used(zoneID string) int {
+	return b.Zones[zoneID]
+}
+
+// Remaining returns how many more URLs may be purged today for a zone under the limit
+func (b *FileBudget) Remaining(zoneID string, limit int) int {
+	remaining := limit - b.Used(zoneID)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// NearLimit returns true once a zone has used most of its daily limit
+func (b *FileBudget) NearLimit(zoneID string, limit int) bool {
+	return limit > 0 && float64(b.Used(zoneID)) >= budgetWarningRatio*float64(limit)
+}
+
+// Record adds purged URLs to a zone's count and saves the counter
+func (b *FileBudget) Record(zoneID string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+	b.Zones[zoneID] += count
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(b.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save purge budget file: %w", err)
+	}
+	return nil
+}
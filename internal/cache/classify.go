@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PurgeItemKind is the purge type a line of a mixed purge list is purged with
+type PurgeItemKind string
+
+const (
+	// PurgeItemFile is a full URL, purged by file
+	PurgeItemFile PurgeItemKind = "file"
+
+	// PurgeItemPrefix is a host with a path, purged by prefix
+	PurgeItemPrefix PurgeItemKind = "prefix"
+
+	// PurgeItemHost is a bare hostname, purged by host
+	PurgeItemHost PurgeItemKind = "host"
+)
+
+// ClassifyPurgeItem decides how a line of a mixed purge list is purged: a URL with an
+// http or https scheme is a file, a host followed by a path is a prefix, and anything
+// else is a hostname. It returns the item as it should be sent to the API, with the
+// host lowercased, along with the host used to find the item's zone.
+func ClassifyPurgeItem(item string) (PurgeItemKind, string, string, error) {
+	item = strings.TrimSpace(item)
+	if item == "" || strings.ContainsAny(item, " \t") {
+		return "", "", "", fmt.Errorf("invalid purge item '%s'", item)
+	}
+
+	if isHTTPURL(item) {
+		parsed, err := url.Parse(item)
+		if err != nil || parsed.Hostname() == "" {
+			return "", "", "", fmt.Errorf("invalid URL '%s'", item)
+		}
+		return PurgeItemFile, strings.ToLower(parsed.Hostname()), item, nil
+	}
+	if strings.Contains(item, "://") {
+		return "", "", "", fmt.Errorf("unsupported scheme in '%s', use http or https URLs", item)
+	}
+
+	// Prefixes are sent without a scheme, as host/path
+	host, path, hasPath := strings.Cut(item, "/")
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || strings.ContainsAny(host, ":?#") {
+		return "", "", "", fmt.Errorf("invalid hostname in '%s'", item)
+	}
+	if hasPath {
+		return PurgeItemPrefix, host, host + "/" + path, nil
+	}
+	return PurgeItemHost, host, host, nil
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// historyFileName is the name of the local purge history file in the home directory
+const historyFileName = ".cache-kv-purger-history.json"
+
+// MaxHistoryEntries is the number of purges kept in the history file
+const MaxHistoryEntries = 500
+
+// PurgeRecord is a purge request accepted by the API. The ID is the one Cloudflare
+// support asks for when investigating a purge.
+type PurgeRecord struct {
+	ID     string    `json:"id"`
+	ZoneID string    `json:"zone_id"`
+	Type   string    `json:"type"`  // everything, files, tags, hosts or prefixes
+	Items  int       `json:"items"` // Number of files, tags, hosts or prefixes in the request
+	Time   time.Time `json:"time"`
+}
+
+var (
+	sessionMu     sync.Mutex
+	sessionPurges []PurgeRecord
+)
+
+// recordPurge remembers a successful purge request for this run
+func recordPurge(zoneID string, options PurgeOptions, id string) {
+	record := PurgeRecord{ID: id, ZoneID: zoneID, Time: time.Now().UTC()}
+	switch {
+	case options.PurgeEverything:
+		record.Type = "everything"
+	case len(options.Tags) > 0:
+		record.Type, record.Items = "tags", len(options.Tags)
+	case len(options.Hosts) > 0:
+		record.Type, record.Items = "hosts", len(options.Hosts)
+	case len(options.Prefixes) > 0:
+		record.Type, record.Items = "prefixes", len(options.Prefixes)
+	default:
+		record.Type = "files"
+		switch files := options.Files.(type) {
+		case []string:
+			record.Items = len(files)
+		case []FileWithHeaders:
+			record.Items = len(files)
+		}
+	}
+
+	sessionMu.Lock()
+	sessionPurges = append(sessionPurges, record)
+	sessionMu.Unlock()
+}
+
+// SessionPurges returns the purge requests accepted during this run, oldest first
+func SessionPurges() []PurgeRecord {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+
+	records := make([]PurgeRecord, len(sessionPurges))
+	copy(records, sessionPurges)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records
+}
+
+// historyPath returns the history file path, defaulting to the home directory
+func historyPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("cannot determine home directory for purge history file")
+	}
+	return filepath.Join(homeDir, historyFileName), nil
+}
+
+// LoadHistory loads the purge history, oldest first. A missing file is an empty history.
+func LoadHistory(path string) ([]PurgeRecord, error) {
+	path, err := historyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read purge history file: %w", err)
+	}
+
+	var records []PurgeRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse purge history file: %w", err)
+	}
+	return records, nil
+}
+
+// AppendHistory adds records to the purge history, keeping the newest MaxHistoryEntries
+func AppendHistory(path string, records []PurgeRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	path, err := historyPath(path)
+	if err != nil {
+		return err
+	}
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		return err
+	}
+	history = append(history, records...)
+	if len(history) > MaxHistoryEntries {
+		history = history[len(history)-MaxHistoryEntries:]
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save purge history file: %w", err)
+	}
+	return nil
+}
+
+// ClearHistory removes the purge history file
+func ClearHistory(path string) error {
+	path, err := historyPath(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove purge history file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxKeySourceSize caps how much is read from a key source
+const maxKeySourceSize = 50 << 20
+
+// SourceKey is an identifier affected by a change, with metadata its cache tags can be
+// read from
+type SourceKey struct {
+	Key      string                 `json:"key"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// KeySource supplies the identifiers whose cache tags are purged, so purges can be driven
+// by a database export, a Worker in front of D1 or R2, or a script instead of KV
+type KeySource interface {
+	// Name describes the source in output, e.g. "stdin" or a file name
+	Name() string
+	// Keys reads the identifiers from the source
+	Keys(ctx context.Context) ([]SourceKey, error)
+}
+
+// NewKeySource returns the key source for spec: "-" for stdin, an http:// or https:// URL,
+// or the path of a CSV file (a "csv:" prefix reads any path as CSV)
+func NewKeySource(spec string, stdin io.Reader) (KeySource, error) {
+	switch {
+	case spec == "":
+		return nil, fmt.Errorf("key source is required")
+	case spec == "-" || spec == "stdin":
+		return &ReaderKeySource{Label: "stdin", Reader: stdin}, nil
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		return &HTTPKeySource{URL: spec}, nil
+	case strings.HasPrefix(spec, "csv:"):
+		return &CSVKeySource{Path: strings.TrimPrefix(spec, "csv:")}, nil
+	case strings.HasSuffix(strings.ToLower(spec), ".csv"):
+		return &CSVKeySource{Path: spec}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key source '%s': use '-' for stdin, an http(s) URL, or a .csv file", spec)
+	}
+}
+
+// ReaderKeySource reads identifiers from a reader, one per line or as JSON (see ParseKeyList)
+type ReaderKeySource struct {
+	Label  string
+	Reader io.Reader
+}
+
+// Name returns the label of the reader
+func (s *ReaderKeySource) Name() string {
+	return s.Label
+}
+
+// Keys reads and parses the whole reader
+func (s *ReaderKeySource) Keys(ctx context.Context) ([]SourceKey, error) {
+	data, err := io.ReadAll(io.LimitReader(s.Reader, maxKeySourceSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys from %s: %w", s.Label, err)
+	}
+	return ParseKeyList(data)
+}
+
+// HTTPKeySource fetches identifiers from an endpoint that returns them one per line or
+// as JSON (see ParseKeyList)
+type HTTPKeySource struct {
+	URL     string
+	Headers map[string]string
+	Timeout time.Duration // Default 30s
+}
+
+// Name returns the URL of the endpoint
+func (s *HTTPKeySource) Name() string {
+	return s.URL
+}
+
+// Keys fetches and parses the endpoint's response
+func (s *HTTPKeySource) Keys(ctx context.Context) ([]SourceKey, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key source URL: %w", err)
+	}
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keys from %s: %w", s.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch keys from %s: HTTP %d", s.URL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxKeySourceSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys from %s: %w", s.URL, err)
+	}
+	return ParseKeyList(data)
+}
+
+// CSVKeySource reads identifiers from a CSV file with a header row. The key is taken from
+// KeyColumn (default "key", or the first column if there is none); the other columns
+// become metadata, so cache tags can be read from them with tag extraction rules.
+type CSVKeySource struct {
+	Path      string
+	KeyColumn string
+}
+
+// Name returns the path of the file
+func (s *CSVKeySource) Name() string {
+	return s.Path
+}
+
+// Keys reads the rows of the file, skipping rows without a key
+func (s *CSVKeySource) Keys(ctx context.Context) ([]SourceKey, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key source: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []SourceKey{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header of %s: %w", s.Path, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	keyColumn := s.KeyColumn
+	if keyColumn == "" {
+		keyColumn = "key"
+	}
+	keyIndex := -1
+	for i, name := range header {
+		if strings.EqualFold(name, keyColumn) {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex < 0 {
+		if s.KeyColumn != "" {
+			return nil, fmt.Errorf("column '%s' not found in %s", s.KeyColumn, s.Path)
+		}
+		keyIndex = 0
+	}
+
+	keys := []SourceKey{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+		}
+		if keyIndex >= len(record) || strings.TrimSpace(record[keyIndex]) == "" {
+			continue
+		}
+
+		key := SourceKey{Key: strings.TrimSpace(record[keyIndex])}
+		for i, value := range record {
+			if i == keyIndex || i >= len(header) || value == "" {
+				continue
+			}
+			if key.Metadata == nil {
+				key.Metadata = make(map[string]interface{})
+			}
+			key.Metadata[header[i]] = value
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseKeyList parses a list of identifiers: a JSON array of strings or of objects with a
+// "key" field and optional "metadata", or plain text with one identifier per line. Blank
+// lines and lines starting with # are skipped.
+func ParseKeyList(data []byte) ([]SourceKey, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse key list: %w", err)
+		}
+
+		keys := make([]SourceKey, 0, len(raw))
+		for i, item := range raw {
+			var key SourceKey
+			if err := json.Unmarshal(item, &key.Key); err != nil {
+				if err := json.Unmarshal(item, &key); err != nil {
+					return nil, fmt.Errorf("failed to parse key list item %d: %w", i+1, err)
+				}
+			}
+			if key.Key == "" {
+				return nil, fmt.Errorf("key list item %d has no key", i+1)
+			}
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+
+	keys := []SourceKey{}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, SourceKey{Key: line})
+	}
+	return keys, nil
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"cache-kv-purger/internal/api"
+)
+
+// ZoneHostsResult is the outcome of purging the hosts of one zone
+type ZoneHostsResult struct {
+	ZoneID string
+	Hosts  []string
+	Purged []string
+	Errors []error
+}
+
+// OK returns true if every host of the zone was purged
+func (r ZoneHostsResult) OK() bool {
+	return len(r.Errors) == 0 && len(r.Purged) == len(r.Hosts)
+}
+
+// PurgeHostsByZone purges hosts grouped by zone ID, working on up to options.ZoneConcurrency
+// zones at once and purging each zone's hosts with PurgeHostsInBatches. options.ZoneProgress
+// is called per zone as its batches complete. Results are returned in zone ID order.
+func PurgeHostsByZone(client *api.Client, hostsByZone map[string][]string, options PurgeBatchOptions) []ZoneHostsResult {
+	zoneIDs := make([]string, 0, len(hostsByZone))
+	for zoneID := range hostsByZone {
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+
+	zoneResults := purgeZonesInBatches(zoneIDs, options, func(zoneID string, options PurgeBatchOptions) ([]string, []error) {
+		return PurgeHostsInBatches(client, zoneID, hostsByZone[zoneID], options)
+	})
+
+	results := make([]ZoneHostsResult, len(zoneResults))
+	for i, result := range zoneResults {
+		results[i] = ZoneHostsResult{
+			ZoneID: result.zoneID,
+			Hosts:  hostsByZone[result.zoneID],
+			Purged: result.successful,
+			Errors: result.errors,
+		}
+	}
+	return results
+}
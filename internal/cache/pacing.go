@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/common"
+)
+
+// PurgePacing is how much the purge rate of a zone delayed its purge calls
+type PurgePacing struct {
+	ZoneID  string
+	Delayed int           // Purge calls that had to wait
+	Waited  time.Duration // Total time those calls waited
+}
+
+// purgePacer spaces out the purge calls of each zone to a rate shared by every batch
+// function, whatever their concurrency. Each zone may make a minute's worth of calls at
+// once; later calls wait for their turn.
+type purgePacer struct {
+	mu    sync.Mutex
+	rate  float64 // Calls per second per zone, 0 for no pacing
+	burst float64
+	zones map[string]*zonePace
+}
+
+// zonePace is the token bucket and pacing statistics of one zone
+type zonePace struct {
+	tokens  float64
+	last    time.Time
+	delayed int
+	waited  time.Duration
+}
+
+var pacer = &purgePacer{zones: make(map[string]*zonePace)}
+
+// SetPurgeRate limits purge calls to callsPerMinute per zone. 0 turns pacing off.
+func SetPurgeRate(callsPerMinute int) {
+	pacer.mu.Lock()
+	defer pacer.mu.Unlock()
+
+	pacer.rate = float64(max(callsPerMinute, 0)) / 60
+	pacer.burst = float64(max(callsPerMinute, 1))
+	pacer.zones = make(map[string]*zonePace)
+}
+
+// PurgeRate returns the purge calls allowed per minute per zone, 0 when pacing is off
+func PurgeRate() int {
+	pacer.mu.Lock()
+	defer pacer.mu.Unlock()
+	return int(pacer.rate*60 + 0.5)
+}
+
+// PurgePacingStats returns the zones whose purge calls were delayed by the purge rate
+func PurgePacingStats() []PurgePacing {
+	pacer.mu.Lock()
+	defer pacer.mu.Unlock()
+
+	var stats []PurgePacing
+	for zoneID, zone := range pacer.zones {
+		if zone.delayed > 0 {
+			stats = append(stats, PurgePacing{ZoneID: zoneID, Delayed: zone.delayed, Waited: zone.waited})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ZoneID < stats[j].ZoneID })
+	return stats
+}
+
+// wait blocks until the zone may make another purge call
+func (p *purgePacer) wait(zoneID string) {
+	p.mu.Lock()
+	if p.rate == 0 {
+		p.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	zone, ok := p.zones[zoneID]
+	if !ok {
+		zone = &zonePace{tokens: p.burst, last: now}
+		p.zones[zoneID] = zone
+	}
+	zone.tokens = min(p.burst, zone.tokens+now.Sub(zone.last).Seconds()*p.rate)
+	zone.last = now
+
+	// Take a token, going into debt so concurrent callers queue up behind each other
+	zone.tokens--
+	if zone.tokens >= 0 {
+		p.mu.Unlock()
+		return
+	}
+	delay := time.Duration(-zone.tokens / p.rate * float64(time.Second))
+	zone.delayed++
+	zone.waited += delay
+	first := zone.delayed == 1
+	rate := int(p.rate*60 + 0.5)
+	p.mu.Unlock()
+
+	if first {
+		common.Logger().Warn(fmt.Sprintf("purge calls for zone %s are paced to %d per minute; remaining batches will wait", zoneID, rate))
+	}
+	common.LogVerbose("Waiting %s before the next purge call for zone %s", delay.Round(time.Millisecond), zoneID)
+	time.Sleep(delay)
+}
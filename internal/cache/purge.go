@@ -1,7 +1,6 @@
 package cache
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -35,13 +34,11 @@ type PurgeOptions struct {
 }
 
 // PurgeResponse represents the response from a cache purge request
-type PurgeResponse struct {
-	Success  bool        `json:"success"`
-	Errors   []api.Error `json:"errors"`
-	Messages []string    `json:"messages"`
-	Result   struct {
-		ID string `json:"id"`
-	} `json:"result"`
+type PurgeResponse = api.Response[PurgeResult]
+
+// PurgeResult is the result of a purge request
+type PurgeResult struct {
+	ID string `json:"id"`
 }
 
 // PurgeCache purges cache for a zone based on the provided options
@@ -72,6 +69,9 @@ func PurgeCache(client *api.Client, zoneID string, options PurgeOptions) (*Purge
 		return nil, fmt.Errorf("at least one purge parameter (purge_everything, files, tags, hosts, prefixes) must be specified")
 	}
 
+	// Wait for the zone's purge rate, shared by every batch in flight
+	pacer.wait(zoneID)
+
 	// Make the purge request
 	path := fmt.Sprintf("/zones/%s/purge_cache", zoneID)
 	respBody, err := client.Request(http.MethodPost, path, nil, options)
@@ -80,20 +80,13 @@ func PurgeCache(client *api.Client, zoneID string, options PurgeOptions) (*Purge
 	}
 
 	// Parse the response
-	var purgeResp PurgeResponse
-	if err := json.Unmarshal(respBody, &purgeResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !purgeResp.Success {
-		errorStr := "API reported failure"
-		if len(purgeResp.Errors) > 0 {
-			errorStr = purgeResp.Errors[0].Message
-		}
-		return nil, fmt.Errorf("cache purge failed: %s", errorStr)
+	purgeResp, err := api.DecodeResponse[PurgeResult](respBody)
+	if err != nil {
+		return nil, fmt.Errorf("cache purge failed: %w", err)
 	}
 
-	return &purgeResp, nil
+	recordPurge(zoneID, options, purgeResp.Result.ID)
+	return purgeResp, nil
 }
 
 // PurgeEverything purges all files from a zone
@@ -124,11 +117,8 @@ func PurgeFilesWithHeaders(client *api.Client, zoneID string, files []FileWithHe
 }
 
 // PurgeFilesWithHeadersInBatches purges files with custom headers in batches to comply with Cloudflare API limits
-// The batch size is set to 100 items per request (Cloudflare API limit)
-// The function takes a progressCallback that receives updates on completed/total batches
-func PurgeFilesWithHeadersInBatches(client *api.Client, zoneID string, files []FileWithHeaders,
-	progressCallback func(completed, total, successful int), concurrencyOverride int) ([]FileWithHeaders, []error) {
-
+// Batch size, concurrency, retries and progress reporting come from options
+func PurgeFilesWithHeadersInBatches(client *api.Client, zoneID string, files []FileWithHeaders, options PurgeBatchOptions) ([]FileWithHeaders, []error) {
 	if zoneID == "" {
 		return nil, []error{fmt.Errorf("zone ID is required")}
 	}
@@ -137,127 +127,16 @@ func PurgeFilesWithHeadersInBatches(client *api.Client, zoneID string, files []F
 		return nil, []error{fmt.Errorf("at least one file with headers is required")}
 	}
 
-	// Default batch size based on API limits
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Calculate number of batches (used for log progress updates)
-	_ = (len(files) + batchSize - 1) / batchSize
-
-	// Simple progress reporting if none provided
-	if progressCallback == nil {
-		progressCallback = func(completed, total, successful int) {}
-	}
-
-	// Create work items for all batches
-	type batchWork struct {
-		batchIndex int
-		batchItems []FileWithHeaders
-	}
-
-	var batches []batchWork
-	for i := 0; i < len(files); i += batchSize {
-		end := i + batchSize
-		if end > len(files) {
-			end = len(files)
-		}
-
-		batch := files[i:end]
-		batches = append(batches, batchWork{
-			batchIndex: i / batchSize,
-			batchItems: batch,
-		})
-	}
-
-	// Create a result channel for completed batches
-	type batchResult struct {
-		batchIndex int
-		batchItems []FileWithHeaders
-		err        error
-	}
-
-	resultChan := make(chan batchResult, len(batches))
-
-	// Set concurrency based on override or default
-	concurrency := 10 // Default concurrency
-	if concurrencyOverride > 0 {
-		concurrency = concurrencyOverride
-	}
-
-	// Cap concurrency based on account tier
-	if concurrency > 50 {
-		concurrency = 50 // Enterprise tier allows 50 requests per second
-	}
-
-	// Use a semaphore to limit concurrent goroutines
-	sem := make(chan struct{}, concurrency)
-
-	// Process all batches
-	for _, batch := range batches {
-		// Acquire semaphore slot (or wait if at capacity)
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this batch
-		go func(b batchWork) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Purge this batch of files with headers
-			_, err := PurgeFilesWithHeaders(client, zoneID, b.batchItems)
-
-			// Send result back through channel
-			if err != nil {
-				resultChan <- batchResult{
-					batchIndex: b.batchIndex,
-					batchItems: nil,
-					err:        fmt.Errorf("batch %d failed: %w", b.batchIndex+1, err),
-				}
-				return
-			}
-
-			resultChan <- batchResult{
-				batchIndex: b.batchIndex,
-				batchItems: b.batchItems,
-				err:        nil,
-			}
-		}(batch)
-	}
-
-	// Collect results
-	successful := make([]FileWithHeaders, 0)
-	var errors []error
-
-	// Track progress for callback
-	completed := 0
-	completedItems := 0
-
-	// Collect results from all batches
-	for i := 0; i < len(batches); i++ {
-		result := <-resultChan
-
-		// Save error or success
-		if result.err != nil {
-			errors = append(errors, result.err)
-		} else if result.batchItems != nil {
-			successful = append(successful, result.batchItems...)
-		}
-
-		// Update progress
-		completed++
-		if result.batchItems != nil {
-			completedItems += len(result.batchItems)
-		}
-
-		// Call progress callback
-		progressCallback(completed, len(batches), len(successful))
-	}
-
-	return successful, errors
+	return purgeInBatches(files, options, func(batch []FileWithHeaders) error {
+		_, err := PurgeFilesWithHeaders(client, zoneID, batch)
+		return err
+	})
 }
 
 // PurgeFilesWithHeadersAcrossZonesInBatches purges files with headers from multiple zones in batches
 // Useful for purging the same set of files across multiple zones
 func PurgeFilesWithHeadersAcrossZonesInBatches(client *api.Client, zoneIDs []string, files []FileWithHeaders,
-	progressCallback func(zoneIndex, totalZones, batchesDone, totalBatches, successful int),
-	concurrencyOverride int) (map[string][]FileWithHeaders, map[string][]error) {
+	options PurgeBatchOptions) (map[string][]FileWithHeaders, map[string][]error) {
 
 	if len(zoneIDs) == 0 {
 		return nil, map[string][]error{"error": {fmt.Errorf("at least one zone ID is required")}}
@@ -267,86 +146,16 @@ func PurgeFilesWithHeadersAcrossZonesInBatches(client *api.Client, zoneIDs []str
 		return nil, map[string][]error{"error": {fmt.Errorf("at least one file with headers is required")}}
 	}
 
-	// Simple progress reporting if none provided
-	if progressCallback == nil {
-		progressCallback = func(zoneIndex, totalZones, batchesDone, totalBatches, successfulCount int) {}
-	}
-
-	// Initialize results for each zone (don't need mutex as we're using a channel for results)
 	successfulByZone := make(map[string][]FileWithHeaders)
 	errorsByZone := make(map[string][]error)
 
-	// Default batch size
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Calculate total number of batches across all zones
-	batchesPerZone := (len(files) + batchSize - 1) / batchSize
-	totalBatches := batchesPerZone * len(zoneIDs)
-
-	// Create a result channel for completed zones (eliminates need for mutex)
-	type zoneResult struct {
-		zoneIndex  int
-		zoneID     string
-		successful []FileWithHeaders
-		errors     []error
-	}
-
-	resultChan := make(chan zoneResult, len(zoneIDs))
-
-	// Set concurrency based on override or default
-	concurrency := 3 // Default maximum number of zones to process concurrently
-	if concurrencyOverride > 0 {
-		concurrency = concurrencyOverride
-	}
-
-	// Use a semaphore to limit concurrent zone processing
-	sem := make(chan struct{}, concurrency)
-
-	// Process all zones
-	for i, zoneID := range zoneIDs {
-		// Acquire semaphore slot
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this zone
-		go func(idx int, zID string) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Counter for batches completed in this zone
-			zoneProgress := 0
-
-			// Create a zone-specific progress callback
-			zoneProgressCallback := func(batchCompleted, batchTotal, successfulCount int) {
-				// Update zone progress
-				zoneProgress = batchCompleted
-
-				// Call the parent progress callback
-				progressCallback(idx+1, len(zoneIDs),
-					(idx*batchesPerZone)+zoneProgress, // overall batches done
-					totalBatches, successfulCount)
-			}
-
-			// Purge files with headers for this zone
-			successful, errors := PurgeFilesWithHeadersInBatches(client, zID, files, zoneProgressCallback, concurrencyOverride)
-
-			// Send result back through channel
-			resultChan <- zoneResult{
-				zoneIndex:  idx,
-				zoneID:     zID,
-				successful: successful,
-				errors:     errors,
-			}
-		}(i, zoneID)
-	}
-
-	// Collect results from all zones
-	for i := 0; i < len(zoneIDs); i++ {
-		result := <-resultChan
-
-		// Store results for this zone
+	results := purgeZonesInBatches(zoneIDs, options, func(zoneID string, options PurgeBatchOptions) ([]FileWithHeaders, []error) {
+		return PurgeFilesWithHeadersInBatches(client, zoneID, files, options)
+	})
+	for _, result := range results {
 		if len(result.successful) > 0 {
 			successfulByZone[result.zoneID] = result.successful
 		}
-
 		if len(result.errors) > 0 {
 			errorsByZone[result.zoneID] = result.errors
 		}
@@ -373,9 +182,7 @@ func PurgeHosts(client *api.Client, zoneID string, hosts []string) (*PurgeRespon
 
 // PurgeHostsInBatches purges hosts in batches with concurrency support
 // This is optimized for purging a large number of hosts
-func PurgeHostsInBatches(client *api.Client, zoneID string, hosts []string,
-	progressCallback func(completed, total, successful int), concurrencyOverride int) ([]string, []error) {
-
+func PurgeHostsInBatches(client *api.Client, zoneID string, hosts []string, options PurgeBatchOptions) ([]string, []error) {
 	if zoneID == "" {
 		return nil, []error{fmt.Errorf("zone ID is required")}
 	}
@@ -384,113 +191,10 @@ func PurgeHostsInBatches(client *api.Client, zoneID string, hosts []string,
 		return nil, []error{fmt.Errorf("at least one host is required")}
 	}
 
-	// Define batch size based on API limits
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(completed, total, successful int) {}
-	}
-
-	// Create work items for all batches
-	type batchWork struct {
-		batchIndex int
-		batchItems []string
-	}
-
-	var batches []batchWork
-	for i := 0; i < len(hosts); i += batchSize {
-		end := i + batchSize
-		if end > len(hosts) {
-			end = len(hosts)
-		}
-
-		batch := hosts[i:end]
-		batches = append(batches, batchWork{
-			batchIndex: i / batchSize,
-			batchItems: batch,
-		})
-	}
-
-	// Create a result channel for completed batches
-	type batchResult struct {
-		batchIndex int
-		batchItems []string
-		err        error
-	}
-
-	resultChan := make(chan batchResult, len(batches))
-
-	// Set concurrency based on override or default
-	concurrency := 10 // Default concurrency
-	if concurrencyOverride > 0 {
-		concurrency = concurrencyOverride
-	}
-
-	// Cap concurrency based on account tier
-	if concurrency > 50 {
-		concurrency = 50 // Enterprise tier allows 50 requests per second
-	}
-
-	// Use a semaphore to limit concurrent goroutines
-	sem := make(chan struct{}, concurrency)
-
-	// Process all batches
-	for _, batch := range batches {
-		// Acquire semaphore slot (or wait if at capacity)
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this batch
-		go func(b batchWork) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Purge this batch of hosts
-			_, err := PurgeHosts(client, zoneID, b.batchItems)
-
-			// Send result back through channel
-			if err != nil {
-				resultChan <- batchResult{
-					batchIndex: b.batchIndex,
-					batchItems: nil,
-					err:        fmt.Errorf("batch %d failed: %w", b.batchIndex+1, err),
-				}
-				return
-			}
-
-			resultChan <- batchResult{
-				batchIndex: b.batchIndex,
-				batchItems: b.batchItems,
-				err:        nil,
-			}
-		}(batch)
-	}
-
-	// Collect results
-	successful := make([]string, 0)
-	var errors []error
-
-	// Track progress for callback
-	completed := 0
-
-	// Collect results from all batches
-	for i := 0; i < len(batches); i++ {
-		result := <-resultChan
-
-		// Save error or success
-		if result.err != nil {
-			errors = append(errors, result.err)
-		} else if result.batchItems != nil {
-			successful = append(successful, result.batchItems...)
-		}
-
-		// Update progress
-		completed++
-
-		// Call progress callback
-		progressCallback(completed, len(batches), len(successful))
-	}
-
-	return successful, errors
+	return purgeInBatches(hosts, options, func(batch []string) error {
+		_, err := PurgeHosts(client, zoneID, batch)
+		return err
+	})
 }
 
 // PurgePrefixes purges files with specific URI prefixes from a zone
@@ -503,9 +207,7 @@ func PurgePrefixes(client *api.Client, zoneID string, prefixes []string) (*Purge
 
 // PurgePrefixesInBatches purges prefixes in batches with concurrency support
 // This is optimized for purging a large number of prefixes
-func PurgePrefixesInBatches(client *api.Client, zoneID string, prefixes []string,
-	progressCallback func(completed, total, successful int), concurrencyOverride int) ([]string, []error) {
-
+func PurgePrefixesInBatches(client *api.Client, zoneID string, prefixes []string, options PurgeBatchOptions) ([]string, []error) {
 	if zoneID == "" {
 		return nil, []error{fmt.Errorf("zone ID is required")}
 	}
@@ -514,119 +216,15 @@ func PurgePrefixesInBatches(client *api.Client, zoneID string, prefixes []string
 		return nil, []error{fmt.Errorf("at least one prefix is required")}
 	}
 
-	// Define batch size based on API limits
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(completed, total, successful int) {}
-	}
-
-	// Create work items for all batches
-	type batchWork struct {
-		batchIndex int
-		batchItems []string
-	}
-
-	var batches []batchWork
-	for i := 0; i < len(prefixes); i += batchSize {
-		end := i + batchSize
-		if end > len(prefixes) {
-			end = len(prefixes)
-		}
-
-		batch := prefixes[i:end]
-		batches = append(batches, batchWork{
-			batchIndex: i / batchSize,
-			batchItems: batch,
-		})
-	}
-
-	// Create a result channel for completed batches
-	type batchResult struct {
-		batchIndex int
-		batchItems []string
-		err        error
-	}
-
-	resultChan := make(chan batchResult, len(batches))
-
-	// Set concurrency based on override or default
-	concurrency := 10 // Default concurrency
-	if concurrencyOverride > 0 {
-		concurrency = concurrencyOverride
-	}
-
-	// Cap concurrency based on account tier
-	if concurrency > 50 {
-		concurrency = 50 // Enterprise tier allows 50 requests per second
-	}
-
-	// Use a semaphore to limit concurrent goroutines
-	sem := make(chan struct{}, concurrency)
-
-	// Process all batches
-	for _, batch := range batches {
-		// Acquire semaphore slot (or wait if at capacity)
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this batch
-		go func(b batchWork) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Purge this batch of prefixes
-			_, err := PurgePrefixes(client, zoneID, b.batchItems)
-
-			// Send result back through channel
-			if err != nil {
-				resultChan <- batchResult{
-					batchIndex: b.batchIndex,
-					batchItems: nil,
-					err:        fmt.Errorf("batch %d failed: %w", b.batchIndex+1, err),
-				}
-				return
-			}
-
-			resultChan <- batchResult{
-				batchIndex: b.batchIndex,
-				batchItems: b.batchItems,
-				err:        nil,
-			}
-		}(batch)
-	}
-
-	// Collect results
-	successful := make([]string, 0)
-	var errors []error
-
-	// Track progress for callback
-	completed := 0
-
-	// Collect results from all batches
-	for i := 0; i < len(batches); i++ {
-		result := <-resultChan
-
-		// Save error or success
-		if result.err != nil {
-			errors = append(errors, result.err)
-		} else if result.batchItems != nil {
-			successful = append(successful, result.batchItems...)
-		}
-
-		// Update progress
-		completed++
-
-		// Call progress callback
-		progressCallback(completed, len(batches), len(successful))
-	}
-
-	return successful, errors
+	return purgeInBatches(prefixes, options, func(batch []string) error {
+		_, err := PurgePrefixes(client, zoneID, batch)
+		return err
+	})
 }
 
-// PurgeTagsInBatches purges tags in batches of 30 or fewer to comply with Cloudflare API limits
-// The function takes a progressCallback that receives updates on completed/total batches
-// This version uses concurrency for faster processing when handling many batches
-func PurgeTagsInBatches(client *api.Client, zoneID string, tags []string, progressCallback func(completed, total, successful int), concurrencyOverride int) ([]string, []error) {
+// PurgeTagsInBatches purges tags in batches to comply with Cloudflare API limits
+// Batches are purged concurrently, with batch size, retries and progress reporting from options
+func PurgeTagsInBatches(client *api.Client, zoneID string, tags []string, options PurgeBatchOptions) ([]string, []error) {
 	if zoneID == "" {
 		return nil, []error{fmt.Errorf("zone ID is required")}
 	}
@@ -635,123 +233,17 @@ func PurgeTagsInBatches(client *api.Client, zoneID string, tags []string, progre
 		return nil, []error{fmt.Errorf("at least one tag is required")}
 	}
 
-	// Define batch size based on API limits
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Calculate number of batches (this will be reflected in the length of the batches slice)
-
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(completed, total, successful int) {}
-	}
-
-	// Create work items for all batches
-	type batchWork struct {
-		batchIndex int
-		batchItems []string
-	}
-
-	var batches []batchWork
-	for i := 0; i < len(tags); i += batchSize {
-		end := i + batchSize
-		if end > len(tags) {
-			end = len(tags)
-		}
-
-		batch := tags[i:end]
-		batches = append(batches, batchWork{
-			batchIndex: i / batchSize,
-			batchItems: batch,
-		})
-	}
-
-	// Create a result channel for completed batches
-	type batchResult struct {
-		batchIndex int
-		batchItems []string
-		err        error
-	}
-
-	resultChan := make(chan batchResult, len(batches))
-
-	// Set concurrency based on override or default
-	concurrency := 10 // Default concurrency
-	if concurrencyOverride > 0 {
-		concurrency = concurrencyOverride
-	}
-
-	// Cap concurrency based on account tier
-	if concurrency > 50 {
-		concurrency = 50 // Enterprise tier allows 50 requests per second
-	}
-
-	// Use a semaphore to limit concurrent goroutines
-	sem := make(chan struct{}, concurrency)
-
-	// Process all batches
-	for _, batch := range batches {
-		// Acquire semaphore slot (or wait if at capacity)
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this batch
-		go func(b batchWork) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Purge this batch of tags
-			_, err := PurgeTags(client, zoneID, b.batchItems)
-
-			// Send result back through channel
-			if err != nil {
-				resultChan <- batchResult{
-					batchIndex: b.batchIndex,
-					batchItems: nil,
-					err:        fmt.Errorf("batch %d failed: %w", b.batchIndex+1, err),
-				}
-				return
-			}
-
-			resultChan <- batchResult{
-				batchIndex: b.batchIndex,
-				batchItems: b.batchItems,
-				err:        nil,
-			}
-		}(batch)
-	}
-
-	// Collect results
-	successful := make([]string, 0)
-	var errors []error
-
-	// Track progress for callback
-	completed := 0
-
-	// Collect results from all batches
-	for i := 0; i < len(batches); i++ {
-		result := <-resultChan
-
-		// Save error or success
-		if result.err != nil {
-			errors = append(errors, result.err)
-		} else if result.batchItems != nil {
-			successful = append(successful, result.batchItems...)
-		}
-
-		// Update progress
-		completed++
-
-		// Call progress callback
-		progressCallback(completed, len(batches), len(successful))
-	}
-
-	return successful, errors
+	return purgeInBatches(tags, options, func(batch []string) error {
+		_, err := PurgeTags(client, zoneID, batch)
+		return err
+	})
 }
 
 // PurgeTagsAcrossZonesInBatches purges tags from multiple zones in batches
 // Useful for purging the same set of tags across multiple zones
-// This version uses concurrency for both zone-level and batch-level processing
+// Zones are purged ZoneConcurrency at a time, and each zone's batches Concurrency at a time
 func PurgeTagsAcrossZonesInBatches(client *api.Client, zoneIDs []string, tags []string,
-	progressCallback func(zoneIndex, totalZones, batchesDone, totalBatches, successful int),
-	batchConcurrency, zoneConcurrency int) (map[string][]string, map[string][]error) {
+	options PurgeBatchOptions) (map[string][]string, map[string][]error) {
 
 	if len(zoneIDs) == 0 {
 		return nil, map[string][]error{"error": {fmt.Errorf("at least one zone ID is required")}}
@@ -761,86 +253,16 @@ func PurgeTagsAcrossZonesInBatches(client *api.Client, zoneIDs []string, tags []
 		return nil, map[string][]error{"error": {fmt.Errorf("at least one tag is required")}}
 	}
 
-	// Simple progress reporting if none provided
-	if progressCallback == nil {
-		progressCallback = func(zoneIndex, totalZones, batchesDone, totalBatches, successfulCount int) {}
-	}
-
-	// Initialize results for each zone (don't need mutex as we're using a channel for results)
 	successfulByZone := make(map[string][]string)
 	errorsByZone := make(map[string][]error)
 
-	// Default batch size
-	batchSize := 100 // API has a limit of 100 items per purge request
-
-	// Calculate total number of batches across all zones
-	batchesPerZone := (len(tags) + batchSize - 1) / batchSize
-	totalBatches := batchesPerZone * len(zoneIDs)
-
-	// Create a result channel for completed zones (eliminates need for mutex)
-	type zoneResult struct {
-		zoneIndex  int
-		zoneID     string
-		successful []string
-		errors     []error
-	}
-
-	resultChan := make(chan zoneResult, len(zoneIDs))
-
-	// Set concurrency based on override or default
-	concurrency := 3 // Default maximum number of zones to process concurrently
-	if zoneConcurrency > 0 {
-		concurrency = zoneConcurrency
-	}
-
-	// Use a semaphore to limit concurrent zone processing
-	sem := make(chan struct{}, concurrency)
-
-	// Process all zones
-	for i, zoneID := range zoneIDs {
-		// Acquire semaphore slot
-		sem <- struct{}{}
-
-		// Launch a goroutine to process this zone
-		go func(idx int, zID string) {
-			defer func() { <-sem }() // Release semaphore when done
-
-			// Counter for batches completed in this zone
-			zoneProgress := 0
-
-			// Create a zone-specific progress callback
-			zoneProgressCallback := func(batchCompleted, batchTotal, successfulCount int) {
-				// Update zone progress
-				zoneProgress = batchCompleted
-
-				// Call the parent progress callback
-				progressCallback(idx+1, len(zoneIDs),
-					(idx*batchesPerZone)+zoneProgress, // overall batches done
-					totalBatches, successfulCount)
-			}
-
-			// Purge tags for this zone
-			successful, errors := PurgeTagsInBatches(client, zID, tags, zoneProgressCallback, batchConcurrency)
-
-			// Send result back through channel
-			resultChan <- zoneResult{
-				zoneIndex:  idx,
-				zoneID:     zID,
-				successful: successful,
-				errors:     errors,
-			}
-		}(i, zoneID)
-	}
-
-	// Collect results from all zones
-	for i := 0; i < len(zoneIDs); i++ {
-		result := <-resultChan
-
-		// Store results for this zone
+	results := purgeZonesInBatches(zoneIDs, options, func(zoneID string, options PurgeBatchOptions) ([]string, []error) {
+		return PurgeTagsInBatches(client, zoneID, tags, options)
+	})
+	for _, result := range results {
 		if len(result.successful) > 0 {
 			successfulByZone[result.zoneID] = result.successful
 		}
-
 		if len(result.errors) > 0 {
 			errorsByZone[result.zoneID] = result.errors
 		}
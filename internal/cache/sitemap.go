@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// MaxSitemapDepth is how many levels of sitemap indexes are followed
+const MaxSitemapDepth = 5
+
+// maxSitemapSize caps the size of one sitemap, which the protocol limits to 50MB uncompressed
+const maxSitemapSize = 50 << 20
+
+// SitemapOptions configures sitemap fetching
+type SitemapOptions struct {
+	Timeout time.Duration // Timeout per sitemap request (default 30s)
+	MaxURLs int           // Stop with an error after this many URLs (0 for no limit)
+}
+
+// SitemapResult holds the page URLs found in a sitemap and its nested sitemaps
+type SitemapResult struct {
+	URLs     []string // Unique page URLs, in sitemap order
+	Sitemaps int      // Number of sitemaps read, including indexes
+}
+
+// sitemapDocument is a <urlset> or a <sitemapindex>
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLocation `xml:"url"`
+	Sitemaps []sitemapLocation `xml:"sitemap"`
+}
+
+type sitemapLocation struct {
+	Loc string `xml:"loc"`
+}
+
+// FetchSitemapURLs reads a sitemap from a URL or a local file and returns the page URLs
+// it lists. Sitemap indexes are followed up to MaxSitemapDepth levels, gzipped sitemaps
+// are decompressed, and relative locations are resolved against the sitemap's URL.
+func FetchSitemapURLs(location string, options SitemapOptions) (*SitemapResult, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	fetcher := &sitemapFetcher{
+		client:  &http.Client{Timeout: options.Timeout},
+		options: options,
+		visited: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+	if err := fetcher.fetch(location, 0); err != nil {
+		return nil, err
+	}
+	return &SitemapResult{URLs: fetcher.urls, Sitemaps: len(fetcher.visited)}, nil
+}
+
+// sitemapFetcher walks a sitemap and its nested sitemaps
+type sitemapFetcher struct {
+	client  *http.Client
+	options SitemapOptions
+	visited map[string]bool
+	seen    map[string]bool
+	urls    []string
+}
+
+func (f *sitemapFetcher) fetch(location string, depth int) error {
+	if f.visited[location] {
+		return nil
+	}
+	f.visited[location] = true
+
+	data, err := f.read(location)
+	if err != nil {
+		return err
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse sitemap %s: %w", location, err)
+	}
+
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, entry := range doc.URLs {
+			pageURL, err := resolveSitemapLocation(location, entry.Loc)
+			if err != nil || f.seen[pageURL] {
+				continue
+			}
+			f.seen[pageURL] = true
+			f.urls = append(f.urls, pageURL)
+			if f.options.MaxURLs > 0 && len(f.urls) > f.options.MaxURLs {
+				return fmt.Errorf("sitemap lists more than %d URLs", f.options.MaxURLs)
+			}
+		}
+	case "sitemapindex":
+		if depth >= MaxSitemapDepth {
+			return fmt.Errorf("sitemap index %s is nested more than %d levels deep", location, MaxSitemapDepth)
+		}
+		for _, entry := range doc.Sitemaps {
+			child, err := resolveSitemapLocation(location, entry.Loc)
+			if err != nil {
+				continue
+			}
+			if err := f.fetch(child, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s is not a sitemap: unexpected <%s> root element", location, doc.XMLName.Local)
+	}
+	return nil
+}
+
+// read returns the contents of a sitemap, decompressed if it is gzipped
+func (f *sitemapFetcher) read(location string) ([]byte, error) {
+	var body io.Reader
+	if isHTTPURL(location) {
+		resp, err := f.client.Get(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch sitemap %s: %w", location, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch sitemap %s: HTTP %d", location, resp.StatusCode)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sitemap: %w", err)
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap %s: %w", location, err)
+	}
+
+	// Servers may send .xml.gz sitemaps without a Content-Encoding, so check the content
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap %s: %w", location, err)
+		}
+		data, err = io.ReadAll(io.LimitReader(reader, maxSitemapSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap %s: %w", location, err)
+		}
+	}
+
+	if len(data) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap %s is larger than %dMB", location, maxSitemapSize>>20)
+	}
+	return data, nil
+}
+
+// resolveSitemapLocation returns the absolute URL of a <loc>, resolving relative ones
+// against the sitemap they appear in
+func resolveSitemapLocation(sitemap, loc string) (string, error) {
+	loc = strings.TrimSpace(loc)
+	if loc == "" {
+		return "", fmt.Errorf("empty location")
+	}
+
+	ref, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	if ref.IsAbs() {
+		return ref.String(), nil
+	}
+	if !isHTTPURL(sitemap) {
+		return "", fmt.Errorf("relative location %s in a local sitemap", loc)
+	}
+
+	base, err := url.Parse(sitemap)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func isHTTPURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// SitemapPrefixes groups page URLs into purge prefixes of the host and the first depth
+// path segments, such as "example.com/blog/" for depth 1. Pages with no more than depth
+// segments, such as the home page, are not under any such prefix and are returned as
+// files to purge by URL.
+func SitemapPrefixes(urls []string, depth int) (prefixes []string, files []string) {
+	if depth <= 0 {
+		depth = 1
+	}
+
+	seen := make(map[string]bool)
+	for _, pageURL := range urls {
+		parsed, err := url.Parse(pageURL)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+
+		segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(segments) <= depth || segments[0] == "" {
+			files = append(files, pageURL)
+			continue
+		}
+
+		prefix := strings.ToLower(parsed.Host) + "/" + strings.Join(segments[:depth], "/") + "/"
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes, files
+}
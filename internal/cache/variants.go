@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DeviceTypeHeader is the header Cloudflare varies the cache on for device types
+	DeviceTypeHeader = "CF-Device-Type"
+
+	// CountryHeader is the header Cloudflare varies the cache on for visitor countries
+	CountryHeader = "CF-IPCountry"
+)
+
+// deviceTypes are the values Cloudflare sets in CF-Device-Type
+var deviceTypes = map[string]bool{"mobile": true, "tablet": true, "desktop": true}
+
+// NormalizeDeviceTypes lowercases and dedupes device types, rejecting any that Cloudflare
+// doesn't cache separately
+func NormalizeDeviceTypes(values []string) ([]string, error) {
+	normalized := make([]string, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		if !deviceTypes[value] {
+			return nil, fmt.Errorf("unknown device type '%s', use mobile, tablet or desktop", value)
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	return normalized, nil
+}
+
+// NormalizeCountries uppercases and dedupes two-letter country codes as sent in
+// CF-IPCountry (including XX and T1)
+func NormalizeCountries(values []string) ([]string, error) {
+	normalized := make([]string, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		if len(value) != 2 || !isAlphanumeric(value) {
+			return nil, fmt.Errorf("invalid country code '%s', use two-letter codes such as US or DE", value)
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	return normalized, nil
+}
+
+// VariantCount returns how many purge entries each URL expands to for the given variants
+func VariantCount(deviceTypes, countries []string) int {
+	return max(len(deviceTypes), 1) * max(len(countries), 1)
+}
+
+// ExpandFileVariants turns each URL into one purge entry per combination of device type
+// and country, with the matching CF-Device-Type and CF-IPCountry headers. Without device
+// types or countries that header is left out, so with neither each URL is one entry.
+func ExpandFileVariants(urls []string, deviceTypes, countries []string) []FileWithHeaders {
+	devices := deviceTypes
+	if len(devices) == 0 {
+		devices = []string{""}
+	}
+	regions := countries
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	files := make([]FileWithHeaders, 0, len(urls)*len(devices)*len(regions))
+	for _, url := range urls {
+		for _, device := range devices {
+			for _, country := range regions {
+				headers := make(map[string]string, 2)
+				if device != "" {
+					headers[DeviceTypeHeader] = device
+				}
+				if country != "" {
+					headers[CountryHeader] = country
+				}
+				if len(headers) == 0 {
+					headers = nil
+				}
+				files = append(files, FileWithHeaders{URL: url, Headers: headers})
+			}
+		}
+	}
+	return files
+}
+
+// isAlphanumeric returns true if s only has ASCII letters and digits
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
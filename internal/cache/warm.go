@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStatusHeader is the response header in which Cloudflare reports how a request was served
+const CacheStatusHeader = "CF-Cache-Status"
+
+// deviceUserAgents are the User-Agent headers that select each device type when the zone
+// caches by device type
+var deviceUserAgents = map[string]string{
+	"desktop": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+	"mobile":  "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+	"tablet":  "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+}
+
+// WarmVariant is a set of request headers for one cached variant of each URL
+type WarmVariant struct {
+	Name    string
+	Headers map[string]string
+}
+
+// WarmOptions configures cache warming
+type WarmOptions struct {
+	Concurrency int               // Concurrent requests (default 20)
+	Timeout     time.Duration     // Timeout per request (default 30s)
+	Variants    []WarmVariant     // Header variants requested for every URL (default: one plain request)
+	Headers     map[string]string // Headers sent with every request
+}
+
+// WarmError is a URL that could not be fetched
+type WarmError struct {
+	URL     string `json:"url"`
+	Variant string `json:"variant,omitempty"`
+	Error   string `json:"error"`
+}
+
+// WarmResult summarizes a warming run
+type WarmResult struct {
+	Requests      int            `json:"requests"`
+	CacheStatuses map[string]int `json:"cache_statuses"` // CF-Cache-Status values, "NONE" when absent
+	HTTPStatuses  map[int]int    `json:"http_statuses"`
+	Errors        []WarmError    `json:"errors,omitempty"`
+	Duration      time.Duration  `json:"-"`
+}
+
+// BuildWarmVariants returns the variants for every combination of device type ("desktop",
+// "mobile" or "tablet") and Accept-Language value. Without either, a single plain variant
+// is returned.
+func BuildWarmVariants(devices, languages []string) ([]WarmVariant, error) {
+	if len(devices) == 0 {
+		devices = []string{""}
+	}
+	if len(languages) == 0 {
+		languages = []string{""}
+	}
+
+	var variants []WarmVariant
+	for _, device := range devices {
+		device = strings.ToLower(strings.TrimSpace(device))
+		userAgent, ok := deviceUserAgents[device]
+		if device != "" && !ok {
+			return nil, fmt.Errorf("unknown device type '%s' (use desktop, mobile or tablet)", device)
+		}
+
+		for _, language := range languages {
+			language = strings.TrimSpace(language)
+			variant := WarmVariant{Headers: make(map[string]string)}
+			var names []string
+			if device != "" {
+				variant.Headers["User-Agent"] = userAgent
+				names = append(names, device)
+			}
+			if language != "" {
+				variant.Headers["Accept-Language"] = language
+				names = append(names, language)
+			}
+			variant.Name = strings.Join(names, "/")
+			variants = append(variants, variant)
+		}
+	}
+	return variants, nil
+}
+
+// WarmURLs requests every URL once per variant so Cloudflare caches the responses again
+// after a purge, and counts the CF-Cache-Status of each response. Response bodies are read
+// in full, since an interrupted response may not be cached. The progress callback is
+// called after each request.
+func WarmURLs(urls []string, options WarmOptions, progressCallback func(completed, total int)) *WarmResult {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 20
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 30 * time.Second
+	}
+	if len(options.Variants) == 0 {
+		options.Variants = []WarmVariant{{}}
+	}
+
+	client := &http.Client{Timeout: options.Timeout}
+
+	type warmRequest struct {
+		url     string
+		variant WarmVariant
+	}
+	requests := make(chan warmRequest)
+	total := len(urls) * len(options.Variants)
+
+	result := &WarmResult{
+		CacheStatuses: make(map[string]int),
+		HTTPStatuses:  make(map[int]int),
+	}
+	var mu sync.Mutex
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range requests {
+				status, code, err := warmURL(client, r.url, options.Headers, r.variant.Headers)
+
+				mu.Lock()
+				result.Requests++
+				if err != nil {
+					result.Errors = append(result.Errors, WarmError{URL: r.url, Variant: r.variant.Name, Error: err.Error()})
+				} else {
+					result.CacheStatuses[status]++
+					result.HTTPStatuses[code]++
+				}
+				completed := result.Requests
+				mu.Unlock()
+
+				if progressCallback != nil {
+					progressCallback(completed, total)
+				}
+			}
+		}()
+	}
+
+	for _, url := range urls {
+		for _, variant := range options.Variants {
+			requests <- warmRequest{url: url, variant: variant}
+		}
+	}
+	close(requests)
+	wg.Wait()
+
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].URL < result.Errors[j].URL })
+	result.Duration = time.Since(start)
+	return result
+}
+
+// warmURL fetches a URL and returns its CF-Cache-Status and HTTP status code
+func warmURL(client *http.Client, url string, headers, variantHeaders map[string]string) (string, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range variantHeaders {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return "", resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	status := strings.ToUpper(resp.Header.Get(CacheStatusHeader))
+	if status == "" {
+		status = "NONE"
+	}
+	return status, resp.StatusCode, nil
+}
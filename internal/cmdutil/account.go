@@ -0,0 +1,60 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/zones"
+
+	"github.com/spf13/cobra"
+)
+
+// ResolveAccountID returns the account ID a command should use, or an empty string if
+// none is set. KV and cache commands share one order of precedence: an ID passed in by
+// the command, the --account-id flag, the CLOUDFLARE_ACCOUNT_ID environment variable,
+// then the account in the config file. cfg may be nil when no config could be loaded.
+func ResolveAccountID(cmd *cobra.Command, cfg *config.Config, providedID ...string) string {
+	if len(providedID) > 0 && providedID[0] != "" {
+		return providedID[0]
+	}
+	if cmd != nil {
+		if accountID, _ := cmd.Flags().GetString("account-id"); accountID != "" {
+			return accountID
+		}
+	}
+	if cfg == nil {
+		cfg = config.New()
+	}
+	return cfg.GetAccountID()
+}
+
+// RequireAccountID is ResolveAccountID for commands that cannot run without an account
+func RequireAccountID(cmd *cobra.Command, cfg *config.Config, providedID ...string) (string, error) {
+	accountID := ResolveAccountID(cmd, cfg, providedID...)
+	if accountID == "" {
+		return "", fmt.Errorf("account ID is required, specify it with --account-id flag, %s environment variable, or set a default account in config", config.EnvAccountID)
+	}
+	return accountID, nil
+}
+
+// ResolveZoneID resolves a zone ID or name to a zone ID, falling back to the default zone
+// from the environment or config when zone is empty. Names are looked up in accountID;
+// when no account is set and the lookup fails, the error says how to set one.
+func ResolveZoneID(client *api.Client, cfg *config.Config, accountID, zone string) (string, error) {
+	if zone == "" && cfg != nil {
+		zone = cfg.GetZoneID()
+	}
+	if zone == "" {
+		return "", fmt.Errorf("zone ID is required, specify it with --zone flag, %s environment variable, or set a default zone in config", config.EnvZoneID)
+	}
+
+	zoneID, err := zones.ResolveZoneIdentifier(client, accountID, zone)
+	if err != nil {
+		if accountID == "" {
+			return "", fmt.Errorf("failed to resolve zone '%s' without an account ID, specify one with --account-id or %s: %w", zone, config.EnvAccountID, err)
+		}
+		return "", fmt.Errorf("failed to resolve zone: %w", err)
+	}
+	return zoneID, nil
+}
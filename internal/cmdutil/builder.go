@@ -21,6 +21,8 @@ func NewCommand(use, short, long string) *CommandBuilder {
 		Long:  long,
 	}
 
+	self := cmd
+
 	// Add a pre-run hook that will:
 	// 1. Check if --help is present and prioritize it
 	// 2. Validate that flags have values if they're specified
@@ -53,10 +55,11 @@ func NewCommand(use, short, long string) *CommandBuilder {
 			return fmt.Errorf("the following flags require values: %s", strings.Join(missingValues, ", "))
 		}
 
-		// If parent has PreRunE, run it
-		if cmd.Parent() != nil && cmd.Parent().PersistentPreRunE != nil {
+		// If parent has PreRunE, run it. The parent is looked up from the command this hook
+		// belongs to, not the one being run, which is a grandchild when the hook is a parent's.
+		if parent := self.Parent(); parent != nil && parent.PersistentPreRunE != nil {
 			// We can't compare functions directly, but we can just run the parent's
-			return cmd.Parent().PersistentPreRunE(cmd, args)
+			return parent.PersistentPreRunE(cmd, args)
 		}
 
 		return nil
@@ -103,6 +106,13 @@ func (b *CommandBuilder) WithStringSliceFlag(name string, value []string, usage
 	return b
 }
 
+// WithStringArrayFlag adds a string array flag to the command; unlike a string slice,
+// values are not split on commas
+func (b *CommandBuilder) WithStringArrayFlag(name string, value []string, usage string, variable *[]string) *CommandBuilder {
+	b.cmd.Flags().StringArrayVar(variable, name, value, usage)
+	return b
+}
+
 // WithBoolFlag adds a boolean flag to the command
 func (b *CommandBuilder) WithBoolFlag(name string, value bool, usage string, variable *bool) *CommandBuilder {
 	b.cmd.Flags().BoolVar(variable, name, value, usage)
@@ -121,6 +131,12 @@ func (b *CommandBuilder) WithInt64Flag(name string, value int64, usage string, v
 	return b
 }
 
+// WithFloat64Flag adds a float64 flag to the command
+func (b *CommandBuilder) WithFloat64Flag(name string, value float64, usage string, variable *float64) *CommandBuilder {
+	b.cmd.Flags().Float64Var(variable, name, value, usage)
+	return b
+}
+
 // WithRequiredFlag marks a flag as required
 func (b *CommandBuilder) WithRequiredFlag(name string) *CommandBuilder {
 	_ = b.cmd.MarkFlagRequired(name)
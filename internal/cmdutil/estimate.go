@@ -0,0 +1,77 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+)
+
+// estimateOperation estimates an operation over the keys of a namespace with a prefix,
+// counting the keys from the first pages of the listing
+func estimateOperation(client *api.Client, accountID, namespaceID, prefix string, readsPerKey, writeBatchSize, concurrency int) (kv.CostEstimate, error) {
+	keys, exact, err := kv.CountKeys(client, accountID, namespaceID, prefix, kv.EstimateMaxPages)
+	if err != nil {
+		return kv.CostEstimate{}, fmt.Errorf("failed to count keys for the estimate: %w", err)
+	}
+	return kv.EstimateCost(keys, exact, readsPerKey, writeBatchSize, concurrency), nil
+}
+
+// printEstimate prints the expected API requests and duration of an operation
+func printEstimate(w io.Writer, operation string, estimate kv.CostEstimate) {
+	atLeast := ""
+	if !estimate.KeysExact {
+		atLeast = "at least "
+	}
+	fmt.Fprintf(w, "Estimate for %s: %s%d keys, %s%d API requests (%d list, %d read, %d write), about %s\n",
+		operation, atLeast, estimate.Keys, atLeast, estimate.APICalls(),
+		estimate.ListCalls, estimate.ReadCalls, estimate.WriteCalls, formatEstimateDuration(estimate.Duration))
+}
+
+// confirmEstimate prints the estimate of an operation and, when its API requests exceed
+// the configured threshold, asks whether to continue. It returns false if the operation
+// should not run. When the prompt cannot be shown on stdout, as for an export written
+// to stdout, an estimate above the threshold is an error instead.
+func confirmEstimate(cfg *config.Config, operation string, estimate kv.CostEstimate, force, stdoutFree bool) (bool, error) {
+	w := io.Writer(os.Stdout)
+	if !stdoutFree {
+		w = os.Stderr
+	}
+	printEstimate(w, operation, estimate)
+
+	threshold := config.DefaultEstimateThreshold
+	if cfg != nil {
+		threshold = cfg.GetEstimateThreshold()
+	}
+	if force || estimate.APICalls() <= threshold {
+		return true, nil
+	}
+
+	if !stdoutFree && !common.AssumeYes() {
+		return false, fmt.Errorf("the estimate exceeds the threshold of %d API requests (estimate_threshold in config) and cannot be confirmed while results go to stdout; set %s=1 to continue",
+			threshold, config.EnvAssumeYes)
+	}
+	if !common.ConfirmAction(fmt.Sprintf("The estimate exceeds the threshold of %d API requests (estimate_threshold in config). Continue?", threshold)) {
+		fmt.Fprintln(w, "Operation cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// formatEstimateDuration rounds an estimated duration for display
+func formatEstimateDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "1s"
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	default:
+		return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	}
+}
@@ -0,0 +1,48 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// FromExportUsage is the help of the --from-export flag of commands that can be planned offline
+const FromExportUsage = "Evaluate the filters against this 'kv export' file instead of the namespace and print the keys that would be affected; makes no API requests and changes nothing"
+
+// WithExportOrClient runs offline against the export named by the command's --from-export
+// flag, which needs no API credentials, and runs online otherwise
+func WithExportOrClient(offline func(*cobra.Command, []string, *config.Config, []kv.BulkWriteItem) error,
+	online func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("from-export")
+		if path == "" {
+			return online(cmd, args)
+		}
+
+		return WithConfig(func(cmd *cobra.Command, args []string, cfg *config.Config) error {
+			items, err := kv.ReadExportFile(path)
+			if err != nil {
+				return err
+			}
+			return offline(cmd, args, cfg, items)
+		})(cmd, args)
+	}
+}
+
+// PrintExportPlan prints the keys an operation would affect, as evaluated against an
+// export of total keys, one per line so the list can be reviewed or diffed
+func PrintExportPlan(path string, total int, action string, keys []string) {
+	fmt.Printf("Evaluated against export %s (%d keys); nothing was changed.\n", path, total)
+	if len(keys) == 0 {
+		fmt.Printf("No keys would be %s.\n", action)
+		return
+	}
+
+	fmt.Printf("%d keys would be %s:\n", len(keys), action)
+	for _, key := range keys {
+		fmt.Printf("  %s\n", key)
+	}
+}
@@ -0,0 +1,83 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// journalFlags holds the --journal and --journal-namespace flag values
+type journalFlags struct {
+	journal   bool
+	namespace string // Namespace name or ID to keep journals in instead of the local journal directory
+}
+
+// enabled returns true if keys should be journaled before they are changed
+func (f journalFlags) enabled() bool {
+	if f.journal || f.namespace != "" {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(config.EnvJournal))
+	return enabled
+}
+
+// journalStore returns the store journals are kept in: the journal namespace if one was
+// given, otherwise the local journal directory
+func journalStore(ctx context.Context, service kv.KVService, client *api.Client, accountID, namespace string) (kv.JournalStore, error) {
+	if namespace == "" {
+		return kv.NewLocalJournalStore("")
+	}
+
+	namespaceID, err := service.ResolveNamespaceID(ctx, accountID, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve journal namespace: %w", err)
+	}
+	return &kv.KVJournalStore{Client: client, AccountID: accountID, NamespaceID: namespaceID}, nil
+}
+
+// recordJournal saves the current state of keys about to be changed by operation and
+// prints how to undo it. An error means the keys must not be changed.
+func recordJournal(ctx context.Context, service kv.KVService, client *api.Client, flags journalFlags,
+	accountID, namespaceID, operation string, keys []string, concurrency int) error {
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	store, err := journalStore(ctx, service, client, accountID, flags.namespace)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Journaling %d keys before %s...\n", len(keys), operation)
+	journal, err := kv.CaptureJournal(client, accountID, namespaceID, operation, keys, concurrency)
+	if err != nil {
+		return fmt.Errorf("failed to journal keys: %w", err)
+	}
+	if err := store.Save(journal); err != nil {
+		return fmt.Errorf("failed to save journal: %w", err)
+	}
+
+	undo := "cache-kv-purger kv undo --journal-id " + journal.ID
+	if flags.namespace != "" {
+		undo += " --journal-namespace " + flags.namespace
+	}
+	fmt.Printf("Journal %s saved. To undo: %s\n", journal.ID, undo)
+	return nil
+}
+
+// JournalEnabled returns true if a command's --journal or --journal-namespace flag, or
+// the CACHE_KV_JOURNAL environment variable, asks for keys to be journaled
+func JournalEnabled(cmd *cobra.Command) bool {
+	var flags journalFlags
+	flags.journal, _ = cmd.Flags().GetBool("journal")
+	flags.namespace, _ = cmd.Flags().GetString("journal-namespace")
+	return flags.enabled()
+}
@@ -0,0 +1,216 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// maxApplyPlanKeys is how many keys of each kind of change the apply plan lists
+const maxApplyPlanKeys = 20
+
+// NewKVApplyCommand creates a new apply command for KV
+func NewKVApplyCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		file        string
+		prefix      string
+		prune       bool
+		approve     bool
+		force       bool
+		concurrency int
+		batchSize   int
+		outputJSON  bool
+		journal     journalFlags
+	}
+
+	// Create command
+	return NewCommand("apply", "Make a namespace match a desired set of keys", `
+Reconcile a namespace with a desired set of keys, values and metadata kept in a
+file, for example in git.
+
+The desired keys are compared with the namespace and a plan is printed: keys to
+create, keys whose value, metadata or expiration differ and will be updated, and,
+with --prune, keys not in the file that will be deleted. Values are only read for
+keys that exist on both sides, and only changed keys are written, so applying the
+same file twice changes nothing the second time.
+
+Without --approve (or --force) only the plan is shown. With --prefix only keys with
+that prefix are compared and pruned, so several files can manage parts of one
+namespace. The file has the format of 'kv put --bulk-file' and 'kv export'.
+Keys with an expiration_ttl are compared by value and metadata only.
+`).WithExample(`  # Show the plan
+  cache-kv-purger kv apply --namespace-id YOUR_NAMESPACE_ID --file desired.json
+
+  # Apply it, deleting keys that are not in the file
+  cache-kv-purger kv apply --namespace-id YOUR_NAMESPACE_ID --file desired.json --prune --approve
+
+  # Manage only the config/ keys, journaling changes so they can be undone
+  cache-kv-purger kv apply --namespace "My Namespace" --file config.json --prefix config/ --prune --approve --journal
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"file", "", "JSON file with the desired keys (same format as kv put --bulk-file)", &opts.file,
+	).WithStringFlag(
+		"prefix", "", "Only manage keys with this prefix", &opts.prefix,
+	).WithBoolFlag(
+		"prune", false, "Delete keys that are not in the file (only keys with --prefix when set)", &opts.prune,
+	).WithBoolFlag(
+		"approve", false, "Apply the plan instead of only showing it", &opts.approve,
+	).WithBoolFlag(
+		"force", false, "Apply the plan without asking, same as --approve", &opts.force,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently when comparing", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 0, "Number of keys per bulk write or delete", &opts.batchSize,
+	).WithBoolFlag(
+		"json", false, "Output the plan and result as JSON", &opts.outputJSON,
+	).WithBoolFlag(
+		"journal", false, "Save the previous value and metadata of changed keys to a local journal so 'kv undo' can restore them", &opts.journal.journal,
+	).WithStringFlag(
+		"journal-namespace", "", "Keep the journal in this namespace (name or ID) instead of locally; implies --journal", &opts.journal.namespace,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.file == "" {
+				return fmt.Errorf("--file is required")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			data, err := os.ReadFile(opts.file)
+			if err != nil {
+				return fmt.Errorf("failed to read desired keys file: %w", err)
+			}
+			var desired []kv.BulkWriteItem
+			if err := json.Unmarshal(data, &desired); err != nil {
+				return fmt.Errorf("failed to parse desired keys file (must be JSON array of objects): %w", err)
+			}
+
+			desiredKeys := make([]string, len(desired))
+			for i, item := range desired {
+				desiredKeys[i] = item.Key
+			}
+			if err := checkKeyNames(desiredKeys, cfg.IsVerbose()); err != nil {
+				return err
+			}
+
+			applyOptions := kv.ApplyOptions{
+				Prefix:      opts.prefix,
+				Prune:       opts.prune,
+				Concurrency: opts.concurrency,
+				BatchSize:   opts.batchSize,
+			}
+			plan, err := kv.PlanApply(client, accountID, opts.namespaceID, desired, applyOptions)
+			if err != nil {
+				return err
+			}
+
+			apply := (opts.approve || opts.force || common.AssumeYes()) && plan.HasChanges()
+			if !opts.outputJSON {
+				printApplyPlan(plan)
+			}
+			if !apply {
+				if opts.outputJSON {
+					return common.OutputJSON(map[string]interface{}{"plan": plan, "applied": false})
+				}
+				if plan.HasChanges() {
+					fmt.Println("\nPlan only. Run again with --approve to apply these changes.")
+				}
+				return nil
+			}
+
+			// Refuse to change protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+				return err
+			}
+
+			if opts.journal.enabled() {
+				changed := make([]string, 0, len(plan.Create)+len(plan.Update)+len(plan.Delete))
+				for _, item := range plan.Create {
+					changed = append(changed, item.Key)
+				}
+				for _, item := range plan.Update {
+					changed = append(changed, item.Key)
+				}
+				changed = append(changed, plan.Delete...)
+				if err := recordJournal(cmd.Context(), service, client, opts.journal, accountID, opts.namespaceID, "kv apply", changed, opts.concurrency); err != nil {
+					return err
+				}
+			}
+
+			result, err := kv.ExecuteApply(client, accountID, opts.namespaceID, plan, applyOptions)
+			if opts.outputJSON {
+				if jsonErr := common.OutputJSON(map[string]interface{}{"plan": plan, "applied": err == nil, "result": result}); jsonErr != nil {
+					return jsonErr
+				}
+			} else if err == nil {
+				fmt.Printf("\nApplied: %d created, %d updated, %d deleted\n", result.Created, result.Updated, result.Deleted)
+			}
+			return err
+		}),
+	)
+}
+
+// printApplyPlan prints the changes of an apply plan, listing the first keys of each kind
+func printApplyPlan(plan *kv.ApplyPlan) {
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		len(plan.Create), len(plan.Update), len(plan.Delete), plan.Unchanged)
+
+	createKeys := make([]string, len(plan.Create))
+	for i, item := range plan.Create {
+		createKeys[i] = item.Key
+	}
+	updateKeys := make([]string, len(plan.Update))
+	for i, item := range plan.Update {
+		updateKeys[i] = item.Key
+	}
+	printApplyKeys("+", createKeys)
+	printApplyKeys("~", updateKeys)
+	printApplyKeys("-", plan.Delete)
+}
+
+// printApplyKeys prints up to maxApplyPlanKeys keys with a change marker
+func printApplyKeys(marker string, keys []string) {
+	for i, key := range keys {
+		if i == maxApplyPlanKeys {
+			fmt.Printf("  %s ... and %d more\n", marker, len(keys)-maxApplyPlanKeys)
+			return
+		}
+		fmt.Printf("  %s %s\n", marker, key)
+	}
+}
@@ -0,0 +1,288 @@
+package cmdutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVBackupCommand creates a new backup command for KV
+func NewKVBackupCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID        string
+		dir              string
+		concurrency      int
+		valueConcurrency int
+		outputJSON       bool
+	}
+
+	// Create command
+	return NewCommand("backup", "Back up every namespace in an account", `
+Export every namespace in an account into a directory, one JSON file per
+namespace in the same format as 'kv export', plus a manifest.json listing the
+namespaces. Namespaces are exported in parallel. Split values are stored
+reassembled and binary values base64-encoded, as in 'kv export'.
+
+Restore the directory with 'kv restore', into the same or another account.
+`).WithExample(`  # Back up all namespaces of an account
+  cache-kv-purger kv backup --account-id YOUR_ACCOUNT_ID --dir backups/
+
+  # Export more namespaces at once
+  cache-kv-purger kv backup --account-id YOUR_ACCOUNT_ID --dir backups/ --concurrency 5
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"dir", "", "Directory to write the backup to", &opts.dir,
+	).WithIntFlag(
+		"concurrency", 3, "Number of namespaces exported in parallel", &opts.concurrency,
+	).WithIntFlag(
+		"value-concurrency", 0, "Number of concurrent value requests per namespace", &opts.valueConcurrency,
+	).WithBoolFlag(
+		"json", false, "Output the manifest as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			if opts.dir == "" {
+				return fmt.Errorf("--dir is required")
+			}
+
+			var progressCallback func(namespace kv.BackupNamespace, err error)
+			if !opts.outputJSON {
+				progressCallback = func(namespace kv.BackupNamespace, err error) {
+					if err != nil {
+						fmt.Printf("Failed to back up %s (%s): %s\n", namespace.Title, namespace.ID, err)
+						return
+					}
+					fmt.Printf("Backed up %s (%d keys)\n", namespace.Title, namespace.Keys)
+					if len(namespace.Failed) > 0 {
+						fmt.Printf("Warning: %d keys of %s could not be read and are missing from the backup (%s); see the manifest\n",
+							len(namespace.Failed), namespace.Title, formatFailureClasses(namespace.Failed))
+					}
+				}
+			}
+
+			manifest, backupErr := kv.BackupAccount(client, accountID, opts.dir, kv.BackupOptions{
+				Concurrency:      opts.concurrency,
+				ValueConcurrency: opts.valueConcurrency,
+			}, progressCallback)
+			if manifest == nil {
+				return fmt.Errorf("backup failed: %w", backupErr)
+			}
+
+			// Display results
+			if opts.outputJSON {
+				if err := common.OutputJSON(manifest); err != nil {
+					return err
+				}
+				return backupErr
+			}
+
+			totalKeys := 0
+			for _, ns := range manifest.Namespaces {
+				totalKeys += ns.Keys
+			}
+
+			data := make(map[string]string)
+			data["Account"] = accountID
+			data["Directory"] = opts.dir
+			data["Namespaces"] = fmt.Sprintf("%d", len(manifest.Namespaces))
+			data["Keys"] = fmt.Sprintf("%d", totalKeys)
+			fmt.Println()
+			common.FormatKeyValueTable(data)
+
+			return backupErr
+		}),
+	)
+}
+
+// NewKVRestoreCommand creates a new restore command for KV
+func NewKVRestoreCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID    string
+		dir          string
+		toAccount    string
+		destAPIToken string
+		concurrency  int
+		batchSize    int
+		dryRun       bool
+		force        bool
+		outputJSON   bool
+	}
+
+	// Create command
+	return NewCommand("restore", "Restore namespaces from a backup", `
+Re-create the namespaces of a 'kv backup' directory and reload their keys.
+
+Namespaces are matched by title. A namespace that already exists in the target
+account is reused and its keys are overwritten; missing namespaces are created.
+Restore into another account with --to-account; if that account needs different
+credentials use --dest-api-token or the CLOUDFLARE_DEST_API_TOKEN (or
+CLOUDFLARE_DEST_API_KEY and CLOUDFLARE_DEST_EMAIL) environment variables.
+
+Values larger than KV accepts are split into chunks again, as 'kv put --chunk'
+does, and binary values are written byte for byte.
+`).WithExample(`  # Restore a backup into the account it came from
+  cache-kv-purger kv restore --account-id YOUR_ACCOUNT_ID --dir backups/
+
+  # Migrate a backup to another account
+  cache-kv-purger kv restore --dir backups/ --to-account OTHER_ACCOUNT_ID --dest-api-token TOKEN
+
+  # Show what would be created and written
+  cache-kv-purger kv restore --dir backups/ --to-account OTHER_ACCOUNT_ID --dry-run
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID to restore into (defaults to the backup's account)", &opts.accountID,
+	).WithStringFlag(
+		"dir", "", "Backup directory to restore from", &opts.dir,
+	).WithStringFlag(
+		"to-account", "", "Account ID to restore into (overrides --account-id)", &opts.toAccount,
+	).WithStringFlag(
+		"dest-api-token", "", "API token for the target account (defaults to CLOUDFLARE_DEST_API_TOKEN or the primary credentials)", &opts.destAPIToken,
+	).WithIntFlag(
+		"concurrency", 3, "Number of namespaces restored in parallel", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 0, "Batch size for bulk writes", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "Show what would be restored without writing", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip the confirmation prompt when existing namespaces will be overwritten", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.dir == "" {
+				return fmt.Errorf("--dir is required")
+			}
+
+			manifest, err := kv.LoadBackupManifest(opts.dir)
+			if err != nil {
+				return err
+			}
+
+			// Resolve the target account, falling back to the account the backup came from
+			accountID := opts.toAccount
+			if accountID == "" {
+				accountID = opts.accountID
+			}
+			if accountID == "" {
+				accountID = cfg.GetAccountID()
+			}
+			if accountID == "" {
+				accountID = manifest.AccountID
+			}
+			if accountID == "" {
+				return fmt.Errorf("account ID is required, specify it with --to-account or --account-id")
+			}
+
+			targetClient := client
+			if opts.toAccount != "" {
+				targetClient, err = newDestinationClient(client, opts.destAPIToken)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Existing namespaces will be overwritten; check protection and confirm first
+			existing, err := kv.ListNamespaces(targetClient, accountID)
+			if err != nil {
+				return fmt.Errorf("failed to list namespaces: %w", err)
+			}
+			existingByTitle := make(map[string]string, len(existing))
+			for _, ns := range existing {
+				existingByTitle[ns.Title] = ns.ID
+			}
+
+			var overwritten []string
+			for _, entry := range manifest.Namespaces {
+				id, ok := existingByTitle[entry.Title]
+				if !ok {
+					continue
+				}
+				if err := common.CheckNamespaceProtection(cmd, cfg, id, entry.Title); err != nil {
+					return err
+				}
+				overwritten = append(overwritten, entry.Title)
+			}
+
+			if len(overwritten) > 0 && !opts.dryRun && !opts.force {
+				fmt.Printf("Restoring will overwrite keys in %d existing namespaces in account %s:\n", len(overwritten), accountID)
+				common.StringsDisplaySample(overwritten, true)
+				fmt.Print("Are you sure? (y/N): ")
+
+				reader := bufio.NewReader(os.Stdin)
+				confirmation, _ := reader.ReadString('\n')
+				confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+
+				if confirmation != "y" && confirmation != "yes" {
+					fmt.Println("Restore cancelled.")
+					return nil
+				}
+			}
+
+			var progressCallback func(result kv.RestoredNamespace)
+			if !opts.outputJSON {
+				progressCallback = func(result kv.RestoredNamespace) {
+					if result.Error != "" {
+						fmt.Printf("Failed to restore %s: %s\n", result.Title, result.Error)
+						return
+					}
+					if opts.dryRun {
+						return
+					}
+					fmt.Printf("Restored %s (%d keys)\n", result.Title, result.Keys)
+				}
+			}
+
+			results, restoreErr := kv.RestoreAccount(targetClient, accountID, opts.dir, kv.RestoreOptions{
+				Concurrency: opts.concurrency,
+				BatchSize:   opts.batchSize,
+				DryRun:      opts.dryRun,
+			}, progressCallback)
+			if results == nil {
+				return fmt.Errorf("restore failed: %w", restoreErr)
+			}
+
+			// Display results
+			if opts.outputJSON {
+				if err := common.OutputJSON(results); err != nil {
+					return err
+				}
+				return restoreErr
+			}
+
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would restore %d namespaces into account %s\n", len(results), accountID)
+			}
+
+			headers := []string{"Namespace", "Target ID", "Action", "Keys"}
+			rows := make([][]string, len(results))
+			for i, result := range results {
+				action := "overwrite"
+				if result.Created {
+					action = "create"
+				}
+				if result.Error != "" {
+					action = "failed"
+				}
+				rows[i] = []string{result.Title, result.TargetID, action, fmt.Sprintf("%d", result.Keys)}
+			}
+			common.FormatTable(headers, rows)
+
+			return restoreErr
+		}),
+	)
+}
@@ -0,0 +1,71 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVBindingsCommand creates a new bindings command for KV
+func NewKVBindingsCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID  string
+		outputJSON bool
+	}
+
+	// Create command
+	return NewCommand("bindings", "List the KV namespace bindings of a Worker", `
+List the KV namespace bindings of a Worker script.
+
+Any KV command that takes --namespace-id also accepts --worker and --binding,
+so a namespace can be referenced by the binding name used in the Worker code
+instead of looking up its ID.
+`).WithExample(`  # Show the KV bindings of a Worker
+  cache-kv-purger kv bindings --worker my-worker
+
+  # List keys in the namespace bound as SESSIONS
+  cache-kv-purger kv list --worker my-worker --binding SESSIONS
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithBoolFlag(
+		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			worker, _ := cmd.Flags().GetString("worker")
+			if worker == "" {
+				return fmt.Errorf("worker is required")
+			}
+
+			bindings, err := kv.ListWorkerKVBindings(client, accountID, worker)
+			if err != nil {
+				return err
+			}
+
+			// Display results
+			if opts.outputJSON {
+				return common.OutputJSON(bindings)
+			}
+
+			headers := []string{"Binding", "Namespace ID"}
+			rows := make([][]string, len(bindings))
+			for i, binding := range bindings {
+				rows[i] = []string{binding.Name, binding.NamespaceID}
+			}
+			fmt.Printf("KV bindings of worker %s (%d):\n", worker, len(bindings))
+			common.FormatTable(headers, rows)
+			return nil
+		}),
+	)
+}
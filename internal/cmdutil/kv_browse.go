@@ -0,0 +1,420 @@
+package cmdutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/cache"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// maxValuePreviewLength caps how much of a value the browser prints
+const maxValuePreviewLength = 500
+
+// browseHelp lists the commands understood by the key browser
+const browseHelp = `Commands:
+  n            next page
+  p            previous page
+  /PREFIX      only show keys starting with PREFIX (a lone / clears it)
+  v N          preview the value and metadata of key N
+  m N          show the metadata of key N
+  d N          delete key N (asks for confirmation)
+  x N          purge the cache tags in the metadata of key N (asks for confirmation)
+  r            reload the current page
+  ?            show this help
+  q            quit`
+
+// NewKVBrowseCommand creates a new browse command for KV
+func NewKVBrowseCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		prefix      string
+		pageSize    int
+		zone        string
+		tagExtract  []string
+	}
+
+	// Create command
+	return NewCommand("browse", "Interactively browse the keys of a namespace", `
+Browse the keys of a namespace page by page from the terminal.
+
+Keys are listed in pages with their expiration. Type a command at the prompt to
+move between pages, narrow the listing to a prefix, preview a value and its
+metadata, or delete a key after confirming. Type ? for the list of commands.
+
+The x command purges the cache tags found in a key's metadata from --zone, or from
+the default zone in config. Tags are found with the tag extraction rules of
+--tag-extract-rule or the config, like in combined purges, and the zone is checked
+against the protected zones before the first purge.
+
+This is a line-prompt browser rather than a full-screen terminal UI, so it works
+in any terminal and over a plain pipe.
+`).WithExample(`  # Browse a namespace
+  cache-kv-purger kv browse --namespace-id YOUR_NAMESPACE_ID
+
+  # Start at a prefix with larger pages
+  cache-kv-purger kv browse --namespace "My Namespace" --prefix "user-" --page-size 50
+
+  # Browse with cache tag purges going to a zone
+  cache-kv-purger kv browse --namespace-id YOUR_NAMESPACE_ID --zone example.com
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"prefix", "", "Only show keys with this prefix", &opts.prefix,
+	).WithIntFlag(
+		"page-size", 20, "Number of keys per page (10-1000)", &opts.pageSize,
+	).WithStringFlag(
+		"zone", "", "Zone ID or name to purge cache tags from (defaults to the zone in config)", &opts.zone,
+	).WithStringArrayFlag(
+		"tag-extract-rule", []string{}, "Where to find cache tags in key metadata, e.g. \"field=labels,delimiter=semicolon\" or \"path=.cdn.tags\" (can be repeated, overrides config)", &opts.tagExtract,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			// The API returns at least 10 keys per page
+			if opts.pageSize < 10 || opts.pageSize > 1000 {
+				return fmt.Errorf("page-size must be between 10 and 1000")
+			}
+
+			// Rules for finding cache tags in key metadata
+			extractRules, err := common.ResolveTagExtractRules(opts.tagExtract, cfg)
+			if err != nil {
+				return err
+			}
+
+			// The zone is resolved and checked on the first purge, so browsing needs none
+			zoneID := ""
+			purgeTags := func(tags []string) error {
+				if zoneID == "" {
+					resolved, err := ResolveZoneID(client, cfg, accountID, opts.zone)
+					if err != nil {
+						return err
+					}
+					if err := CheckZoneProtection(cmd, cfg, client, resolved); err != nil {
+						return err
+					}
+					zoneID = resolved
+				}
+
+				_, errs := cache.PurgeTagsInBatches(client, zoneID, tags, cache.NewPurgeBatchOptions())
+				if len(errs) > 0 {
+					return fmt.Errorf("failed to purge cache tags: %s", common.SummarizeBatchErrors(errs))
+				}
+				return nil
+			}
+
+			browser := &keyBrowser{
+				client:      client,
+				accountID:   accountID,
+				namespaceID: opts.namespaceID,
+				prefix:      opts.prefix,
+				pageSize:    opts.pageSize,
+				in:          bufio.NewReader(os.Stdin),
+				out:         os.Stdout,
+				checkProtection: func() error {
+					return CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID)
+				},
+				extractRules: extractRules,
+				purgeTags:    purgeTags,
+			}
+			return browser.run()
+		}),
+	)
+}
+
+// keyBrowser is an interactive, page-by-page view of the keys in a namespace
+type keyBrowser struct {
+	client      *api.Client
+	accountID   string
+	namespaceID string
+	prefix      string
+	pageSize    int
+	in          *bufio.Reader
+	out         io.Writer
+
+	// checkProtection returns an error if the namespace must not be modified
+	checkProtection func() error
+
+	extractRules []config.TagExtractRule
+	// purgeTags purges cache tags from the browsed zone
+	purgeTags func(tags []string) error
+
+	cursors    []string // Cursor of each page visited; the last one is the current page
+	nextCursor string
+	keys       []kv.KeyValuePair
+}
+
+// run shows the first page and handles commands until the user quits
+func (b *keyBrowser) run() error {
+	b.cursors = []string{""}
+	if err := b.load(); err != nil {
+		return err
+	}
+	b.render()
+
+	for {
+		fmt.Fprint(b.out, "\nbrowse> ")
+		line, err := b.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(b.out)
+			return nil
+		}
+
+		quit, err := b.handle(strings.TrimSpace(line))
+		if err != nil {
+			fmt.Fprintf(b.out, "Error: %s\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// handle runs one command, returning true when the browser should exit
+func (b *keyBrowser) handle(line string) (bool, error) {
+	if line == "" {
+		return false, nil
+	}
+
+	if strings.HasPrefix(line, "/") {
+		b.prefix = strings.TrimPrefix(line, "/")
+		b.cursors = []string{""}
+		return false, b.reload()
+	}
+
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "q", "quit", "exit":
+		return true, nil
+	case "?", "h", "help":
+		fmt.Fprintln(b.out, browseHelp)
+	case "n", "next":
+		if b.nextCursor == "" {
+			return false, fmt.Errorf("already on the last page")
+		}
+		b.cursors = append(b.cursors, b.nextCursor)
+		return false, b.reload()
+	case "p", "prev":
+		if len(b.cursors) <= 1 {
+			return false, fmt.Errorf("already on the first page")
+		}
+		b.cursors = b.cursors[:len(b.cursors)-1]
+		return false, b.reload()
+	case "r", "reload":
+		return false, b.reload()
+	case "v", "view", "m", "meta", "d", "delete", "x", "purge":
+		if len(fields) != 2 {
+			return false, fmt.Errorf("usage: %s N", fields[0])
+		}
+		key, err := b.keyAt(fields[1])
+		if err != nil {
+			return false, err
+		}
+		switch fields[0] {
+		case "v", "view":
+			return false, b.showValue(key)
+		case "m", "meta":
+			b.showMetadata(key.Metadata)
+		case "x", "purge":
+			return false, b.purge(key)
+		default:
+			return false, b.delete(key.Key)
+		}
+	default:
+		return false, fmt.Errorf("unknown command '%s' (type ? for help)", fields[0])
+	}
+
+	return false, nil
+}
+
+// load fetches the current page of keys
+func (b *keyBrowser) load() error {
+	result, err := kv.ListKeysWithOptions(b.client, b.accountID, b.namespaceID, &kv.ListKeysOptions{
+		Limit:  b.pageSize,
+		Cursor: b.cursors[len(b.cursors)-1],
+		Prefix: b.prefix,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	b.keys = result.Keys
+	b.nextCursor = ""
+	if result.HasMore {
+		b.nextCursor = result.Cursor
+	}
+	return nil
+}
+
+// reload fetches and shows the current page
+func (b *keyBrowser) reload() error {
+	if err := b.load(); err != nil {
+		return err
+	}
+	b.render()
+	return nil
+}
+
+// render prints the current page
+func (b *keyBrowser) render() {
+	filter := ""
+	if b.prefix != "" {
+		filter = fmt.Sprintf(", prefix '%s'", b.prefix)
+	}
+	more := ""
+	if b.nextCursor != "" {
+		more = ", more with n"
+	}
+	fmt.Fprintf(b.out, "\nPage %d%s%s\n", len(b.cursors), filter, more)
+
+	if len(b.keys) == 0 {
+		fmt.Fprintln(b.out, "No keys found.")
+		return
+	}
+
+	headers := []string{"#", "Key", "Expiration"}
+	rows := make([][]string, len(b.keys))
+	for i, key := range b.keys {
+		rows[i] = []string{strconv.Itoa(i + 1), key.Key, formatExpiration(key.Expiration)}
+	}
+	common.FormatTable(headers, rows)
+}
+
+// keyAt returns the key with the given 1-based number on the current page
+func (b *keyBrowser) keyAt(number string) (kv.KeyValuePair, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(b.keys) {
+		return kv.KeyValuePair{}, fmt.Errorf("no key number %s on this page", number)
+	}
+	return b.keys[n-1], nil
+}
+
+// showValue prints a preview of a key's value and its metadata
+func (b *keyBrowser) showValue(key kv.KeyValuePair) error {
+	value, err := kv.GetValue(b.client, b.accountID, b.namespaceID, key.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get value: %w", err)
+	}
+
+	fmt.Fprintf(b.out, "\nKey:   %s\n", key.Key)
+	fmt.Fprintf(b.out, "Size:  %d bytes\n", len(value))
+	if key.Expiration > 0 {
+		fmt.Fprintf(b.out, "Expires: %s\n", formatExpiration(key.Expiration))
+	}
+
+	preview := value
+	if len(preview) > maxValuePreviewLength {
+		preview = preview[:maxValuePreviewLength] + "..."
+	}
+	fmt.Fprintf(b.out, "Value:\n%s\n", preview)
+
+	b.showMetadata(key.Metadata)
+	return nil
+}
+
+// showMetadata prints a key's metadata
+func (b *keyBrowser) showMetadata(metadata *kv.KeyValueMetadata) {
+	if metadata == nil || len(*metadata) == 0 {
+		fmt.Fprintln(b.out, "Metadata: <none>")
+		return
+	}
+
+	jsonData, err := common.ToJSON(metadata)
+	if err != nil {
+		fmt.Fprintf(b.out, "Metadata: %s\n", summarizeMetadata(metadata))
+		return
+	}
+	fmt.Fprintf(b.out, "Metadata:\n%s\n", jsonData)
+}
+
+// delete removes a key after confirmation and reloads the page
+func (b *keyBrowser) delete(key string) error {
+	if b.checkProtection != nil {
+		if err := b.checkProtection(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(b.out, "You are about to delete the key '%s'. This action cannot be undone.\n", key)
+	fmt.Fprint(b.out, "Are you sure? (y/N): ")
+
+	confirmation, _ := b.in.ReadString('\n')
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "y" && confirmation != "yes" {
+		fmt.Fprintln(b.out, "Deletion cancelled.")
+		return nil
+	}
+
+	if err := kv.DeleteValue(b.client, b.accountID, b.namespaceID, key); err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+	fmt.Fprintf(b.out, "Deleted '%s'\n", key)
+
+	return b.reload()
+}
+
+// purge purges the cache tags in a key's metadata after confirmation
+func (b *keyBrowser) purge(key kv.KeyValuePair) error {
+	var tags []string
+	if key.Metadata != nil {
+		tags = common.ExtractTagsFromMetadata(*key.Metadata, b.extractRules)
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("no cache tags found in the metadata of '%s'", key.Key)
+	}
+	if b.purgeTags == nil {
+		return fmt.Errorf("purging is not available")
+	}
+
+	fmt.Fprintf(b.out, "You are about to purge %d cache tags of '%s': %s\n", len(tags), key.Key, strings.Join(tags, ", "))
+	fmt.Fprint(b.out, "Are you sure? (y/N): ")
+
+	confirmation, _ := b.in.ReadString('\n')
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "y" && confirmation != "yes" {
+		fmt.Fprintln(b.out, "Purge cancelled.")
+		return nil
+	}
+
+	if err := b.purgeTags(tags); err != nil {
+		return err
+	}
+	fmt.Fprintf(b.out, "Purged %d cache tags of '%s'\n", len(tags), key.Key)
+	return nil
+}
@@ -0,0 +1,35 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+)
+
+// checkKeyCapacity refuses a bulk write that would take a namespace over its key limit:
+// maxKeys if given, otherwise namespace_key_limit from the config. Writes that would
+// fill most of the limit are warned about. Nothing is checked without a limit.
+func checkKeyCapacity(cfg *config.Config, client *api.Client, accountID, namespaceID string, keys []string, maxKeys int) error {
+	limit := maxKeys
+	if limit <= 0 && cfg != nil {
+		limit = cfg.GetNamespaceKeyLimit()
+	}
+	if limit <= 0 || len(keys) == 0 {
+		return nil
+	}
+
+	capacity, err := kv.CheckKeyCapacity(client, accountID, namespaceID, keys, limit)
+	if err != nil {
+		return err
+	}
+	if err := capacity.Err(); err != nil {
+		return err
+	}
+	if capacity.NearLimit() {
+		fmt.Printf("Warning: after this write the namespace will hold %d of its %d key limit (%d keys now, %d new)\n",
+			capacity.Projected(), capacity.Limit, capacity.Current, capacity.New)
+	}
+	return nil
+}
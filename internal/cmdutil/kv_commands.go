@@ -15,6 +15,10 @@ func RegisterKVCommands(rootCmd *cobra.Command) {
 		Long:  "Manage Cloudflare Workers KV store namespaces and values",
 	}
 
+	// Namespaces can be referenced through a Worker's binding names
+	kvCmd.PersistentFlags().String("worker", "", "Worker script whose KV bindings --binding refers to")
+	kvCmd.PersistentFlags().String("binding", "", "KV binding name of the --worker script (alternative to namespace-id)")
+
 	// Add new verb-based commands
 	kvCmd.AddCommand(NewKVListCommand().Build())
 	kvCmd.AddCommand(NewKVGetCommand().Build())
@@ -22,6 +26,23 @@ func RegisterKVCommands(rootCmd *cobra.Command) {
 	kvCmd.AddCommand(NewKVDeleteCommand().Build())
 	kvCmd.AddCommand(NewKVCreateCommand().Build())
 	kvCmd.AddCommand(NewKVRenameCommand().Build())
+	kvCmd.AddCommand(NewKVEnsureCommand().Build())
+	kvCmd.AddCommand(NewKVCopyCommand().Build())
+	kvCmd.AddCommand(NewKVExportCommand().Build())
+	kvCmd.AddCommand(NewKVBackupCommand().Build())
+	kvCmd.AddCommand(NewKVRestoreCommand().Build())
+	kvCmd.AddCommand(NewKVTagsCommand().Build())
+	kvCmd.AddCommand(NewKVBrowseCommand().Build())
+	kvCmd.AddCommand(NewKVJSONCommand().Build())
+	kvCmd.AddCommand(NewKVValidateKeysCommand().Build())
+	kvCmd.AddCommand(NewKVExpireCommand().Build())
+	kvCmd.AddCommand(NewKVUndoCommand().Build())
+	kvCmd.AddCommand(NewKVReplaceCommand().Build())
+	kvCmd.AddCommand(NewKVRetagCommand().Build())
+	kvCmd.AddCommand(NewKVApplyCommand().Build())
+	kvCmd.AddCommand(NewKVSampleCommand().Build())
+	kvCmd.AddCommand(NewKVBindingsCommand().Build())
+	kvCmd.AddCommand(NewKVEnvCommand().Build())
 	kvCmd.AddCommand(NewKVConfigCommand().Build())
 
 	// Register legacy commands with deprecation notices
@@ -0,0 +1,275 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/auth"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVCopyCommand creates a new copy command for KV
+func NewKVCopyCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID         string
+		sourceNamespaceID string
+		sourceNamespace   string
+		destNamespaceID   string
+		destNamespace     string
+		destAccountID     string
+		destAPIToken      string
+		key               string
+		prefix            string
+		overwrite         string
+		transform         string
+		replacements      []string
+		batchSize         int
+		dryRun            bool
+		outputJSON        bool
+		verify            verifyFlags
+	}
+
+	// Create command
+	return NewCommand("copy", "Copy keys between namespaces", `
+Copy a key, or every key under a prefix, from one namespace to another.
+
+The value, metadata and expiration of each key are copied. The destination
+namespace may be in a different account; use --dest-account-id and, if the
+destination needs different credentials, --dest-api-token or the
+CLOUDFLARE_DEST_API_TOKEN (or CLOUDFLARE_DEST_API_KEY and CLOUDFLARE_DEST_EMAIL)
+environment variables.
+
+--overwrite controls what happens when a key already exists at the destination:
+  skip     leave the existing key untouched (default)
+  replace  overwrite the existing key
+  fail     abort the copy without writing anything
+
+Values and string metadata values can be rewritten in flight with --replace old=new
+(repeatable) and --transform, a jq-like pipeline such as
+'gsub("https://staging\\."; "https://") | rtrimstr("?debug")'.
+sub replaces the first regex match and gsub every match. Values compressed with
+--compress and other binary values are copied byte for byte and never transformed.
+A value split into chunks with kv put --chunk is copied as one key, chunks included.
+
+With --verify, a random sample of the copied keys is read back from the
+destination and the SHA-256 hash of each value is compared with the value
+written; --verify-all checks every key.
+`).WithExample(`  # Copy a single key
+  cache-kv-purger kv copy --source-namespace-id SRC_ID --dest-namespace-id DEST_ID --key mykey
+
+  # Copy all keys under a prefix, replacing existing keys
+  cache-kv-purger kv copy --source-namespace "Staging" --dest-namespace "Production" --prefix "config/" --overwrite replace
+
+  # Promote staging data to production, rewriting URLs
+  cache-kv-purger kv copy --source-namespace "Staging" --dest-namespace "Production" --prefix "pages/" --replace "staging.example.com=www.example.com"
+
+  # Copy a prefix and check every copied key afterwards
+  cache-kv-purger kv copy --source-namespace "Staging" --dest-namespace "Production" --prefix "config/" --verify-all
+
+  # Copy to another account with separate credentials
+  cache-kv-purger kv copy --source-namespace-id SRC_ID --dest-namespace-id DEST_ID --dest-account-id OTHER_ACCOUNT --dest-api-token TOKEN --prefix "users/"
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID of the source namespace", &opts.accountID,
+	).WithStringFlag(
+		"source-namespace-id", "", "Source namespace ID", &opts.sourceNamespaceID,
+	).WithStringFlag(
+		"source-namespace", "", "Source namespace name (alternative to source-namespace-id)", &opts.sourceNamespace,
+	).WithStringFlag(
+		"dest-namespace-id", "", "Destination namespace ID", &opts.destNamespaceID,
+	).WithStringFlag(
+		"dest-namespace", "", "Destination namespace name (alternative to dest-namespace-id)", &opts.destNamespace,
+	).WithStringFlag(
+		"dest-account-id", "", "Cloudflare account ID of the destination namespace (defaults to the source account)", &opts.destAccountID,
+	).WithStringFlag(
+		"dest-api-token", "", "API token for the destination account (defaults to CLOUDFLARE_DEST_API_TOKEN or the source credentials)", &opts.destAPIToken,
+	).WithStringFlag(
+		"key", "", "Key to copy", &opts.key,
+	).WithStringFlag(
+		"prefix", "", "Copy all keys with this prefix", &opts.prefix,
+	).WithStringFlag(
+		"overwrite", "skip", "Policy for existing destination keys: skip, replace, or fail", &opts.overwrite,
+	).WithStringFlag(
+		"transform", "", "jq-like expression applied to each value and metadata string", &opts.transform,
+	).WithStringSliceFlag(
+		"replace", nil, "Replace old=new in each value and metadata string (can be repeated)", &opts.replacements,
+	).WithIntFlag(
+		"batch-size", 0, "Batch size for writes to the destination", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "Show what would be copied without copying", &opts.dryRun,
+	).WithBoolFlag(
+		"verify", false, "Re-read a sample of copied keys and compare SHA-256 hashes with the source", &opts.verify.verify,
+	).WithBoolFlag(
+		"verify-all", false, "Re-read every copied key and compare SHA-256 hashes with the source", &opts.verify.verifyAll,
+	).WithIntFlag(
+		"verify-sample", kv.DefaultVerifySampleSize, "Number of keys to re-read with --verify", &opts.verify.sampleSize,
+	).WithBoolFlag(
+		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Validate inputs
+			if opts.key == "" && opts.prefix == "" {
+				return fmt.Errorf("either --key or --prefix is required")
+			}
+			if opts.key != "" && opts.prefix != "" {
+				return fmt.Errorf("--key and --prefix cannot be used together")
+			}
+
+			policy, err := kv.ParseOverwritePolicy(opts.overwrite)
+			if err != nil {
+				return err
+			}
+
+			transformer, err := common.NewValueTransformer(opts.transform, opts.replacements)
+			if err != nil {
+				return err
+			}
+
+			// Set up the destination client, using separate credentials if configured
+			destClient, err := newDestinationClient(client, opts.destAPIToken)
+			if err != nil {
+				return err
+			}
+
+			destAccountID := opts.destAccountID
+			if destAccountID == "" {
+				destAccountID = accountID
+			}
+
+			// Resolve namespaces on their own accounts
+			sourceService := kv.NewKVService(client)
+			destService := kv.NewKVService(destClient)
+
+			if opts.sourceNamespace != "" && opts.sourceNamespaceID == "" {
+				nsID, err := sourceService.ResolveNamespaceID(cmd.Context(), accountID, opts.sourceNamespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve source namespace: %w", err)
+				}
+				opts.sourceNamespaceID = nsID
+			}
+			if opts.destNamespace != "" && opts.destNamespaceID == "" {
+				nsID, err := destService.ResolveNamespaceID(cmd.Context(), destAccountID, opts.destNamespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve destination namespace: %w", err)
+				}
+				opts.destNamespaceID = nsID
+			}
+
+			if opts.sourceNamespaceID == "" {
+				return fmt.Errorf("source-namespace-id or source-namespace is required")
+			}
+			if opts.destNamespaceID == "" {
+				return fmt.Errorf("dest-namespace-id or dest-namespace is required")
+			}
+
+			// Refuse to write to a protected destination namespace
+			if !opts.dryRun {
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, destService, destAccountID, opts.destNamespaceID); err != nil {
+					return err
+				}
+			}
+
+			var progressCallback func(completed, total int)
+			if cfg.IsVerbose() {
+				progressCallback = func(completed, total int) {
+					fmt.Printf("Progress: %d/%d operations\n", completed, total)
+				}
+			}
+
+			result, err := kv.CopyKeys(
+				kv.CopyEndpoint{Client: client, AccountID: accountID, NamespaceID: opts.sourceNamespaceID},
+				kv.CopyEndpoint{Client: destClient, AccountID: destAccountID, NamespaceID: opts.destNamespaceID},
+				kv.CopyOptions{
+					Key:       opts.key,
+					Prefix:    opts.prefix,
+					Overwrite: policy,
+					DryRun:    opts.dryRun,
+					BatchSize: opts.batchSize,
+					Transform: transformer,
+				},
+				progressCallback,
+			)
+			if err != nil {
+				return fmt.Errorf("copy failed: %w", err)
+			}
+
+			// Display results
+			if opts.outputJSON {
+				if opts.verify.enabled() && !opts.dryRun {
+					verification, err := kv.VerifyValues(destClient, destAccountID, opts.destNamespaceID, result.ValueHashes,
+						kv.VerifyOptions{All: opts.verify.verifyAll, SampleSize: opts.verify.sampleSize})
+					if err != nil {
+						return fmt.Errorf("verification failed: %w", err)
+					}
+					if err := common.OutputJSON(struct {
+						*kv.CopyResult
+						Verification *kv.VerifyResult `json:"verification"`
+					}{result, verification}); err != nil {
+						return err
+					}
+					if !verification.OK() {
+						return fmt.Errorf("verification failed for %d of %d keys checked",
+							len(verification.Mismatched)+len(verification.Missing)+len(verification.Errors), verification.Checked)
+					}
+					return nil
+				}
+				return common.OutputJSON(result)
+			}
+
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would copy %d keys\n", len(result.Copied))
+				common.StringsDisplaySample(result.Copied, cfg.IsVerbose())
+			}
+
+			data := make(map[string]string)
+			data["Source"] = fmt.Sprintf("%s (account %s)", opts.sourceNamespaceID, accountID)
+			data["Destination"] = fmt.Sprintf("%s (account %s)", opts.destNamespaceID, destAccountID)
+			data["Overwrite Policy"] = string(policy)
+			if opts.dryRun {
+				data["Would Copy"] = fmt.Sprintf("%d", len(result.Copied))
+			} else {
+				data["Copied"] = fmt.Sprintf("%d", len(result.Copied))
+			}
+			data["Skipped (existing)"] = fmt.Sprintf("%d", len(result.Skipped))
+
+			common.FormatKeyValueTable(data)
+
+			if opts.verify.enabled() && !opts.dryRun {
+				return verifyWrittenValues(destClient, destAccountID, opts.destNamespaceID, result.ValueHashes, opts.verify, 0)
+			}
+			return nil
+		}),
+	)
+}
+
+// newDestinationClient returns a client for the destination of a cross-account operation.
+// It uses apiToken, or CLOUDFLARE_DEST_API_TOKEN (or CLOUDFLARE_DEST_API_KEY and
+// CLOUDFLARE_DEST_EMAIL), and falls back to the source client when neither is set.
+func newDestinationClient(client *api.Client, apiToken string) (*api.Client, error) {
+	destCreds, err := auth.GetDestinationCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if apiToken != "" {
+		destCreds = &auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: apiToken}
+	}
+	if destCreds == nil {
+		return client, nil
+	}
+
+	destClient, err := api.NewClient(api.WithBaseURL(client.BaseURL), api.WithCredentials(destCreds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination API client: %w", err)
+	}
+	return destClient, nil
+}
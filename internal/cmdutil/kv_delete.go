@@ -2,9 +2,11 @@ package cmdutil
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/common"
@@ -23,19 +25,35 @@ func NewKVDeleteCommand() *CommandBuilder {
 		namespace       string
 		key             string
 		namespaceItself bool
+		namespaceIDs    string
+		nsPattern       string
+		titlesFile      string
+		excludePattern  string
+		excludePrefixes []string
+		excludeKeysFile string
 		bulk            bool
 		keys            string
 		keysFile        string
 		prefix          string
 		pattern         string
 		searchValue     string
+		failOnErrors    string
 		tagField        string
 		tagValue        string
 		allKeys         bool
+		noExpiration    bool
+		hasExpiration   bool
 		dryRun          bool
 		force           bool
+		sample          int
 		batchSize       int
 		concurrency     int
+		estimate        bool
+		journal         journalFlags
+		archiveTo       string
+		archiveTTL      string
+		plan            PlanFlags
+		fromExport      string
 	}
 
 	// Create command
@@ -44,19 +62,74 @@ Delete one or more keys from a KV namespace, or delete a namespace itself.
 
 When used with --key, deletes a single key.
 When used with --namespace-itself, deletes the namespace itself.
-When used with --bulk, deletes multiple keys based on filters.
+When used with --namespace-itself and --namespace-ids, --namespace-pattern or
+--titles-file, deletes several namespaces at once. Use --exclude-pattern to
+protect critical namespaces from being selected.
+When used with --bulk, deletes multiple keys based on filters. Keys matching
+--exclude-prefix, --exclude-pattern or listed in --exclude-keys-file are never
+deleted; exclusions are applied after the other filters. --no-expiration and
+--has-expiration keep only keys without, or with, an expiration; on their own
+they select from all keys in the namespace.
+
+Bulk deletes that find their keys with filters ask for confirmation unless --force
+is given, showing --sample randomly chosen matching keys with their metadata.
+
+With --journal, the value and metadata of every key are saved to a local journal
+(or to --journal-namespace) before deleting, and 'kv undo' can restore them.
+
+With --archive-to, bulk deletes become soft deletes: every key is copied with its
+value and metadata to the archive namespace before it is deleted, and can be
+restored with 'kv copy'. --archive-ttl lets archived keys expire; otherwise they
+are kept until deleted. Expirations of the deleted keys are not carried over.
+
+To make sure a bulk delete removes the keys a dry run showed, save the dry run's
+matches with --plan-out and pass the file to the real run with --plan-in. The real
+run aborts before deleting anything when its matches differ from the plan by more
+than --plan-threshold percent of the planned keys, and warns about smaller changes.
+Plans store hashes of the key names, not the names themselves.
+
+With --from-export, the filters are evaluated against a file written by 'kv export'
+instead of the namespace, and the exact keys that would be deleted are printed.
+Nothing is deleted and no API requests are made, so destructive changes can be
+reviewed offline; the result is only as current as the export. Add --plan-out (with
+--namespace-id) to save the reviewed keys as a plan for the real run's --plan-in.
 `).WithExample(`  # Delete a single key
   cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --key mykey
 
   # Delete the namespace itself
   cache-kv-purger kv delete --namespace "My Namespace" --namespace-itself
 
+  # Delete namespaces listed by title in a file, protecting production ones
+  cache-kv-purger kv delete --namespace-itself --titles-file namespaces.txt --exclude-pattern "^prod-" --dry-run
+
+  # Delete namespaces matching a pattern
+  cache-kv-purger kv delete --namespace-itself --namespace-pattern "^test-"
+
   # Delete all keys with a prefix (with dry run)
   cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "temp-" --dry-run
   
+  # Delete everything under cache/ except cache/critical/
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "cache/" --exclude-prefix "cache/critical/" --dry-run
+
+  # Delete keys under cache/ that were written without a TTL
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "cache/" --no-expiration --dry-run
+
   # Delete all keys in the namespace
   cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --all-keys
 
+  # Journal keys before deleting them, so 'kv undo' can restore them
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "temp-" --journal
+
+  # Soft delete: move keys to an archive namespace that keeps them for 30 days
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "temp-" --archive-to archive --archive-ttl 30d
+
+  # Preview a delete, then delete only if the matched keys haven't changed
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "temp-" --dry-run --plan-out plan.json
+  cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "temp-" --plan-in plan.json
+
+  # Review offline which keys a delete would remove, using a backup made with 'kv export'
+  cache-kv-purger kv delete --bulk --tag-field "status" --tag-value "archived" --from-export backup.json
+
   # Delete keys by metadata (with confirmation)
   cache-kv-purger kv delete --namespace-id YOUR_NAMESPACE_ID --bulk --tag-field "status" --tag-value "archived"
 
@@ -72,6 +145,18 @@ When used with --bulk, deletes multiple keys based on filters.
 		"key", "", "Key to delete (required unless bulk deletion or namespace deletion)", &opts.key,
 	).WithBoolFlag(
 		"namespace-itself", false, "Delete the namespace itself (not keys)", &opts.namespaceItself,
+	).WithStringFlag(
+		"namespace-ids", "", "Comma-separated namespace IDs to delete (with --namespace-itself)", &opts.namespaceIDs,
+	).WithStringFlag(
+		"namespace-pattern", "", "Delete namespaces with titles matching regex pattern (with --namespace-itself)", &opts.nsPattern,
+	).WithStringFlag(
+		"titles-file", "", "File containing exact namespace titles to delete, one per line (with --namespace-itself)", &opts.titlesFile,
+	).WithStringFlag(
+		"exclude-pattern", "", "Never delete namespaces with titles (with --namespace-itself) or keys (with --bulk) matching this regex pattern", &opts.excludePattern,
+	).WithStringSliceFlag(
+		"exclude-prefix", nil, "Never delete keys with this prefix (with --bulk, can be repeated)", &opts.excludePrefixes,
+	).WithStringFlag(
+		"exclude-keys-file", "", "File containing keys never to delete, one per line (with --bulk)", &opts.excludeKeysFile,
 	).WithBoolFlag(
 		"bulk", false, "Delete multiple keys based on filters", &opts.bulk,
 	).WithStringFlag(
@@ -85,23 +170,102 @@ When used with --bulk, deletes multiple keys based on filters.
 	).WithStringFlag(
 		"search", "", "Delete keys containing this value (deep recursive search in metadata)", &opts.searchValue,
 	).WithStringFlag(
-		"tag-field", "", "Delete keys with this metadata field", &opts.tagField,
+		"fail-on-errors", "", failOnErrorsUsage+"; nothing is deleted then", &opts.failOnErrors,
 	).WithStringFlag(
-		"tag-value", "", "Delete keys with this metadata field/value", &opts.tagValue,
+		"tag-field", "", "Delete keys with this metadata field (a dot path like meta.tags reaches nested fields)", &opts.tagField,
+	).WithBoolFlag(
+		"no-expiration", false, "Only delete keys that never expire (with --bulk)", &opts.noExpiration,
+	).WithBoolFlag(
+		"has-expiration", false, "Only delete keys that have an expiration (with --bulk)", &opts.hasExpiration,
+	).WithStringFlag(
+		"tag-value", "", "Delete keys with this metadata field/value (matches arrays containing it and numbers)", &opts.tagValue,
 	).WithBoolFlag(
 		"all-keys", false, "Delete all keys in the namespace", &opts.allKeys,
 	).WithBoolFlag(
 		"dry-run", false, "Show what would be deleted without deleting", &opts.dryRun,
 	).WithBoolFlag(
 		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithIntFlag(
+		"sample", 5, "Number of random matching keys, with their metadata, shown when confirming a bulk delete", &opts.sample,
 	).WithIntFlag(
 		"batch-size", 0, "Batch size for bulk operations", &opts.batchSize,
 	).WithIntFlag(
 		"concurrency", 0, "Concurrency for bulk operations", &opts.concurrency,
+	).WithBoolFlag(
+		"estimate", false, "Estimate the API requests and duration of a filtered bulk delete and ask before running an expensive one (always shown with --dry-run)", &opts.estimate,
+	).WithBoolFlag(
+		"journal", false, "Save the value and metadata of deleted keys to a local journal so 'kv undo' can restore them", &opts.journal.journal,
+	).WithStringFlag(
+		"journal-namespace", "", "Keep the journal in this namespace (name or ID) instead of locally; implies --journal", &opts.journal.namespace,
+	).WithStringFlag(
+		"archive-to", "", "Copy keys (value and metadata) to this namespace (name or ID) before deleting them (with --bulk)", &opts.archiveTo,
+	).WithStringFlag(
+		"archive-ttl", "", "Expire archived keys after this long (seconds, duration like 1h30m, or days like 7d)", &opts.archiveTTL,
+	).WithStringFlag(
+		"plan-out", "", "Save the keys matched by a dry run to this plan file (with --bulk and --dry-run)", &opts.plan.Out,
+	).WithStringFlag(
+		"plan-in", "", "Check the keys matched now against this plan file from a dry run before deleting (with --bulk)", &opts.plan.In,
+	).WithFloat64Flag(
+		"plan-threshold", 0, "Abort when the matched keys differ from the --plan-in plan by more than this percentage", &opts.plan.Threshold,
+	).WithStringFlag(
+		"from-export", "", FromExportUsage+" (with --bulk)", &opts.fromExport,
 	).WithRunE(
-		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+		WithExportOrClient(func(cmd *cobra.Command, args []string, cfg *config.Config, items []kv.BulkWriteItem) error {
+			if !opts.bulk || opts.namespaceItself {
+				return fmt.Errorf("--from-export plans bulk key deletes; use it with --bulk")
+			}
+			if opts.keys != "" || opts.keysFile != "" {
+				return fmt.Errorf("--from-export evaluates filters and cannot be combined with --keys or --keys-file")
+			}
+			if opts.tagValue != "" && opts.tagField == "" {
+				return fmt.Errorf("--tag-value requires --tag-field")
+			}
+			if err := opts.plan.Validate(true); err != nil {
+				return err
+			}
+			if opts.plan.Enabled() && opts.namespaceID == "" {
+				return fmt.Errorf("--plan-out and --plan-in with --from-export require --namespace-id")
+			}
+
+			expirationFilter, err := kv.NewExpirationFilter(opts.noExpiration, opts.hasExpiration)
+			if err != nil {
+				return err
+			}
+			exclusion, err := newDeleteExclusion(opts.excludePrefixes, opts.excludePattern, opts.excludeKeysFile)
+			if err != nil {
+				return err
+			}
+			prefixSpecified := opts.prefix != "" || cmd.Flags().Changed("prefix")
+			if !prefixSpecified && opts.pattern == "" && opts.tagField == "" && opts.searchValue == "" &&
+				!opts.allKeys && expirationFilter == kv.AnyExpiration {
+				return fmt.Errorf("no keys specified for bulk deletion. Use --prefix, --pattern, --tag-field, --search or --all-keys")
+			}
+
+			matches, err := kv.SearchExport(items, kv.SearchOptions{
+				SearchValue: opts.searchValue,
+				TagField:    opts.tagField,
+				TagValue:    opts.tagValue,
+				Prefix:      opts.prefix,
+				NameRegex:   opts.pattern,
+				Expiration:  expirationFilter,
+			})
+			if err != nil {
+				return err
+			}
+
+			names, excluded := exclusion.Apply(keyNames(matches))
+			if excluded > 0 {
+				fmt.Printf("Excluded %d keys matching the exclusion filters\n", excluded)
+			}
+			PrintExportPlan(opts.fromExport, len(items), "deleted", names)
+
+			if opts.plan.Enabled() {
+				return opts.plan.Apply("kv delete", opts.namespaceID, names)
+			}
+			return nil
+		}, WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
@@ -109,6 +273,26 @@ When used with --bulk, deletes multiple keys based on filters.
 			// Create KV service
 			service := kv.NewKVService(client)
 
+			// Bulk namespace deletion doesn't target a single namespace
+			bulkNamespaces := bulkNamespaceDeleteOptions{
+				namespaceIDs:   opts.namespaceIDs,
+				pattern:        opts.nsPattern,
+				titlesFile:     opts.titlesFile,
+				excludePattern: opts.excludePattern,
+				concurrency:    opts.concurrency,
+				dryRun:         opts.dryRun,
+				force:          opts.force,
+				verbose:        cfg.IsVerbose(),
+				cfg:            cfg,
+				cmd:            cmd,
+			}
+			if bulkNamespaces.hasSelection() {
+				if !opts.namespaceItself {
+					return fmt.Errorf("--namespace-ids, --namespace-pattern and --titles-file require --namespace-itself")
+				}
+				return deleteNamespacesInBulk(cmd.Context(), client, service, accountID, bulkNamespaces)
+			}
+
 			// Handle namespace ID resolution if namespace name is provided
 			if opts.namespace != "" && opts.namespaceID == "" {
 				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
@@ -123,6 +307,11 @@ When used with --bulk, deletes multiple keys based on filters.
 				return fmt.Errorf("namespace-id or namespace is required")
 			}
 
+			// Refuse to touch protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+				return err
+			}
+
 			// If we're deleting the namespace itself, that's a separate operation
 			if opts.namespaceItself {
 				// Get namespace info for confirmation
@@ -174,6 +363,22 @@ When used with --bulk, deletes multiple keys based on filters.
 			}
 
 			// Otherwise, we're deleting keys, either single or bulk
+			if !opts.bulk && opts.archiveTo != "" {
+				return fmt.Errorf("--archive-to requires --bulk")
+			}
+			if !opts.bulk && opts.plan.Enabled() {
+				return fmt.Errorf("--plan-out and --plan-in require --bulk")
+			}
+			if err := opts.plan.Validate(opts.dryRun); err != nil {
+				return err
+			}
+			expirationFilter, err := kv.NewExpirationFilter(opts.noExpiration, opts.hasExpiration)
+			if err != nil {
+				return err
+			}
+			if expirationFilter != kv.AnyExpiration && (!opts.bulk || opts.keys != "" || opts.keysFile != "") {
+				return fmt.Errorf("--no-expiration and --has-expiration require --bulk and cannot be combined with --keys or --keys-file")
+			}
 			if !opts.bulk {
 				// Single key mode validation
 				if opts.key == "" {
@@ -200,6 +405,12 @@ When used with --bulk, deletes multiple keys based on filters.
 					return nil
 				}
 
+				if opts.journal.enabled() {
+					if err := recordJournal(cmd.Context(), service, client, opts.journal, accountID, opts.namespaceID, "kv delete", []string{opts.key}, 1); err != nil {
+						return err
+					}
+				}
+
 				// Delete the key
 				err := service.Delete(cmd.Context(), accountID, opts.namespaceID, opts.key)
 				if err != nil {
@@ -210,7 +421,56 @@ When used with --bulk, deletes multiple keys based on filters.
 				return nil
 			}
 
-			// Bulk mode - get keys to delete
+			// Soft deletes copy keys to an archive namespace first
+			if opts.archiveTTL != "" && opts.archiveTo == "" {
+				return fmt.Errorf("--archive-ttl requires --archive-to")
+			}
+			var archiveNamespaceID string
+			var archiveOptions kv.ArchiveOptions
+			if opts.archiveTo != "" {
+				archiveNamespaceID, err = service.ResolveNamespaceID(cmd.Context(), accountID, opts.archiveTo)
+				if err != nil {
+					return fmt.Errorf("failed to resolve archive namespace: %w", err)
+				}
+				if archiveNamespaceID == opts.namespaceID {
+					return fmt.Errorf("--archive-to must be a different namespace")
+				}
+				if opts.archiveTTL != "" {
+					ttl, err := common.ParseTTL(opts.archiveTTL)
+					if err != nil {
+						return err
+					}
+					archiveOptions.TTL = int64(ttl.Round(time.Second) / time.Second)
+				}
+				archiveOptions.BatchSize = opts.batchSize
+				archiveOptions.Concurrency = opts.concurrency
+			}
+
+			// Journal and archive the keys about to be deleted; an error stops the deletion
+			beforeDelete := func(keys []string) error {
+				if opts.journal.enabled() {
+					if err := recordJournal(cmd.Context(), service, client, opts.journal, accountID, opts.namespaceID, "kv delete", keys, opts.concurrency); err != nil {
+						return err
+					}
+				}
+				if archiveNamespaceID != "" {
+					fmt.Printf("Archiving %d keys to %s...\n", len(keys), opts.archiveTo)
+					archived, err := kv.ArchiveKeys(client, accountID, opts.namespaceID, archiveNamespaceID, keys, archiveOptions)
+					if err != nil {
+						return fmt.Errorf("failed to archive keys, nothing was deleted: %w", err)
+					}
+					fmt.Printf("Archived %d keys to %s\n", archived, opts.archiveTo)
+				}
+				return nil
+			}
+
+			// Bulk mode - build the exclusions applied after the other filters
+			exclusion, err := newDeleteExclusion(opts.excludePrefixes, opts.excludePattern, opts.excludeKeysFile)
+			if err != nil {
+				return err
+			}
+
+			// Get keys to delete
 			var keys []string
 
 			// If explicit keys are provided
@@ -230,11 +490,50 @@ When used with --bulk, deletes multiple keys based on filters.
 					}
 				}
 			}
+			if len(keys) > 0 {
+				if err := checkKeyNames(keys, cfg.IsVerbose()); err != nil {
+					return err
+				}
+
+				var excluded int
+				if keys, excluded = exclusion.Apply(keys); excluded > 0 {
+					fmt.Printf("Excluded %d keys matching the exclusion filters\n", excluded)
+				}
+				if len(keys) == 0 {
+					fmt.Println("No keys left to delete after exclusions.")
+					return nil
+				}
+			}
 
 			// Check if we have filtering criteria without explicit keys
 			// Note: An empty prefix means match all keys when explicitly provided
 			prefixSpecified := opts.prefix != "" || cmd.Flags().Changed("prefix")
-			hasFilteringCriteria := prefixSpecified || opts.pattern != "" || opts.tagField != "" || opts.tagValue != "" || opts.searchValue != "" || opts.allKeys
+			hasFilteringCriteria := prefixSpecified || opts.pattern != "" || opts.tagField != "" || opts.tagValue != "" || opts.searchValue != "" ||
+				opts.allKeys || expirationFilter != kv.AnyExpiration
+			if opts.failOnErrors != "" && (opts.searchValue == "" || opts.tagField != "") {
+				return fmt.Errorf("--fail-on-errors applies to --search without --tag-field")
+			}
+
+			// Estimate filtered deletes, which list the namespace and may read every key
+			if len(keys) == 0 && hasFilteringCriteria && (opts.estimate || opts.dryRun) {
+				readsPerKey := 0
+				if opts.searchValue != "" || opts.tagField != "" {
+					readsPerKey = 1
+				}
+				deleteBatchSize := opts.batchSize
+				if deleteBatchSize <= 0 {
+					deleteBatchSize = 10000
+				}
+				estimate, err := estimateOperation(client, accountID, opts.namespaceID, opts.prefix, readsPerKey, deleteBatchSize, opts.concurrency)
+				if err != nil {
+					return err
+				}
+				if opts.dryRun {
+					printEstimate(os.Stdout, "bulk delete", estimate)
+				} else if proceed, err := confirmEstimate(cfg, "bulk delete", estimate, opts.force, true); !proceed {
+					return err
+				}
+			}
 
 			// Check for the enhanced "deep search" capability
 			if opts.searchValue != "" && opts.tagField == "" {
@@ -244,10 +543,15 @@ When used with --bulk, deletes multiple keys based on filters.
 				// Use the service.Search directly
 				searchOptions := kv.SearchOptions{
 					SearchValue:     opts.searchValue,
+					Prefix:          opts.prefix,
+					Expiration:      expirationFilter,
 					IncludeMetadata: true,
 					BatchSize:       opts.batchSize,
 					Concurrency:     opts.concurrency,
 				}
+				if err := applySearchFailures(&searchOptions, opts.failOnErrors, cfg.IsVerbose()); err != nil {
+					return err
+				}
 
 				// Find matching keys first
 				matchingKeys, err := service.Search(cmd.Context(), accountID, opts.namespaceID, searchOptions)
@@ -266,32 +570,25 @@ When used with --bulk, deletes multiple keys based on filters.
 					keyNames[i] = key.Key
 				}
 
-				// Confirm deletion unless --force is used
-				if !opts.force {
-					fmt.Printf("Found %d keys matching '%s'.\n", len(keyNames), opts.searchValue)
-					fmt.Println("Sample matched keys:")
-
-					// Show the first few keys as samples
-					sampleSize := 5
-					if len(keyNames) < sampleSize {
-						sampleSize = len(keyNames)
-					}
-
-					for i := 0; i < sampleSize; i++ {
-						fmt.Printf("  - %s\n", keyNames[i])
-					}
+				var excluded int
+				if keyNames, excluded = exclusion.Apply(keyNames); excluded > 0 {
+					fmt.Printf("Excluded %d keys matching the exclusion filters\n", excluded)
+				}
+				if len(keyNames) == 0 {
+					fmt.Println("No keys left to delete after exclusions.")
+					return nil
+				}
 
-					if len(keyNames) > sampleSize {
-						fmt.Printf("  - ... and %d more\n", len(keyNames)-sampleSize)
+				if opts.plan.Enabled() {
+					if err := opts.plan.Apply("kv delete", opts.namespaceID, keyNames); err != nil {
+						return err
 					}
+				}
 
-					fmt.Print("\nAre you sure you want to delete these keys? This action cannot be undone. [y/N]: ")
-
-					reader := bufio.NewReader(os.Stdin)
-					confirmation, _ := reader.ReadString('\n')
-					confirmation = strings.TrimSpace(strings.ToLower(confirmation))
-
-					if confirmation != "y" && confirmation != "yes" {
+				// Confirm deletion unless --force is used
+				if !opts.force {
+					fmt.Printf("Found %d keys matching '%s'.\n", len(keyNames), opts.searchValue)
+					if !confirmBulkDelete(client, accountID, opts.namespaceID, keyNames, opts.sample) {
 						fmt.Println("Deletion cancelled.")
 						return nil
 					}
@@ -302,6 +599,10 @@ When used with --bulk, deletes multiple keys based on filters.
 					return nil
 				}
 
+				if err := beforeDelete(keyNames); err != nil {
+					return err
+				}
+
 				// Delete the keys
 				// Get verbosity flags
 				verbosityStr, _ := cmd.Flags().GetString("verbosity")
@@ -372,12 +673,36 @@ When used with --bulk, deletes multiple keys based on filters.
 				TagField:        opts.tagField,
 				TagValue:        opts.tagValue,
 				SearchValue:     opts.searchValue, // This is less powerful than the deep search above
+				Exclude:         exclusion,
+				Expiration:      expirationFilter,
+			}
+			if opts.journal.enabled() || archiveNamespaceID != "" {
+				bulkDeleteOptions.BeforeDelete = beforeDelete
+			}
+			if opts.plan.Enabled() {
+				bulkDeleteOptions.OnMatch = func(matched []string) error {
+					return opts.plan.Apply("kv delete", opts.namespaceID, matched)
+				}
 			}
 
 			// If we have filtering criteria but no explicit keys
 			if len(keys) == 0 && hasFilteringCriteria {
+				// Show a sample of the matched keys and ask before deleting them
+				if !opts.force && !opts.dryRun {
+					bulkDeleteOptions.BeforeDelete = func(matched []string) error {
+						if !confirmBulkDelete(client, accountID, opts.namespaceID, matched, opts.sample) {
+							return errDeleteCancelled
+						}
+						return beforeDelete(matched)
+					}
+				}
+
 				// We'll let the service handle finding matching keys
 				count, err := service.BulkDelete(cmd.Context(), accountID, opts.namespaceID, nil, bulkDeleteOptions)
+				if errors.Is(err, errDeleteCancelled) {
+					fmt.Println("Deletion cancelled.")
+					return nil
+				}
 				if err != nil {
 					return fmt.Errorf("bulk delete operation failed: %w", err)
 				}
@@ -392,6 +717,14 @@ When used with --bulk, deletes multiple keys based on filters.
 
 			// If we have explicit keys
 			if len(keys) > 0 {
+				// The keys are known up front, so check the plan once before confirming
+				if opts.plan.Enabled() {
+					if err := opts.plan.Apply("kv delete", opts.namespaceID, keys); err != nil {
+						return err
+					}
+					bulkDeleteOptions.OnMatch = nil
+				}
+
 				// Confirm deletion unless --force is used
 				if !opts.force {
 					fmt.Printf("You are about to delete %d keys. This action cannot be undone.\n", len(keys))
@@ -423,6 +756,24 @@ When used with --bulk, deletes multiple keys based on filters.
 			}
 
 			return fmt.Errorf("no keys specified for bulk deletion. Use --key, --keys, --keys-file, --prefix, --pattern, or --search")
-		}),
+		})),
 	)
 }
+
+// newDeleteExclusion builds the exclusions of a bulk delete from --exclude-prefix,
+// --exclude-pattern and the keys listed in --exclude-keys-file
+func newDeleteExclusion(prefixes []string, pattern, keysFile string) (*kv.KeyExclusion, error) {
+	var excludeKeys []string
+	if keysFile != "" {
+		fileData, err := os.ReadFile(keysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exclude keys file: %w", err)
+		}
+		for _, line := range strings.Split(string(fileData), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				excludeKeys = append(excludeKeys, line)
+			}
+		}
+	}
+	return kv.NewKeyExclusion(prefixes, pattern, excludeKeys)
+}
@@ -0,0 +1,82 @@
+package cmdutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/kv"
+)
+
+// errDeleteCancelled stops a bulk delete when the user declines the confirmation
+var errDeleteCancelled = errors.New("deletion cancelled")
+
+// confirmBulkDelete asks the user to confirm deleting keys, first showing a random
+// sample of sampleSize of them with their metadata so the user sees representative
+// keys rather than only a count
+func confirmBulkDelete(client *api.Client, accountID, namespaceID string, keys []string, sampleSize int) bool {
+	fmt.Printf("You are about to delete %d keys.\n", len(keys))
+
+	sample := sampleKeyNames(keys, sampleSize)
+	if len(sample) > 0 {
+		fmt.Printf("Random sample of %d matched keys:\n", len(sample))
+		for _, key := range sample {
+			metadata, err := kv.GetMetadata(client, accountID, namespaceID, key)
+			switch {
+			case err != nil:
+				fmt.Printf("  - %s (metadata unavailable: %s)\n", key, err)
+			case metadata == nil:
+				fmt.Printf("  - %s\n", key)
+			default:
+				encoded, _ := json.Marshal(metadata)
+				fmt.Printf("  - %s %s\n", key, encoded)
+			}
+		}
+	}
+
+	fmt.Print("\nAre you sure you want to delete these keys? This action cannot be undone. [y/N]: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	confirmation, _ := reader.ReadString('\n')
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	confirmed := confirmation == "y" || confirmation == "yes"
+	common.RecordConfirmation(fmt.Sprintf("delete %d keys", len(keys)), confirmed, false)
+	return confirmed
+}
+
+// sampleKeyNames picks up to n keys uniformly at random, returned in key order
+func sampleKeyNames(keys []string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	if n >= len(keys) {
+		sample := append([]string(nil), keys...)
+		sort.Strings(sample)
+		return sample
+	}
+
+	// A partial Fisher-Yates shuffle over the indexes, tracking only the swapped ones
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if j, ok := swapped[i]; ok {
+			return j
+		}
+		return i
+	}
+	sample := make([]string, n)
+	for i := 0; i < n; i++ {
+		j := i + rand.Intn(len(keys)-i)
+		pick := at(j)
+		swapped[j] = at(i)
+		sample[i] = keys[pick]
+	}
+	sort.Strings(sample)
+	return sample
+}
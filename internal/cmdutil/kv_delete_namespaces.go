@@ -0,0 +1,191 @@
+package cmdutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// bulkNamespaceDeleteOptions holds the selection criteria for deleting several namespaces at once
+type bulkNamespaceDeleteOptions struct {
+	namespaceIDs   string // Comma-separated namespace IDs
+	pattern        string // Regex matched against namespace titles
+	titlesFile     string // File with exact namespace titles, one per line
+	excludePattern string // Regex for titles that must never be deleted
+	concurrency    int    // Namespaces deleted at once
+	dryRun         bool
+	force          bool
+	verbose        bool
+	cfg            *config.Config // For protected namespaces
+	cmd            *cobra.Command // For --override-protection
+}
+
+// hasSelection returns true if any bulk namespace selection criteria were given
+func (o bulkNamespaceDeleteOptions) hasSelection() bool {
+	return o.namespaceIDs != "" || o.pattern != "" || o.titlesFile != ""
+}
+
+// deleteNamespacesInBulk resolves the namespaces selected by IDs, pattern and titles file,
+// removes any protected by the exclude pattern, and deletes the rest after confirmation
+func deleteNamespacesInBulk(ctx context.Context, client *api.Client, service kv.KVService, accountID string, opts bulkNamespaceDeleteOptions) error {
+	// List namespaces once so IDs can be shown with their titles
+	allNamespaces, err := service.ListNamespaces(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	byID := make(map[string]kv.Namespace, len(allNamespaces))
+	for _, ns := range allNamespaces {
+		byID[ns.ID] = ns
+	}
+
+	var selected []kv.Namespace
+	seen := make(map[string]bool)
+	addNamespace := func(ns kv.Namespace) {
+		if !seen[ns.ID] {
+			seen[ns.ID] = true
+			selected = append(selected, ns)
+		}
+	}
+
+	// Namespaces given by ID
+	if opts.namespaceIDs != "" {
+		for _, id := range strings.Split(opts.namespaceIDs, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			ns, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("namespace with ID %s not found", id)
+			}
+			addNamespace(ns)
+		}
+	}
+
+	// Namespaces matching a title pattern
+	if opts.pattern != "" {
+		matches, err := service.FindNamespacesByPattern(ctx, accountID, opts.pattern)
+		if err != nil {
+			return fmt.Errorf("failed to find namespaces by pattern: %w", err)
+		}
+		for _, ns := range matches {
+			addNamespace(ns)
+		}
+	}
+
+	// Namespaces listed by exact title in a file
+	if opts.titlesFile != "" {
+		titles, err := common.ReadItemsFromFile(opts.titlesFile, common.FileTypeText, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read titles file: %w", err)
+		}
+		if len(titles) == 0 {
+			return fmt.Errorf("titles file %s contains no namespace titles", opts.titlesFile)
+		}
+
+		found, missing, err := kv.FindNamespacesByTitles(client, accountID, titles)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("namespaces not found for titles: %s", strings.Join(missing, ", "))
+		}
+		for _, ns := range found {
+			addNamespace(ns)
+		}
+	}
+
+	// Drop protected namespaces
+	toDelete, excluded, err := kv.ExcludeNamespacesByPattern(selected, opts.excludePattern)
+	if err != nil {
+		return err
+	}
+
+	if len(excluded) > 0 {
+		fmt.Printf("Excluding %d protected namespaces matching '%s':\n", len(excluded), opts.excludePattern)
+		for _, ns := range excluded {
+			fmt.Printf("  - %s (%s)\n", ns.Title, ns.ID)
+		}
+	}
+
+	// Refuse to touch namespaces protected in config
+	for _, ns := range toDelete {
+		if err := common.CheckNamespaceProtection(opts.cmd, opts.cfg, ns.ID, ns.Title); err != nil {
+			return err
+		}
+	}
+
+	if len(toDelete) == 0 {
+		fmt.Println("No namespaces selected for deletion.")
+		return nil
+	}
+
+	// Show what will be deleted
+	headers := []string{"ID", "Title"}
+	rows := make([][]string, len(toDelete))
+	for i, ns := range toDelete {
+		rows[i] = []string{ns.ID, ns.Title}
+	}
+	fmt.Printf("Namespaces selected for deletion (%d):\n", len(toDelete))
+	common.FormatTable(headers, rows)
+
+	if opts.dryRun {
+		fmt.Printf("DRY RUN: Would delete %d namespaces and ALL of their keys\n", len(toDelete))
+		return nil
+	}
+
+	// Confirm deletion unless --force is used
+	if !opts.force {
+		fmt.Printf("\nYou are about to delete %d namespaces and ALL of their keys. This action cannot be undone.\n", len(toDelete))
+		fmt.Print("Are you sure? (y/N): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		confirmation, _ := reader.ReadString('\n')
+		confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+
+		if confirmation != "y" && confirmation != "yes" {
+			fmt.Println("Deletion cancelled.")
+			return nil
+		}
+	}
+
+	var progressCallback func(completed, total, success, failed int)
+	if opts.verbose {
+		progressCallback = func(completed, total, success, failed int) {
+			fmt.Printf("Progress: %d/%d namespaces processed (%d deleted, %d failed)\n", completed, total, success, failed)
+		}
+	}
+
+	results, err := kv.DeleteMultipleNamespacesWithProgress(client, accountID, toDelete,
+		kv.NamespaceDeleteOptions{Concurrency: opts.concurrency}, progressCallback)
+	if err != nil {
+		return err
+	}
+
+	var failedRows [][]string
+	for _, result := range results {
+		if !result.OK() {
+			failedRows = append(failedRows, []string{result.ID, result.Title, strconv.Itoa(result.Attempts), result.Error})
+		}
+	}
+
+	fmt.Printf("Successfully deleted %d/%d namespaces\n", len(results)-len(failedRows), len(results))
+	if len(failedRows) > 0 {
+		fmt.Println("Failed namespaces:")
+		common.FormatTable([]string{"ID", "Title", "Attempts", "Error"}, failedRows)
+		return fmt.Errorf("failed to delete %d namespaces", len(failedRows))
+	}
+
+	return nil
+}
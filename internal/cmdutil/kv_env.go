@@ -0,0 +1,174 @@
+package cmdutil
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// nonBindingChars matches the runs of characters that can't be part of a binding name
+var nonBindingChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// NewKVEnvCommand creates a new env command for KV
+func NewKVEnvCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID    string
+		titles       []string
+		namespaceIDs []string
+		pattern      string
+		format       string
+	}
+
+	// Create command
+	return NewCommand("env", "Print namespace IDs as wrangler.toml or .env entries", `
+Print the IDs of KV namespaces in a form that Workers deployment configs can use,
+so a namespace created or looked up with this tool can be wired into a Worker.
+
+With --format wrangler (the default) each namespace is printed as a
+[[kv_namespaces]] entry for wrangler.toml. With --format dotenv each namespace is
+printed as a NAME_KV_NAMESPACE_ID=id line. Binding names are derived from the
+namespace titles: uppercased, with other characters replaced by underscores, so
+"my-app" becomes MY_APP.
+
+Namespaces are selected by exact --title, by --namespace-id, or by titles matching
+--pattern, and are printed in title order.
+`).WithExample(`  # Print a wrangler.toml entry for a namespace
+  cache-kv-purger kv env --title my-app
+
+  # Append .env lines for every staging namespace
+  cache-kv-purger kv env --pattern "^staging-" --format dotenv >> .env
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringSliceFlag(
+		"title", nil, "Title of a namespace to print (can be repeated)", &opts.titles,
+	).WithStringSliceFlag(
+		"namespace-id", nil, "ID of a namespace to print (can be repeated)", &opts.namespaceIDs,
+	).WithStringFlag(
+		"pattern", "", "Print namespaces with titles matching this regex pattern", &opts.pattern,
+	).WithStringFlag(
+		"format", "wrangler", "Output format: wrangler or dotenv", &opts.format,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.format != "wrangler" && opts.format != "dotenv" {
+				return fmt.Errorf("unknown format '%s', use wrangler or dotenv", opts.format)
+			}
+			if len(opts.titles) == 0 && len(opts.namespaceIDs) == 0 && opts.pattern == "" {
+				return fmt.Errorf("--title, --namespace-id or --pattern is required")
+			}
+			var pattern *regexp.Regexp
+			if opts.pattern != "" {
+				var err error
+				if pattern, err = regexp.Compile(opts.pattern); err != nil {
+					return fmt.Errorf("invalid pattern '%s': %w", opts.pattern, err)
+				}
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			namespaces, err := service.ListNamespaces(cmd.Context(), accountID)
+			if err != nil {
+				return fmt.Errorf("failed to list namespaces: %w", err)
+			}
+
+			selected, err := selectEnvNamespaces(namespaces, opts.titles, opts.namespaceIDs, pattern)
+			if err != nil {
+				return err
+			}
+
+			names, err := envBindingNames(selected)
+			if err != nil {
+				return err
+			}
+
+			for i, ns := range selected {
+				if opts.format == "dotenv" {
+					fmt.Printf("%s_KV_NAMESPACE_ID=%s\n", names[i], ns.ID)
+					continue
+				}
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("# %s\n[[kv_namespaces]]\nbinding = %q\nid = %q\n", ns.Title, names[i], ns.ID)
+			}
+			return nil
+		}),
+	)
+}
+
+// selectEnvNamespaces returns the namespaces with the given titles or IDs, or titles
+// matching pattern, sorted by title. Every title and ID must exist.
+func selectEnvNamespaces(namespaces []kv.Namespace, titles, ids []string, pattern *regexp.Regexp) ([]kv.Namespace, error) {
+	byTitle := make(map[string]kv.Namespace, len(namespaces))
+	byID := make(map[string]kv.Namespace, len(namespaces))
+	for _, ns := range namespaces {
+		byTitle[ns.Title] = ns
+		byID[ns.ID] = ns
+	}
+
+	picked := make(map[string]kv.Namespace)
+	for _, title := range titles {
+		ns, ok := byTitle[title]
+		if !ok {
+			return nil, fmt.Errorf("namespace with title '%s' not found", title)
+		}
+		picked[ns.ID] = ns
+	}
+	for _, id := range ids {
+		ns, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("namespace with ID %s not found", id)
+		}
+		picked[ns.ID] = ns
+	}
+	if pattern != nil {
+		for _, ns := range namespaces {
+			if pattern.MatchString(ns.Title) {
+				picked[ns.ID] = ns
+			}
+		}
+	}
+	if len(picked) == 0 {
+		return nil, fmt.Errorf("no namespaces match pattern '%s'", pattern)
+	}
+
+	selected := make([]kv.Namespace, 0, len(picked))
+	for _, ns := range picked {
+		selected = append(selected, ns)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Title < selected[j].Title })
+	return selected, nil
+}
+
+// envBindingNames derives a binding name from each namespace title, refusing titles
+// that would give two namespaces the same binding
+func envBindingNames(namespaces []kv.Namespace) ([]string, error) {
+	names := make([]string, len(namespaces))
+	seen := make(map[string]string, len(namespaces))
+	for i, ns := range namespaces {
+		name := strings.Trim(nonBindingChars.ReplaceAllString(strings.ToUpper(ns.Title), "_"), "_")
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "KV_" + name
+		}
+		if other, dup := seen[name]; dup {
+			return nil, fmt.Errorf("namespaces '%s' and '%s' would both be bound as %s", other, ns.Title, name)
+		}
+		seen[name] = ns.Title
+		names[i] = name
+	}
+	return names, nil
+}
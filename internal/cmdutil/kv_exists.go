@@ -0,0 +1,230 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVExistsCommand creates a new exists command for KV
+func NewKVExistsCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		key         string
+		bulk        bool
+		keys        string
+		keysFile    string
+		require     string
+		concurrency int
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("exists", "Check whether keys exist in a namespace", `
+Check whether one or more keys exist, with HEAD requests that don't download values.
+
+With --key, checks a single key and fails when it is missing. With --bulk, checks
+the keys given by --keys or --keys-file (use - to read them from stdin)
+concurrently and prints the keys found and missing. The command fails unless
+every key exists, or with --require any, unless at least one does, so it can gate
+a deployment smoke check. Keys whose check fails with an API error are reported
+separately and never count as found.
+`).WithExample(`  # Check a single key
+  cache-kv-purger kv exists --namespace-id YOUR_NAMESPACE_ID --key config:site
+
+  # Check that every key a deployment needs was written
+  cache-kv-purger kv exists --namespace "My Namespace" --bulk --keys-file required-keys.txt
+
+  # Pass when at least one of the keys exists
+  cat keys.txt | cache-kv-purger kv exists --namespace-id YOUR_NAMESPACE_ID --bulk --keys-file - --require any
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"key", "", "Key to check (required unless bulk operation)", &opts.key,
+	).WithBoolFlag(
+		"bulk", false, "Check multiple keys", &opts.bulk,
+	).WithStringFlag(
+		"keys", "", "Comma-separated list of keys or @file.txt (for bulk)", &opts.keys,
+	).WithStringFlag(
+		"keys-file", "", "File containing keys, one per line, or - for stdin (for bulk)", &opts.keysFile,
+	).WithStringFlag(
+		"require", "all", "Keys that must exist for the check to pass: all or any", &opts.require,
+	).WithIntFlag(
+		"concurrency", 20, "Number of keys to check concurrently", &opts.concurrency,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			require, err := kv.ParseExistsRequirement(opts.require)
+			if err != nil {
+				return err
+			}
+
+			// Validate operation mode
+			if !opts.bulk && opts.key == "" {
+				return fmt.Errorf("either --key or --bulk is required")
+			}
+			if opts.bulk && opts.keys == "" && opts.keysFile == "" {
+				return fmt.Errorf("bulk mode requires --keys or --keys-file")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			// Single key mode
+			if !opts.bulk {
+				exists, err := service.Exists(cmd.Context(), accountID, opts.namespaceID, opts.key)
+				if err != nil {
+					return fmt.Errorf("failed to check key: %w", err)
+				}
+				if opts.outputJSON {
+					if err := common.OutputJSON(map[string]interface{}{"key": opts.key, "exists": exists}); err != nil {
+						return err
+					}
+				} else if exists {
+					fmt.Printf("Key '%s' exists\n", opts.key)
+				}
+				if !exists {
+					return fmt.Errorf("key '%s' does not exist", opts.key)
+				}
+				return nil
+			}
+
+			keys, err := readExistsKeys(opts.keys, opts.keysFile)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				return fmt.Errorf("no keys to check")
+			}
+
+			var progress func(checked, total int)
+			if !opts.outputJSON && !common.IsQuietMode() {
+				progress = func(checked, total int) {
+					fmt.Fprintf(os.Stderr, "Checked %d/%d keys...  \r", checked, total)
+				}
+			}
+
+			result, err := kv.CheckKeysExist(client, accountID, opts.namespaceID, keys, opts.concurrency, progress)
+			if err != nil {
+				return err
+			}
+			if progress != nil {
+				fmt.Fprintln(os.Stderr)
+			}
+
+			if opts.outputJSON {
+				if err := common.OutputJSON(result); err != nil {
+					return err
+				}
+			} else {
+				printExistsResult(result)
+			}
+
+			if !result.Satisfies(require) {
+				if require == kv.RequireAny {
+					return fmt.Errorf("none of the %d keys exist", result.Checked)
+				}
+				return fmt.Errorf("%d of %d keys are missing or could not be checked",
+					result.Checked-len(result.Found), result.Checked)
+			}
+			return nil
+		}),
+	)
+}
+
+// readExistsKeys reads the keys to check from --keys and --keys-file, dropping blank lines
+func readExistsKeys(keysFlag, keysFile string) ([]string, error) {
+	var keys []string
+	if keysFlag != "" {
+		if strings.HasPrefix(keysFlag, "@") {
+			fileKeys, err := readKeysFileLines(strings.TrimPrefix(keysFlag, "@"))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, fileKeys...)
+		} else {
+			keys = append(keys, strings.Split(keysFlag, ",")...)
+		}
+	}
+
+	switch keysFile {
+	case "":
+	case "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keys from stdin: %w", err)
+		}
+		keys = append(keys, strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")...)
+	default:
+		fileKeys, err := readKeysFileLines(keysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	cleaned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			cleaned = append(cleaned, key)
+		}
+	}
+	return cleaned, nil
+}
+
+// printExistsResult prints the keys found, missing and failed, then a summary line
+func printExistsResult(result *kv.ExistsResult) {
+	if len(result.Found) > 0 {
+		fmt.Printf("Found (%d):\n", len(result.Found))
+		for _, key := range result.Found {
+			fmt.Printf("  + %s\n", key)
+		}
+	}
+	if len(result.Missing) > 0 {
+		fmt.Printf("Missing (%d):\n", len(result.Missing))
+		for _, key := range result.Missing {
+			fmt.Printf("  - %s\n", key)
+		}
+	}
+	if len(result.Failed) > 0 {
+		fmt.Printf("Could not check (%d):\n", len(result.Failed))
+		printFailedKeys(result.Failed)
+	}
+	fmt.Printf("%d of %d keys exist\n", len(result.Found), result.Checked)
+}
@@ -0,0 +1,258 @@
+package cmdutil
+
+import (
+	"fmt"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVExpireCommand creates a new expire command for KV
+func NewKVExpireCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		key         string
+		bulk        bool
+		prefix      string
+		pattern     string
+		noExpiry    bool
+		ttl         string
+		expiration  string
+		concurrency int
+		batchSize   int
+		dryRun      bool
+		force       bool
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("expire", "Set an expiration on existing keys", `
+Set an expiration on keys that already exist, keeping their values and metadata.
+
+KV cannot change the expiration of a key in place, so each value is read and
+written back together with its metadata and the new expiration. Keys that are
+written elsewhere between the read and the write are overwritten with the value
+that was read.
+
+When used with --key, updates a single key.
+When used with --bulk, updates all keys matching --prefix and/or --pattern.
+With --no-expiration, only keys that never expire are updated, which gives keys
+accidentally written without a TTL one.
+
+The expiration is either a TTL from now (--ttl: seconds, a duration like 1h30m,
+or days like 7d) or an absolute time (--expiration: RFC 3339, YYYY-MM-DD, or Unix
+seconds). KV requires expirations at least 60 seconds in the future.
+`).WithExample(`  # Expire a single key in one hour
+  cache-kv-purger kv expire --namespace-id YOUR_NAMESPACE_ID --key session:abc --ttl 1h
+
+  # Expire all keys with a prefix in 7 days
+  cache-kv-purger kv expire --namespace-id YOUR_NAMESPACE_ID --bulk --prefix "session:" --ttl 7d
+
+  # Give every key written without a TTL one of 30 days
+  cache-kv-purger kv expire --namespace-id YOUR_NAMESPACE_ID --bulk --no-expiration --ttl 30d
+
+  # Expire keys matching a pattern at a fixed time
+  cache-kv-purger kv expire --namespace "My Namespace" --bulk --pattern "^tmp-.*" --expiration 2025-01-01
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"key", "", "Key to update (required unless --bulk)", &opts.key,
+	).WithBoolFlag(
+		"bulk", false, "Update all keys matching --prefix and/or --pattern", &opts.bulk,
+	).WithStringFlag(
+		"prefix", "", "Update keys with prefix (with --bulk)", &opts.prefix,
+	).WithStringFlag(
+		"pattern", "", "Update keys matching regex pattern (with --bulk)", &opts.pattern,
+	).WithBoolFlag(
+		"no-expiration", false, "Only update keys that never expire (with --bulk)", &opts.noExpiry,
+	).WithStringFlag(
+		"ttl", "", "Expire keys after this long (seconds, duration like 1h30m, or days like 7d)", &opts.ttl,
+	).WithStringFlag(
+		"expiration", "", "Expire keys at this time (RFC 3339, YYYY-MM-DD, or Unix seconds)", &opts.expiration,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 1000, "Number of keys per bulk write", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "Show which keys would be updated without updating them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Work out the expiration
+			options := kv.ExpireOptions{Concurrency: opts.concurrency, BatchSize: opts.batchSize}
+			if (opts.ttl == "") == (opts.expiration == "") {
+				return fmt.Errorf("exactly one of --ttl or --expiration is required")
+			}
+			if opts.ttl != "" {
+				ttl, err := common.ParseTTL(opts.ttl)
+				if err != nil {
+					return err
+				}
+				options.ExpirationTTL = int64(ttl.Round(time.Second) / time.Second)
+			} else {
+				at, err := common.ParseTimestamp(opts.expiration)
+				if err != nil {
+					return fmt.Errorf("invalid --expiration: %w", err)
+				}
+				options.Expiration = at.Unix()
+			}
+			if err := options.Validate(); err != nil {
+				return err
+			}
+
+			// Validate the key selection
+			if opts.bulk {
+				if opts.key != "" {
+					return fmt.Errorf("--key cannot be combined with --bulk")
+				}
+				if opts.prefix == "" && opts.pattern == "" && !opts.noExpiry {
+					return fmt.Errorf("--bulk requires --prefix, --pattern and/or --no-expiration")
+				}
+			} else {
+				if opts.key == "" {
+					return fmt.Errorf("key is required (use --key, or --bulk with --prefix or --pattern)")
+				}
+				if opts.prefix != "" || opts.pattern != "" || opts.noExpiry {
+					return fmt.Errorf("--prefix, --pattern and --no-expiration require --bulk")
+				}
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			// Expiring keys deletes them later, so respect protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+				return err
+			}
+
+			expiresAt := describeExpiration(options)
+
+			// Single key
+			if !opts.bulk {
+				if opts.dryRun {
+					fmt.Printf("DRY RUN: Would set key '%s' to expire %s\n", opts.key, expiresAt)
+					return nil
+				}
+
+				result, err := kv.ExpireKey(client, accountID, opts.namespaceID, opts.key, options)
+				if err != nil {
+					return err
+				}
+				return reportExpireResult(result, expiresAt, opts.outputJSON)
+			}
+
+			// Bulk: find the matching keys with their metadata
+			filter, err := kv.NewNameFilter(opts.prefix, "", opts.pattern)
+			if err != nil {
+				return err
+			}
+			var keys []kv.KeyValuePair
+			if filter != nil {
+				keys, err = kv.FindKeysByName(client, accountID, opts.namespaceID, filter, nil)
+			} else {
+				keys, err = kv.ListAllKeysWithOptions(client, accountID, opts.namespaceID, nil, nil)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to list keys: %w", err)
+			}
+			if opts.noExpiry {
+				keys = kv.FilterKeysByExpiration(keys, kv.WithoutExpiration)
+			}
+			if len(keys) == 0 {
+				fmt.Println("No keys found matching the criteria.")
+				return nil
+			}
+
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would set %d keys to expire %s\n", len(keys), expiresAt)
+				if cfg.IsVerbose() {
+					for _, key := range keys {
+						fmt.Printf("  - %s\n", key.Key)
+					}
+				}
+				return nil
+			}
+
+			if !common.ConfirmBatchOperation(len(keys), "keys", "set an expiration on", opts.force) {
+				fmt.Println("Update cancelled.")
+				return nil
+			}
+
+			var progress func(completed, total int)
+			if !opts.outputJSON {
+				progress = func(completed, total int) {
+					fmt.Printf("Progress: %d/%d operations...  \r", completed, total)
+				}
+			}
+
+			result, err := kv.ExpireKeys(client, accountID, opts.namespaceID, keys, options, progress)
+			if err != nil {
+				return err
+			}
+			if !opts.outputJSON {
+				fmt.Println()
+			}
+			return reportExpireResult(result, expiresAt, opts.outputJSON)
+		}),
+	)
+}
+
+// describeExpiration describes when keys with the given options expire
+func describeExpiration(options kv.ExpireOptions) string {
+	if options.ExpirationTTL > 0 {
+		return fmt.Sprintf("in %v", time.Duration(options.ExpirationTTL)*time.Second)
+	}
+	return fmt.Sprintf("at %s", time.Unix(options.Expiration, 0).UTC().Format(time.RFC3339))
+}
+
+// reportExpireResult prints how many keys were updated and returns an error if any failed
+func reportExpireResult(result *kv.ExpireResult, expiresAt string, outputJSON bool) error {
+	if outputJSON {
+		if err := common.OutputJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Updated %d keys to expire %s\n", len(result.Updated), expiresAt)
+		printFailedKeys(result.Failed)
+	}
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("failed to update %d keys", len(result.Failed))
+	}
+	return nil
+}
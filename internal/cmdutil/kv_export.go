@@ -0,0 +1,243 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVExportCommand creates a new export command for KV
+func NewKVExportCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		prefix      string
+		outputFile  string
+		metadata    bool
+		since       string
+		sinceField  string
+		concurrency int
+		estimate    bool
+		noExpiry    bool
+		hasExpiry   bool
+		errorsOut   string
+		retryErrors string
+	}
+
+	// Create command
+	return NewCommand("export", "Export keys and values to JSON", `
+Export the keys of a namespace, with their values, metadata and expiration, as a
+JSON array that can be written back with 'kv put --bulk --bulk-file'.
+
+With --since only keys whose metadata timestamp field (--since-field, default
+"updated_at") is newer than the given time are exported, and the values of
+unchanged keys are never fetched. Writers must maintain that field for this to
+work; keys without it are left out of incremental exports. Timestamps may be
+RFC 3339, YYYY-MM-DD, or Unix seconds or milliseconds.
+
+--no-expiration and --has-expiration export only keys without, or with, an
+expiration, e.g. to re-import keys written without a TTL with one.
+
+Keys whose value cannot be read are left out of the export with a warning on
+stderr. --errors-out writes them to a JSON report with the error and its class
+(not_found, rate_limited, auth, server, network, decode or other) per key, and
+--retry-errors exports only the keys listed in such a report, e.g. into a second
+file to merge with the first.
+`).WithExample(`  # Full backup of a namespace
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --output namespace-backup.json
+
+  # Back up only keys under a prefix
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --prefix "config-" --output config-backup.json
+
+  # Incremental backup of keys changed since June 1st
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --since 2024-06-01 --output changes.json
+
+  # Export keys written without a TTL
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --no-expiration --output no-ttl.json
+
+  # Record the keys that failed, then export just those once the cause is fixed
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --output backup.json --errors-out export-errors.json
+  cache-kv-purger kv export --namespace-id YOUR_NAMESPACE_ID --retry-errors export-errors.json --output backup-retry.json
+
+  # Incremental backup using a custom timestamp field
+  cache-kv-purger kv export --namespace "My Namespace" --since 2024-06-01T12:00:00Z --since-field modified --output changes.json
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"prefix", "", "Only export keys with this prefix", &opts.prefix,
+	).WithStringFlag(
+		"output", "", "Write the export to this file instead of stdout", &opts.outputFile,
+	).WithBoolFlag(
+		"metadata", true, "Include key metadata in the export", &opts.metadata,
+	).WithStringFlag(
+		"since", "", "Only export keys modified after this time", &opts.since,
+	).WithStringFlag(
+		"since-field", kv.DefaultSinceField, "Metadata field holding the modification time", &opts.sinceField,
+	).WithBoolFlag(
+		"no-expiration", false, "Only export keys that never expire", &opts.noExpiry,
+	).WithBoolFlag(
+		"has-expiration", false, "Only export keys that have an expiration", &opts.hasExpiry,
+	).WithStringFlag(
+		"errors-out", "", "Write the keys that could not be exported, with their errors, to this JSON file", &opts.errorsOut,
+	).WithStringFlag(
+		"retry-errors", "", "Only export the keys listed in an --errors-out report", &opts.retryErrors,
+	).WithIntFlag(
+		"concurrency", 0, "Number of concurrent value requests", &opts.concurrency,
+	).WithBoolFlag(
+		"estimate", false, "Estimate the API requests and duration and ask before running an expensive export", &opts.estimate,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			var since time.Time
+			if opts.since != "" {
+				since, err = common.ParseTimestamp(opts.since)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+			}
+
+			expirationFilter, err := kv.NewExpirationFilter(opts.noExpiry, opts.hasExpiry)
+			if err != nil {
+				return err
+			}
+
+			var retryKeys []string
+			if opts.retryErrors != "" {
+				report, err := kv.ReadExportErrorReport(opts.retryErrors)
+				if err != nil {
+					return err
+				}
+				retryKeys = report.Keys()
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			// Status messages would corrupt an export written to stdout
+			showStatus := opts.outputFile != ""
+
+			if opts.estimate {
+				// The listing includes metadata, so each key costs one value request
+				estimate, err := estimateOperation(client, accountID, opts.namespaceID, opts.prefix, 1, 0, opts.concurrency)
+				if err != nil {
+					return err
+				}
+				if proceed, err := confirmEstimate(cfg, "export", estimate, false, showStatus); !proceed {
+					return err
+				}
+			}
+
+			var progressCallback kv.ProgressFunc
+			if showStatus && cfg.IsVerbose() {
+				progressCallback = func(event kv.ProgressEvent) {
+					fmt.Printf("Progress: %s\n", event)
+				}
+			}
+
+			result, err := kv.ExportKeys(client, accountID, opts.namespaceID, kv.ExportOptions{
+				Prefix:          opts.prefix,
+				IncludeMetadata: opts.metadata,
+				Since:           since,
+				SinceField:      opts.sinceField,
+				Expiration:      expirationFilter,
+				Keys:            retryKeys,
+				Concurrency:     opts.concurrency,
+			}, progressCallback)
+
+			// Report failed keys even when none could be exported
+			if result != nil && opts.errorsOut != "" && (len(result.Failed) > 0 || err == nil) {
+				report := kv.NewExportErrorReport(opts.namespaceID, result.TotalKeys, result.Failed)
+				if writeErr := kv.WriteExportErrorReport(opts.errorsOut, report); writeErr != nil {
+					return writeErr
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export keys: %w", err)
+			}
+			if len(result.Failed) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d of %d keys could not be exported (%s)\n",
+					len(result.Failed), result.TotalKeys, formatFailureClasses(result.Failed))
+				if opts.errorsOut != "" {
+					fmt.Fprintf(os.Stderr, "Failed keys written to %s; export them again with --retry-errors %s\n", opts.errorsOut, opts.errorsOut)
+				} else {
+					fmt.Fprintln(os.Stderr, "Use --errors-out to save the failed keys and --retry-errors to export them again")
+				}
+			}
+
+			if err := outputResult(result.Items, opts.outputFile, true); err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+
+			if showStatus {
+				fmt.Printf("Exported %d of %d keys to %s\n", len(result.Items), result.TotalKeys, opts.outputFile)
+				if retryKeys != nil && result.TotalKeys < len(retryKeys) {
+					fmt.Printf("%d keys of the error report no longer exist\n", len(retryKeys)-result.TotalKeys)
+				}
+				if !since.IsZero() {
+					fmt.Printf("Skipped %d keys unchanged since %s and %d keys without a '%s' field\n",
+						result.Unchanged, since.UTC().Format(time.RFC3339), result.Undated, opts.sinceField)
+				}
+				if expirationFilter != kv.AnyExpiration {
+					fmt.Printf("Skipped %d keys by expiration\n", result.Filtered)
+				}
+			}
+
+			return nil
+		}),
+	)
+}
+
+// formatFailureClasses summarizes export failures per class, e.g. "3 rate_limited, 1 not_found"
+func formatFailureClasses(failures []kv.ExportFailure) string {
+	counts := kv.CountExportFailures(failures)
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, string(class))
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		ci, cj := counts[kv.ExportErrorClass(classes[i])], counts[kv.ExportErrorClass(classes[j])]
+		if ci != cj {
+			return ci > cj
+		}
+		return classes[i] < classes[j]
+	})
+
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%d %s", counts[kv.ExportErrorClass(class)], class)
+	}
+	return strings.Join(parts, ", ")
+}
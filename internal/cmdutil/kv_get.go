@@ -1,12 +1,16 @@
 package cmdutil
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
 	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/kv"
 
@@ -17,22 +21,26 @@ import (
 func NewKVGetCommand() *CommandBuilder {
 	// Define flag variables
 	var opts struct {
-		accountID   string
-		namespaceID string
-		namespace   string
-		key         string
-		bulk        bool
-		keys        string
-		prefix      string
-		pattern     string
-		searchValue string
-		tagField    string
-		tagValue    string
-		metadata    bool
-		outputFile  string
-		outputJSON  bool
-		batchSize   int
-		concurrency int
+		accountID    string
+		namespaceID  string
+		namespace    string
+		key          string
+		bulk         bool
+		keys         string
+		prefix       string
+		pattern      string
+		searchValue  string
+		failOnErrors string
+		tagField     string
+		tagValue     string
+		metadata     bool
+		outputFile   string
+		outputJSON   bool
+		pretty       bool
+		raw          bool
+		color        bool
+		batchSize    int
+		concurrency  int
 	}
 
 	// Create command
@@ -41,12 +49,22 @@ Get values for one or more keys from a KV namespace.
 
 When used with --key, gets a single key value.
 When used with --bulk, gets multiple key values based on filters.
+
+With --pretty, a JSON value is printed indented and, on a terminal, colored; other text is
+printed unchanged and binary values are refused. With --raw, the value is written byte for
+byte with nothing added, for piping into other tools.
 `).WithExample(`  # Get a single key
   cache-kv-purger kv get --namespace-id YOUR_NAMESPACE_ID --key mykey
 
   # Get a key with metadata
   cache-kv-purger kv get --namespace "My Namespace" --key mykey --metadata
 
+  # Pretty-print a JSON value, with colors even when piped into less
+  cache-kv-purger kv get --namespace-id YOUR_NAMESPACE_ID --key config --pretty --color | less -R
+
+  # Write a binary value byte for byte
+  cache-kv-purger kv get --namespace-id YOUR_NAMESPACE_ID --key logo.png --raw > logo.png
+
   # Get multiple keys
   cache-kv-purger kv get --namespace-id YOUR_NAMESPACE_ID --bulk --keys "key1,key2,key3"
 
@@ -71,15 +89,23 @@ When used with --bulk, gets multiple key values based on filters.
 	).WithStringFlag(
 		"search", "", "Get keys containing this value (for bulk)", &opts.searchValue,
 	).WithStringFlag(
-		"tag-field", "", "Get keys with this metadata field (for bulk)", &opts.tagField,
+		"fail-on-errors", "", failOnErrorsUsage, &opts.failOnErrors,
 	).WithStringFlag(
-		"tag-value", "", "Get keys with this metadata field/value (for bulk)", &opts.tagValue,
+		"tag-field", "", "Get keys with this metadata field, or dot path like meta.tags (for bulk)", &opts.tagField,
+	).WithStringFlag(
+		"tag-value", "", "Get keys with this metadata field/value, matching array elements and numbers (for bulk)", &opts.tagValue,
 	).WithBoolFlag(
 		"metadata", false, "Include metadata with values", &opts.metadata,
 	).WithStringFlag(
 		"file", "", "Write output to file instead of stdout", &opts.outputFile,
 	).WithBoolFlag(
 		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithBoolFlag(
+		"pretty", false, "Print the value alone, indented and colored if it is JSON", &opts.pretty,
+	).WithBoolFlag(
+		"raw", false, "Write the value alone, byte for byte, with no trailing newline", &opts.raw,
+	).WithBoolFlag(
+		"color", false, "Color --pretty output even when stdout is not a terminal", &opts.color,
 	).WithIntFlag(
 		"batch-size", 0, "Batch size for bulk operations", &opts.batchSize,
 	).WithIntFlag(
@@ -87,7 +113,7 @@ When used with --bulk, gets multiple key values based on filters.
 	).WithRunE(
 		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
@@ -120,6 +146,24 @@ When used with --bulk, gets multiple key values based on filters.
 				return fmt.Errorf("bulk mode requires at least one filter (--keys, --prefix, --pattern, --search, or --tag-field)")
 			}
 
+			if opts.failOnErrors != "" && (!opts.bulk || opts.searchValue == "" || opts.tagField != "") {
+				return fmt.Errorf("--fail-on-errors applies to --bulk --search without --tag-field")
+			}
+
+			// --pretty and --raw print one value on its own
+			if opts.pretty && opts.raw {
+				return fmt.Errorf("--pretty and --raw cannot be used together")
+			}
+			if (opts.pretty || opts.raw) && (opts.bulk || opts.outputJSON) {
+				return fmt.Errorf("--pretty and --raw print a single value and cannot be used with --bulk or --json")
+			}
+			if opts.raw && opts.metadata {
+				return fmt.Errorf("--raw writes the value alone; use --pretty or --json to see metadata")
+			}
+			if opts.color && !opts.pretty {
+				return fmt.Errorf("--color only applies to --pretty")
+			}
+
 			// Single key mode
 			if !opts.bulk {
 				key, err := service.Get(cmd.Context(), accountID, opts.namespaceID, opts.key, kv.ServiceGetOptions{
@@ -133,6 +177,17 @@ When used with --bulk, gets multiple key values based on filters.
 				if opts.outputJSON {
 					return outputResult(key, opts.outputFile, true)
 				}
+				if opts.raw {
+					return writeRawValue(key.Value, opts.outputFile)
+				}
+				if opts.pretty {
+					if opts.outputFile != "" {
+						render.SetColor(false)
+					} else if opts.color {
+						render.SetColor(true)
+					}
+					return writePrettyValue(key, opts.metadata, opts.outputFile)
+				}
 
 				// If we're writing to a file, just write the raw value
 				if opts.outputFile != "" {
@@ -207,6 +262,11 @@ When used with --bulk, gets multiple key values based on filters.
 					BatchSize:       opts.batchSize,
 					Concurrency:     opts.concurrency,
 				}
+				if opts.searchValue != "" && opts.tagField == "" {
+					if err := applySearchFailures(&searchOptions, opts.failOnErrors, cfg.IsVerbose()); err != nil {
+						return err
+					}
+				}
 
 				matchingKeys, err := service.Search(cmd.Context(), accountID, opts.namespaceID, searchOptions)
 				if err != nil {
@@ -318,6 +378,56 @@ func extractKeys(pairs []kv.KeyValuePair) []string {
 	return keys
 }
 
+// writeRawValue writes a value byte for byte, with nothing added, to a file or stdout
+func writeRawValue(value, filePath string) error {
+	if filePath != "" {
+		return os.WriteFile(filePath, []byte(value), 0644)
+	}
+	_, err := io.WriteString(common.DataOutput(), value)
+	return err
+}
+
+// writePrettyValue prints a value for --pretty: indented and colored if it is JSON, unchanged
+// if it is other text. With metadata, the key, its expiration and metadata are printed
+// as one JSON document with the value. Binary values are refused, since they garble
+// terminals and can't be pretty-printed.
+func writePrettyValue(pair *kv.KeyValuePair, withMetadata bool, filePath string) error {
+	if !utf8.ValidString(pair.Value) || strings.ContainsRune(pair.Value, 0) {
+		return fmt.Errorf("value of key '%s' is binary (%d bytes), not JSON; use --raw or --file to write it byte for byte",
+			pair.Key, len(pair.Value))
+	}
+
+	document := []byte(pair.Value)
+	if withMetadata {
+		entry := map[string]interface{}{"key": pair.Key, "value": pair.Value}
+		if json.Valid([]byte(pair.Value)) {
+			entry["value"] = json.RawMessage(pair.Value)
+		}
+		if pair.Metadata != nil {
+			entry["metadata"] = pair.Metadata
+		}
+		if pair.Expiration > 0 {
+			entry["expiration"] = pair.Expiration
+		}
+		var err error
+		if document, err = json.Marshal(entry); err != nil {
+			return fmt.Errorf("failed to encode key: %w", err)
+		}
+	}
+
+	output, ok := render.PrettyJSON(document)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Value of key '%s' is not JSON; printing it unchanged\n", pair.Key)
+		output = pair.Value
+	}
+
+	if filePath != "" {
+		return os.WriteFile(filePath, []byte(output+"\n"), 0644)
+	}
+	fmt.Fprintln(common.DataOutput(), output)
+	return nil
+}
+
 // Helper function to output results to stdout or file
 func outputResult(data interface{}, filePath string, asJSON bool) error {
 	jsonData, err := common.ToJSON(data)
@@ -329,6 +439,6 @@ func outputResult(data interface{}, filePath string, asJSON bool) error {
 		return os.WriteFile(filePath, jsonData, 0644)
 	}
 
-	fmt.Println(string(jsonData))
+	fmt.Fprintln(common.DataOutput(), string(jsonData))
 	return nil
 }
@@ -0,0 +1,258 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVJSONCommand creates the json command group for KV
+func NewKVJSONCommand() *CommandBuilder {
+	return NewCommand("json", "Read and modify paths inside JSON values", `
+Read or change a single path inside a value stored as JSON, instead of editing
+the whole value by hand.
+
+Paths use dots for object fields and [N] for array elements, for example
+.config.flags.enabled or .items[0].name.
+`).WithExample(`  # Read a field
+  cache-kv-purger kv json get --namespace-id YOUR_NAMESPACE_ID --key settings --path .config.flags.enabled
+
+  # Change a field, keeping the key's expiration and metadata
+  cache-kv-purger kv json set --namespace-id YOUR_NAMESPACE_ID --key settings --path .config.flags.enabled --value true
+`).WithSubCommand(
+		NewKVJSONGetCommand().Build(),
+	).WithSubCommand(
+		NewKVJSONSetCommand().Build(),
+	)
+}
+
+// NewKVJSONGetCommand creates a new json get command for KV
+func NewKVJSONGetCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		key         string
+		path        string
+		raw         bool
+	}
+
+	// Create command
+	return NewCommand("get", "Get a path inside a JSON value", `
+Parse the value of a key as JSON and print the part at --path. Objects and arrays
+are printed as indented JSON. Without --path the whole document is printed.
+`).WithExample(`  # Read a nested field
+  cache-kv-purger kv json get --namespace-id YOUR_NAMESPACE_ID --key settings --path .config.flags.enabled
+
+  # Print a string without quotes
+  cache-kv-purger kv json get --namespace "My Namespace" --key settings --path .items[0].name --raw
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"key", "", "Key holding the JSON value", &opts.key,
+	).WithStringFlag(
+		"path", ".", "Path inside the JSON value, e.g. .config.flags.enabled", &opts.path,
+	).WithBoolFlag(
+		"raw", false, "Print strings without JSON quotes", &opts.raw,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+			if opts.key == "" {
+				return fmt.Errorf("key is required")
+			}
+
+			doc, err := kv.GetJSONValue(client, accountID, opts.namespaceID, opts.key)
+			if err != nil {
+				return err
+			}
+
+			value, err := common.GetJSONPath(doc, opts.path)
+			if err != nil {
+				return err
+			}
+
+			if s, ok := value.(string); ok && opts.raw {
+				fmt.Println(s)
+				return nil
+			}
+			return common.OutputJSON(value)
+		}),
+	)
+}
+
+// NewKVJSONSetCommand creates a new json set command for KV
+func NewKVJSONSetCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		key         string
+		path        string
+		value       string
+		asString    bool
+		dryRun      bool
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("set", "Set a path inside a JSON value", `
+Parse the value of a key as JSON, set the part at --path and write the value back.
+The key's expiration and metadata are kept. Objects missing along the path are
+created; array elements must already exist.
+
+--value is parsed as JSON, so true, 42, null, "text" and {"a":1} keep their type.
+A value that is not valid JSON is stored as a string, as is any value with --string.
+
+The value is read, modified and written in separate requests. KV has no
+compare-and-swap, so a write to the same key in between is overwritten.
+`).WithExample(`  # Enable a flag
+  cache-kv-purger kv json set --namespace-id YOUR_NAMESPACE_ID --key settings --path .config.flags.enabled --value true
+
+  # Store a number as a string
+  cache-kv-purger kv json set --namespace-id YOUR_NAMESPACE_ID --key settings --path .version --value 42 --string
+
+  # Preview the new value without writing it
+  cache-kv-purger kv json set --namespace "My Namespace" --key settings --path .items[0].name --value renamed --dry-run
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"key", "", "Key holding the JSON value", &opts.key,
+	).WithStringFlag(
+		"path", "", "Path inside the JSON value, e.g. .config.flags.enabled", &opts.path,
+	).WithStringFlag(
+		"value", "", "New value, parsed as JSON", &opts.value,
+	).WithBoolFlag(
+		"string", false, "Store --value as a string without parsing it", &opts.asString,
+	).WithBoolFlag(
+		"dry-run", false, "Show the updated value without writing it", &opts.dryRun,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+			if opts.key == "" {
+				return fmt.Errorf("key is required")
+			}
+			if opts.path == "" {
+				return fmt.Errorf("path is required")
+			}
+			if !cmd.Flags().Changed("value") {
+				return fmt.Errorf("value is required")
+			}
+
+			// Refuse to rewrite values in protected namespaces
+			if !opts.dryRun {
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+					return err
+				}
+			}
+
+			newValue := parseJSONFlagValue(opts.value, opts.asString)
+
+			previous := "unset"
+			result, err := kv.UpdateJSONValue(client, accountID, opts.namespaceID, opts.key, func(doc interface{}) (interface{}, error) {
+				if old, err := common.GetJSONPath(doc, opts.path); err == nil {
+					previous = formatJSONValue(old)
+				}
+				return common.SetJSONPath(doc, opts.path, newValue)
+			}, opts.dryRun)
+			if err != nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				return common.OutputJSON(result)
+			}
+
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would set %s in key '%s' to %s\n", opts.path, opts.key, formatJSONValue(newValue))
+				fmt.Printf("Updated value:\n%s\n", result.Value)
+				return nil
+			}
+
+			fmt.Printf("Set %s in key '%s' to %s (was %s)\n", opts.path, opts.key,
+				formatJSONValue(newValue), previous)
+			return nil
+		}),
+	)
+}
+
+// parseJSONFlagValue parses a flag value as JSON, falling back to a plain string
+func parseJSONFlagValue(value string, asString bool) interface{} {
+	if asString {
+		return value
+	}
+	doc, err := kv.DecodeJSONValue(value)
+	if err != nil {
+		return value
+	}
+	return doc
+}
+
+// formatJSONValue formats a value compactly for status messages
+func formatJSONValue(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
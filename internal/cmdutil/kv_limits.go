@@ -0,0 +1,222 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVLimitsCommand creates a new limits command for KV
+func NewKVLimitsCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		countKeys   bool
+		maxPages    int
+		keyLimit    int
+		concurrency int
+		strict      bool
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("limits", "Check an account's KV capacity against Cloudflare limits", `
+Report how close an account is to its KV limits, so teams can monitor capacity.
+
+Shows the number of namespaces against the cap of 1000 per account and the API rate
+limit quota reported by Cloudflare while checking (or the documented 1200 requests per
+5 minutes when responses don't report it), along with the run's request rate and
+in-flight limit.
+
+KV has no fixed limit on keys per namespace, but namespace_key_limit in the config (or
+--key-limit) caps bulk writes. With --keys, the keys of every namespace are counted,
+listing up to --max-pages pages of 1000 keys each, and compared against that limit.
+Usage above 80% of a limit is a warning; with --strict, warnings and exceeded limits
+make the command fail, for monitoring jobs.
+`).WithExample(`  # Check the namespace count and API quota
+  cache-kv-purger kv limits --account-id YOUR_ACCOUNT_ID
+
+  # Also count the keys of every namespace against a 5 million key budget
+  cache-kv-purger kv limits --account-id YOUR_ACCOUNT_ID --keys --key-limit 5000000
+
+  # Fail a monitoring job when any limit is close
+  cache-kv-purger kv limits --account-id YOUR_ACCOUNT_ID --strict --json
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithBoolFlag(
+		"keys", false, "Count the keys of every namespace", &opts.countKeys,
+	).WithIntFlag(
+		"max-pages", kv.EstimateMaxPages, "Pages of 1000 keys listed per namespace with --keys; larger namespaces are shown as lower bounds", &opts.maxPages,
+	).WithIntFlag(
+		"key-limit", 0, "Keys a namespace may hold (overrides namespace_key_limit in config)", &opts.keyLimit,
+	).WithIntFlag(
+		"concurrency", 5, "Number of namespaces counted concurrently with --keys", &opts.concurrency,
+	).WithBoolFlag(
+		"strict", false, "Fail when any usage is above 80% of its limit", &opts.strict,
+	).WithBoolFlag(
+		"json", false, "Output the report as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.maxPages <= 0 {
+				return fmt.Errorf("--max-pages must be positive")
+			}
+			if opts.keyLimit < 0 {
+				return fmt.Errorf("--key-limit cannot be negative")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			keyLimit := opts.keyLimit
+			if keyLimit == 0 {
+				keyLimit = cfg.GetNamespaceKeyLimit()
+			}
+
+			if opts.countKeys && !opts.outputJSON && !common.IsQuietMode() {
+				fmt.Fprintln(os.Stderr, "Counting keys in every namespace...")
+			}
+			limits, err := kv.CheckAccountLimits(client, accountID, kv.LimitsOptions{
+				CountKeys:   opts.countKeys,
+				MaxPages:    opts.maxPages,
+				KeyLimit:    keyLimit,
+				Concurrency: opts.concurrency,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to check limits: %w", err)
+			}
+
+			if opts.outputJSON {
+				if err := common.OutputJSON(limits); err != nil {
+					return err
+				}
+			} else {
+				printAccountLimits(accountID, limits, opts.countKeys, opts.maxPages)
+			}
+
+			if opts.strict {
+				switch limits.Worst() {
+				case kv.LimitExceeded:
+					return fmt.Errorf("account %s has reached a KV limit", accountID)
+				case kv.LimitWarning:
+					return fmt.Errorf("account %s is above 80%% of a KV limit", accountID)
+				}
+			}
+			return nil
+		}),
+	)
+}
+
+// printAccountLimits prints a limits report as a key-value list, the key counts as a table
+// and guidance for what wasn't checked
+func printAccountLimits(accountID string, limits *kv.AccountLimits, countedKeys bool, maxPages int) {
+	fmt.Println(render.Bold(fmt.Sprintf("KV limits of account %s:", accountID)))
+
+	pairs := []render.Pair{
+		{Key: "Namespaces", Value: fmt.Sprintf("%d of %d (%s)", limits.Namespaces, limits.NamespaceLimit,
+			percentOf(limits.Namespaces, limits.NamespaceLimit))},
+		{Key: "Namespace status", Value: limitStatusLabel(limits.NamespaceStatus)},
+	}
+
+	keyLimit := "none (set namespace_key_limit in config or pass --key-limit)"
+	if limits.KeyLimit > 0 {
+		keyLimit = fmt.Sprintf("%d keys per namespace", limits.KeyLimit)
+	}
+	pairs = append(pairs, render.Pair{Key: "Key limit", Value: keyLimit})
+
+	rate := limits.RateLimit
+	if rate.Reported {
+		quota := fmt.Sprintf("%d remaining", rate.Remaining)
+		if rate.Limit > 0 {
+			quota = fmt.Sprintf("%d of %d remaining", rate.Remaining, rate.Limit)
+		}
+		pairs = append(pairs,
+			render.Pair{Key: "API quota", Value: fmt.Sprintf("%s, resets in %s", quota, time.Duration(rate.ResetSeconds)*time.Second)},
+			render.Pair{Key: "API quota status", Value: limitStatusLabel(rate.Status)},
+		)
+	} else {
+		pairs = append(pairs, render.Pair{Key: "API quota", Value: fmt.Sprintf("not reported by the API; the documented limit is %d requests per %s",
+			rate.Limit, time.Duration(rate.WindowSeconds)*time.Second)})
+	}
+	pairs = append(pairs, render.Pair{Key: "Request pacing", Value: fmt.Sprintf("%d requests/s, %d in flight (--rate-limit, --max-concurrency)",
+		rate.RequestsPerSec, rate.MaxConcurrency)})
+	render.PrintKeyValues(pairs)
+
+	if !countedKeys {
+		fmt.Println(render.Dim("Keys per namespace were not counted; use --keys to count them."))
+		return
+	}
+
+	usage := append([]kv.NamespaceKeyUsage(nil), limits.KeyUsage...)
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Keys > usage[j].Keys })
+
+	fmt.Println()
+	fmt.Println(render.Bold(fmt.Sprintf("Keys per namespace (%d):", len(usage))))
+	headers := []string{"Title", "ID", "Keys"}
+	if limits.KeyLimit > 0 {
+		headers = append(headers, "Of Limit")
+	}
+	headers = append(headers, "Status")
+	table := render.NewTable(headers...)
+
+	lowerBounds := 0
+	for _, entry := range usage {
+		if entry.Error != "" {
+			cells := []string{entry.Title, entry.ID, "-"}
+			if limits.KeyLimit > 0 {
+				cells = append(cells, "-")
+			}
+			table.AddRow(append(cells, render.Red("Error: "+entry.Error))...)
+			continue
+		}
+
+		keys := fmt.Sprintf("%d", entry.Keys)
+		if !entry.KeysExact {
+			keys = fmt.Sprintf("%d+", entry.Keys)
+			lowerBounds++
+		}
+		cells := []string{entry.Title, entry.ID, keys}
+		if limits.KeyLimit > 0 {
+			cells = append(cells, percentOf(entry.Keys, limits.KeyLimit))
+		}
+		table.AddRow(append(cells, limitStatusLabel(entry.Status))...)
+	}
+	table.Print()
+
+	if lowerBounds > 0 {
+		fmt.Println(render.Dim(fmt.Sprintf("%d namespaces have more than %d keys (%d pages); raise --max-pages to count them in full.",
+			lowerBounds, maxPages*1000, maxPages)))
+	}
+}
+
+// limitStatusLabel renders a limit status, colored
+func limitStatusLabel(status kv.LimitStatus) string {
+	switch status {
+	case kv.LimitWarning:
+		return render.Yellow("Warning (above 80%)")
+	case kv.LimitExceeded:
+		return render.Red("Exceeded")
+	default:
+		return render.Green("OK")
+	}
+}
+
+// percentOf formats used as a percentage of limit
+func percentOf(used, limit int) string {
+	if limit <= 0 {
+		return "-"
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", 100*float64(used)/float64(limit)), ".0") + "%"
+}
@@ -3,9 +3,13 @@ package cmdutil
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/common/render"
 	"cache-kv-purger/internal/config"
 	"cache-kv-purger/internal/kv"
 
@@ -16,25 +20,44 @@ import (
 func NewKVListCommand() *CommandBuilder {
 	// Define flag variables
 	var opts struct {
-		accountID   string
-		namespaceID string
-		namespace   string
-		key         string
-		prefix      string
-		pattern     string
-		limit       int
-		cursor      string
-		metadata    bool
-		values      bool
-		searchValue string
-		tagField    string
-		tagValue    string
-		batchSize   int
-		concurrency int
-		outputJSON  bool
-		verbose     bool
-		debug       bool
-		all         bool
+		accountID    string
+		namespaceID  string
+		namespace    string
+		key          string
+		prefix       string
+		pattern      string
+		limit        int
+		offset       int
+		afterKey     string
+		cursor       string
+		page         int
+		cacheCursors bool
+		metadata     bool
+		metaField    string
+		values       bool
+		searchValue  string
+		failOnErrors string
+		tagField     string
+		tagValue     string
+		nameContains string
+		nameRegex    string
+		keysOnly     bool
+		format       string
+		sortBy       string
+		reverse      bool
+		sizes        bool
+		batchSize    int
+		concurrency  int
+		outputJSON   bool
+		verbose      bool
+		debug        bool
+		all          bool
+		estimate     bool
+		countOnly    bool
+		perPage      int
+		noExpiration bool
+		hasExpiry    bool
+		fromExport   string
 	}
 
 	// Create command
@@ -43,6 +66,37 @@ List KV namespaces or keys in a namespace.
 
 When used without --namespace-id or --namespace, lists all namespaces in the account.
 When used with --namespace-id or --namespace, lists keys in the specified namespace.
+
+--name-contains and --name-regex search by key name only. When no metadata or value
+criteria are given, names are filtered during pagination without fetching any
+metadata, which is the cheapest way to search a large namespace.
+
+--metadata shows the metadata the list endpoint returns with each key, so it costs no
+extra requests. --metadata-field shows a single metadata field as its own column, and
+limits the metadata in JSON output to that field.
+
+--no-expiration and --has-expiration keep only keys without, or with, an
+expiration, e.g. to find keys accidentally written without a TTL. They filter each
+page after it is listed, so use --all to see every match.
+
+With a search, --limit caps the matches: keys are checked in name order and the
+scan stops as soon as enough matches are found, instead of reading the metadata
+of every key. --offset skips that many matches first; --after-key only checks
+keys sorted after the given name, and is cheaper than a large --offset since the
+skipped keys need no metadata requests. When the scan stops early, the last key
+shown is printed so the next call can continue from it with --after-key.
+
+Without --all, JSON output of keys is an object with the page of keys, its count,
+has_more and the cursor of the next page, so scripts can page with --cursor
+themselves. With --all it is a plain array of keys.
+
+With --from-export, keys are listed and searched in a file written by 'kv export'
+instead of the namespace, without any API requests, e.g. to check offline which keys
+a filter selects before deleting or purging them. Sizes there come from the values in
+the file.
+
+--sort applies to every output, table, JSON and --keys-only alike; sorting by size
+fetches the value sizes first, with one HEAD request per key.
 `).WithExample(`  # List all namespaces
   cache-kv-purger kv list --account-id YOUR_ACCOUNT_ID
 
@@ -58,11 +112,43 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
   # List keys with metadata
   cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --metadata
 
+  # Show one metadata field as a column
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --metadata-field "status"
+
   # Search for keys containing a value (deep recursive search in metadata)
   cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --search "product-image"
   
   # Search for keys with specific metadata field
   cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --tag-field "status" --tag-value "archived"
+
+  # Stop after the first 50 matches, then continue after the last key shown
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --search "product-image" --limit 50
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --search "product-image" --limit 50 --after-key LAST_KEY_SHOWN
+
+  # Largest values first, with sizes fetched via HEAD requests
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --all --sort size --reverse
+
+  # Keys expiring soonest first, with a metadata summary
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --all --metadata --sort expiration
+
+  # Find keys written without a TTL
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --all --no-expiration --keys-only
+
+  # Page through keys from a script, passing each page's cursor to the next call
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --json --limit 1000
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --json --limit 1000 --cursor CURSOR_FROM_PREVIOUS_PAGE
+
+  # Jump to page 5, reusing cursors cached by earlier invocations
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --page 5 --cache-cursors
+
+  # Count the keys with a prefix, printing just the number
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --prefix "session-" --count-only
+
+  # Fast name-only search, printing just the matching key names
+  cache-kv-purger kv list --namespace-id YOUR_NAMESPACE_ID --name-regex "^session-[0-9]+$" --keys-only
+
+  # Search a backup made with 'kv export' offline
+  cache-kv-purger kv list --from-export backup.json --search "product-image" --keys-only
 `).WithStringFlag(
 		"account-id", "", "Cloudflare account ID", &opts.accountID,
 	).WithStringFlag(
@@ -76,23 +162,51 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 	).WithStringFlag(
 		"pattern", "", "Filter keys by regex pattern", &opts.pattern,
 	).WithIntFlag(
-		"limit", 0, "Maximum number of items to return", &opts.limit,
+		"limit", 0, "Maximum number of items to return (with a search, stop scanning after this many matches)", &opts.limit,
+	).WithIntFlag(
+		"offset", 0, "Skip this many search matches before showing any", &opts.offset,
+	).WithStringFlag(
+		"after-key", "", "Only search keys sorted after this key name", &opts.afterKey,
 	).WithStringFlag(
 		"cursor", "", "Pagination cursor", &opts.cursor,
+	).WithIntFlag(
+		"page", 0, "Page of keys to show, starting at 1 (pages through the earlier pages unless their cursors are cached)", &opts.page,
+	).WithBoolFlag(
+		"cache-cursors", false, "Cache page cursors for an hour so later --page requests start from them (or set "+config.EnvCacheCursors+")", &opts.cacheCursors,
 	).WithBoolFlag(
-		"metadata", false, "Include metadata with keys", &opts.metadata,
+		"metadata", false, "Show the metadata returned with each key (no extra requests)", &opts.metadata,
+	).WithStringFlag(
+		"metadata-field", "", "Show this metadata field as a column (in JSON output, keep only this field)", &opts.metaField,
 	).WithBoolFlag(
 		"values", false, "Include values with keys (slower for large result sets)", &opts.values,
 	).WithStringFlag(
 		"search", "", "Search for keys containing this value (deep recursive search in metadata)", &opts.searchValue,
 	).WithStringFlag(
-		"tag-field", "", "Metadata field to filter by", &opts.tagField,
+		"fail-on-errors", "", failOnErrorsUsage, &opts.failOnErrors,
+	).WithStringFlag(
+		"tag-field", "", "Metadata field to filter by, or a dot path like meta.tags", &opts.tagField,
+	).WithStringFlag(
+		"tag-value", "", "Value to match in the tag field (or any element of an array field)", &opts.tagValue,
 	).WithStringFlag(
-		"tag-value", "", "Value to match in the tag field", &opts.tagValue,
+		"name-contains", "", "Search for keys whose name contains this substring", &opts.nameContains,
+	).WithStringFlag(
+		"name-regex", "", "Search for keys whose name matches this regex", &opts.nameRegex,
+	).WithBoolFlag(
+		"keys-only", false, "Output only key names, one per line", &opts.keysOnly,
+	).WithStringFlag(
+		"format", "table", "Output format: table or json", &opts.format,
+	).WithStringFlag(
+		"sort", "", "Sort keys by key, expiration, or size", &opts.sortBy,
+	).WithBoolFlag(
+		"reverse", false, "Reverse the sort order", &opts.reverse,
+	).WithBoolFlag(
+		"sizes", false, "Show value sizes (one HEAD request per key)", &opts.sizes,
 	).WithIntFlag(
 		"batch-size", 0, "Batch size for bulk operations", &opts.batchSize,
 	).WithIntFlag(
 		"concurrency", 0, "Number of concurrent operations", &opts.concurrency,
+	).WithBoolFlag(
+		"estimate", false, "Estimate the API requests and duration of a search and ask before running an expensive one", &opts.estimate,
 	).WithBoolFlag(
 		"json", false, "Output as JSON", &opts.outputJSON,
 	).WithBoolFlag(
@@ -101,17 +215,175 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 		"debug", false, "Enable debug output", &opts.debug,
 	).WithBoolFlag(
 		"all", false, "Fetch all keys (automatically handle pagination)", &opts.all,
+	).WithBoolFlag(
+		"no-expiration", false, "Only show keys that never expire", &opts.noExpiration,
+	).WithBoolFlag(
+		"has-expiration", false, "Only show keys that have an expiration", &opts.hasExpiry,
+	).WithBoolFlag(
+		"count-only", false, "Print only the number of keys (with --prefix, the keys with that prefix), without keeping them in memory", &opts.countOnly,
+	).WithIntFlag(
+		"per-page", kv.DefaultNamespacesPerPage, "Namespaces fetched per API page when listing namespaces (5-100)", &opts.perPage,
+	).WithStringFlag(
+		"from-export", "", "List and search the keys of this 'kv export' file instead of the namespace, without API requests", &opts.fromExport,
 	).WithRunE(
-		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+		WithExportOrClient(func(cmd *cobra.Command, args []string, cfg *config.Config, items []kv.BulkWriteItem) error {
+			switch opts.format {
+			case "", "table":
+			case "json":
+				opts.outputJSON = true
+			default:
+				return fmt.Errorf("invalid format '%s': must be table or json", opts.format)
+			}
+			if err := validateKeySort(opts.sortBy); err != nil {
+				return err
+			}
+			if opts.key != "" || opts.cursor != "" || opts.page > 0 {
+				return fmt.Errorf("--key, --cursor and --page need the API and cannot be combined with --from-export")
+			}
+			if opts.pattern != "" && opts.nameRegex != "" {
+				return fmt.Errorf("--pattern and --name-regex cannot be combined")
+			}
+			if opts.limit < 0 || opts.offset < 0 {
+				return fmt.Errorf("--limit and --offset cannot be negative")
+			}
+			expirationFilter, err := kv.NewExpirationFilter(opts.noExpiration, opts.hasExpiry)
+			if err != nil {
+				return err
+			}
+
+			nameRegex := opts.nameRegex
+			if nameRegex == "" {
+				nameRegex = opts.pattern
+			}
+			keys, err := kv.SearchExport(items, kv.SearchOptions{
+				SearchValue:  opts.searchValue,
+				TagField:     opts.tagField,
+				TagValue:     opts.tagValue,
+				Prefix:       opts.prefix,
+				NameContains: opts.nameContains,
+				NameRegex:    nameRegex,
+				Expiration:   expirationFilter,
+				Limit:        opts.limit,
+				Offset:       opts.offset,
+				AfterKey:     opts.afterKey,
+			})
+			if err != nil {
+				return err
+			}
+
+			if opts.countOnly {
+				fmt.Println(len(keys))
+				return nil
+			}
+			// The export holds the values, so their sizes need no requests
+			var sizes map[string]int64
+			if opts.sizes || opts.sortBy == sortBySize {
+				sizes = make(map[string]int64, len(items))
+				for _, item := range items {
+					sizes[item.Key] = int64(kv.ItemSize(item))
+				}
+			}
+			sortKeys(keys, opts.sortBy, sizes, opts.reverse)
+			if opts.outputJSON {
+				var output interface{} = keys
+				if opts.keysOnly {
+					output = keyNames(keys)
+				} else if opts.metaField != "" {
+					output = selectMetadataField(keys, opts.metaField)
+				}
+				return common.OutputJSON(output)
+			}
+			if opts.keysOnly {
+				printKeyNames(keys)
+				return nil
+			}
+
+			fmt.Println(render.Bold(fmt.Sprintf("Keys in export %s (%d of %d):", opts.fromExport, len(keys), len(items))))
+			renderKeyTable(keys, keyTableOptions{
+				showMetadata:  opts.metadata,
+				metadataField: opts.metaField,
+				sizes:         sizes,
+			})
+			return nil
+		}, WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if cmd.Flags().Changed("per-page") {
+				if err := kv.SetNamespacesPerPage(opts.perPage); err != nil {
+					return err
+				}
+			}
+
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
 
+			// Validate output options
+			switch opts.format {
+			case "", "table":
+			case "json":
+				opts.outputJSON = true
+			default:
+				return fmt.Errorf("invalid format '%s': must be table or json", opts.format)
+			}
+			if err := validateKeySort(opts.sortBy); err != nil {
+				return err
+			}
+			if opts.sortBy == sortBySize {
+				opts.sizes = true
+			}
+			if opts.page < 0 {
+				return fmt.Errorf("--page must be at least 1")
+			}
+			if opts.page > 0 && (opts.cursor != "" || opts.all) {
+				return fmt.Errorf("--page cannot be combined with --cursor or --all")
+			}
+			searching := opts.searchValue != "" || opts.tagField != "" || opts.nameContains != "" || opts.nameRegex != ""
+			if opts.limit < 0 || opts.offset < 0 {
+				return fmt.Errorf("--limit and --offset cannot be negative")
+			}
+			if (opts.offset > 0 || opts.afterKey != "") && !searching {
+				return fmt.Errorf("--offset and --after-key require a search (--search, --tag-field, --name-contains or --name-regex)")
+			}
+			expirationFilter, err := kv.NewExpirationFilter(opts.noExpiration, opts.hasExpiry)
+			if err != nil {
+				return err
+			}
+			if !opts.cacheCursors {
+				opts.cacheCursors, _ = strconv.ParseBool(os.Getenv(config.EnvCacheCursors))
+			}
+
 			// Create KV service
 			service := kv.NewKVService(client)
 
+			// Sort keys, fetching the value sizes that sorting by size, or a table with
+			// --sizes, needs
+			sortListedKeys := func(keys []kv.KeyValuePair, table bool) (map[string]int64, error) {
+				var sizes map[string]int64
+				if len(keys) > 0 && (opts.sortBy == sortBySize || (table && opts.sizes)) {
+					sizes, err = kv.GetValueSizes(client, accountID, opts.namespaceID, keyNames(keys), opts.concurrency)
+					if err != nil {
+						return nil, err
+					}
+				}
+				sortKeys(keys, opts.sortBy, sizes, opts.reverse)
+				return sizes, nil
+			}
+
+			// Sort keys and render them as a table, fetching value sizes if requested
+			renderKeys := func(keys []kv.KeyValuePair) error {
+				sizes, err := sortListedKeys(keys, true)
+				if err != nil {
+					return err
+				}
+				renderKeyTable(keys, keyTableOptions{
+					showMetadata:  opts.metadata,
+					metadataField: opts.metaField,
+					sizes:         sizes,
+				})
+				return nil
+			}
+
 			// Handle namespace ID resolution if namespace name is provided
 			if opts.namespace != "" && opts.namespaceID == "" {
 				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
@@ -121,9 +393,16 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 				opts.namespaceID = nsID
 			}
 
+			if opts.countOnly && opts.namespaceID == "" {
+				return fmt.Errorf("--count-only requires --namespace-id or --namespace")
+			}
+
 			// If namespace ID is not provided, list namespaces
 			if opts.namespaceID == "" {
 				// Create a context with verbosity flags
+				if opts.debug {
+					common.RaiseLogLevel(common.VerbosityDebug)
+				}
 				verboseCtx := context.WithValue(cmd.Context(), common.VerboseKey, opts.verbose)
 				ctx := context.WithValue(verboseCtx, common.DebugKey, opts.debug)
 
@@ -139,13 +418,26 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 				}
 
 				// Table format
-				headers := []string{"ID", "Title"}
-				rows := make([][]string, len(namespaces))
-				for i, ns := range namespaces {
-					rows[i] = []string{ns.ID, ns.Title}
+				table := render.NewTable("ID", "Title")
+				for _, ns := range namespaces {
+					table.AddRow(ns.ID, ns.Title)
 				}
-				fmt.Printf("Namespaces (%d):\n", len(namespaces))
-				common.FormatTable(headers, rows)
+				fmt.Println(render.Bold(fmt.Sprintf("Namespaces (%d):", len(namespaces))))
+				table.Print()
+				return nil
+			}
+
+			// Count keys without listing them out
+			if opts.countOnly {
+				if opts.key != "" || opts.pattern != "" || opts.searchValue != "" || opts.tagField != "" ||
+					opts.nameContains != "" || opts.nameRegex != "" || expirationFilter != kv.AnyExpiration {
+					return fmt.Errorf("--count-only can only be combined with --prefix")
+				}
+				count, err := kv.CountAllKeys(client, accountID, opts.namespaceID, opts.prefix)
+				if err != nil {
+					return fmt.Errorf("failed to count keys: %w", err)
+				}
+				fmt.Println(count)
 				return nil
 			}
 
@@ -202,46 +494,113 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 				Cursor:          opts.cursor,
 				Prefix:          opts.prefix,
 				Pattern:         opts.pattern,
-				IncludeMetadata: opts.metadata,
+				IncludeMetadata: opts.metadata || opts.metaField != "",
 				IncludeValues:   opts.values,
 			}
 
 			// If we have search criteria, use search instead of list
-			if opts.searchValue != "" || opts.tagField != "" {
-				var keys []kv.KeyValuePair
-				var err error
-
-				// Check for the enhanced "deep search" capability
-				fmt.Println("Searching for keys...")
-
+			if searching {
 				searchOptions := kv.SearchOptions{
 					SearchValue:     opts.searchValue,
 					TagField:        opts.tagField,
 					TagValue:        opts.tagValue,
-					IncludeMetadata: opts.metadata,
+					Prefix:          opts.prefix,
+					NameContains:    opts.nameContains,
+					NameRegex:       opts.nameRegex,
+					IncludeMetadata: opts.metadata || opts.metaField != "",
 					BatchSize:       opts.batchSize,
 					Concurrency:     opts.concurrency,
+					Expiration:      expirationFilter,
+					Limit:           opts.limit,
+					Offset:          opts.offset,
+					AfterKey:        opts.afterKey,
+				}
+				if opts.failOnErrors != "" && (opts.searchValue == "" || opts.tagField != "" || searchOptions.Limited()) {
+					return fmt.Errorf("--fail-on-errors applies to --search without --tag-field, --limit, --offset or --after-key")
+				}
+				if err := applySearchFailures(&searchOptions, opts.failOnErrors, opts.verbose || cfg.IsVerbose()); err != nil {
+					return err
+				}
+
+				if opts.estimate {
+					// Metadata and value searches read each key; name searches only list
+					readsPerKey := 0
+					if opts.searchValue != "" || opts.tagField != "" {
+						readsPerKey = 1
+					}
+					estimate, err := estimateOperation(client, accountID, opts.namespaceID, opts.prefix, readsPerKey, 0, opts.concurrency)
+					if err != nil {
+						return err
+					}
+					stdoutFree := !opts.keysOnly && !opts.outputJSON && opts.format != "json"
+					if proceed, err := confirmEstimate(cfg, "search", estimate, false, stdoutFree); !proceed {
+						return err
+					}
 				}
 
-				// If search value provided without tag field, indicate we're doing a deep recursive search
-				if opts.searchValue != "" && opts.tagField == "" {
-					fmt.Printf("Performing deep recursive metadata search for '%s'...\n", opts.searchValue)
-				} else if opts.tagField != "" {
-					if opts.tagValue != "" {
-						fmt.Printf("Searching for keys with metadata field '%s' matching '%s'...\n", opts.tagField, opts.tagValue)
+				// With --keys-only, key names go to stdout alone so they can be piped
+				if !opts.keysOnly {
+					fmt.Println("Searching for keys...")
+
+					// If search value provided without tag field, indicate we're doing a deep recursive search
+					if opts.searchValue != "" && opts.tagField == "" {
+						fmt.Printf("Performing deep recursive metadata search for '%s'...\n", opts.searchValue)
+					} else if opts.tagField != "" {
+						if opts.tagValue != "" {
+							fmt.Printf("Searching for keys with metadata field '%s' matching '%s'...\n", opts.tagField, opts.tagValue)
+						} else {
+							fmt.Printf("Searching for keys with metadata field '%s'...\n", opts.tagField)
+						}
 					} else {
-						fmt.Printf("Searching for keys with metadata field '%s'...\n", opts.tagField)
+						fmt.Println("Searching key names only (no metadata requests)...")
 					}
 				}
 
-				keys, err = service.Search(cmd.Context(), accountID, opts.namespaceID, searchOptions)
+				// A capped search scans in name order and stops early
+				var keys []kv.KeyValuePair
+				var searchPage *kv.SearchPage
+				if searchOptions.Limited() {
+					searchPage, err = kv.SearchKeysPage(cmd.Context(), client, accountID, opts.namespaceID, searchOptions)
+					if searchPage != nil {
+						keys = searchPage.Keys
+					}
+				} else {
+					keys, err = service.Search(cmd.Context(), accountID, opts.namespaceID, searchOptions)
+				}
 				if err != nil {
 					return fmt.Errorf("search failed: %w", err)
 				}
 
 				// Display results
+				if opts.outputJSON || opts.keysOnly {
+					if _, err := sortListedKeys(keys, false); err != nil {
+						return err
+					}
+				}
 				if opts.outputJSON {
-					return common.OutputJSON(keys)
+					var output interface{} = keys
+					if opts.keysOnly {
+						output = keyNames(keys)
+					} else if opts.metaField != "" {
+						output = selectMetadataField(keys, opts.metaField)
+					}
+					if searchPage != nil {
+						return common.OutputJSON(searchResultPage{
+							Keys:    output,
+							Count:   len(keys),
+							Scanned: searchPage.Scanned,
+							HasMore: searchPage.HasMore,
+							LastKey: searchPage.LastKey,
+						})
+					}
+					return common.OutputJSON(output)
+				}
+				if opts.keysOnly {
+					printKeyNames(keys)
+					if searchPage != nil && searchPage.HasMore {
+						fmt.Fprintf(os.Stderr, "More matches may exist; continue with --after-key '%s'\n", searchPage.LastKey)
+					}
+					return nil
 				}
 
 				// Table format
@@ -250,50 +609,30 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 				// If we have no results, exit early
 				if len(keys) == 0 {
 					fmt.Println("No keys match the search criteria.")
+					if searchPage != nil && opts.offset > 0 {
+						fmt.Printf("Checked %d keys; no matches beyond the offset of %d.\n", searchPage.Scanned, opts.offset)
+					}
 					return nil
 				}
 
-				// Prepare table data
-				var headers []string
-				var rows [][]string
-
-				if opts.metadata {
-					headers = []string{"Key", "Expiration", "Metadata"}
-					rows = make([][]string, len(keys))
-
-					for i, key := range keys {
-						expStr := ""
-						if key.Expiration > 0 {
-							expStr = fmt.Sprintf("%d", key.Expiration)
-						}
-
-						metaStr := "<none>"
-						if key.Metadata != nil {
-							metaStr = fmt.Sprintf("%v", *key.Metadata)
-						}
-
-						rows[i] = []string{key.Key, expStr, metaStr}
-					}
-				} else {
-					headers = []string{"Key", "Expiration"}
-					rows = make([][]string, len(keys))
-
-					for i, key := range keys {
-						expStr := ""
-						if key.Expiration > 0 {
-							expStr = fmt.Sprintf("%d", key.Expiration)
-						}
-						rows[i] = []string{key.Key, expStr}
-					}
+				if err := renderKeys(keys); err != nil {
+					return err
 				}
 
-				common.FormatTable(headers, rows)
-
 				// Include note about metadata
-				if !opts.metadata && len(keys) > 0 {
+				if !opts.metadata && opts.metaField == "" && len(keys) > 0 {
 					fmt.Println("\nTip: Use --metadata to see metadata for these keys")
 				}
 
+				if searchPage != nil {
+					if searchPage.HasMore {
+						fmt.Printf("\nStopped after checking %d keys at the limit of %d matches. More matches may exist; continue with --after-key '%s'.\n",
+							searchPage.Scanned, opts.limit, searchPage.LastKey)
+					} else {
+						fmt.Println(render.Dim(fmt.Sprintf("\nChecked %d keys; no more matches.", searchPage.Scanned)))
+					}
+				}
+
 				return nil
 			}
 
@@ -301,12 +640,21 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 			var keys []kv.KeyValuePair
 			var hasMore bool
 			var currentCursor string
+			var pageResult *kv.ListPageResult
 
 			if opts.all {
 				keys, err = service.ListAll(cmd.Context(), accountID, opts.namespaceID, listOptions)
 				if err != nil {
 					return fmt.Errorf("failed to list keys: %w", err)
 				}
+			} else if opts.cursor == "" && (opts.page > 0 || opts.cacheCursors) {
+				pageResult, err = listKeysPage(client, accountID, opts.namespaceID, opts.prefix, opts.limit, max(opts.page, 1), opts.cacheCursors)
+				if err != nil {
+					return err
+				}
+				keys = pageResult.Keys
+				hasMore = pageResult.HasMore
+				currentCursor = pageResult.Cursor
 			} else {
 				result, err := service.List(cmd.Context(), accountID, opts.namespaceID, listOptions)
 				if err != nil {
@@ -315,62 +663,156 @@ When used with --namespace-id or --namespace, lists keys in the specified namesp
 				keys = result.Keys
 				hasMore = result.Cursor != ""
 				currentCursor = result.Cursor
+				pageResult = &kv.ListPageResult{ListKeysResult: result}
+				if result.TotalCount > 0 {
+					pageResult.TotalKeys = result.TotalCount
+					pageResult.TotalPages = (result.TotalCount + max(opts.limit, 1000) - 1) / max(opts.limit, 1000)
+				}
+				if opts.cursor == "" {
+					pageResult.Page = 1
+				}
 			}
 
+			keys = kv.FilterKeysByExpiration(keys, expirationFilter)
+
 			// Display results
-			if opts.outputJSON {
-				return common.OutputJSON(keys)
+			if opts.outputJSON || opts.keysOnly {
+				if _, err := sortListedKeys(keys, false); err != nil {
+					return err
+				}
 			}
-
-			// Table format
-			fmt.Printf("Keys in namespace (%d):\n", len(keys))
-
-			// Prepare table data
-			var headers []string
-			var rows [][]string
-
-			if opts.metadata {
-				headers = []string{"Key", "Expiration", "Metadata"}
-				rows = make([][]string, len(keys))
-
-				for i, key := range keys {
-					expStr := ""
-					if key.Expiration > 0 {
-						expStr = fmt.Sprintf("%d", key.Expiration)
-					}
-
-					metaStr := "<none>"
-					if key.Metadata != nil {
-						metaStr = fmt.Sprintf("%v", *key.Metadata)
-					}
-
-					rows[i] = []string{key.Key, expStr, metaStr}
+			if opts.outputJSON {
+				var output interface{} = keys
+				if opts.keysOnly {
+					output = keyNames(keys)
+				} else if opts.metaField != "" {
+					output = selectMetadataField(keys, opts.metaField)
+				}
+				if opts.all {
+					return common.OutputJSON(output)
 				}
-			} else {
-				headers = []string{"Key", "Expiration"}
-				rows = make([][]string, len(keys))
 
-				for i, key := range keys {
-					expStr := ""
-					if key.Expiration > 0 {
-						expStr = fmt.Sprintf("%d", key.Expiration)
-					}
-					rows[i] = []string{key.Key, expStr}
+				page := keyListPage{Keys: output, Count: len(keys), Cursor: currentCursor, HasMore: hasMore}
+				if pageResult != nil {
+					page.Page = pageResult.Page
+					page.TotalPages = pageResult.TotalPages
+					page.TotalKeys = pageResult.TotalKeys
 				}
+				return common.OutputJSON(page)
 			}
+			if opts.keysOnly {
+				printKeyNames(keys)
+				return nil
+			}
+
+			// Table format
+			fmt.Println(render.Bold(fmt.Sprintf("Keys in namespace (%d):", len(keys))))
 
-			common.FormatTable(headers, rows)
+			if err := renderKeys(keys); err != nil {
+				return err
+			}
 
 			// Include note about metadata if appropriate
-			if !opts.metadata && len(keys) > 0 {
-				fmt.Println("\nTip: Use --metadata to see metadata information")
+			if !opts.metadata && opts.metaField == "" && len(keys) > 0 {
+				fmt.Println(render.Dim("\nTip: Use --metadata to see metadata information"))
+			}
+
+			if pageResult != nil {
+				if summary := pageSummary(pageResult); summary != "" {
+					fmt.Println(render.Dim("\n" + summary))
+				}
 			}
 
 			if hasMore && !opts.all {
-				fmt.Printf("\nMore keys available. Use --cursor '%s' to see the next page, or use --all to fetch all keys.\n", currentCursor)
+				if pageResult != nil && pageResult.Page > 0 {
+					fmt.Printf("\nMore keys available. Use --page %d or --cursor '%s' to see the next page, or use --all to fetch all keys.\n", pageResult.Page+1, currentCursor)
+				} else {
+					fmt.Printf("\nMore keys available. Use --cursor '%s' to see the next page, or use --all to fetch all keys.\n", currentCursor)
+				}
 			}
 
 			return nil
-		}),
+		})),
 	)
 }
+
+// listKeysPage lists a page of keys, starting from cached cursors when cacheCursors is set
+func listKeysPage(client *api.Client, accountID, namespaceID, prefix string, limit, page int, cacheCursors bool) (*kv.ListPageResult, error) {
+	var cache *kv.CursorCache
+	if cacheCursors {
+		var err error
+		cache, err = kv.LoadCursorCache("")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, paging from the first page\n", err)
+			cache = nil
+		}
+	}
+
+	result, err := kv.ListKeysPage(client, accountID, namespaceID, &kv.ListKeysOptions{Limit: limit, Prefix: prefix}, page, cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// pageSummary describes which page of keys is shown and, when known, how many there are
+func pageSummary(result *kv.ListPageResult) string {
+	var parts []string
+	if result.Page > 0 {
+		if result.TotalPages > 0 {
+			parts = append(parts, fmt.Sprintf("Page %d of %d", result.Page, result.TotalPages))
+		} else {
+			parts = append(parts, fmt.Sprintf("Page %d", result.Page))
+		}
+	} else if result.TotalPages > 0 {
+		parts = append(parts, fmt.Sprintf("%d pages", result.TotalPages))
+	}
+	if result.TotalKeys > 0 {
+		parts = append(parts, fmt.Sprintf("%d keys in total", result.TotalKeys))
+	}
+	if result.Requests > 1 {
+		parts = append(parts, fmt.Sprintf("%d list requests", result.Requests))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keyListPage is the JSON output of a page of keys, with what a script needs to list the next one
+type keyListPage struct {
+	Keys       interface{} `json:"keys"`
+	Count      int         `json:"count"`
+	Cursor     string      `json:"cursor"` // Empty on the last page
+	HasMore    bool        `json:"has_more"`
+	Page       int         `json:"page,omitempty"` // Unknown when started from --cursor
+	TotalPages int         `json:"total_pages,omitempty"`
+	TotalKeys  int         `json:"total_keys,omitempty"`
+}
+
+// searchResultPage is the JSON output of a capped search, with where to continue it
+type searchResultPage struct {
+	Keys    interface{} `json:"keys"`
+	Count   int         `json:"count"`
+	Scanned int         `json:"scanned"`
+	HasMore bool        `json:"has_more"`
+	LastKey string      `json:"last_key,omitempty"`
+}
+
+// keyNames extracts the names of keys
+func keyNames(keys []kv.KeyValuePair) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Key
+	}
+	return names
+}
+
+// printKeyNames prints one key name per line with no decoration
+func printKeyNames(keys []kv.KeyValuePair) {
+	for _, key := range keys {
+		fmt.Println(key.Key)
+	}
+}
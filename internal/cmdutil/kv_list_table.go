@@ -0,0 +1,182 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/common/render"
+	"cache-kv-purger/internal/kv"
+)
+
+// Sort orders for key listings
+const (
+	sortByKey        = "key"
+	sortByExpiration = "expiration"
+	sortBySize       = "size"
+)
+
+// keyTableOptions controls how a key listing is rendered
+type keyTableOptions struct {
+	showMetadata  bool
+	metadataField string           // Metadata field shown as its own column; empty hides the column
+	sizes         map[string]int64 // Value sizes by key; nil hides the size column
+}
+
+// validateKeySort checks a --sort value
+func validateKeySort(sortBy string) error {
+	switch sortBy {
+	case "", sortByKey, sortByExpiration, sortBySize:
+		return nil
+	default:
+		return fmt.Errorf("invalid sort '%s': must be key, expiration, or size", sortBy)
+	}
+}
+
+// sortKeys sorts keys in place. Keys without an expiration or known size sort last.
+func sortKeys(keys []kv.KeyValuePair, sortBy string, sizes map[string]int64, reverse bool) {
+	// value returns the sort value of a key, and false if it has none
+	value := func(key kv.KeyValuePair) (int64, bool) {
+		switch sortBy {
+		case sortByExpiration:
+			return key.Expiration, key.Expiration > 0
+		case sortBySize:
+			size, ok := sizes[key.Key]
+			return size, ok && size >= 0
+		default:
+			return 0, true
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, aok := value(keys[i])
+		b, bok := value(keys[j])
+		if aok != bok {
+			return aok
+		}
+		if a == b {
+			if reverse {
+				return keys[j].Key < keys[i].Key
+			}
+			return keys[i].Key < keys[j].Key
+		}
+		if reverse {
+			return a > b
+		}
+		return a < b
+	})
+}
+
+// renderKeyTable prints keys as a table with expiration, and optionally metadata and value size
+func renderKeyTable(keys []kv.KeyValuePair, options keyTableOptions) {
+	headers := []string{"Key", "Expiration"}
+	if options.sizes != nil {
+		headers = append(headers, "Size")
+	}
+	if options.metadataField != "" {
+		headers = append(headers, options.metadataField)
+	}
+	if options.showMetadata {
+		headers = append(headers, "Metadata")
+	}
+
+	// Long keys and metadata are truncated unless --wide is set
+	table := render.NewTable(headers...)
+	for _, key := range keys {
+		row := []string{key.Key, formatExpiration(key.Expiration)}
+		if options.sizes != nil {
+			row = append(row, formatValueSize(options.sizes, key.Key))
+		}
+		if options.metadataField != "" {
+			row = append(row, formatMetadataField(key.Metadata, options.metadataField))
+		}
+		if options.showMetadata {
+			row = append(row, summarizeMetadata(key.Metadata))
+		}
+		table.AddRow(row...)
+	}
+	table.Print()
+}
+
+// formatExpiration formats a Unix expiration time for display
+func formatExpiration(expiration int64) string {
+	if expiration <= 0 {
+		return ""
+	}
+	return time.Unix(expiration, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// formatValueSize formats a value size in bytes for display
+func formatValueSize(sizes map[string]int64, key string) string {
+	size, ok := sizes[key]
+	if !ok || size < 0 {
+		return "?"
+	}
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KiB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// summarizeMetadata renders metadata as sorted key=value pairs for table display
+func summarizeMetadata(metadata *kv.KeyValueMetadata) string {
+	if metadata == nil || len(*metadata) == 0 {
+		return "<none>"
+	}
+
+	fields := make([]string, 0, len(*metadata))
+	for field := range *metadata {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = fmt.Sprintf("%s=%v", field, (*metadata)[field])
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// formatMetadataField renders one metadata field for table display: strings as they are,
+// other values as JSON, and nothing when the key doesn't have the field
+func formatMetadataField(metadata *kv.KeyValueMetadata, field string) string {
+	if metadata == nil {
+		return ""
+	}
+	value, ok := (*metadata)[field]
+	if !ok || value == nil {
+		return ""
+	}
+	if str, ok := value.(string); ok {
+		return str
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// selectMetadataField returns copies of keys whose metadata holds only the given field,
+// for JSON output with --metadata-field
+func selectMetadataField(keys []kv.KeyValuePair, field string) []kv.KeyValuePair {
+	selected := make([]kv.KeyValuePair, len(keys))
+	for i, key := range keys {
+		selected[i] = key
+		selected[i].Metadata = nil
+		if key.Metadata == nil {
+			continue
+		}
+		if value, ok := (*key.Metadata)[field]; ok {
+			selected[i].Metadata = &kv.KeyValueMetadata{field: value}
+		}
+	}
+	return selected
+}
@@ -37,7 +37,7 @@ Create a KV namespace with the specified title.
 	).WithRunE(
 		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
@@ -85,17 +85,30 @@ func NewKVRenameCommand() *CommandBuilder {
 		namespaceID string
 		namespace   string
 		title       string
+		pattern     string
+		replace     string
+		dryRun      bool
+		force       bool
 		outputJSON  bool
 	}
 
 	// Create command
 	return NewCommand("rename", "Rename a namespace", `
 Rename a KV namespace to the specified title.
+
+With --pattern and --replace, renames every namespace whose title matches the regex
+pattern, for naming migrations across an account. The replacement can refer to
+groups of the pattern as $1, $2 or ${name}. The new titles are previewed before
+anything is renamed, and renames that would give two namespaces the same title are
+refused.
 `).WithExample(`  # Rename a namespace
   cache-kv-purger kv rename --namespace-id YOUR_NAMESPACE_ID --title "New Name"
 
   # Rename a namespace by name
   cache-kv-purger kv rename --namespace "Old Name" --title "New Name"
+
+  # Preview renaming every old-* namespace to new-*
+  cache-kv-purger kv rename --pattern '^old-(.*)$' --replace 'new-$1' --dry-run
 `).WithStringFlag(
 		"account-id", "", "Cloudflare account ID", &opts.accountID,
 	).WithStringFlag(
@@ -103,13 +116,21 @@ Rename a KV namespace to the specified title.
 	).WithStringFlag(
 		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
 	).WithStringFlag(
-		"title", "", "New title for the namespace (required)", &opts.title,
+		"title", "", "New title for the namespace (required unless --pattern)", &opts.title,
+	).WithStringFlag(
+		"pattern", "", "Rename all namespaces with titles matching this regex pattern (with --replace)", &opts.pattern,
+	).WithStringFlag(
+		"replace", "", "New title for namespaces matching --pattern, with $1 for the first group", &opts.replace,
+	).WithBoolFlag(
+		"dry-run", false, "Show the new titles of namespaces matching --pattern without renaming them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip the confirmation prompt of --pattern renames", &opts.force,
 	).WithBoolFlag(
 		"json", false, "Output as JSON", &opts.outputJSON,
 	).WithRunE(
 		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
@@ -117,6 +138,25 @@ Rename a KV namespace to the specified title.
 			// Create KV service
 			service := kv.NewKVService(client)
 
+			// Rename namespaces matching a pattern
+			if opts.pattern != "" || cmd.Flags().Changed("replace") {
+				if opts.pattern == "" || !cmd.Flags().Changed("replace") {
+					return fmt.Errorf("--pattern and --replace must be used together")
+				}
+				if opts.namespaceID != "" || opts.namespace != "" || opts.title != "" {
+					return fmt.Errorf("--pattern cannot be combined with --namespace-id, --namespace or --title")
+				}
+				return renameNamespacesInBulk(cmd.Context(), service, accountID, bulkNamespaceRenameOptions{
+					pattern:    opts.pattern,
+					replace:    opts.replace,
+					dryRun:     opts.dryRun,
+					force:      opts.force,
+					outputJSON: opts.outputJSON,
+					cfg:        cfg,
+					cmd:        cmd,
+				})
+			}
+
 			// Handle namespace ID resolution if namespace name is provided
 			if opts.namespace != "" && opts.namespaceID == "" {
 				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
@@ -135,6 +175,16 @@ Rename a KV namespace to the specified title.
 				return fmt.Errorf("title is required")
 			}
 
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would rename namespace %s to '%s'\n", opts.namespaceID, opts.title)
+				return nil
+			}
+
+			// Refuse to rename protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+				return err
+			}
+
 			// Rename the namespace
 			ns, err := service.RenameNamespace(cmd.Context(), accountID, opts.namespaceID, opts.title)
 			if err != nil {
@@ -157,3 +207,82 @@ Rename a KV namespace to the specified title.
 		}),
 	)
 }
+
+// NewKVEnsureCommand creates a new command for creating namespaces only if they don't exist
+func NewKVEnsureCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID  string
+		title      string
+		idOnly     bool
+		outputJSON bool
+	}
+
+	// Create command
+	return NewCommand("ensure", "Create a namespace unless it already exists", `
+Return the KV namespace with the specified title, creating it if no namespace has
+that title yet. The namespace ID is printed either way, so provisioning scripts can
+run the command repeatedly with the same result.
+
+The JSON output is always an object with "id", "title" and "created" fields.
+`).WithExample(`  # Make sure a namespace exists
+  cache-kv-purger kv ensure --title "My Application Cache"
+
+  # Capture the namespace ID in a script
+  NAMESPACE_ID=$(cache-kv-purger kv ensure --title "My Application Cache" --id-only)
+
+  # Stable JSON for provisioning tools
+  cache-kv-purger --quiet kv ensure --title "My Application Cache" --json
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"title", "", "Title of the namespace (required)", &opts.title,
+	).WithBoolFlag(
+		"id-only", false, "Print only the namespace ID", &opts.idOnly,
+	).WithBoolFlag(
+		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			if opts.title == "" {
+				return fmt.Errorf("title is required")
+			}
+
+			ns, created, err := kv.EnsureNamespace(client, accountID, opts.title)
+			if err != nil {
+				return fmt.Errorf("failed to ensure namespace: %w", err)
+			}
+
+			// Display results
+			if opts.outputJSON {
+				return common.OutputJSON(struct {
+					ID      string `json:"id"`
+					Title   string `json:"title"`
+					Created bool   `json:"created"`
+				}{ns.ID, ns.Title, created})
+			}
+			if opts.idOnly {
+				fmt.Println(ns.ID)
+				return nil
+			}
+
+			// Format using key-value table
+			data := make(map[string]string)
+			data["ID"] = ns.ID
+			data["Title"] = ns.Title
+
+			if created {
+				fmt.Println("Successfully created namespace:")
+			} else {
+				fmt.Println("Namespace already exists:")
+			}
+			common.FormatKeyValueTable(data)
+			return nil
+		}),
+	)
+}
@@ -1,6 +1,7 @@
 package cmdutil
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -30,6 +31,19 @@ func NewKVPutCommand() *CommandBuilder {
 		bulkFile      string
 		batchSize     int
 		concurrency   int
+		transform     string
+		replacements  []string
+		ifNotExists   bool
+		ifChanged     bool
+		ifValueSHA256 string
+		ifUnchanged   string
+		sinceField    string
+		casRetries    int
+		compress      bool
+		chunk         bool
+		verify        verifyFlags
+		journal       journalFlags
+		maxKeys       int
 	}
 
 	// Create command
@@ -38,6 +52,40 @@ Put values for one or more keys in a KV namespace.
 
 When used with --key and --value or --file, puts a single key value.
 When used with --bulk and --bulk-file, puts multiple key values from a file.
+
+Repeated bulk imports can skip keys that don't need writing, saving write quota:
+  --if-not-exists  only write keys that don't exist yet
+  --if-changed     only write keys that are new or whose value or metadata changed
+
+With --compress, values are gzipped before upload and the "content-encoding" metadata
+field is set to "gzip". kv get, export and sample decompress such values transparently.
+
+KV rejects values over 25 MiB. With --chunk, such values are split into 25 MiB chunks
+stored under "<key>#chunk-0000", "<key>#chunk-0001", ... and the key itself holds a small
+manifest with the chunk count, size and SHA-256 hash, marked by the "chunked" metadata
+field. kv get and export reassemble and check them transparently, and export leaves the
+chunk keys out. Chunks are written one at a time, before the manifest. Overwriting a split
+value deletes the chunks the new value doesn't use, and kv delete deletes the chunks of
+the keys it deletes. Bulk puts only look for chunks to replace with --chunk.
+
+With --verify, a random sample of the written keys is read back and the SHA-256
+hash of each value is compared with the file; --verify-all checks every key.
+
+Single key writes can be made conditional, for workflows with concurrent writers:
+  --if-value-sha256       only write if the current value has this SHA-256 hash
+  --if-unchanged-since    only write if the key's metadata timestamp (--since-field,
+                          default "updated_at") is not after this time; the write
+                          records the current time in that field
+KV has no native compare-and-swap, so the key is read just before writing. A failed
+check is retried --cas-retries times with backoff, since KV reads are eventually
+consistent. The check narrows the window for a lost update but cannot close it.
+
+With --journal, the previous value and metadata of every key are saved before writing,
+and 'kv undo' restores them (keys that did not exist are deleted again).
+
+With --max-keys, or namespace_key_limit in the config, a bulk put counts the keys in
+the namespace first and refuses to start when the new keys would take it over the
+limit, instead of failing partway through the import.
 `).WithExample(`  # Put a single key
   cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --key mykey --value "My value"
 
@@ -49,6 +97,30 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 
   # Bulk put from JSON file
   cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json
+
+  # Re-run an import, only writing keys whose value or metadata changed
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json --if-changed
+
+  # Import and check that every key was written correctly
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json --verify-all
+
+  # Bulk put, rewriting staging URLs in values and metadata
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json --replace "staging.example.com=www.example.com"
+
+  # Refuse an import that would take the namespace over 1 million keys
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json --max-keys 1000000
+
+  # Import large JSON values gzip-compressed
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --bulk --bulk-file data.json --compress
+
+  # Store a 60 MiB asset as three chunks plus a manifest
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --key assets/model.bin --file ./model.bin --chunk
+
+  # Only replace the value that was read earlier
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --key config --file ./config.json --if-value-sha256 3a7bd3e2...
+
+  # Only write if nobody has changed the key since the last read
+  cache-kv-purger kv put --namespace-id YOUR_NAMESPACE_ID --key config --file ./config.json --if-unchanged-since 2024-06-01T12:00:00Z --cas-retries 3
 `).WithStringFlag(
 		"account-id", "", "Cloudflare account ID", &opts.accountID,
 	).WithStringFlag(
@@ -71,14 +143,46 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 		"bulk", false, "Put multiple values from file", &opts.bulk,
 	).WithStringFlag(
 		"bulk-file", "", "File containing key-value pairs (JSON format)", &opts.bulkFile,
+	).WithStringFlag(
+		"transform", "", "jq-like expression applied to each value and metadata string in bulk operations", &opts.transform,
+	).WithStringSliceFlag(
+		"replace", nil, "Replace old=new in each value and metadata string in bulk operations (can be repeated)", &opts.replacements,
+	).WithBoolFlag(
+		"if-not-exists", false, "In bulk operations, skip keys that already exist", &opts.ifNotExists,
+	).WithBoolFlag(
+		"if-changed", false, "In bulk operations, skip keys whose value and metadata are unchanged", &opts.ifChanged,
+	).WithStringFlag(
+		"if-value-sha256", "", "Only write if the current value has this SHA-256 hash", &opts.ifValueSHA256,
+	).WithStringFlag(
+		"if-unchanged-since", "", "Only write if the key's metadata timestamp is not after this time", &opts.ifUnchanged,
+	).WithStringFlag(
+		"since-field", kv.DefaultSinceField, "Metadata field holding the modification time for --if-unchanged-since", &opts.sinceField,
+	).WithIntFlag(
+		"cas-retries", 0, "Times to re-check a failed --if-value-sha256 or --if-unchanged-since condition", &opts.casRetries,
+	).WithBoolFlag(
+		"compress", false, "Gzip values and record the encoding in metadata", &opts.compress,
+	).WithBoolFlag(
+		"chunk", false, "Split values over 25 MiB into chunk keys plus a manifest under the key", &opts.chunk,
 	).WithIntFlag(
 		"batch-size", 0, "Batch size for bulk operations", &opts.batchSize,
 	).WithIntFlag(
 		"concurrency", 0, "Concurrency for bulk operations", &opts.concurrency,
+	).WithBoolFlag(
+		"verify", false, "Re-read a sample of written keys and compare SHA-256 hashes with the source", &opts.verify.verify,
+	).WithBoolFlag(
+		"verify-all", false, "Re-read every written key and compare SHA-256 hashes with the source", &opts.verify.verifyAll,
+	).WithIntFlag(
+		"verify-sample", kv.DefaultVerifySampleSize, "Number of keys to re-read with --verify", &opts.verify.sampleSize,
+	).WithBoolFlag(
+		"journal", false, "Save the previous value and metadata of overwritten keys to a local journal so 'kv undo' can restore them", &opts.journal.journal,
+	).WithStringFlag(
+		"journal-namespace", "", "Keep the journal in this namespace (name or ID) instead of locally; implies --journal", &opts.journal.namespace,
+	).WithIntFlag(
+		"max-keys", 0, "Refuse a bulk put that would take the namespace over this many keys (default: namespace_key_limit in config)", &opts.maxKeys,
 	).WithRunE(
 		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
 			// Resolve account ID
-			accountID, err := common.ValidateAccountID(cmd, cfg, opts.accountID)
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
 			if err != nil {
 				return err
 			}
@@ -116,6 +220,41 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 					return fmt.Errorf("bulk-file is required for bulk operations")
 				}
 			}
+			if (opts.ifNotExists || opts.ifChanged) && !opts.bulk {
+				return fmt.Errorf("--if-not-exists and --if-changed require --bulk")
+			}
+			if opts.ifNotExists && opts.ifChanged {
+				return fmt.Errorf("--if-not-exists and --if-changed cannot be used together")
+			}
+			if opts.verify.enabled() && !opts.bulk {
+				return fmt.Errorf("--verify and --verify-all require --bulk")
+			}
+
+			// Build the compare-and-swap condition
+			casCondition := kv.CASCondition{ValueSHA256: opts.ifValueSHA256, SinceField: opts.sinceField}
+			if opts.ifUnchanged != "" {
+				casCondition.UnchangedSince, err = common.ParseTimestamp(opts.ifUnchanged)
+				if err != nil {
+					return fmt.Errorf("invalid --if-unchanged-since: %w", err)
+				}
+			}
+			if casCondition.IsSet() && opts.bulk {
+				return fmt.Errorf("--if-value-sha256 and --if-unchanged-since only apply to single key writes")
+			}
+			if casCondition.IsSet() && opts.compress {
+				return fmt.Errorf("--compress cannot be combined with --if-value-sha256 or --if-unchanged-since")
+			}
+			if casCondition.IsSet() && opts.chunk {
+				return fmt.Errorf("--chunk cannot be combined with --if-value-sha256 or --if-unchanged-since")
+			}
+			if opts.casRetries < 0 {
+				return fmt.Errorf("--cas-retries cannot be negative")
+			}
+
+			// Refuse to write to protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+				return err
+			}
 
 			// Single key mode
 			if !opts.bulk {
@@ -148,16 +287,72 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 					writeOptions.Metadata = metadata
 				}
 
-				// Put the value
-				err := service.Put(cmd.Context(), accountID, opts.namespaceID, opts.key, value, writeOptions)
+				if opts.journal.enabled() {
+					if err := recordJournal(cmd.Context(), service, client, opts.journal, accountID, opts.namespaceID, "kv put", []string{opts.key}, 1); err != nil {
+						return err
+					}
+				}
+
+				// Put the value, checking the current one first for conditional writes.
+				// Compressed values are binary, so they go through the bulk API as base64.
+				// Values too large for KV are split into chunks when --chunk is set.
+				var casResult *kv.CASResult
+				var compressed kv.BulkWriteItem
+				chunks := 0
+				item := kv.BulkWriteItem{
+					Key:           opts.key,
+					Value:         value,
+					Expiration:    writeOptions.Expiration,
+					ExpirationTTL: writeOptions.ExpirationTTL,
+					Metadata:      writeOptions.Metadata,
+				}
+				if opts.compress {
+					if compressed, err = kv.CompressBulkItem(item); err != nil {
+						return fmt.Errorf("failed to put value: %w", err)
+					}
+					item = compressed
+				}
+				// A value that replaces a split one leaves its chunks unused, so they are found
+				// before the write and deleted after it. Chunked writes handle this themselves.
+				writeChunks := opts.chunk && kv.ItemSize(item) > kv.MaxValueSize
+				var staleChunks []string
+				if !writeChunks {
+					if staleChunks, err = kv.StoredChunkKeys(client, accountID, opts.namespaceID, opts.key); err != nil {
+						return fmt.Errorf("failed to put value: %w", err)
+					}
+				}
+				if writeChunks {
+					chunks, err = kv.WriteChunkedItem(client, accountID, opts.namespaceID, item)
+				} else if opts.compress {
+					err = kv.WriteMultipleValues(client, accountID, opts.namespaceID, []kv.BulkWriteItem{compressed})
+				} else if casCondition.IsSet() {
+					casResult, err = kv.PutWithCAS(client, accountID, opts.namespaceID, opts.key, value,
+						writeOptions, casCondition, opts.casRetries)
+				} else {
+					err = service.Put(cmd.Context(), accountID, opts.namespaceID, opts.key, value, writeOptions)
+				}
 				if err != nil {
 					return fmt.Errorf("failed to put value: %w", err)
 				}
+				if err := kv.DeleteStaleChunks(client, accountID, opts.namespaceID, opts.key, staleChunks, 0); err != nil {
+					return err
+				}
 
 				// Format success message with key-value table
 				data := make(map[string]string)
 				data["Key"] = opts.key
 				data["Status"] = "Successfully stored"
+				if casResult != nil {
+					data["Replaced SHA-256"] = casResult.PreviousSHA
+					data["Checks"] = fmt.Sprintf("%d", casResult.Attempts)
+				}
+				if opts.compress {
+					stored, _ := base64.StdEncoding.DecodeString(compressed.Value)
+					data["Compressed"] = fmt.Sprintf("%d -> %d bytes (gzip)", len(value), len(stored))
+				}
+				if chunks > 0 {
+					data["Chunks"] = fmt.Sprintf("%d (%s#chunk-*)", chunks, opts.key)
+				}
 				if opts.expiration > 0 {
 					data["Expiration"] = fmt.Sprintf("%d", opts.expiration)
 				} else if opts.expirationTTL > 0 {
@@ -181,6 +376,49 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 				return fmt.Errorf("failed to parse bulk file (must be JSON array of objects): %w", err)
 			}
 
+			// Rewrite values and metadata in flight if requested
+			transformer, err := common.NewValueTransformer(opts.transform, opts.replacements)
+			if err != nil {
+				return err
+			}
+			if transformer != nil {
+				for i := range bulkItems {
+					bulkItems[i].Value = transformer.Apply(bulkItems[i].Value)
+					bulkItems[i].Metadata = transformer.ApplyToMetadata(bulkItems[i].Metadata)
+				}
+			}
+
+			// Refuse key names the API would reject before writing anything
+			bulkKeys := make([]string, len(bulkItems))
+			for i, item := range bulkItems {
+				bulkKeys[i] = item.Key
+			}
+			if err := checkKeyNames(bulkKeys, cfg.IsVerbose()); err != nil {
+				return err
+			}
+
+			// Compress before the change check, so it compares the bytes that would be stored
+			if opts.compress {
+				for i := range bulkItems {
+					if bulkItems[i], err = kv.CompressBulkItem(bulkItems[i]); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Skip keys that don't need writing
+			condition := kv.WriteAlways
+			if opts.ifNotExists {
+				condition = kv.WriteIfNotExists
+			} else if opts.ifChanged {
+				condition = kv.WriteIfChanged
+			}
+
+			filtered, err := kv.FilterBulkWriteItems(client, accountID, opts.namespaceID, bulkItems, condition, opts.concurrency)
+			if err != nil {
+				return fmt.Errorf("failed to check existing keys: %w", err)
+			}
+
 			// Set up bulk write options
 			bulkWriteOptions := kv.BulkWriteOptions{
 				BatchSize:   opts.batchSize,
@@ -188,9 +426,59 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 			}
 
 			// Put values in bulk
-			count, err := service.BulkPut(cmd.Context(), accountID, opts.namespaceID, bulkItems, bulkWriteOptions)
-			if err != nil {
-				return fmt.Errorf("bulk put operation failed: %w", err)
+			count := 0
+			chunkCount := 0
+			if len(filtered.ToWrite) > 0 {
+				writeKeys := make([]string, len(filtered.ToWrite))
+				for i, item := range filtered.ToWrite {
+					writeKeys[i] = item.Key
+				}
+				if err := checkKeyCapacity(cfg, client, accountID, opts.namespaceID, writeKeys, opts.maxKeys); err != nil {
+					return err
+				}
+
+				if opts.journal.enabled() {
+					if err := recordJournal(cmd.Context(), service, client, opts.journal, accountID, opts.namespaceID, "kv put", writeKeys, opts.concurrency); err != nil {
+						return err
+					}
+				}
+				// Values too large for the bulk API are written as chunks when --chunk is set
+				toWrite := filtered.ToWrite
+				var oversized []kv.BulkWriteItem
+				// With --chunk the namespace may hold split values, so the chunks of the ones
+				// replaced by values that fit are found before the write and deleted after it
+				var staleChunks map[string][]string
+				if opts.chunk {
+					toWrite, oversized = kv.SplitOversizedItems(filtered.ToWrite)
+					fittingKeys := make([]string, len(toWrite))
+					for i, item := range toWrite {
+						fittingKeys[i] = item.Key
+					}
+					staleChunks, err = kv.StoredChunkKeysOf(cmd.Context(), client, accountID, opts.namespaceID, fittingKeys, opts.concurrency)
+					if err != nil {
+						return fmt.Errorf("bulk put operation failed: %w", err)
+					}
+				}
+				if len(toWrite) > 0 {
+					count, err = service.BulkPut(cmd.Context(), accountID, opts.namespaceID, toWrite, bulkWriteOptions)
+					if err != nil {
+						return fmt.Errorf("bulk put operation failed: %w", err)
+					}
+				}
+				for key, chunks := range staleChunks {
+					if err := kv.DeleteStaleChunks(client, accountID, opts.namespaceID, key, chunks, 0); err != nil {
+						return err
+					}
+				}
+				for _, item := range oversized {
+					written, err := kv.WriteChunkedItem(client, accountID, opts.namespaceID, item)
+					if err != nil {
+						return fmt.Errorf("bulk put operation failed after %d keys: %w", count, err)
+					}
+					count++
+					chunkCount += written
+				}
+				filtered.ToWrite = toWrite
 			}
 
 			// Format bulk operation result
@@ -198,6 +486,15 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 			data["Operation"] = "Bulk Store"
 			data["Success Count"] = fmt.Sprintf("%d", count)
 			data["Total Items"] = fmt.Sprintf("%d", len(bulkItems))
+			if opts.ifNotExists {
+				data["Skipped (existing)"] = fmt.Sprintf("%d", len(filtered.SkippedExisting))
+			}
+			if opts.ifChanged {
+				data["Skipped (unchanged)"] = fmt.Sprintf("%d", len(filtered.SkippedUnchanged))
+			}
+			if chunkCount > 0 {
+				data["Chunks Written"] = fmt.Sprintf("%d", chunkCount)
+			}
 			if opts.concurrency > 0 {
 				data["Concurrency"] = fmt.Sprintf("%d workers", opts.concurrency)
 			}
@@ -206,6 +503,11 @@ When used with --bulk and --bulk-file, puts multiple key values from a file.
 			}
 
 			common.FormatKeyValueTable(data)
+
+			if opts.verify.enabled() {
+				return verifyWrittenValues(client, accountID, opts.namespaceID,
+					kv.HashBulkWriteItems(filtered.ToWrite), opts.verify, opts.concurrency)
+			}
 			return nil
 		}),
 	)
@@ -0,0 +1,119 @@
+package cmdutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// bulkNamespaceRenameOptions holds the pattern and replacement for renaming several namespaces at once
+type bulkNamespaceRenameOptions struct {
+	pattern    string // Regex matched against namespace titles
+	replace    string // Replacement, with $1 for the first group
+	dryRun     bool
+	force      bool
+	outputJSON bool
+	cfg        *config.Config // For protected namespaces
+	cmd        *cobra.Command // For --override-protection
+}
+
+// renameNamespacesInBulk renames every namespace whose title matches the pattern after
+// showing a preview and asking for confirmation, then prints a table of the results
+func renameNamespacesInBulk(ctx context.Context, service kv.KVService, accountID string, opts bulkNamespaceRenameOptions) error {
+	namespaces, err := service.ListNamespaces(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	renames, err := kv.PlanNamespaceRenames(namespaces, opts.pattern, opts.replace)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to touch namespaces protected in config
+	for _, rename := range renames {
+		if err := common.CheckNamespaceProtection(opts.cmd, opts.cfg, rename.ID, rename.OldTitle); err != nil {
+			return err
+		}
+	}
+
+	if len(renames) == 0 {
+		if opts.outputJSON {
+			return common.OutputJSON(renames)
+		}
+		fmt.Printf("No namespace titles matching '%s' would change.\n", opts.pattern)
+		return nil
+	}
+
+	// Show what will be renamed
+	if opts.outputJSON && opts.dryRun {
+		return common.OutputJSON(renames)
+	}
+	if !opts.outputJSON {
+		rows := make([][]string, len(renames))
+		for i, rename := range renames {
+			rows[i] = []string{rename.ID, rename.OldTitle, rename.NewTitle}
+		}
+		fmt.Printf("Namespaces selected for renaming (%d):\n", len(renames))
+		common.FormatTable([]string{"ID", "Title", "New Title"}, rows)
+	}
+
+	if opts.dryRun {
+		fmt.Printf("DRY RUN: Would rename %d namespaces\n", len(renames))
+		return nil
+	}
+
+	// Confirm renaming unless --force is used
+	if !opts.force && !common.AssumeYes() {
+		fmt.Printf("\nYou are about to rename %d namespaces. Workers and tools that look them up by title will need updating.\n", len(renames))
+		fmt.Print("Are you sure? (y/N): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		confirmation, _ := reader.ReadString('\n')
+		confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+
+		if confirmation != "y" && confirmation != "yes" {
+			fmt.Println("Rename cancelled.")
+			return nil
+		}
+	}
+
+	// Rename in order, since a rename may free the title the next one takes
+	failed := 0
+	for i := range renames {
+		if _, err := service.RenameNamespace(ctx, accountID, renames[i].ID, renames[i].NewTitle); err != nil {
+			renames[i].Error = err.Error()
+			failed++
+		}
+	}
+
+	if opts.outputJSON {
+		if err := common.OutputJSON(renames); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, len(renames))
+		for i, rename := range renames {
+			status := "Renamed"
+			if rename.Error != "" {
+				status = "Failed: " + rename.Error
+			}
+			rows[i] = []string{rename.ID, rename.OldTitle, rename.NewTitle, status}
+		}
+		fmt.Printf("Renamed %d/%d namespaces\n", len(renames)-failed, len(renames))
+		common.FormatTable([]string{"ID", "Old Title", "New Title", "Status"}, rows)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to rename %d namespaces", failed)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package cmdutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// maxDiffLineLength caps how much of a changed line the replace preview shows
+const maxDiffLineLength = 160
+
+// NewKVReplaceCommand creates a new replace command for KV
+func NewKVReplaceCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		find        string
+		replace     string
+		regex       bool
+		prefix      string
+		concurrency int
+		batchSize   int
+		samples     int
+		dryRun      bool
+		force       bool
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("replace", "Find and replace text in values across a namespace", `
+Find and replace text in the values of all keys in a namespace.
+
+Keys are listed page by page, the values of each page are fetched concurrently,
+and the values that change are written back with the bulk API. Metadata and
+expiration of each key are kept. Keys that expire within a minute cannot be
+written with their expiration and are reported as failed.
+
+With --regex, --find is a regular expression and --replace may reference groups
+as $1 or ${name}. Use --dry-run to preview sample changes without writing.
+`).WithExample(`  # Preview replacing a CDN hostname
+  cache-kv-purger kv replace --namespace-id YOUR_NAMESPACE_ID --find "cdn.old.com" --replace "cdn.new.com" --dry-run
+
+  # Replace it for real, only in keys with a prefix
+  cache-kv-purger kv replace --namespace-id YOUR_NAMESPACE_ID --prefix "page:" --find "cdn.old.com" --replace "cdn.new.com"
+
+  # Rewrite versioned paths with a regex
+  cache-kv-purger kv replace --namespace "My Namespace" --regex --find '/v1/(\w+)' --replace '/v2/$1' --dry-run
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"find", "", "Text to find (regular expression with --regex)", &opts.find,
+	).WithStringFlag(
+		"replace", "", "Replacement text (may reference groups with --regex)", &opts.replace,
+	).WithBoolFlag(
+		"regex", false, "Treat --find as a regular expression", &opts.regex,
+	).WithStringFlag(
+		"prefix", "", "Only replace in keys with this prefix", &opts.prefix,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 1000, "Number of keys per bulk write", &opts.batchSize,
+	).WithIntFlag(
+		"samples", 5, "Number of sample changes to preview", &opts.samples,
+	).WithBoolFlag(
+		"dry-run", false, "Preview the changes without writing them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.find == "" {
+				return fmt.Errorf("--find is required")
+			}
+			if !cmd.Flags().Changed("replace") {
+				return fmt.Errorf("--replace is required (use --replace \"\" to delete the matches)")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			if !opts.dryRun {
+				// Refuse to rewrite protected namespaces
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+					return err
+				}
+
+				// The number of changes is only known after scanning, so confirm the operation itself
+				if !opts.force && !common.ConfirmAction(fmt.Sprintf(
+					"Replace '%s' with '%s' in all matching values of namespace %s? This cannot be undone.",
+					opts.find, opts.replace, opts.namespaceID)) {
+					fmt.Println("Replace cancelled.")
+					return nil
+				}
+			}
+
+			var progress func(scanned, matched, updated int)
+			if !opts.outputJSON {
+				progress = func(scanned, matched, updated int) {
+					fmt.Printf("Progress: %d keys scanned, %d matched, %d updated...  \r", scanned, matched, updated)
+				}
+			}
+
+			result, err := kv.ReplaceInValues(client, accountID, opts.namespaceID, kv.ReplaceOptions{
+				Find:        opts.find,
+				Replace:     opts.replace,
+				Regex:       opts.regex,
+				Prefix:      opts.prefix,
+				Concurrency: opts.concurrency,
+				BatchSize:   opts.batchSize,
+				SampleSize:  opts.samples,
+				DryRun:      opts.dryRun,
+			}, progress)
+			if err != nil && result == nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				if jsonErr := common.OutputJSON(result); jsonErr != nil {
+					return jsonErr
+				}
+			} else {
+				fmt.Println()
+				for _, change := range result.Samples {
+					printReplaceChange(change)
+				}
+				if opts.dryRun {
+					fmt.Printf("DRY RUN: Would update %d of %d keys\n", result.Matched, result.Scanned)
+				} else {
+					fmt.Printf("Updated %d of %d keys (%d matched)\n", len(result.Updated), result.Scanned, result.Matched)
+				}
+				printFailedKeys(result.Failed)
+			}
+
+			if err != nil {
+				return err
+			}
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("failed to update %d keys", len(result.Failed))
+			}
+			return nil
+		}),
+	)
+}
+
+// printReplaceChange prints the lines of a value changed by a replacement
+func printReplaceChange(change kv.ReplaceChange) {
+	fmt.Printf("--- %s (%d matches)\n", change.Key, change.Matches)
+
+	before := strings.Split(change.Before, "\n")
+	after := strings.Split(change.After, "\n")
+	if len(before) != len(after) {
+		// The replacement added or removed lines, so show the values whole
+		fmt.Printf("- %s\n+ %s\n", truncateDiffLine(change.Before), truncateDiffLine(change.After))
+		return
+	}
+
+	for i := range before {
+		if before[i] != after[i] {
+			fmt.Printf("- %s\n+ %s\n", truncateDiffLine(before[i]), truncateDiffLine(after[i]))
+		}
+	}
+}
+
+// truncateDiffLine shortens a line for the replace preview
+func truncateDiffLine(line string) string {
+	line = strings.ReplaceAll(line, "\n", "\\n")
+	if len(line) <= maxDiffLineLength {
+		return line
+	}
+	return line[:maxDiffLineLength] + "..."
+}
+
+// printFailedKeys lists keys that could not be updated, in name order
+func printFailedKeys(failed map[string]string) {
+	keys := make([]string, 0, len(failed))
+	for key := range failed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  - %s: %s\n", key, failed[key])
+	}
+}
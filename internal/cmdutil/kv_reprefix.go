@@ -0,0 +1,187 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVReprefixCommand creates a new reprefix command for KV
+func NewKVReprefixCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID    string
+		namespaceID  string
+		namespace    string
+		from         string
+		to           string
+		overwrite    string
+		deleteSource bool
+		concurrency  int
+		batchSize    int
+		dryRun       bool
+		force        bool
+		outputJSON   bool
+	}
+
+	// Create command
+	return NewCommand("reprefix", "Move the keys under a prefix to a new prefix", `
+Copy every key under --from to the same name under --to, keeping its value,
+metadata and expiration, for example when migrating a key schema from v1: to v2:.
+With --delete-source, the old keys are deleted once their copies are written.
+
+Keys are handled in batches: values are read concurrently, written with the bulk
+API, and only then deleted, so an interrupted move loses nothing and can simply
+be run again. New key names that already exist are skipped unless --overwrite is
+replace; skipped keys are never deleted. The move is refused when a new key name
+is also one of the keys being moved, which can happen when one prefix starts
+with the other. Keys that expire within a minute cannot be written with their
+expiration and are reported as failed.
+
+Binary and compressed values are copied byte for byte. A value split into chunks
+with kv put --chunk moves as one key: its chunks are written under the new name
+and, with --delete-source, the old chunks are deleted with it.
+`).WithExample(`  # Preview the keys that would move
+  cache-kv-purger kv reprefix --namespace-id YOUR_NAMESPACE_ID --from "v1:" --to "v2:" --dry-run
+
+  # Copy the keys to the new prefix and delete the old ones
+  cache-kv-purger kv reprefix --namespace "My Namespace" --from "v1:" --to "v2:" --delete-source
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"from", "", "Prefix of the keys to move (required)", &opts.from,
+	).WithStringFlag(
+		"to", "", "Prefix to replace it with (may be empty to strip the prefix)", &opts.to,
+	).WithStringFlag(
+		"overwrite", "skip", "When a new key name already exists: skip, replace, or fail", &opts.overwrite,
+	).WithBoolFlag(
+		"delete-source", false, "Delete each old key once it is copied", &opts.deleteSource,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 1000, "Number of keys per bulk write and delete", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "List the keys that would move without writing them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.from == "" {
+				return fmt.Errorf("--from is required")
+			}
+			if !cmd.Flags().Changed("to") {
+				return fmt.Errorf("--to is required")
+			}
+			overwrite, err := kv.ParseOverwritePolicy(opts.overwrite)
+			if err != nil {
+				return err
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			if !opts.dryRun {
+				// Refuse to rewrite protected namespaces
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+					return err
+				}
+
+				action := "Copy"
+				if opts.deleteSource {
+					action = "Move"
+				}
+				if !opts.force && !common.ConfirmAction(fmt.Sprintf(
+					"%s all keys under '%s' to '%s' in namespace %s?", action, opts.from, opts.to, opts.namespaceID)) {
+					fmt.Println("Reprefix cancelled.")
+					return nil
+				}
+			}
+
+			var progress func(processed, total int)
+			if !opts.outputJSON {
+				progress = func(processed, total int) {
+					fmt.Printf("Progress: %d/%d keys...  \r", processed, total)
+				}
+			}
+
+			result, err := kv.ReprefixKeys(client, accountID, opts.namespaceID, kv.ReprefixOptions{
+				From:         opts.from,
+				To:           opts.to,
+				Overwrite:    overwrite,
+				DeleteSource: opts.deleteSource,
+				Concurrency:  opts.concurrency,
+				BatchSize:    opts.batchSize,
+				DryRun:       opts.dryRun,
+			}, progress)
+			if err != nil && result == nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				if jsonErr := common.OutputJSON(result); jsonErr != nil {
+					return jsonErr
+				}
+			} else {
+				fmt.Println()
+				if opts.dryRun {
+					for _, key := range result.Copied {
+						fmt.Printf("  ~ %s -> %s\n", key, kv.ReprefixKey(key, opts.from, opts.to))
+					}
+					fmt.Printf("DRY RUN: Would copy %d of %d keys", len(result.Copied), result.Scanned)
+					if opts.deleteSource {
+						fmt.Print(" and delete the originals")
+					}
+					fmt.Println()
+				} else {
+					fmt.Printf("Copied %d of %d keys from '%s' to '%s'\n", len(result.Copied), result.Scanned, opts.from, opts.to)
+					if opts.deleteSource {
+						fmt.Printf("Deleted %d source keys\n", len(result.Deleted))
+					}
+				}
+				if len(result.Skipped) > 0 {
+					fmt.Printf("Skipped %d keys whose new name already exists (use --overwrite replace to replace them)\n", len(result.Skipped))
+				}
+				printFailedKeys(result.Failed)
+			}
+
+			if err != nil {
+				return err
+			}
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("failed to copy %d keys", len(result.Failed))
+			}
+			return nil
+		}),
+	)
+}
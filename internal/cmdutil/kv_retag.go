@@ -0,0 +1,162 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVRetagCommand creates a new retag command for KV
+func NewKVRetagCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		tagField    string
+		from        string
+		to          string
+		prefix      string
+		concurrency int
+		batchSize   int
+		dryRun      bool
+		force       bool
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("retag", "Rename a tag in key metadata across a namespace", `
+Replace a tag in the metadata of every key that carries it, for example when
+renaming cache tag conventions.
+
+A key carries the tag when its metadata field equals --from, or is a list that
+contains it. Keys are listed page by page with their metadata, so values are only
+read for matching keys. Matching keys are written back with the bulk API with the
+new tag, keeping their value, the rest of their metadata and their expiration.
+Keys that expire within a minute cannot be written with their expiration and are
+reported as failed.
+`).WithExample(`  # Preview which keys carry the old tag
+  cache-kv-purger kv retag --namespace-id YOUR_NAMESPACE_ID --tag-field cache-tag --from product-v1 --to product --dry-run
+
+  # Rename the tag, only in keys with a prefix
+  cache-kv-purger kv retag --namespace "My Namespace" --prefix "page:" --from product-v1 --to product
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"tag-field", "cache-tag", "Metadata field holding the tag", &opts.tagField,
+	).WithStringFlag(
+		"from", "", "Tag to replace (required)", &opts.from,
+	).WithStringFlag(
+		"to", "", "New tag (required)", &opts.to,
+	).WithStringFlag(
+		"prefix", "", "Only retag keys with this prefix", &opts.prefix,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently", &opts.concurrency,
+	).WithIntFlag(
+		"batch-size", 1000, "Number of keys per bulk write", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "List the keys carrying the old tag without writing them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.from == "" || opts.to == "" {
+				return fmt.Errorf("--from and --to are required")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			if !opts.dryRun {
+				// Refuse to rewrite protected namespaces
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+					return err
+				}
+
+				// The number of keys is only known after scanning, so confirm the operation itself
+				if !opts.force && !common.ConfirmAction(fmt.Sprintf(
+					"Replace %s '%s' with '%s' in the metadata of all matching keys of namespace %s?",
+					opts.tagField, opts.from, opts.to, opts.namespaceID)) {
+					fmt.Println("Retag cancelled.")
+					return nil
+				}
+			}
+
+			var progress func(scanned, matched, updated int)
+			if !opts.outputJSON {
+				progress = func(scanned, matched, updated int) {
+					fmt.Printf("Progress: %d keys scanned, %d matched, %d updated...  \r", scanned, matched, updated)
+				}
+			}
+
+			result, err := kv.RetagKeys(client, accountID, opts.namespaceID, kv.RetagOptions{
+				TagField:    opts.tagField,
+				From:        opts.from,
+				To:          opts.to,
+				Prefix:      opts.prefix,
+				Concurrency: opts.concurrency,
+				BatchSize:   opts.batchSize,
+				DryRun:      opts.dryRun,
+			}, progress)
+			if err != nil && result == nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				if jsonErr := common.OutputJSON(result); jsonErr != nil {
+					return jsonErr
+				}
+			} else {
+				fmt.Println()
+				if opts.dryRun {
+					for _, key := range result.Matched {
+						fmt.Printf("  ~ %s\n", key)
+					}
+					fmt.Printf("DRY RUN: Would retag %d of %d keys\n", len(result.Matched), result.Scanned)
+				} else {
+					fmt.Printf("Retagged %d of %d keys (%d matched)\n", len(result.Updated), result.Scanned, len(result.Matched))
+				}
+				printFailedKeys(result.Failed)
+			}
+
+			if err != nil {
+				return err
+			}
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("failed to retag %d keys", len(result.Failed))
+			}
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,160 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVSampleCommand creates a new sample command for KV
+func NewKVSampleCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		prefix      string
+		pattern     string
+		count       int
+		maxLength   int
+		concurrency int
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("sample", "Show the values of randomly sampled keys", `
+Show the values and metadata of keys chosen at random from a namespace.
+
+Use it to check what a prefix or pattern actually matches before a bulk delete,
+expire or replace with the same filter. Every matching key is equally likely to
+be sampled. JSON values are pretty-printed and long values are truncated.
+`).WithExample(`  # Check what a prefix-based delete would remove
+  cache-kv-purger kv sample --namespace-id YOUR_NAMESPACE_ID --prefix "session-" --count 10
+
+  # Sample keys matching a pattern, showing values in full
+  cache-kv-purger kv sample --namespace "My Namespace" --pattern "^cache:v1:" --max-length 0
+
+  # Sample as JSON for scripts
+  cache-kv-purger kv sample --namespace-id YOUR_NAMESPACE_ID --prefix "session-" --json
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"prefix", "", "Only sample keys with this prefix", &opts.prefix,
+	).WithStringFlag(
+		"pattern", "", "Only sample keys matching this regex pattern", &opts.pattern,
+	).WithIntFlag(
+		"count", 10, "Number of keys to sample", &opts.count,
+	).WithIntFlag(
+		"max-length", 500, "Truncate values longer than this many characters (0 for no limit)", &opts.maxLength,
+	).WithIntFlag(
+		"concurrency", 10, "Number of values to read concurrently", &opts.concurrency,
+	).WithBoolFlag(
+		"json", false, "Output the samples as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.count <= 0 {
+				return fmt.Errorf("--count must be positive")
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			var progress func(matched int)
+			if !opts.outputJSON {
+				progress = func(matched int) {
+					fmt.Printf("Scanning: %d matching keys...  \r", matched)
+				}
+			}
+
+			result, err := kv.SampleKeys(client, accountID, opts.namespaceID, kv.SampleOptions{
+				Prefix:      opts.prefix,
+				Pattern:     opts.pattern,
+				Count:       opts.count,
+				Concurrency: opts.concurrency,
+			}, progress)
+			if err != nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				return common.OutputJSON(result)
+			}
+
+			fmt.Println()
+			if result.Matched == 0 {
+				fmt.Println("No matching keys found.")
+				return nil
+			}
+			fmt.Printf("Sampled %d of %d matching keys:\n\n", len(result.Samples), result.Matched)
+			for _, sample := range result.Samples {
+				printSampledKey(sample, opts.maxLength)
+			}
+			return nil
+		}),
+	)
+}
+
+// printSampledKey prints a sampled key with its expiration, metadata and value
+func printSampledKey(sample kv.SampledKey, maxLength int) {
+	fmt.Printf("--- %s\n", sample.Key)
+	if sample.Expiration > 0 {
+		expires := time.Unix(sample.Expiration, 0)
+		fmt.Printf("Expires:  %s (in %s)\n", expires.Local().Format(time.DateTime),
+			time.Until(expires).Round(time.Second))
+	}
+	if sample.Metadata != nil {
+		metadata, _ := json.Marshal(sample.Metadata)
+		fmt.Printf("Metadata: %s\n", metadata)
+	}
+	if sample.Error != "" {
+		fmt.Printf("Error:    %s\n\n", sample.Error)
+		return
+	}
+
+	fmt.Printf("Value (%d bytes):\n", len(sample.Value))
+	value := sample.Value
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, []byte(value), "", "  ") == nil {
+		value = pretty.String()
+	}
+	if maxLength > 0 && len(value) > maxLength {
+		value = fmt.Sprintf("%s\n... (%d more characters)", value[:maxLength], len(value)-maxLength)
+	}
+	for _, line := range strings.Split(value, "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,197 @@
+package cmdutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVSeedCommand creates a new seed command for KV
+func NewKVSeedCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID     string
+		namespaceID   string
+		namespace     string
+		count         int
+		prefix        string
+		valueSize     int
+		valueTemplate string
+		tagField      string
+		tags          string
+		seed          int64
+		expirationTTL int64
+		batchSize     int
+		dryRun        bool
+		force         bool
+		outputJSON    bool
+	}
+
+	// Create command
+	return NewCommand("seed", "Fill a namespace with generated test data", `
+Write generated keys to a namespace, as a reproducible data set for benchmarking
+searches and purges or for testing scripts without touching real data.
+
+Keys are named --prefix followed by a zero-padded index, so they sort in order and
+are easy to delete again with a prefix delete. Values are random characters of
+--value-size, or --value-template with {key}, {index} and {tag} replaced. Each
+key's metadata records its seed_index and, with --tag-field, one of --tags in
+turn. The same --seed always generates the same keys, values and metadata.
+
+Existing keys with the same names are overwritten, so seed a test namespace.
+`).WithExample(`  # 10,000 keys with 256-byte values, tagged a, b and c in turn
+  cache-kv-purger kv seed --namespace-id YOUR_NAMESPACE_ID --count 10000 --value-size 256 --tag-field cache-tag --tags a,b,c
+
+  # Templated JSON values that expire after a day
+  cache-kv-purger kv seed --namespace "Test Data" --count 500 --value-template '{"id":"{key}","tag":"{tag}"}' --tag-field cache-tag --tags x,y --expiration-ttl 86400
+
+  # Remove the seeded keys again
+  cache-kv-purger kv delete --namespace "Test Data" --bulk --prefix "seed:"
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithIntFlag(
+		"count", 1000, "Number of keys to generate", &opts.count,
+	).WithStringFlag(
+		"prefix", "seed:", "Prefix of the generated key names", &opts.prefix,
+	).WithIntFlag(
+		"value-size", 256, "Length of random values in bytes", &opts.valueSize,
+	).WithStringFlag(
+		"value-template", "", "Value template with {key}, {index} and {tag} placeholders (instead of random values)", &opts.valueTemplate,
+	).WithStringFlag(
+		"tag-field", "", "Metadata field to write the tags into", &opts.tagField,
+	).WithStringFlag(
+		"tags", "", "Comma-separated tags assigned to the keys in turn", &opts.tags,
+	).WithInt64Flag(
+		"seed", 1, "Random seed; the same seed generates the same data", &opts.seed,
+	).WithInt64Flag(
+		"expiration-ttl", 0, "Expire the keys after this many seconds (at least 60)", &opts.expirationTTL,
+	).WithIntFlag(
+		"batch-size", 1000, "Number of keys per bulk write", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "Generate the keys without writing them", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			var tags []string
+			for _, tag := range strings.Split(opts.tags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			seedOptions := kv.SeedOptions{
+				Count:         opts.count,
+				Prefix:        opts.prefix,
+				ValueSize:     opts.valueSize,
+				ValueTemplate: opts.valueTemplate,
+				TagField:      opts.tagField,
+				Tags:          tags,
+				Seed:          opts.seed,
+				ExpirationTTL: opts.expirationTTL,
+				BatchSize:     opts.batchSize,
+				DryRun:        opts.dryRun,
+			}
+			// Check the options before resolving anything
+			if opts.count <= 0 {
+				return fmt.Errorf("--count must be positive")
+			}
+			if _, err := kv.GenerateSeedItems(kv.SeedOptions{Count: 1, ValueSize: opts.valueSize,
+				TagField: opts.tagField, Tags: tags, ExpirationTTL: opts.expirationTTL}); err != nil {
+				return err
+			}
+
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			// Validate that we have a namespace ID
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			if !opts.dryRun {
+				// Refuse to write test data into protected namespaces
+				if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, accountID, opts.namespaceID); err != nil {
+					return err
+				}
+
+				if !opts.force && !common.ConfirmAction(fmt.Sprintf(
+					"Write %d generated keys under '%s' to namespace %s, overwriting keys with the same names?",
+					opts.count, opts.prefix, opts.namespaceID)) {
+					fmt.Println("Seed cancelled.")
+					return nil
+				}
+			}
+
+			var progress func(written, total int)
+			if !opts.outputJSON {
+				progress = func(written, total int) {
+					fmt.Printf("Progress: %d/%d keys...  \r", written, total)
+				}
+			}
+
+			result, err := kv.SeedNamespace(client, accountID, opts.namespaceID, seedOptions, progress)
+			if err != nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				if err := common.OutputJSON(result); err != nil {
+					return err
+				}
+			} else {
+				if progress != nil && !opts.dryRun {
+					fmt.Println()
+				}
+				if opts.dryRun {
+					fmt.Printf("DRY RUN: Would write %d keys, %s to %s\n", result.Generated, result.First, result.Last)
+				} else {
+					fmt.Printf("Wrote %d of %d keys, %s to %s\n", result.Written, result.Generated, result.First, result.Last)
+				}
+				if len(result.TagCounts) > 0 {
+					tagNames := make([]string, 0, len(result.TagCounts))
+					for tag := range result.TagCounts {
+						tagNames = append(tagNames, tag)
+					}
+					sort.Strings(tagNames)
+					for _, tag := range tagNames {
+						fmt.Printf("  %s=%s: %d keys\n", opts.tagField, tag, result.TagCounts[tag])
+					}
+				}
+				printFailedKeys(result.Failed)
+			}
+
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("failed to write %d keys", len(result.Failed))
+			}
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,145 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVTagsCommand creates the tags command group for KV
+func NewKVTagsCommand() *CommandBuilder {
+	return NewCommand("tags", "Inspect metadata tags", `
+Inspect the tags stored in KV key metadata.
+`).WithExample(`  # Show how many keys carry each cache tag
+  cache-kv-purger kv tags report --namespace-id YOUR_NAMESPACE_ID --tag-field cache-tag
+`).WithSubCommand(
+		NewKVTagsReportCommand().Build(),
+	)
+}
+
+// NewKVTagsReportCommand creates a new tags report command for KV
+func NewKVTagsReportCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID   string
+		namespaceID string
+		namespace   string
+		tagField    string
+		prefix      string
+		minCount    int
+		concurrency int
+		delimiter   string
+		outputJSON  bool
+	}
+
+	// Create command
+	return NewCommand("report", "Report tag cardinality from key metadata", `
+Scan the metadata of every key in a namespace and report each distinct value of
+a tag field together with the number of keys carrying it.
+
+Each tag is counted separately: arrays count each element, and string values are
+split by --delimiter (a comma by default; pass an empty --delimiter to count each
+value whole). A key counts once per distinct tag it carries.
+
+Metadata returned by the key listing is used first; metadata is only requested
+individually for keys listed without any. Use this before deciding what to purge.
+`).WithExample(`  # Report cache tag usage
+  cache-kv-purger kv tags report --namespace-id YOUR_NAMESPACE_ID --tag-field cache-tag
+
+  # Only show tags used by at least 100 keys, as JSON
+  cache-kv-purger kv tags report --namespace "My Namespace" --tag-field cache-tag --min-count 100 --json
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"namespace-id", "", "Namespace ID", &opts.namespaceID,
+	).WithStringFlag(
+		"namespace", "", "Namespace name (alternative to namespace-id)", &opts.namespace,
+	).WithStringFlag(
+		"tag-field", "cache-tag", "Metadata field to report on", &opts.tagField,
+	).WithStringFlag(
+		"prefix", "", "Only scan keys with this prefix", &opts.prefix,
+	).WithIntFlag(
+		"min-count", 0, "Only show tag values carried by at least this many keys", &opts.minCount,
+	).WithIntFlag(
+		"concurrency", 0, "Number of concurrent metadata requests", &opts.concurrency,
+	).WithStringFlag(
+		"delimiter", ",", "Splits string tag values into several tags (empty to count values whole)", &opts.delimiter,
+	).WithBoolFlag(
+		"json", false, "Output as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			// Resolve account ID
+			accountID, err := RequireAccountID(cmd, cfg, opts.accountID)
+			if err != nil {
+				return err
+			}
+
+			// Create KV service
+			service := kv.NewKVService(client)
+
+			// Handle namespace ID resolution if namespace name is provided
+			if opts.namespace != "" && opts.namespaceID == "" {
+				nsID, err := service.ResolveNamespaceID(cmd.Context(), accountID, opts.namespace)
+				if err != nil {
+					return fmt.Errorf("failed to resolve namespace: %w", err)
+				}
+				opts.namespaceID = nsID
+			}
+
+			if opts.namespaceID == "" {
+				return fmt.Errorf("namespace-id or namespace is required")
+			}
+
+			var progressCallback func(processed, total int)
+			if cfg.IsVerbose() {
+				progressCallback = func(processed, total int) {
+					if processed%1000 == 0 || processed == total {
+						fmt.Printf("Progress: %d/%d keys scanned\n", processed, total)
+					}
+				}
+			}
+
+			report, err := kv.BuildTagReport(client, accountID, opts.namespaceID, opts.tagField, kv.TagReportOptions{
+				Prefix:      opts.prefix,
+				MinCount:    opts.minCount,
+				Concurrency: opts.concurrency,
+				Delimiter:   opts.delimiter,
+			}, progressCallback)
+			if err != nil {
+				return fmt.Errorf("failed to build tag report: %w", err)
+			}
+
+			// Display results
+			if opts.outputJSON {
+				return common.OutputJSON(report)
+			}
+
+			data := make(map[string]string)
+			data["Tag Field"] = report.TagField
+			data["Keys Scanned"] = fmt.Sprintf("%d", report.TotalKeys)
+			data["Tagged Keys"] = fmt.Sprintf("%d", report.TaggedKeys)
+			data["Distinct Values"] = fmt.Sprintf("%d", report.DistinctValues)
+			common.FormatKeyValueTable(data)
+
+			if len(report.Tags) == 0 {
+				fmt.Println("\nNo tag values to report.")
+				return nil
+			}
+
+			headers := []string{"Tag Value", "Keys"}
+			rows := make([][]string, len(report.Tags))
+			for i, tag := range report.Tags {
+				rows[i] = []string{tag.Value, fmt.Sprintf("%d", tag.Count)}
+			}
+			fmt.Printf("\nTag values (%d):\n", len(report.Tags))
+			common.FormatTable(headers, rows)
+
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,145 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewKVUndoCommand creates a new undo command for KV
+func NewKVUndoCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		accountID        string
+		journalID        string
+		journalNamespace string
+		list             bool
+		batchSize        int
+		dryRun           bool
+		force            bool
+		outputJSON       bool
+	}
+
+	// Create command
+	return NewCommand("undo", "Restore keys from a write journal", `
+Restore keys changed by 'kv delete --journal' or 'kv put --journal'.
+
+Every journaled command records the value and metadata keys had before it changed
+them, and prints the ID of its journal. Undo writes those values back to the
+namespace they came from and deletes keys the command created. Changes made to the
+keys after the journaled command are overwritten.
+
+Journals are kept in ~/.cache-kv-purger-journal, or in the namespace given with
+--journal-namespace. Expirations are not journaled, so restored keys don't expire.
+`).WithExample(`  # List journals
+  cache-kv-purger kv undo --list
+
+  # Show what undoing a journal would do
+  cache-kv-purger kv undo --journal-id 20250101-120000-a1b2c3 --dry-run
+
+  # Undo a journal kept in a namespace
+  cache-kv-purger kv undo --journal-id 20250101-120000-a1b2c3 --journal-namespace journals
+`).WithStringFlag(
+		"account-id", "", "Cloudflare account ID", &opts.accountID,
+	).WithStringFlag(
+		"journal-id", "", "ID of the journal to undo", &opts.journalID,
+	).WithStringFlag(
+		"journal-namespace", "", "Namespace (name or ID) the journal is kept in, instead of the local journal directory", &opts.journalNamespace,
+	).WithBoolFlag(
+		"list", false, "List journals, newest first", &opts.list,
+	).WithIntFlag(
+		"batch-size", 0, "Batch size for restoring keys", &opts.batchSize,
+	).WithBoolFlag(
+		"dry-run", false, "Show what would be restored without restoring", &opts.dryRun,
+	).WithBoolFlag(
+		"force", false, "Skip confirmation prompt", &opts.force,
+	).WithBoolFlag(
+		"json", false, "Output the result as JSON", &opts.outputJSON,
+	).WithRunE(
+		WithConfigAndClient(func(cmd *cobra.Command, args []string, cfg *config.Config, client *api.Client) error {
+			if opts.list == (opts.journalID != "") {
+				return fmt.Errorf("exactly one of --journal-id or --list is required")
+			}
+
+			// Journals in a namespace need the account; local ones carry their own
+			accountID := opts.accountID
+			if opts.journalNamespace != "" {
+				var err error
+				if accountID, err = RequireAccountID(cmd, cfg, opts.accountID); err != nil {
+					return err
+				}
+			}
+
+			service := kv.NewKVService(client)
+			store, err := journalStore(cmd.Context(), service, client, accountID, opts.journalNamespace)
+			if err != nil {
+				return err
+			}
+
+			if opts.list {
+				summaries, err := store.List()
+				if err != nil {
+					return err
+				}
+				if opts.outputJSON {
+					return common.OutputJSON(summaries)
+				}
+				if len(summaries) == 0 {
+					fmt.Println("No journals found.")
+					return nil
+				}
+				fmt.Printf("%-24s %-20s %-32s %-10s %s\n", "ID", "Created", "Namespace", "Operation", "Keys")
+				for _, summary := range summaries {
+					fmt.Printf("%-24s %-20s %-32s %-10s %d\n", summary.ID, summary.CreatedAt.Format("2006-01-02 15:04:05"),
+						summary.NamespaceID, summary.Operation, summary.Keys)
+				}
+				return nil
+			}
+
+			journal, err := store.Load(opts.journalID)
+			if err != nil {
+				return err
+			}
+			if opts.accountID != "" && opts.accountID != journal.AccountID {
+				return fmt.Errorf("journal %s belongs to account %s, not %s", journal.ID, journal.AccountID, opts.accountID)
+			}
+
+			// Undo overwrites and deletes keys, so respect protected namespaces
+			if err := CheckNamespaceProtection(cmd.Context(), cmd, cfg, service, journal.AccountID, journal.NamespaceID); err != nil {
+				return err
+			}
+
+			preview, err := kv.UndoJournal(client, journal, kv.UndoOptions{DryRun: true})
+			if err != nil {
+				return err
+			}
+			if opts.dryRun {
+				fmt.Printf("DRY RUN: Would restore %d keys and delete %d keys in namespace %s (journal %s of %s, %s)\n",
+					preview.Restored, preview.Deleted, journal.NamespaceID, journal.ID, journal.Operation,
+					journal.CreatedAt.Format("2006-01-02 15:04:05"))
+				return nil
+			}
+
+			if !common.ConfirmBatchOperation(len(journal.Entries), "keys", "restore", opts.force) {
+				fmt.Println("Undo cancelled.")
+				return nil
+			}
+
+			result, err := kv.UndoJournal(client, journal, kv.UndoOptions{BatchSize: opts.batchSize})
+			if err != nil {
+				return err
+			}
+
+			if opts.outputJSON {
+				return common.OutputJSON(result)
+			}
+			fmt.Printf("Restored %d keys and deleted %d keys created since journal %s\n", result.Restored, result.Deleted, journal.ID)
+			return nil
+		}),
+	)
+}
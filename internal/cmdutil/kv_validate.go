@@ -0,0 +1,207 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// maxKeyIssuesShown caps how many problems are listed before bulk writes and deletes
+const maxKeyIssuesShown = 10
+
+// NewKVValidateKeysCommand creates a new validate-keys command for KV
+func NewKVValidateKeysCommand() *CommandBuilder {
+	// Define flag variables
+	var opts struct {
+		keysFile   string
+		bulkFile   string
+		normalize  bool
+		outputFile string
+		outputJSON bool
+	}
+
+	// Create command
+	return NewCommand("validate-keys", "Check key names before bulk writes and deletes", `
+Check a list of key names without calling the API.
+
+Keys that the API rejects are reported as errors: empty names, names longer than
+512 bytes, names with control characters or invalid UTF-8, and the reserved names
+"." and "..". Leading or trailing whitespace and duplicate entries are reported as
+warnings, since they are accepted but usually a mistake.
+
+With --normalize, whitespace is trimmed and empty and duplicate entries are dropped,
+and the cleaned list is written to --output (or printed).
+
+The command exits with an error when any key would be rejected.
+`).WithExample(`  # Check a keys file before a bulk delete
+  cache-kv-purger kv validate-keys --keys-file keys.txt
+
+  # Check the keys of a bulk write file
+  cache-kv-purger kv validate-keys --bulk-file data.json
+
+  # Write a trimmed, de-duplicated copy of a keys file
+  cache-kv-purger kv validate-keys --keys-file keys.txt --normalize --output clean-keys.txt
+`).WithStringFlag(
+		"keys-file", "", "File containing keys (one per line)", &opts.keysFile,
+	).WithStringFlag(
+		"bulk-file", "", "Bulk write file (JSON array of objects with a key field)", &opts.bulkFile,
+	).WithBoolFlag(
+		"normalize", false, "Trim whitespace and drop empty and duplicate keys", &opts.normalize,
+	).WithStringFlag(
+		"output", "", "File to write normalized keys to (with --normalize)", &opts.outputFile,
+	).WithBoolFlag(
+		"json", false, "Output the issues as JSON", &opts.outputJSON,
+	).WithRunE(
+		func(cmd *cobra.Command, args []string) error {
+			if (opts.keysFile == "") == (opts.bulkFile == "") {
+				return fmt.Errorf("exactly one of --keys-file or --bulk-file is required")
+			}
+			if opts.outputFile != "" && !opts.normalize {
+				return fmt.Errorf("--output requires --normalize")
+			}
+
+			var keys []string
+			var err error
+			if opts.keysFile != "" {
+				keys, err = readKeysFileLines(opts.keysFile)
+			} else {
+				keys, err = readBulkFileKeys(opts.bulkFile)
+			}
+			if err != nil {
+				return err
+			}
+
+			if opts.normalize {
+				keys = common.NormalizeKVKeys(keys)
+				output := strings.Join(keys, "\n") + "\n"
+				if opts.outputFile != "" {
+					if err := os.WriteFile(opts.outputFile, []byte(output), 0644); err != nil {
+						return fmt.Errorf("failed to write normalized keys: %w", err)
+					}
+					if !opts.outputJSON {
+						fmt.Printf("Wrote %d normalized keys to %s\n", len(keys), opts.outputFile)
+					}
+				} else if !opts.outputJSON {
+					fmt.Print(output)
+				}
+			}
+
+			issues := common.ValidateKVKeys(keys)
+			fatal := common.FatalKeyIssues(issues)
+
+			if opts.outputJSON {
+				if err := common.OutputJSON(map[string]interface{}{
+					"keys":   len(keys),
+					"issues": issues,
+				}); err != nil {
+					return err
+				}
+			} else if !opts.normalize || opts.outputFile != "" {
+				printKeyIssues(keys, issues)
+			}
+
+			if len(fatal) > 0 {
+				return fmt.Errorf("%d of %d keys would be rejected by the API", len(fatal), len(keys))
+			}
+			return nil
+		},
+	)
+}
+
+// readKeysFileLines reads one key per line, keeping surrounding whitespace so it can be reported
+func readKeysFileLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+
+	content := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if content == "" {
+		return nil, nil
+	}
+	return strings.Split(content, "\n"), nil
+}
+
+// readBulkFileKeys reads the keys of a bulk write file
+func readBulkFileKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bulk file: %w", err)
+	}
+
+	var items []kv.BulkWriteItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse bulk file (must be JSON array of objects): %w", err)
+	}
+
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	return keys, nil
+}
+
+// printKeyIssues prints a summary and a table of the problems found
+func printKeyIssues(keys []string, issues []common.KeyIssue) {
+	fatal := common.FatalKeyIssues(issues)
+
+	data := make(map[string]string)
+	data["Keys Checked"] = fmt.Sprintf("%d", len(keys))
+	data["Errors"] = fmt.Sprintf("%d", len(fatal))
+	data["Warnings"] = fmt.Sprintf("%d", len(issues)-len(fatal))
+	common.FormatKeyValueTable(data)
+
+	if len(issues) == 0 {
+		fmt.Println("\nAll keys are valid.")
+		return
+	}
+
+	headers := []string{"Line", "Key", "Problem", "Severity"}
+	rows := make([][]string, len(issues))
+	for i, issue := range issues {
+		severity := "warning"
+		if issue.Fatal() {
+			severity = "error"
+		}
+		rows[i] = []string{strconv.Itoa(issue.Index + 1), strconv.Quote(issue.Key), string(issue.Problem), severity}
+	}
+	fmt.Println()
+	common.FormatTable(headers, rows)
+}
+
+// checkKeyNames refuses key lists the API would reject and warns about duplicates and
+// stray whitespace before a bulk write or delete
+func checkKeyNames(keys []string, verbose bool) error {
+	issues := common.ValidateKVKeys(keys)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	fatal := common.FatalKeyIssues(issues)
+	if len(fatal) == 0 {
+		fmt.Printf("Warning: %d key names have duplicates or surrounding whitespace (run 'kv validate-keys' for details)\n", len(issues))
+		if verbose {
+			for _, issue := range issues {
+				fmt.Printf("  %s\n", common.FormatKeyIssue(issue))
+			}
+		}
+		return nil
+	}
+
+	lines := make([]string, 0, maxKeyIssuesShown+1)
+	for i, issue := range fatal {
+		if i == maxKeyIssuesShown {
+			lines = append(lines, fmt.Sprintf("...and %d more", len(fatal)-maxKeyIssuesShown))
+			break
+		}
+		lines = append(lines, common.FormatKeyIssue(issue))
+	}
+	return fmt.Errorf("%d key names would be rejected by the API:\n  %s", len(fatal), strings.Join(lines, "\n  "))
+}
@@ -4,6 +4,7 @@ import (
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/common"
 	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
 	"fmt"
 	"github.com/spf13/cobra"
 )
@@ -50,7 +51,7 @@ func WithConfigAndClient(fn func(*cobra.Command, []string, *config.Config, *api.
 		// Check verbosity settings as well - this ensures all commands using this middleware
 		// will respect the verbosity flags even if they don't use WithVerbose specifically
 		verbosityStr, _ := cmd.Root().PersistentFlags().GetString("verbosity")
-		verboseFlag, _ := cmd.Flags().GetBool("verbose")
+		verboseFlag := commandVerboseFlag(cmd)
 
 		// Set verbose environment flag for commands to check
 		if verboseFlag || verbosityStr == "verbose" || verbosityStr == "debug" {
@@ -65,10 +66,62 @@ func WithConfigAndClient(fn func(*cobra.Command, []string, *config.Config, *api.
 			}
 		}
 
+		// Let commands that take a namespace use a Worker binding name instead
+		if err := ApplyWorkerBinding(cmd, cfg, client); err != nil {
+			return err
+		}
+
+		// Fill in options configured for the target namespace
+		ApplyNamespaceDefaults(cmd, cfg, client)
+
 		return fn(cmd, args, cfg, client)
 	}
 }
 
+// ApplyWorkerBinding resolves --binding through the KV bindings of the --worker script
+// and uses the bound namespace as the command's --namespace-id
+func ApplyWorkerBinding(cmd *cobra.Command, cfg *config.Config, client *api.Client) error {
+	binding, _ := cmd.Flags().GetString("binding")
+	if binding == "" {
+		return nil
+	}
+
+	worker, _ := cmd.Flags().GetString("worker")
+	if worker == "" {
+		return fmt.Errorf("--binding requires --worker to look up the binding")
+	}
+
+	nsFlag := cmd.Flags().Lookup("namespace-id")
+	if nsFlag == nil {
+		return fmt.Errorf("--binding is not supported by '%s'", cmd.CommandPath())
+	}
+	if nsFlag.Changed {
+		return fmt.Errorf("--binding and --namespace-id cannot be used together")
+	}
+
+	accountID, err := RequireAccountID(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	namespaceID, err := kv.ResolveWorkerBinding(client, accountID, worker, binding)
+	if err != nil {
+		return err
+	}
+
+	return cmd.Flags().Set("namespace-id", namespaceID)
+}
+
+// commandVerboseFlag returns the command's own --verbose flag, showing verbose log
+// records when it is set
+func commandVerboseFlag(cmd *cobra.Command) bool {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	if verbose {
+		common.RaiseLogLevel(common.VerbosityVerbose)
+	}
+	return verbose
+}
+
 // WithVerbose adds a verbose flag extractor to simplify checking verbose mode
 // This original version is kept for backward compatibility
 func WithVerbose(fn func(*cobra.Command, []string, bool, bool) error) func(*cobra.Command, []string) error {
@@ -77,7 +130,7 @@ func WithVerbose(fn func(*cobra.Command, []string, bool, bool) error) func(*cobr
 		verbosityStr, _ := cmd.Root().PersistentFlags().GetString("verbosity")
 
 		// Check command-specific verbose flag
-		verboseFlag, _ := cmd.Flags().GetBool("verbose")
+		verboseFlag := commandVerboseFlag(cmd)
 
 		// Determine verbose and debug status - either flag can enable verbose mode
 		verbose := verboseFlag || verbosityStr == "verbose" || verbosityStr == "debug"
@@ -94,7 +147,7 @@ func WithVerbosity(fn func(*cobra.Command, []string, *common.Verbosity) error) f
 		verbosityStr, _ := cmd.Root().PersistentFlags().GetString("verbosity")
 
 		// Check command-specific verbose flag
-		verboseFlag, _ := cmd.Flags().GetBool("verbose")
+		verboseFlag := commandVerboseFlag(cmd)
 
 		// Parse verbosity level
 		level := common.ParseVerbosityLevel(verbosityStr)
@@ -124,7 +177,7 @@ func WithClientAndVerbosity(fn func(*cobra.Command, []string, *api.Client, *comm
 		verbosityStr, _ := cmd.Root().PersistentFlags().GetString("verbosity")
 
 		// Check command-specific verbose flag
-		verboseFlag, _ := cmd.Flags().GetBool("verbose")
+		verboseFlag := commandVerboseFlag(cmd)
 
 		// Parse verbosity level
 		level := common.ParseVerbosityLevel(verbosityStr)
@@ -161,7 +214,7 @@ func WithConfigClientAndVerbosity(fn func(*cobra.Command, []string, *config.Conf
 		verbosityStr, _ := cmd.Root().PersistentFlags().GetString("verbosity")
 
 		// Check command-specific verbose flag
-		verboseFlag, _ := cmd.Flags().GetBool("verbose")
+		verboseFlag := commandVerboseFlag(cmd)
 
 		// Parse verbosity level
 		level := common.ParseVerbosityLevel(verbosityStr)
@@ -0,0 +1,94 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strconv"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+
+	"github.com/spf13/cobra"
+)
+
+// ApplyNamespaceDefaults sets the --batch-size, --concurrency and --tag-field flags of a
+// command from the defaults configured for its target namespace. Flags given on the
+// command line are left alone, and --tag-field is only set on commands where it names
+// the field to work on rather than a filter. Looking up the namespace is best effort:
+// when it fails, the command runs with its own defaults.
+func ApplyNamespaceDefaults(cmd *cobra.Command, cfg *config.Config, client *api.Client) {
+	if cfg == nil || len(cfg.Namespaces) == 0 {
+		return
+	}
+
+	namespaceID, _ := cmd.Flags().GetString("namespace-id")
+	title, _ := cmd.Flags().GetString("namespace")
+	if namespaceID == "" && title == "" {
+		return
+	}
+
+	// Only look up the other half of the namespace when some defaults are keyed by it
+	ids, titles := cfg.NamespaceDefaultKeys()
+	if (namespaceID == "" && len(ids) > 0) || (title == "" && len(titles) > 0) {
+		if accountID := ResolveAccountID(cmd, cfg); accountID != "" {
+			namespaceID, title = lookupNamespace(cmd, kv.NewKVService(client), accountID, namespaceID, title)
+		}
+	}
+
+	defaults, ok := cfg.GetNamespaceDefaults(namespaceID, title)
+	if !ok {
+		return
+	}
+
+	var applied []any
+	setDefault := func(name, value string) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			return
+		}
+		if name == "tag-field" && flag.DefValue == "" {
+			return
+		}
+		if err := cmd.Flags().Set(name, value); err == nil {
+			applied = append(applied, name, value)
+		}
+	}
+	if defaults.BatchSize > 0 {
+		setDefault("batch-size", strconv.Itoa(defaults.BatchSize))
+	}
+	if defaults.Concurrency > 0 {
+		setDefault("concurrency", strconv.Itoa(defaults.Concurrency))
+	}
+	if defaults.TagField != "" {
+		setDefault("tag-field", defaults.TagField)
+	}
+
+	if len(applied) > 0 {
+		common.Logger().Info(fmt.Sprintf("applied namespace defaults for %s", firstNonEmpty(title, namespaceID)), applied...)
+	}
+}
+
+// lookupNamespace fills in the namespace ID from the title, or the title from the ID
+func lookupNamespace(cmd *cobra.Command, service kv.KVService, accountID, namespaceID, title string) (string, string) {
+	namespaces, err := service.ListNamespaces(cmd.Context(), accountID)
+	if err != nil {
+		return namespaceID, title
+	}
+	for _, ns := range namespaces {
+		if (namespaceID != "" && ns.ID == namespaceID) || (namespaceID == "" && ns.Title == title) {
+			return ns.ID, ns.Title
+		}
+	}
+	return namespaceID, title
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
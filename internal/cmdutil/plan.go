@@ -0,0 +1,59 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/common"
+)
+
+// PlanFlags holds the --plan-out, --plan-in and --plan-threshold flag values
+type PlanFlags struct {
+	Out       string  // Save the items matched by a dry run to this file
+	In        string  // Check the items matched by the real run against this file
+	Threshold float64 // Percentage of changed items above which the real run is aborted
+}
+
+// Enabled returns true if a plan is saved or checked
+func (f PlanFlags) Enabled() bool {
+	return f.Out != "" || f.In != ""
+}
+
+// Validate checks that --plan-out is only given with --dry-run
+func (f PlanFlags) Validate(dryRun bool) error {
+	if f.Out != "" && !dryRun {
+		return fmt.Errorf("--plan-out requires --dry-run")
+	}
+	if f.Threshold < 0 || f.Threshold > 100 {
+		return fmt.Errorf("--plan-threshold must be between 0 and 100")
+	}
+	return nil
+}
+
+// Apply saves the matched items of a dry run with --plan-out, and checks them against
+// --plan-in. An error means the operation must not go ahead.
+func (f PlanFlags) Apply(operation, target string, items []string) error {
+	if f.In != "" {
+		plan, err := common.ReadPlan(f.In)
+		if err != nil {
+			return err
+		}
+		diff, err := plan.Check(operation, target, items, f.Threshold)
+		if err != nil {
+			return err
+		}
+		if diff.Divergence() > 0 {
+			fmt.Printf("Warning: matches changed since the plan was made: %s\n", diff)
+		} else {
+			fmt.Printf("Matches are unchanged since the plan was made (%d items)\n", diff.Live)
+		}
+	}
+
+	if f.Out != "" {
+		plan := common.NewPlan(operation, target, items)
+		if err := common.WritePlan(f.Out, plan); err != nil {
+			return err
+		}
+		fmt.Printf("Saved plan of %d items to %s; pass it to the real run with --plan-in %s\n", plan.Count, f.Out, f.Out)
+	}
+	return nil
+}
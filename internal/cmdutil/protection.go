@@ -0,0 +1,64 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+	"cache-kv-purger/internal/kv"
+	"cache-kv-purger/internal/zones"
+
+	"github.com/spf13/cobra"
+)
+
+// CheckNamespaceProtection refuses to modify a namespace that is protected in config,
+// unless --override-protection is passed. The namespace title is only looked up when
+// protected namespaces are configured.
+func CheckNamespaceProtection(ctx context.Context, cmd *cobra.Command, cfg *config.Config, service kv.KVService, accountID, namespaceID string) error {
+	if cfg == nil || !cfg.HasProtectedNamespaces() {
+		return nil
+	}
+
+	title := ""
+	if !cfg.IsNamespaceProtected(namespaceID, "") {
+		namespaces, err := service.ListNamespaces(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to list namespaces for protection check: %w", err)
+		}
+		for _, ns := range namespaces {
+			if ns.ID == namespaceID {
+				title = ns.Title
+				break
+			}
+		}
+	}
+
+	return common.CheckNamespaceProtection(cmd, cfg, namespaceID, title)
+}
+
+// CheckZoneProtection refuses to purge a zone that is protected in config, unless
+// --override-protection is passed. The zone name is only looked up when protected zones
+// are configured, and a failed lookup refuses the purge, since the zone may be protected
+// by name.
+func CheckZoneProtection(cmd *cobra.Command, cfg *config.Config, client *api.Client, zoneID string) error {
+	if cfg == nil || len(cfg.ProtectedZones) == 0 {
+		return nil
+	}
+
+	name := ""
+	if !cfg.IsZoneProtected(zoneID, "") {
+		details, err := zones.GetZoneDetails(client, zoneID)
+		if err != nil {
+			if !common.ProtectionOverridden(cmd) {
+				return fmt.Errorf("failed to look up zone %s for protection check: %w (pass --%s to purge anyway)",
+					zoneID, err, common.OverrideProtectionFlag)
+			}
+		} else {
+			name = details.Result.Name
+		}
+	}
+
+	return common.CheckZoneProtection(cmd, cfg, zoneID, name)
+}
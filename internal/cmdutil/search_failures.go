@@ -0,0 +1,61 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cache-kv-purger/internal/kv"
+)
+
+// failOnErrorsUsage is the help of the --fail-on-errors flag of commands that search values
+const failOnErrorsUsage = "Abort a --search when more than this share of metadata reads fail, e.g. 5% or 0.05; 0 aborts on the first failure"
+
+// applySearchFailures sets up a value search to count the keys it skips because their
+// metadata can't be read: the counts are shown in verbose progress lines and a warning at
+// the end, and with --fail-on-errors too many failures abort the search
+func applySearchFailures(options *kv.SearchOptions, failOnErrors string, verbose bool) error {
+	if failOnErrors != "" {
+		rate, err := parseErrorRate(failOnErrors)
+		if err != nil {
+			return err
+		}
+		options.FailOnErrors, options.MaxErrorRate = true, rate
+	}
+
+	options.OnFailures = printSearchFailures
+	if verbose {
+		options.Progress = func(event kv.ProgressEvent) {
+			fmt.Fprintf(os.Stderr, "Progress: %s\n", event)
+		}
+	}
+	return nil
+}
+
+// printSearchFailures warns on stderr that a search skipped keys, so results piped from
+// stdout stay clean
+func printSearchFailures(failures kv.SearchErrorStats) {
+	if failures.Failed == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: skipped %d of %d keys whose metadata could not be read (%s); matching keys among them are missing from the results\n",
+		failures.Failed, failures.Checked, failures.Classes())
+}
+
+// parseErrorRate parses an error rate given as a percentage ("5%") or a fraction ("0.05")
+func parseErrorRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --fail-on-errors %q: use a percentage like 5%% or a fraction like 0.05", value)
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("invalid --fail-on-errors %q: must be between 0 and 100%%", value)
+	}
+	return rate, nil
+}
@@ -0,0 +1,74 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/kv"
+)
+
+// verifyFlags holds the --verify, --verify-all and --verify-sample flag values
+type verifyFlags struct {
+	verify     bool
+	verifyAll  bool
+	sampleSize int
+}
+
+// enabled returns true if any verification was requested
+func (f verifyFlags) enabled() bool {
+	return f.verify || f.verifyAll
+}
+
+// verifyWrittenValues re-reads written keys, prints a verification report, and returns
+// an error if any key is missing or differs from its source
+func verifyWrittenValues(client *api.Client, accountID, namespaceID string, expected map[string]string,
+	flags verifyFlags, concurrency int) error {
+
+	if len(expected) == 0 {
+		return nil
+	}
+
+	result, err := kv.VerifyValues(client, accountID, namespaceID, expected, kv.VerifyOptions{
+		All:         flags.verifyAll,
+		SampleSize:  flags.sampleSize,
+		Concurrency: concurrency,
+	})
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	status := "Passed"
+	if !result.OK() {
+		status = "FAILED"
+	}
+
+	data := make(map[string]string)
+	data["Verification"] = status
+	data["Keys Checked"] = fmt.Sprintf("%d of %d", result.Checked, result.Total)
+	data["Mismatched"] = fmt.Sprintf("%d", len(result.Mismatched))
+	data["Missing"] = fmt.Sprintf("%d", len(result.Missing))
+	data["Read Errors"] = fmt.Sprintf("%d", len(result.Errors))
+	fmt.Println()
+	common.FormatKeyValueTable(data)
+
+	if result.OK() {
+		return nil
+	}
+
+	if len(result.Mismatched) > 0 {
+		fmt.Println("\nKeys with mismatched values:")
+		common.StringsDisplaySample(result.Mismatched, true)
+	}
+	if len(result.Missing) > 0 {
+		fmt.Println("\nKeys missing after write:")
+		common.StringsDisplaySample(result.Missing, true)
+	}
+	if len(result.Errors) > 0 {
+		fmt.Println("\nKeys that could not be read:")
+		common.StringsDisplaySample(result.Errors, true)
+	}
+
+	return fmt.Errorf("verification failed: %d mismatched, %d missing, %d unreadable of %d keys checked",
+		len(result.Mismatched), len(result.Missing), len(result.Errors), result.Checked)
+}
@@ -0,0 +1,187 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/config"
+)
+
+// DefaultInitialConcurrency is where the shared adaptive request limiter starts
+const DefaultInitialConcurrency = 10
+
+// Tuning for AdaptiveLimiter
+const (
+	// adaptiveBackoffWindow is the minimum time between two decreases, so a burst of
+	// 429s from requests that were already in flight only halves the limit once
+	adaptiveBackoffWindow = time.Second
+	// adaptiveLatencyFactor is how far average latency may rise above the baseline
+	// before the limit is reduced
+	adaptiveLatencyFactor = 2.0
+	// adaptiveLatencyWeight is the weight of a new sample in the latency average
+	adaptiveLatencyWeight = 0.1
+	// adaptiveBaselineDrift lets the baseline creep up towards the average, so a change
+	// in request mix does not keep shrinking the limit forever
+	adaptiveBaselineDrift = 0.01
+)
+
+// AdaptiveLimiter bounds the number of in-flight requests and adjusts that bound from
+// observed outcomes: it halves on rate limiting (HTTP 429), shrinks by 10% when latency
+// climbs well above the fastest recent average, and otherwise grows by one after each
+// full window of successful requests, never exceeding its maximum.
+// Unlike ConcurrencyManager, which sizes worker pools, it gates requests directly, so
+// callers with fixed worker counts share one limit.
+type AdaptiveLimiter struct {
+	mu           sync.Mutex
+	cond         *sync.Cond
+	limit        int
+	maxLimit     int
+	inFlight     int
+	successes    int // Successful requests since the limit last changed
+	avgLatency   time.Duration
+	baseLatency  time.Duration // Lowest recent average latency
+	lastDecrease time.Time
+}
+
+// NewAdaptiveLimiter creates a limiter starting at initial in-flight requests
+func NewAdaptiveLimiter(initial, max int) *AdaptiveLimiter {
+	if max <= 0 {
+		max = config.DefaultMaxConcurrency
+	}
+	if initial <= 0 || initial > max {
+		initial = max
+	}
+
+	l := &AdaptiveLimiter{limit: initial, maxLimit: max}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a request slot is free or the context is done
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	// Wake waiters when the context ends so they can give up
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.inFlight >= l.limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.cond.Wait()
+	}
+	l.inFlight++
+	return nil
+}
+
+// Release frees a request slot and records how the request went
+func (l *AdaptiveLimiter) Release(latency time.Duration, rateLimited bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	defer l.cond.Broadcast()
+
+	if rateLimited {
+		l.decrease(0.5)
+		return
+	}
+
+	// Track average latency and the best recent average
+	if l.avgLatency == 0 {
+		l.avgLatency = latency
+	} else {
+		l.avgLatency += time.Duration(adaptiveLatencyWeight * float64(latency-l.avgLatency))
+	}
+	if l.baseLatency == 0 || l.avgLatency < l.baseLatency {
+		l.baseLatency = l.avgLatency
+	} else {
+		l.baseLatency += time.Duration(adaptiveBaselineDrift * float64(l.avgLatency-l.baseLatency))
+	}
+
+	if float64(l.avgLatency) > adaptiveLatencyFactor*float64(l.baseLatency) {
+		l.decrease(0.9)
+		return
+	}
+
+	l.successes++
+	if l.successes >= l.limit && l.limit < l.maxLimit {
+		l.limit++
+		l.successes = 0
+	}
+}
+
+// decrease scales the limit down, at most once per backoff window
+func (l *AdaptiveLimiter) decrease(factor float64) {
+	if time.Since(l.lastDecrease) < adaptiveBackoffWindow {
+		return
+	}
+
+	l.limit = int(float64(l.limit) * factor)
+	if l.limit < 1 {
+		l.limit = 1
+	}
+	l.successes = 0
+	l.lastDecrease = time.Now()
+}
+
+// SetMax changes the upper bound, lowering the current limit if needed
+func (l *AdaptiveLimiter) SetMax(max int) {
+	if max <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxLimit = max
+	if l.limit > max {
+		l.limit = max
+	}
+	l.cond.Broadcast()
+}
+
+// Limit returns the current number of allowed in-flight requests
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// InFlight returns the number of requests currently holding a slot
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// globalRequestLimiter is shared by every API request in the process, so export, search
+// and purge workers all back off together when the API starts throttling
+var globalRequestLimiter = NewAdaptiveLimiter(DefaultInitialConcurrency, config.DefaultMaxConcurrency)
+
+// SetMaxConcurrency sets the upper bound of in-flight API requests
+func SetMaxConcurrency(max int) {
+	globalRequestLimiter.SetMax(max)
+}
+
+// AcquireRequestSlot waits for a free in-flight API request slot
+func AcquireRequestSlot(ctx context.Context) error {
+	return globalRequestLimiter.Acquire(ctx)
+}
+
+// ReleaseRequestSlot frees an API request slot and records its outcome
+func ReleaseRequestSlot(latency time.Duration, rateLimited bool) {
+	globalRequestLimiter.Release(latency, rateLimited)
+}
+
+// CurrentConcurrencyLimit returns the current adaptive in-flight request limit
+func CurrentConcurrencyLimit() int {
+	return globalRequestLimiter.Limit()
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterHalvesOnRateLimit(t *testing.T) {
+	l := NewAdaptiveLimiter(20, 50)
+
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	l.Release(10*time.Millisecond, true)
+
+	if got := l.Limit(); got != 10 {
+		t.Errorf("limit after 429 = %d, want 10", got)
+	}
+
+	// A second 429 inside the backoff window must not halve again
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+	l.Release(10*time.Millisecond, true)
+
+	if got := l.Limit(); got != 10 {
+		t.Errorf("limit after second 429 in window = %d, want 10", got)
+	}
+}
+
+func TestAdaptiveLimiterGrowsUpToMax(t *testing.T) {
+	l := NewAdaptiveLimiter(2, 4)
+
+	for i := 0; i < 100; i++ {
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire returned error: %v", err)
+		}
+		l.Release(10*time.Millisecond, false)
+	}
+
+	if got := l.Limit(); got != 4 {
+		t.Errorf("limit after steady successes = %d, want max of 4", got)
+	}
+}
+
+func TestAdaptiveLimiterShrinksOnLatency(t *testing.T) {
+	l := NewAdaptiveLimiter(10, 10)
+
+	for i := 0; i < 10; i++ {
+		l.Acquire(context.Background())
+		l.Release(10*time.Millisecond, false)
+	}
+	for i := 0; i < 20; i++ {
+		l.Acquire(context.Background())
+		l.Release(500*time.Millisecond, false)
+	}
+
+	if got := l.Limit(); got >= 10 {
+		t.Errorf("limit after latency spike = %d, want below 10", got)
+	}
+}
+
+func TestAdaptiveLimiterBlocksAtLimit(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 1)
+
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Acquire(ctx); err == nil {
+		t.Fatal("second Acquire at limit should wait until the context ends")
+	}
+
+	l.Release(time.Millisecond, false)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire after Release returned error: %v", err)
+	}
+	if got := l.InFlight(); got != 1 {
+		t.Errorf("in-flight = %d, want 1", got)
+	}
+}
+
+func TestAdaptiveLimiterSetMaxLowersLimit(t *testing.T) {
+	l := NewAdaptiveLimiter(30, 50)
+	l.SetMax(5)
+
+	if got := l.Limit(); got != 5 {
+		t.Errorf("limit after SetMax(5) = %d, want 5", got)
+	}
+}
@@ -1,5 +1,7 @@
 package common
 
+import "fmt"
+
 // GenericBatchProcessor handles batch processing with configurable concurrency using Go generics
 // This is an enhanced version of the original BatchProcessor that can handle any type
 type GenericBatchProcessor[T any, R any] struct {
@@ -80,10 +82,17 @@ func (p *GenericBatchProcessor[T, R]) ProcessItems(items []T, processor func([]T
 	// Use a semaphore to limit concurrent goroutines
 	sem := make(chan struct{}, p.Concurrency)
 
-	// Process all batches concurrently with semaphore control
+	// Process all batches concurrently with semaphore control, starting no new batches
+	// once the run is interrupted
+	dispatched := 0
 	for idx, batch := range batches {
 		// Acquire semaphore slot
 		sem <- struct{}{}
+		if Interrupted() {
+			<-sem
+			break
+		}
+		dispatched++
 
 		// Launch a goroutine to process this batch
 		go func(batchIdx int, batchItems []T) {
@@ -118,8 +127,8 @@ func (p *GenericBatchProcessor[T, R]) ProcessItems(items []T, processor func([]T
 	completed := 0
 	successCount := 0
 
-	// Collect results from all batches
-	for i := 0; i < len(batches); i++ {
+	// Collect results from all started batches
+	for i := 0; i < dispatched; i++ {
 		result := <-resultChan
 
 		// Save error or success
@@ -137,6 +146,10 @@ func (p *GenericBatchProcessor[T, R]) ProcessItems(items []T, processor func([]T
 		p.ProgressCallback(completed, len(batches), successCount)
 	}
 
+	if dispatched < len(batches) {
+		errors = append(errors, fmt.Errorf("%w: %d of %d batches not started", ErrInterrupted, len(batches)-dispatched, len(batches)))
+	}
+
 	return successful, errors
 }
 
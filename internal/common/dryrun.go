@@ -169,20 +169,24 @@ func displaySampleItems(items []string, batches [][]string, verbose bool) {
 }
 
 // ConfirmBatchOperation asks the user to confirm a batch operation
-// Returns true if the user confirms, or if force is true
+// Returns true if the user confirms, or if force is true or prompts are answered yes
 func ConfirmBatchOperation(itemCount int, itemType string, actionVerb string, force bool) bool {
-	if force {
+	message := fmt.Sprintf("%s %d %s", actionVerb, itemCount, itemType)
+	if force || AssumeYes() {
+		RecordConfirmation(message, true, true)
 		return true
 	}
 
-	fmt.Printf("\nYou are about to %s %d %s.\n", actionVerb, itemCount, itemType)
-	fmt.Print("This operation cannot be undone. Are you sure? [y/N]: ")
+	fmt.Printf("\nYou are about to %s.\n", message)
+	fmt.Print("This operation cannot be undone. Are you sure? " + confirmPromptSuffix)
 
 	var confirm string
 	if _, err := fmt.Scanln(&confirm); err != nil || (confirm != "y" && confirm != "Y") {
+		RecordConfirmation(message, false, false)
 		fmt.Println("Operation cancelled.")
 		return false
 	}
 
+	RecordConfirmation(message, true, false)
 	return true
 }
@@ -2,18 +2,36 @@ package common
 
 import (
 	"fmt"
+	"os"
 )
 
 // ConfirmAction prompts the user for confirmation of an action
 func ConfirmAction(message string) bool {
-	fmt.Printf("%s [y/N]: ", message)
+	if AssumeYes() {
+		RecordConfirmation(message, true, true)
+		return true
+	}
+	fmt.Printf("%s %s", message, confirmPromptSuffix)
 	var confirm string
 	if _, err := fmt.Scanln(&confirm); err != nil || (confirm != "y" && confirm != "Y") {
+		RecordConfirmation(message, false, false)
 		return false
 	}
+	RecordConfirmation(message, true, false)
 	return true
 }
 
+// StdinIsTerminal returns true when stdin is an interactive terminal that can answer a
+// prompt. Pipes, files and /dev/null are not.
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
 // ConfirmDeletion is a specialized confirmation for deletion operations
 func ConfirmDeletion(count int, itemType string) bool {
 	return ConfirmAction(fmt.Sprintf("\nAre you sure you want to delete these %d %s? This cannot be undone.", count, itemType))
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// ExitInterrupted is the exit code of a run stopped by SIGINT or SIGTERM
+const ExitInterrupted = 130
+
+// ErrInterrupted is returned for API requests that were not sent because the run was interrupted
+var ErrInterrupted = errors.New("interrupted, request not sent")
+
+var (
+	interrupted atomic.Bool
+
+	completedMu sync.Mutex
+	completed   = make(map[string]int)
+)
+
+// CatchInterrupts handles SIGINT and SIGTERM for the rest of the run. The first signal
+// marks the run as interrupted: requests already in flight finish, but no new ones are
+// sent, so batches wind down and commands return with what they completed. A second
+// signal exits immediately.
+func CatchInterrupts() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		interrupted.Store(true)
+		fmt.Fprintf(os.Stderr, "\nReceived %s: waiting for in-flight requests, no new work is started (signal again to exit immediately)\n", sig)
+
+		<-signals
+		fmt.Fprintln(os.Stderr, "Exiting without waiting for in-flight requests")
+		os.Exit(ExitInterrupted)
+	}()
+}
+
+// Interrupted returns true once the run has been interrupted
+func Interrupted() bool {
+	return interrupted.Load()
+}
+
+// MarkInterrupted marks the run as interrupted, as a first SIGINT does
+func MarkInterrupted() {
+	interrupted.Store(true)
+}
+
+// RecordCompleted counts work the API confirmed during this run, e.g. "keys deleted", so an
+// interrupted run can report what it finished
+func RecordCompleted(what string, n int) {
+	if n <= 0 {
+		return
+	}
+	completedMu.Lock()
+	completed[what] += n
+	completedMu.Unlock()
+}
+
+// CompletedWork returns the work counted by RecordCompleted, sorted by description
+func CompletedWork() []CompletedCount {
+	completedMu.Lock()
+	defer completedMu.Unlock()
+
+	counts := make([]CompletedCount, 0, len(completed))
+	for what, n := range completed {
+		counts = append(counts, CompletedCount{What: what, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].What < counts[j].What })
+	return counts
+}
+
+// CompletedCount is an amount of completed work
+type CompletedCount struct {
+	What  string `json:"what"`
+	Count int    `json:"count"`
+}
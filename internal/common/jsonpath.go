@@ -0,0 +1,123 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseJSONPath splits a path such as .config.flags.enabled or .items[0].name into
+// its segments. A path of "." or "" refers to the whole document.
+func ParseJSONPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "." {
+		return nil, nil
+	}
+
+	// Treat [N] as a segment of its own
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	path = strings.TrimPrefix(path, ".")
+
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid path '%s': empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// GetJSONPath returns the value at a path inside a decoded JSON document
+func GetJSONPath(doc interface{}, path string) (interface{}, error) {
+	segments, err := ParseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for i, segment := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("path '%s' not found", joinJSONPath(segments[:i+1]))
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(segment, len(node))
+			if err != nil {
+				return nil, fmt.Errorf("path '%s': %w", joinJSONPath(segments[:i+1]), err)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path '%s' is not an object or array", joinJSONPath(segments[:i]))
+		}
+	}
+
+	return current, nil
+}
+
+// SetJSONPath sets the value at a path inside a decoded JSON document and returns the
+// updated document. Missing objects along the path are created; array indexes must exist.
+func SetJSONPath(doc interface{}, path string, value interface{}) (interface{}, error) {
+	segments, err := ParseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return setJSONPath(doc, segments, 0, value)
+}
+
+// setJSONPath sets the value below one node and returns the updated node
+func setJSONPath(node interface{}, segments []string, depth int, value interface{}) (interface{}, error) {
+	if depth == len(segments) {
+		return value, nil
+	}
+
+	segment := segments[depth]
+	switch current := node.(type) {
+	case nil:
+		child, err := setJSONPath(nil, segments, depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{segment: child}, nil
+	case map[string]interface{}:
+		child, err := setJSONPath(current[segment], segments, depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+		current[segment] = child
+		return current, nil
+	case []interface{}:
+		index, err := arrayIndex(segment, len(current))
+		if err != nil {
+			return nil, fmt.Errorf("path '%s': %w", joinJSONPath(segments[:depth+1]), err)
+		}
+		child, err := setJSONPath(current[index], segments, depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+		current[index] = child
+		return current, nil
+	default:
+		return nil, fmt.Errorf("path '%s' is not an object or array", joinJSONPath(segments[:depth]))
+	}
+}
+
+// arrayIndex parses an array index segment and checks it is in range
+func arrayIndex(segment string, length int) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not an array index", segment)
+	}
+	if index < 0 || index >= length {
+		return 0, fmt.Errorf("index %d out of range (length %d)", index, length)
+	}
+	return index, nil
+}
+
+// joinJSONPath formats path segments for error messages
+func joinJSONPath(segments []string) string {
+	return "." + strings.Join(segments, ".")
+}
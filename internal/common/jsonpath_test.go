@@ -0,0 +1,94 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeTestJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return doc
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := map[string][]string{
+		"":                      nil,
+		".":                     nil,
+		".config.flags.enabled": {"config", "flags", "enabled"},
+		"config.flags":          {"config", "flags"},
+		".items[0].name":        {"items", "0", "name"},
+	}
+	for path, want := range tests {
+		got, err := ParseJSONPath(path)
+		if err != nil {
+			t.Errorf("ParseJSONPath(%q) error: %v", path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseJSONPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := ParseJSONPath(".config..flags"); err == nil {
+		t.Error("expected error for empty segment")
+	}
+}
+
+func TestGetJSONPath(t *testing.T) {
+	doc := decodeTestJSON(t, `{"config":{"flags":{"enabled":true}},"items":[{"name":"a"},{"name":"b"}]}`)
+
+	got, err := GetJSONPath(doc, ".config.flags.enabled")
+	if err != nil || got != true {
+		t.Errorf("got %v, %v; want true", got, err)
+	}
+
+	got, err = GetJSONPath(doc, ".items[1].name")
+	if err != nil || got != "b" {
+		t.Errorf("got %v, %v; want b", got, err)
+	}
+
+	for _, path := range []string{".config.missing", ".items[2]", ".config.flags.enabled.deeper"} {
+		if _, err := GetJSONPath(doc, path); err == nil {
+			t.Errorf("expected error for %s", path)
+		}
+	}
+}
+
+func TestSetJSONPath(t *testing.T) {
+	doc := decodeTestJSON(t, `{"config":{"flags":{"enabled":true}},"items":[1,2]}`)
+
+	doc, err := SetJSONPath(doc, ".config.flags.enabled", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err = SetJSONPath(doc, ".config.limits.max", 10.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err = SetJSONPath(doc, ".items[0]", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := decodeTestJSON(t, `{"config":{"flags":{"enabled":false},"limits":{"max":10}},"items":["first",2]}`)
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("got %v, want %v", doc, want)
+	}
+
+	if _, err := SetJSONPath(doc, ".items[5]", 1.0); err == nil {
+		t.Error("expected error for out of range index")
+	}
+	if _, err := SetJSONPath(doc, ".config.flags.enabled.deeper", 1.0); err == nil {
+		t.Error("expected error when traversing a scalar")
+	}
+
+	root, err := SetJSONPath(doc, ".", "replaced")
+	if err != nil || root != "replaced" {
+		t.Errorf("got %v, %v; want replaced", root, err)
+	}
+}
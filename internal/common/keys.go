@@ -0,0 +1,114 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxKVKeyLength is the longest KV key name the API accepts, in bytes
+const MaxKVKeyLength = 512
+
+// KeyProblem describes why a key name was flagged
+type KeyProblem string
+
+const (
+	// KeyEmpty is an empty key name
+	KeyEmpty KeyProblem = "empty key"
+	// KeyTooLong is a key name over MaxKVKeyLength bytes
+	KeyTooLong KeyProblem = "longer than 512 bytes"
+	// KeyInvalidChars is a key name with control characters or invalid UTF-8
+	KeyInvalidChars KeyProblem = "invalid characters"
+	// KeyReserved is one of the names the API rejects, "." and ".."
+	KeyReserved KeyProblem = "reserved name"
+	// KeyWhitespace is a key name with leading or trailing whitespace
+	KeyWhitespace KeyProblem = "leading or trailing whitespace"
+	// KeyDuplicate is a key name that appears earlier in the list
+	KeyDuplicate KeyProblem = "duplicate"
+)
+
+// KeyIssue is a problem found with one key name
+type KeyIssue struct {
+	Index   int        `json:"index"` // Position of the key in the list, starting at 0
+	Key     string     `json:"key"`
+	Problem KeyProblem `json:"problem"`
+}
+
+// Fatal returns true for problems that make the API reject the key. Whitespace and
+// duplicates are accepted by the API but are usually mistakes.
+func (i KeyIssue) Fatal() bool {
+	return i.Problem != KeyWhitespace && i.Problem != KeyDuplicate
+}
+
+// ValidateKVKeys checks key names before a bulk write or delete and returns every
+// problem found, in list order
+func ValidateKVKeys(keys []string) []KeyIssue {
+	var issues []KeyIssue
+	seen := make(map[string]bool, len(keys))
+
+	for i, key := range keys {
+		add := func(problem KeyProblem) {
+			issues = append(issues, KeyIssue{Index: i, Key: key, Problem: problem})
+		}
+
+		switch {
+		case key == "":
+			add(KeyEmpty)
+		case key == "." || key == "..":
+			add(KeyReserved)
+		case len(key) > MaxKVKeyLength:
+			add(KeyTooLong)
+		}
+		if !utf8.ValidString(key) || strings.IndexFunc(key, unicode.IsControl) >= 0 {
+			add(KeyInvalidChars)
+		}
+		if key != "" && strings.TrimSpace(key) != key {
+			add(KeyWhitespace)
+		}
+		if seen[key] {
+			add(KeyDuplicate)
+		}
+		seen[key] = true
+	}
+
+	return issues
+}
+
+// FatalKeyIssues returns the issues that make the API reject a key
+func FatalKeyIssues(issues []KeyIssue) []KeyIssue {
+	var fatal []KeyIssue
+	for _, issue := range issues {
+		if issue.Fatal() {
+			fatal = append(fatal, issue)
+		}
+	}
+	return fatal
+}
+
+// NormalizeKVKeys trims whitespace from key names and drops empty and duplicate
+// entries, keeping the first occurrence of each key
+func NormalizeKVKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, key)
+	}
+
+	return normalized
+}
+
+// FormatKeyIssue formats an issue for error messages
+func FormatKeyIssue(issue KeyIssue) string {
+	key := issue.Key
+	if len(key) > 60 {
+		key = key[:60] + "..."
+	}
+	return fmt.Sprintf("%q: %s", key, issue.Problem)
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateKVKeys(t *testing.T) {
+	keys := []string{
+		"good-key",
+		"",
+		"..",
+		strings.Repeat("a", MaxKVKeyLength+1),
+		"bad\x00key",
+		" padded ",
+		"good-key",
+		"\xff",
+	}
+
+	got := ValidateKVKeys(keys)
+	want := []KeyIssue{
+		{Index: 1, Key: "", Problem: KeyEmpty},
+		{Index: 2, Key: "..", Problem: KeyReserved},
+		{Index: 3, Key: keys[3], Problem: KeyTooLong},
+		{Index: 4, Key: "bad\x00key", Problem: KeyInvalidChars},
+		{Index: 5, Key: " padded ", Problem: KeyWhitespace},
+		{Index: 6, Key: "good-key", Problem: KeyDuplicate},
+		{Index: 7, Key: "\xff", Problem: KeyInvalidChars},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateKVKeys() = %+v, want %+v", got, want)
+	}
+
+	fatal := FatalKeyIssues(got)
+	if len(fatal) != 5 {
+		t.Errorf("expected 5 fatal issues, got %d", len(fatal))
+	}
+
+	if issues := ValidateKVKeys([]string{"a", "b/c", "ünïcode"}); len(issues) != 0 {
+		t.Errorf("expected valid keys, got %+v", issues)
+	}
+}
+
+func TestNormalizeKVKeys(t *testing.T) {
+	got := NormalizeKVKeys([]string{" a ", "b", "a", "", "  ", "c\t", "b"})
+	want := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeKVKeys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Log formats accepted by ConfigureLogging
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var (
+	// consoleLevel and fileLevel are the lowest levels logged to the console and the log file
+	consoleLevel = new(slog.LevelVar)
+	fileLevel    = new(slog.LevelVar)
+
+	logger = slog.New(&redactHandler{handler: &consoleHandler{level: consoleLevel}})
+
+	// consoleMu keeps console lines of concurrent records whole
+	consoleMu sync.Mutex
+)
+
+func init() {
+	consoleLevel.Set(slog.LevelWarn)
+	fileLevel.Set(slog.LevelInfo)
+}
+
+// Logger returns the process logger. Debug records are shown at --verbosity debug and Info
+// records at --verbosity verbose; warnings and errors are always shown.
+func Logger() *slog.Logger {
+	return logger
+}
+
+// LogDebug logs a formatted message at debug verbosity
+func LogDebug(format string, args ...interface{}) {
+	logger.Debug(fmt.Sprintf(format, args...))
+}
+
+// LogVerbose logs a formatted message at verbose verbosity
+func LogVerbose(format string, args ...interface{}) {
+	logger.Info(fmt.Sprintf(format, args...))
+}
+
+// LogError logs a formatted error message that does not stop the operation
+func LogError(format string, args ...interface{}) {
+	logger.Error(fmt.Sprintf(format, args...))
+}
+
+// ConfigureLogging sets up the process logger. With the text format and no file, log
+// records are printed to the console as "[VERBOSE] message" lines like any other output.
+// With a file, they are also written to it as text or JSON records, at least at the
+// verbose level, so runs leave a complete log. The JSON format without a file writes
+// JSON records to stderr instead of console lines, for CI logs. The log file, if any,
+// is returned for the caller to close. Secrets in every record are redacted (see Redact).
+func ConfigureLogging(format, path string, level VerbosityLevel) (*os.File, error) {
+	if format == "" {
+		format = LogFormatText
+	}
+	if format != LogFormatText && format != LogFormatJSON {
+		return nil, fmt.Errorf("invalid log format '%s', must be text or json", format)
+	}
+
+	SetLogLevel(level)
+
+	var file *os.File
+	var handler slog.Handler = &consoleHandler{level: consoleLevel}
+	switch {
+	case path != "":
+		var err error
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handler = &fanoutHandler{handlers: []slog.Handler{handler, newFormatHandler(format, file, fileLevel)}}
+	case format == LogFormatJSON:
+		handler = newFormatHandler(format, os.Stderr, consoleLevel)
+	}
+
+	logger = slog.New(&redactHandler{handler: handler})
+	return file, nil
+}
+
+// SetLogLevel sets the lowest level logged from a verbosity level. Log files always
+// receive verbose records.
+func SetLogLevel(level VerbosityLevel) {
+	consoleLevel.Set(slogLevel(level))
+	fileLevel.Set(min(slogLevel(level), slog.LevelInfo))
+}
+
+// RaiseLogLevel logs at least the records of a verbosity level, as a command's own
+// --verbose flag does, without hiding records that are already logged
+func RaiseLogLevel(level VerbosityLevel) {
+	if slogLevel(level) < consoleLevel.Level() {
+		SetLogLevel(level)
+	}
+}
+
+// slogLevel returns the lowest log level shown at a verbosity level
+func slogLevel(level VerbosityLevel) slog.Level {
+	switch level {
+	case VerbosityQuiet:
+		return slog.LevelError
+	case VerbosityVerbose:
+		return slog.LevelInfo
+	case VerbosityDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelWarn
+	}
+}
+
+func newFormatHandler(format string, w io.Writer, level slog.Leveler) slog.Handler {
+	options := &slog.HandlerOptions{Level: level}
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, options)
+	}
+	return slog.NewTextHandler(w, options)
+}
+
+// consoleHandler prints log records as human-readable lines on stdout, looked up when
+// each record is written so quiet mode discards them
+type consoleHandler struct {
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	switch {
+	case record.Level >= slog.LevelError:
+		line.WriteString("[ERROR] ")
+	case record.Level >= slog.LevelWarn:
+		line.WriteString("Warning: ")
+	case record.Level >= slog.LevelInfo:
+		line.WriteString("[VERBOSE] ")
+	default:
+		line.WriteString("[DEBUG] ")
+	}
+	line.WriteString(record.Message)
+
+	writeAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	line.WriteByte('\n')
+
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+	_, err := io.WriteString(os.Stdout, line.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &consoleHandler{level: h.level, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *consoleHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// fanoutHandler passes each record to several handlers
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
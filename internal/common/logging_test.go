@@ -0,0 +1,148 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetLogging restores the default console logger after a test
+func resetLogging(t *testing.T) {
+	t.Cleanup(func() {
+		if _, err := ConfigureLogging(LogFormatText, "", VerbosityNormal); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestConfigureLoggingInvalidFormat(t *testing.T) {
+	resetLogging(t)
+
+	if _, err := ConfigureLogging("xml", "", VerbosityNormal); err == nil {
+		t.Fatal("expected an error for an unknown log format")
+	}
+}
+
+func TestConsoleLogLevels(t *testing.T) {
+	resetLogging(t)
+
+	tests := []struct {
+		name  string
+		level VerbosityLevel
+		want  []string
+		skip  []string
+	}{
+		{
+			name:  "quiet shows errors only",
+			level: VerbosityQuiet,
+			want:  []string{"[ERROR] failed 3"},
+			skip:  []string{"Warning:", "[VERBOSE]", "[DEBUG]"},
+		},
+		{
+			name:  "normal hides verbose records",
+			level: VerbosityNormal,
+			want:  []string{"[ERROR] failed 3", "Warning: low quota"},
+			skip:  []string{"[VERBOSE]", "[DEBUG]"},
+		},
+		{
+			name:  "debug shows everything",
+			level: VerbosityDebug,
+			want:  []string{"[ERROR] failed 3", "Warning: low quota", "[VERBOSE] batch 1 done", "[DEBUG] request key=a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ConfigureLogging(LogFormatText, "", tt.level); err != nil {
+				t.Fatal(err)
+			}
+			out := captureStdout(t, func() {
+				LogError("failed %d", 3)
+				Logger().Warn("low quota")
+				LogVerbose("batch %d done", 1)
+				Logger().Debug("request", "key", "a")
+			})
+
+			for _, want := range tt.want {
+				if !strings.Contains(out, want+"\n") {
+					t.Errorf("output %q is missing %q", out, want)
+				}
+			}
+			for _, skip := range tt.skip {
+				if strings.Contains(out, skip) {
+					t.Errorf("output %q should not contain %q", out, skip)
+				}
+			}
+		})
+	}
+}
+
+func TestRaiseLogLevel(t *testing.T) {
+	resetLogging(t)
+
+	if _, err := ConfigureLogging(LogFormatText, "", VerbosityDebug); err != nil {
+		t.Fatal(err)
+	}
+	RaiseLogLevel(VerbosityVerbose)
+
+	out := captureStdout(t, func() { LogDebug("still shown") })
+	if !strings.Contains(out, "[DEBUG] still shown") {
+		t.Errorf("raising to verbose should keep debug records, got %q", out)
+	}
+}
+
+func TestJSONLogFile(t *testing.T) {
+	resetLogging(t)
+
+	path := filepath.Join(t.TempDir(), "run.log")
+	file, err := ConfigureLogging(LogFormatJSON, path, VerbosityQuiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(t, func() {
+		LogVerbose("deleted %d keys", 10)
+		Logger().Info("command finished", "command", "kv delete")
+		LogDebug("not in the file")
+	})
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records in the log file, got %d: %q", len(lines), data)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatalf("log record is not JSON: %v", err)
+	}
+	if record["msg"] != "command finished" || record["level"] != "INFO" || record["command"] != "kv delete" {
+		t.Errorf("unexpected log record %v", record)
+	}
+}
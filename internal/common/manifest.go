@@ -0,0 +1,262 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ManifestVersion is the format version of purge manifests
+const ManifestVersion = 1
+
+// Stage types of a purge manifest
+const (
+	StageKVDelete        = "kv-delete"        // Delete KV keys by name, prefix, metadata field or value search
+	StagePurgeTags       = "purge-tags"       // Purge cache tags
+	StagePurgeFiles      = "purge-files"      // Purge URLs
+	StagePurgePrefixes   = "purge-prefixes"   // Purge URL prefixes
+	StagePurgeHosts      = "purge-hosts"      // Purge hostnames
+	StagePurgeEverything = "purge-everything" // Purge the whole cache of the zones
+	StageWarm            = "warm"             // Request URLs so the cache is populated again
+)
+
+// Failure policies of a manifest stage
+const (
+	OnFailureAbort    = "abort"    // Skip every later stage (the default)
+	OnFailureContinue = "continue" // Run the later stages that don't depend on this one
+)
+
+// Outcomes of a manifest stage
+const (
+	StageSucceeded = "succeeded"
+	StageFailed    = "failed"
+	StageSkipped   = "skipped"
+)
+
+// Manifest is a release purge described in one file: stages run one after another in file
+// order, so for example KV is cleaned before the cache tags serving it are purged, and URLs
+// are warmed only once both are done. Zones and Namespace are the defaults of stages that
+// don't name their own.
+type Manifest struct {
+	Version   int             `json:"version"`
+	Zones     []string        `json:"zones,omitempty"`     // Zone IDs or names of cache stages
+	Namespace string          `json:"namespace,omitempty"` // Namespace ID or title of kv-delete stages
+	Stages    []ManifestStage `json:"stages"`
+}
+
+// ManifestStage is one step of a manifest. A stage runs only once every stage named in
+// DependsOn has succeeded; when it fails, OnFailure decides whether the run aborts or
+// carries on with the stages that don't depend on it.
+type ManifestStage struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	OnFailure string   `json:"on_failure,omitempty"` // abort (default) or continue
+	DependsOn []string `json:"depends_on,omitempty"` // Names of earlier stages that must succeed first
+
+	// Cache stages
+	Zones []string `json:"zones,omitempty"` // Overrides the manifest zones
+	Items []string `json:"items,omitempty"` // Tags, URLs, prefixes or hosts, by stage type
+
+	// kv-delete stages: Keys, or Prefix with an optional TagField/TagValue or Search filter
+	Namespace string   `json:"namespace,omitempty"` // Overrides the manifest namespace
+	Keys      []string `json:"keys,omitempty"`
+	Prefix    string   `json:"prefix,omitempty"`
+	TagField  string   `json:"tag_field,omitempty"`
+	TagValue  string   `json:"tag_value,omitempty"`
+	Search    string   `json:"search,omitempty"`
+}
+
+// StageResult is the outcome of running, or skipping, a manifest stage
+type StageResult struct {
+	Stage    ManifestStage
+	Status   string // succeeded, failed or skipped
+	Err      error  // Why the stage failed or was skipped
+	Duration time.Duration
+}
+
+// LoadManifest reads and validates a purge manifest. Unknown fields are refused, so a
+// misspelled filter can't silently widen a stage.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// Validate checks the manifest and fills in the defaults of its stages: a name from the
+// stage's position, the abort failure policy, and the manifest zones and namespace
+func (m *Manifest) Validate() error {
+	if m.Version == 0 {
+		m.Version = ManifestVersion
+	}
+	if m.Version != ManifestVersion {
+		return fmt.Errorf("unsupported manifest version %d (expected %d)", m.Version, ManifestVersion)
+	}
+	if len(m.Stages) == 0 {
+		return fmt.Errorf("at least one stage is required")
+	}
+
+	seen := make(map[string]bool, len(m.Stages))
+	for i := range m.Stages {
+		stage := &m.Stages[i]
+		if stage.Name == "" {
+			stage.Name = fmt.Sprintf("stage-%d", i+1)
+		}
+		if seen[stage.Name] {
+			return fmt.Errorf("stage name '%s' is used more than once", stage.Name)
+		}
+
+		switch stage.OnFailure {
+		case "":
+			stage.OnFailure = OnFailureAbort
+		case OnFailureAbort, OnFailureContinue:
+		default:
+			return fmt.Errorf("stage '%s': on_failure must be %s or %s, got '%s'", stage.Name, OnFailureAbort, OnFailureContinue, stage.OnFailure)
+		}
+
+		// Dependencies must come first, so the file order is the execution order
+		for _, dependency := range stage.DependsOn {
+			if !seen[dependency] {
+				return fmt.Errorf("stage '%s' depends on '%s', which must be an earlier stage", stage.Name, dependency)
+			}
+		}
+		seen[stage.Name] = true
+
+		if err := stage.validate(m); err != nil {
+			return fmt.Errorf("stage '%s': %w", stage.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the fields of a stage against its type and applies the manifest defaults
+func (s *ManifestStage) validate(m *Manifest) error {
+	switch s.Type {
+	case StageKVDelete:
+		if len(s.Items) > 0 || len(s.Zones) > 0 {
+			return fmt.Errorf("items and zones don't apply to %s stages", s.Type)
+		}
+		if s.Namespace == "" {
+			s.Namespace = m.Namespace
+		}
+		if len(s.Keys) > 0 && (s.Prefix != "" || s.TagField != "" || s.Search != "") {
+			return fmt.Errorf("keys cannot be combined with prefix, tag_field or search")
+		}
+		if len(s.Keys) == 0 && s.Prefix == "" && s.TagField == "" && s.Search == "" {
+			return fmt.Errorf("one of keys, prefix, tag_field or search is required")
+		}
+		if s.TagField != "" && s.Search != "" {
+			return fmt.Errorf("tag_field cannot be combined with search")
+		}
+		if s.TagValue != "" && s.TagField == "" {
+			return fmt.Errorf("tag_value requires tag_field")
+		}
+		return nil
+
+	case StagePurgeTags, StagePurgeFiles, StagePurgePrefixes, StagePurgeHosts, StagePurgeEverything:
+		if s.Type == StagePurgeEverything && len(s.Items) > 0 {
+			return fmt.Errorf("items don't apply to %s stages", s.Type)
+		}
+		if s.Type != StagePurgeEverything && len(s.Items) == 0 {
+			return fmt.Errorf("at least one item is required")
+		}
+		if len(s.Zones) == 0 {
+			s.Zones = m.Zones
+		}
+		if s.Type == StagePurgeFiles {
+			if err := validateStageURLs(s.Items); err != nil {
+				return err
+			}
+		}
+
+	case StageWarm:
+		if len(s.Zones) > 0 {
+			return fmt.Errorf("zones don't apply to %s stages", s.Type)
+		}
+		if len(s.Items) == 0 {
+			return fmt.Errorf("at least one URL is required")
+		}
+		if err := validateStageURLs(s.Items); err != nil {
+			return err
+		}
+
+	case "":
+		return fmt.Errorf("type is required")
+	default:
+		return fmt.Errorf("unknown type '%s'", s.Type)
+	}
+
+	if s.Namespace != "" || len(s.Keys) > 0 || s.Prefix != "" || s.TagField != "" || s.TagValue != "" || s.Search != "" {
+		return fmt.Errorf("KV fields don't apply to %s stages", s.Type)
+	}
+	return nil
+}
+
+// validateStageURLs checks that the items of a stage are absolute http(s) URLs
+func validateStageURLs(items []string) error {
+	for _, item := range items {
+		u, err := url.Parse(item)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid URL '%s': must be an absolute http or https URL", item)
+		}
+	}
+	return nil
+}
+
+// RunManifestStages runs the stages in order with run. A stage whose dependencies didn't
+// all succeed is skipped; a failed stage with the abort policy, or an interrupt, skips
+// every stage after it.
+func RunManifestStages(stages []ManifestStage, run func(ManifestStage) error) []StageResult {
+	results := make([]StageResult, 0, len(stages))
+	status := make(map[string]string, len(stages))
+	var stopped error
+
+	for _, stage := range stages {
+		result := StageResult{Stage: stage, Status: StageSkipped}
+		if stopped == nil && Interrupted() {
+			stopped = fmt.Errorf("interrupted")
+		}
+
+		if stopped != nil {
+			result.Err = stopped
+		} else {
+			for _, dependency := range stage.DependsOn {
+				if status[dependency] != StageSucceeded {
+					result.Err = fmt.Errorf("dependency '%s' %s", dependency, status[dependency])
+					break
+				}
+			}
+		}
+
+		if result.Err == nil {
+			start := time.Now()
+			result.Err = run(stage)
+			result.Duration = time.Since(start)
+			result.Status = StageSucceeded
+			if result.Err != nil {
+				result.Status = StageFailed
+				if stage.OnFailure != OnFailureContinue {
+					stopped = fmt.Errorf("aborted after stage '%s' failed", stage.Name)
+				}
+			}
+		}
+
+		status[stage.Name] = result.Status
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.json")
+	data := `{
+		"zones": ["example.com"],
+		"namespace": "content",
+		"stages": [
+			{"name": "kv", "type": "kv-delete", "tag_field": "cache-tag", "tag_value": "products"},
+			{"type": "purge-tags", "items": ["products"], "depends_on": ["kv"], "on_failure": "continue"},
+			{"type": "warm", "items": ["https://example.com/products"], "zones": []}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if manifest.Version != ManifestVersion {
+		t.Errorf("Version = %d, want %d", manifest.Version, ManifestVersion)
+	}
+	kvStage, tagStage := manifest.Stages[0], manifest.Stages[1]
+	if kvStage.Namespace != "content" || kvStage.OnFailure != OnFailureAbort {
+		t.Errorf("kv stage = %+v, want the manifest namespace and the abort policy", kvStage)
+	}
+	if tagStage.Name != "stage-2" || len(tagStage.Zones) != 1 || tagStage.OnFailure != OnFailureContinue {
+		t.Errorf("tag stage = %+v, want a default name, the manifest zones and the continue policy", tagStage)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"stages": [{"type": "purge-tags", "itmes": ["a"]}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadManifest(path); err == nil || !strings.Contains(err.Error(), "itmes") {
+		t.Errorf("expected an unknown field to be refused, got %v", err)
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []ManifestStage
+		errMsg string
+	}{
+		{name: "no stages", errMsg: "at least one stage"},
+		{name: "unknown type", stages: []ManifestStage{{Type: "purge-all"}}, errMsg: "unknown type"},
+		{name: "duplicate names", stages: []ManifestStage{
+			{Name: "a", Type: StagePurgeEverything}, {Name: "a", Type: StagePurgeEverything},
+		}, errMsg: "more than once"},
+		{name: "later dependency", stages: []ManifestStage{
+			{Name: "a", Type: StagePurgeEverything, DependsOn: []string{"b"}}, {Name: "b", Type: StagePurgeEverything},
+		}, errMsg: "earlier stage"},
+		{name: "bad policy", stages: []ManifestStage{{Type: StagePurgeEverything, OnFailure: "retry"}}, errMsg: "on_failure"},
+		{name: "tags without items", stages: []ManifestStage{{Type: StagePurgeTags}}, errMsg: "at least one item"},
+		{name: "kv without filter", stages: []ManifestStage{{Type: StageKVDelete}}, errMsg: "one of keys"},
+		{name: "keys and prefix", stages: []ManifestStage{{Type: StageKVDelete, Keys: []string{"a"}, Prefix: "p"}}, errMsg: "cannot be combined"},
+		{name: "relative warm URL", stages: []ManifestStage{{Type: StageWarm, Items: []string{"/products"}}}, errMsg: "invalid URL"},
+		{name: "kv fields on purge", stages: []ManifestStage{{Type: StagePurgeTags, Items: []string{"a"}, Prefix: "p"}}, errMsg: "KV fields"},
+		{name: "valid", stages: []ManifestStage{
+			{Type: StageKVDelete, Prefix: "products/"},
+			{Type: StagePurgeHosts, Items: []string{"example.com"}, DependsOn: []string{"stage-1"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Manifest{Stages: tt.stages}).Validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want one containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestRunManifestStages(t *testing.T) {
+	manifest := &Manifest{Stages: []ManifestStage{
+		{Name: "kv", Type: StageKVDelete, Keys: []string{"a"}, OnFailure: OnFailureContinue},
+		{Name: "tags", Type: StagePurgeTags, Items: []string{"t"}, DependsOn: []string{"kv"}},
+		{Name: "hosts", Type: StagePurgeHosts, Items: []string{"example.com"}},
+		{Name: "everything", Type: StagePurgeEverything},
+		{Name: "warm", Type: StageWarm, Items: []string{"https://example.com/"}},
+	}}
+	if err := manifest.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	results := RunManifestStages(manifest.Stages, func(stage ManifestStage) error {
+		ran = append(ran, stage.Name)
+		if stage.Name == "kv" || stage.Name == "everything" {
+			return fmt.Errorf("%s failed", stage.Name)
+		}
+		return nil
+	})
+
+	// kv continues on failure, tags depends on it, and everything aborts the rest
+	if got := strings.Join(ran, ","); got != "kv,hosts,everything" {
+		t.Errorf("ran %s, want kv,hosts,everything", got)
+	}
+	want := []string{StageFailed, StageSkipped, StageSucceeded, StageFailed, StageSkipped}
+	for i, result := range results {
+		if result.Status != want[i] {
+			t.Errorf("stage %s: status %s, want %s", result.Stage.Name, result.Status, want[i])
+		}
+	}
+	if err := results[1].Err; err == nil || !strings.Contains(err.Error(), "dependency 'kv' failed") {
+		t.Errorf("skipped dependent error = %v", err)
+	}
+	if err := results[4].Err; err == nil || !strings.Contains(err.Error(), "aborted after stage 'everything'") {
+		t.Errorf("aborted stage error = %v", err)
+	}
+}
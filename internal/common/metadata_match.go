@@ -0,0 +1,76 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LookupMetadataField returns the value of a metadata field. A field naming a top-level key
+// is looked up directly, so keys containing dots keep working; otherwise a dot path such as
+// meta.tags descends into nested objects, and into arrays by index (meta.tags.0).
+func LookupMetadataField(metadata map[string]interface{}, field string) (interface{}, bool) {
+	if metadata == nil || field == "" {
+		return nil, false
+	}
+	if value, ok := metadata[field]; ok {
+		return value, true
+	}
+	if !strings.Contains(field, ".") {
+		return nil, false
+	}
+	value, err := GetJSONPath(metadata, field)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// MetadataValueMatches reports whether a metadata value matches a tag value. An empty tag
+// value matches any value; strings match exactly, numbers and booleans match their text
+// ("42" matches 42 and 42.0, "true" matches true), and arrays match when any element does,
+// so tags stored as ["a", "b"] match "a".
+func MetadataValueMatches(value interface{}, want string) bool {
+	if want == "" {
+		return true
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v == want
+	case float64:
+		if n, err := strconv.ParseFloat(want, 64); err == nil {
+			return n == v
+		}
+		return false
+	case int:
+		return strconv.Itoa(v) == strings.TrimSpace(want)
+	case int64:
+		return strconv.FormatInt(v, 10) == strings.TrimSpace(want)
+	case bool:
+		b, err := strconv.ParseBool(want)
+		return err == nil && b == v
+	case []interface{}:
+		for _, element := range v {
+			if MetadataValueMatches(element, want) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, element := range v {
+			if element == want {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// MetadataFieldMatches reports whether metadata has a field, given as a name or dot path,
+// whose value matches want as MetadataValueMatches does
+func MetadataFieldMatches(metadata map[string]interface{}, field, want string) bool {
+	value, ok := LookupMetadataField(metadata, field)
+	return ok && MetadataValueMatches(value, want)
+}
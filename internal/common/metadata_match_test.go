@@ -0,0 +1,56 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetadataFieldMatches(t *testing.T) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"cache-tag": "product-1",
+		"tags": ["red", "blue", 7],
+		"version": 3,
+		"ratio": 0.5,
+		"live": true,
+		"meta": {"tags": ["nested-a", "nested-b"], "owner": {"team": "web"}},
+		"dotted.key": "literal"
+	}`), &metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field, value string
+		want         bool
+	}{
+		{"cache-tag", "product-1", true},
+		{"cache-tag", "product", false},
+		{"cache-tag", "", true},
+		{"tags", "blue", true},
+		{"tags", "green", false},
+		{"tags", "7", true},
+		{"version", "3", true},
+		{"version", "3.0", true},
+		{"version", "4", false},
+		{"ratio", "0.5", true},
+		{"live", "true", true},
+		{"live", "false", false},
+		{"meta.tags", "nested-b", true},
+		{"meta.owner.team", "web", true},
+		{"meta.owner", "", true},
+		{"meta.owner", "web", false},
+		{"meta.tags.0", "nested-a", true},
+		{"meta.missing", "", false},
+		{"dotted.key", "literal", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		if got := MetadataFieldMatches(metadata, tt.field, tt.value); got != tt.want {
+			t.Errorf("MetadataFieldMatches(%q, %q) = %v, want %v", tt.field, tt.value, got, tt.want)
+		}
+	}
+
+	if MetadataFieldMatches(nil, "tags", "") {
+		t.Error("nil metadata matched")
+	}
+}
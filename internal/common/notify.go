@@ -0,0 +1,68 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// notifyTimeout bounds a notification request, so an unreachable webhook can't hold up
+// the end of a run
+const notifyTimeout = 10 * time.Second
+
+// ValidateNotifyURL checks that a webhook URL is an absolute http(s) URL
+func ValidateNotifyURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid notification URL: must be an http or https URL")
+	}
+	return nil
+}
+
+// PostNotification POSTs payload as JSON to a webhook and fails unless it answers with a
+// 2xx status. Webhook URLs often embed a secret, so errors name only the host.
+func PostNotification(ctx context.Context, webhookURL string, payload interface{}) error {
+	if err := ValidateNotifyURL(webhookURL); err != nil {
+		return err
+	}
+	host := webhookURL
+	if u, err := url.Parse(webhookURL); err == nil {
+		host = u.Host
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error of a request repeats its URL, so report only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send notification to %s: %w", host, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification to %s failed: HTTP %d", host, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPostNotification(t *testing.T) {
+	var got map[string]interface{}
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid notification body: %v", err)
+		}
+		if r.URL.Path == "/fail/secret-token" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	if err := PostNotification(context.Background(), server.URL+"/hook", map[string]interface{}{"status": "succeeded"}); err != nil {
+		t.Fatalf("PostNotification: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if got["status"] != "succeeded" {
+		t.Errorf("payload = %v, want status succeeded", got)
+	}
+
+	err := PostNotification(context.Background(), server.URL+"/fail/secret-token", map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Fatalf("error = %v, want HTTP 403", err)
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error %q leaks the webhook path", err)
+	}
+}
+
+func TestValidateNotifyURL(t *testing.T) {
+	for _, raw := range []string{"https://hooks.slack.com/services/T/B/X", "http://localhost:8080/hook"} {
+		if err := ValidateNotifyURL(raw); err != nil {
+			t.Errorf("ValidateNotifyURL(%q) = %v, want nil", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "hooks.slack.com/services", "ftp://example.com/hook", "https://"} {
+		if err := ValidateNotifyURL(raw); err == nil {
+			t.Errorf("ValidateNotifyURL(%q) = nil, want an error", raw)
+		}
+	}
+}
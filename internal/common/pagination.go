@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -27,8 +28,15 @@ type PaginationOptions struct {
 
 	// BatchSize is the size of each page to fetch
 	BatchSize int
+
+	// MaxCursorRestarts is the number of times pagination restarts after the API
+	// rejects an expired or invalid cursor (0 = default of 3, negative = never)
+	MaxCursorRestarts int
 }
 
+// defaultMaxCursorRestarts is used when PaginationOptions.MaxCursorRestarts is zero
+const defaultMaxCursorRestarts = 3
+
 // PaginationResult captures the results and metadata from a pagination operation
 type PaginationResult struct {
 	// PageCount is the number of pages fetched
@@ -40,6 +48,9 @@ type PaginationResult struct {
 	// Warnings contains any non-fatal issues encountered during pagination
 	Warnings []string
 
+	// CursorRestarts is the number of times pagination restarted after a cursor expired
+	CursorRestarts int
+
 	// StartTime is when the pagination operation started
 	StartTime time.Time
 
@@ -68,7 +79,7 @@ func (l *PaginationLogger) Debug(format string, args ...interface{}) {
 		if l.options.LogPrefix != "" {
 			prefix = l.options.LogPrefix + " "
 		}
-		fmt.Printf("[DEBUG] %s"+format+"\n", append([]interface{}{prefix}, args...)...)
+		LogDebug("%s"+format, append([]interface{}{prefix}, args...)...)
 	}
 }
 
@@ -79,7 +90,7 @@ func (l *PaginationLogger) Verbose(format string, args ...interface{}) {
 		if l.options.LogPrefix != "" {
 			prefix = l.options.LogPrefix + " "
 		}
-		fmt.Printf("[INFO] %s"+format+"\n", append([]interface{}{prefix}, args...)...)
+		LogVerbose("%s"+format, append([]interface{}{prefix}, args...)...)
 	}
 }
 
@@ -100,12 +111,36 @@ type PaginationHandler interface {
 	ProcessItems(items interface{}) error
 }
 
+// RestartablePaginationHandler is a PaginationHandler that can resume after a cursor expires.
+// Pagination restarts from the first page, so the handler must skip items it already processed.
+type RestartablePaginationHandler interface {
+	PaginationHandler
+
+	// PrepareRestart is called before pagination restarts from the first page
+	PrepareRestart() error
+}
+
+// IsCursorExpiredError returns true if the error indicates the API rejected a pagination cursor
+// because it expired or is no longer valid
+func IsCursorExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "cursor") {
+		return false
+	}
+
+	return strings.Contains(msg, "expired") || strings.Contains(msg, "invalid") || strings.Contains(msg, "not valid")
+}
+
 // ExecutePagination performs a pagination operation with consistent handling
 func ExecutePagination(handler PaginationHandler, options *PaginationOptions) (*PaginationResult, error) {
 	// Initialize default options if not provided
 	if options == nil {
 		options = &PaginationOptions{
-			MaxRetries: 3,
+			MaxRetries: CurrentPolicy().Retries,
 			Timeout:    30 * time.Second,
 		}
 	}
@@ -124,6 +159,12 @@ func ExecutePagination(handler PaginationHandler, options *PaginationOptions) (*
 	var cursor string
 	var seenCursors = make(map[string]bool)
 
+	maxCursorRestarts := options.MaxCursorRestarts
+	if maxCursorRestarts == 0 {
+		maxCursorRestarts = defaultMaxCursorRestarts
+	}
+	restartable, canRestart := handler.(RestartablePaginationHandler)
+
 	// Set an overall timeout if specified
 	var timeoutChan <-chan time.Time
 	if options.Timeout > 0 {
@@ -173,6 +214,23 @@ func ExecutePagination(handler PaginationHandler, options *PaginationOptions) (*
 				break
 			}
 
+			// An expired cursor can't be retried, start over and let the handler dedupe
+			if cursor != "" && canRestart && IsCursorExpiredError(err) && result.CursorRestarts < maxCursorRestarts {
+				result.CursorRestarts++
+				logger.Warning(fmt.Sprintf("Cursor expired after %d pages, restarting pagination (restart %d of %d)",
+					result.PageCount, result.CursorRestarts, maxCursorRestarts))
+
+				if restartErr := restartable.PrepareRestart(); restartErr != nil {
+					result.EndTime = time.Now()
+					return result, fmt.Errorf("failed to restart pagination: %w", restartErr)
+				}
+
+				cursor = ""
+				seenCursors = make(map[string]bool)
+				retries = options.MaxRetries
+				continue
+			}
+
 			// Check if we can retry
 			if retries > 0 {
 				retries--
@@ -183,7 +241,10 @@ func ExecutePagination(handler PaginationHandler, options *PaginationOptions) (*
 
 			// No more retries, return the error
 			result.EndTime = time.Now()
-			return result, fmt.Errorf("pagination failed after %d retries: %w", options.MaxRetries, err)
+			if options.MaxRetries > 0 {
+				return result, fmt.Errorf("pagination failed after %d retries: %w", options.MaxRetries, err)
+			}
+			return result, err
 		}
 
 		// Increment page counter
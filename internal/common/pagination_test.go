@@ -0,0 +1,100 @@
+package common
+
+import (
+	"errors"
+	"testing"
+)
+
+// expiringCursorHandler serves fixed pages and rejects one cursor the first time it's used
+type expiringCursorHandler struct {
+	pages         map[string][]string
+	next          map[string]string
+	expiredCursor string
+	expiredOnce   bool
+	seen          map[string]bool
+	collected     []string
+}
+
+func (h *expiringCursorHandler) FetchPage(cursor string) (interface{}, string, bool, error) {
+	if cursor == h.expiredCursor && !h.expiredOnce {
+		h.expiredOnce = true
+		return nil, "", false, errors.New("API error (HTTP 400): cursor expired")
+	}
+	next := h.next[cursor]
+	return h.pages[cursor], next, next == "", nil
+}
+
+func (h *expiringCursorHandler) ProcessItems(items interface{}) error {
+	for _, item := range items.([]string) {
+		if h.seen != nil {
+			if h.seen[item] {
+				continue
+			}
+			h.seen[item] = true
+		}
+		h.collected = append(h.collected, item)
+	}
+	return nil
+}
+
+func (h *expiringCursorHandler) PrepareRestart() error {
+	h.seen = make(map[string]bool)
+	for _, item := range h.collected {
+		h.seen[item] = true
+	}
+	return nil
+}
+
+func TestExecutePaginationCursorRestart(t *testing.T) {
+	handler := &expiringCursorHandler{
+		pages: map[string][]string{
+			"":   {"a", "b"},
+			"c1": {"c", "d"},
+			"c2": {"e"},
+		},
+		next:          map[string]string{"": "c1", "c1": "c2"},
+		expiredCursor: "c2",
+	}
+
+	result, err := ExecutePagination(handler, &PaginationOptions{MaxRetries: 1})
+	if err != nil {
+		t.Fatalf("Expected pagination to recover, got error: %v", err)
+	}
+
+	if result.CursorRestarts != 1 {
+		t.Errorf("Expected 1 cursor restart, got %d", result.CursorRestarts)
+	}
+
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %d", len(result.Warnings))
+	}
+
+	expected := []string{"a", "b", "c", "d", "e"}
+	if len(handler.collected) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, handler.collected)
+	}
+	for i, item := range expected {
+		if handler.collected[i] != item {
+			t.Errorf("Expected item %d to be %s, got %s", i, item, handler.collected[i])
+		}
+	}
+}
+
+func TestIsCursorExpiredError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("API error (HTTP 400): cursor expired"), true},
+		{errors.New("failed to list keys: Invalid cursor"), true},
+		{errors.New("API error (HTTP 500): internal error"), false},
+		{errors.New("invalid namespace ID"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCursorExpiredError(tt.err); got != tt.expected {
+			t.Errorf("IsCursorExpiredError(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
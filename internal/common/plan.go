@@ -0,0 +1,156 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// PlanVersion is the format version written to plan files
+const PlanVersion = 1
+
+// Plan is the set of items a dry run matched, saved with --plan-out so the real run can
+// check with --plan-in that it still matches the same items. Items are stored as SHA-256
+// hashes so plans don't leak key names or URLs.
+type Plan struct {
+	Version   int       `json:"version"`
+	Operation string    `json:"operation"` // e.g. "kv delete" or "cache purge files"
+	Target    string    `json:"target"`    // Namespace or zone ID the items belong to
+	CreatedAt time.Time `json:"created_at"`
+	Count     int       `json:"count"`
+	Hashes    []string  `json:"hashes"` // Sorted SHA-256 hashes of the items
+}
+
+// PlanDiff is how the items of a real run differ from a plan
+type PlanDiff struct {
+	Planned int // Items in the plan
+	Live    int // Items matched by the real run
+	Added   int // Live items not in the plan
+	Removed int // Planned items no longer matched
+}
+
+// Divergence returns the changed items as a percentage of the planned items. A plan with
+// no items diverges 100% from any live items.
+func (d PlanDiff) Divergence() float64 {
+	changed := d.Added + d.Removed
+	if changed == 0 {
+		return 0
+	}
+	if d.Planned == 0 {
+		return 100
+	}
+	return float64(changed) / float64(d.Planned) * 100
+}
+
+// String summarizes the difference for output
+func (d PlanDiff) String() string {
+	return fmt.Sprintf("%d planned, %d matched now, %d added, %d removed (%.1f%% divergence)",
+		d.Planned, d.Live, d.Added, d.Removed, d.Divergence())
+}
+
+// hashPlanItem returns the hex SHA-256 hash of an item
+func hashPlanItem(item string) string {
+	sum := sha256.Sum256([]byte(item))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewPlan creates a plan for the items matched by an operation on a target
+func NewPlan(operation, target string, items []string) *Plan {
+	seen := make(map[string]bool, len(items))
+	hashes := make([]string, 0, len(items))
+	for _, item := range items {
+		hash := hashPlanItem(item)
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	return &Plan{
+		Version:   PlanVersion,
+		Operation: operation,
+		Target:    target,
+		CreatedAt: time.Now().UTC(),
+		Count:     len(hashes),
+		Hashes:    hashes,
+	}
+}
+
+// WritePlan saves a plan as JSON
+func WritePlan(path string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadPlan loads a plan saved by WritePlan
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("plan %s has unsupported version %d", path, plan.Version)
+	}
+	return &plan, nil
+}
+
+// Compare returns how the items matched now differ from the plan
+func (p *Plan) Compare(items []string) PlanDiff {
+	planned := make(map[string]bool, len(p.Hashes))
+	for _, hash := range p.Hashes {
+		planned[hash] = true
+	}
+
+	diff := PlanDiff{Planned: len(planned)}
+	live := make(map[string]bool, len(items))
+	for _, item := range items {
+		hash := hashPlanItem(item)
+		if live[hash] {
+			continue
+		}
+		live[hash] = true
+		if !planned[hash] {
+			diff.Added++
+		}
+	}
+	diff.Live = len(live)
+
+	for hash := range planned {
+		if !live[hash] {
+			diff.Removed++
+		}
+	}
+	return diff
+}
+
+// Check compares the items matched now with the plan. It returns an error when the plan
+// was made for another operation or target, or when the items diverge from the plan by
+// more than threshold percent. Smaller divergences are returned for a warning.
+func (p *Plan) Check(operation, target string, items []string, threshold float64) (PlanDiff, error) {
+	if p.Operation != operation || p.Target != target {
+		return PlanDiff{}, fmt.Errorf("plan was made for %s on %s, not %s on %s", p.Operation, p.Target, operation, target)
+	}
+
+	diff := p.Compare(items)
+	if diff.Divergence() > threshold {
+		return diff, fmt.Errorf("matches changed since the plan was made at %s: %s exceeds the %.1f%% threshold",
+			p.CreatedAt.Format(time.RFC3339), diff, threshold)
+	}
+	return diff, nil
+}
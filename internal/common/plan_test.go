@@ -0,0 +1,76 @@
+package common
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanCompare(t *testing.T) {
+	plan := NewPlan("kv delete", "ns1", []string{"a", "b", "c", "d", "a"})
+	if plan.Count != 4 {
+		t.Fatalf("expected 4 unique items in the plan, got %d", plan.Count)
+	}
+
+	tests := []struct {
+		name       string
+		items      []string
+		added      int
+		removed    int
+		divergence float64
+	}{
+		{name: "unchanged", items: []string{"d", "c", "b", "a"}, divergence: 0},
+		{name: "one added", items: []string{"a", "b", "c", "d", "e"}, added: 1, divergence: 25},
+		{name: "one removed", items: []string{"a", "b", "c"}, removed: 1, divergence: 25},
+		{name: "replaced", items: []string{"a", "b", "x", "y"}, added: 2, removed: 2, divergence: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := plan.Compare(tt.items)
+			if diff.Added != tt.added || diff.Removed != tt.removed || diff.Divergence() != tt.divergence {
+				t.Errorf("got %s, want %d added, %d removed, %.1f%%", diff, tt.added, tt.removed, tt.divergence)
+			}
+		})
+	}
+}
+
+func TestPlanCheck(t *testing.T) {
+	plan := NewPlan("kv delete", "ns1", []string{"a", "b", "c", "d"})
+
+	if _, err := plan.Check("kv delete", "ns1", []string{"a", "b", "c", "d", "e"}, 30); err != nil {
+		t.Errorf("expected divergence within the threshold to pass, got %v", err)
+	}
+	if _, err := plan.Check("kv delete", "ns1", []string{"a", "b", "c", "d", "e"}, 0); err == nil {
+		t.Error("expected divergence above the threshold to fail")
+	}
+	if _, err := plan.Check("kv delete", "ns2", []string{"a", "b", "c", "d"}, 100); err == nil || !strings.Contains(err.Error(), "ns1") {
+		t.Errorf("expected a plan for another target to fail, got %v", err)
+	}
+
+	empty := NewPlan("kv delete", "ns1", nil)
+	if diff := empty.Compare([]string{"a"}); diff.Divergence() != 100 {
+		t.Errorf("expected an empty plan to diverge 100%%, got %.1f%%", diff.Divergence())
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	plan := NewPlan("cache purge files", "zone1", []string{"https://example.com/a", "https://example.com/b"})
+
+	if err := WritePlan(path, plan); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Operation != plan.Operation || loaded.Target != plan.Target || loaded.Count != 2 {
+		t.Errorf("loaded plan %+v does not match %+v", loaded, plan)
+	}
+	for _, hash := range loaded.Hashes {
+		if strings.Contains(hash, "example.com") {
+			t.Errorf("plan stores item %q instead of its hash", hash)
+		}
+	}
+}
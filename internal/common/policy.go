@@ -0,0 +1,153 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy decides how API work is retried, timed out, paced and sized. It is built once per
+// run from flags, environment variables and config and installed with SetPolicy; the API
+// client and the kv and cache bulk operations read it with CurrentPolicy instead of
+// hard-coding their own limits.
+type Policy struct {
+	Retries        int           // Retries of a failed request or batch, after the first attempt
+	Backoff        time.Duration // Delay before the first retry, doubled for each further one
+	MaxBackoff     time.Duration // Longest delay between retries
+	Timeout        time.Duration // Time allowed for one API request
+	RateLimit      int           // API requests per second across the process
+	MaxConcurrency int           // Most workers a bulk operation runs at once
+	BatchSize      int           // Keys per bulk request when a command doesn't set one, at most MaxBulkBatchSize
+	WorkerDelay    time.Duration // Delay workers add before requests, so they don't all send at once
+}
+
+// Policy defaults, used for every field a run doesn't set
+const (
+	DefaultRetries        = 4
+	DefaultBackoff        = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultTimeout        = 300 * time.Second
+	DefaultRateLimit      = 100
+	DefaultMaxConcurrency = 50
+	DefaultBatchSize      = 1000
+	DefaultWorkerDelay    = 5 * time.Millisecond
+)
+
+// MaxBulkBatchSize is the most keys the KV bulk write and delete endpoints take per request
+const MaxBulkBatchSize = 10000
+
+// DefaultPolicy returns the policy of runs that don't configure one
+func DefaultPolicy() Policy {
+	return Policy{
+		Retries:        DefaultRetries,
+		Backoff:        DefaultBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Timeout:        DefaultTimeout,
+		RateLimit:      DefaultRateLimit,
+		MaxConcurrency: DefaultMaxConcurrency,
+		BatchSize:      DefaultBatchSize,
+		WorkerDelay:    DefaultWorkerDelay,
+	}
+}
+
+var (
+	policyMu      sync.RWMutex
+	currentPolicy = DefaultPolicy()
+)
+
+// SetPolicy installs the policy of the run, with defaults for unset fields, and applies its
+// rate limit and concurrency bound to the process-wide limiters. Retries can be 0; the
+// other fields must be positive to take effect.
+func SetPolicy(p Policy) {
+	p = p.withDefaults()
+
+	policyMu.Lock()
+	currentPolicy = p
+	policyMu.Unlock()
+
+	ConfigureGlobalRateLimit(p.RateLimit, 2*p.RateLimit)
+	SetMaxConcurrency(p.MaxConcurrency)
+}
+
+// CurrentPolicy returns the policy installed with SetPolicy, or DefaultPolicy
+func CurrentPolicy() Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return currentPolicy
+}
+
+// withDefaults fills the fields that are not set from DefaultPolicy
+func (p Policy) withDefaults() Policy {
+	defaults := DefaultPolicy()
+	if p.Retries < 0 {
+		p.Retries = 0
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = defaults.Backoff
+	}
+	if p.MaxBackoff < p.Backoff {
+		p.MaxBackoff = max(defaults.MaxBackoff, p.Backoff)
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaults.Timeout
+	}
+	if p.RateLimit <= 0 {
+		p.RateLimit = defaults.RateLimit
+	}
+	if p.MaxConcurrency <= 0 {
+		p.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if p.BatchSize <= 0 {
+		p.BatchSize = defaults.BatchSize
+	}
+	if p.BatchSize > MaxBulkBatchSize {
+		p.BatchSize = MaxBulkBatchSize
+	}
+	if p.WorkerDelay < 0 {
+		p.WorkerDelay = 0
+	}
+	return p
+}
+
+// RetryConfig returns the retry settings of the policy for Retry and RetryOperation
+func (p Policy) RetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts:  p.Retries + 1,
+		InitialDelay: p.Backoff,
+		MaxDelay:     p.MaxBackoff,
+		Multiplier:   2.0,
+		Jitter:       0.2,
+	}
+}
+
+// RetryDelay returns the delay before retry attempt (1 for the first retry)
+func (p Policy) RetryDelay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// Concurrency returns the workers to run for a requested concurrency: fallback when none
+// was requested, and never more than MaxConcurrency
+func (p Policy) Concurrency(requested, fallback int) int {
+	if requested <= 0 {
+		requested = fallback
+	}
+	return max(min(requested, p.MaxConcurrency), 1)
+}
+
+// Batch returns the requested batch size, or BatchSize when none was requested, at most
+// MaxBulkBatchSize
+func (p Policy) Batch(requested int) int {
+	if requested <= 0 {
+		return p.BatchSize
+	}
+	return min(requested, MaxBulkBatchSize)
+}
+
+// Stagger returns how long worker should wait before its first request, so workers
+// started together spread out
+func (p Policy) Stagger(worker int) time.Duration {
+	return time.Duration(worker) * p.WorkerDelay
+}
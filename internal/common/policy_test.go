@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyWithDefaults(t *testing.T) {
+	p := Policy{Retries: -1, BatchSize: 50000, Backoff: time.Minute}.withDefaults()
+
+	if p.Retries != 0 {
+		t.Errorf("Retries = %d, want 0", p.Retries)
+	}
+	if p.BatchSize != MaxBulkBatchSize {
+		t.Errorf("BatchSize = %d, want %d", p.BatchSize, MaxBulkBatchSize)
+	}
+	if p.MaxBackoff != time.Minute {
+		t.Errorf("MaxBackoff = %s, want it raised to the backoff", p.MaxBackoff)
+	}
+	if p.Timeout != DefaultTimeout || p.RateLimit != DefaultRateLimit || p.MaxConcurrency != DefaultMaxConcurrency {
+		t.Errorf("unset fields not defaulted: %+v", p)
+	}
+}
+
+func TestPolicyRetryDelay(t *testing.T) {
+	p := Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.RetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyConcurrencyAndBatch(t *testing.T) {
+	p := DefaultPolicy()
+	p.MaxConcurrency = 8
+
+	if got := p.Concurrency(0, 20); got != 8 {
+		t.Errorf("Concurrency(0, 20) = %d, want 8", got)
+	}
+	if got := p.Concurrency(3, 20); got != 3 {
+		t.Errorf("Concurrency(3, 20) = %d, want 3", got)
+	}
+	if got := p.Batch(0); got != DefaultBatchSize {
+		t.Errorf("Batch(0) = %d, want %d", got, DefaultBatchSize)
+	}
+	if got := p.Batch(20000); got != MaxBulkBatchSize {
+		t.Errorf("Batch(20000) = %d, want %d", got, MaxBulkBatchSize)
+	}
+}
+
+func TestPolicyRetryConfig(t *testing.T) {
+	config := Policy{Retries: 2, Backoff: time.Second, MaxBackoff: time.Minute}.RetryConfig()
+
+	if config.MaxAttempts != 3 {
+		t.Errorf("MaxAttempts = %d, want 3", config.MaxAttempts)
+	}
+	if config.InitialDelay != time.Second || config.MaxDelay != time.Minute {
+		t.Errorf("delays = %s/%s, want 1s/1m", config.InitialDelay, config.MaxDelay)
+	}
+}
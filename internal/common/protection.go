@@ -0,0 +1,56 @@
+package common
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// OverrideProtectionFlag is the flag that lets destructive commands touch protected resources
+const OverrideProtectionFlag = "override-protection"
+
+// ProtectionOverridden returns true if --override-protection was passed
+func ProtectionOverridden(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	override, _ := cmd.Flags().GetBool(OverrideProtectionFlag)
+	return override
+}
+
+// CheckNamespaceProtection returns an error if the namespace is protected in config,
+// unless protection is overridden
+func CheckNamespaceProtection(cmd *cobra.Command, cfg *config.Config, namespaceID, title string) error {
+	if cfg == nil || !cfg.IsNamespaceProtected(namespaceID, title) {
+		return nil
+	}
+	return protectionError(cmd, "namespace", describeResource(namespaceID, title))
+}
+
+// CheckZoneProtection returns an error if the zone is protected in config,
+// unless protection is overridden
+func CheckZoneProtection(cmd *cobra.Command, cfg *config.Config, zoneID, zoneName string) error {
+	if cfg == nil || !cfg.IsZoneProtected(zoneID, zoneName) {
+		return nil
+	}
+	return protectionError(cmd, "zone", describeResource(zoneID, zoneName))
+}
+
+// protectionError refuses the operation, or warns if protection is overridden
+func protectionError(cmd *cobra.Command, kind, resource string) error {
+	if ProtectionOverridden(cmd) {
+		fmt.Printf("Warning: overriding protection for %s %s\n", kind, resource)
+		return nil
+	}
+	return fmt.Errorf("%s %s is protected by config; pass --%s to modify it", kind, resource, OverrideProtectionFlag)
+}
+
+// describeResource formats an ID with its human-readable name when known
+func describeResource(id, name string) string {
+	if name == "" || name == id {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", name, id)
+}
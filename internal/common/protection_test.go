@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+
+	"cache-kv-purger/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCheckProtection(t *testing.T) {
+	cfg := config.New()
+	cfg.ProtectedNamespaces = []string{"prod-ns-id", "Production Sessions"}
+	cfg.ProtectedZones = []string{"example.com", "zone-id-prod"}
+
+	newCmd := func(override bool) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().Bool(OverrideProtectionFlag, false, "")
+		if override {
+			_ = cmd.Flags().Set(OverrideProtectionFlag, "true")
+		}
+		return cmd
+	}
+
+	tests := []struct {
+		name      string
+		check     func(cmd *cobra.Command) error
+		protected bool
+	}{
+		{"namespace by ID", func(cmd *cobra.Command) error {
+			return CheckNamespaceProtection(cmd, cfg, "prod-ns-id", "")
+		}, true},
+		{"namespace by title", func(cmd *cobra.Command) error {
+			return CheckNamespaceProtection(cmd, cfg, "other-id", "Production Sessions")
+		}, true},
+		{"unprotected namespace", func(cmd *cobra.Command) error {
+			return CheckNamespaceProtection(cmd, cfg, "other-id", "Staging")
+		}, false},
+		{"zone by ID", func(cmd *cobra.Command) error {
+			return CheckZoneProtection(cmd, cfg, "zone-id-prod", "")
+		}, true},
+		{"zone by name ignoring case", func(cmd *cobra.Command) error {
+			return CheckZoneProtection(cmd, cfg, "some-id", "Example.COM")
+		}, true},
+		{"unprotected zone", func(cmd *cobra.Command) error {
+			return CheckZoneProtection(cmd, cfg, "some-id", "example.org")
+		}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.check(newCmd(false))
+			if tc.protected && err == nil {
+				t.Errorf("expected protection error, got nil")
+			}
+			if !tc.protected && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			// Overriding protection always allows the operation
+			if err := tc.check(newCmd(true)); err != nil {
+				t.Errorf("expected override to allow operation, got %v", err)
+			}
+		})
+	}
+
+	// A nil config protects nothing
+	if err := CheckZoneProtection(newCmd(false), nil, "zone-id-prod", ""); err != nil {
+		t.Errorf("expected nil config to protect nothing, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PurgeURLEntry is a file URL to purge and where it was given
+type PurgeURLEntry struct {
+	URL    string
+	Source string // Flag or file the URL came from, e.g. "--file" or "urls.txt"
+	Line   int    // Line in Source, 0 for flags
+}
+
+// Location describes where the entry was given, e.g. "urls.txt:12" or "--file"
+func (e PurgeURLEntry) Location() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.Source, e.Line)
+	}
+	return e.Source
+}
+
+// PurgeURLIssue is an entry left out of a purge because it is not a valid URL
+type PurgeURLIssue struct {
+	Entry   PurgeURLEntry
+	Problem string
+}
+
+// String formats the issue for output, e.g. "urls.txt:12: example.com/a: missing http:// or https://"
+func (i PurgeURLIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Entry.Location(), i.Entry.URL, i.Problem)
+}
+
+// PreparedPurgeURLs is the result of PreparePurgeURLs
+type PreparedPurgeURLs struct {
+	URLs       []string        // Normalized, unique URLs in the order given
+	Invalid    []PurgeURLIssue // Entries that are not valid URLs, in the order given
+	Duplicates int             // Entries dropped because they normalize to an earlier URL
+}
+
+// NormalizePurgeURL checks that a file URL has an http or https scheme and a host, and
+// normalizes it the way the cache does: the scheme and host are lowercased and any
+// fragment is removed
+func NormalizePurgeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("empty URL")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "":
+		return "", fmt.Errorf("missing http:// or https://")
+	default:
+		return "", fmt.Errorf("unsupported scheme %q, only http and https URLs can be purged", u.Scheme)
+	}
+	if u.Host == "" || u.Hostname() == "" {
+		return "", fmt.Errorf("missing host")
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+// PreparePurgeURLs validates, normalizes and dedupes file URLs before they are sent in
+// purge batches, so one bad entry doesn't make the API reject a whole batch
+func PreparePurgeURLs(entries []PurgeURLEntry) PreparedPurgeURLs {
+	result := PreparedPurgeURLs{URLs: make([]string, 0, len(entries))}
+	seen := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		normalized, err := NormalizePurgeURL(entry.URL)
+		if err != nil {
+			result.Invalid = append(result.Invalid, PurgeURLIssue{Entry: entry, Problem: err.Error()})
+			continue
+		}
+		if seen[normalized] {
+			result.Duplicates++
+			continue
+		}
+		seen[normalized] = true
+		result.URLs = append(result.URLs, normalized)
+	}
+
+	return result
+}
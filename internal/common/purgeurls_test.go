@@ -0,0 +1,54 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePurgeURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "https://example.com/a.css", want: "https://example.com/a.css"},
+		{raw: "  HTTPS://Example.COM/Path/A.css?v=1#top ", want: "https://example.com/Path/A.css?v=1"},
+		{raw: "http://example.com:8080/a", want: "http://example.com:8080/a"},
+		{raw: "example.com/a.css", wantErr: true},
+		{raw: "ftp://example.com/a", wantErr: true},
+		{raw: "https:///a.css", wantErr: true},
+		{raw: "https://exa mple.com/%zz", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePurgeURL(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizePurgeURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizePurgeURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestPreparePurgeURLs(t *testing.T) {
+	entries := []PurgeURLEntry{
+		{URL: "https://example.com/a", Source: "--file"},
+		{URL: "https://EXAMPLE.com/a#section", Source: "urls.txt", Line: 1},
+		{URL: "example.com/b", Source: "urls.txt", Line: 3},
+		{URL: "https://example.com/c", Source: "urls.txt", Line: 4},
+	}
+
+	got := PreparePurgeURLs(entries)
+	if want := []string{"https://example.com/a", "https://example.com/c"}; !reflect.DeepEqual(got.URLs, want) {
+		t.Errorf("URLs = %v, want %v", got.URLs, want)
+	}
+	if got.Duplicates != 1 {
+		t.Errorf("Duplicates = %d, want 1", got.Duplicates)
+	}
+	if len(got.Invalid) != 1 || got.Invalid[0].String() != "urls.txt:3: example.com/b: missing http:// or https://" {
+		t.Errorf("Invalid = %v", got.Invalid)
+	}
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// quietMode is set by EnableQuietMode
+	quietMode bool
+	// assumeYes is set by SetAssumeYes
+	assumeYes bool
+	// dataOutput is the real stdout while quiet mode discards everything else
+	dataOutput io.Writer
+)
+
+// EnableQuietMode switches the process to machine mode for scripts and provisioning
+// tools: all output written to os.Stdout is discarded, confirmation prompts are
+// answered yes, and only data written through OutputJSON reaches the real stdout.
+// Errors and warnings on stderr are unaffected.
+func EnableQuietMode() error {
+	if quietMode {
+		return nil
+	}
+
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to enable quiet mode: %w", err)
+	}
+
+	dataOutput = os.Stdout
+	os.Stdout = null
+	quietMode = true
+	return nil
+}
+
+// IsQuietMode returns true if quiet mode is enabled
+func IsQuietMode() bool {
+	return quietMode
+}
+
+// DataOutput returns the writer for command results: the real stdout, even in quiet mode
+func DataOutput() io.Writer {
+	if dataOutput != nil {
+		return dataOutput
+	}
+	// Results are data, not steps of a transcript
+	if transcript.stdout != nil && transcript.rec != nil {
+		return transcript.stdout
+	}
+	return os.Stdout
+}
+
+// SetAssumeYes makes every confirmation prompt answer yes, as --force does for a single
+// command. It is used when no one can answer prompts, such as in per-account runs.
+func SetAssumeYes(yes bool) {
+	assumeYes = yes
+}
+
+// AssumeYes returns true if confirmation prompts are answered yes, which quiet mode implies
+func AssumeYes() bool {
+	return assumeYes || quietMode
+}
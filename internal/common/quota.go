@@ -0,0 +1,191 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quotaLowWaterRatio is the share of the quota below which requests are paced
+const quotaLowWaterRatio = 0.1
+
+// quotaLowWaterMin is the minimum remaining count below which requests are paced
+const quotaLowWaterMin = 5
+
+// unixTimestampThreshold separates reset values given as Unix times from ones given in seconds
+const unixTimestampThreshold = 1e9
+
+// RateLimitStatus is the API quota reported in response headers
+type RateLimitStatus struct {
+	Limit      int           // Requests allowed per window (0 if not reported)
+	Remaining  int           // Requests left in the current window
+	Reset      time.Duration // Time until the window resets, as of ObservedAt
+	ObservedAt time.Time
+}
+
+// String formats the status for verbose output
+func (s RateLimitStatus) String() string {
+	quota := fmt.Sprintf("%d", s.Remaining)
+	if s.Limit > 0 {
+		quota = fmt.Sprintf("%d of %d", s.Remaining, s.Limit)
+	}
+	return fmt.Sprintf("%s requests remaining, resets in %s", quota, s.resetIn(time.Now()).Round(time.Second))
+}
+
+// resetIn returns the time left until the window resets
+func (s RateLimitStatus) resetIn(now time.Time) time.Duration {
+	left := s.Reset - now.Sub(s.ObservedAt)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// low returns true when the remaining quota is small enough to pace requests
+func (s RateLimitStatus) low() bool {
+	lowWater := quotaLowWaterMin
+	if threshold := int(quotaLowWaterRatio * float64(s.Limit)); threshold > lowWater {
+		lowWater = threshold
+	}
+	return s.Remaining <= lowWater
+}
+
+// Delay returns how long to wait before the next request so the remaining quota is
+// spread over the rest of the window. It is zero while plenty of quota remains.
+func (s RateLimitStatus) Delay(now time.Time) time.Duration {
+	if !s.low() {
+		return 0
+	}
+	left := s.resetIn(now)
+	if s.Remaining <= 0 {
+		return left
+	}
+	return left / time.Duration(s.Remaining+1)
+}
+
+// ParseRateLimitHeaders reads the quota from API response headers. It understands
+// Cloudflare's structured Ratelimit and Ratelimit-Policy headers as well as the
+// RateLimit-* and X-RateLimit-* Limit, Remaining and Reset headers.
+func ParseRateLimitHeaders(h http.Header, now time.Time) (RateLimitStatus, bool) {
+	status := RateLimitStatus{ObservedAt: now}
+
+	// Structured form: Ratelimit: "default";r=50;t=30 and Ratelimit-Policy: "default";q=1200;w=300
+	if value := h.Get("Ratelimit"); strings.Contains(value, "r=") {
+		remaining, ok := structuredParam(value, "r")
+		if !ok {
+			return status, false
+		}
+		status.Remaining = remaining
+		if reset, ok := structuredParam(value, "t"); ok {
+			status.Reset = time.Duration(reset) * time.Second
+		}
+		if limit, ok := structuredParam(h.Get("Ratelimit-Policy"), "q"); ok {
+			status.Limit = limit
+		}
+		return status, true
+	}
+
+	for _, prefix := range []string{"Ratelimit-", "X-Ratelimit-"} {
+		remaining, ok := leadingInt(h.Get(prefix + "Remaining"))
+		if !ok {
+			continue
+		}
+		status.Remaining = remaining
+		if limit, ok := leadingInt(h.Get(prefix + "Limit")); ok {
+			status.Limit = limit
+		}
+		if reset, ok := leadingInt(h.Get(prefix + "Reset")); ok {
+			if reset > unixTimestampThreshold {
+				status.Reset = time.Unix(int64(reset), 0).Sub(now)
+			} else {
+				status.Reset = time.Duration(reset) * time.Second
+			}
+		}
+		return status, true
+	}
+
+	return status, false
+}
+
+// structuredParam returns an integer parameter such as r=50 from a structured header value
+func structuredParam(value, name string) (int, bool) {
+	for _, part := range strings.Split(value, ";") {
+		key, val, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found && strings.EqualFold(key, name) {
+			n, err := strconv.Atoi(strings.TrimSpace(val))
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+// leadingInt parses the integer at the start of a header value such as "100, 100;w=60"
+func leadingInt(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value[:end])
+	return n, err == nil
+}
+
+// quotaTracker keeps the most recent quota reported by the API
+type quotaTracker struct {
+	mu     sync.Mutex
+	status RateLimitStatus
+	known  bool
+}
+
+// globalQuota is updated from every API response, so all workers pace together
+var globalQuota = &quotaTracker{}
+
+// RecordRateLimitHeaders updates the tracked API quota from response headers
+func RecordRateLimitHeaders(h http.Header) {
+	status, ok := ParseRateLimitHeaders(h, time.Now())
+	if !ok {
+		return
+	}
+
+	globalQuota.mu.Lock()
+	globalQuota.status = status
+	globalQuota.known = true
+	globalQuota.mu.Unlock()
+}
+
+// CurrentRateLimitStatus returns the last quota reported by the API, and false if none was seen
+func CurrentRateLimitStatus() (RateLimitStatus, bool) {
+	globalQuota.mu.Lock()
+	defer globalQuota.mu.Unlock()
+	return globalQuota.status, globalQuota.known
+}
+
+// PaceForQuota waits when the API reported little remaining quota, spreading the
+// remaining requests over the rest of the window instead of running into 429s
+func PaceForQuota(ctx context.Context) error {
+	status, ok := CurrentRateLimitStatus()
+	if !ok {
+		return nil
+	}
+
+	delay := status.Delay(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
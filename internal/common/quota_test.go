@@ -0,0 +1,76 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		remaining int
+		limit     int
+		reset     time.Duration
+	}{
+		{
+			name:      "structured Cloudflare headers",
+			headers:   map[string]string{"Ratelimit": `"default";r=50;t=30`, "Ratelimit-Policy": `"default";q=1200;w=300`},
+			remaining: 50, limit: 1200, reset: 30 * time.Second,
+		},
+		{
+			name:      "RateLimit headers",
+			headers:   map[string]string{"RateLimit-Limit": "100, 100;w=60", "RateLimit-Remaining": "7", "RateLimit-Reset": "12"},
+			remaining: 7, limit: 100, reset: 12 * time.Second,
+		},
+		{
+			name:      "X-RateLimit headers with Unix reset",
+			headers:   map[string]string{"X-RateLimit-Limit": "1200", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000060"},
+			remaining: 0, limit: 1200, reset: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			status, ok := ParseRateLimitHeaders(h, now)
+			if !ok {
+				t.Fatal("expected headers to be parsed")
+			}
+			if status.Remaining != tt.remaining || status.Limit != tt.limit || status.Reset != tt.reset {
+				t.Errorf("got remaining=%d limit=%d reset=%s, want remaining=%d limit=%d reset=%s",
+					status.Remaining, status.Limit, status.Reset, tt.remaining, tt.limit, tt.reset)
+			}
+		})
+	}
+
+	if _, ok := ParseRateLimitHeaders(http.Header{}, now); ok {
+		t.Error("expected no status without rate limit headers")
+	}
+}
+
+func TestRateLimitStatusDelay(t *testing.T) {
+	now := time.Now()
+
+	plenty := RateLimitStatus{Limit: 1200, Remaining: 600, Reset: time.Minute, ObservedAt: now}
+	if d := plenty.Delay(now); d != 0 {
+		t.Errorf("delay with plenty of quota = %s, want 0", d)
+	}
+
+	low := RateLimitStatus{Limit: 1200, Remaining: 59, Reset: time.Minute, ObservedAt: now}
+	if d := low.Delay(now); d != time.Second {
+		t.Errorf("delay with low quota = %s, want 1s", d)
+	}
+
+	exhausted := RateLimitStatus{Limit: 1200, Remaining: 0, Reset: time.Minute, ObservedAt: now}
+	if d := exhausted.Delay(now.Add(20 * time.Second)); d != 40*time.Second {
+		t.Errorf("delay with no quota = %s, want 40s", d)
+	}
+}
@@ -0,0 +1,146 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+)
+
+// RedactedText replaces secrets in log output
+const RedactedText = "[REDACTED]"
+
+// secretPattern matches a secret in log output. With keep set, the first submatch (such
+// as the name in "api_key=...") is kept and the rest of the match is redacted.
+type secretPattern struct {
+	re   *regexp.Regexp
+	keep bool
+}
+
+// defaultSecretPatterns match JWT-like tokens, bearer credentials, well-known API key
+// formats and values assigned to names that suggest a secret
+var defaultSecretPatterns = []secretPattern{
+	{re: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)},
+	{re: regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`), keep: true},
+	{re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{re: regexp.MustCompile(`\b(?:sk|rk|pk)_(?:live|test)_[0-9A-Za-z]{16,}\b`)},
+	{re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}\b`)},
+	{re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{re: regexp.MustCompile(`(?i)((?:api[_-]?key|[a-z_-]*token|[a-z_-]*secret|password|passwd)["']?\s*[:=]\s*["']?)[^\s"',;&]{4,}`), keep: true},
+}
+
+var (
+	redactMu       sync.RWMutex
+	redactEnabled  = true
+	redactPatterns []secretPattern
+)
+
+// SetRedaction turns the redaction of secrets in log output on or off. It is on by default.
+func SetRedaction(enabled bool) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactEnabled = enabled
+}
+
+// AddRedactPatterns adds regular expressions whose matches are redacted in log output,
+// on top of the built-in patterns
+func AddRedactPatterns(patterns []string) error {
+	compiled := make([]secretPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern '%s': %w", pattern, err)
+		}
+		compiled = append(compiled, secretPattern{re: re})
+	}
+
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactPatterns = append(redactPatterns, compiled...)
+	return nil
+}
+
+// ResetRedaction turns redaction back on with only the built-in patterns
+func ResetRedaction() {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactEnabled = true
+	redactPatterns = nil
+}
+
+// Redact returns s with the secrets matched by the built-in and added patterns replaced
+// by RedactedText, or s unchanged when redaction is off
+func Redact(s string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	if !redactEnabled || s == "" {
+		return s
+	}
+
+	for _, patterns := range [][]secretPattern{defaultSecretPatterns, redactPatterns} {
+		for _, pattern := range patterns {
+			if pattern.keep {
+				s = pattern.re.ReplaceAllString(s, "${1}"+RedactedText)
+			} else {
+				s = pattern.re.ReplaceAllLiteralString(s, RedactedText)
+			}
+		}
+	}
+	return s
+}
+
+// redactHandler redacts the message and attribute values of log records before passing
+// them to the wrapped handler
+type redactHandler struct {
+	handler slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+	return &redactHandler{handler: h.handler.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{handler: h.handler.WithGroup(name)}
+}
+
+// redactAttr redacts the string form of an attribute value, keeping numbers, durations
+// and other values that can't hold a secret as they are
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+		return slog.String(attr.Key, Redact(fmt.Sprint(value.Any())))
+	default:
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// PrettyJSON indents a JSON document two spaces per level, coloring keys, strings, numbers
+// and literals when colors are enabled. It returns false if data is not JSON.
+func PrettyJSON(data []byte) (string, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || !json.Valid(trimmed) {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, trimmed, "", "  "); err != nil {
+		return "", false
+	}
+	if !ColorEnabled() {
+		return buf.String(), true
+	}
+	return colorJSON(buf.String()), true
+}
+
+// colorJSON colors the tokens of a valid JSON document: keys cyan, strings green, numbers
+// and booleans yellow, null dim. Punctuation and whitespace are left as they are.
+func colorJSON(doc string) string {
+	var out strings.Builder
+	out.Grow(len(doc) * 2)
+
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(doc) && doc[end] != '"' {
+				if doc[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(doc))
+			token := doc[i:end]
+
+			// A string followed by a colon is an object key
+			next := end
+			for next < len(doc) && (doc[next] == ' ' || doc[next] == '\n') {
+				next++
+			}
+			if next < len(doc) && doc[next] == ':' {
+				out.WriteString(Cyan(token))
+			} else {
+				out.WriteString(Green(token))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(doc) && strings.IndexByte("+-.eE0123456789", doc[end]) >= 0 {
+				end++
+			}
+			out.WriteString(Yellow(doc[i:end]))
+			i = end
+		case strings.HasPrefix(doc[i:], "true"):
+			out.WriteString(Yellow("true"))
+			i += 4
+		case strings.HasPrefix(doc[i:], "false"):
+			out.WriteString(Yellow("false"))
+			i += 5
+		case strings.HasPrefix(doc[i:], "null"):
+			out.WriteString(Dim("null"))
+			i += 4
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
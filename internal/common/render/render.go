@@ -0,0 +1,275 @@
+// Package render draws tables and key-value lists for terminal output. Headers and statuses
+// are colorized when stdout is a terminal and NO_COLOR is not set, and long cells are
+// truncated unless wide output is enabled.
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultMaxColumnWidth is the widest a column is drawn without wide output
+const DefaultMaxColumnWidth = 60
+
+// columnGap is the space between columns
+const columnGap = 3
+
+// ANSI escape codes
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	dim    = "\x1b[2m"
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	cyan   = "\x1b[36m"
+)
+
+// ansiPattern matches the escape codes added by the color functions
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+var (
+	mu        sync.Mutex
+	wide      bool
+	colorSet  bool // Whether SetColor overrode detection
+	colorOn   bool
+	detectOne sync.Once
+	detected  bool
+)
+
+// SetWide sets whether tables show cells in full instead of truncating long ones
+func SetWide(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	wide = enabled
+}
+
+// Wide reports whether wide output is enabled
+func Wide() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return wide
+}
+
+// SetColor forces colors on or off, overriding terminal and NO_COLOR detection
+func SetColor(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	colorSet, colorOn = true, enabled
+}
+
+// ColorEnabled reports whether output is colorized: stdout must be a terminal, NO_COLOR
+// (https://no-color.org) unset and TERM not "dumb"
+func ColorEnabled() bool {
+	mu.Lock()
+	set, on := colorSet, colorOn
+	mu.Unlock()
+	if set {
+		return on
+	}
+
+	detectOne.Do(func() {
+		if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+			return
+		}
+		info, err := os.Stdout.Stat()
+		detected = err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Stdout.Name() != os.DevNull
+	})
+	return detected
+}
+
+// paint wraps s in an escape code when colors are enabled
+func paint(code, s string) string {
+	if s == "" || !ColorEnabled() {
+		return s
+	}
+	return code + s + reset
+}
+
+// Bold renders s in bold
+func Bold(s string) string { return paint(bold, s) }
+
+// Dim renders s faint
+func Dim(s string) string { return paint(dim, s) }
+
+// Red renders s in red
+func Red(s string) string { return paint(red, s) }
+
+// Green renders s in green
+func Green(s string) string { return paint(green, s) }
+
+// Yellow renders s in yellow
+func Yellow(s string) string { return paint(yellow, s) }
+
+// Cyan renders s in cyan
+func Cyan(s string) string { return paint(cyan, s) }
+
+// Status colors a status word: green for success, red for failure, yellow for dry runs,
+// skips and warnings. Other text is returned unchanged.
+func Status(s string) string {
+	switch lower := strings.ToLower(s); {
+	case lower == "ok" || strings.HasPrefix(lower, "success") || lower == "succeeded" || lower == "active":
+		return Green(s)
+	case strings.HasPrefix(lower, "fail") || strings.HasPrefix(lower, "error"):
+		return Red(s)
+	case strings.HasPrefix(lower, "dry run") || strings.HasPrefix(lower, "skip") || strings.HasPrefix(lower, "warn"):
+		return Yellow(s)
+	default:
+		return s
+	}
+}
+
+// Width returns the number of characters s takes on screen, ignoring color codes
+func Width(s string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, ""))
+}
+
+// Truncate shortens s to at most max characters, ending it with "..." when cut. Colored
+// text is returned unchanged, since cutting it could leave an escape code open.
+func Truncate(s string, max int) string {
+	if max <= 0 || Width(s) <= max || ansiPattern.MatchString(s) {
+		return s
+	}
+	if max <= 3 {
+		return string([]rune(s)[:max])
+	}
+	return string([]rune(s)[:max-3]) + "..."
+}
+
+// pad right-pads s with spaces to width characters
+func pad(s string, width int) string {
+	if n := width - Width(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// Table is a table with a header row
+type Table struct {
+	Headers  []string
+	Rows     [][]string
+	MaxWidth int // Widest a column is drawn without wide output; 0 uses DefaultMaxColumnWidth
+}
+
+// NewTable creates a table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row. Missing cells are drawn empty.
+func (t *Table) AddRow(cells ...string) *Table {
+	t.Rows = append(t.Rows, cells)
+	return t
+}
+
+// Render writes the table to w. It returns whether any cell was truncated.
+func (t *Table) Render(w io.Writer) bool {
+	maxWidth := t.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = DefaultMaxColumnWidth
+	}
+	if Wide() {
+		maxWidth = 0
+	}
+
+	// Truncate cells and measure columns
+	truncated := false
+	rows := make([][]string, len(t.Rows))
+	widths := make([]int, len(t.Headers))
+	for i, h := range t.Headers {
+		widths[i] = Width(h)
+	}
+	for i, row := range t.Rows {
+		rows[i] = make([]string, len(t.Headers))
+		for j := range t.Headers {
+			if j >= len(row) {
+				continue
+			}
+			cell := strings.ReplaceAll(row[j], "\n", " ")
+			if short := Truncate(cell, maxWidth); short != cell {
+				cell, truncated = short, true
+			}
+			rows[i][j] = cell
+			widths[j] = max(widths[j], Width(cell))
+		}
+	}
+
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	total += columnGap * max(len(widths)-1, 0)
+
+	writeRow := func(cells []string, style func(string) string) {
+		var line strings.Builder
+		for j, cell := range cells {
+			if j > 0 {
+				line.WriteString(strings.Repeat(" ", columnGap))
+			}
+			if j < len(cells)-1 {
+				cell = pad(cell, widths[j])
+			}
+			line.WriteString(style(cell))
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+
+	writeRow(t.Headers, Bold)
+	fmt.Fprintln(w, Dim(strings.Repeat("-", total)))
+	for _, row := range rows {
+		writeRow(row, func(s string) string { return s })
+	}
+	return truncated
+}
+
+// Print writes the table to stdout, with a hint about --wide when cells were truncated
+func (t *Table) Print() {
+	if t.Render(os.Stdout) {
+		fmt.Println(Dim("(long values truncated, use --wide to show them in full)"))
+	}
+}
+
+// Pair is a labelled value in a key-value list
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// SortedPairs returns the entries of a map as pairs in key order
+func SortedPairs(data map[string]string) []Pair {
+	pairs := make([]Pair, 0, len(data))
+	for k, v := range data {
+		pairs = append(pairs, Pair{Key: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	return pairs
+}
+
+// RenderKeyValues writes pairs as an aligned two-column list between separator lines.
+// Lines after the first of a multi-line value are indented to the value column.
+func RenderKeyValues(w io.Writer, pairs []Pair) {
+	keyWidth := 0
+	for _, p := range pairs {
+		keyWidth = max(keyWidth, Width(p.Key))
+	}
+	separator := Dim(strings.Repeat("-", keyWidth+20))
+	indent := strings.Repeat(" ", keyWidth+columnGap)
+
+	fmt.Fprintln(w, separator)
+	for _, p := range pairs {
+		value := strings.ReplaceAll(p.Value, "\n", "\n"+indent)
+		fmt.Fprintln(w, strings.TrimRight(Cyan(pad(p.Key, keyWidth))+strings.Repeat(" ", columnGap)+Status(value), " "))
+	}
+	fmt.Fprintln(w, separator)
+}
+
+// PrintKeyValues writes pairs to stdout as an aligned two-column list
+func PrintKeyValues(pairs []Pair) {
+	RenderKeyValues(os.Stdout, pairs)
+}
@@ -0,0 +1,166 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a long cell value", 10, "a long ..."},
+		{"héllo wörld", 8, "héllo..."},
+		{"anything", 0, "anything"},
+		{"abcdef", 2, "ab"},
+	}
+	for _, tc := range tests {
+		if got := Truncate(tc.in, tc.max); got != tc.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestWidthIgnoresColors(t *testing.T) {
+	SetColor(true)
+	defer SetColor(false)
+
+	colored := Green("OK")
+	if colored == "OK" {
+		t.Fatal("expected color codes when colors are forced on")
+	}
+	if got := Width(colored); got != 2 {
+		t.Errorf("Width(%q) = %d, want 2", colored, got)
+	}
+}
+
+func TestTableAlignsColumns(t *testing.T) {
+	SetColor(false)
+
+	var buf bytes.Buffer
+	NewTable("ID", "Name").AddRow("1", "first").AddRow("22", "second").Render(&buf)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	want := []string{
+		"ID   Name",
+		"-----------",
+		"1    first",
+		"22   second",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestTableTruncatesUnlessWide(t *testing.T) {
+	SetColor(false)
+	long := strings.Repeat("k", 30)
+
+	table := &Table{Headers: []string{"Key"}, Rows: [][]string{{long}}, MaxWidth: 10}
+
+	var buf bytes.Buffer
+	if !table.Render(&buf) {
+		t.Error("expected Render to report truncation")
+	}
+	if strings.Contains(buf.String(), long) || !strings.Contains(buf.String(), "kkkkkkk...") {
+		t.Errorf("expected truncated cell, got:\n%s", buf.String())
+	}
+
+	SetWide(true)
+	defer SetWide(false)
+	buf.Reset()
+	if table.Render(&buf) {
+		t.Error("expected no truncation with wide output")
+	}
+	if !strings.Contains(buf.String(), long) {
+		t.Errorf("expected full cell with wide output, got:\n%s", buf.String())
+	}
+}
+
+func TestStatus(t *testing.T) {
+	SetColor(true)
+	defer SetColor(false)
+
+	tests := map[string]string{
+		"OK":                "\x1b[32m",
+		"Successfully done": "\x1b[32m",
+		"FAILED":            "\x1b[31m",
+		"DRY RUN Completed": "\x1b[33m",
+	}
+	for status, code := range tests {
+		if got := Status(status); !strings.HasPrefix(got, code) {
+			t.Errorf("Status(%q) = %q, want prefix %q", status, got, code)
+		}
+	}
+	if got := Status("42"); got != "42" {
+		t.Errorf("Status(%q) = %q, want it unchanged", "42", got)
+	}
+}
+
+func TestRenderKeyValuesIndentsMultilineValues(t *testing.T) {
+	SetColor(false)
+
+	var buf bytes.Buffer
+	RenderKeyValues(&buf, []Pair{{Key: "Key", Value: "k"}, {Key: "Value", Value: "line1\nline2"}})
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[1] != "Key     k" || lines[2] != "Value   line1" || lines[3] != "        line2" {
+		t.Errorf("unexpected key-value output:\n%s", buf.String())
+	}
+}
+
+func TestSortedPairs(t *testing.T) {
+	pairs := SortedPairs(map[string]string{"b": "2", "a": "1", "c": "3"})
+	for i, key := range []string{"a", "b", "c"} {
+		if pairs[i].Key != key {
+			t.Errorf("pairs[%d].Key = %q, want %q", i, pairs[i].Key, key)
+		}
+	}
+}
+
+func TestPrettyJSON(t *testing.T) {
+	SetColor(false)
+
+	got, ok := PrettyJSON([]byte(` {"a":[1,true],"b":{"c":null}} `))
+	if !ok {
+		t.Fatal("expected valid JSON to be pretty-printed")
+	}
+	want := "{\n  \"a\": [\n    1,\n    true\n  ],\n  \"b\": {\n    \"c\": null\n  }\n}"
+	if got != want {
+		t.Errorf("PrettyJSON = %q, want %q", got, want)
+	}
+
+	for _, in := range []string{"", "plain text", "{\"a\":", "\x1f\x8b\x08"} {
+		if _, ok := PrettyJSON([]byte(in)); ok {
+			t.Errorf("PrettyJSON(%q) reported JSON", in)
+		}
+	}
+}
+
+func TestPrettyJSONColors(t *testing.T) {
+	SetColor(true)
+	defer SetColor(false)
+
+	got, ok := PrettyJSON([]byte(`{"key":"va\"l:ue","n":-1.5e3,"t":false,"z":null}`))
+	if !ok {
+		t.Fatal("expected valid JSON to be pretty-printed")
+	}
+	for _, want := range []string{Cyan(`"key"`), Green(`"va\"l:ue"`), Yellow("-1.5e3"), Yellow("false"), Dim("null")} {
+		if !strings.Contains(got, want) {
+			t.Errorf("PrettyJSON output %q missing %q", got, want)
+		}
+	}
+	if plain := ansiPattern.ReplaceAllString(got, ""); !strings.Contains(plain, `"key": "va\"l:ue"`) {
+		t.Errorf("colors changed the document: %q", plain)
+	}
+}
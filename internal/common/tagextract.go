@@ -0,0 +1,187 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/config"
+)
+
+// tagTemplatePlaceholder is replaced by the extracted value in a rule template
+const tagTemplatePlaceholder = "{value}"
+
+// DefaultTagExtractRules are used when neither the config nor flags define rules.
+// They cover the metadata field names the tool has always recognised.
+var DefaultTagExtractRules = []config.TagExtractRule{
+	{Field: "cache-tag"},
+	{Field: "cache-tags", Delimiter: ","},
+	{Field: "cacheTags", Delimiter: ","},
+	{Field: "tag", Delimiter: ","},
+	{Field: "tags", Delimiter: ","},
+}
+
+// namedDelimiters lets delimiters that clash with the rule syntax be given by name
+var namedDelimiters = map[string]string{
+	"comma":     ",",
+	"semicolon": ";",
+	"space":     " ",
+	"pipe":      "|",
+}
+
+// ParseTagExtractRule parses a rule such as "field=cache-tags,delimiter=comma" or
+// "path=.cdn.tags,template=product-{value}". A value without "=" is a field name.
+func ParseTagExtractRule(spec string) (config.TagExtractRule, error) {
+	var rule config.TagExtractRule
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return rule, fmt.Errorf("empty tag extract rule")
+	}
+	if !strings.Contains(spec, "=") {
+		rule.Field = spec
+		return rule, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		name, value, found := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !found || name == "" {
+			return rule, fmt.Errorf("invalid tag extract rule '%s': expected name=value pairs", spec)
+		}
+
+		switch name {
+		case "field":
+			rule.Field = strings.TrimSpace(value)
+		case "path":
+			rule.Path = strings.TrimSpace(value)
+		case "delimiter":
+			if named, ok := namedDelimiters[strings.ToLower(strings.TrimSpace(value))]; ok {
+				value = named
+			}
+			rule.Delimiter = value
+		case "template":
+			rule.Template = strings.TrimSpace(value)
+		default:
+			return rule, fmt.Errorf("invalid tag extract rule '%s': unknown setting '%s'", spec, name)
+		}
+	}
+
+	if err := ValidateTagExtractRule(rule); err != nil {
+		return rule, fmt.Errorf("invalid tag extract rule '%s': %w", spec, err)
+	}
+	return rule, nil
+}
+
+// ValidateTagExtractRule checks that a rule names exactly one source
+func ValidateTagExtractRule(rule config.TagExtractRule) error {
+	if rule.Field == "" && rule.Path == "" {
+		return fmt.Errorf("a field or path is required")
+	}
+	if rule.Field != "" && rule.Path != "" {
+		return fmt.Errorf("field and path cannot be used together")
+	}
+	if rule.Path != "" {
+		if _, err := ParseJSONPath(rule.Path); err != nil {
+			return err
+		}
+	}
+	if rule.Template != "" && !strings.Contains(rule.Template, tagTemplatePlaceholder) {
+		return fmt.Errorf("template must contain %s", tagTemplatePlaceholder)
+	}
+	return nil
+}
+
+// ResolveTagExtractRules returns the rules to use: rules given as flags win over the
+// config file, which wins over DefaultTagExtractRules
+func ResolveTagExtractRules(specs []string, cfg *config.Config) ([]config.TagExtractRule, error) {
+	if len(specs) > 0 {
+		rules := make([]config.TagExtractRule, 0, len(specs))
+		for _, spec := range specs {
+			rule, err := ParseTagExtractRule(spec)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+		return rules, nil
+	}
+
+	if cfg != nil && len(cfg.TagExtractRules) > 0 {
+		for _, rule := range cfg.TagExtractRules {
+			if err := ValidateTagExtractRule(rule); err != nil {
+				return nil, fmt.Errorf("invalid tag extract rule '%s' in config: %w", rule, err)
+			}
+		}
+		return cfg.TagExtractRules, nil
+	}
+
+	return DefaultTagExtractRules, nil
+}
+
+// ExtractTagsFromMetadata applies the rules to key metadata and returns the distinct
+// tags found, sorted. String values are split by the rule's delimiter, arrays are read
+// element by element, and numbers and booleans are used as written.
+func ExtractTagsFromMetadata(metadata map[string]interface{}, rules []config.TagExtractRule) []string {
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		var value interface{}
+		if rule.Path != "" {
+			found, err := GetJSONPath(metadata, rule.Path)
+			if err != nil {
+				continue
+			}
+			value = found
+		} else {
+			found, ok := metadata[rule.Field]
+			if !ok {
+				continue
+			}
+			value = found
+		}
+
+		for _, tag := range tagValues(value, rule.Delimiter) {
+			if rule.Template != "" {
+				tag = strings.ReplaceAll(rule.Template, tagTemplatePlaceholder, tag)
+			}
+			seen[tag] = true
+		}
+	}
+
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// tagValues turns a metadata value into individual tags
+func tagValues(value interface{}, delimiter string) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		if delimiter == "" {
+			values = []string{v}
+		} else {
+			values = strings.Split(v, delimiter)
+		}
+	case []interface{}:
+		for _, element := range v {
+			values = append(values, tagValues(element, delimiter)...)
+		}
+		return values
+	case float64, bool, int, int64:
+		values = []string{fmt.Sprintf("%v", v)}
+	default:
+		return nil
+	}
+
+	tags := make([]string, 0, len(values))
+	for _, tag := range values {
+		if trimmed := strings.TrimSpace(tag); trimmed != "" {
+			tags = append(tags, trimmed)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"cache-kv-purger/internal/config"
+)
+
+func TestParseTagExtractRule(t *testing.T) {
+	tests := map[string]config.TagExtractRule{
+		"cache-tag":                              {Field: "cache-tag"},
+		"field=cache-tags,delimiter=comma":       {Field: "cache-tags", Delimiter: ","},
+		"field=labels,delimiter=;":               {Field: "labels", Delimiter: ";"},
+		"path=.cdn.tags,template=site-{value}":   {Path: ".cdn.tags", Template: "site-{value}"},
+		" field = sku , delimiter = pipe ":       {Field: "sku", Delimiter: "|"},
+		"path=.items[0].tag,delimiter=semicolon": {Path: ".items[0].tag", Delimiter: ";"},
+	}
+	for spec, want := range tests {
+		got, err := ParseTagExtractRule(spec)
+		if err != nil {
+			t.Errorf("ParseTagExtractRule(%q) error: %v", spec, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseTagExtractRule(%q) = %+v, want %+v", spec, got, want)
+		}
+	}
+
+	for _, spec := range []string{"", "delimiter=comma", "field=a,path=.b", "field=a,color=red", "field=a,template=fixed", "path=.a..b"} {
+		if _, err := ParseTagExtractRule(spec); err == nil {
+			t.Errorf("ParseTagExtractRule(%q) expected error", spec)
+		}
+	}
+}
+
+func TestExtractTagsFromMetadata(t *testing.T) {
+	metadata := map[string]interface{}{
+		"cache-tag":  "products",
+		"cache-tags": "a, b,,c",
+		"cacheTags":  []interface{}{"d", "e,f"},
+		"cdn": map[string]interface{}{
+			"tags": []interface{}{"x", 42.0},
+		},
+		"sku": "123",
+	}
+
+	got := ExtractTagsFromMetadata(metadata, DefaultTagExtractRules)
+	want := []string{"a", "b", "c", "d", "e", "f", "products"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default rules = %v, want %v", got, want)
+	}
+
+	rules := []config.TagExtractRule{
+		{Path: ".cdn.tags"},
+		{Field: "sku", Template: "product-{value}"},
+		{Field: "missing"},
+		{Path: ".cdn.missing"},
+	}
+	got = ExtractTagsFromMetadata(metadata, rules)
+	want = []string{"42", "product-123", "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("custom rules = %v, want %v", got, want)
+	}
+}
+
+func TestResolveTagExtractRules(t *testing.T) {
+	cfg := config.New()
+
+	rules, err := ResolveTagExtractRules(nil, cfg)
+	if err != nil || !reflect.DeepEqual(rules, DefaultTagExtractRules) {
+		t.Errorf("expected default rules, got %v, %v", rules, err)
+	}
+
+	cfg.TagExtractRules = []config.TagExtractRule{{Field: "labels", Delimiter: ";"}}
+	rules, err = ResolveTagExtractRules(nil, cfg)
+	if err != nil || !reflect.DeepEqual(rules, cfg.TagExtractRules) {
+		t.Errorf("expected config rules, got %v, %v", rules, err)
+	}
+
+	rules, err = ResolveTagExtractRules([]string{"field=sku"}, cfg)
+	if err != nil || !reflect.DeepEqual(rules, []config.TagExtractRule{{Field: "sku"}}) {
+		t.Errorf("expected flag rules, got %v, %v", rules, err)
+	}
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the string formats accepted by ParseTimestamp, most specific first
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// unixMillisThreshold separates Unix seconds from Unix milliseconds; seconds stay
+// below it until the year 33658
+const unixMillisThreshold = 1e12
+
+// ParseTimestamp parses a timestamp as found in user input or KV metadata.
+// Accepted forms are RFC 3339, "2006-01-02", "2006-01-02 15:04:05" (UTC), and
+// Unix seconds or milliseconds as a number or numeric string.
+func ParseTimestamp(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case float64:
+		return unixTimestamp(v), nil
+	case int64:
+		return unixTimestamp(float64(v)), nil
+	case int:
+		return unixTimestamp(float64(v)), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return time.Time{}, fmt.Errorf("empty timestamp")
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return unixTimestamp(n), nil
+		}
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized timestamp '%s' (use RFC 3339, YYYY-MM-DD, or Unix seconds)", s)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp type %T", value)
+	}
+}
+
+// unixTimestamp converts Unix seconds or milliseconds to a time
+func unixTimestamp(n float64) time.Time {
+	if math.Abs(n) >= unixMillisThreshold {
+		return time.UnixMilli(int64(n)).UTC()
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+// ParseTTL parses a time-to-live as found in user input: a number of seconds ("3600"),
+// a Go duration ("90m", "1h30m"), or a number of days ("7d"). The result must be positive.
+func ParseTTL(value string) (time.Duration, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return 0, fmt.Errorf("empty TTL")
+	}
+
+	var ttl time.Duration
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		ttl = time.Duration(n) * time.Second
+	} else if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid TTL '%s' (use seconds, a duration like 1h30m, or days like 7d)", s)
+		}
+		ttl = time.Duration(n * float64(24*time.Hour))
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid TTL '%s' (use seconds, a duration like 1h30m, or days like 7d)", s)
+		}
+		ttl = d
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("TTL '%s' must be positive", s)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected time.Time
+	}{
+		{"date only", "2024-06-01", want},
+		{"RFC 3339", "2024-06-01T00:00:00Z", want},
+		{"RFC 3339 with offset", "2024-06-01T02:00:00+02:00", want},
+		{"date and time", "2024-06-01 00:00:00", want},
+		{"Unix seconds as number", float64(want.Unix()), want},
+		{"Unix seconds as string", "1717200000", want},
+		{"Unix milliseconds", float64(want.UnixMilli()), want},
+		{"int64 seconds", want.Unix(), want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimestamp(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTimestamp(%v) returned error: %v", tt.input, err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("ParseTimestamp(%v) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTimestampInvalid(t *testing.T) {
+	for _, input := range []interface{}{"", "yesterday", "2024-13-01", true, nil} {
+		if _, err := ParseTimestamp(input); err == nil {
+			t.Errorf("ParseTimestamp(%v) expected error, got nil", input)
+		}
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"3600", time.Hour},
+		{"90s", 90 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"0.5d", 12 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTTL(tt.input)
+		if err != nil {
+			t.Fatalf("ParseTTL(%q) returned error: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("ParseTTL(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"", "0", "-5m", "soon", "xd"} {
+		if _, err := ParseTTL(input); err == nil {
+			t.Errorf("ParseTTL(%q) expected error, got nil", input)
+		}
+	}
+}
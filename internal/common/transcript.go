@@ -0,0 +1,244 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// confirmPromptSuffix ends the prompts printed by ConfirmAction. Prompts are left out of
+// transcripts, which record the question and its answer as one step instead.
+const confirmPromptSuffix = "[y/N]: "
+
+// TranscriptStep is one line of output, or one answered confirmation, of a transcribed run
+type TranscriptStep struct {
+	Time time.Time
+	Text string
+}
+
+// TranscriptSection is a table appended to a transcript, such as the purge requests of the run
+type TranscriptSection struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// transcriptStepMarker starts a step written to stdout by RecordTranscriptStep. Steps travel
+// through stdout so they are recorded in order with the output around them; the recorder
+// strips them from the output.
+const transcriptStepMarker = '\x00'
+
+// transcriptRecorder collects the lines written to stdout as steps, passing the output
+// through unchanged. Progress lines redrawn with \r are recorded once, in their last state.
+type transcriptRecorder struct {
+	mu       sync.Mutex
+	out      io.Writer
+	pending  []byte
+	marker   []byte
+	inMarker bool
+	steps    []TranscriptStep
+	now      func() time.Time
+}
+
+// Write passes p through, without step markers, and records every complete line in it
+func (r *transcriptRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	output := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch {
+		case r.inMarker && b == '\n':
+			r.steps = append(r.steps, TranscriptStep{Time: r.now(), Text: Redact(string(r.marker))})
+			r.marker, r.inMarker = r.marker[:0], false
+		case r.inMarker:
+			r.marker = append(r.marker, b)
+		case b == transcriptStepMarker:
+			r.inMarker = true
+		default:
+			output = append(output, b)
+			r.pending = append(r.pending, b)
+			if b == '\n' {
+				r.addLine(string(r.pending[:len(r.pending)-1]))
+				r.pending = r.pending[:0]
+			}
+		}
+	}
+	if bytes.HasSuffix(r.pending, []byte(confirmPromptSuffix)) {
+		r.pending = r.pending[:0]
+	}
+
+	if r.out != nil && len(output) > 0 {
+		if _, err := r.out.Write(output); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// addLine records the last state of a line, skipping blank ones
+func (r *transcriptRecorder) addLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return
+	}
+	r.steps = append(r.steps, TranscriptStep{Time: r.now(), Text: Redact(line)})
+}
+
+// flush records a last line that was not terminated by a newline
+func (r *transcriptRecorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > 0 {
+		r.addLine(string(r.pending))
+		r.pending = nil
+	}
+}
+
+// transcript is the --transcript of this run, set by StartTranscript
+var transcript struct {
+	path    string
+	command string
+	started time.Time
+	stdout  *os.File
+	pipe    *os.File
+	done    chan struct{}
+	rec     *transcriptRecorder
+}
+
+// StartTranscript records everything the run prints to stdout, and every confirmation
+// answer, until FinishTranscript writes it to path as Markdown for change tickets.
+// Output still reaches the terminal as before.
+func StartTranscript(path string, args []string) error {
+	if transcript.rec != nil {
+		return nil
+	}
+
+	// Fail now rather than after the work is done
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create transcript: %w", err)
+	}
+	file.Close()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to start transcript: %w", err)
+	}
+
+	transcript.path = path
+	transcript.command = strings.Join(args, " ")
+	transcript.started = time.Now()
+	transcript.stdout = os.Stdout
+	transcript.pipe = writer
+	transcript.done = make(chan struct{})
+	transcript.rec = &transcriptRecorder{out: os.Stdout, now: time.Now}
+
+	go func() {
+		_, _ = io.Copy(transcript.rec, reader)
+		reader.Close()
+		close(transcript.done)
+	}()
+	os.Stdout = writer
+	return nil
+}
+
+// TranscriptActive returns true if this run is being transcribed
+func TranscriptActive() bool {
+	return transcript.rec != nil
+}
+
+// RecordTranscriptStep adds a step to the transcript, if there is one
+func RecordTranscriptStep(format string, args ...interface{}) {
+	if transcript.rec == nil {
+		return
+	}
+	text := strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " ")
+	fmt.Fprintf(os.Stdout, "%c%s\n", transcriptStepMarker, text)
+}
+
+// RecordConfirmation adds a confirmation prompt and its answer to the transcript, if there is one
+func RecordConfirmation(message string, confirmed, assumed bool) {
+	answer := "no"
+	if confirmed {
+		answer = "yes"
+	}
+	if assumed {
+		answer += " (assumed by --force, --quiet or CACHE_KV_ASSUME_YES)"
+	}
+	RecordTranscriptStep("Confirmation: %s -> %s", strings.TrimSpace(message), answer)
+}
+
+// FinishTranscript restores stdout and writes the transcript, with the outcome of the run
+// and the given sections after the steps. It does nothing without a transcript.
+func FinishTranscript(runErr error, sections ...TranscriptSection) error {
+	if transcript.rec == nil {
+		return nil
+	}
+
+	os.Stdout = transcript.stdout
+	transcript.pipe.Close()
+	<-transcript.done
+	transcript.rec.flush()
+
+	var buf bytes.Buffer
+	writeTranscript(&buf, transcript.command, transcript.started, time.Now(), runErr, transcript.rec.steps, sections)
+	transcript.rec = nil
+
+	if err := os.WriteFile(transcript.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// writeTranscript renders a transcript as Markdown
+func writeTranscript(w io.Writer, command string, started, finished time.Time, runErr error,
+	steps []TranscriptStep, sections []TranscriptSection) {
+
+	result := "succeeded"
+	if runErr != nil {
+		result = "failed: " + Redact(runErr.Error())
+	}
+
+	fmt.Fprintf(w, "# cache-kv-purger transcript\n\n")
+	fmt.Fprintf(w, "- **Command:** `%s`\n", strings.ReplaceAll(Redact(command), "`", "'"))
+	fmt.Fprintf(w, "- **Started:** %s\n", started.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "- **Finished:** %s\n", finished.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "- **Duration:** %s\n", finished.Sub(started).Round(time.Millisecond))
+	fmt.Fprintf(w, "- **Result:** %s\n", result)
+
+	fmt.Fprintf(w, "\n## Steps\n\n")
+	if len(steps) == 0 {
+		fmt.Fprintf(w, "No output.\n")
+	} else {
+		fmt.Fprintf(w, "```\n")
+		for _, step := range steps {
+			fmt.Fprintf(w, "%s  %s\n", step.Time.UTC().Format("15:04:05.000"), step.Text)
+		}
+		fmt.Fprintf(w, "```\n")
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(w, "\n## %s\n\n", section.Title)
+		if len(section.Rows) == 0 {
+			fmt.Fprintf(w, "None.\n")
+			continue
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(section.Header, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(section.Header)))
+		for _, row := range section.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.ReplaceAll(cell, "|", `\|`)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscriptRecorder(t *testing.T) {
+	var out bytes.Buffer
+	rec := &transcriptRecorder{out: &out, now: time.Now}
+
+	writes := []string{
+		"Found 3 keys\n",
+		"Progress: 1/3\r", "Progress: 2/3\r", "Progress: 3/3\r\n",
+		"\nDelete them? [y/N]: ",
+		fmt.Sprintf("%cConfirmation: Delete them? -> yes\n", transcriptStepMarker),
+		"Deleted 3 keys",
+	}
+	for _, w := range writes {
+		if _, err := rec.Write([]byte(w)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.flush()
+
+	var got []string
+	for _, step := range rec.steps {
+		got = append(got, step.Text)
+	}
+	want := []string{"Found 3 keys", "Progress: 3/3", "Confirmation: Delete them? -> yes", "Deleted 3 keys"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("steps = %q, want %q", got, want)
+	}
+
+	if strings.ContainsRune(out.String(), transcriptStepMarker) || strings.Contains(out.String(), "Confirmation:") {
+		t.Errorf("step markers leaked into output: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "Delete them? [y/N]: ") {
+		t.Errorf("prompt missing from output: %q", out.String())
+	}
+}
+
+func TestWriteTranscript(t *testing.T) {
+	started := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	steps := []TranscriptStep{{Time: started, Text: "Purged 2 tags"}}
+	sections := []TranscriptSection{
+		{Title: "Purge requests", Header: []string{"Purge ID", "Zone"}, Rows: [][]string{{"abc", "zone|1"}}},
+		{Title: "Empty", Header: []string{"A"}},
+	}
+
+	var buf bytes.Buffer
+	writeTranscript(&buf, "cache-kv-purger cache purge tags", started, started.Add(1500*time.Millisecond),
+		errors.New("boom"), steps, sections)
+	got := buf.String()
+
+	for _, want := range []string{
+		"- **Command:** `cache-kv-purger cache purge tags`",
+		"- **Duration:** 1.5s",
+		"- **Result:** failed: boom",
+		"15:04:05.000  Purged 2 tags",
+		"| Purge ID | Zone |\n| --- | --- |\n| abc | zone\\|1 |",
+		"## Empty\n\nNone.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("transcript missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValueTransformer applies a pipeline of string transformations to values in flight,
+// e.g. rewriting environment-specific URLs when promoting data between namespaces
+type ValueTransformer struct {
+	steps []func(string) string
+}
+
+// NewValueTransformer builds a transformer from a jq-like expression and a list of
+// old=new replacements. Either may be empty. Returns nil if there is nothing to apply.
+//
+// Supported expression functions, chained with "|":
+//
+//	sub("regex"; "replacement")   replace the first regex match
+//	gsub("regex"; "replacement")  replace all regex matches
+//	replace("old"; "new")         replace all literal occurrences
+//	ltrimstr("prefix")            remove a leading prefix
+//	rtrimstr("suffix")            remove a trailing suffix
+//	ascii_downcase                lowercase the value
+//	ascii_upcase                  uppercase the value
+//	trim                          remove surrounding whitespace
+func NewValueTransformer(expression string, replacements []string) (*ValueTransformer, error) {
+	t := &ValueTransformer{}
+
+	for _, r := range replacements {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid replacement '%s': expected old=new", r)
+		}
+		oldValue, newValue := parts[0], parts[1]
+		t.steps = append(t.steps, func(s string) string {
+			return strings.ReplaceAll(s, oldValue, newValue)
+		})
+	}
+
+	if strings.TrimSpace(expression) != "" {
+		for _, stage := range splitOutsideQuotes(expression, '|') {
+			step, err := parseTransformStage(strings.TrimSpace(stage))
+			if err != nil {
+				return nil, err
+			}
+			t.steps = append(t.steps, step)
+		}
+	}
+
+	if len(t.steps) == 0 {
+		return nil, nil
+	}
+
+	return t, nil
+}
+
+// Apply runs the transformation pipeline on a value
+func (t *ValueTransformer) Apply(value string) string {
+	if t == nil {
+		return value
+	}
+	for _, step := range t.steps {
+		value = step(value)
+	}
+	return value
+}
+
+// ApplyToMetadata returns a copy of metadata with the pipeline applied to every string value,
+// including strings nested in objects and arrays
+func (t *ValueTransformer) ApplyToMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if t == nil || metadata == nil {
+		return metadata
+	}
+	return t.applyToAny(metadata).(map[string]interface{})
+}
+
+// applyToAny recursively transforms string values in decoded JSON data
+func (t *ValueTransformer) applyToAny(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return t.Apply(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = t.applyToAny(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = t.applyToAny(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// parseTransformStage parses a single function call of a transform expression
+func parseTransformStage(stage string) (func(string) string, error) {
+	name := stage
+	var args []string
+
+	if open := strings.Index(stage, "("); open >= 0 {
+		if !strings.HasSuffix(stage, ")") {
+			return nil, fmt.Errorf("invalid transform '%s': missing closing parenthesis", stage)
+		}
+		name = strings.TrimSpace(stage[:open])
+		for _, arg := range splitOutsideQuotes(stage[open+1:len(stage)-1], ';') {
+			unquoted, err := unquoteTransformArg(strings.TrimSpace(arg))
+			if err != nil {
+				return nil, fmt.Errorf("invalid transform '%s': %w", stage, err)
+			}
+			args = append(args, unquoted)
+		}
+	}
+
+	requireArgs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("transform '%s' expects %d arguments, got %d", name, n, len(args))
+		}
+		return nil
+	}
+
+	switch name {
+	case "sub", "gsub":
+		if err := requireArgs(2); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in transform '%s': %w", stage, err)
+		}
+		replacement := args[1]
+		if name == "gsub" {
+			return func(s string) string { return re.ReplaceAllString(s, replacement) }, nil
+		}
+		return func(s string) string { return replaceFirst(re, s, replacement) }, nil
+	case "replace":
+		if err := requireArgs(2); err != nil {
+			return nil, err
+		}
+		oldValue, newValue := args[0], args[1]
+		return func(s string) string { return strings.ReplaceAll(s, oldValue, newValue) }, nil
+	case "ltrimstr":
+		if err := requireArgs(1); err != nil {
+			return nil, err
+		}
+		prefix := args[0]
+		return func(s string) string { return strings.TrimPrefix(s, prefix) }, nil
+	case "rtrimstr":
+		if err := requireArgs(1); err != nil {
+			return nil, err
+		}
+		suffix := args[0]
+		return func(s string) string { return strings.TrimSuffix(s, suffix) }, nil
+	case "ascii_downcase":
+		if err := requireArgs(0); err != nil {
+			return nil, err
+		}
+		return strings.ToLower, nil
+	case "ascii_upcase":
+		if err := requireArgs(0); err != nil {
+			return nil, err
+		}
+		return strings.ToUpper, nil
+	case "trim":
+		if err := requireArgs(0); err != nil {
+			return nil, err
+		}
+		return strings.TrimSpace, nil
+	default:
+		return nil, fmt.Errorf("unknown transform function '%s'", name)
+	}
+}
+
+// replaceFirst replaces the first match of re in s, expanding $1-style references in
+// replacement like ReplaceAllString does
+func replaceFirst(re *regexp.Regexp, s, replacement string) string {
+	match := re.FindStringSubmatchIndex(s)
+	if match == nil {
+		return s
+	}
+	expanded := re.ExpandString(nil, replacement, s, match)
+	return s[:match[0]] + string(expanded) + s[match[1]:]
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside double quotes
+func splitOutsideQuotes(s string, sep rune) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	parts = append(parts, current.String())
+
+	return parts
+}
+
+// unquoteTransformArg removes the double quotes around a transform argument
+func unquoteTransformArg(arg string) (string, error) {
+	if len(arg) < 2 || !strings.HasPrefix(arg, "\"") || !strings.HasSuffix(arg, "\"") {
+		return "", fmt.Errorf("argument %s must be a double-quoted string", arg)
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(arg[1 : len(arg)-1]), nil
+}
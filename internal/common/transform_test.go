@@ -0,0 +1,109 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestValueTransformer(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		replacements []string
+		input        string
+		expected     string
+	}{
+		{
+			name:         "Literal replacement",
+			replacements: []string{"staging.example.com=www.example.com"},
+			input:        `{"url":"https://staging.example.com/a"}`,
+			expected:     `{"url":"https://www.example.com/a"}`,
+		},
+		{
+			name:       "Regex substitution",
+			expression: `sub("v[0-9]+"; "v2")`,
+			input:      "/api/v1/users",
+			expected:   "/api/v2/users",
+		},
+		{
+			name:       "sub replaces the first match only",
+			expression: `sub("(v)[0-9]+"; "${1}2")`,
+			input:      "/v1/a/v1",
+			expected:   "/v2/a/v1",
+		},
+		{
+			name:       "gsub replaces every match",
+			expression: `gsub("v[0-9]+"; "v2")`,
+			input:      "/v1/a/v1",
+			expected:   "/v2/a/v2",
+		},
+		{
+			name:       "Pipeline",
+			expression: `ltrimstr("tmp-") | ascii_upcase | replace("|"; "/")`,
+			input:      "tmp-a|b",
+			expected:   "A/B",
+		},
+		{
+			name:         "Replacements run before expression",
+			expression:   `rtrimstr("!")`,
+			replacements: []string{"dev=prod"},
+			input:        "dev!",
+			expected:     "prod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformer, err := NewValueTransformer(tt.expression, tt.replacements)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := transformer.Apply(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValueTransformerErrors(t *testing.T) {
+	if _, err := NewValueTransformer(`unknown("x")`, nil); err == nil {
+		t.Error("Expected error for unknown function")
+	}
+	if _, err := NewValueTransformer(`sub("a")`, nil); err == nil {
+		t.Error("Expected error for wrong argument count")
+	}
+	if _, err := NewValueTransformer("", []string{"missing-separator"}); err == nil {
+		t.Error("Expected error for invalid replacement")
+	}
+
+	transformer, err := NewValueTransformer("", nil)
+	if err != nil || transformer != nil {
+		t.Errorf("Expected nil transformer for empty input, got %v, %v", transformer, err)
+	}
+}
+
+func TestValueTransformerMetadata(t *testing.T) {
+	transformer, err := NewValueTransformer("", []string{"staging=prod"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	metadata := map[string]interface{}{
+		"env":   "staging",
+		"count": 3.0,
+		"tags":  []interface{}{"staging-a", "b"},
+	}
+
+	result := transformer.ApplyToMetadata(metadata)
+	if result["env"] != "prod" {
+		t.Errorf("Expected env to be prod, got %v", result["env"])
+	}
+	if result["count"] != 3.0 {
+		t.Errorf("Expected count to be unchanged, got %v", result["count"])
+	}
+	if tags := result["tags"].([]interface{}); tags[0] != "prod-a" {
+		t.Errorf("Expected nested tag to be transformed, got %v", tags[0])
+	}
+	if metadata["env"] != "staging" {
+		t.Error("Expected original metadata to be unchanged")
+	}
+}
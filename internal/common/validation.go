@@ -8,29 +8,6 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// ValidateAccountID ensures a valid account ID is available
-func ValidateAccountID(cmd *cobra.Command, cfg *config.Config, providedID ...string) (string, error) {
-	// First check if we were provided an account ID directly
-	if len(providedID) > 0 && providedID[0] != "" {
-		return providedID[0], nil
-	}
-
-	// Next try to get from flag
-	accountID, _ := cmd.Flags().GetString("account-id")
-
-	// If not in flag, try from config
-	if accountID == "" && cfg != nil {
-		accountID = cfg.GetAccountID()
-	}
-
-	// If still not found, return error
-	if accountID == "" {
-		return "", fmt.Errorf("account ID is required, specify it with --account-id flag, CLOUDFLARE_ACCOUNT_ID environment variable, or set a default account in config")
-	}
-
-	return accountID, nil
-}
-
 // ValidateNamespaceID ensures a valid namespace ID is available
 func ValidateNamespaceID(cmd *cobra.Command, cfg *config.Config, client interface{}, accountID string) (string, error) {
 	// First try to get from flag
@@ -115,7 +92,7 @@ func ResolveZoneIdentifiers(cmd *cobra.Command, client interface{}, accountID st
 	if allZones {
 		// Make sure we have an account ID
 		if accountID == "" {
-			return nil, fmt.Errorf("account ID is required for --all-zones, set it with CLOUDFLARE_ACCOUNT_ID or in config")
+			return nil, fmt.Errorf("account ID is required for --all-zones, set it with --account-id, CLOUDFLARE_ACCOUNT_ID or in config")
 		}
 
 		// Check if the client has ListZones method
@@ -109,17 +109,17 @@ func (v *Verbosity) Verboseln(format string, args ...interface{}) {
 	}
 }
 
-// Debug prints a message at the debug level
+// Debug prints a message at the debug level, with secrets redacted
 func (v *Verbosity) Debug(format string, args ...interface{}) {
 	if v.Level >= VerbosityDebug {
-		fmt.Fprintf(v.Writer, "[DEBUG] "+format, args...)
+		fmt.Fprint(v.Writer, "[DEBUG] "+Redact(fmt.Sprintf(format, args...)))
 	}
 }
 
-// Debugln prints a message with a trailing newline at the debug level
+// Debugln prints a message with a trailing newline at the debug level, with secrets redacted
 func (v *Verbosity) Debugln(format string, args ...interface{}) {
 	if v.Level >= VerbosityDebug {
-		fmt.Fprintf(v.Writer, "[DEBUG] "+format+"\n", args...)
+		fmt.Fprintln(v.Writer, "[DEBUG] "+Redact(fmt.Sprintf(format, args...)))
 	}
 }
 
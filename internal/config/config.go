@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
-	"path/filepath"
+	"strings"
 )
 
 const (
@@ -13,18 +14,44 @@ const (
 	DefaultAPIEndpoint = "https://api.cloudflare.com/client/v4"
 
 	// Environment variables for configuration
+	EnvAPIEndpoint          = "CLOUDFLARE_API_ENDPOINT"
 	EnvZoneID               = "CLOUDFLARE_ZONE_ID"
 	EnvAccountID            = "CLOUDFLARE_ACCOUNT_ID"
+	EnvNamespaceID          = "CLOUDFLARE_NAMESPACE_ID"
 	EnvCacheConcurrency     = "CLOUDFLARE_CACHE_CONCURRENCY"
 	EnvMultiZoneConcurrency = "CLOUDFLARE_MULTI_ZONE_CONCURRENCY"
+	EnvMaxConcurrency       = "CLOUDFLARE_MAX_CONCURRENCY"
+	EnvPurgeRate            = "CLOUDFLARE_PURGE_RATE"
+	EnvMetadataWorkers      = "CLOUDFLARE_METADATA_WORKERS"
+	EnvRetryCount           = "CLOUDFLARE_RETRY_COUNT"   // Retries of failed requests, as --retries does
+	EnvBackoffDelay         = "CLOUDFLARE_BACKOFF_DELAY" // First retry delay in milliseconds, as --backoff does
+	EnvAPITimeout           = "CLOUDFLARE_API_TIMEOUT"   // API request timeout in seconds, as --api-timeout does
+	EnvRateLimit            = "CLOUDFLARE_RATE_LIMIT"    // API requests per second, as --rate-limit does
+	EnvBatchSize            = "CLOUDFLARE_BATCH_SIZE"    // Keys per bulk request of operations without --batch-size
+	EnvCACert               = "CLOUDFLARE_CA_CERT"       // PEM bundle trusted for API connections, as --ca-cert does
+	EnvClientCert           = "CLOUDFLARE_CLIENT_CERT"   // Client certificate for mutual TLS, as --client-cert does
+	EnvClientKey            = "CLOUDFLARE_CLIENT_KEY"    // Client key for mutual TLS, as --client-key does
+	EnvMock                 = "CACHE_KV_MOCK"
+	EnvMockOffline          = "CACHE_KV_MOCK_OFFLINE"
+	EnvAssumeYes            = "CACHE_KV_ASSUME_YES"      // Answer yes to every confirmation prompt
+	EnvCacheCursors         = "CACHE_KV_CACHE_CURSORS"   // Cache kv list page cursors, as --cache-cursors does
+	EnvJournal              = "CACHE_KV_JOURNAL"         // Journal keys changed by kv delete and kv put, as --journal does
+	EnvNamespaceCache       = "CACHE_KV_NAMESPACE_CACHE" // Keep namespace title resolutions on disk this long, as --namespace-cache does
+	EnvNotifyURL            = "CACHE_KV_NOTIFY_URL"      // Webhook receiving a JSON summary of purge and delete runs, as --notify-url does
+	EnvNotifySlack          = "CACHE_KV_NOTIFY_SLACK"    // Slack incoming webhook receiving the summary, as --notify-slack does
 
 	// Default concurrency values for Enterprise tier
 	DefaultCacheConcurrency     = 50 // Enterprise tier allows 50 requests per second
 	DefaultMaxCacheConcurrency  = 50 // Enterprise tier cap
 	DefaultMultiZoneConcurrency = 10 // Increased for Enterprise performance
+	DefaultMaxConcurrency       = 50 // Upper bound of in-flight API requests across all workers
 
 	// Default batch size per API limits
 	DefaultBatchSize = 100 // Maximum items per API request (Cloudflare limit)
+
+	// DefaultEstimateThreshold is the number of estimated API requests above which
+	// commands run with --estimate ask before continuing
+	DefaultEstimateThreshold = 5000
 )
 
 // Config holds the application configuration
@@ -32,13 +59,67 @@ type Config struct {
 	APIEndpoint          string `json:"api_endpoint"`
 	DefaultZone          string `json:"default_zone,omitempty"`
 	AccountID            string `json:"account_id,omitempty"`
+	DefaultNamespace     string `json:"default_namespace,omitempty"`
 	CacheConcurrency     int    `json:"cache_concurrency,omitempty"`
 	MultiZoneConcurrency int    `json:"multi_zone_concurrency,omitempty"`
+	MaxConcurrency       int    `json:"max_concurrency,omitempty"`
+	EstimateThreshold    int    `json:"estimate_threshold,omitempty"`  // API requests above which --estimate asks to continue
+	PurgeRate            int    `json:"purge_rate,omitempty"`          // Purge calls per minute per zone, 0 for no pacing
+	MetadataWorkers      int    `json:"metadata_workers,omitempty"`    // Metadata requests in flight across the process, 0 for no separate bound
+	NamespaceKeyLimit    int    `json:"namespace_key_limit,omitempty"` // Keys a namespace may hold before bulk writes are refused, 0 for no limit
+	Retries              int    `json:"retries,omitempty"`             // Retries of failed requests, 0 for the default
+	BackoffMS            int    `json:"backoff_ms,omitempty"`          // First retry delay in milliseconds, 0 for the default
+	TimeoutSeconds       int    `json:"timeout_seconds,omitempty"`     // API request timeout, 0 for the default
+	RateLimit            int    `json:"rate_limit,omitempty"`          // API requests per second across the process, 0 for the default
+	BatchSize            int    `json:"batch_size,omitempty"`          // Keys per bulk request when a command sets none, 0 for the default
+
+	// Protected resources that destructive commands refuse to touch
+	ProtectedNamespaces []string `json:"protected_namespaces,omitempty"` // Namespace IDs or titles
+	ProtectedZones      []string `json:"protected_zones,omitempty"`      // Zone IDs or names
+
+	// Options applied to commands that target a namespace, keyed by namespace ID or title
+	Namespaces map[string]NamespaceDefaults `json:"namespaces,omitempty"`
+
+	// Rules for extracting cache tags from KV metadata (sync purge --extract-tags)
+	TagExtractRules []TagExtractRule `json:"tag_extract_rules,omitempty"`
+
+	// Regular expressions whose matches are redacted from log output, on top of the built-in ones
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
 
 	// Runtime configuration values (not persisted)
 	runtimeValues map[string]string
 }
 
+// TagExtractRule describes where cache tags are found in KV key metadata
+type TagExtractRule struct {
+	Field     string `json:"field,omitempty"`     // Top-level metadata field
+	Path      string `json:"path,omitempty"`      // JSON path inside the metadata, e.g. .cdn.tags
+	Delimiter string `json:"delimiter,omitempty"` // Splits string values into several tags
+	Template  string `json:"template,omitempty"`  // Formats each tag, with {value} replaced by the tag
+}
+
+// String formats the rule in the syntax accepted by --tag-extract-rule
+func (r TagExtractRule) String() string {
+	var parts []string
+	if r.Field != "" {
+		parts = append(parts, "field="+r.Field)
+	}
+	if r.Path != "" {
+		parts = append(parts, "path="+r.Path)
+	}
+	if r.Delimiter != "" {
+		delimiter := r.Delimiter
+		if delimiter == "," {
+			delimiter = "comma"
+		}
+		parts = append(parts, "delimiter="+delimiter)
+	}
+	if r.Template != "" {
+		parts = append(parts, "template="+r.Template)
+	}
+	return strings.Join(parts, ",")
+}
+
 // New creates a Config with default values
 func New() *Config {
 	return &Config{
@@ -53,13 +134,12 @@ func New() *Config {
 func LoadFromFile(path string) (*Config, error) {
 	if path == "" {
 		// Try to find config in default locations
-		homeDir, err := os.UserHomeDir()
+		homePath, err := DefaultPath()
 		if err != nil {
 			return New(), nil // Return default config if we can't find home dir
 		}
 
 		// Check home directory first
-		homePath := filepath.Join(homeDir, ".cache-kv-purger.json")
 		if fileExists(homePath) {
 			path = homePath
 		}
@@ -76,7 +156,8 @@ func LoadFromFile(path string) (*Config, error) {
 
 		cfg = New()
 		if err := json.Unmarshal(data, cfg); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to parse config file %s: %s (run 'config validate' for details)",
+				path, describeJSONError(data, err))
 		}
 	}
 
@@ -85,6 +166,11 @@ func LoadFromFile(path string) (*Config, error) {
 		cfg.APIEndpoint = DefaultAPIEndpoint
 	}
 
+	// Check environment variable for the API endpoint
+	if envAPIEndpoint := os.Getenv(EnvAPIEndpoint); envAPIEndpoint != "" {
+		cfg.APIEndpoint = envAPIEndpoint
+	}
+
 	// Check environment variables for zone ID and account ID
 	if envZoneID := os.Getenv(EnvZoneID); envZoneID != "" {
 		cfg.DefaultZone = envZoneID
@@ -94,6 +180,10 @@ func LoadFromFile(path string) (*Config, error) {
 		cfg.AccountID = envAccountID
 	}
 
+	if envNamespaceID := os.Getenv(EnvNamespaceID); envNamespaceID != "" {
+		cfg.DefaultNamespace = envNamespaceID
+	}
+
 	// Check environment variables for concurrency settings
 	if envCacheConcurrency := os.Getenv(EnvCacheConcurrency); envCacheConcurrency != "" {
 		var concurrency int
@@ -109,17 +199,23 @@ func LoadFromFile(path string) (*Config, error) {
 		}
 	}
 
+	if envMaxConcurrency := os.Getenv(EnvMaxConcurrency); envMaxConcurrency != "" {
+		var concurrency int
+		if _, err := fmt.Sscanf(envMaxConcurrency, "%d", &concurrency); err == nil && concurrency > 0 {
+			cfg.MaxConcurrency = concurrency
+		}
+	}
+
 	return cfg, nil
 }
 
 // SaveToFile saves the configuration to a JSON file
 func (c *Config) SaveToFile(path string) error {
 	if path == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return errors.New("cannot determine home directory for config file")
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return err
 		}
-		path = filepath.Join(homeDir, ".cache-kv-purger.json")
 	}
 
 	data, err := json.MarshalIndent(c, "", "  ")
@@ -130,6 +226,44 @@ func (c *Config) SaveToFile(path string) error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// GetAPIEndpoint returns the API endpoint from the config, or the default endpoint if not set
+func (c *Config) GetAPIEndpoint() string {
+	// First check environment variable (highest priority)
+	if envAPIEndpoint := os.Getenv(EnvAPIEndpoint); envAPIEndpoint != "" {
+		return envAPIEndpoint
+	}
+	// Then use config value
+	if c.APIEndpoint != "" {
+		return c.APIEndpoint
+	}
+	return DefaultAPIEndpoint
+}
+
+// ValidateAPIEndpoint checks that an API endpoint is an absolute http(s) URL and returns it
+// without a trailing slash. Plain http is allowed for local mocks and gateway proxies.
+func ValidateAPIEndpoint(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", errors.New("API endpoint cannot be empty")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid API endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("invalid API endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid API endpoint %q: missing host", endpoint)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid API endpoint %q: must not contain a query or fragment", endpoint)
+	}
+
+	return strings.TrimRight(endpoint, "/"), nil
+}
+
 // GetZoneID returns the zone ID from the config, or an empty string if not set
 func (c *Config) GetZoneID() string {
 	// First check environment variable (highest priority)
@@ -150,6 +284,16 @@ func (c *Config) GetAccountID() string {
 	return c.AccountID
 }
 
+// GetNamespaceID returns the default KV namespace ID from the config, or an empty string if not set
+func (c *Config) GetNamespaceID() string {
+	// First check environment variable (highest priority)
+	if envNamespaceID := os.Getenv(EnvNamespaceID); envNamespaceID != "" {
+		return envNamespaceID
+	}
+	// Then use config value
+	return c.DefaultNamespace
+}
+
 // GetCacheConcurrency returns the cache concurrency setting from the config
 func (c *Config) GetCacheConcurrency() int {
 	// First check environment variable
@@ -184,6 +328,133 @@ func (c *Config) GetMultiZoneConcurrency() int {
 	return DefaultMultiZoneConcurrency
 }
 
+// GetMaxConcurrency returns the upper bound of in-flight API requests from the config
+func (c *Config) GetMaxConcurrency() int {
+	// First check environment variable
+	if envConcurrency := os.Getenv(EnvMaxConcurrency); envConcurrency != "" {
+		var concurrency int
+		if _, err := fmt.Sscanf(envConcurrency, "%d", &concurrency); err == nil && concurrency > 0 {
+			return concurrency
+		}
+	}
+
+	// Then use config value, with default as fallback
+	if c.MaxConcurrency > 0 {
+		return c.MaxConcurrency
+	}
+	return DefaultMaxConcurrency
+}
+
+// GetPurgeRate returns the purge calls allowed per minute per zone, 0 when purges are not paced
+func (c *Config) GetPurgeRate() int {
+	// First check environment variable
+	if envRate := os.Getenv(EnvPurgeRate); envRate != "" {
+		var rate int
+		if _, err := fmt.Sscanf(envRate, "%d", &rate); err == nil && rate > 0 {
+			return rate
+		}
+	}
+
+	return max(c.PurgeRate, 0)
+}
+
+// GetMetadataWorkers returns the metadata requests allowed in flight across the process,
+// 0 when they are only bounded by the overall concurrency limit
+func (c *Config) GetMetadataWorkers() int {
+	// First check environment variable
+	if envWorkers := os.Getenv(EnvMetadataWorkers); envWorkers != "" {
+		var workers int
+		if _, err := fmt.Sscanf(envWorkers, "%d", &workers); err == nil && workers > 0 {
+			return workers
+		}
+	}
+
+	return max(c.MetadataWorkers, 0)
+}
+
+// envOrConfigInt returns the positive number in the environment variable, or else the
+// config value if it is positive, or else 0
+func envOrConfigInt(env string, value int) int {
+	if envValue := os.Getenv(env); envValue != "" {
+		var n int
+		if _, err := fmt.Sscanf(envValue, "%d", &n); err == nil && n > 0 {
+			return n
+		}
+	}
+	return max(value, 0)
+}
+
+// GetRetries returns the retries of failed requests, 0 when not configured
+func (c *Config) GetRetries() int {
+	return envOrConfigInt(EnvRetryCount, c.Retries)
+}
+
+// GetBackoffMS returns the first retry delay in milliseconds, 0 when not configured
+func (c *Config) GetBackoffMS() int {
+	return envOrConfigInt(EnvBackoffDelay, c.BackoffMS)
+}
+
+// GetTimeoutSeconds returns the API request timeout in seconds, 0 when not configured
+func (c *Config) GetTimeoutSeconds() int {
+	return envOrConfigInt(EnvAPITimeout, c.TimeoutSeconds)
+}
+
+// GetRateLimit returns the API requests per second, 0 when not configured
+func (c *Config) GetRateLimit() int {
+	return envOrConfigInt(EnvRateLimit, c.RateLimit)
+}
+
+// GetBatchSize returns the keys per bulk request of commands that set none, 0 when not configured
+func (c *Config) GetBatchSize() int {
+	return envOrConfigInt(EnvBatchSize, c.BatchSize)
+}
+
+// GetEstimateThreshold returns the number of estimated API requests above which commands
+// run with --estimate ask before continuing
+func (c *Config) GetEstimateThreshold() int {
+	if c.EstimateThreshold > 0 {
+		return c.EstimateThreshold
+	}
+	return DefaultEstimateThreshold
+}
+
+// GetNamespaceKeyLimit returns the number of keys a namespace may hold before bulk
+// writes are refused, or 0 if writes are not checked
+func (c *Config) GetNamespaceKeyLimit() int {
+	return max(c.NamespaceKeyLimit, 0)
+}
+
+// IsNamespaceProtected returns true if the namespace ID or title is listed as protected,
+// or its namespace defaults protect it
+func (c *Config) IsNamespaceProtected(namespaceID, title string) bool {
+	if defaults, ok := c.GetNamespaceDefaults(namespaceID, title); ok && defaults.Protected {
+		return true
+	}
+	for _, protected := range c.ProtectedNamespaces {
+		if protected == "" {
+			continue
+		}
+		if protected == namespaceID || protected == title {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZoneProtected returns true if the zone ID or name is listed as protected.
+// Zone names are compared case-insensitively.
+func (c *Config) IsZoneProtected(zoneID, zoneName string) bool {
+	for _, protected := range c.ProtectedZones {
+		if protected == "" {
+			continue
+		}
+		if protected == zoneID || (zoneName != "" && strings.EqualFold(protected, zoneName)) {
+			return true
+		}
+	}
+	return false
+}
+
 // fileExists checks if a file exists and is not a directory
 func fileExists(filename string) bool {
 	info, err := os.Stat(filename)
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NamespaceDefaults are options applied to commands that target one namespace, so team
+// runbooks don't have to repeat them. Flags given on the command line win.
+type NamespaceDefaults struct {
+	BatchSize   int    `json:"batch_size,omitempty"`  // Default for --batch-size
+	Concurrency int    `json:"concurrency,omitempty"` // Default for --concurrency
+	TagField    string `json:"tag_field,omitempty"`   // Default for --tag-field
+	Protected   bool   `json:"protected,omitempty"`   // Protect the namespace, as protected_namespaces does
+}
+
+// Empty returns true if the defaults set nothing
+func (d NamespaceDefaults) Empty() bool {
+	return d == NamespaceDefaults{}
+}
+
+// String describes the defaults, e.g. "batch_size=500, concurrency=20"
+func (d NamespaceDefaults) String() string {
+	var parts []string
+	if d.BatchSize > 0 {
+		parts = append(parts, fmt.Sprintf("batch_size=%d", d.BatchSize))
+	}
+	if d.Concurrency > 0 {
+		parts = append(parts, fmt.Sprintf("concurrency=%d", d.Concurrency))
+	}
+	if d.TagField != "" {
+		parts = append(parts, fmt.Sprintf("tag_field=%s", d.TagField))
+	}
+	if d.Protected {
+		parts = append(parts, "protected")
+	}
+	if len(parts) == 0 {
+		return "(none)"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// merge fills the unset options of d from other
+func (d NamespaceDefaults) merge(other NamespaceDefaults) NamespaceDefaults {
+	if d.BatchSize == 0 {
+		d.BatchSize = other.BatchSize
+	}
+	if d.Concurrency == 0 {
+		d.Concurrency = other.Concurrency
+	}
+	if d.TagField == "" {
+		d.TagField = other.TagField
+	}
+	d.Protected = d.Protected || other.Protected
+	return d
+}
+
+// GetNamespaceDefaults returns the defaults configured for a namespace by ID or title.
+// When both are configured, options set for the ID win over those set for the title.
+func (c *Config) GetNamespaceDefaults(namespaceID, title string) (NamespaceDefaults, bool) {
+	var defaults NamespaceDefaults
+	found := false
+	if namespaceID != "" {
+		if byID, ok := c.Namespaces[namespaceID]; ok {
+			defaults, found = byID, true
+		}
+	}
+	if title != "" {
+		if byTitle, ok := c.Namespaces[title]; ok {
+			defaults, found = defaults.merge(byTitle), true
+		}
+	}
+	return defaults, found
+}
+
+// NamespaceDefaultKeys returns the namespace IDs and titles with configured defaults,
+// split by whether they look like namespace IDs
+func (c *Config) NamespaceDefaultKeys() (ids, titles []string) {
+	for key := range c.Namespaces {
+		if cloudflareIDPattern.MatchString(key) {
+			ids = append(ids, key)
+		} else {
+			titles = append(titles, key)
+		}
+	}
+	sort.Strings(ids)
+	sort.Strings(titles)
+	return ids, titles
+}
+
+// hasProtectedNamespaceDefaults returns true if any namespace defaults protect a namespace
+func (c *Config) hasProtectedNamespaceDefaults() bool {
+	for _, defaults := range c.Namespaces {
+		if defaults.Protected {
+			return true
+		}
+	}
+	return false
+}
+
+// HasProtectedNamespaces returns true if any namespace is protected, in
+// protected_namespaces or in the namespace defaults
+func (c *Config) HasProtectedNamespaces() bool {
+	return len(c.ProtectedNamespaces) > 0 || c.hasProtectedNamespaceDefaults()
+}
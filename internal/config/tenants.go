@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Tenant is an account that --accounts-file runs a command against
+type Tenant struct {
+	AccountID   string `json:"account_id"`
+	Name        string `json:"name,omitempty"`          // Label shown in the summary
+	APIToken    string `json:"api_token,omitempty"`     // Token for this account
+	APITokenEnv string `json:"api_token_env,omitempty"` // Environment variable holding the token
+}
+
+// Token returns the tenant's API token, or "" to use the credentials of the environment
+func (t Tenant) Token() string {
+	if t.APIToken != "" {
+		return t.APIToken
+	}
+	if t.APITokenEnv != "" {
+		return os.Getenv(t.APITokenEnv)
+	}
+	return ""
+}
+
+// LoadTenants reads a tenants file. It is either a JSON array of tenant objects, or text
+// with one account ID per line, optionally followed by a comma and an API token. Blank
+// lines and lines starting with # are skipped.
+func LoadTenants(path string) ([]Tenant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+
+	var tenants []Tenant
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &tenants); err != nil {
+			return nil, fmt.Errorf("failed to parse accounts file: %w", err)
+		}
+	} else {
+		for _, line := range strings.Split(trimmed, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			accountID, token, _ := strings.Cut(line, ",")
+			tenants = append(tenants, Tenant{AccountID: strings.TrimSpace(accountID), APIToken: strings.TrimSpace(token)})
+		}
+	}
+
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("accounts file %s lists no accounts", path)
+	}
+
+	seen := make(map[string]bool, len(tenants))
+	for i, tenant := range tenants {
+		if !cloudflareIDPattern.MatchString(tenant.AccountID) {
+			return nil, fmt.Errorf("accounts file entry %d: account ID '%s' must be 32 hexadecimal characters", i+1, tenant.AccountID)
+		}
+		if seen[tenant.AccountID] {
+			return nil, fmt.Errorf("accounts file entry %d: account %s is listed twice", i+1, tenant.AccountID)
+		}
+		seen[tenant.AccountID] = true
+
+		if tenant.APITokenEnv != "" && os.Getenv(tenant.APITokenEnv) == "" {
+			return nil, fmt.Errorf("accounts file entry %d: environment variable %s is not set", i+1, tenant.APITokenEnv)
+		}
+	}
+
+	return tenants, nil
+}
@@ -0,0 +1,471 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultFileName is the name of the config file in the home directory
+const DefaultFileName = ".cache-kv-purger.json"
+
+var (
+	// cloudflareIDPattern matches zone, account and namespace IDs
+	cloudflareIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	// domainPattern matches a zone name such as example.com
+	domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+// ValidationIssue is a problem found in a config file
+type ValidationIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// String formats the issue for display
+func (i ValidationIssue) String() string {
+	if i.Field == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// DefaultPath returns the path of the config file in the home directory
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("cannot determine home directory for config file")
+	}
+	return filepath.Join(homeDir, DefaultFileName), nil
+}
+
+// ValidateData checks the contents of a config file: that it is JSON, that every field is
+// known and of the right type, and that the values are usable. The config is returned when
+// it could be decoded, even if some values are invalid.
+func ValidateData(data []byte) (*Config, []ValidationIssue) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, []ValidationIssue{{Message: describeJSONError(data, err)}}
+	}
+
+	var issues []ValidationIssue
+	known := fieldNames()
+	for name := range raw {
+		if _, ok := known[name]; !ok {
+			message := "unknown field"
+			if suggestion := closestFieldName(name); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			issues = append(issues, ValidationIssue{Field: name, Message: message})
+		}
+	}
+
+	cfg := New()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			issues = append(issues, ValidationIssue{Field: typeErr.Field, Message: describeTypeError(typeErr)})
+		} else {
+			issues = append(issues, ValidationIssue{Message: describeJSONError(data, err)})
+		}
+		sortIssues(issues)
+		return nil, issues
+	}
+
+	issues = append(issues, cfg.Validate()...)
+	sortIssues(issues)
+	return cfg, issues
+}
+
+// Validate checks the values of the config
+func (c *Config) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.APIEndpoint != "" {
+		if _, err := ValidateAPIEndpoint(c.APIEndpoint); err != nil {
+			add("api_endpoint", "%v", err)
+		}
+	}
+	if c.DefaultZone != "" && !cloudflareIDPattern.MatchString(c.DefaultZone) &&
+		!domainPattern.MatchString(strings.ToLower(c.DefaultZone)) {
+		add("default_zone", "%q is neither a 32 character zone ID nor a domain name", c.DefaultZone)
+	}
+	if c.AccountID != "" && !cloudflareIDPattern.MatchString(c.AccountID) {
+		add("account_id", "%q is not a 32 character hexadecimal account ID", c.AccountID)
+	}
+	if c.DefaultNamespace != "" && !cloudflareIDPattern.MatchString(c.DefaultNamespace) {
+		add("default_namespace", "%q is not a 32 character hexadecimal namespace ID", c.DefaultNamespace)
+	}
+
+	if c.CacheConcurrency < 0 || c.CacheConcurrency > DefaultMaxCacheConcurrency {
+		add("cache_concurrency", "must be between 0 (the default) and %d", DefaultMaxCacheConcurrency)
+	}
+	if c.MultiZoneConcurrency < 0 {
+		add("multi_zone_concurrency", "cannot be negative")
+	}
+	if c.MaxConcurrency < 0 {
+		add("max_concurrency", "cannot be negative")
+	}
+	if c.EstimateThreshold < 0 {
+		add("estimate_threshold", "cannot be negative")
+	}
+	if c.PurgeRate < 0 {
+		add("purge_rate", "cannot be negative")
+	}
+	if c.MetadataWorkers < 0 {
+		add("metadata_workers", "cannot be negative")
+	}
+	if c.NamespaceKeyLimit < 0 {
+		add("namespace_key_limit", "cannot be negative")
+	}
+	for field, value := range map[string]int{"retries": c.Retries, "backoff_ms": c.BackoffMS,
+		"timeout_seconds": c.TimeoutSeconds, "rate_limit": c.RateLimit} {
+		if value < 0 {
+			add(field, "cannot be negative")
+		}
+	}
+	if c.BatchSize < 0 || c.BatchSize > 10000 {
+		add("batch_size", "must be between 0 (the default) and 10000")
+	}
+
+	for i, namespace := range c.ProtectedNamespaces {
+		if strings.TrimSpace(namespace) == "" {
+			add(fmt.Sprintf("protected_namespaces[%d]", i), "cannot be empty")
+		}
+	}
+	ids, titles := c.NamespaceDefaultKeys()
+	for _, key := range append(ids, titles...) {
+		defaults := c.Namespaces[key]
+		field := fmt.Sprintf("namespaces[%q]", key)
+		switch {
+		case strings.TrimSpace(key) == "":
+			add(field, "a namespace ID or title is required")
+		case defaults.BatchSize < 0:
+			add(field+".batch_size", "cannot be negative")
+		case defaults.Concurrency < 0:
+			add(field+".concurrency", "cannot be negative")
+		case defaults.Empty():
+			add(field, "sets no options")
+		}
+	}
+	for i, zone := range c.ProtectedZones {
+		if strings.TrimSpace(zone) == "" {
+			add(fmt.Sprintf("protected_zones[%d]", i), "cannot be empty")
+		}
+	}
+	for i, rule := range c.TagExtractRules {
+		if rule.Field == "" && rule.Path == "" {
+			add(fmt.Sprintf("tag_extract_rules[%d]", i), "a field or path is required")
+		}
+	}
+	for i, pattern := range c.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add(fmt.Sprintf("redact_patterns[%d]", i), "%q is not a valid regular expression: %v", pattern, err)
+		}
+	}
+
+	return issues
+}
+
+// ValidateEnvironment checks the configuration environment variables that are set
+func ValidateEnvironment() []ValidationIssue {
+	var issues []ValidationIssue
+
+	if value := os.Getenv(EnvAPIEndpoint); value != "" {
+		if _, err := ValidateAPIEndpoint(value); err != nil {
+			issues = append(issues, ValidationIssue{Field: EnvAPIEndpoint, Message: err.Error()})
+		}
+	}
+	for _, name := range []string{EnvAccountID, EnvNamespaceID} {
+		if value := os.Getenv(name); value != "" && !cloudflareIDPattern.MatchString(value) {
+			issues = append(issues, ValidationIssue{Field: name, Message: fmt.Sprintf("%q is not a 32 character hexadecimal ID", value)})
+		}
+	}
+	if value := os.Getenv(EnvZoneID); value != "" && !cloudflareIDPattern.MatchString(value) &&
+		!domainPattern.MatchString(strings.ToLower(value)) {
+		issues = append(issues, ValidationIssue{Field: EnvZoneID, Message: fmt.Sprintf("%q is neither a zone ID nor a domain name", value)})
+	}
+	for _, name := range []string{EnvCacheConcurrency, EnvMultiZoneConcurrency, EnvMaxConcurrency, EnvPurgeRate, EnvMetadataWorkers,
+		EnvRetryCount, EnvBackoffDelay, EnvAPITimeout, EnvRateLimit, EnvBatchSize} {
+		if value := os.Getenv(name); value != "" {
+			if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+				issues = append(issues, ValidationIssue{Field: name, Message: fmt.Sprintf("%q is not a positive number and is ignored", value)})
+			}
+		}
+	}
+
+	return issues
+}
+
+// FieldNames returns the names of the fields in the config file, sorted
+func FieldNames() []string {
+	known := fieldNames()
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetField returns the value of a config file field as a string; lists are comma-separated
+func (c *Config) GetField(name string) (string, error) {
+	switch name {
+	case "api_endpoint":
+		return c.APIEndpoint, nil
+	case "default_zone":
+		return c.DefaultZone, nil
+	case "account_id":
+		return c.AccountID, nil
+	case "default_namespace":
+		return c.DefaultNamespace, nil
+	case "cache_concurrency":
+		return strconv.Itoa(c.CacheConcurrency), nil
+	case "multi_zone_concurrency":
+		return strconv.Itoa(c.MultiZoneConcurrency), nil
+	case "max_concurrency":
+		return strconv.Itoa(c.MaxConcurrency), nil
+	case "estimate_threshold":
+		return strconv.Itoa(c.EstimateThreshold), nil
+	case "purge_rate":
+		return strconv.Itoa(c.PurgeRate), nil
+	case "metadata_workers":
+		return strconv.Itoa(c.MetadataWorkers), nil
+	case "namespace_key_limit":
+		return strconv.Itoa(c.NamespaceKeyLimit), nil
+	case "retries":
+		return strconv.Itoa(c.Retries), nil
+	case "backoff_ms":
+		return strconv.Itoa(c.BackoffMS), nil
+	case "timeout_seconds":
+		return strconv.Itoa(c.TimeoutSeconds), nil
+	case "rate_limit":
+		return strconv.Itoa(c.RateLimit), nil
+	case "batch_size":
+		return strconv.Itoa(c.BatchSize), nil
+	case "protected_namespaces":
+		return strings.Join(c.ProtectedNamespaces, ","), nil
+	case "protected_zones":
+		return strings.Join(c.ProtectedZones, ","), nil
+	case "redact_patterns":
+		return strings.Join(c.RedactPatterns, "\n"), nil
+	case "tag_extract_rules":
+		rules := make([]string, len(c.TagExtractRules))
+		for i, rule := range c.TagExtractRules {
+			rules[i] = rule.String()
+		}
+		return strings.Join(rules, "\n"), nil
+	}
+	return "", unknownFieldError(name)
+}
+
+// SetField sets a config file field from strings. List fields take one value per entry;
+// other fields take exactly one value, and an empty value clears the field. The new value
+// is validated before it is set. Tag extraction rules are parsed by the caller.
+func (c *Config) SetField(name string, values ...string) error {
+	switch name {
+	case "protected_namespaces", "protected_zones":
+		var list []string
+		for _, value := range values {
+			for _, entry := range strings.Split(value, ",") {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					list = append(list, entry)
+				}
+			}
+		}
+		if name == "protected_namespaces" {
+			c.ProtectedNamespaces = list
+		} else {
+			c.ProtectedZones = list
+		}
+		return nil
+	case "redact_patterns":
+		// Patterns may contain commas, so each value is one pattern
+		var patterns []string
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				if _, err := regexp.Compile(value); err != nil {
+					return fmt.Errorf("redact_patterns: %q is not a valid regular expression: %v", value, err)
+				}
+				patterns = append(patterns, value)
+			}
+		}
+		c.RedactPatterns = patterns
+		return nil
+	case "tag_extract_rules":
+		return fmt.Errorf("tag_extract_rules must be parsed with the rule syntax")
+	}
+
+	if _, ok := fieldNames()[name]; !ok {
+		return unknownFieldError(name)
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%s takes exactly one value", name)
+	}
+
+	updated := *c
+	value := strings.TrimSpace(values[0])
+	switch name {
+	case "api_endpoint":
+		updated.APIEndpoint = strings.TrimRight(value, "/")
+	case "default_zone":
+		updated.DefaultZone = value
+	case "account_id":
+		updated.AccountID = value
+	case "default_namespace":
+		updated.DefaultNamespace = value
+	case "cache_concurrency", "multi_zone_concurrency", "max_concurrency", "estimate_threshold", "purge_rate",
+		"metadata_workers", "namespace_key_limit", "retries", "backoff_ms", "timeout_seconds", "rate_limit", "batch_size":
+		n := 0
+		if value != "" {
+			var err error
+			if n, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("%s must be a number, not %q", name, value)
+			}
+		}
+		switch name {
+		case "cache_concurrency":
+			updated.CacheConcurrency = n
+		case "multi_zone_concurrency":
+			updated.MultiZoneConcurrency = n
+		case "estimate_threshold":
+			updated.EstimateThreshold = n
+		case "purge_rate":
+			updated.PurgeRate = n
+		case "metadata_workers":
+			updated.MetadataWorkers = n
+		case "namespace_key_limit":
+			updated.NamespaceKeyLimit = n
+		case "retries":
+			updated.Retries = n
+		case "backoff_ms":
+			updated.BackoffMS = n
+		case "timeout_seconds":
+			updated.TimeoutSeconds = n
+		case "rate_limit":
+			updated.RateLimit = n
+		case "batch_size":
+			updated.BatchSize = n
+		default:
+			updated.MaxConcurrency = n
+		}
+	}
+
+	for _, issue := range updated.Validate() {
+		if issue.Field == name {
+			return errors.New(issue.String())
+		}
+	}
+	*c = updated
+	return nil
+}
+
+// fieldNames returns the JSON names of the persisted config fields
+func fieldNames() map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, name := range []string{
+		"api_endpoint", "default_zone", "account_id", "default_namespace",
+		"cache_concurrency", "multi_zone_concurrency", "max_concurrency", "estimate_threshold", "purge_rate",
+		"metadata_workers", "namespace_key_limit", "retries", "backoff_ms", "timeout_seconds", "rate_limit", "batch_size",
+		"protected_namespaces", "protected_zones", "tag_extract_rules", "redact_patterns",
+	} {
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+// unknownFieldError reports a field that is not in the config file
+func unknownFieldError(name string) error {
+	message := fmt.Sprintf("unknown config field %q", name)
+	if suggestion := closestFieldName(name); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	return fmt.Errorf("%s; fields are: %s", message, strings.Join(FieldNames(), ", "))
+}
+
+// closestFieldName returns the known field nearest to name, if it is close enough to be a typo
+func closestFieldName(name string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	best, bestDistance := "", 4
+	for _, known := range FieldNames() {
+		if d := editDistance(normalized, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// describeJSONError adds the line and column to a JSON syntax error and names the field
+// of a type error
+func describeJSONError(data []byte, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Sprintf("%s %s", typeErr.Field, describeTypeError(typeErr))
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset := int(syntaxErr.Offset)
+		if offset > len(data) {
+			offset = len(data)
+		}
+		line := bytes.Count(data[:offset], []byte("\n")) + 1
+		column := offset - bytes.LastIndexByte(data[:offset], '\n')
+		return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, column, err)
+	}
+	return fmt.Sprintf("invalid JSON: %v", err)
+}
+
+// describeTypeError explains a value of the wrong type
+func describeTypeError(err *json.UnmarshalTypeError) string {
+	return fmt.Sprintf("must be %s, not %s", describeType(err.Type.String()), err.Value)
+}
+
+// describeType names a Go type the way the config file spells it
+func describeType(goType string) string {
+	switch {
+	case goType == "int":
+		return "a number"
+	case goType == "string":
+		return "a string"
+	case strings.HasPrefix(goType, "[]"):
+		return "a list"
+	}
+	return "an object"
+}
+
+// sortIssues orders issues by field
+func sortIssues(issues []ValidationIssue) {
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Field < issues[j].Field })
+}
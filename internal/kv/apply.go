@@ -0,0 +1,142 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// ApplyOptions configures reconciling a namespace with a desired set of keys
+type ApplyOptions struct {
+	Prefix      string // Only keys with this prefix are managed; desired keys must have it
+	Prune       bool   // Delete managed keys that are not in the desired set
+	Concurrency int    // Concurrent value requests when comparing existing keys
+	BatchSize   int    // Batch size for bulk writes and deletes
+}
+
+// ApplyPlan is the set of changes that makes a namespace match the desired keys
+type ApplyPlan struct {
+	Create    []BulkWriteItem `json:"create"`
+	Update    []BulkWriteItem `json:"update"`
+	Delete    []string        `json:"delete"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// HasChanges returns true if applying the plan would change the namespace
+func (p *ApplyPlan) HasChanges() bool {
+	return len(p.Create) > 0 || len(p.Update) > 0 || len(p.Delete) > 0
+}
+
+// ApplyResult reports the changes made by applying a plan
+type ApplyResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// PlanApply compares the desired keys with the keys of a namespace and returns the
+// minimal writes and deletes that make them match. Desired keys that don't exist are
+// created; existing keys are updated only if their value, metadata or absolute expiration
+// differs, so values are only fetched for keys that exist in both. With Prune, existing
+// keys under the prefix that are not desired are deleted.
+func PlanApply(client *api.Client, accountID, namespaceID string, desired []BulkWriteItem, options ApplyOptions) (*ApplyPlan, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+
+	byKey := make(map[string]BulkWriteItem, len(desired))
+	for _, item := range desired {
+		if item.Key == "" {
+			return nil, fmt.Errorf("desired keys must have a name")
+		}
+		if !strings.HasPrefix(item.Key, options.Prefix) {
+			return nil, fmt.Errorf("desired key '%s' does not have the prefix '%s'", item.Key, options.Prefix)
+		}
+		if _, dup := byKey[item.Key]; dup {
+			return nil, fmt.Errorf("desired key '%s' is listed more than once", item.Key)
+		}
+		byKey[item.Key] = item
+	}
+
+	// The listing gives metadata and expiration without a request per key
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.Prefix}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	existing := make(map[string]KeyValuePair, len(keys))
+	for _, key := range keys {
+		existing[key.Key] = key
+	}
+
+	plan := &ApplyPlan{Create: []BulkWriteItem{}, Update: []BulkWriteItem{}, Delete: []string{}}
+	var compare []BulkWriteItem
+	for _, item := range desired {
+		current, ok := existing[item.Key]
+		switch {
+		case !ok:
+			plan.Create = append(plan.Create, item)
+		case item.Expiration != 0 && item.Expiration != current.Expiration:
+			plan.Update = append(plan.Update, item)
+		default:
+			compare = append(compare, item)
+		}
+	}
+
+	if len(compare) > 0 {
+		changed, err := changedItems(client, accountID, namespaceID, compare, existing, options.Concurrency)
+		if err != nil {
+			return nil, err
+		}
+		for n, item := range compare {
+			if changed[n] {
+				plan.Update = append(plan.Update, item)
+			} else {
+				plan.Unchanged++
+			}
+		}
+	}
+
+	if options.Prune {
+		for _, key := range keys {
+			if _, ok := byKey[key.Key]; !ok {
+				plan.Delete = append(plan.Delete, key.Key)
+			}
+		}
+	}
+
+	sort.Slice(plan.Create, func(i, j int) bool { return plan.Create[i].Key < plan.Create[j].Key })
+	sort.Slice(plan.Update, func(i, j int) bool { return plan.Update[i].Key < plan.Update[j].Key })
+	sort.Strings(plan.Delete)
+	return plan, nil
+}
+
+// ExecuteApply writes the created and updated keys of a plan with the bulk API, then
+// deletes the pruned keys
+func ExecuteApply(client *api.Client, accountID, namespaceID string, plan *ApplyPlan, options ApplyOptions) (*ApplyResult, error) {
+	result := &ApplyResult{}
+
+	writes := make([]BulkWriteItem, 0, len(plan.Create)+len(plan.Update))
+	writes = append(writes, plan.Create...)
+	writes = append(writes, plan.Update...)
+	if len(writes) > 0 {
+		if _, err := WriteMultipleValuesInBatches(client, accountID, namespaceID, writes, options.BatchSize, nil); err != nil {
+			return result, fmt.Errorf("failed to write keys: %w", err)
+		}
+		result.Created = len(plan.Create)
+		result.Updated = len(plan.Update)
+	}
+
+	if len(plan.Delete) > 0 {
+		if err := DeleteMultipleValuesInBatches(client, accountID, namespaceID, plan.Delete, options.BatchSize, nil); err != nil {
+			return result, fmt.Errorf("failed to delete keys: %w", err)
+		}
+		result.Deleted = len(plan.Delete)
+	}
+
+	return result, nil
+}
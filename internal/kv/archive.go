@@ -0,0 +1,58 @@
+package kv
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+)
+
+// ArchiveOptions configures copying keys to an archive namespace before they are deleted
+type ArchiveOptions struct {
+	TTL         int64 // Seconds archived keys are kept, 0 to keep them until deleted
+	BatchSize   int
+	Concurrency int
+}
+
+// ArchiveKeys copies the value and metadata of keys from a namespace to an archive
+// namespace, so deleting them from the source can be reversed by copying them back.
+// Keys that no longer exist are skipped. Expirations are not carried over: archived
+// keys expire after options.TTL, or never. Returns the number of keys archived.
+func ArchiveKeys(client *api.Client, accountID, namespaceID, archiveNamespaceID string, keys []string, options ArchiveOptions) (int, error) {
+	if archiveNamespaceID == "" {
+		return 0, fmt.Errorf("archive namespace ID is required")
+	}
+	if archiveNamespaceID == namespaceID {
+		return 0, fmt.Errorf("archive namespace must differ from the namespace keys are deleted from")
+	}
+	if options.TTL > 0 && options.TTL < MinExpirationTTL {
+		return 0, fmt.Errorf("archive TTL must be at least %d seconds", MinExpirationTTL)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// Read the keys the same way a journal does, including binary values
+	snapshot, err := CaptureJournal(client, accountID, namespaceID, "archive", keys, options.Concurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]BulkWriteItem, 0, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		if !entry.Existed || entry.Before == nil {
+			continue
+		}
+		item := *entry.Before
+		item.ExpirationTTL = options.TTL
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	written, err := WriteMultipleValuesInBatches(client, accountID, archiveNamespaceID, items, options.BatchSize, nil)
+	if err != nil {
+		return written, fmt.Errorf("failed to write keys to the archive namespace: %w", err)
+	}
+	return written, nil
+}
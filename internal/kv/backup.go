@@ -0,0 +1,309 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// BackupManifestFile is the name of the manifest written into a backup directory
+const BackupManifestFile = "manifest.json"
+
+// backupManifestVersion is the manifest format written by BackupAccount
+const backupManifestVersion = 1
+
+// BackupManifest describes the namespaces stored in a backup directory
+type BackupManifest struct {
+	Version    int               `json:"version"`
+	CreatedAt  time.Time         `json:"created_at"`
+	AccountID  string            `json:"account_id"`
+	Namespaces []BackupNamespace `json:"namespaces"`
+}
+
+// BackupNamespace is one namespace in a backup
+type BackupNamespace struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	File  string `json:"file"` // Export file, relative to the backup directory
+	Keys  int    `json:"keys"`
+
+	// Failed lists the keys left out because reading them failed
+	Failed []ExportFailure `json:"failed,omitempty"`
+}
+
+// BackupOptions configures an account backup
+type BackupOptions struct {
+	Concurrency      int // Namespaces exported in parallel
+	ValueConcurrency int // Concurrent value requests per namespace
+}
+
+// BackupAccount exports every namespace of an account into dir, one JSON file per namespace
+// in the same format as 'kv export', and writes a manifest listing them. The manifest only
+// lists namespaces that were exported successfully.
+func BackupAccount(client *api.Client, accountID, dir string, options BackupOptions,
+	progressCallback func(namespace BackupNamespace, err error)) (*BackupManifest, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 3
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	namespaces, err := ListNamespaces(client, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	manifest := &BackupManifest{
+		Version:    backupManifestVersion,
+		CreatedAt:  time.Now().UTC(),
+		AccountID:  accountID,
+		Namespaces: []BackupNamespace{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed []string
+	sem := make(chan struct{}, options.Concurrency)
+
+	for _, ns := range namespaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ns Namespace) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry := BackupNamespace{ID: ns.ID, Title: ns.Title, File: ns.ID + ".json"}
+			err := backupNamespace(client, accountID, filepath.Join(dir, entry.File), &entry, options.ValueConcurrency)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s (%s): %v", ns.Title, ns.ID, err))
+			} else {
+				manifest.Namespaces = append(manifest.Namespaces, entry)
+			}
+			if progressCallback != nil {
+				progressCallback(entry, err)
+			}
+		}(ns)
+	}
+	wg.Wait()
+
+	sort.Slice(manifest.Namespaces, func(i, j int) bool {
+		return manifest.Namespaces[i].Title < manifest.Namespaces[j].Title
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, BackupManifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if len(failed) > 0 {
+		return manifest, fmt.Errorf("failed to back up %d of %d namespaces: %s", len(failed), len(namespaces), failed[0])
+	}
+
+	return manifest, nil
+}
+
+// backupNamespace exports one namespace to a file
+func backupNamespace(client *api.Client, accountID, path string, entry *BackupNamespace, concurrency int) error {
+	result, err := ExportKeys(client, accountID, entry.ID, ExportOptions{
+		IncludeMetadata: true,
+		Concurrency:     concurrency,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(result.Items, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	entry.Keys = len(result.Items)
+	entry.Failed = result.Failed
+	return nil
+}
+
+// LoadBackupManifest reads the manifest of a backup directory
+func LoadBackupManifest(dir string) (*BackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Version > backupManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+
+	return &manifest, nil
+}
+
+// RestoreOptions configures an account restore
+type RestoreOptions struct {
+	Concurrency int  // Namespaces restored in parallel
+	BatchSize   int  // Batch size for bulk writes
+	DryRun      bool // Only report what would be restored
+}
+
+// RestoredNamespace is the outcome of restoring one namespace
+type RestoredNamespace struct {
+	Title    string `json:"title"`
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id,omitempty"`
+	Created  bool   `json:"created"`
+	Keys     int    `json:"keys"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RestoreAccount re-creates the namespaces of a backup in an account and reloads their keys.
+// Namespaces are matched by title: an existing namespace with the same title is reused and
+// its keys are overwritten, otherwise the namespace is created.
+func RestoreAccount(client *api.Client, accountID, dir string, options RestoreOptions,
+	progressCallback func(result RestoredNamespace)) ([]RestoredNamespace, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 3
+	}
+
+	manifest, err := LoadBackupManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ListNamespaces(client, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	existingByTitle := make(map[string]string, len(existing))
+	for _, ns := range existing {
+		existingByTitle[ns.Title] = ns.ID
+	}
+
+	results := make([]RestoredNamespace, len(manifest.Namespaces))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, options.Concurrency)
+
+	for i, entry := range manifest.Namespaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, entry BackupNamespace) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := RestoredNamespace{Title: entry.Title, SourceID: entry.ID, TargetID: existingByTitle[entry.Title]}
+			if err := restoreNamespace(client, accountID, filepath.Join(dir, entry.File), &result, options); err != nil {
+				result.Error = err.Error()
+			}
+
+			results[i] = result
+			if progressCallback != nil {
+				progressCallback(result)
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("failed to restore %d of %d namespaces", failed, len(results))
+	}
+
+	return results, nil
+}
+
+// restoreNamespace loads one export file into its target namespace, creating it if needed.
+// Values too large for KV are written as chunks, and keys that replaced split values in an
+// existing namespace have the chunks they no longer use deleted.
+func restoreNamespace(client *api.Client, accountID, path string, result *RestoredNamespace, options RestoreOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	var items []BulkWriteItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("failed to parse export file: %w", err)
+	}
+	result.Keys = len(items)
+
+	if result.TargetID == "" {
+		result.Created = true
+		if options.DryRun {
+			return nil
+		}
+
+		ns, err := CreateNamespace(client, accountID, result.Title)
+		if err != nil {
+			return fmt.Errorf("failed to create namespace: %w", err)
+		}
+		result.TargetID = ns.ID
+	}
+
+	if options.DryRun {
+		return nil
+	}
+
+	fitting, oversized := SplitOversizedItems(items)
+
+	var stored map[string][]string
+	if !result.Created && len(fitting) > 0 {
+		names := make([]string, len(fitting))
+		for i, item := range fitting {
+			names[i] = item.Key
+		}
+		if stored, err = StoredChunkKeysOf(context.Background(), client, accountID, result.TargetID, names, 0); err != nil {
+			return err
+		}
+	}
+
+	written, err := WriteMultipleValuesInBatches(client, accountID, result.TargetID, fitting, options.BatchSize, nil)
+	result.Keys = written
+	if err != nil {
+		return fmt.Errorf("failed to write keys: %w", err)
+	}
+	for _, item := range fitting {
+		if err := DeleteStaleChunks(client, accountID, result.TargetID, item.Key, stored[item.Key], 0); err != nil {
+			return err
+		}
+	}
+	for _, item := range oversized {
+		if _, err := WriteChunkedItem(client, accountID, result.TargetID, item); err != nil {
+			return fmt.Errorf("failed to write key '%s': %w", item.Key, err)
+		}
+		result.Keys++
+	}
+
+	return nil
+}
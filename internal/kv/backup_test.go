@@ -0,0 +1,65 @@
+package kv
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestoreBinaryAndChunkedValues(t *testing.T) {
+	src, srcClient := newFakeNamespace(t)
+	dst, dstClient := newFakeNamespace(t)
+	src.listMetadata = true
+	dst.listMetadata = true
+	const account = "account"
+
+	binary := "\x89PNG\r\n\x1a\n\xff\xfe"
+	src.values["logo"] = binary
+	large := strings.Repeat("x", MaxValueSize+10)
+	if _, err := WriteChunkedValue(srcClient, account, "src", "large", large, nil, MaxValueSize); err != nil {
+		t.Fatal(err)
+	}
+	src.values["notes"] = "short"
+
+	// The restore target holds a split value under a key the backup overwrites with a small one
+	if _, err := WriteChunkedValue(dstClient, account, "dst", "notes", "0123456789", nil, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "src.json")
+	entry := BackupNamespace{ID: "src", Title: "assets"}
+	if err := backupNamespace(srcClient, account, path, &entry, 0); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Keys != 3 {
+		t.Errorf("backed up %d keys, want 3", entry.Keys)
+	}
+
+	result := RestoredNamespace{Title: "assets", SourceID: "src", TargetID: "dst"}
+	if err := restoreNamespace(dstClient, account, path, &result, RestoreOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if result.Keys != 3 {
+		t.Errorf("restored %d keys, want 3", result.Keys)
+	}
+
+	if got := dst.values["logo"]; got != binary {
+		t.Errorf("binary value = %q, want %q", got, binary)
+	}
+	if got := dst.values["notes"]; got != "short" {
+		t.Errorf("notes = %q, want short", got)
+	}
+
+	// The large value is split again, and the replaced split value leaves no chunks behind
+	want := "large,large#chunk-0000,large#chunk-0001,logo,notes"
+	if got := strings.Join(dst.keys(), ","); got != want {
+		t.Errorf("restored keys = %s, want %s", got, want)
+	}
+	value, err := ReadChunkedValue(dstClient, account, "dst", "large", dst.values["large"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != large {
+		t.Errorf("large value has %d bytes, want %d", len(value), len(large))
+	}
+}
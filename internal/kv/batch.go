@@ -2,6 +2,8 @@ package kv
 
 import (
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"errors"
 	"fmt"
 	"sync"
 )
@@ -13,11 +15,7 @@ func WriteMultipleValuesInBatches(client *api.Client, accountID, namespaceID str
 		return 0, nil
 	}
 
-	if batchSize <= 0 {
-		batchSize = 10000 // Maximum batch size supported by API
-	} else if batchSize > 10000 {
-		batchSize = 10000 // Max batch size supported by API
-	}
+	batchSize = common.CurrentPolicy().Batch(batchSize)
 
 	totalSuccess := 0
 	totalItems := len(items)
@@ -57,19 +55,10 @@ func WriteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 		return 0, nil
 	}
 
-	if batchSize <= 0 {
-		batchSize = 10000 // Maximum batch size supported by API
-	} else if batchSize > 10000 {
-		batchSize = 10000 // Max batch size supported by API
-	}
+	batchSize = common.CurrentPolicy().Batch(batchSize)
 
-	// Set reasonable concurrency
-	if concurrency <= 0 {
-		concurrency = 20 // Default concurrency
-	}
-	if concurrency > 100 {
-		concurrency = 100 // Cap concurrency to avoid overwhelming API
-	}
+	// Set reasonable concurrency, capped by the policy
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
 	// Simple progress reporting if none provided
 	if progressCallback == nil {
@@ -209,9 +198,7 @@ func DeleteMultipleValuesInBatches(client *api.Client, accountID, namespaceID st
 		return nil
 	}
 
-	if batchSize <= 0 {
-		batchSize = 10000 // Maximum batch size supported by API
-	}
+	batchSize = common.CurrentPolicy().Batch(batchSize)
 
 	totalItems := len(keys)
 
@@ -246,17 +233,10 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 		return 0, nil
 	}
 
-	if batchSize <= 0 {
-		batchSize = 10000 // Maximum batch size supported by API
-	}
+	batchSize = common.CurrentPolicy().Batch(batchSize)
 
-	// Set reasonable concurrency
-	if concurrency <= 0 {
-		concurrency = 20 // Default concurrency
-	}
-	if concurrency > 100 {
-		concurrency = 100 // Cap concurrency to avoid overwhelming API
-	}
+	// Set reasonable concurrency, capped by the policy
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
 	// Simple progress reporting if none provided
 	if progressCallback == nil {
@@ -286,12 +266,13 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 	}
 
 	// Verbose logging about how many batches we're creating
-	fmt.Printf("[DEBUG] Created %d batches for %d keys with batch size %d\n", len(batches), totalItems, batchSize)
+	common.LogDebug("Created %d batches for %d keys with batch size %d", len(batches), totalItems, batchSize)
 
 	// Create a result channel for completed batches
 	type batchResult struct {
 		batchIndex int
 		success    bool
+		deleted    int // Keys deleted from a batch that failed in part
 		err        error
 	}
 
@@ -309,23 +290,29 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 		go func(b batchWork) {
 			defer func() { <-sem }() // Release semaphore when done
 
-			fmt.Printf("[VERBOSE] Processing batch %d with %d keys\n", b.batchIndex+1, len(b.batchItems))
+			common.LogVerbose("Processing batch %d with %d keys", b.batchIndex+1, len(b.batchItems))
 
 			// Delete this batch
 			err := DeleteMultipleValues(client, accountID, namespaceID, b.batchItems)
 
 			// Send result back through channel
 			if err != nil {
-				fmt.Printf("[ERROR] Batch %d failed: %v\n", b.batchIndex+1, err)
+				common.LogError("Batch %d failed: %v", b.batchIndex+1, err)
+				deleted := 0
+				var bulkErr *BulkDeleteError
+				if errors.As(err, &bulkErr) {
+					deleted = bulkErr.Deleted
+				}
 				resultChan <- batchResult{
 					batchIndex: b.batchIndex,
 					success:    false,
+					deleted:    deleted,
 					err:        fmt.Errorf("batch %d failed: %w", b.batchIndex+1, err),
 				}
 				return
 			}
 
-			fmt.Printf("[VERBOSE] Batch %d completed successfully\n", b.batchIndex+1)
+			common.LogVerbose("Batch %d completed successfully", b.batchIndex+1)
 			resultChan <- batchResult{
 				batchIndex: b.batchIndex,
 				success:    true,
@@ -336,7 +323,7 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 
 	// Collect results
 	successCount := 0
-	var errors []error
+	var errs []error
 
 	// Track progress for callback
 	completed := 0
@@ -349,7 +336,8 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 		if result.success {
 			successCount += len(batches[result.batchIndex].batchItems)
 		} else if result.err != nil {
-			errors = append(errors, result.err)
+			successCount += result.deleted
+			errs = append(errs, result.err)
 		}
 
 		// Update progress
@@ -357,8 +345,8 @@ func DeleteMultipleValuesConcurrently(client *api.Client, accountID, namespaceID
 
 		// Call progress callback
 		progressCallback(completed, len(batches))
-		fmt.Printf("[DEBUG] Completed %d/%d batches, success count: %d\n", completed, len(batches), successCount)
+		common.LogDebug("Completed %d/%d batches, success count: %d", completed, len(batches), successCount)
 	}
 
-	return successCount, errors
+	return successCount, errs
 }
@@ -18,13 +18,10 @@ func WriteMultipleValuesGeneric(ctx context.Context, client *api.Client, account
 		return 0, nil
 	}
 
-	// Set defaults
-	if batchSize <= 0 {
-		batchSize = 100 // Cloudflare limit
-	}
-	if concurrency <= 0 {
-		concurrency = 10
-	}
+	// Set defaults, capped by the policy
+	policy := common.CurrentPolicy()
+	batchSize = policy.Batch(batchSize)
+	concurrency = policy.Concurrency(concurrency, 10)
 
 	// Track total successful writes
 	var successCount int32
@@ -114,13 +111,10 @@ func DeleteMultipleValuesGeneric(ctx context.Context, client *api.Client, accoun
 		return 0, nil
 	}
 
-	// Set defaults
-	if batchSize <= 0 {
-		batchSize = 100
-	}
-	if concurrency <= 0 {
-		concurrency = 10
-	}
+	// Set defaults, capped by the policy
+	policy := common.CurrentPolicy()
+	batchSize = policy.Batch(batchSize)
+	concurrency = policy.Concurrency(concurrency, 10)
 
 	// Track total successful deletes
 	var successCount int32
@@ -192,10 +186,9 @@ func FetchMetadataGeneric(ctx context.Context, client *api.Client, accountID, na
 		return make(map[string]*KeyValueMetadata), nil
 	}
 
-	// Set defaults
-	if concurrency <= 0 {
-		concurrency = 50
-	}
+	// Use the policy's concurrency if not specified, capped by the policy
+	policy := common.CurrentPolicy()
+	concurrency = policy.Concurrency(concurrency, policy.MaxConcurrency)
 
 	// Result map
 	results := make(map[string]*KeyValueMetadata)
@@ -270,13 +263,10 @@ func PurgeByMetadataGeneric(ctx context.Context, client *api.Client, accountID,
 		return 0, nil
 	}
 
-	// Set defaults
-	if batchSize <= 0 {
-		batchSize = 100
-	}
-	if concurrency <= 0 {
-		concurrency = 50
-	}
+	// Use the policy's batch size and concurrency if not specified, capped by the policy
+	policy := common.CurrentPolicy()
+	batchSize = policy.Batch(batchSize)
+	concurrency = policy.Concurrency(concurrency, policy.MaxConcurrency)
 
 	// Track progress
 	var checked, matched, deleted int32
@@ -356,20 +346,16 @@ func CheckMetadataMatch(metadata *KeyValueMetadata, field, value string) bool {
 	// Convert to map for easier access
 	metadataMap := map[string]interface{}(*metadata)
 
-	// Check for exact match
-	if val, exists := metadataMap[field]; exists {
-		if strVal, ok := val.(string); ok && strVal == value {
-			return true
-		}
+	// Check the field, or a dot path into nested objects
+	if common.MetadataFieldMatches(metadataMap, field, value) {
+		return true
 	}
 
 	// Check nested fields (simplified)
 	for _, v := range metadataMap {
 		if nestedMap, ok := v.(map[string]interface{}); ok {
-			if val, exists := nestedMap[field]; exists {
-				if strVal, ok := val.(string); ok && strVal == value {
-					return true
-				}
+			if common.MetadataFieldMatches(nestedMap, field, value) {
+				return true
 			}
 		}
 	}
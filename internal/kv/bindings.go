@@ -0,0 +1,76 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// WorkerBinding is a KV namespace binding of a Worker script
+type WorkerBinding struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	NamespaceID string `json:"namespace_id"`
+}
+
+// workerSettings represents the settings of a Worker script
+type workerSettings struct {
+	Bindings []WorkerBinding `json:"bindings"`
+}
+
+// ListWorkerKVBindings lists the KV namespace bindings of a Worker script
+func ListWorkerKVBindings(client *api.Client, accountID, scriptName string) ([]WorkerBinding, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if scriptName == "" {
+		return nil, fmt.Errorf("worker script name is required")
+	}
+
+	path := fmt.Sprintf("/accounts/%s/workers/scripts/%s/settings", accountID, url.PathEscape(scriptName))
+
+	settings, err := api.RequestJSON[workerSettings](context.Background(), client, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings for worker '%s': %w", scriptName, err)
+	}
+
+	var bindings []WorkerBinding
+	for _, binding := range settings.Bindings {
+		if binding.Type == "kv_namespace" {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Name < bindings[j].Name
+	})
+
+	return bindings, nil
+}
+
+// ResolveWorkerBinding returns the namespace ID bound to a binding name in a Worker script
+func ResolveWorkerBinding(client *api.Client, accountID, scriptName, bindingName string) (string, error) {
+	bindings, err := ListWorkerKVBindings(client, accountID, scriptName)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(bindings))
+	for i, binding := range bindings {
+		if binding.Name == bindingName {
+			return binding.NamespaceID, nil
+		}
+		names[i] = binding.Name
+	}
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("worker '%s' has no KV namespace bindings", scriptName)
+	}
+	return "", fmt.Errorf("worker '%s' has no KV binding named '%s' (available: %s)",
+		scriptName, bindingName, strings.Join(names, ", "))
+}
@@ -0,0 +1,80 @@
+package kv
+
+import (
+	"fmt"
+
+	"cache-kv-purger/internal/api"
+)
+
+// keyLimitWarnRatio is the share of the key limit above which a write is warned about
+const keyLimitWarnRatio = 0.9
+
+// KeyCapacity is how many keys a namespace will hold after a bulk write
+type KeyCapacity struct {
+	Current int // Keys in the namespace now
+	Writes  int // Keys to write
+	New     int // Keys to write that don't exist yet
+	Limit   int
+}
+
+// Projected returns the number of keys in the namespace after the write
+func (c KeyCapacity) Projected() int {
+	return c.Current + c.New
+}
+
+// Exceeded returns true if the write would take the namespace over the limit
+func (c KeyCapacity) Exceeded() bool {
+	return c.Projected() > c.Limit
+}
+
+// NearLimit returns true if the write would fill most of the limit
+func (c KeyCapacity) NearLimit() bool {
+	return float64(c.Projected()) > keyLimitWarnRatio*float64(c.Limit)
+}
+
+// Err returns an error describing the overrun when the write would exceed the limit
+func (c KeyCapacity) Err() error {
+	if !c.Exceeded() {
+		return nil
+	}
+	return fmt.Errorf("writing %d keys (%d new) would take the namespace from %d to %d keys, over the limit of %d keys; "+
+		"delete keys first, write fewer keys or raise namespace_key_limit in the config",
+		c.Writes, c.New, c.Current, c.Projected(), c.Limit)
+}
+
+// CheckKeyCapacity counts the keys of a namespace and the keys that a write would add.
+// Written keys are assumed to be new while that keeps the namespace clear of the limit;
+// closer to the limit, the keys under their common prefix are listed so that keys which
+// would only be overwritten are not counted.
+func CheckKeyCapacity(client *api.Client, accountID, namespaceID string, keys []string, limit int) (KeyCapacity, error) {
+	capacity := KeyCapacity{Writes: len(keys), New: len(keys), Limit: limit}
+
+	current, err := CountAllKeys(client, accountID, namespaceID, "")
+	if err != nil {
+		return capacity, fmt.Errorf("failed to count keys in the namespace: %w", err)
+	}
+	capacity.Current = current
+	if !capacity.NearLimit() || len(keys) == 0 {
+		return capacity, nil
+	}
+
+	existing, err := ListAllKeysWithOptions(client, accountID, namespaceID,
+		&ListKeysOptions{Prefix: commonKeyPrefix(keys)}, nil)
+	if err != nil {
+		return capacity, fmt.Errorf("failed to list existing keys: %w", err)
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		exists[key.Key] = true
+	}
+
+	capacity.New = 0
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !exists[key] && !seen[key] {
+			capacity.New++
+		}
+		seen[key] = true
+	}
+	return capacity, nil
+}
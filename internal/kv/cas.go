@@ -0,0 +1,135 @@
+package kv
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// CASCondition is the state a key must be in for a conditional write. KV has no native
+// compare-and-swap, so the condition is checked by reading the key just before writing.
+type CASCondition struct {
+	ValueSHA256    string    // Hex SHA-256 the current value must have ("" skips the check)
+	UnchangedSince time.Time // The key's SinceField timestamp must not be after this (zero skips the check)
+	SinceField     string    // Metadata field holding the modification time (default "updated_at")
+}
+
+// IsSet returns true if the condition checks anything
+func (c CASCondition) IsSet() bool {
+	return c.ValueSHA256 != "" || !c.UnchangedSince.IsZero()
+}
+
+// CASConflictError reports that a key was not in the expected state
+type CASConflictError struct {
+	Key    string
+	Reason string
+}
+
+// Error describes the conflict
+func (e *CASConflictError) Error() string {
+	return fmt.Sprintf("key '%s' was not written: %s", e.Key, e.Reason)
+}
+
+// CASResult describes a conditional write
+type CASResult struct {
+	Attempts    int    // Number of times the condition was checked
+	PreviousSHA string // SHA-256 of the value the write replaced
+}
+
+// PutWithCAS writes a value only if the key is in the state described by condition. A
+// conflict or failed read is retried up to retries times with exponential backoff, since
+// KV reads are eventually consistent and a value written elsewhere moments ago may not be
+// visible yet. The check narrows but cannot close the window for a concurrent write.
+//
+// With an UnchangedSince condition, the write records the current time in the SinceField
+// of the written metadata, so the next conditional write can check it.
+func PutWithCAS(client *api.Client, accountID, namespaceID, key, value string, options WriteOptions,
+	condition CASCondition, retries int) (*CASResult, error) {
+
+	if condition.SinceField == "" {
+		condition.SinceField = DefaultSinceField
+	}
+	condition.ValueSHA256 = strings.ToLower(strings.TrimSpace(condition.ValueSHA256))
+
+	result := &CASResult{}
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(1<<uint(attempt-1)) * time.Second)
+		}
+		result.Attempts++
+
+		result.PreviousSHA, err = checkCASCondition(client, accountID, namespaceID, key, condition)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if !condition.UnchangedSince.IsZero() {
+		metadata := make(KeyValueMetadata, len(options.Metadata)+1)
+		for k, v := range options.Metadata {
+			metadata[k] = v
+		}
+		metadata[condition.SinceField] = time.Now().UTC().Format(time.RFC3339)
+		options.Metadata = metadata
+	}
+
+	if err := WriteValue(client, accountID, namespaceID, key, value, &options); err != nil {
+		return result, fmt.Errorf("failed to write value: %w", err)
+	}
+	return result, nil
+}
+
+// checkCASCondition reads the key and returns the SHA-256 of its current value, or a
+// *CASConflictError if the key is not in the expected state
+func checkCASCondition(client *api.Client, accountID, namespaceID, key string, condition CASCondition) (string, error) {
+	current, err := GetValue(client, accountID, namespaceID, key)
+	if err != nil {
+		if strings.Contains(err.Error(), "HTTP 404") {
+			return "", &CASConflictError{Key: key, Reason: "it does not exist"}
+		}
+		return "", fmt.Errorf("failed to read current value: %w", err)
+	}
+
+	currentSHA := HashValue(current)
+	if condition.ValueSHA256 != "" && currentSHA != condition.ValueSHA256 {
+		return currentSHA, &CASConflictError{
+			Key:    key,
+			Reason: fmt.Sprintf("its value has SHA-256 %s, expected %s", currentSHA, condition.ValueSHA256),
+		}
+	}
+
+	if !condition.UnchangedSince.IsZero() {
+		metadata, err := GetMetadata(client, accountID, namespaceID, key)
+		if err != nil {
+			return currentSHA, fmt.Errorf("failed to read current metadata: %w", err)
+		}
+
+		var stamp interface{}
+		if metadata != nil {
+			stamp = (*metadata)[condition.SinceField]
+		}
+		modified, err := common.ParseTimestamp(stamp)
+		if err != nil {
+			return currentSHA, &CASConflictError{
+				Key:    key,
+				Reason: fmt.Sprintf("its metadata has no usable '%s' timestamp", condition.SinceField),
+			}
+		}
+		if modified.After(condition.UnchangedSince) {
+			return currentSHA, &CASConflictError{
+				Key: key,
+				Reason: fmt.Sprintf("it was modified at %s, after %s", modified.UTC().Format(time.RFC3339),
+					condition.UnchangedSince.UTC().Format(time.RFC3339)),
+			}
+		}
+	}
+
+	return currentSHA, nil
+}
@@ -0,0 +1,388 @@
+package kv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// MaxValueSize is the largest value KV accepts, 25 MiB
+const MaxValueSize = 25 * 1024 * 1024
+
+// ChunkedField is the metadata field that marks a key's value as a chunk manifest
+const ChunkedField = "chunked"
+
+// ChunkOfField is the metadata field naming the key a chunk belongs to
+const ChunkOfField = "chunk-of"
+
+// chunkManifestPrefix starts every chunk manifest value
+const chunkManifestPrefix = `{"chunk_manifest":`
+
+// ChunkManifest describes a value split into chunks. It is stored, as JSON, as the value of
+// the key itself; the chunks are stored under ChunkKey names.
+type ChunkManifest struct {
+	Chunks int    `json:"chunks"`
+	Size   int    `json:"size"`   // Length of the reassembled value in bytes
+	SHA256 string `json:"sha256"` // Hash of the reassembled value
+}
+
+// chunkManifestValue is the stored form of a ChunkManifest
+type chunkManifestValue struct {
+	Manifest ChunkManifest `json:"chunk_manifest"`
+}
+
+// ChunkKey returns the name of chunk index of key
+func ChunkKey(key string, index int) string {
+	return fmt.Sprintf("%s#chunk-%04d", key, index)
+}
+
+// IsChunked reports whether metadata marks a value as a chunk manifest
+func IsChunked(metadata map[string]interface{}) bool {
+	chunked, _ := metadata[ChunkedField].(bool)
+	return chunked
+}
+
+// isChunk reports whether a listed key is a chunk of another key's value
+func isChunk(key KeyValuePair) bool {
+	if key.Metadata == nil {
+		return false
+	}
+	_, ok := (*key.Metadata)[ChunkOfField].(string)
+	return ok
+}
+
+// withoutChunks drops the chunks of split values from a key listing, so a split value is
+// handled as the one key of its manifest
+func withoutChunks(keys []KeyValuePair) []KeyValuePair {
+	kept := keys[:0]
+	for _, key := range keys {
+		if !isChunk(key) {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// withoutChunkMarker returns a copy of metadata without the chunk marker, or nil if nothing
+// else is left
+func withoutChunkMarker(metadata map[string]interface{}) map[string]interface{} {
+	if len(metadata) <= 1 {
+		return nil
+	}
+	stripped := make(map[string]interface{}, len(metadata)-1)
+	for k, v := range metadata {
+		if k != ChunkedField {
+			stripped[k] = v
+		}
+	}
+	return stripped
+}
+
+// ItemSize returns the number of bytes the value of a bulk write item takes once stored
+func ItemSize(item BulkWriteItem) int {
+	if item.Base64 {
+		return base64.StdEncoding.DecodedLen(len(item.Value))
+	}
+	return len(item.Value)
+}
+
+// SplitOversizedItems separates the items whose values are larger than KV accepts
+func SplitOversizedItems(items []BulkWriteItem) (fitting, oversized []BulkWriteItem) {
+	for _, item := range items {
+		if ItemSize(item) > MaxValueSize {
+			oversized = append(oversized, item)
+		} else {
+			fitting = append(fitting, item)
+		}
+	}
+	return fitting, oversized
+}
+
+// WriteChunkedItem writes a bulk write item whose value is too large for KV as chunks plus
+// a manifest under the item's key, and returns the number of chunks
+func WriteChunkedItem(client *api.Client, accountID, namespaceID string, item BulkWriteItem) (int, error) {
+	value := item.Value
+	if item.Base64 {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid base64 value for key '%s': %w", item.Key, err)
+		}
+		value = string(decoded)
+	}
+	return WriteChunkedValue(client, accountID, namespaceID, item.Key, value, &WriteOptions{
+		Expiration:    item.Expiration,
+		ExpirationTTL: item.ExpirationTTL,
+		Metadata:      item.Metadata,
+	}, MaxValueSize)
+}
+
+// WriteChunkedValue splits value into chunks of chunkSize bytes, writes each under its
+// ChunkKey and then writes the manifest under key, with the chunk marker added to the
+// metadata. The manifest is written last, so readers never find a manifest whose chunks
+// are missing. Chunks share the expiration of the key.
+func WriteChunkedValue(client *api.Client, accountID, namespaceID, key, value string, options *WriteOptions, chunkSize int) (int, error) {
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("chunk size must be positive")
+	}
+	if options == nil {
+		options = &WriteOptions{}
+	}
+
+	// The chunks of a value already split under key are read first, so the ones the new
+	// value doesn't overwrite can be deleted once it is written
+	stored, err := StoredChunkKeys(client, accountID, namespaceID, key)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	manifest := ChunkManifest{
+		Chunks: (len(value) + chunkSize - 1) / chunkSize,
+		Size:   len(value),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk := value[i*chunkSize : min((i+1)*chunkSize, len(value))]
+		chunkOptions := &WriteOptions{
+			Expiration:    options.Expiration,
+			ExpirationTTL: options.ExpirationTTL,
+			Metadata:      KeyValueMetadata{ChunkOfField: key, "chunk-index": i},
+		}
+		if err := WriteValue(client, accountID, namespaceID, ChunkKey(key, i), chunk, chunkOptions); err != nil {
+			return 0, fmt.Errorf("failed to write chunk %d of key '%s': %w", i, key, err)
+		}
+	}
+
+	manifestJSON, err := json.Marshal(chunkManifestValue{Manifest: manifest})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode chunk manifest: %w", err)
+	}
+
+	metadata := make(KeyValueMetadata, len(options.Metadata)+1)
+	for k, v := range options.Metadata {
+		metadata[k] = v
+	}
+	metadata[ChunkedField] = true
+
+	manifestOptions := *options
+	manifestOptions.Metadata = metadata
+	if err := WriteValue(client, accountID, namespaceID, key, string(manifestJSON), &manifestOptions); err != nil {
+		return 0, fmt.Errorf("failed to write chunk manifest of key '%s': %w", key, err)
+	}
+	if err := DeleteStaleChunks(client, accountID, namespaceID, key, stored, manifest.Chunks); err != nil {
+		return 0, err
+	}
+	return manifest.Chunks, nil
+}
+
+// chunkKeys returns the names of the chunks of a value split under key
+func chunkKeys(key string, manifest *ChunkManifest) []string {
+	keys := make([]string, manifest.Chunks)
+	for i := range keys {
+		keys[i] = ChunkKey(key, i)
+	}
+	return keys
+}
+
+// manifestChunkKeys reads the manifest stored under key and returns the names of its chunks
+func manifestChunkKeys(client *api.Client, accountID, namespaceID, key string) ([]string, error) {
+	value, err := GetValue(client, accountID, namespaceID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk manifest of key '%s': %w", key, err)
+	}
+	manifest, err := ParseChunkManifest(value)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s': %w", key, err)
+	}
+	return chunkKeys(key, manifest), nil
+}
+
+// StoredChunkKeys returns the names of the chunks of the value stored under key, or nil if
+// the key doesn't exist or its value isn't split. Read it before overwriting or deleting the
+// key, since the chunk count is only recorded in the manifest.
+func StoredChunkKeys(client *api.Client, accountID, namespaceID, key string) ([]string, error) {
+	metadata, err := GetMetadata(client, accountID, namespaceID, key)
+	if err != nil {
+		if strings.Contains(err.Error(), "HTTP 404") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check key '%s' for chunks: %w", key, err)
+	}
+	if metadata == nil || !IsChunked(*metadata) {
+		return nil, nil
+	}
+	return manifestChunkKeys(client, accountID, namespaceID, key)
+}
+
+// StoredChunkKeysOf returns the chunk names of the split values among keys, by key, checking
+// up to concurrency keys at a time. Keys that don't exist or aren't split are left out.
+func StoredChunkKeysOf(ctx context.Context, client *api.Client, accountID, namespaceID string, keys []string, concurrency int) (map[string][]string, error) {
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	stored := make(map[string][]string)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			chunks, err := StoredChunkKeys(client, accountID, namespaceID, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else if len(chunks) > 0 {
+				stored[key] = chunks
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// withStoredChunkKeys adds the chunks of the split values among names, the keys about to be
+// deleted, reading the metadata of each key to find them
+func withStoredChunkKeys(ctx context.Context, client *api.Client, accountID, namespaceID string, names []string, concurrency int) ([]string, error) {
+	stored, err := StoredChunkKeysOf(ctx, client, accountID, namespaceID, names, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	return appendChunkKeys(names, stored), nil
+}
+
+// appendChunkKeys returns names followed by the chunks of each of them in stored, leaving out
+// the chunks names already holds
+func appendChunkKeys(names []string, stored map[string][]string) []string {
+	if len(stored) == 0 {
+		return names
+	}
+
+	deleting := make(map[string]bool, len(names))
+	for _, name := range names {
+		deleting[name] = true
+	}
+	expanded := append([]string(nil), names...)
+	for _, name := range names {
+		for _, chunk := range stored[name] {
+			if !deleting[chunk] {
+				deleting[chunk] = true
+				expanded = append(expanded, chunk)
+			}
+		}
+	}
+	return expanded
+}
+
+// DeleteStaleChunks deletes the chunks a write of key left unused: those of stored, the
+// chunk names read before the write, from index chunks on. A write of a value that isn't
+// split leaves every chunk unused, so it passes 0.
+func DeleteStaleChunks(client *api.Client, accountID, namespaceID, key string, stored []string, chunks int) error {
+	if chunks >= len(stored) {
+		return nil
+	}
+	if err := DeleteMultipleValues(client, accountID, namespaceID, stored[chunks:]); err != nil {
+		return fmt.Errorf("failed to delete the old chunks of key '%s': %w", key, err)
+	}
+	return nil
+}
+
+// WithChunkKeys adds the chunks of the split values among names, the keys about to be
+// deleted, so deleting a split value doesn't leave its chunks behind. Split values are found
+// by the chunk marker in the metadata of keys, the listed or matched keys the names were
+// taken from; chunks already in names aren't repeated.
+func WithChunkKeys(client *api.Client, accountID, namespaceID string, names []string, keys []KeyValuePair) ([]string, error) {
+	deleting := make(map[string]bool, len(names))
+	for _, name := range names {
+		deleting[name] = true
+	}
+
+	stored := make(map[string][]string)
+	for _, key := range keys {
+		if !deleting[key.Key] || key.Metadata == nil || !IsChunked(*key.Metadata) {
+			continue
+		}
+		chunks, err := manifestChunkKeys(client, accountID, namespaceID, key.Key)
+		if err != nil {
+			return nil, err
+		}
+		stored[key.Key] = chunks
+	}
+	return appendChunkKeys(names, stored), nil
+}
+
+// ParseChunkManifest decodes a manifest value written by WriteChunkedValue
+func ParseChunkManifest(value string) (*ChunkManifest, error) {
+	var stored chunkManifestValue
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return nil, fmt.Errorf("invalid chunk manifest: %w", err)
+	}
+	if stored.Manifest.Chunks <= 0 {
+		return nil, fmt.Errorf("invalid chunk manifest: no chunks")
+	}
+	return &stored.Manifest, nil
+}
+
+// ReadChunkedValue reads the chunks of a manifest value and reassembles them, checking the
+// size and hash the manifest recorded
+func ReadChunkedValue(client *api.Client, accountID, namespaceID, key, manifestValue string) (string, error) {
+	manifest, err := ParseChunkManifest(manifestValue)
+	if err != nil {
+		return "", fmt.Errorf("key '%s': %w", key, err)
+	}
+
+	var value strings.Builder
+	value.Grow(manifest.Size)
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk, err := GetValue(client, accountID, namespaceID, ChunkKey(key, i))
+		if err != nil {
+			return "", fmt.Errorf("failed to read chunk %d of key '%s': %w", i, key, err)
+		}
+		value.WriteString(chunk)
+	}
+
+	assembled := value.String()
+	if len(assembled) != manifest.Size {
+		return "", fmt.Errorf("chunks of key '%s' add up to %d bytes, manifest records %d", key, len(assembled), manifest.Size)
+	}
+	sum := sha256.Sum256([]byte(assembled))
+	if hex.EncodeToString(sum[:]) != manifest.SHA256 {
+		return "", fmt.Errorf("chunks of key '%s' don't match the SHA-256 hash of the manifest", key)
+	}
+	return assembled, nil
+}
+
+// assembleStoredValue reassembles a value read from a namespace if its metadata marks it as
+// a chunk manifest, returning the value unchanged otherwise
+func assembleStoredValue(client *api.Client, accountID, namespaceID, key, value string, metadata *KeyValueMetadata) (string, error) {
+	if metadata == nil || !IsChunked(*metadata) {
+		return value, nil
+	}
+	return ReadChunkedValue(client, accountID, namespaceID, key, value)
+}
@@ -0,0 +1,207 @@
+package kv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/auth"
+)
+
+// fakeNamespace is an in-memory KV namespace served over the keys, values, metadata, bulk
+// write and bulk delete endpoints. Keys are listed without their metadata unless
+// listMetadata is set, so searches have to fetch it.
+type fakeNamespace struct {
+	mu            sync.Mutex
+	values        map[string]string
+	metadata      map[string]json.RawMessage
+	metadataReads []string // Keys whose metadata was requested
+	listMetadata  bool
+}
+
+// newFakeNamespace starts a fake namespace and returns it with a client for it
+func newFakeNamespace(t *testing.T) (*fakeNamespace, *api.Client) {
+	t.Helper()
+	ns := &fakeNamespace{values: map[string]string{}, metadata: map[string]json.RawMessage{}}
+	server := httptest.NewServer(http.HandlerFunc(ns.serve))
+	t.Cleanup(server.Close)
+
+	client, err := api.NewClient(
+		api.WithBaseURL(server.URL),
+		api.WithCredentials(&auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "test-token"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns, client
+}
+
+func (ns *fakeNamespace) serve(w http.ResponseWriter, r *http.Request) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"success": false, "errors": [{"code": 10009, "message": "key not found"}]}`)
+	}
+
+	if _, key, ok := strings.Cut(r.URL.Path, "/values/"); ok {
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			ns.values[key] = string(body)
+			ns.metadata[key] = json.RawMessage(r.URL.Query().Get("metadata"))
+		case http.MethodGet:
+			value, exists := ns.values[key]
+			if !exists {
+				notFound()
+				return
+			}
+			_, _ = io.WriteString(w, value)
+			return
+		case http.MethodDelete:
+			delete(ns.values, key)
+			delete(ns.metadata, key)
+		}
+		_, _ = io.WriteString(w, `{"success": true, "result": {}}`)
+		return
+	}
+
+	if _, key, ok := strings.Cut(r.URL.Path, "/metadata/"); ok {
+		ns.metadataReads = append(ns.metadataReads, key)
+		metadata, exists := ns.metadata[key]
+		if !exists {
+			notFound()
+			return
+		}
+		if len(metadata) == 0 {
+			metadata = json.RawMessage(`{}`)
+		}
+		_, _ = io.WriteString(w, `{"success": true, "result": `+string(metadata)+`}`)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/keys") {
+		prefix := r.URL.Query().Get("prefix")
+		names := make([]string, 0, len(ns.values))
+		for key := range ns.values {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if metadata := ns.metadata[key]; ns.listMetadata && len(metadata) > 0 {
+				names = append(names, `{"name": `+strconv.Quote(key)+`, "metadata": `+string(metadata)+`}`)
+			} else {
+				names = append(names, `{"name": `+strconv.Quote(key)+`}`)
+			}
+		}
+		sort.Strings(names)
+		_, _ = io.WriteString(w, `{"success": true, "result": [`+strings.Join(names, ",")+`], "result_info": {"cursor": ""}}`)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/bulk/delete") {
+		var keys []string
+		_ = json.NewDecoder(r.Body).Decode(&keys)
+		for _, key := range keys {
+			delete(ns.values, key)
+			delete(ns.metadata, key)
+		}
+		_, _ = io.WriteString(w, `{"success": true, "result": {"successful_key_count": `+strconv.Itoa(len(keys))+`}}`)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/bulk") && r.Method == http.MethodPut {
+		var items []BulkWriteItem
+		_ = json.NewDecoder(r.Body).Decode(&items)
+		for _, item := range items {
+			value := item.Value
+			if item.Base64 {
+				decoded, _ := base64.StdEncoding.DecodeString(value)
+				value = string(decoded)
+			}
+			ns.values[item.Key] = value
+			ns.metadata[item.Key], _ = json.Marshal(item.Metadata)
+			if item.Metadata == nil {
+				ns.metadata[item.Key] = nil
+			}
+		}
+		_, _ = io.WriteString(w, `{"success": true, "result": {"success_count": `+strconv.Itoa(len(items))+`}}`)
+		return
+	}
+
+	notFound()
+}
+
+// keys returns the names stored in the namespace, sorted
+func (ns *fakeNamespace) keys() []string {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	keys := make([]string, 0, len(ns.values))
+	for key := range ns.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestChunkedValueCleanup(t *testing.T) {
+	ns, client := newFakeNamespace(t)
+	const account, namespace = "account", "namespace"
+
+	if _, err := WriteChunkedValue(client, account, namespace, "asset", "0123456789", nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteValue(client, account, namespace, "other", "kept", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overwriting with fewer chunks deletes the ones the new value doesn't use
+	chunks, err := WriteChunkedValue(client, account, namespace, "asset", "abcde", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 2 {
+		t.Fatalf("wrote %d chunks, want 2", chunks)
+	}
+	want := "asset,asset#chunk-0000,asset#chunk-0001,other"
+	if got := strings.Join(ns.keys(), ","); got != want {
+		t.Errorf("after overwrite keys = %s, want %s", got, want)
+	}
+	manifest, _ := GetValue(client, account, namespace, "asset")
+	if value, err := ReadChunkedValue(client, account, namespace, "asset", manifest); err != nil || value != "abcde" {
+		t.Errorf("reassembled value = %q, %v, want abcde", value, err)
+	}
+
+	// Deleting the key deletes its chunks
+	service := NewKVService(client)
+	if err := service.Delete(context.Background(), account, namespace, "asset"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ns.keys(), ","); got != "other" {
+		t.Errorf("after delete keys = %s, want other", got)
+	}
+
+	// So does a bulk delete of keys given by name
+	if _, err := WriteChunkedValue(client, account, namespace, "asset", "0123456789", nil, 4); err != nil {
+		t.Fatal(err)
+	}
+	count, err := service.BulkDelete(context.Background(), account, namespace, []string{"asset", "missing"}, BulkDeleteOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("bulk delete count = %d, want the 2 keys and 3 chunks", count)
+	}
+	if got := strings.Join(ns.keys(), ","); got != "other" {
+		t.Errorf("after bulk delete keys = %s, want other", got)
+	}
+}
@@ -0,0 +1,133 @@
+package kv
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// ContentEncodingField is the metadata field that marks a value as compressed
+const ContentEncodingField = "content-encoding"
+
+// EncodingGzip is the ContentEncodingField value of gzip-compressed values
+const EncodingGzip = "gzip"
+
+// gzipMagic starts every gzip stream
+const gzipMagic = "\x1f\x8b"
+
+// CompressValue gzips a value. The output is deterministic, so unchanged values compress to
+// the same bytes and --if-changed imports still skip them.
+func CompressValue(value string) (string, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write([]byte(value)); err != nil {
+		return "", fmt.Errorf("failed to compress value: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress value: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// DecompressValue gunzips a value written with CompressValue
+func DecompressValue(value string) (string, error) {
+	zr, err := gzip.NewReader(strings.NewReader(value))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+	return string(data), nil
+}
+
+// IsCompressed reports whether metadata marks a value as gzip-compressed
+func IsCompressed(metadata map[string]interface{}) bool {
+	encoding, _ := metadata[ContentEncodingField].(string)
+	return encoding == EncodingGzip
+}
+
+// WithCompressionMarker returns a copy of metadata with the gzip content encoding recorded
+func WithCompressionMarker(metadata map[string]interface{}) map[string]interface{} {
+	marked := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		marked[k] = v
+	}
+	marked[ContentEncodingField] = EncodingGzip
+	return marked
+}
+
+// withoutCompressionMarker returns a copy of metadata without the content encoding, or nil
+// if nothing else is left
+func withoutCompressionMarker(metadata map[string]interface{}) map[string]interface{} {
+	if len(metadata) <= 1 {
+		return nil
+	}
+	stripped := make(map[string]interface{}, len(metadata)-1)
+	for k, v := range metadata {
+		if k != ContentEncodingField {
+			stripped[k] = v
+		}
+	}
+	return stripped
+}
+
+// CompressBulkItem gzips the value of a bulk write item and records the encoding in its
+// metadata. The bulk API takes JSON strings, so the compressed value is sent base64-encoded.
+func CompressBulkItem(item BulkWriteItem) (BulkWriteItem, error) {
+	value := item.Value
+	if item.Base64 {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return item, fmt.Errorf("invalid base64 value for key '%s': %w", item.Key, err)
+		}
+		value = string(decoded)
+	}
+
+	compressed, err := CompressValue(value)
+	if err != nil {
+		return item, fmt.Errorf("key '%s': %w", item.Key, err)
+	}
+
+	item.Value = base64.StdEncoding.EncodeToString([]byte(compressed))
+	item.Base64 = true
+	item.Metadata = WithCompressionMarker(item.Metadata)
+	return item, nil
+}
+
+// decodeStoredValue decompresses a value read from a namespace if its metadata marks it as
+// compressed, returning the value unchanged otherwise
+func decodeStoredValue(value string, metadata *KeyValueMetadata) (string, error) {
+	if metadata == nil || !IsCompressed(*metadata) {
+		return value, nil
+	}
+	return DecompressValue(value)
+}
+
+// decodeValue reassembles and decompresses a value read without its metadata. Only values
+// starting with the gzip magic bytes or a chunk manifest can be encoded, so the metadata is
+// fetched just for those.
+func decodeValue(client *api.Client, accountID, namespaceID, key, value string) (string, error) {
+	if !strings.HasPrefix(value, gzipMagic) && !strings.HasPrefix(value, chunkManifestPrefix) {
+		return value, nil
+	}
+	metadata, err := GetMetadata(client, accountID, namespaceID, key)
+	if err != nil {
+		return "", err
+	}
+	if value, err = assembleStoredValue(client, accountID, namespaceID, key, value, metadata); err != nil {
+		return "", err
+	}
+	return decodeStoredValue(value, metadata)
+}
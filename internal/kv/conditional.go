@@ -0,0 +1,178 @@
+package kv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// WriteCondition controls which bulk write items are written when keys already exist
+type WriteCondition string
+
+const (
+	// WriteAlways writes every item
+	WriteAlways WriteCondition = ""
+	// WriteIfNotExists only writes keys that do not exist yet
+	WriteIfNotExists WriteCondition = "if-not-exists"
+	// WriteIfChanged only writes keys that are new or whose value or metadata differ
+	WriteIfChanged WriteCondition = "if-changed"
+)
+
+// ConditionalWriteResult describes which bulk write items still need writing
+type ConditionalWriteResult struct {
+	ToWrite          []BulkWriteItem
+	SkippedExisting  []string // Keys skipped because they already exist
+	SkippedUnchanged []string // Keys skipped because value and metadata are identical
+}
+
+// FilterBulkWriteItems checks the namespace for existing keys and drops the items that
+// the condition says must not be written. Existence is checked with a single key listing
+// narrowed to the common prefix of the items; values are only fetched for WriteIfChanged
+// and only for keys that already exist. Expiration is not compared.
+func FilterBulkWriteItems(client *api.Client, accountID, namespaceID string, items []BulkWriteItem,
+	condition WriteCondition, concurrency int) (*ConditionalWriteResult, error) {
+
+	result := &ConditionalWriteResult{}
+	if condition == WriteAlways || len(items) == 0 {
+		result.ToWrite = items
+		return result, nil
+	}
+	if condition != WriteIfNotExists && condition != WriteIfChanged {
+		return nil, fmt.Errorf("unknown write condition '%s'", condition)
+	}
+
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+
+	existingKeys, err := ListAllKeysWithOptions(client, accountID, namespaceID,
+		&ListKeysOptions{Prefix: commonKeyPrefix(keys)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing keys: %w", err)
+	}
+
+	existing := make(map[string]KeyValuePair, len(existingKeys))
+	for _, k := range existingKeys {
+		existing[k.Key] = k
+	}
+
+	// Collect the existing keys whose values need comparing
+	var compare []int
+	for i, item := range items {
+		if _, ok := existing[item.Key]; !ok {
+			result.ToWrite = append(result.ToWrite, item)
+			continue
+		}
+		if condition == WriteIfNotExists {
+			result.SkippedExisting = append(result.SkippedExisting, item.Key)
+			continue
+		}
+		compare = append(compare, i)
+	}
+
+	if len(compare) == 0 {
+		return result, nil
+	}
+
+	compareItems := make([]BulkWriteItem, len(compare))
+	for n, idx := range compare {
+		compareItems[n] = items[idx]
+	}
+	changed, err := changedItems(client, accountID, namespaceID, compareItems, existing, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, item := range compareItems {
+		if changed[n] {
+			result.ToWrite = append(result.ToWrite, item)
+		} else {
+			result.SkippedUnchanged = append(result.SkippedUnchanged, item.Key)
+		}
+	}
+
+	return result, nil
+}
+
+// changedItems fetches the current values of items whose keys exist and reports, for each
+// item, whether writing it would change the value or metadata. existing holds the listed
+// keys with their metadata. Expiration is not compared.
+func changedItems(client *api.Client, accountID, namespaceID string, items []BulkWriteItem,
+	existing map[string]KeyValuePair, concurrency int) ([]bool, error) {
+
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	// Fetch current values concurrently
+	changed := make([]bool, len(items))
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for n, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n int, item BulkWriteItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			value, err := GetValue(client, accountID, namespaceID, item.Key)
+			if err != nil {
+				errs[n] = fmt.Errorf("failed to read existing value for key '%s': %w", item.Key, err)
+				return
+			}
+
+			var metadata map[string]interface{}
+			if current := existing[item.Key]; current.Metadata != nil {
+				metadata = *current.Metadata
+			}
+			if item.Base64 {
+				value = base64.StdEncoding.EncodeToString([]byte(value))
+			}
+			changed[n] = value != item.Value || !metadataEqual(metadata, item.Metadata)
+		}(n, item)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// metadataEqual compares metadata, treating nil and empty as equal
+func metadataEqual(a, b map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// commonKeyPrefix returns the longest prefix shared by all keys
+func commonKeyPrefix(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	prefix := keys[0]
+	for _, key := range keys[1:] {
+		for !strings.HasPrefix(key, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+		if prefix == "" {
+			break
+		}
+	}
+	// Don't cut a multi-byte character in half
+	for prefix != "" && !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
@@ -0,0 +1,225 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// OverwritePolicy controls what happens when a copied key already exists at the destination
+type OverwritePolicy string
+
+const (
+	// OverwriteSkip leaves existing destination keys untouched
+	OverwriteSkip OverwritePolicy = "skip"
+	// OverwriteReplace replaces existing destination keys
+	OverwriteReplace OverwritePolicy = "replace"
+	// OverwriteFail aborts the copy if any destination key already exists
+	OverwriteFail OverwritePolicy = "fail"
+)
+
+// ParseOverwritePolicy parses an overwrite policy name
+func ParseOverwritePolicy(s string) (OverwritePolicy, error) {
+	switch OverwritePolicy(s) {
+	case OverwriteSkip, OverwriteReplace, OverwriteFail:
+		return OverwritePolicy(s), nil
+	case "":
+		return OverwriteSkip, nil
+	default:
+		return "", fmt.Errorf("invalid overwrite policy '%s': must be skip, replace, or fail", s)
+	}
+}
+
+// CopyEndpoint identifies one side of a copy operation
+type CopyEndpoint struct {
+	Client      *api.Client
+	AccountID   string
+	NamespaceID string
+}
+
+// CopyOptions represents options for copying keys between namespaces
+type CopyOptions struct {
+	Key       string          // Single key to copy
+	Prefix    string          // Copy all keys with this prefix (used when Key is empty)
+	Overwrite OverwritePolicy // What to do when the destination key exists
+	DryRun    bool            // Only report what would be copied
+	BatchSize int             // Batch size for bulk writes to the destination
+
+	// Transform is applied to each text value and to string metadata values in flight
+	// (optional). Compressed and other binary values are copied unchanged.
+	Transform *common.ValueTransformer
+}
+
+// CopyResult contains the outcome of a copy operation
+type CopyResult struct {
+	Copied  []string `json:"copied"`
+	Skipped []string `json:"skipped,omitempty"`
+	DryRun  bool     `json:"dry_run,omitempty"`
+
+	// ValueHashes maps each copied key to the SHA-256 hash of the value written, for verification
+	ValueHashes map[string]string `json:"-"`
+}
+
+// CopyKeys copies a key, or every key under a prefix, including value, metadata and expiration,
+// from the source namespace to the destination namespace. The endpoints may belong to different
+// accounts and use different credentials. Binary values are written base64-encoded, so they
+// arrive byte for byte, and a split value is copied as one key: it is reassembled and split
+// again at the destination if it is still too large for KV.
+func CopyKeys(src, dst CopyEndpoint, options CopyOptions, progressCallback func(completed, total int)) (*CopyResult, error) {
+	if src.AccountID == "" || dst.AccountID == "" {
+		return nil, fmt.Errorf("source and destination account IDs are required")
+	}
+	if src.NamespaceID == "" || dst.NamespaceID == "" {
+		return nil, fmt.Errorf("source and destination namespace IDs are required")
+	}
+	if options.Key == "" && options.Prefix == "" {
+		return nil, fmt.Errorf("either a key or a prefix is required")
+	}
+	if src.AccountID == dst.AccountID && src.NamespaceID == dst.NamespaceID {
+		return nil, fmt.Errorf("source and destination namespaces must be different")
+	}
+	if options.Overwrite == "" {
+		options.Overwrite = OverwriteSkip
+	}
+
+	// Listing gives us expiration and metadata without a request per key
+	listPrefix := options.Prefix
+	if options.Key != "" {
+		listPrefix = options.Key
+	}
+
+	sourceKeys, err := ListAllKeysWithOptions(src.Client, src.AccountID, src.NamespaceID, &ListKeysOptions{Prefix: listPrefix}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source keys: %w", err)
+	}
+
+	// For a single key, only keep the exact match
+	if options.Key != "" {
+		var exact []KeyValuePair
+		for _, k := range sourceKeys {
+			if k.Key == options.Key {
+				exact = append(exact, k)
+				break
+			}
+		}
+		if len(exact) == 0 {
+			return nil, fmt.Errorf("key '%s' not found in source namespace", options.Key)
+		}
+		sourceKeys = exact
+	} else {
+		// Chunks are copied with the value they belong to
+		sourceKeys = withoutChunks(sourceKeys)
+	}
+
+	// Check which keys already exist at the destination
+	existing := make(map[string]bool)
+	if options.Overwrite != OverwriteReplace {
+		destKeys, err := ListAllKeysWithOptions(dst.Client, dst.AccountID, dst.NamespaceID, &ListKeysOptions{Prefix: listPrefix}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list destination keys: %w", err)
+		}
+		for _, k := range destKeys {
+			existing[k.Key] = true
+		}
+	}
+
+	result := &CopyResult{DryRun: options.DryRun}
+	var toCopy []KeyValuePair
+	for _, k := range sourceKeys {
+		if existing[k.Key] {
+			if options.Overwrite == OverwriteFail {
+				return nil, fmt.Errorf("key '%s' already exists in destination namespace (use --overwrite skip or replace)", k.Key)
+			}
+			result.Skipped = append(result.Skipped, k.Key)
+			continue
+		}
+		toCopy = append(toCopy, k)
+	}
+
+	if options.DryRun {
+		for _, k := range toCopy {
+			result.Copied = append(result.Copied, k.Key)
+		}
+		return result, nil
+	}
+
+	// Fetch values from the source
+	items := make([]BulkWriteItem, 0, len(toCopy))
+	for i, k := range toCopy {
+		value, err := GetValue(src.Client, src.AccountID, src.NamespaceID, k.Key)
+		if err != nil {
+			return result, fmt.Errorf("failed to read key '%s': %w", k.Key, err)
+		}
+
+		var metadata map[string]interface{}
+		if k.Metadata != nil {
+			metadata = *k.Metadata
+		}
+		if IsChunked(metadata) {
+			if value, err = ReadChunkedValue(src.Client, src.AccountID, src.NamespaceID, k.Key, value); err != nil {
+				return result, err
+			}
+			metadata = withoutChunkMarker(metadata)
+		}
+
+		// Transforms rewrite text, so compressed and other binary values are left as they are
+		if !IsCompressed(metadata) && utf8.ValidString(value) {
+			value = options.Transform.Apply(value)
+		}
+
+		item := ValueItem(k.Key, value)
+		item.Expiration = k.Expiration
+		item.Metadata = options.Transform.ApplyToMetadata(metadata)
+		items = append(items, item)
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(toCopy)*2)
+		}
+	}
+
+	// Replaced keys may hold split values whose chunks the copies leave unused
+	var stored map[string][]string
+	if options.Overwrite == OverwriteReplace && len(items) > 0 {
+		names := make([]string, len(items))
+		for i, item := range items {
+			names[i] = item.Key
+		}
+		if stored, err = StoredChunkKeysOf(context.Background(), dst.Client, dst.AccountID, dst.NamespaceID, names, 0); err != nil {
+			return result, err
+		}
+	}
+
+	// Write them to the destination in bulk, splitting values still too large for KV
+	fitting, oversized := SplitOversizedItems(items)
+	if len(fitting) > 0 {
+		_, err = WriteMultipleValuesInBatches(dst.Client, dst.AccountID, dst.NamespaceID, fitting, options.BatchSize,
+			func(completed, total int) {
+				if progressCallback != nil {
+					progressCallback(len(toCopy)+completed, len(toCopy)*2)
+				}
+			})
+		if err != nil {
+			return result, fmt.Errorf("failed to write keys to destination: %w", err)
+		}
+	}
+	for _, item := range fitting {
+		if err := DeleteStaleChunks(dst.Client, dst.AccountID, dst.NamespaceID, item.Key, stored[item.Key], 0); err != nil {
+			return result, err
+		}
+	}
+	for _, item := range oversized {
+		if _, err := WriteChunkedItem(dst.Client, dst.AccountID, dst.NamespaceID, item); err != nil {
+			return result, fmt.Errorf("failed to write key '%s' to destination: %w", item.Key, err)
+		}
+	}
+
+	for _, item := range items {
+		result.Copied = append(result.Copied, item.Key)
+	}
+	result.ValueHashes = HashBulkWriteItems(items)
+
+	return result, nil
+}
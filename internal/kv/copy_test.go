@@ -0,0 +1,71 @@
+package kv
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cache-kv-purger/internal/common"
+)
+
+func TestCopyKeysBinaryAndChunkedValues(t *testing.T) {
+	src, srcClient := newFakeNamespace(t)
+	dst, dstClient := newFakeNamespace(t)
+	src.listMetadata = true
+	const account = "account"
+
+	compressed, err := CompressValue(strings.Repeat("staging ", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.values["app/gzip"] = compressed
+	src.metadata["app/gzip"] = json.RawMessage(`{"content-encoding": "gzip"}`)
+	src.values["app/binary"] = "staging\xff\xfe"
+	src.values["app/text"] = "https://staging.example.com"
+	if _, err := WriteChunkedValue(srcClient, account, "src", "app/split", "staging-0123456789", nil, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	transform, err := common.NewValueTransformer(`gsub("staging"; "www")`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CopyKeys(
+		CopyEndpoint{Client: srcClient, AccountID: account, NamespaceID: "src"},
+		CopyEndpoint{Client: dstClient, AccountID: account, NamespaceID: "dst"},
+		CopyOptions{Prefix: "app/", Transform: transform}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Copied, ","); got != "app/binary,app/gzip,app/split,app/text" {
+		t.Errorf("copied = %s", got)
+	}
+
+	// Compressed and binary values arrive byte for byte, untouched by the transform
+	if dst.values["app/gzip"] != compressed {
+		t.Error("gzip value was not copied byte for byte")
+	}
+	if got := dst.values["app/binary"]; got != "staging\xff\xfe" {
+		t.Errorf("binary value = %q", got)
+	}
+	if got := dst.values["app/text"]; got != "https://www.example.com" {
+		t.Errorf("text value = %q, want the transformed URL", got)
+	}
+
+	// The split value is copied as one key, without the chunks of the source
+	if got := dst.values["app/split"]; got != "www-0123456789" {
+		t.Errorf("split value = %q, want it reassembled and transformed", got)
+	}
+	if got := strings.Join(dst.keys(), ","); got != "app/binary,app/gzip,app/split,app/text" {
+		t.Errorf("destination keys = %s", got)
+	}
+
+	// The copies verify against the hashes of the values written
+	verification, err := VerifyValues(dstClient, account, "dst", result.ValueHashes, VerifyOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verification.Mismatched) > 0 || len(verification.Missing) > 0 {
+		t.Errorf("verification mismatched %v, missing %v", verification.Mismatched, verification.Missing)
+	}
+}
@@ -0,0 +1,189 @@
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// cursorCacheFileName is the name of the local key listing cursor file in the home directory
+const cursorCacheFileName = ".cache-kv-purger-cursors.json"
+
+// cursorCacheTTL is how long cached cursors of a listing are reused. Listings that were
+// written to since may shift page boundaries, so old cursors are dropped.
+const cursorCacheTTL = time.Hour
+
+// CursorCache remembers the cursors of key listing pages between invocations, so
+// `kv list --page N` can start from the cursor of page N instead of paging from the start
+type CursorCache struct {
+	Listings map[string]*CachedListing `json:"listings"` // Listings by account, namespace, prefix and limit
+
+	path string
+}
+
+// CachedListing holds the known cursors of a key listing
+type CachedListing struct {
+	Cursors  []string  `json:"cursors"`             // Cursors[i] starts page i+2
+	LastPage int       `json:"last_page,omitempty"` // Number of pages, once the last page was listed
+	Updated  time.Time `json:"updated"`
+}
+
+// LoadCursorCache loads the cursor cache, dropping listings older than the cache TTL
+func LoadCursorCache(path string) (*CursorCache, error) {
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.New("cannot determine home directory for cursor cache file")
+		}
+		path = filepath.Join(homeDir, cursorCacheFileName)
+	}
+
+	cache := &CursorCache{Listings: make(map[string]*CachedListing), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cursor cache file: %w", err)
+	}
+
+	var stored CursorCache
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor cache file: %w", err)
+	}
+	for key, listing := range stored.Listings {
+		if listing != nil && time.Since(listing.Updated) < cursorCacheTTL {
+			cache.Listings[key] = listing
+		}
+	}
+
+	return cache, nil
+}
+
+// Save writes the cursor cache
+func (c *CursorCache) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save cursor cache file: %w", err)
+	}
+	return nil
+}
+
+// Forget drops the cached cursors of a listing, e.g. after a cached cursor was rejected
+func (c *CursorCache) Forget(key string) {
+	delete(c.Listings, key)
+}
+
+// listing returns the cached cursors of a listing, creating an empty entry if needed
+func (c *CursorCache) listing(key string) *CachedListing {
+	listing, ok := c.Listings[key]
+	if !ok {
+		listing = &CachedListing{}
+		c.Listings[key] = listing
+	}
+	return listing
+}
+
+// CursorCacheKey identifies a listing in the cursor cache. Pages of listings with a
+// different prefix or page size have different cursors.
+func CursorCacheKey(accountID, namespaceID, prefix string, limit int) string {
+	return fmt.Sprintf("%s/%s/%d/%s", accountID, namespaceID, limit, prefix)
+}
+
+// ListPageResult is one page of a key listing with what is known about its size
+type ListPageResult struct {
+	*ListKeysResult
+	Page       int // Page number, starting at 1
+	TotalPages int // Number of pages, 0 until the API reports a total or the last page is seen
+	TotalKeys  int // Number of keys, 0 unless the API reports a total
+	Requests   int // List requests made to reach the page
+}
+
+// ListKeysPage lists page N of the keys, starting at 1. Without a cache, reaching page N
+// takes N requests. With a cache, paging starts from the closest known cursor, and the
+// cursors seen on the way are stored for later invocations. The caller saves the cache.
+func ListKeysPage(client *api.Client, accountID, namespaceID string, options *ListKeysOptions, page int, cache *CursorCache) (*ListPageResult, error) {
+	if page < 1 {
+		return nil, fmt.Errorf("page must be at least 1")
+	}
+	if options == nil {
+		options = &ListKeysOptions{}
+	}
+	if options.Limit == 0 {
+		options.Limit = 1000
+	}
+
+	var cached *CachedListing
+	cacheKey := CursorCacheKey(accountID, namespaceID, options.Prefix, options.Limit)
+	if cache != nil {
+		cached = cache.listing(cacheKey)
+		if cached.LastPage > 0 && page > cached.LastPage {
+			return nil, fmt.Errorf("page %d is past the last page (%d)", page, cached.LastPage)
+		}
+	}
+
+	// Start from the closest page with a known cursor
+	current := 1
+	options.Cursor = ""
+	if cached != nil && page > 1 {
+		known := min(page-1, len(cached.Cursors))
+		if known > 0 {
+			current = known + 1
+			options.Cursor = cached.Cursors[known-1]
+		}
+	}
+
+	requests := 0
+	for {
+		result, err := ListKeysWithOptions(client, accountID, namespaceID, options)
+		requests++
+		if err != nil {
+			if cached != nil && options.Cursor != "" && requests == 1 {
+				// A cached cursor may have expired: start over from the first page
+				cache.Forget(cacheKey)
+				return ListKeysPage(client, accountID, namespaceID, options, page, cache)
+			}
+			return nil, err
+		}
+
+		if cached != nil {
+			cached.Updated = time.Now()
+			if result.HasMore && len(cached.Cursors) == current-1 {
+				cached.Cursors = append(cached.Cursors, result.Cursor)
+			}
+			if !result.HasMore {
+				cached.LastPage = current
+				cached.Cursors = cached.Cursors[:min(len(cached.Cursors), current-1)]
+			}
+		}
+
+		if current == page || !result.HasMore {
+			if current < page {
+				return nil, fmt.Errorf("page %d is past the last page (%d)", page, current)
+			}
+			pageResult := &ListPageResult{ListKeysResult: result, Page: current, Requests: requests}
+			switch {
+			case result.TotalCount > 0:
+				pageResult.TotalKeys = result.TotalCount
+				pageResult.TotalPages = (result.TotalCount + options.Limit - 1) / options.Limit
+			case !result.HasMore:
+				pageResult.TotalPages = current
+			case cached != nil && cached.LastPage > 0:
+				pageResult.TotalPages = cached.LastPage
+			}
+			return pageResult, nil
+		}
+
+		options.Cursor = result.Cursor
+		current++
+	}
+}
@@ -1,12 +1,16 @@
 package kv
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // DeleteValue deletes a value from a KV namespace
@@ -25,22 +29,8 @@ func DeleteValue(client *api.Client, accountID, namespaceID, key string) error {
 	encodedKey := url.PathEscape(key)
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/values/%s", accountID, namespaceID, encodedKey)
 
-	respBody, err := client.Request(http.MethodDelete, path, nil, nil)
-	if err != nil {
-		return err
-	}
-
-	var resp api.APIResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !resp.Success {
-		errorStr := "API reported failure"
-		if len(resp.Errors) > 0 {
-			errorStr = resp.Errors[0].Message
-		}
-		return fmt.Errorf("failed to delete value: %s", errorStr)
+	if _, err := api.RequestJSON[json.RawMessage](context.Background(), client, http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete value: %w", err)
 	}
 
 	return nil
@@ -58,83 +48,174 @@ func DeleteMultipleValues(client *api.Client, accountID, namespaceID string, key
 		return fmt.Errorf("at least one key is required")
 	}
 
-	fmt.Printf("[DEBUG] DeleteMultipleValues called with %d keys\n", len(keys))
+	common.LogDebug("DeleteMultipleValues called with %d keys", len(keys))
 
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/bulk/delete", accountID, namespaceID)
 
 	// API expects an array of strings, not objects with 'name' property
-	fmt.Printf("[VERBOSE] Sending bulk delete request to %s with %d keys\n", path, len(keys))
+	common.LogVerbose("Sending bulk delete request to %s with %d keys", path, len(keys))
 
 	// Send the keys directly as an array of strings
 	respBody, err := client.Request(http.MethodPost, path, nil, keys)
+	if err != nil && isBisectableDeleteError(err) && len(keys) > 1 {
+		// The payload was rejected as too large or invalid: split it to find the offending keys
+		common.LogVerbose("Bulk delete rejected (%v), splitting batch of %d keys", err, len(keys))
+		deleted, failedKeys, cause := bisectBulkDelete(client, path, keys)
+		if len(failedKeys) == 0 {
+			common.LogVerbose("Bulk delete of %d keys completed after splitting", len(keys))
+			return nil
+		}
+		return &BulkDeleteError{FailedKeys: failedKeys, Deleted: deleted, Total: len(keys), Cause: cause}
+	}
+	if errors.Is(err, common.ErrInterrupted) {
+		return err
+	}
 	if err != nil {
-		fmt.Printf("[ERROR] Bulk delete request failed: %v\n", err)
+		common.LogError("Bulk delete request failed: %v", err)
 
 		// Fall back to individual deletions if bulk delete fails
-		fmt.Printf("[VERBOSE] Falling back to individual deletions for %d keys\n", len(keys))
+		common.LogVerbose("Falling back to individual deletions for %d keys", len(keys))
 		fallbackErrors := 0
 		for i, key := range keys {
 			if i%100 == 0 {
-				fmt.Printf("[DEBUG] Performing individual deletion %d/%d\n", i+1, len(keys))
+				common.LogDebug("Performing individual deletion %d/%d", i+1, len(keys))
 			}
 			if deleteErr := DeleteValue(client, accountID, namespaceID, key); deleteErr != nil {
 				fallbackErrors++
-				fmt.Printf("[ERROR] Individual deletion failed for key %s: %v\n", key, deleteErr)
+				common.LogError("Individual deletion failed for key %s: %v", key, deleteErr)
 			}
 		}
 
 		// If all individual deletes failed too, return the original error
 		if fallbackErrors == len(keys) {
-			fmt.Printf("[ERROR] All %d individual deletions failed\n", len(keys))
+			common.LogError("All %d individual deletions failed", len(keys))
 			return err
 		}
 
 		// Otherwise we succeeded with individual deletes
-		fmt.Printf("[INFO] Completed with %d/%d successful individual deletions\n", len(keys)-fallbackErrors, len(keys))
+		common.LogVerbose("Completed with %d/%d successful individual deletions", len(keys)-fallbackErrors, len(keys))
 		return nil
 	}
 
 	var resp api.APIResponse
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		fmt.Printf("[ERROR] Failed to parse API response: %v\n", err)
+		common.LogError("Failed to parse API response: %v", err)
 		return fmt.Errorf("failed to parse API response: %w", err)
 	}
 
-	fmt.Printf("[DEBUG] API response: success=%v, errors=%v\n", resp.Success, len(resp.Errors))
+	common.LogDebug("API response: success=%v, errors=%v", resp.Success, len(resp.Errors))
 
 	if !resp.Success {
 		errorStr := "API reported failure"
 		if len(resp.Errors) > 0 {
 			errorStr = resp.Errors[0].Message
-			fmt.Printf("[ERROR] API reported error: %s\n", errorStr)
+			common.LogError("API reported error: %s", errorStr)
 		}
 
 		// Try individual deletions as fallback
-		fmt.Printf("[VERBOSE] API reported failure, falling back to individual deletions for %d keys\n", len(keys))
+		common.LogVerbose("API reported failure, falling back to individual deletions for %d keys", len(keys))
 		fallbackErrors := 0
 		for i, key := range keys {
 			if i%100 == 0 {
-				fmt.Printf("[DEBUG] Performing individual deletion %d/%d\n", i+1, len(keys))
+				common.LogDebug("Performing individual deletion %d/%d", i+1, len(keys))
 			}
 			if deleteErr := DeleteValue(client, accountID, namespaceID, key); deleteErr != nil {
 				fallbackErrors++
-				fmt.Printf("[ERROR] Individual deletion failed for key %s: %v\n", key, deleteErr)
+				common.LogError("Individual deletion failed for key %s: %v", key, deleteErr)
 			}
 		}
 
 		// If all individual deletes failed too, return the original error
 		if fallbackErrors == len(keys) {
-			fmt.Printf("[ERROR] All %d individual deletions failed\n", len(keys))
+			common.LogError("All %d individual deletions failed", len(keys))
 			return fmt.Errorf("failed to delete multiple values: %s", errorStr)
 		}
 
 		// Otherwise we succeeded with individual deletes
-		fmt.Printf("[INFO] Completed with %d/%d successful individual deletions\n", len(keys)-fallbackErrors, len(keys))
+		common.LogVerbose("Completed with %d/%d successful individual deletions", len(keys)-fallbackErrors, len(keys))
 		return nil
 	}
 
-	fmt.Printf("[INFO] Bulk delete of %d keys completed successfully\n", len(keys))
+	common.LogVerbose("Bulk delete of %d keys completed successfully", len(keys))
 	return nil
 }
 
-// Note: DeleteMultipleValuesInBatches is now moved to batch.go
+// maxRejectedKeysShown caps how many rejected keys a BulkDeleteError message lists
+const maxRejectedKeysShown = 5
+
+// BulkDeleteError reports keys that a bulk delete rejected after every other key in
+// the batch was deleted
+type BulkDeleteError struct {
+	FailedKeys []string // Keys the API rejected, isolated by splitting the batch
+	Deleted    int      // Keys deleted from the batch
+	Total      int      // Keys in the batch
+	Cause      error    // Error returned for the last rejected key
+}
+
+// Error lists the rejected keys, abbreviated when there are many
+func (e *BulkDeleteError) Error() string {
+	shown := e.FailedKeys
+	suffix := ""
+	if len(shown) > maxRejectedKeysShown {
+		shown = shown[:maxRejectedKeysShown]
+		suffix = fmt.Sprintf(" and %d more", len(e.FailedKeys)-maxRejectedKeysShown)
+	}
+	return fmt.Sprintf("%d of %d keys rejected by bulk delete (%s%s): %v",
+		len(e.FailedKeys), e.Total, strings.Join(shown, ", "), suffix, e.Cause)
+}
+
+// Unwrap returns the underlying API error
+func (e *BulkDeleteError) Unwrap() error {
+	return e.Cause
+}
+
+// mergeBulkDeleteErrors combines batch errors into one BulkDeleteError listing every
+// rejected key when all of them are rejections, and otherwise returns the first error
+func mergeBulkDeleteErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	merged := &BulkDeleteError{}
+	for _, err := range errs {
+		var bulkErr *BulkDeleteError
+		if !errors.As(err, &bulkErr) {
+			return errs[0]
+		}
+		merged.FailedKeys = append(merged.FailedKeys, bulkErr.FailedKeys...)
+		merged.Deleted += bulkErr.Deleted
+		merged.Total += bulkErr.Total
+		merged.Cause = bulkErr.Cause
+	}
+	return merged
+}
+
+// isBisectableDeleteError returns true for errors caused by the payload rather than the
+// request: too large (HTTP 413) or containing an invalid key (HTTP 400)
+func isBisectableDeleteError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "HTTP 413") || strings.Contains(msg, "HTTP 400")
+}
+
+// bisectBulkDelete deletes keys in bulk, splitting a rejected batch in half until the
+// keys the API refuses are isolated. It returns the number of keys deleted, the keys
+// that could not be deleted, and the last error seen for them.
+func bisectBulkDelete(client *api.Client, path string, keys []string) (int, []string, error) {
+	_, err := client.Request(http.MethodPost, path, nil, keys)
+	if err == nil {
+		return len(keys), nil, nil
+	}
+	if len(keys) == 1 || !isBisectableDeleteError(err) {
+		return 0, keys, err
+	}
+
+	mid := len(keys) / 2
+	leftDeleted, leftFailed, leftErr := bisectBulkDelete(client, path, keys[:mid])
+	rightDeleted, rightFailed, rightErr := bisectBulkDelete(client, path, keys[mid:])
+
+	cause := rightErr
+	if cause == nil {
+		cause = leftErr
+	}
+	return leftDeleted + rightDeleted, append(leftFailed, rightFailed...), cause
+}
@@ -0,0 +1,102 @@
+package kv
+
+import (
+	"math"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+const (
+	// EstimateMaxPages is how many key pages are listed to count keys for an estimate
+	EstimateMaxPages = 20
+
+	// estimateRequestLatency is the assumed duration of one API request
+	estimateRequestLatency = 300 * time.Millisecond
+
+	// estimateBurstRequests is the number of requests the API allows before its rate limit
+	// of 1200 requests per 5 minutes throttles a run to estimateSustainedRate
+	estimateBurstRequests = 1200
+	estimateSustainedRate = 4 // Requests per second
+)
+
+// CostEstimate is the expected number of API requests and duration of an operation
+// over the keys of a namespace
+type CostEstimate struct {
+	Keys       int           `json:"keys"`
+	KeysExact  bool          `json:"keys_exact"` // False when only the first pages were counted
+	ListCalls  int           `json:"list_calls"`
+	ReadCalls  int           `json:"read_calls"`  // Value and metadata requests
+	WriteCalls int           `json:"write_calls"` // Bulk write and delete requests
+	Duration   time.Duration `json:"duration"`
+}
+
+// APICalls returns the total number of API requests of the estimate
+func (e CostEstimate) APICalls() int {
+	return e.ListCalls + e.ReadCalls + e.WriteCalls
+}
+
+// CountKeys counts the keys with a prefix by listing at most maxPages pages. It returns
+// the count and whether it is exact; when the namespace has more keys than the pages
+// hold, the count is a lower bound. A total reported by the API is used when present.
+func CountKeys(client *api.Client, accountID, namespaceID, prefix string, maxPages int) (int, bool, error) {
+	if maxPages <= 0 {
+		maxPages = EstimateMaxPages
+	}
+
+	count := 0
+	options := &ListKeysOptions{Limit: 1000, Prefix: prefix}
+	for page := 0; page < maxPages; page++ {
+		result, err := ListKeysWithOptions(client, accountID, namespaceID, options)
+		if err != nil {
+			return 0, false, err
+		}
+		if page == 0 && result.TotalCount > 0 {
+			return result.TotalCount, true, nil
+		}
+
+		count += len(result.Keys)
+		if !result.HasMore {
+			return count, true, nil
+		}
+		options.Cursor = result.Cursor
+	}
+	return count, false, nil
+}
+
+// CountAllKeys counts every key with a prefix, paging through the keys without keeping
+// them, or takes the total reported by the API when present
+func CountAllKeys(client *api.Client, accountID, namespaceID, prefix string) (int, error) {
+	count, _, err := CountKeys(client, accountID, namespaceID, prefix, math.MaxInt)
+	return count, err
+}
+
+// EstimateCost estimates an operation that lists keys, makes readsPerKey value or metadata
+// requests for each key and writes or deletes them in batches of writeBatchSize (0 when
+// nothing is written), with up to concurrency requests in flight
+func EstimateCost(keys int, exact bool, readsPerKey, writeBatchSize, concurrency int) CostEstimate {
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	estimate := CostEstimate{
+		Keys:      keys,
+		KeysExact: exact,
+		ListCalls: max(1, (keys+999)/1000),
+		ReadCalls: keys * readsPerKey,
+	}
+	if writeBatchSize > 0 {
+		estimate.WriteCalls = (keys + writeBatchSize - 1) / writeBatchSize
+	}
+
+	// Listing is sequential, the other requests run concurrently until the rate limit applies
+	duration := time.Duration(estimate.ListCalls) * estimateRequestLatency
+	concurrent := estimate.ReadCalls + estimate.WriteCalls
+	if burst := min(concurrent, max(0, estimateBurstRequests-estimate.ListCalls)); burst > 0 {
+		duration += time.Duration(burst) * estimateRequestLatency / time.Duration(concurrency)
+		concurrent -= burst
+	}
+	duration += time.Duration(concurrent) * time.Second / estimateSustainedRate
+	estimate.Duration = duration
+
+	return estimate
+}
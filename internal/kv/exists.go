@@ -0,0 +1,117 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// ExistsRequirement decides when a bulk existence check passes
+type ExistsRequirement string
+
+const (
+	// RequireAll passes when every key exists
+	RequireAll ExistsRequirement = "all"
+	// RequireAny passes when at least one key exists
+	RequireAny ExistsRequirement = "any"
+)
+
+// ParseExistsRequirement parses the --require flag of the exists command
+func ParseExistsRequirement(value string) (ExistsRequirement, error) {
+	switch ExistsRequirement(strings.ToLower(value)) {
+	case RequireAll, "":
+		return RequireAll, nil
+	case RequireAny:
+		return RequireAny, nil
+	}
+	return "", fmt.Errorf("invalid --require value '%s': must be all or any", value)
+}
+
+// ExistsResult is the outcome of checking whether a list of keys exists
+type ExistsResult struct {
+	Checked int               `json:"checked"`
+	Found   []string          `json:"found"`
+	Missing []string          `json:"missing"`
+	Failed  map[string]string `json:"failed,omitempty"` // Key to error message
+}
+
+// Satisfies returns true if the result meets the requirement. Keys whose check failed
+// count as neither found nor missing, so they fail an "all" requirement.
+func (r *ExistsResult) Satisfies(require ExistsRequirement) bool {
+	if require == RequireAny {
+		return len(r.Found) > 0
+	}
+	return len(r.Found) == r.Checked
+}
+
+// CheckKeysExist checks whether each key exists with concurrent HEAD requests, without
+// downloading any values. Duplicate keys are checked once. The progress callback is
+// called after each key.
+func CheckKeysExist(client *api.Client, accountID, namespaceID string, keys []string, concurrency int,
+	progressCallback func(checked, total int)) (*ExistsResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
+
+	unique := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+
+	result := &ExistsResult{
+		Checked: len(unique),
+		Found:   []string{},
+		Missing: []string{},
+		Failed:  make(map[string]string),
+	}
+	var mu sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(unique)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				exists, err := KeyExists(client, accountID, namespaceID, key)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed[key] = err.Error()
+				case exists:
+					result.Found = append(result.Found, key)
+				default:
+					result.Missing = append(result.Missing, key)
+				}
+				if progressCallback != nil {
+					progressCallback(len(result.Found)+len(result.Missing)+len(result.Failed), len(unique))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, key := range unique {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(result.Found)
+	sort.Strings(result.Missing)
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// MinExpirationTTL is the shortest expiration KV accepts, in seconds from now
+const MinExpirationTTL = 60
+
+// ExpirationFilter selects keys by whether the listing shows an expiration for them
+type ExpirationFilter int
+
+const (
+	AnyExpiration     ExpirationFilter = iota // Keys with or without an expiration
+	WithoutExpiration                         // Keys that never expire
+	WithExpiration                            // Keys that have an expiration
+)
+
+// NewExpirationFilter builds an expiration filter from the --no-expiration and
+// --has-expiration flags, which are mutually exclusive
+func NewExpirationFilter(noExpiration, hasExpiration bool) (ExpirationFilter, error) {
+	switch {
+	case noExpiration && hasExpiration:
+		return AnyExpiration, fmt.Errorf("--no-expiration and --has-expiration cannot be combined")
+	case noExpiration:
+		return WithoutExpiration, nil
+	case hasExpiration:
+		return WithExpiration, nil
+	default:
+		return AnyExpiration, nil
+	}
+}
+
+// Match returns true if the key satisfies the filter
+func (f ExpirationFilter) Match(key KeyValuePair) bool {
+	switch f {
+	case WithoutExpiration:
+		return key.Expiration <= 0
+	case WithExpiration:
+		return key.Expiration > 0
+	default:
+		return true
+	}
+}
+
+// FilterKeysByExpiration returns the keys that satisfy the filter
+func FilterKeysByExpiration(keys []KeyValuePair, filter ExpirationFilter) []KeyValuePair {
+	if filter == AnyExpiration {
+		return keys
+	}
+
+	matched := make([]KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		if filter.Match(key) {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// ExpireOptions configures setting an expiration on existing keys
+type ExpireOptions struct {
+	ExpirationTTL int64 // Seconds from now until the keys expire
+	Expiration    int64 // Unix time at which the keys expire (alternative to ExpirationTTL)
+	Concurrency   int   // Concurrent value reads (default 10)
+	BatchSize     int   // Keys per bulk write (default 1000)
+}
+
+// Validate checks that exactly one expiration is set and that it is far enough ahead
+func (o ExpireOptions) Validate() error {
+	if (o.ExpirationTTL > 0) == (o.Expiration > 0) {
+		return fmt.Errorf("exactly one of a TTL or an absolute expiration is required")
+	}
+	if o.ExpirationTTL > 0 && o.ExpirationTTL < MinExpirationTTL {
+		return fmt.Errorf("TTL must be at least %d seconds", MinExpirationTTL)
+	}
+	if o.Expiration > 0 && o.Expiration < time.Now().Unix()+MinExpirationTTL {
+		return fmt.Errorf("expiration must be at least %d seconds in the future", MinExpirationTTL)
+	}
+	return nil
+}
+
+// ExpireResult reports which keys received the new expiration
+type ExpireResult struct {
+	Updated []string          `json:"updated"`
+	Failed  map[string]string `json:"failed,omitempty"` // Key to error message
+}
+
+// ExpireKey sets an expiration on a single existing key, keeping its value and metadata
+func ExpireKey(client *api.Client, accountID, namespaceID, key string, options ExpireOptions) (*ExpireResult, error) {
+	existing, err := lookupKey(client, accountID, namespaceID, key)
+	if err != nil {
+		return nil, err
+	}
+	return ExpireKeys(client, accountID, namespaceID, []KeyValuePair{*existing}, options, nil)
+}
+
+// ExpireKeys sets an expiration on existing keys. KV cannot change the expiration of a
+// key in place, so each value is read and written back with the key's metadata from the
+// listing and the new expiration. Keys whose value cannot be read are reported as failed
+// and left unchanged. The progress callback is called as values are read and batches written.
+func ExpireKeys(client *api.Client, accountID, namespaceID string, keys []KeyValuePair, options ExpireOptions,
+	progressCallback func(completed, total int)) (*ExpireResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	total := len(keys) * 2 // Every key is read once and written once
+	completed := 0
+	report := func(n int) {
+		completed += n
+		if progressCallback != nil {
+			progressCallback(completed, total)
+		}
+	}
+
+	// Read the current values
+	values, failed := readValues(client, accountID, namespaceID, keys, options.Concurrency, func() { report(1) })
+
+	items := make([]BulkWriteItem, 0, len(keys))
+	for i, key := range keys {
+		if _, ok := failed[key.Key]; ok {
+			continue
+		}
+		item := BulkWriteItem{
+			Key:           key.Key,
+			Value:         values[i],
+			Expiration:    options.Expiration,
+			ExpirationTTL: options.ExpirationTTL,
+		}
+		if key.Metadata != nil {
+			item.Metadata = *key.Metadata
+		}
+		items = append(items, item)
+	}
+	completed += len(failed) // Failed reads have nothing to write
+
+	// Write the values back with the new expiration
+	updated, rejected := writeValues(client, accountID, namespaceID, items, options.BatchSize, report)
+	for key, msg := range rejected {
+		failed[key] = msg
+	}
+
+	result := &ExpireResult{Updated: updated, Failed: failed}
+	sort.Strings(result.Updated)
+	return result, nil
+}
@@ -1,48 +1,59 @@
 package kv
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // ExportKeysAndValuesToJSON exports all keys and values from a KV namespace to a JSON file
 // This is a simple wrapper around the parallel version with default concurrency
-func ExportKeysAndValuesToJSON(client *api.Client, accountID, namespaceID string, includeMetadata bool, progressCallback func(fetched, total int)) ([]BulkWriteItem, error) {
+func ExportKeysAndValuesToJSON(client *api.Client, accountID, namespaceID, prefix string, includeMetadata bool, progressCallback func(fetched, total int)) ([]BulkWriteItem, []ExportFailure, error) {
 	// Use the parallel version with default concurrency
-	return ExportKeysAndValuesToJSONParallel(client, accountID, namespaceID, includeMetadata, 10, progressCallback)
+	return ExportKeysAndValuesToJSONParallel(client, accountID, namespaceID, prefix, includeMetadata, 10, progressCallback)
 }
 
-// ExportKeysAndValuesToJSONParallel exports all keys and values with concurrent fetching
-func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceID string, includeMetadata bool, concurrency int, progressCallback func(fetched, total int)) ([]BulkWriteItem, error) {
+// ExportKeysAndValuesToJSONParallel exports all keys and values with concurrent fetching.
+// Keys that cannot be read are left out of the items and returned as failures.
+func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceID, prefix string, includeMetadata bool, concurrency int, progressCallback func(fetched, total int)) ([]BulkWriteItem, []ExportFailure, error) {
 	if accountID == "" {
-		return nil, fmt.Errorf("account ID is required")
+		return nil, nil, fmt.Errorf("account ID is required")
 	}
 	if namespaceID == "" {
-		return nil, fmt.Errorf("namespace ID is required")
-	}
-
-	// Use default concurrency if not specified or invalid
-	if concurrency <= 0 {
-		concurrency = 10 // Default concurrency
-	}
-	if concurrency > 50 {
-		concurrency = 50 // Cap maximum concurrency to avoid overwhelming the API
+		return nil, nil, fmt.Errorf("namespace ID is required")
 	}
 
 	// First, list all keys
-	keys, err := ListAllKeys(client, accountID, namespaceID, progressCallback)
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, progressCallback)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list keys: %w", err)
+		return nil, nil, fmt.Errorf("failed to list keys: %w", err)
 	}
+	keys = withoutChunks(keys)
+
+	var progress ProgressFunc
+	if progressCallback != nil {
+		progress = func(event ProgressEvent) { progressCallback(event.Fetched, event.Total) }
+	}
+	return fetchExportItems(client, accountID, namespaceID, keys, includeMetadata, concurrency, progress)
+}
+
+// fetchExportItems fetches the values (and optionally metadata) of the given keys concurrently,
+// reporting fetching progress with its rate and time remaining. Keys that cannot be read are
+// returned as classified failures instead of items; only an export where every key fails is
+// an error.
+func fetchExportItems(client *api.Client, accountID, namespaceID string, keys []KeyValuePair, includeMetadata bool,
+	concurrency int, progress ProgressFunc) ([]BulkWriteItem, []ExportFailure, error) {
+
+	// Use default concurrency if not specified, capped by the policy
+	policy := common.CurrentPolicy()
+	concurrency = policy.Concurrency(concurrency, 10)
 
 	if len(keys) == 0 {
-		return []BulkWriteItem{}, nil // Return empty slice, not nil
+		return []BulkWriteItem{}, nil, nil // Return empty slice, not nil
 	}
 
 	// Create result array
@@ -60,6 +71,7 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 		index int
 		item  BulkWriteItem
 		err   error
+		class ExportErrorClass // Set when the error is not an API error
 	}
 	resultChan := make(chan resultItem, concurrency*2)
 
@@ -82,7 +94,7 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 				var metadata map[string]interface{}
 
 				// Add a small delay between workers to prevent API rate limiting
-				time.Sleep(time.Duration(workerNum*5) * time.Millisecond)
+				time.Sleep(policy.Stagger(workerNum))
 
 				if includeMetadata {
 					// Get value with metadata - thread safe by using mutex
@@ -120,17 +132,55 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 					value = val
 				}
 
+				// Export split values reassembled and compressed values decompressed, without
+				// their markers, so the export reads as plain data and imports as written
+				var listed map[string]interface{}
+				if work.key.Metadata != nil {
+					listed = *work.key.Metadata
+				}
+				if IsChunked(metadata) || IsChunked(listed) {
+					assembled, err := ReadChunkedValue(client, accountID, namespaceID, work.key.Key, value)
+					if err != nil {
+						resultChan <- resultItem{
+							index: work.index,
+							err:   err,
+							class: ExportErrorDecode,
+						}
+						progressChan <- 1 // Count as processed even if error
+						continue
+					}
+					value = assembled
+					if metadata != nil {
+						metadata = withoutChunkMarker(metadata)
+					}
+				}
+				if IsCompressed(metadata) || IsCompressed(listed) {
+					decoded, err := DecompressValue(value)
+					if err != nil {
+						resultChan <- resultItem{
+							index: work.index,
+							err:   fmt.Errorf("failed to decompress value: %w", err),
+							class: ExportErrorDecode,
+						}
+						progressChan <- 1 // Count as processed even if error
+						continue
+					}
+					value = decoded
+					if metadata != nil {
+						metadata = withoutCompressionMarker(metadata)
+					}
+				}
+
+				// Binary values are exported base64-encoded, since JSON strings can't hold them
+				item := ValueItem(work.key.Key, value)
+				item.Expiration = work.key.Expiration
+				item.Metadata = metadata
+
 				// Return successful result
 				resultChan <- resultItem{
 					index: work.index,
-					// kv/export.go (continued)
-					item: BulkWriteItem{
-						Key:        work.key.Key,
-						Value:      value,
-						Expiration: work.key.Expiration,
-						Metadata:   metadata,
-					},
-					err: nil,
+					item:  item,
+					err:   nil,
 				}
 
 				// Update progress
@@ -151,28 +201,28 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 	}()
 
 	// Start a goroutine to track progress
+	tracker := newProgressTracker(progress)
 	go func() {
 		processed := 0
 		total := len(keys)
 
 		for range progressChan {
 			processed++
-			if progressCallback != nil && processed%10 == 0 { // Update progress every 10 items
-				progressCallback(processed, total)
+			if processed%10 == 0 { // Update progress every 10 items
+				tracker.report(ProgressEvent{Phase: PhaseFetching, Fetched: processed, Total: total})
 			}
 
 			if processed >= total {
 				// Final progress update
-				if progressCallback != nil {
-					progressCallback(processed, total)
-				}
+				tracker.report(ProgressEvent{Phase: PhaseFetching, Fetched: processed, Total: total})
 				close(progressChan)
 			}
 		}
 	}()
 
 	// Collect all results
-	var errMsgs []string
+	var failures []ExportFailure
+	failed := make([]bool, len(keys))
 	resultsProcessed := 0
 
 	for resultsProcessed < len(keys) {
@@ -180,8 +230,16 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 		resultsProcessed++
 
 		if result.err != nil {
-			errMsg := fmt.Sprintf("failed to get value for key index %d: %v", result.index, result.err)
-			errMsgs = append(errMsgs, errMsg)
+			class := result.class
+			if class == "" {
+				class = ClassifyExportError(result.err)
+			}
+			failures = append(failures, ExportFailure{
+				Key:   keys[result.index].Key,
+				Class: class,
+				Error: result.err.Error(),
+			})
+			failed[result.index] = true
 			continue
 		}
 
@@ -191,22 +249,25 @@ func ExportKeysAndValuesToJSONParallel(client *api.Client, accountID, namespaceI
 	// Wait for all workers to finish
 	wg.Wait()
 
-	// If we had any errors, report them
-	if len(errMsgs) > 0 {
-		// If all operations failed, return an error
-		if len(errMsgs) == len(keys) {
-			return nil, fmt.Errorf("all key fetch operations failed: %s", errMsgs[0])
-		}
-
-		// If some operations succeeded, log errors but continue
-		fmt.Printf("Warning: %d of %d key fetch operations failed\n", len(errMsgs), len(keys))
+	if len(failures) == 0 {
+		return results, nil, nil
+	}
+	if len(failures) == len(keys) {
+		return nil, failures, fmt.Errorf("all key fetch operations failed: key '%s': %s", failures[0].Key, failures[0].Error)
 	}
 
-	return results, nil
+	// Leave failed keys out rather than exporting them empty
+	items := make([]BulkWriteItem, 0, len(keys)-len(failures))
+	for i, item := range results {
+		if !failed[i] {
+			items = append(items, item)
+		}
+	}
+	return items, failures, nil
 }
 
 // FilterKeys filters keys in a KV namespace based on a custom filter function
-func FilterKeys(client *api.Client, accountID, namespaceID string, filterFunc func(key KeyValuePair) bool, progressCallback func(fetched, total int)) ([]KeyValuePair, error) {
+func FilterKeys(client *api.Client, accountID, namespaceID, prefix string, filterFunc func(key KeyValuePair) bool, progressCallback func(fetched, total int)) ([]KeyValuePair, error) {
 	if accountID == "" {
 		return nil, fmt.Errorf("account ID is required")
 	}
@@ -215,7 +276,7 @@ func FilterKeys(client *api.Client, accountID, namespaceID string, filterFunc fu
 	}
 
 	// List all keys
-	allKeys, err := ListAllKeys(client, accountID, namespaceID, progressCallback)
+	allKeys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, progressCallback)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list keys: %w", err)
 	}
@@ -251,13 +312,8 @@ func FetchAllMetadata(client *api.Client, accountID, namespaceID string, keys []
 	}
 
 	// Set proper concurrency limit
-	concurrency := maxConcurrency
-	if concurrency <= 0 {
-		concurrency = 50 // Default concurrency
-	}
-	if concurrency > 1000 {
-		concurrency = 1000 // Cap maximum concurrency to avoid system resource issues
-	}
+	policy := common.CurrentPolicy()
+	concurrency := policy.Concurrency(maxConcurrency, policy.MaxConcurrency)
 
 	// Progress tracking
 	if progressCallback == nil {
@@ -291,7 +347,7 @@ func FetchAllMetadata(client *api.Client, accountID, namespaceID string, keys []
 					accountID, namespaceID, encodedKey)
 
 				// Fetch the metadata
-				metadataResp, err := client.Request(http.MethodGet, metadataPath, nil, nil)
+				metadataResp, err := requestMetadata(client, metadataPath)
 
 				// Skip errors - not all keys will have metadata
 				if err != nil {
@@ -299,13 +355,8 @@ func FetchAllMetadata(client *api.Client, accountID, namespaceID string, keys []
 				}
 
 				// Parse the metadata response
-				var metadataResponse struct {
-					Success bool                   `json:"success"`
-					Errors  []api.Error            `json:"errors,omitempty"`
-					Result  map[string]interface{} `json:"result,omitempty"`
-				}
-
-				if err := json.Unmarshal(metadataResp, &metadataResponse); err != nil {
+				metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp)
+				if metadataResponse == nil {
 					// Use mutex instead of atomic
 					errorCountMutex.Lock()
 					errorCount++
@@ -319,7 +370,7 @@ func FetchAllMetadata(client *api.Client, accountID, namespaceID string, keys []
 					continue
 				}
 
-				if !metadataResponse.Success {
+				if err != nil {
 					continue // Skip unsuccessful responses
 				}
 
@@ -367,3 +418,133 @@ func FetchAllMetadata(client *api.Client, accountID, namespaceID string, keys []
 
 	return metadataMap, nil
 }
+
+// DefaultSinceField is the metadata field holding a key's last modification time
+const DefaultSinceField = "updated_at"
+
+// ExportOptions configures an export
+type ExportOptions struct {
+	Prefix          string           // Only export keys with this prefix
+	IncludeMetadata bool             // Include key metadata in the exported items
+	Since           time.Time        // Only export keys modified after this time (zero exports all keys)
+	SinceField      string           // Metadata field holding the modification time (default "updated_at")
+	Expiration      ExpirationFilter // Only export keys with, or without, an expiration
+	Keys            []string         // Only export these keys, e.g. the failures of an earlier export
+	Concurrency     int              // Concurrent value requests
+}
+
+// ExportResult contains the exported items and how many keys were left out
+type ExportResult struct {
+	Items     []BulkWriteItem `json:"items"`
+	TotalKeys int             `json:"total_keys"`
+	Unchanged int             `json:"unchanged"`        // Keys modified at or before Since
+	Undated   int             `json:"undated"`          // Keys without a parseable SinceField
+	Filtered  int             `json:"filtered"`         // Keys left out by the expiration filter
+	Failed    []ExportFailure `json:"failed,omitempty"` // Keys left out because reading them failed
+}
+
+// ExportKeys exports the keys of a namespace with their values. When Since is set only keys
+// whose SinceField metadata is newer are exported, so values of unchanged keys are never
+// fetched; this makes incremental backups cheap. Keys without that field are skipped.
+func ExportKeys(client *api.Client, accountID, namespaceID string, options ExportOptions,
+	progress ProgressFunc) (*ExportResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.SinceField == "" {
+		options.SinceField = DefaultSinceField
+	}
+
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.Prefix}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	keys = withoutChunks(keys)
+
+	if options.Keys != nil {
+		wanted := make(map[string]bool, len(options.Keys))
+		for _, key := range options.Keys {
+			wanted[key] = true
+		}
+		selected := keys[:0]
+		for _, key := range keys {
+			if wanted[key.Key] {
+				selected = append(selected, key)
+			}
+		}
+		keys = selected
+	}
+
+	result := &ExportResult{TotalKeys: len(keys)}
+
+	if options.Expiration != AnyExpiration {
+		keys = FilterKeysByExpiration(keys, options.Expiration)
+		result.Filtered = result.TotalKeys - len(keys)
+	}
+
+	if !options.Since.IsZero() {
+		keys, err = filterKeysModifiedSince(client, accountID, namespaceID, keys, options, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Items, result.Failed, err = fetchExportItems(client, accountID, namespaceID, keys, options.IncludeMetadata,
+		options.Concurrency, progress)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// filterKeysModifiedSince keeps the keys whose since-field is after options.Since, using listing
+// metadata first and only fetching metadata for keys listed without any
+func filterKeysModifiedSince(client *api.Client, accountID, namespaceID string, keys []KeyValuePair,
+	options ExportOptions, result *ExportResult) ([]KeyValuePair, error) {
+
+	var missing []KeyValuePair
+	for _, key := range keys {
+		if key.Metadata == nil {
+			missing = append(missing, key)
+		}
+	}
+
+	var fetched map[string]*KeyValueMetadata
+	if len(missing) > 0 {
+		var err error
+		fetched, err = FetchAllMetadata(client, accountID, namespaceID, missing, options.Concurrency, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+		}
+	}
+
+	var changed []KeyValuePair
+	for _, key := range keys {
+		metadata := key.Metadata
+		if metadata == nil {
+			metadata = fetched[key.Key]
+		}
+
+		var value interface{}
+		if metadata != nil {
+			value = (*metadata)[options.SinceField]
+		}
+		modified, err := common.ParseTimestamp(value)
+		if err != nil {
+			result.Undated++
+			continue
+		}
+		if !modified.After(options.Since) {
+			result.Unchanged++
+			continue
+		}
+		changed = append(changed, key)
+	}
+
+	return changed, nil
+}
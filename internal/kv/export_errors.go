@@ -0,0 +1,139 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportErrorClass groups the reasons a key could not be exported
+type ExportErrorClass string
+
+const (
+	// ExportErrorNotFound is a key deleted or expired between listing and reading it
+	ExportErrorNotFound ExportErrorClass = "not_found"
+	// ExportErrorRateLimited is a read rejected with HTTP 429
+	ExportErrorRateLimited ExportErrorClass = "rate_limited"
+	// ExportErrorAuth is a read rejected with HTTP 401 or 403
+	ExportErrorAuth ExportErrorClass = "auth"
+	// ExportErrorServer is a read that failed with an HTTP 5xx error
+	ExportErrorServer ExportErrorClass = "server"
+	// ExportErrorNetwork is a read that failed before a response arrived
+	ExportErrorNetwork ExportErrorClass = "network"
+	// ExportErrorDecode is a compressed value that could not be decompressed, or a split value
+	// whose chunks could not be reassembled
+	ExportErrorDecode ExportErrorClass = "decode"
+	// ExportErrorOther is any other failure
+	ExportErrorOther ExportErrorClass = "other"
+)
+
+// httpStatusPattern finds the status code in API error messages
+var httpStatusPattern = regexp.MustCompile(`HTTP (\d{3})`)
+
+// ClassifyExportError returns the class of an error from reading a key
+func ClassifyExportError(err error) ExportErrorClass {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+
+	if match := httpStatusPattern.FindStringSubmatch(msg); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		switch {
+		case status == 404:
+			return ExportErrorNotFound
+		case status == 429:
+			return ExportErrorRateLimited
+		case status == 401 || status == 403:
+			return ExportErrorAuth
+		case status >= 500:
+			return ExportErrorServer
+		}
+		return ExportErrorOther
+	}
+
+	lower := strings.ToLower(msg)
+	for _, marker := range []string{"timeout", "connection refused", "connection reset", "eof", "broken pipe", "no such host"} {
+		if strings.Contains(lower, marker) {
+			return ExportErrorNetwork
+		}
+	}
+	return ExportErrorOther
+}
+
+// ExportFailure is a key that could not be exported
+type ExportFailure struct {
+	Key   string           `json:"key"`
+	Class ExportErrorClass `json:"class"`
+	Error string           `json:"error"`
+}
+
+// ExportErrorReport is the machine-readable list of keys an export left out because
+// reading them failed
+type ExportErrorReport struct {
+	NamespaceID string                   `json:"namespace_id"`
+	TotalKeys   int                      `json:"total_keys"`
+	Failed      int                      `json:"failed"`
+	ByClass     map[ExportErrorClass]int `json:"by_class"`
+	Errors      []ExportFailure          `json:"errors"`
+}
+
+// NewExportErrorReport builds a report from the failures of an export, sorted by key
+func NewExportErrorReport(namespaceID string, totalKeys int, failures []ExportFailure) *ExportErrorReport {
+	report := &ExportErrorReport{
+		NamespaceID: namespaceID,
+		TotalKeys:   totalKeys,
+		Failed:      len(failures),
+		ByClass:     CountExportFailures(failures),
+		Errors:      append([]ExportFailure{}, failures...),
+	}
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Key < report.Errors[j].Key })
+	return report
+}
+
+// CountExportFailures counts failures per error class
+func CountExportFailures(failures []ExportFailure) map[ExportErrorClass]int {
+	counts := make(map[ExportErrorClass]int)
+	for _, failure := range failures {
+		counts[failure.Class]++
+	}
+	return counts
+}
+
+// Keys returns the names of the keys in the report
+func (r *ExportErrorReport) Keys() []string {
+	keys := make([]string, len(r.Errors))
+	for i, failure := range r.Errors {
+		keys[i] = failure.Key
+	}
+	return keys
+}
+
+// WriteExportErrorReport writes a report as indented JSON
+func WriteExportErrorReport(path string, report *ExportErrorReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write error report: %w", err)
+	}
+	return nil
+}
+
+// ReadExportErrorReport reads a report written by WriteExportErrorReport
+func ReadExportErrorReport(path string) (*ExportErrorReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error report: %w", err)
+	}
+	var report ExportErrorReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse error report %s: %w", path, err)
+	}
+	return &report, nil
+}
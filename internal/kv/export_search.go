@@ -0,0 +1,80 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"cache-kv-purger/internal/common"
+)
+
+// ReadExportFile reads a file written by 'kv export': a JSON array of keys with their
+// values, metadata and expiration
+func ReadExportFile(path string) ([]BulkWriteItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	var items []BulkWriteItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse export file %s (must be a JSON array written by 'kv export'): %w", path, err)
+	}
+	return items, nil
+}
+
+// SearchExport evaluates search options against the keys of an export instead of the
+// namespace, so the keys a search, delete or purge would affect can be reviewed without
+// any API requests. Matching follows Search: a SearchValue is looked for anywhere in the
+// metadata, otherwise TagField and TagValue match a metadata field, and the name and
+// expiration filters narrow the matches. Keys are checked in name order, as the API lists
+// them, so Limit, Offset and AfterKey select the same window a capped search would.
+func SearchExport(items []BulkWriteItem, options SearchOptions) ([]KeyValuePair, error) {
+	nameFilter, err := NewNameFilter(options.Prefix, options.NameContains, options.NameRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]BulkWriteItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	var keys []KeyValuePair
+	for _, item := range sorted {
+		if options.AfterKey != "" && item.Key <= options.AfterKey {
+			continue
+		}
+
+		key := KeyValuePair{Key: item.Key, Value: item.Value, Expiration: item.Expiration}
+		if item.Metadata != nil {
+			metadata := KeyValueMetadata(item.Metadata)
+			key.Metadata = &metadata
+		}
+
+		if !nameFilter.Match(key.Key) || !options.Expiration.Match(key) {
+			continue
+		}
+		switch {
+		case options.SearchValue != "":
+			if item.Metadata == nil || !SmartMetadataSearch(item.Metadata, options.SearchValue) {
+				continue
+			}
+		case options.TagField != "":
+			if !common.MetadataFieldMatches(item.Metadata, options.TagField, options.TagValue) {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if options.Offset > 0 {
+		if options.Offset >= len(keys) {
+			return nil, nil
+		}
+		keys = keys[options.Offset:]
+	}
+	if options.Limit > 0 && len(keys) > options.Limit {
+		keys = keys[:options.Limit]
+	}
+	return keys, nil
+}
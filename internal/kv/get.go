@@ -1,14 +1,15 @@
 package kv
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"cache-kv-purger/internal/api"
 	"cache-kv-purger/internal/auth"
+	"cache-kv-purger/internal/common"
 )
 
 // GetValue gets a value from a KV namespace
@@ -69,20 +70,14 @@ func GetKeyWithMetadata(client *api.Client, accountID, namespaceID, key string)
 	metadataPath := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/metadata/%s", accountID, namespaceID, encodedKey)
 
 	// Request metadata specifically
-	metadataRespBody, err := client.Request(http.MethodGet, metadataPath, nil, nil)
+	metadataRespBody, err := requestMetadata(client, metadataPath)
 
 	// Metadata is optional, so if there's an error (like 404), we just continue without metadata
 	var metadata *KeyValueMetadata
 
 	if err == nil {
 		// Try to parse the metadata response
-		var metadataResponse struct {
-			Success bool                   `json:"success"`
-			Errors  []api.Error            `json:"errors,omitempty"`
-			Result  map[string]interface{} `json:"result,omitempty"`
-		}
-
-		if err := json.Unmarshal(metadataRespBody, &metadataResponse); err == nil && metadataResponse.Success {
+		if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataRespBody); err == nil {
 			if len(metadataResponse.Result) > 0 {
 				metadataObj := KeyValueMetadata(metadataResponse.Result)
 				metadata = &metadataObj
@@ -110,15 +105,100 @@ func KeyExists(client *api.Client, accountID, namespaceID, key string) (bool, er
 		return false, fmt.Errorf("key is required")
 	}
 
+	// We'll use a HEAD request to check if the key exists without retrieving the value
+	resp, err := headValue(client, accountID, namespaceID, key)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode == http.StatusOK {
+		return true, nil
+	} else if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else {
+		// Read response body for error details
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("API error checking key existence (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+}
+
+// GetValueSize returns the size of a value in bytes using a HEAD request, without
+// downloading the value. Returns -1 if the API does not report a Content-Length.
+func GetValueSize(client *api.Client, accountID, namespaceID, key string) (int64, error) {
+	if accountID == "" {
+		return 0, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return 0, fmt.Errorf("namespace ID is required")
+	}
+	if key == "" {
+		return 0, fmt.Errorf("key is required")
+	}
+
+	resp, err := headValue(client, accountID, namespaceID, key)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("API error getting value size (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	return resp.ContentLength, nil
+}
+
+// GetValueSizes returns the value sizes of several keys, fetched concurrently with HEAD requests
+func GetValueSizes(client *api.Client, accountID, namespaceID string, keys []string, concurrency int) (map[string]int64, error) {
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	sizes := make(map[string]int64, len(keys))
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			size, err := GetValueSize(client, accountID, namespaceID, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to get size of key '%s': %w", key, err)
+				}
+				return
+			}
+			sizes[key] = size
+		}(key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return sizes, nil
+}
+
+// headValue sends a HEAD request for a value
+// This is handled manually since the API client only supports JSON responses
+func headValue(client *api.Client, accountID, namespaceID, key string) (*http.Response, error) {
 	// URL encode the key
 	encodedKey := url.PathEscape(key)
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/values/%s", accountID, namespaceID, encodedKey)
 
-	// We'll use a HEAD request to check if the key exists without retrieving the value
-	// This is handled manually since it's a special case
 	req, err := http.NewRequest(http.MethodHead, client.BaseURL+path, nil)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	// Add authentication headers
@@ -132,21 +212,37 @@ func KeyExists(client *api.Client, accountID, namespaceID, key string) (bool, er
 		}
 	}
 
-	// Make request
-	resp, err := client.HTTPClient.Do(req)
+	return client.Do(req)
+}
+
+// GetMetadata gets only the metadata of a key, returning nil if the key has none
+func GetMetadata(client *api.Client, accountID, namespaceID, key string) (*KeyValueMetadata, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+
+	encodedKey := url.PathEscape(key)
+	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/metadata/%s", accountID, namespaceID, encodedKey)
+
+	respBody, err := requestMetadata(client, path)
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Check status code
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
-	} else if resp.StatusCode == http.StatusNotFound {
-		return false, nil
-	} else {
-		// Read response body for error details
-		body, _ := io.ReadAll(resp.Body)
-		return false, fmt.Errorf("API error checking key existence (HTTP %d): %s", resp.StatusCode, string(body))
+	metadataResponse, err := api.DecodeResponse[map[string]interface{}](respBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	if len(metadataResponse.Result) == 0 {
+		return nil, nil
 	}
+	metadata := KeyValueMetadata(metadataResponse.Result)
+	return &metadata, nil
 }
@@ -0,0 +1,344 @@
+package kv
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// journalDirName is the name of the local journal directory in the home directory
+const journalDirName = ".cache-kv-purger-journal"
+
+// JournalKeyPrefix is the key prefix of journals stored in a KV namespace
+const JournalKeyPrefix = "journal/"
+
+// maxJournalValueSize is the largest journal that fits in one KV value
+const maxJournalValueSize = 25 * 1024 * 1024
+
+// Journal records the state of keys before an operation changed or deleted them, so
+// the operation can be undone
+type Journal struct {
+	ID          string         `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	AccountID   string         `json:"account_id"`
+	NamespaceID string         `json:"namespace_id"`
+	Operation   string         `json:"operation"` // Command that changed the keys, e.g. "kv delete"
+	Entries     []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is the before-image of one key. Keys that did not exist are deleted on undo.
+type JournalEntry struct {
+	Key     string         `json:"key"`
+	Existed bool           `json:"existed"`
+	Before  *BulkWriteItem `json:"before,omitempty"` // Value and metadata, base64 for binary values
+}
+
+// JournalSummary describes a stored journal without its entries
+type JournalSummary struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	NamespaceID string    `json:"namespace_id"`
+	Operation   string    `json:"operation"`
+	Keys        int       `json:"keys"`
+}
+
+// Summary returns the journal without its entries
+func (j *Journal) Summary() JournalSummary {
+	return JournalSummary{ID: j.ID, CreatedAt: j.CreatedAt, NamespaceID: j.NamespaceID, Operation: j.Operation, Keys: len(j.Entries)}
+}
+
+// JournalStore saves and loads journals
+type JournalStore interface {
+	Save(journal *Journal) error
+	Load(id string) (*Journal, error)
+	List() ([]JournalSummary, error)
+}
+
+// NewJournalID returns a journal ID that sorts by creation time
+func NewJournalID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// CaptureJournal reads the current value and metadata of keys about to be changed. Keys
+// that don't exist are recorded as such. Expirations cannot be read back per key, so
+// restored keys don't expire.
+func CaptureJournal(client *api.Client, accountID, namespaceID, operation string, keys []string, concurrency int) (*Journal, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	journal := &Journal{
+		ID:          NewJournalID(),
+		CreatedAt:   time.Now().UTC(),
+		AccountID:   accountID,
+		NamespaceID: namespaceID,
+		Operation:   operation,
+		Entries:     make([]JournalEntry, len(keys)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+
+	for i, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry, err := captureEntry(client, accountID, namespaceID, key)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to read key '%s': %w", key, err)
+				}
+				mu.Unlock()
+				return
+			}
+			journal.Entries[i] = entry
+		}(i, key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return journal, nil
+}
+
+// captureEntry reads the before-image of one key
+func captureEntry(client *api.Client, accountID, namespaceID, key string) (JournalEntry, error) {
+	value, err := GetValue(client, accountID, namespaceID, key)
+	if err != nil {
+		if strings.Contains(err.Error(), "HTTP 404") {
+			return JournalEntry{Key: key}, nil
+		}
+		return JournalEntry{}, err
+	}
+
+	item := ValueItem(key, value)
+
+	metadata, err := GetMetadata(client, accountID, namespaceID, key)
+	if err != nil && !strings.Contains(err.Error(), "HTTP 404") {
+		return JournalEntry{}, err
+	}
+	if metadata != nil {
+		item.Metadata = *metadata
+	}
+
+	return JournalEntry{Key: key, Existed: true, Before: &item}, nil
+}
+
+// UndoOptions configures an undo
+type UndoOptions struct {
+	BatchSize int
+	DryRun    bool // Only count what would be restored and deleted
+}
+
+// UndoResult is the outcome of an undo
+type UndoResult struct {
+	Restored int `json:"restored"` // Keys written back with their before-image
+	Deleted  int `json:"deleted"`  // Keys deleted because they did not exist before
+}
+
+// UndoJournal restores the keys of a journal to their recorded state: keys that existed
+// are written back with their value and metadata, and keys that did not are deleted
+func UndoJournal(client *api.Client, journal *Journal, options UndoOptions) (*UndoResult, error) {
+	var restore []BulkWriteItem
+	var remove []string
+	for _, entry := range journal.Entries {
+		if entry.Existed && entry.Before != nil {
+			restore = append(restore, *entry.Before)
+		} else {
+			remove = append(remove, entry.Key)
+		}
+	}
+
+	result := &UndoResult{}
+	if options.DryRun {
+		result.Restored, result.Deleted = len(restore), len(remove)
+		return result, nil
+	}
+
+	if len(restore) > 0 {
+		written, err := WriteMultipleValuesInBatches(client, journal.AccountID, journal.NamespaceID, restore, options.BatchSize, nil)
+		result.Restored = written
+		if err != nil {
+			return result, fmt.Errorf("failed to restore keys: %w", err)
+		}
+	}
+	if len(remove) > 0 {
+		if err := DeleteMultipleValuesInBatches(client, journal.AccountID, journal.NamespaceID, remove, options.BatchSize, nil); err != nil {
+			return result, fmt.Errorf("failed to delete keys created after the journal: %w", err)
+		}
+		result.Deleted = len(remove)
+	}
+
+	return result, nil
+}
+
+// LocalJournalStore keeps journals as JSON files in a directory
+type LocalJournalStore struct {
+	Dir string
+}
+
+// NewLocalJournalStore returns a store in dir, or in ~/.cache-kv-purger-journal if dir is empty
+func NewLocalJournalStore(dir string) (*LocalJournalStore, error) {
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.New("cannot determine home directory for journal directory")
+		}
+		dir = filepath.Join(homeDir, journalDirName)
+	}
+	return &LocalJournalStore{Dir: dir}, nil
+}
+
+// Save writes a journal file
+func (s *LocalJournalStore) Save(journal *Journal) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, journal.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Load reads a journal file
+func (s *LocalJournalStore) Load(id string) (*Journal, error) {
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid journal ID '%s'", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.Dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("journal '%s' not found in %s", id, s.Dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return parseJournal(data)
+}
+
+// List returns the journals in the directory, newest first
+func (s *LocalJournalStore) List() ([]JournalSummary, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []JournalSummary{}
+	for _, file := range files {
+		journal, err := s.Load(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, journal.Summary())
+	}
+	sortJournalSummaries(summaries)
+	return summaries, nil
+}
+
+// KVJournalStore keeps journals as values of a dedicated KV namespace, under JournalKeyPrefix.
+// A summary of each journal is kept in its metadata so journals can be listed cheaply.
+type KVJournalStore struct {
+	Client      *api.Client
+	AccountID   string
+	NamespaceID string
+}
+
+// Save writes a journal value
+func (s *KVJournalStore) Save(journal *Journal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxJournalValueSize {
+		return fmt.Errorf("journal of %d keys is %d bytes, more than a KV value can hold; use a local journal", len(journal.Entries), len(data))
+	}
+
+	summary, err := json.Marshal(journal.Summary())
+	if err != nil {
+		return err
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(summary, &metadata); err != nil {
+		return err
+	}
+
+	item := BulkWriteItem{Key: JournalKeyPrefix + journal.ID, Value: string(data), Metadata: metadata}
+	if err := WriteMultipleValues(s.Client, s.AccountID, s.NamespaceID, []BulkWriteItem{item}); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Load reads a journal value
+func (s *KVJournalStore) Load(id string) (*Journal, error) {
+	value, err := GetValue(s.Client, s.AccountID, s.NamespaceID, JournalKeyPrefix+id)
+	if err != nil {
+		if strings.Contains(err.Error(), "HTTP 404") {
+			return nil, fmt.Errorf("journal '%s' not found in namespace %s", id, s.NamespaceID)
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return parseJournal([]byte(value))
+}
+
+// List returns the journals of the namespace from their metadata, newest first
+func (s *KVJournalStore) List() ([]JournalSummary, error) {
+	keys, err := ListAllKeysWithOptions(s.Client, s.AccountID, s.NamespaceID, &ListKeysOptions{Prefix: JournalKeyPrefix}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+
+	summaries := []JournalSummary{}
+	for _, key := range keys {
+		summary := JournalSummary{ID: strings.TrimPrefix(key.Key, JournalKeyPrefix)}
+		if key.Metadata != nil {
+			if data, err := json.Marshal(*key.Metadata); err == nil {
+				_ = json.Unmarshal(data, &summary)
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sortJournalSummaries(summaries)
+	return summaries, nil
+}
+
+// parseJournal decodes a stored journal
+func parseJournal(data []byte) (*Journal, error) {
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse journal: %w", err)
+	}
+	return &journal, nil
+}
+
+// sortJournalSummaries orders journals newest first
+func sortJournalSummaries(summaries []JournalSummary) {
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID > summaries[j].ID })
+}
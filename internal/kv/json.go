@@ -0,0 +1,128 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// JSONUpdateResult describes a JSON value rewritten by UpdateJSONValue
+type JSONUpdateResult struct {
+	Key        string            `json:"key"`
+	Value      string            `json:"value"`
+	Expiration int64             `json:"expiration,omitempty"`
+	Metadata   *KeyValueMetadata `json:"metadata,omitempty"`
+	Written    bool              `json:"written"`
+}
+
+// DecodeJSONValue parses a stored value as JSON, keeping numbers exactly as written
+func DecodeJSONValue(value string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("value is not valid JSON: unexpected data after the top-level value")
+	}
+	return doc, nil
+}
+
+// EncodeJSONValue encodes a JSON document for storage without escaping HTML characters
+func EncodeJSONValue(doc interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// GetJSONValue reads a key and parses its value as JSON
+func GetJSONValue(client *api.Client, accountID, namespaceID, key string) (interface{}, error) {
+	value, err := GetValue(client, accountID, namespaceID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get value: %w", err)
+	}
+	return DecodeJSONValue(value)
+}
+
+// lookupKey returns the listing entry of a key, which carries its expiration and metadata.
+// Keys are listed in lexicographic order, so an exact match is the first key with its prefix.
+func lookupKey(client *api.Client, accountID, namespaceID, key string) (*KeyValuePair, error) {
+	result, err := ListKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: key, Limit: 10})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up key: %w", err)
+	}
+	for _, k := range result.Keys {
+		if k.Key == key {
+			return &k, nil
+		}
+	}
+	return nil, fmt.Errorf("key '%s' not found", key)
+}
+
+// UpdateJSONValue reads a key, parses its value as JSON, applies update to the document
+// and writes the result back with the key's expiration and metadata unchanged. KV has no
+// compare-and-swap, so a write to the same key between the read and the write is lost.
+// With dryRun the updated value is returned without writing it.
+func UpdateJSONValue(client *api.Client, accountID, namespaceID, key string,
+	update func(doc interface{}) (interface{}, error), dryRun bool) (*JSONUpdateResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+
+	listing, err := lookupKey(client, accountID, namespaceID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := GetJSONValue(client, accountID, namespaceID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = update(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := EncodeJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &JSONUpdateResult{
+		Key:        key,
+		Value:      value,
+		Expiration: listing.Expiration,
+		Metadata:   listing.Metadata,
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	options := &WriteOptions{Expiration: listing.Expiration}
+	if listing.Metadata != nil {
+		options.Metadata = *listing.Metadata
+	}
+	if err := WriteValue(client, accountID, namespaceID, key, value, options); err != nil {
+		return nil, fmt.Errorf("failed to write value: %w", err)
+	}
+	result.Written = true
+
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package kv
+
+import (
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+const (
+	// MaxNamespacesPerAccount is the most KV namespaces Cloudflare allows in one account
+	MaxNamespacesPerAccount = 1000
+
+	// APIRequestsPerWindow is the documented Cloudflare API rate limit, per APIRateWindow,
+	// used when responses don't report the quota
+	APIRequestsPerWindow = 1200
+	APIRateWindow        = 5 * time.Minute
+
+	// limitWarnRatio is the share of a limit above which usage is reported as a warning
+	limitWarnRatio = 0.8
+)
+
+// LimitStatus classifies usage against a limit
+type LimitStatus string
+
+const (
+	LimitOK       LimitStatus = "ok"
+	LimitWarning  LimitStatus = "warning"  // Above limitWarnRatio of the limit
+	LimitExceeded LimitStatus = "exceeded" // At or over the limit
+)
+
+// limitStatus returns the status of used out of limit, or LimitOK without a limit
+func limitStatus(used, limit int) LimitStatus {
+	switch {
+	case limit <= 0:
+		return LimitOK
+	case used >= limit:
+		return LimitExceeded
+	case float64(used) > limitWarnRatio*float64(limit):
+		return LimitWarning
+	default:
+		return LimitOK
+	}
+}
+
+// NamespaceKeyUsage is the number of keys in a namespace against the configured key limit
+type NamespaceKeyUsage struct {
+	ID        string      `json:"id"`
+	Title     string      `json:"title"`
+	Keys      int         `json:"keys"`
+	KeysExact bool        `json:"keys_exact"` // False when only the first pages were counted
+	Limit     int         `json:"limit,omitempty"`
+	Status    LimitStatus `json:"status,omitempty"` // Empty when the keys couldn't be counted
+	Error     string      `json:"error,omitempty"`
+}
+
+// AccountLimits is the KV capacity of an account: its namespaces against the account cap,
+// optionally the keys of each namespace, and the API quota seen while checking
+type AccountLimits struct {
+	Namespaces      int                 `json:"namespaces"`
+	NamespaceLimit  int                 `json:"namespace_limit"`
+	NamespaceStatus LimitStatus         `json:"namespace_status"`
+	KeyLimit        int                 `json:"key_limit,omitempty"` // namespace_key_limit of the config, 0 for none
+	KeyUsage        []NamespaceKeyUsage `json:"key_usage,omitempty"`
+	RateLimit       *RateLimitUsage     `json:"rate_limit"`
+}
+
+// RateLimitUsage is the API quota reported by the last response, or the documented limit
+// when responses carried no rate limit headers
+type RateLimitUsage struct {
+	Reported       bool        `json:"reported"`
+	Limit          int         `json:"limit"`
+	Remaining      int         `json:"remaining"` // Only set when Reported
+	ResetSeconds   int         `json:"reset_seconds,omitempty"`
+	WindowSeconds  int         `json:"window_seconds,omitempty"` // Window of the documented limit
+	Status         LimitStatus `json:"status"`
+	MaxConcurrency int         `json:"max_concurrency"` // Current adaptive in-flight limit
+	RequestsPerSec int         `json:"requests_per_second"`
+}
+
+// LimitsOptions controls CheckAccountLimits
+type LimitsOptions struct {
+	CountKeys   bool // Count the keys of every namespace
+	MaxPages    int  // Key pages listed per namespace; 0 for EstimateMaxPages
+	KeyLimit    int  // Keys a namespace may hold, 0 for none
+	Concurrency int  // Namespaces counted at once
+}
+
+// CheckAccountLimits lists the namespaces of an account and, with CountKeys, counts the keys
+// of each, then reports them against the account and configured limits along with the API
+// quota of the last response
+func CheckAccountLimits(client *api.Client, accountID string, options LimitsOptions) (*AccountLimits, error) {
+	namespaces, err := ListNamespaces(client, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &AccountLimits{
+		Namespaces:      len(namespaces),
+		NamespaceLimit:  MaxNamespacesPerAccount,
+		NamespaceStatus: limitStatus(len(namespaces), MaxNamespacesPerAccount),
+		KeyLimit:        options.KeyLimit,
+	}
+
+	if options.CountKeys {
+		limits.KeyUsage = countNamespaceKeys(client, accountID, namespaces, options)
+	}
+
+	limits.RateLimit = currentRateLimitUsage()
+	return limits, nil
+}
+
+// countNamespaceKeys counts the keys of each namespace, a few namespaces at a time. A
+// namespace whose keys can't be listed is reported with its error.
+func countNamespaceKeys(client *api.Client, accountID string, namespaces []Namespace, options LimitsOptions) []NamespaceKeyUsage {
+	usage := make([]NamespaceKeyUsage, len(namespaces))
+	workers := common.CurrentPolicy().Concurrency(options.Concurrency, 5)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i, ns := range namespaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ns Namespace) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entry := NamespaceKeyUsage{ID: ns.ID, Title: ns.Title, Limit: options.KeyLimit}
+			keys, exact, err := CountKeys(client, accountID, ns.ID, "", options.MaxPages)
+			if err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Keys, entry.KeysExact = keys, exact
+				entry.Status = limitStatus(keys, options.KeyLimit)
+			}
+			usage[i] = entry
+		}(i, ns)
+	}
+	wg.Wait()
+	return usage
+}
+
+// currentRateLimitUsage reports the quota of the last API response, falling back to the
+// documented limit
+func currentRateLimitUsage() *RateLimitUsage {
+	policy := common.CurrentPolicy()
+	usage := &RateLimitUsage{
+		Limit:          APIRequestsPerWindow,
+		WindowSeconds:  int(APIRateWindow.Seconds()),
+		Status:         LimitOK,
+		MaxConcurrency: common.CurrentConcurrencyLimit(),
+		RequestsPerSec: policy.RateLimit,
+	}
+
+	status, ok := common.CurrentRateLimitStatus()
+	if !ok {
+		return usage
+	}
+	usage.Reported = true
+	usage.Remaining = status.Remaining
+	usage.ResetSeconds = int(status.Reset.Seconds())
+	usage.WindowSeconds = 0
+	if status.Limit > 0 {
+		usage.Limit = status.Limit
+		usage.Status = limitStatus(status.Limit-status.Remaining, status.Limit)
+	} else {
+		usage.Limit = 0
+		if status.Remaining <= 0 {
+			usage.Status = LimitExceeded
+		}
+	}
+	return usage
+}
+
+// Worst returns the most severe status of the namespace count, the counted namespaces and
+// the API quota
+func (l *AccountLimits) Worst() LimitStatus {
+	statuses := []LimitStatus{l.NamespaceStatus}
+	for _, usage := range l.KeyUsage {
+		statuses = append(statuses, usage.Status)
+	}
+	if l.RateLimit != nil {
+		statuses = append(statuses, l.RateLimit.Status)
+	}
+
+	worst := LimitOK
+	for _, status := range statuses {
+		if status == LimitExceeded {
+			return LimitExceeded
+		}
+		if status == LimitWarning {
+			worst = LimitWarning
+		}
+	}
+	return worst
+}
@@ -1,12 +1,13 @@
 package kv
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // ListKeys lists all keys in a KV namespace
@@ -56,74 +57,42 @@ func ListKeysWithOptions(client *api.Client, accountID, namespaceID string, opti
 		return nil, err
 	}
 
-	var keysResp KeyValuesResponse
-	if err := json.Unmarshal(respBody, &keysResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !keysResp.Success {
-		errorStr := "API reported failure"
-		if len(keysResp.Errors) > 0 {
-			errorStr = keysResp.Errors[0].Message
-		}
-		return nil, fmt.Errorf("failed to list keys: %s", errorStr)
+	keysResp, err := api.DecodeResponse[[]KeyValuePair](respBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
 	}
 
 	// Prepare result
 	result := &ListKeysResult{
-		Keys:    keysResp.Result,
-		Cursor:  keysResp.ResultInfo.Cursor,
-		HasMore: keysResp.ResultInfo.Cursor != "",
+		Keys:       keysResp.Result,
+		Cursor:     keysResp.ResultInfo.Cursor,
+		HasMore:    keysResp.ResultInfo.Cursor != "",
+		TotalCount: keysResp.ResultInfo.Total,
 	}
 
 	return result, nil
 }
 
-// ListAllKeysWithOptions lists all keys in a KV namespace, handling pagination automatically with custom options
+// ListAllKeysWithOptions lists all keys in a KV namespace, handling pagination automatically with custom options.
+// A listing whose cursor expires restarts without repeating keys, as ListKeysWithPagination does,
+// with a warning on stderr; use ListKeysWithPagination for the restart count.
 func ListAllKeysWithOptions(client *api.Client, accountID, namespaceID string, options *ListKeysOptions, progressCallback func(fetched, total int)) ([]KeyValuePair, error) {
-	var allKeys []KeyValuePair
-
-	// Create options if not provided
-	if options == nil {
-		options = &ListKeysOptions{
-			Limit: 1000, // Maximum limit per request
-		}
-	} else if options.Limit == 0 {
-		options.Limit = 1000 // Ensure a reasonable default
-	}
+	// The client retries failed requests itself, and a full listing takes as long as it takes
+	pagOptions := &common.PaginationOptions{LogPrefix: "Keys"}
 
-	// Cloudflare API requires a minimum limit of 10
-	if options.Limit < 10 {
-		options.Limit = 10
+	result, keys, err := listKeysWithPagination(client, accountID, namespaceID, options, pagOptions, progressCallback)
+	if err != nil {
+		return nil, err
 	}
+	warnCursorRestarts(result)
+	return keys, nil
+}
 
-	// Use a copy of options so we don't modify the original
-	requestOptions := *options
-
-	totalFetched := 0
-
-	for {
-		result, err := ListKeysWithOptions(client, accountID, namespaceID, &requestOptions)
-		if err != nil {
-			return nil, err
-		}
-
-		allKeys = append(allKeys, result.Keys...)
-		totalFetched += len(result.Keys)
-
-		if progressCallback != nil {
-			progressCallback(totalFetched, -1) // -1 means total unknown
-		}
-
-		if !result.HasMore {
-			break
-		}
-
-		// Update cursor for next request
-		requestOptions.Cursor = result.Cursor
+// warnCursorRestarts reports on stderr that a key listing restarted after its cursor expired
+func warnCursorRestarts(result *common.PaginationResult) {
+	if result != nil && result.CursorRestarts > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: key listing restarted %d times after its cursor expired\n", result.CursorRestarts)
 	}
-
-	return allKeys, nil
 }
 
 // ListAllKeys lists all keys in a KV namespace, handling pagination automatically (legacy function)
@@ -1,10 +1,7 @@
 package kv
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/url"
 	"time"
 
 	"cache-kv-purger/internal/api"
@@ -13,8 +10,16 @@ import (
 
 // ListKeysWithPagination is an enhanced version of ListKeysWithOptions that provides
 // better pagination handling and debugging capabilities
+// The result counts the cursor restarts of the listing.
 func ListKeysWithPagination(client *api.Client, accountID, namespaceID string, options *ListKeysOptions,
 	pagOptions *common.PaginationOptions) (*common.PaginationResult, []KeyValuePair, error) {
+	return listKeysWithPagination(client, accountID, namespaceID, options, pagOptions, nil)
+}
+
+// listKeysWithPagination lists keys like ListKeysWithPagination, reporting the number of keys
+// collected after each page to progressCallback
+func listKeysWithPagination(client *api.Client, accountID, namespaceID string, options *ListKeysOptions,
+	pagOptions *common.PaginationOptions, progressCallback func(fetched, total int)) (*common.PaginationResult, []KeyValuePair, error) {
 
 	if accountID == "" {
 		return nil, nil, fmt.Errorf("account ID is required")
@@ -26,7 +31,7 @@ func ListKeysWithPagination(client *api.Client, accountID, namespaceID string, o
 	// Initialize pagination options if not provided
 	if pagOptions == nil {
 		pagOptions = &common.PaginationOptions{
-			MaxRetries: 3,
+			MaxRetries: common.CurrentPolicy().Retries,
 			Timeout:    30 * time.Second,
 			LogPrefix:  "Keys",
 		}
@@ -51,11 +56,13 @@ func ListKeysWithPagination(client *api.Client, accountID, namespaceID string, o
 
 	// Create a key listing handler to use with the pagination utility
 	handler := &keyListingHandler{
-		client:      client,
-		accountID:   accountID,
-		namespaceID: namespaceID,
-		options:     &requestOptions,
-		allKeys:     []KeyValuePair{},
+		client:           client,
+		accountID:        accountID,
+		namespaceID:      namespaceID,
+		options:          &requestOptions,
+		startCursor:      options.Cursor,
+		allKeys:          []KeyValuePair{},
+		progressCallback: progressCallback,
 	}
 
 	// Execute pagination
@@ -76,59 +83,25 @@ type keyListingHandler struct {
 	accountID   string
 	namespaceID string
 	options     *ListKeysOptions
+	startCursor string // Cursor of the first page, and of the first page after a restart
 	allKeys     []KeyValuePair
+	seen        map[string]bool // Keys already collected, only tracked after a cursor restart
+
+	progressCallback func(fetched, total int) // Called with the keys collected after each page
 }
 
 // FetchPage fetches a single page of keys
 func (h *keyListingHandler) FetchPage(cursor string) (interface{}, string, bool, error) {
-	// Update the cursor in our options
-	h.options.Cursor = cursor
-
-	// Call the API to fetch a page of keys
-	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/keys", h.accountID, h.namespaceID)
-
-	// Add query parameters
-	queryParams := url.Values{}
-	if h.options.Limit > 0 {
-		// Cloudflare API requires a minimum limit of 10
-		if h.options.Limit < 10 {
-			h.options.Limit = 10
-		}
-		queryParams.Set("limit", fmt.Sprintf("%d", h.options.Limit))
-	}
-	if h.options.Cursor != "" {
-		queryParams.Set("cursor", h.options.Cursor)
-	}
-	if h.options.Prefix != "" {
-		queryParams.Set("prefix", h.options.Prefix)
+	if cursor == "" {
+		cursor = h.startCursor
 	}
+	h.options.Cursor = cursor
 
-	// Make the API request
-	respBody, err := h.client.Request(http.MethodGet, path, queryParams, nil)
+	result, err := ListKeysWithOptions(h.client, h.accountID, h.namespaceID, h.options)
 	if err != nil {
 		return nil, "", false, err
 	}
-
-	// Parse the response
-	var keysResp KeyValuesResponse
-	if err := json.Unmarshal(respBody, &keysResp); err != nil {
-		return nil, "", false, fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !keysResp.Success {
-		errorStr := "API reported failure"
-		if len(keysResp.Errors) > 0 {
-			errorStr = keysResp.Errors[0].Message
-		}
-		return nil, "", false, fmt.Errorf("failed to list keys: %s", errorStr)
-	}
-
-	// Get cursor and completion status
-	nextCursor := keysResp.ResultInfo.Cursor
-	isComplete := nextCursor == ""
-
-	// Return the results
-	return keysResp.Result, nextCursor, isComplete, nil
+	return result.Keys, result.Cursor, result.Cursor == "", nil
 }
 
 // ProcessItems processes the keys returned by FetchPage
@@ -138,8 +111,32 @@ func (h *keyListingHandler) ProcessItems(items interface{}) error {
 		return fmt.Errorf("unexpected item type in key listing")
 	}
 
-	// Append the keys to our collection
-	h.allKeys = append(h.allKeys, keys...)
+	// After a restart, skip keys collected before the cursor expired
+	if h.seen != nil {
+		for _, key := range keys {
+			if !h.seen[key.Key] {
+				h.seen[key.Key] = true
+				h.allKeys = append(h.allKeys, key)
+			}
+		}
+	} else {
+		h.allKeys = append(h.allKeys, keys...)
+	}
+
+	if h.progressCallback != nil {
+		h.progressCallback(len(h.allKeys), -1) // -1 means total unknown
+	}
+	return nil
+}
+
+// PrepareRestart enables deduplication so pagination can restart after a cursor expires
+func (h *keyListingHandler) PrepareRestart() error {
+	if h.seen == nil {
+		h.seen = make(map[string]bool, len(h.allKeys))
+		for _, key := range h.allKeys {
+			h.seen[key.Key] = true
+		}
+	}
 	return nil
 }
 
@@ -151,7 +148,7 @@ func EnhancedListAllKeys(client *api.Client, accountID, namespaceID string,
 	// Configure pagination options
 	if pagOptions == nil {
 		pagOptions = &common.PaginationOptions{
-			MaxRetries: 3,
+			MaxRetries: common.CurrentPolicy().Retries,
 			Timeout:    120 * time.Second, // Longer timeout for potentially large key sets
 			LogPrefix:  "Keys",
 		}
@@ -168,6 +165,11 @@ func EnhancedListAllKeys(client *api.Client, accountID, namespaceID string,
 		progressCallback(len(keys), -1) // -1 means total unknown
 	}
 
+	// Always surface cursor restarts, they mean the listing was resumed
+	if !pagOptions.Verbose {
+		warnCursorRestarts(result)
+	}
+
 	// Log any warnings if verbose
 	if pagOptions.Verbose && len(result.Warnings) > 0 {
 		fmt.Println("\nWarnings during key listing:")
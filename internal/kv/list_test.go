@@ -0,0 +1,60 @@
+package kv
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/auth"
+)
+
+func TestListKeysCursorRestart(t *testing.T) {
+	// Two pages, where the cursor of the second expires the first time it is used
+	expired := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = io.WriteString(w, `{"success": true, "result": [{"name": "a"}, {"name": "b"}], "result_info": {"cursor": "c1"}}`)
+		case "c1":
+			if !expired {
+				expired = true
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"success": false, "errors": [{"code": 10025, "message": "cursor expired"}]}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"success": true, "result": [{"name": "b"}, {"name": "c"}], "result_info": {"cursor": ""}}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(
+		api.WithBaseURL(server.URL),
+		api.WithCredentials(&auth.CredentialInfo{Type: auth.AuthTypeAPIToken, Key: "test-token"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, keys, err := ListKeysWithPagination(client, "account", "namespace", nil, nil)
+	if err != nil {
+		t.Fatalf("listing failed: %v", err)
+	}
+	if result.CursorRestarts != 1 {
+		t.Errorf("CursorRestarts = %d, want 1", result.CursorRestarts)
+	}
+	if got := strings.Join(keyNamesOf(keys), ","); got != "a,b,c" {
+		t.Errorf("keys = %s, want a,b,c without repeats", got)
+	}
+}
+
+// keyNamesOf returns the names of keys
+func keyNamesOf(keys []KeyValuePair) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Key
+	}
+	return names
+}
@@ -0,0 +1,128 @@
+package kv
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/api"
+)
+
+// metadataCall is a metadata request that callers asking for the same key wait on
+type metadataCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// metadataFetcher sends the metadata requests of the whole process. Concurrent requests
+// for the same key of the same client share one API call, and when a worker limit is set
+// metadata requests run in that many slots shared by every command and worker pool.
+type metadataFetcher struct {
+	mu       sync.Mutex
+	inFlight map[string]*metadataCall
+	slots    chan struct{} // nil when metadata requests are only bounded by the client
+
+	statsMu   sync.Mutex
+	requests  int
+	coalesced int
+	first     time.Time
+	last      time.Time
+}
+
+var metadataFetches = &metadataFetcher{inFlight: make(map[string]*metadataCall)}
+
+// SetMetadataWorkers bounds the metadata requests in flight across the process to n, so
+// the metadata fetches of concurrent worker pools don't crowd out other API calls. 0
+// leaves them bounded only by the client's concurrency limit.
+func SetMetadataWorkers(n int) {
+	metadataFetches.mu.Lock()
+	defer metadataFetches.mu.Unlock()
+	if n <= 0 {
+		metadataFetches.slots = nil
+		return
+	}
+	metadataFetches.slots = make(chan struct{}, n)
+}
+
+// MetadataFetchStats describes the metadata requests of the run so far
+type MetadataFetchStats struct {
+	Requests  int           // Metadata API calls sent
+	Coalesced int           // Lookups answered by a call another worker already had in flight
+	Elapsed   time.Duration // Time from the first metadata lookup starting to the last finishing
+}
+
+// Rate returns the metadata lookups answered per second, coalesced ones included
+func (s MetadataFetchStats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Requests+s.Coalesced) / s.Elapsed.Seconds()
+}
+
+// String formats the stats for output, e.g. "1200 requests, 300 coalesced, 850.0 lookups/s"
+func (s MetadataFetchStats) String() string {
+	return fmt.Sprintf("%d requests, %d coalesced, %.1f lookups/s", s.Requests, s.Coalesced, s.Rate())
+}
+
+// CurrentMetadataFetchStats returns the metadata request stats of the run so far
+func CurrentMetadataFetchStats() MetadataFetchStats {
+	f := metadataFetches
+	f.statsMu.Lock()
+	defer f.statsMu.Unlock()
+	return MetadataFetchStats{Requests: f.requests, Coalesced: f.coalesced, Elapsed: f.last.Sub(f.first)}
+}
+
+// requestMetadata sends a GET for a metadata path, joining a request for the same path
+// of the same client that is already in flight. The returned body is shared by every
+// caller of the call and must not be modified.
+func requestMetadata(client *api.Client, path string) ([]byte, error) {
+	f := metadataFetches
+	callKey := fmt.Sprintf("%p %s", client, path)
+	started := time.Now()
+
+	f.mu.Lock()
+	if call, ok := f.inFlight[callKey]; ok {
+		f.mu.Unlock()
+		<-call.done
+		f.record(true, started)
+		return call.body, call.err
+	}
+	call := &metadataCall{done: make(chan struct{})}
+	f.inFlight[callKey] = call
+	slots := f.slots
+	f.mu.Unlock()
+
+	if slots != nil {
+		slots <- struct{}{}
+	}
+	call.body, call.err = client.Request(http.MethodGet, path, nil, nil)
+	if slots != nil {
+		<-slots
+	}
+
+	f.mu.Lock()
+	delete(f.inFlight, callKey)
+	f.mu.Unlock()
+	close(call.done)
+	f.record(false, started)
+
+	return call.body, call.err
+}
+
+// record counts a metadata lookup that started at the given time for the stats
+func (f *metadataFetcher) record(coalesced bool, started time.Time) {
+	now := time.Now()
+	f.statsMu.Lock()
+	defer f.statsMu.Unlock()
+	if coalesced {
+		f.coalesced++
+	} else {
+		f.requests++
+	}
+	if f.first.IsZero() || started.Before(f.first) {
+		f.first = started
+	}
+	f.last = now
+}
@@ -1,13 +1,18 @@
 package kv
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // Namespace represents a KV namespace
@@ -30,14 +35,27 @@ type NamespacesResponse struct {
 	Success    bool        `json:"success"`
 	Errors     []api.Error `json:"errors,omitempty"`
 	Messages   []string    `json:"messages,omitempty"`
-	ResultInfo struct {
-		Cursor string `json:"cursor"`
-		Count  int    `json:"count"`
-	} `json:"result_info"`
-	Result []Namespace `json:"result"`
+	ResultInfo api.PaginationInfo `json:"result_info"`
+	Result     []Namespace        `json:"result"`
 }
 
-// ListNamespaces lists all KV namespaces for an account with automatic pagination
+// DefaultNamespacesPerPage is the page size used when listing namespaces, the most the
+// API returns per page
+const DefaultNamespacesPerPage = 100
+
+// namespacesPerPage is the page size used when listing namespaces
+var namespacesPerPage = DefaultNamespacesPerPage
+
+// SetNamespacesPerPage sets the page size used when listing namespaces, between 5 and 100
+func SetNamespacesPerPage(perPage int) error {
+	if perPage < 5 || perPage > 100 {
+		return fmt.Errorf("namespaces per page must be between 5 and 100, got %d", perPage)
+	}
+	namespacesPerPage = perPage
+	return nil
+}
+
+// ListNamespaces lists all KV namespaces for an account, following every page
 func ListNamespaces(client *api.Client, accountID string) ([]Namespace, error) {
 	if accountID == "" {
 		return nil, fmt.Errorf("account ID is required")
@@ -45,46 +63,12 @@ func ListNamespaces(client *api.Client, accountID string) ([]Namespace, error) {
 
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces", accountID)
 
-	var allNamespaces []Namespace
-	var cursor string
-
-	for {
-		// Set up query parameters for pagination if we have a cursor
-		var queryParams url.Values
-		if cursor != "" {
-			queryParams = url.Values{}
-			queryParams.Set("cursor", cursor)
-		}
-
-		respBody, err := client.Request(http.MethodGet, path, queryParams, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		var nsResp NamespacesResponse
-		if err := json.Unmarshal(respBody, &nsResp); err != nil {
-			return nil, fmt.Errorf("failed to parse API response: %w", err)
-		}
-
-		if !nsResp.Success {
-			errorStr := "API reported failure"
-			if len(nsResp.Errors) > 0 {
-				errorStr = nsResp.Errors[0].Message
-			}
-			return nil, fmt.Errorf("failed to list namespaces: %s", errorStr)
-		}
-
-		// Append results from this page
-		allNamespaces = append(allNamespaces, nsResp.Result...)
-
-		// Check if we need to fetch more pages
-		cursor = nsResp.ResultInfo.Cursor
-		if cursor == "" {
-			break
-		}
+	namespaces, err := api.Paginate[Namespace](context.Background(), client, path, nil, namespacesPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
-	return allNamespaces, nil
+	return namespaces, nil
 }
 
 // GetNamespace gets details of a specific namespace
@@ -98,25 +82,12 @@ func GetNamespace(client *api.Client, accountID, namespaceID string) (*Namespace
 
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s", accountID, namespaceID)
 
-	respBody, err := client.Request(http.MethodGet, path, nil, nil)
+	ns, err := api.RequestJSON[Namespace](context.Background(), client, http.MethodGet, path, nil, nil)
 	if err != nil {
-		return nil, err
-	}
-
-	var nsResp NamespaceResponse
-	if err := json.Unmarshal(respBody, &nsResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
 	}
 
-	if !nsResp.Success {
-		errorStr := "API reported failure"
-		if len(nsResp.Errors) > 0 {
-			errorStr = nsResp.Errors[0].Message
-		}
-		return nil, fmt.Errorf("failed to get namespace: %s", errorStr)
-	}
-
-	return &nsResp.Result, nil
+	return &ns, nil
 }
 
 // CreateNamespace creates a new KV namespace
@@ -134,25 +105,12 @@ func CreateNamespace(client *api.Client, accountID, title string) (*Namespace, e
 		"title": title,
 	}
 
-	respBody, err := client.Request(http.MethodPost, path, nil, requestBody)
+	ns, err := api.RequestJSON[Namespace](context.Background(), client, http.MethodPost, path, nil, requestBody)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create namespace: %w", err)
 	}
 
-	var nsResp NamespaceResponse
-	if err := json.Unmarshal(respBody, &nsResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !nsResp.Success {
-		errorStr := "API reported failure"
-		if len(nsResp.Errors) > 0 {
-			errorStr = nsResp.Errors[0].Message
-		}
-		return nil, fmt.Errorf("failed to create namespace: %s", errorStr)
-	}
-
-	return &nsResp.Result, nil
+	return &ns, nil
 }
 
 // DeleteNamespace deletes a KV namespace
@@ -166,33 +124,27 @@ func DeleteNamespace(client *api.Client, accountID, namespaceID string) error {
 
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s", accountID, namespaceID)
 
-	respBody, err := client.Request(http.MethodDelete, path, nil, nil)
-	if err != nil {
-		return err
-	}
-
-	var resp api.APIResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !resp.Success {
-		errorStr := "API reported failure"
-		if len(resp.Errors) > 0 {
-			errorStr = resp.Errors[0].Message
-		}
-		return fmt.Errorf("failed to delete namespace: %s", errorStr)
+	if _, err := api.RequestJSON[json.RawMessage](context.Background(), client, http.MethodDelete, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete namespace: %w", err)
 	}
 
+	namespaceTitles.forget(accountID, namespaceID)
 	return nil
 }
 
-// FindNamespaceByTitle finds a namespace by its title
+// FindNamespaceByTitle finds a namespace by its title. Resolutions are cached, see
+// SetNamespaceCache; a cached namespace has only its ID and title set.
 func FindNamespaceByTitle(client *api.Client, accountID, title string) (*Namespace, error) {
+	if id, ok := namespaceTitles.lookup(accountID, title); ok {
+		common.LogDebug("Resolved namespace '%s' to %s from cache", title, id)
+		return &Namespace{ID: id, Title: title}, nil
+	}
+
 	namespaces, err := ListNamespaces(client, accountID)
 	if err != nil {
 		return nil, err
 	}
+	namespaceTitles.store(accountID, namespaces)
 
 	for _, ns := range namespaces {
 		if ns.Title == title {
@@ -203,6 +155,47 @@ func FindNamespaceByTitle(client *api.Client, accountID, title string) (*Namespa
 	return nil, fmt.Errorf("namespace with title '%s' not found", title)
 }
 
+// EnsureNamespace returns the namespace with the given title, creating it if no namespace
+// has that title. The boolean is true if the namespace was created. If the create fails
+// because the title was taken in the meantime, the existing namespace is returned.
+func EnsureNamespace(client *api.Client, accountID, title string) (*Namespace, bool, error) {
+	if accountID == "" {
+		return nil, false, fmt.Errorf("account ID is required")
+	}
+	if title == "" {
+		return nil, false, fmt.Errorf("title is required")
+	}
+
+	find := func() (*Namespace, error) {
+		namespaces, err := ListNamespaces(client, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		for _, ns := range namespaces {
+			if ns.Title == title {
+				return &ns, nil
+			}
+		}
+		return nil, nil
+	}
+
+	existing, err := find()
+	if err != nil || existing != nil {
+		return existing, false, err
+	}
+
+	created, createErr := CreateNamespace(client, accountID, title)
+	if createErr == nil {
+		return created, true, nil
+	}
+
+	// Another run may have created it between the listing and the create
+	if existing, err := find(); err == nil && existing != nil {
+		return existing, false, nil
+	}
+	return nil, false, createErr
+}
+
 // DeleteMultipleNamespaces deletes multiple KV namespaces
 func DeleteMultipleNamespaces(client *api.Client, accountID string, namespaceIDs []string) ([]string, []error) {
 	if accountID == "" {
@@ -233,43 +226,121 @@ func DeleteMultipleNamespaces(client *api.Client, accountID string, namespaceIDs
 	return successIDs, errors
 }
 
-// DeleteMultipleNamespacesWithProgress deletes multiple KV namespaces with progress callback
-func DeleteMultipleNamespacesWithProgress(client *api.Client, accountID string, namespaceIDs []string, progressCallback func(completed, total, success, failed int)) ([]string, []error) {
-	if accountID == "" {
-		return nil, []error{fmt.Errorf("account ID is required")}
-	}
-	if len(namespaceIDs) == 0 {
-		return nil, []error{fmt.Errorf("at least one namespace ID is required")}
-	}
+// NamespaceDeleteOptions configures deleting several namespaces at once
+type NamespaceDeleteOptions struct {
+	Concurrency int           // Namespaces deleted at once (default 3)
+	Interval    time.Duration // Minimum time between starting two deletions (default 250ms)
+	MaxRetries  int           // Retries of a rate-limited deletion (default 3)
+}
 
-	var successIDs []string
-	var errors []error
-	totalCount := len(namespaceIDs)
+// NamespaceDeleteResult is the outcome of deleting one namespace
+type NamespaceDeleteResult struct {
+	ID       string `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
 
-	// Process each namespace deletion separately
-	for i, nsID := range namespaceIDs {
-		if nsID == "" {
-			errors = append(errors, fmt.Errorf("empty namespace ID provided"))
+// OK returns true if the namespace was deleted
+func (r NamespaceDeleteResult) OK() bool {
+	return r.Error == ""
+}
 
-			if progressCallback != nil {
-				progressCallback(i+1, totalCount, len(successIDs), len(errors))
+// DeleteMultipleNamespacesWithProgress deletes namespaces with a small worker pool. Deletions
+// are started at most once per Interval so a large selection doesn't run into the rate limit,
+// and a deletion that is rate limited anyway (HTTP 429) is retried with exponential backoff,
+// honouring Retry-After. Results are returned in the order of the namespaces given, one per
+// namespace. The progress callback is called after each namespace.
+func DeleteMultipleNamespacesWithProgress(client *api.Client, accountID string, namespaces []Namespace,
+	options NamespaceDeleteOptions, progressCallback func(completed, total, success, failed int)) ([]NamespaceDeleteResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("at least one namespace is required")
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 3
+	}
+	if options.Interval <= 0 {
+		options.Interval = 250 * time.Millisecond
+	}
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = 3
+	}
+
+	results := make([]NamespaceDeleteResult, len(namespaces))
+	var mu sync.Mutex
+	completed, success, failed := 0, 0, 0
+
+	// A single ticker paces the start of every deletion attempt across all workers
+	pacer := time.NewTicker(options.Interval)
+	defer pacer.Stop()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(options.Concurrency, len(namespaces)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ns := namespaces[i]
+				result := NamespaceDeleteResult{ID: ns.ID, Title: ns.Title}
+
+				if ns.ID == "" {
+					result.Error = "empty namespace ID provided"
+				} else {
+					for {
+						<-pacer.C
+						result.Attempts++
+						err := DeleteNamespace(client, accountID, ns.ID)
+						if err == nil {
+							result.Error = ""
+							break
+						}
+						result.Error = err.Error()
+						if !strings.Contains(err.Error(), "HTTP 429") || result.Attempts > options.MaxRetries {
+							break
+						}
+						time.Sleep(namespaceRetryDelay(err, result.Attempts))
+					}
+				}
+
+				mu.Lock()
+				results[i] = result
+				completed++
+				if result.OK() {
+					success++
+				} else {
+					failed++
+				}
+				if progressCallback != nil {
+					progressCallback(completed, len(namespaces), success, failed)
+				}
+				mu.Unlock()
 			}
-			continue
-		}
+		}()
+	}
 
-		err := DeleteNamespace(client, accountID, nsID)
-		if err != nil {
-			errors = append(errors, fmt.Errorf("failed to delete namespace %s: %w", nsID, err))
-		} else {
-			successIDs = append(successIDs, nsID)
-		}
+	for i := range namespaces {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
-		if progressCallback != nil {
-			progressCallback(i+1, totalCount, len(successIDs), len(errors))
+	return results, nil
+}
+
+// namespaceRetryDelay returns how long to wait before retrying a rate-limited deletion: the
+// Retry-After seconds reported by the API, or 1s, 2s, 4s... capped at 30s
+func namespaceRetryDelay(err error, attempt int) time.Duration {
+	if _, after, ok := strings.Cut(err.Error(), "retry after: "); ok {
+		if seconds, convErr := strconv.Atoi(strings.TrimSpace(after)); convErr == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
 		}
 	}
-
-	return successIDs, errors
+	return min(time.Duration(1<<uint(attempt-1))*time.Second, 30*time.Second)
 }
 
 // FindNamespacesByPattern finds namespaces with titles matching a regex pattern
@@ -327,23 +398,156 @@ func RenameNamespace(client *api.Client, accountID, namespaceID, newTitle string
 		"title": newTitle,
 	}
 
-	respBody, err := client.Request(http.MethodPut, path, nil, requestBody)
+	ns, err := api.RequestJSON[Namespace](context.Background(), client, http.MethodPut, path, nil, requestBody)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to rename namespace: %w", err)
+	}
+
+	namespaceTitles.forget(accountID, namespaceID)
+	return &ns, nil
+}
+
+// FindNamespacesByTitles finds namespaces whose titles exactly match one of the given titles.
+// Titles that don't match any namespace are returned as the second value.
+func FindNamespacesByTitles(client *api.Client, accountID string, titles []string) ([]Namespace, []string, error) {
+	if accountID == "" {
+		return nil, nil, fmt.Errorf("account ID is required")
+	}
+	if len(titles) == 0 {
+		return nil, nil, fmt.Errorf("at least one title is required")
+	}
+
+	namespaces, err := ListNamespaces(client, accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
-	var nsResp NamespaceResponse
-	if err := json.Unmarshal(respBody, &nsResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	// Index namespaces by title for exact lookups
+	byTitle := make(map[string]Namespace, len(namespaces))
+	for _, ns := range namespaces {
+		byTitle[ns.Title] = ns
 	}
 
-	if !nsResp.Success {
-		errorStr := "API reported failure"
-		if len(nsResp.Errors) > 0 {
-			errorStr = nsResp.Errors[0].Message
+	var found []Namespace
+	var missing []string
+	seen := make(map[string]bool)
+	for _, title := range titles {
+		if seen[title] {
+			continue
+		}
+		seen[title] = true
+
+		if ns, ok := byTitle[title]; ok {
+			found = append(found, ns)
+		} else {
+			missing = append(missing, title)
 		}
-		return nil, fmt.Errorf("failed to rename namespace: %s", errorStr)
 	}
 
-	return &nsResp.Result, nil
+	return found, missing, nil
+}
+
+// ExcludeNamespacesByPattern splits namespaces into those to keep and those whose
+// titles match the exclusion pattern
+func ExcludeNamespacesByPattern(namespaces []Namespace, pattern string) ([]Namespace, []Namespace, error) {
+	if pattern == "" {
+		return namespaces, nil, nil
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	var kept, excluded []Namespace
+	for _, ns := range namespaces {
+		if regex.MatchString(ns.Title) {
+			excluded = append(excluded, ns)
+		} else {
+			kept = append(kept, ns)
+		}
+	}
+
+	return kept, excluded, nil
+}
+
+// NamespaceRename is a planned or completed rename of one namespace
+type NamespaceRename struct {
+	ID       string `json:"id"`
+	OldTitle string `json:"old_title"`
+	NewTitle string `json:"new_title"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PlanNamespaceRenames works out the new titles of the namespaces whose titles match
+// pattern, with replace expanded as in regexp.ReplaceAllString ($1 for the first group).
+// Titles that would not change are skipped. Renames that would give two namespaces the
+// same title are refused before anything is renamed.
+func PlanNamespaceRenames(namespaces []Namespace, pattern, replace string) ([]NamespaceRename, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	var renames []NamespaceRename
+	renamed := make(map[string]bool)
+	for _, ns := range namespaces {
+		if !regex.MatchString(ns.Title) {
+			continue
+		}
+		newTitle := regex.ReplaceAllString(ns.Title, replace)
+		if newTitle == ns.Title {
+			continue
+		}
+		if newTitle == "" {
+			return nil, fmt.Errorf("namespace '%s' would be renamed to an empty title", ns.Title)
+		}
+		renames = append(renames, NamespaceRename{ID: ns.ID, OldTitle: ns.Title, NewTitle: newTitle})
+		renamed[ns.ID] = true
+	}
+
+	// Titles that remain in use after the renames
+	titles := make(map[string]string)
+	for _, ns := range namespaces {
+		if !renamed[ns.ID] {
+			titles[ns.Title] = ns.ID
+		}
+	}
+	for _, rename := range renames {
+		if other, ok := titles[rename.NewTitle]; ok {
+			return nil, fmt.Errorf("cannot rename '%s' to '%s': the title is already used by namespace %s",
+				rename.OldTitle, rename.NewTitle, other)
+		}
+		titles[rename.NewTitle] = rename.ID
+	}
+
+	return orderNamespaceRenames(renames)
+}
+
+// orderNamespaceRenames puts each rename after the rename that frees its new title,
+// e.g. "b" to "c" before "a" to "b"
+func orderNamespaceRenames(renames []NamespaceRename) ([]NamespaceRename, error) {
+	pending := renames
+	ordered := make([]NamespaceRename, 0, len(renames))
+	for len(pending) > 0 {
+		held := make(map[string]bool, len(pending))
+		for _, rename := range pending {
+			held[rename.OldTitle] = true
+		}
+
+		var next []NamespaceRename
+		for _, rename := range pending {
+			if held[rename.NewTitle] {
+				next = append(next, rename)
+			} else {
+				ordered = append(ordered, rename)
+			}
+		}
+		if len(next) == len(pending) {
+			return nil, fmt.Errorf("renames of '%s' and %d other namespaces form a cycle; rename one of them to a temporary title first",
+				next[0].OldTitle, len(next)-1)
+		}
+		pending = next
+	}
+	return ordered, nil
 }
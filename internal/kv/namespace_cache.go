@@ -0,0 +1,146 @@
+package kv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cache-kv-purger/internal/common"
+)
+
+// namespaceCacheFileName is the name of the namespace title cache file in the home directory
+const namespaceCacheFileName = ".cache-kv-purger-namespaces.json"
+
+// cachedNamespaceID is a resolved namespace title
+type cachedNamespaceID struct {
+	ID       string    `json:"id"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// namespaceTitleCache remembers namespace title to ID resolutions, so commands resolving
+// --namespace don't each list every namespace of the account. Resolutions last for the
+// process, and with a disk TTL set they are shared between invocations for that long.
+type namespaceTitleCache struct {
+	mu       sync.Mutex
+	disabled bool
+	diskTTL  time.Duration // 0 keeps resolutions in the process only
+	path     string
+	loaded   bool
+	entries  map[string]cachedNamespaceID // By account ID and title
+}
+
+var namespaceTitles = &namespaceTitleCache{entries: make(map[string]cachedNamespaceID)}
+
+// SetNamespaceCache configures caching of namespace title resolutions. disabled turns the
+// cache off, e.g. from --no-cache. A positive diskTTL also keeps resolutions in a file in
+// the home directory for that long, so scripts running many commands list namespaces once.
+func SetNamespaceCache(disabled bool, diskTTL time.Duration) {
+	c := namespaceTitles
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = disabled
+	c.diskTTL = diskTTL
+	c.loaded = false
+	c.entries = make(map[string]cachedNamespaceID)
+}
+
+// namespaceCacheKey is the cache key of a title in an account
+func namespaceCacheKey(accountID, title string) string {
+	return accountID + "/" + title
+}
+
+// lookup returns the cached ID of the namespace with the title
+func (c *namespaceTitleCache) lookup(accountID, title string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return "", false
+	}
+	c.loadLocked()
+	entry, ok := c.entries[namespaceCacheKey(accountID, title)]
+	return entry.ID, ok
+}
+
+// store caches the titles of the namespaces of an account
+func (c *namespaceTitleCache) store(accountID string, namespaces []Namespace) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return
+	}
+	c.loadLocked()
+	now := time.Now()
+	for _, ns := range namespaces {
+		c.entries[namespaceCacheKey(accountID, ns.Title)] = cachedNamespaceID{ID: ns.ID, Resolved: now}
+	}
+	c.saveLocked()
+}
+
+// forget drops the cached titles of a namespace after it was renamed or deleted
+func (c *namespaceTitleCache) forget(accountID, namespaceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return
+	}
+	c.loadLocked()
+	prefix := namespaceCacheKey(accountID, "")
+	changed := false
+	for key, entry := range c.entries {
+		if entry.ID == namespaceID && len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			delete(c.entries, key)
+			changed = true
+		}
+	}
+	if changed {
+		c.saveLocked()
+	}
+}
+
+// loadLocked reads unexpired resolutions from the cache file once per configuration.
+// A missing or unreadable file leaves the cache empty.
+func (c *namespaceTitleCache) loadLocked() {
+	if c.loaded || c.diskTTL <= 0 {
+		return
+	}
+	c.loaded = true
+
+	if c.path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		c.path = filepath.Join(homeDir, namespaceCacheFileName)
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var stored map[string]cachedNamespaceID
+	if err := json.Unmarshal(data, &stored); err != nil {
+		common.LogDebug("Ignoring unreadable namespace cache file %s: %v", c.path, err)
+		return
+	}
+	for key, entry := range stored {
+		if time.Since(entry.Resolved) < c.diskTTL {
+			c.entries[key] = entry
+		}
+	}
+}
+
+// saveLocked writes the resolutions to the cache file when the disk cache is on
+func (c *namespaceTitleCache) saveLocked() {
+	if c.diskTTL <= 0 || c.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		common.LogDebug("Failed to save namespace cache file: %v", err)
+	}
+}
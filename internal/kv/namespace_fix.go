@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // ListNamespacesOptions provides advanced options for listing namespaces
@@ -17,6 +19,7 @@ type ListNamespacesOptions struct {
 	MaxRetries int           // Maximum number of retries for failed requests
 	Timeout    time.Duration // Timeout for the entire operation
 	PageLimit  int           // Maximum number of pages to fetch (0 = no limit)
+	PerPage    int           // Namespaces per page (0 = the configured page size, see SetNamespacesPerPage)
 }
 
 // ListNamespacesResult contains the results of a namespace listing operation
@@ -44,13 +47,13 @@ func EnhancedListNamespaces(client *api.Client, accountID string, options *ListN
 	// Initialize debugging functions
 	debug := func(format string, args ...interface{}) {
 		if options.Debug {
-			fmt.Printf("[DEBUG] "+format+"\n", args...)
+			common.LogDebug(format, args...)
 		}
 	}
 
 	verbose := func(format string, args ...interface{}) {
 		if options.Verbose {
-			fmt.Printf("[INFO] "+format+"\n", args...)
+			common.LogVerbose(format, args...)
 		}
 	}
 
@@ -59,8 +62,14 @@ func EnhancedListNamespaces(client *api.Client, accountID string, options *ListN
 
 	path := fmt.Sprintf("/accounts/%s/storage/kv/namespaces", accountID)
 
+	perPage := options.PerPage
+	if perPage <= 0 {
+		perPage = namespacesPerPage
+	}
+
 	var allNamespaces []Namespace
 	var cursor string
+	page := 1
 	var seenCursors = make(map[string]bool)
 	var warnings []string
 	pageCount := 0
@@ -99,14 +108,16 @@ func EnhancedListNamespaces(client *api.Client, accountID string, options *ListN
 			break
 		}
 
-		// Set up query parameters for pagination if we have a cursor
-		var queryParams url.Values
+		// Set up query parameters for pagination: the API pages by number, but follow a
+		// cursor if one is returned
+		queryParams := url.Values{}
+		queryParams.Set("per_page", strconv.Itoa(perPage))
 		if cursor != "" {
-			queryParams = url.Values{}
 			queryParams.Set("cursor", cursor)
 			debug("Fetching page with cursor: %s", cursor)
 		} else {
-			debug("Fetching first page")
+			queryParams.Set("page", strconv.Itoa(page))
+			debug("Fetching page %d", page)
 		}
 
 		respBody, err := client.Request(http.MethodGet, path, queryParams, nil)
@@ -157,7 +168,11 @@ func EnhancedListNamespaces(client *api.Client, accountID string, options *ListN
 		// Check if we need to fetch more pages
 		cursor = currentPageCursor
 		if cursor == "" {
-			debug("No more pages (empty cursor)")
+			if page < nsResp.ResultInfo.TotalPages && currentPageCount > 0 {
+				page++
+				continue
+			}
+			debug("No more pages (last page)")
 			break
 		}
 
@@ -0,0 +1,138 @@
+package kv
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressPhase names the step a long-running operation is in
+type ProgressPhase string
+
+const (
+	PhaseListing  ProgressPhase = "listing"  // Listing the keys of the namespace
+	PhaseChecking ProgressPhase = "checking" // Checking keys against a filter
+	PhaseFetching ProgressPhase = "fetching" // Fetching values
+	PhaseDeleting ProgressPhase = "deleting" // Deleting matched keys
+)
+
+// ProgressEvent reports how far a long-running operation has got, with its rate and
+// estimated time remaining so callers don't have to derive them from counts
+type ProgressEvent struct {
+	Phase     ProgressPhase
+	Fetched   int // Keys listed, or values fetched while exporting
+	Processed int // Keys checked against the filter
+	Matched   int // Keys that matched the filter
+	Deleted   int // Keys deleted
+	Total     int // Keys in the namespace (or to export), 0 while unknown
+
+	Failed        int                      // Keys skipped because reading them failed
+	FailedByClass map[ExportErrorClass]int // Failed keys per error class
+
+	Elapsed   time.Duration // Time since the operation started
+	Rate      float64       // Items per second in the current phase
+	Remaining time.Duration // Estimated time left in the current phase, 0 while unknown
+}
+
+// ProgressFunc receives progress events
+type ProgressFunc func(ProgressEvent)
+
+// Done returns the items completed in the current phase and how many there are in total
+func (e ProgressEvent) Done() (int, int) {
+	switch e.Phase {
+	case PhaseChecking:
+		return e.Processed, e.Total
+	case PhaseDeleting:
+		return e.Deleted, e.Matched
+	default:
+		return e.Fetched, e.Total
+	}
+}
+
+// String describes the event for progress lines, e.g.
+// "checking 1200/5000 keys (24.0%), 350/s, about 11s left"
+func (e ProgressEvent) String() string {
+	done, total := e.Done()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d", e.Phase, done)
+	if total > 0 {
+		fmt.Fprintf(&b, "/%d keys (%.1f%%)", total, float64(done)/float64(total)*100)
+	} else {
+		b.WriteString(" keys")
+	}
+	if e.Matched > 0 && e.Phase != PhaseDeleting {
+		fmt.Fprintf(&b, ", %d matched", e.Matched)
+	}
+	if e.Failed > 0 {
+		fmt.Fprintf(&b, ", %d failed (%s)", e.Failed, SearchErrorStats{Failed: e.Failed, ByClass: e.FailedByClass}.Classes())
+	}
+	if e.Rate > 0 {
+		fmt.Fprintf(&b, ", %.0f/s", e.Rate)
+	}
+	if e.Remaining > 0 {
+		fmt.Fprintf(&b, ", about %s left", e.Remaining.Round(time.Second))
+	}
+	return b.String()
+}
+
+// progressTracker stamps progress events with the elapsed time, rate and time remaining,
+// and serializes calls to the callback so it can be used from concurrent workers
+type progressTracker struct {
+	mu         sync.Mutex
+	callback   ProgressFunc
+	start      time.Time
+	phase      ProgressPhase
+	phaseStart time.Time
+}
+
+// newProgressTracker returns a tracker for callback, which may be nil
+func newProgressTracker(callback ProgressFunc) *progressTracker {
+	now := time.Now()
+	return &progressTracker{callback: callback, start: now, phaseStart: now}
+}
+
+// report fills in the timing of an event and passes it to the callback. Rates are
+// measured from the start of the event's phase.
+func (t *progressTracker) report(event ProgressEvent) {
+	if t == nil || t.callback == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if event.Phase != t.phase {
+		// The first phase starts with the tracker, later ones when their first event arrives
+		if t.phase != "" {
+			t.phaseStart = now
+		}
+		t.phase = event.Phase
+	}
+
+	event.Elapsed = now.Sub(t.start)
+	done, total := event.Done()
+	if inPhase := now.Sub(t.phaseStart).Seconds(); inPhase > 0 && done > 0 {
+		event.Rate = float64(done) / inPhase
+		if total > done {
+			event.Remaining = time.Duration(float64(total-done) / event.Rate * float64(time.Second))
+		}
+	}
+
+	t.callback(event)
+}
+
+// purgeProgress turns the counts of a purge into a progress event. Purges list the keys,
+// then check them against the filter, then delete the rest of the matched keys.
+func purgeProgress(fetched, processed, matched, deleted, total int) ProgressEvent {
+	phase := PhaseChecking
+	switch {
+	case processed == 0 && deleted == 0:
+		phase = PhaseListing
+	case total > 0 && processed >= total && deleted > 0:
+		phase = PhaseDeleting
+	}
+	return ProgressEvent{Phase: phase, Fetched: fetched, Processed: processed, Matched: matched, Deleted: deleted, Total: total}
+}
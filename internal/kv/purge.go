@@ -2,9 +2,9 @@ package kv
 
 import (
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strings"
 	"sync"
@@ -13,9 +13,21 @@ import (
 
 // StreamingFilterKeysByMetadata performs a streaming filter of keys by metadata
 // This is much more efficient for large namespaces as it processes in chunks
-func StreamingFilterKeysByMetadata(client *api.Client, accountID, namespaceID, metadataField, metadataValue string,
+// A non-empty prefix is passed to the list API, so only keys under it are fetched
+func StreamingFilterKeysByMetadata(client *api.Client, accountID, namespaceID, prefix, metadataField, metadataValue string,
 	chunkSize int, _ int, progressCallback func(keysFetched, keysProcessed, keysMatched, total int)) ([]KeyValuePair, error) {
 
+	names, _ := NewNameFilter(prefix, "", "")
+	return filterKeysByMetadata(client, accountID, namespaceID, names, AnyExpiration, metadataField, metadataValue,
+		chunkSize, progressCallback)
+}
+
+// filterKeysByMetadata is StreamingFilterKeysByMetadata for keys that also match names and
+// expiration. Those are checked on the listing, so only the metadata of keys that pass them
+// is fetched.
+func filterKeysByMetadata(client *api.Client, accountID, namespaceID string, names *NameFilter, expiration ExpirationFilter,
+	metadataField, metadataValue string, chunkSize int, progressCallback func(keysFetched, keysProcessed, keysMatched, total int)) ([]KeyValuePair, error) {
+
 	if accountID == "" {
 		return nil, fmt.Errorf("account ID is required")
 	}
@@ -35,13 +47,18 @@ func StreamingFilterKeysByMetadata(client *api.Client, accountID, namespaceID, m
 		progressCallback = func(keysFetched, keysProcessed, keysMatched, total int) {}
 	}
 
-	// First, list all keys
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	// First, list all keys, narrowed by name and expiration before any metadata request
+	prefix := ""
+	if names != nil {
+		prefix = names.Prefix
+	}
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, total)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list keys: %w", err)
 	}
+	keys = FilterKeysByExpiration(FilterKeysByName(keys, names), expiration)
 
 	if len(keys) == 0 {
 		return []KeyValuePair{}, nil // Return empty slice, not nil
@@ -106,9 +123,9 @@ func StreamingFilterKeysByMetadata(client *api.Client, accountID, namespaceID, m
 
 // StreamingPurgeByTag performs a streaming purge of keys with a specific tag value
 // This is much more efficient for large namespaces as it processes in chunks
-func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, tagValue string,
+func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, prefix, tagField, tagValue string,
 	chunkSize int, concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -122,20 +139,18 @@ func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, t
 	if chunkSize <= 0 {
 		chunkSize = 100 // Default chunk size
 	}
-	if concurrency <= 0 {
-		concurrency = 20 // Default concurrency
-	}
-	if concurrency > 50 {
-		concurrency = 50 // Cap maximum concurrency
-	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysDeleted, total int) {}
+	var totalMatched int // Counted as chunks are filtered, for progress events
+
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, totalMatched, keysDeleted, total))
 	}
 
 	// First, list all keys (we need this to get the total count)
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, total)
 	})
 	if err != nil {
@@ -148,13 +163,13 @@ func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, t
 
 	totalKeys := len(keys)
 	totalProcessed := 0
-	totalMatched := 0
 	totalDeleted := 0
 
 	// To improve performance, we'll:
 	// 1. Process keys in larger batches
 	// 2. Optimize for bulk API operations where possible
-	// 3. Delete keys in batches of up to 1000 (API limit)
+	// 3. Delete keys in batches of the policy's batch size
+	batchSize := common.CurrentPolicy().BatchSize
 
 	// Track all matched keys across all chunks
 	var allMatchedKeys []string
@@ -184,10 +199,10 @@ func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, t
 		totalMatched += len(matchedKeys)
 
 		// If we've reached a significant number of keys to delete, batch delete them
-		if !dryRun && len(allMatchedKeys) >= 1000 {
-			// Delete in batches of 1000 (API limit)
-			for j := 0; j < len(allMatchedKeys); j += 1000 {
-				batchEnd := j + 1000
+		if !dryRun && len(allMatchedKeys) >= batchSize {
+			// Delete in batches
+			for j := 0; j < len(allMatchedKeys); j += batchSize {
+				batchEnd := j + batchSize
 				if batchEnd > len(allMatchedKeys) {
 					batchEnd = len(allMatchedKeys)
 				}
@@ -215,9 +230,9 @@ func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, t
 
 	// Delete any remaining matched keys
 	if len(allMatchedKeys) > 0 {
-		// Delete in batches of 1000 (API limit)
-		for j := 0; j < len(allMatchedKeys); j += 1000 {
-			batchEnd := j + 1000
+		// Delete in batches
+		for j := 0; j < len(allMatchedKeys); j += batchSize {
+			batchEnd := j + batchSize
 			if batchEnd > len(allMatchedKeys) {
 				batchEnd = len(allMatchedKeys)
 			}
@@ -239,9 +254,9 @@ func StreamingPurgeByTag(client *api.Client, accountID, namespaceID, tagField, t
 
 // PurgeByMetadataUpfront fetches all metadata first then processes in memory
 // This is much more efficient when you have a high API rate limit
-func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadataField, metadataValue string,
+func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, prefix, metadataField, metadataValue string,
 	concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -252,20 +267,17 @@ func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadata
 	if metadataField == "" {
 		metadataField = "cache-tag" // Default field
 	}
-	if concurrency <= 0 {
-		concurrency = 50 // Default concurrency
-	}
-	if concurrency > 1000 {
-		concurrency = 1000 // Cap maximum concurrency
-	}
+	policy := common.CurrentPolicy()
+	concurrency = policy.Concurrency(concurrency, policy.MaxConcurrency)
 
-	// Default progress callback
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {}
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, keysMatched, keysDeleted, total))
 	}
 
 	// First, list all keys
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, 0, total)
 	})
 	if err != nil {
@@ -284,14 +296,9 @@ func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadata
 	for _, key := range keys {
 		// First check if metadata is already available in the response
 		if key.Metadata != nil {
-			// Check if the field exists and matches the value
-			fieldValue, exists := (*key.Metadata)[metadataField]
-			if exists {
-				// Check if the field value matches (empty value matches anything)
-				fieldValueStr := fmt.Sprintf("%v", fieldValue)
-				if metadataValue == "" || fieldValueStr == metadataValue {
-					matchingKeys = append(matchingKeys, key.Key)
-				}
+			// Check if the field exists and matches the value (empty value matches anything)
+			if common.MetadataFieldMatches(*key.Metadata, metadataField, metadataValue) {
+				matchingKeys = append(matchingKeys, key.Key)
 			}
 		}
 
@@ -329,15 +336,8 @@ func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadata
 						continue // No metadata for this key
 					}
 
-					// Check if the field exists and matches the value
-					fieldValue, exists := (*metadata)[metadataField]
-					if !exists {
-						continue // Field doesn't exist
-					}
-
-					// Check if the field value matches (empty value matches anything)
-					fieldValueStr := fmt.Sprintf("%v", fieldValue)
-					if metadataValue == "" || fieldValueStr == metadataValue {
+					// Check if the field exists and matches the value (empty value matches anything)
+					if common.MetadataFieldMatches(*metadata, metadataField, metadataValue) {
 						matchingKeys = append(matchingKeys, key.Key)
 					}
 				}
@@ -353,8 +353,8 @@ func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadata
 	// Delete matching keys in batches
 	totalDeleted := 0
 	if len(matchingKeys) > 0 {
-		// Use batch size of 1000 for deletions (Cloudflare API limit)
-		batchSize := 1000
+		// Delete in batches of the policy's batch size
+		batchSize := common.CurrentPolicy().BatchSize
 
 		for i := 0; i < len(matchingKeys); i += batchSize {
 			end := i + batchSize
@@ -379,9 +379,9 @@ func PurgeByMetadataUpfront(client *api.Client, accountID, namespaceID, metadata
 
 // PurgeByMetadataOnly uses a metadata-first approach for better performance
 // It only checks metadata and doesn't look at values at all
-func PurgeByMetadataOnly(client *api.Client, accountID, namespaceID, metadataField, metadataValue string,
+func PurgeByMetadataOnly(client *api.Client, accountID, namespaceID, prefix, metadataField, metadataValue string,
 	chunkSize int, concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -393,22 +393,18 @@ func PurgeByMetadataOnly(client *api.Client, accountID, namespaceID, metadataFie
 		metadataField = "cache-tag" // Default field
 	}
 	if chunkSize <= 0 {
-		chunkSize = 1000 // Use larger chunks for better performance
-	}
-	if concurrency <= 0 {
-		concurrency = 20 // Use higher concurrency for better performance
-	}
-	if concurrency > 50 {
-		concurrency = 50 // Cap maximum concurrency
+		chunkSize = common.CurrentPolicy().BatchSize // Use larger chunks for better performance
 	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
-	// Default progress callback
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {}
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, keysMatched, keysDeleted, total))
 	}
 
 	// First, list all keys (we need this to get the total count)
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, 0, total)
 	})
 	if err != nil {
@@ -500,10 +496,16 @@ func PurgeByMetadataOnly(client *api.Client, accountID, namespaceID, metadataFie
 		return totalMatched, nil
 	}
 
+	// Deleting a split value deletes its chunks too
+	allMatchedKeys, err = WithChunkKeys(client, accountID, namespaceID, allMatchedKeys, keys)
+	if err != nil {
+		return 0, err
+	}
+
 	// Delete matching keys in batches
 	if len(allMatchedKeys) > 0 {
-		// Use batch size of 1000 for deletions (Cloudflare API limit)
-		batchSize := 1000
+		// Delete in batches of the policy's batch size
+		batchSize := common.CurrentPolicy().BatchSize
 
 		for i := 0; i < len(allMatchedKeys); i += batchSize {
 			end := i + batchSize
@@ -547,10 +549,9 @@ func processMetadataOnlyChunk(client *api.Client, accountID, namespaceID string,
 		// First check if key already has metadata from the list response
 		if key.Metadata != nil {
 			// Check if metadata contains our field
-			if fieldValue, ok := (*key.Metadata)[metadataField]; ok {
+			if fieldValue, ok := common.LookupMetadataField(*key.Metadata, metadataField); ok {
 				// We found the field in metadata!
-				fieldStr, isString := fieldValue.(string)
-				if isString && (metadataValue == "" || fieldStr == metadataValue) {
+				if common.MetadataValueMatches(fieldValue, metadataValue) {
 					mu.Lock()
 					matchedKeys = append(matchedKeys, key.Key)
 					mu.Unlock()
@@ -566,23 +567,16 @@ func processMetadataOnlyChunk(client *api.Client, accountID, namespaceID string,
 			accountID, namespaceID, encodedKey)
 
 		// Get metadata
-		metadataResp, metadataErr := client.Request(http.MethodGet, metadataPath, nil, nil)
+		metadataResp, metadataErr := requestMetadata(client, metadataPath)
 
 		// If we got metadata and it contains our field, check it
 		if metadataErr == nil {
-			var metadataResponse struct {
-				Success bool                   `json:"success"`
-				Result  map[string]interface{} `json:"result"`
-			}
-
-			if err := json.Unmarshal(metadataResp, &metadataResponse); err == nil &&
-				metadataResponse.Success && metadataResponse.Result != nil {
+			if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp); err == nil && metadataResponse.Result != nil {
 
 				// Check if metadata has our field
-				if fieldValue, ok := metadataResponse.Result[metadataField]; ok {
+				if fieldValue, ok := common.LookupMetadataField(metadataResponse.Result, metadataField); ok {
 					// We found the field in metadata!
-					fieldStr, isString := fieldValue.(string)
-					if isString && (metadataValue == "" || fieldStr == metadataValue) {
+					if common.MetadataValueMatches(fieldValue, metadataValue) {
 						mu.Lock()
 						matchedKeys = append(matchedKeys, key.Key)
 						mu.Unlock()
@@ -626,10 +620,9 @@ func processKeyChunkOptimized(client *api.Client, accountID, namespaceID string,
 				// First check if metadata is already in the list response
 				if key.Metadata != nil {
 					// Check if metadata has our tag field
-					if fieldValue, ok := (*key.Metadata)[tagField]; ok {
+					if fieldValue, ok := common.LookupMetadataField(*key.Metadata, tagField); ok {
 						// We found the field in metadata!
-						foundTagStr, isString := fieldValue.(string)
-						if isString && (tagValue == "" || foundTagStr == tagValue) {
+						if common.MetadataValueMatches(fieldValue, tagValue) {
 							matches = true
 						}
 
@@ -654,23 +647,16 @@ func processKeyChunkOptimized(client *api.Client, accountID, namespaceID string,
 					accountID, namespaceID, encodedKey)
 
 				// Get metadata
-				metadataResp, metadataErr := client.Request(http.MethodGet, metadataPath, nil, nil)
+				metadataResp, metadataErr := requestMetadata(client, metadataPath)
 
 				// If we got metadata and it contains our tag field, check it
 				if metadataErr == nil {
-					var metadataResponse struct {
-						Success bool                   `json:"success"`
-						Result  map[string]interface{} `json:"result"`
-					}
-
-					if err := json.Unmarshal(metadataResp, &metadataResponse); err == nil &&
-						metadataResponse.Success && metadataResponse.Result != nil {
+					if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp); err == nil && metadataResponse.Result != nil {
 
 						// Check if metadata has our tag field
-						if fieldValue, ok := metadataResponse.Result[tagField]; ok {
+						if fieldValue, ok := common.LookupMetadataField(metadataResponse.Result, tagField); ok {
 							// We found the field in metadata!
-							foundTagStr, isString := fieldValue.(string)
-							if isString && (tagValue == "" || foundTagStr == tagValue) {
+							if common.MetadataValueMatches(fieldValue, tagValue) {
 								matches = true
 							}
 
@@ -691,7 +677,7 @@ func processKeyChunkOptimized(client *api.Client, accountID, namespaceID string,
 
 				// If we still didn't find metadata, fall back to getting the value
 				// Add a small delay to avoid rate limiting
-				time.Sleep(time.Duration(10) * time.Millisecond)
+				time.Sleep(common.CurrentPolicy().WorkerDelay)
 
 				// Get the value
 				value, err := GetValue(client, accountID, namespaceID, key.Key)
@@ -725,14 +711,8 @@ func processKeyChunkOptimized(client *api.Client, accountID, namespaceID string,
 					continue
 				}
 
-				// Check if the tag field exists and matches
-				if foundTagValue, ok := valueMap[tagField]; ok {
-					// Convert tag value to string
-					if foundTagStr, ok := foundTagValue.(string); ok {
-						// Check if tag value matches (if tagValue is empty, match any tag)
-						matches = tagValue == "" || foundTagStr == tagValue
-					}
-				}
+				// Check if the tag field exists and matches (if tagValue is empty, match any tag)
+				matches = common.MetadataFieldMatches(valueMap, tagField, tagValue)
 
 				// Report result
 				resultChan <- struct {
@@ -831,45 +811,84 @@ func SmartMetadataSearch(metadata interface{}, searchValue string) bool {
 	return strings.Contains(strings.ToLower(str), strings.ToLower(searchValue))
 }
 
+// ValueSearchOptions controls FindKeysWithValue
+type ValueSearchOptions struct {
+	Prefix      string
+	ChunkSize   int // Keys per worker task
+	Concurrency int
+	// Names and Expiration narrow the listed keys before any metadata request
+	Names      *NameFilter
+	Expiration ExpirationFilter
+	// FailOnErrors aborts the search once more than MaxErrorRate (0 to 1) of the checked keys
+	// failed; 0 aborts on the first failure. Without it, keys whose metadata can't be read
+	// are skipped and counted.
+	FailOnErrors bool
+	MaxErrorRate float64
+	// Progress receives the counts of the search and its failures so far
+	Progress func(keysFetched, keysProcessed, keysMatched, total int, failures SearchErrorStats)
+}
+
 // SmartFindKeysWithValue finds all keys containing a specific value anywhere in their metadata
-// Much more flexible than field-specific searches
-func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, searchValue string,
+// Much more flexible than field-specific searches. Keys whose metadata can't be read are
+// skipped; FindKeysWithValue reports them.
+func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, prefix, searchValue string,
 	chunkSize int, concurrency int, progressCallback func(keysFetched, keysProcessed, keysMatched, total int)) ([]KeyValuePair, error) {
 
+	options := ValueSearchOptions{Prefix: prefix, ChunkSize: chunkSize, Concurrency: concurrency}
+	if progressCallback != nil {
+		options.Progress = func(keysFetched, keysProcessed, keysMatched, total int, _ SearchErrorStats) {
+			progressCallback(keysFetched, keysProcessed, keysMatched, total)
+		}
+	}
+	keys, failures, err := FindKeysWithValue(client, accountID, namespaceID, searchValue, options)
+	if failures.Failed > 0 {
+		common.LogVerbose("Value search skipped keys whose metadata couldn't be read: %s", failures)
+	}
+	return keys, err
+}
+
+// FindKeysWithValue finds all keys containing a specific value anywhere in their metadata,
+// fetching the metadata of keys the listing doesn't include. Keys whose metadata can't be
+// read are counted by error class in the returned stats, which are complete even when the
+// search fails; with FailOnErrors, too many failures abort the search with
+// ErrSearchErrorRate and the matches found so far.
+func FindKeysWithValue(client *api.Client, accountID, namespaceID, searchValue string, options ValueSearchOptions) ([]KeyValuePair, SearchErrorStats, error) {
 	if accountID == "" {
-		return nil, fmt.Errorf("account ID is required")
+		return nil, SearchErrorStats{}, fmt.Errorf("account ID is required")
 	}
 	if namespaceID == "" {
-		return nil, fmt.Errorf("namespace ID is required")
+		return nil, SearchErrorStats{}, fmt.Errorf("namespace ID is required")
 	}
+	chunkSize := options.ChunkSize
 	if chunkSize <= 0 {
 		chunkSize = 100 // Default chunk size
 	}
-	if concurrency <= 0 {
-		concurrency = 10 // Default concurrency
-	}
+	concurrency := common.CurrentPolicy().Concurrency(options.Concurrency, 10)
 
 	// Simple progress callback if none provided
+	progressCallback := options.Progress
 	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, total int) {}
+		progressCallback = func(keysFetched, keysProcessed, keysMatched, total int, failures SearchErrorStats) {}
 	}
 
 	// First, list all keys
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
-		progressCallback(fetched, 0, 0, total)
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.Prefix}, func(fetched, total int) {
+		progressCallback(fetched, 0, 0, total, SearchErrorStats{})
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list keys: %w", err)
+		return nil, SearchErrorStats{}, fmt.Errorf("failed to list keys: %w", err)
 	}
+	keys = FilterKeysByExpiration(FilterKeysByName(keys, options.Names), options.Expiration)
 
 	if len(keys) == 0 {
-		return []KeyValuePair{}, nil // Return empty slice, not nil
+		return []KeyValuePair{}, SearchErrorStats{}, nil // Return empty slice, not nil
 	}
 
 	totalKeys := len(keys)
 	var matchedKeys []KeyValuePair
 	totalProcessed := 0
 	totalMatched := 0
+	failures := newSearchErrorCounter(totalKeys, options.FailOnErrors, options.MaxErrorRate)
 
 	// Create a mutex for thread safety in the concurrent section
 	var mu sync.Mutex
@@ -877,10 +896,9 @@ func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, searchVa
 	// Process keys in batches using a worker pool for concurrency
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, concurrency)
-	errorsChan := make(chan error, concurrency)
 
-	// Process in chunks
-	for i := 0; i < totalKeys; i += chunkSize {
+	// Process in chunks, until too many failures abort the search
+	for i := 0; i < totalKeys && !failures.stopped(); i += chunkSize {
 		end := i + chunkSize
 		if end > totalKeys {
 			end = totalKeys
@@ -897,12 +915,15 @@ func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, searchVa
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			chunkMatched := 0
-
 			// Process each key in this chunk
 			for j, key := range chunk {
+				if failures.stopped() {
+					return
+				}
 				processedIdx := chunkStartIdx + j
 
+				var readErr error
+
 				// Step 1: Check if metadata already available from list response
 				if key.Metadata != nil {
 					// Use smart recursive search on the metadata
@@ -910,7 +931,6 @@ func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, searchVa
 						mu.Lock()
 						matchedKeys = append(matchedKeys, key)
 						totalMatched++
-						chunkMatched++
 						mu.Unlock()
 					}
 				} else {
@@ -919,76 +939,62 @@ func SmartFindKeysWithValue(client *api.Client, accountID, namespaceID, searchVa
 					metadataPath := fmt.Sprintf("/accounts/%s/storage/kv/namespaces/%s/metadata/%s",
 						accountID, namespaceID, encodedKey)
 
-					// Get metadata via API
-					metadataResp, metadataErr := client.Request(http.MethodGet, metadataPath, nil, nil)
-					if metadataErr == nil {
-						var metadataResponse struct {
-							Success bool                   `json:"success"`
-							Result  map[string]interface{} `json:"result"`
-						}
-
-						if err := json.Unmarshal(metadataResp, &metadataResponse); err == nil &&
-							metadataResponse.Success && metadataResponse.Result != nil {
-
-							// Use smart recursive search on the metadata
-							if SmartMetadataSearch(metadataResponse.Result, searchValue) {
-								// Copy the key and add metadata
-								keyCopy := key // Make a copy to avoid modifying the original
-								metadata := KeyValueMetadata(metadataResponse.Result)
-								keyCopy.Metadata = &metadata
-
-								mu.Lock()
-								matchedKeys = append(matchedKeys, keyCopy)
-								totalMatched++
-								chunkMatched++
-								mu.Unlock()
-							}
+					// Get metadata via API; keys without metadata have a nil result
+					metadataResp, metadataErr := requestMetadata(client, metadataPath)
+					if metadataErr != nil {
+						readErr = metadataErr
+					} else if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp); err != nil {
+						readErr = fmt.Errorf("failed to decode metadata of key '%s': %w", key.Key, err)
+					} else if metadataResponse.Result != nil {
+						// Use smart recursive search on the metadata
+						if SmartMetadataSearch(metadataResponse.Result, searchValue) {
+							// Copy the key and add metadata
+							keyCopy := key // Make a copy to avoid modifying the original
+							metadata := KeyValueMetadata(metadataResponse.Result)
+							keyCopy.Metadata = &metadata
+
+							mu.Lock()
+							matchedKeys = append(matchedKeys, keyCopy)
+							totalMatched++
+							mu.Unlock()
 						}
 					}
 				}
+				failures.record(readErr)
 
 				// Update progress periodically (not on every item to reduce overhead)
-				if j%20 == 0 || j == len(chunk)-1 {
+				if j%20 == 0 || j == len(chunk)-1 || readErr != nil {
 					mu.Lock()
 					// Use max value calculation manually (Go 1.20 and earlier don't have built-in max)
 					if processedIdx+1 > totalProcessed {
 						totalProcessed = processedIdx + 1
 					}
-					progressCallback(totalKeys, totalProcessed, totalMatched, totalKeys)
+					progressCallback(totalKeys, totalProcessed, totalMatched, totalKeys, failures.snapshot())
 					mu.Unlock()
 				}
 			}
-
-			// Final progress update for this chunk
-			mu.Lock()
-			progressCallback(totalKeys, totalProcessed, totalMatched, totalKeys)
-			mu.Unlock()
-
 		}(chunkKeys, startIdx)
 	}
 
 	// Wait for all chunks to complete
 	wg.Wait()
-	close(errorsChan)
 
-	// Check for errors
-	for err := range errorsChan {
-		if err != nil {
-			return matchedKeys, fmt.Errorf("error during processing: %w", err)
-		}
+	stats := failures.snapshot()
+	if err := failures.err(); err != nil {
+		return matchedKeys, stats, err
 	}
 
 	// Final progress update
-	progressCallback(totalKeys, totalKeys, len(matchedKeys), totalKeys)
+	progressCallback(totalKeys, totalKeys, len(matchedKeys), totalKeys, stats)
 
-	return matchedKeys, nil
+	return matchedKeys, stats, nil
 }
 
 // SmartPurgeByValue finds and purges all keys containing a specific value in their metadata
 // Much more flexible than field-specific purges
-func SmartPurgeByValue(client *api.Client, accountID, namespaceID, searchValue string,
+func SmartPurgeByValue(client *api.Client, accountID, namespaceID, prefix, searchValue string,
 	chunkSize int, concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -1002,25 +1008,33 @@ func SmartPurgeByValue(client *api.Client, accountID, namespaceID, searchValue s
 	if chunkSize <= 0 {
 		chunkSize = 100 // Default chunk size
 	}
-	if concurrency <= 0 {
-		concurrency = 10 // Default concurrency
-	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
 
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {}
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, keysMatched, keysDeleted, total))
 	}
 
-	// Use our smart find function to locate matching keys
-	matchedKeys, err := SmartFindKeysWithValue(client, accountID, namespaceID, searchValue,
-		chunkSize, concurrency,
-		func(keysFetched, keysProcessed, keysMatched, total int) {
-			progressCallback(keysFetched, keysProcessed, keysMatched, 0, total)
-		})
+	// Use our smart find function to locate matching keys, reporting skipped keys with the progress
+	matchedKeys, failures, err := FindKeysWithValue(client, accountID, namespaceID, searchValue, ValueSearchOptions{
+		Prefix:      prefix,
+		ChunkSize:   chunkSize,
+		Concurrency: concurrency,
+		Progress: func(keysFetched, keysProcessed, keysMatched, total int, failures SearchErrorStats) {
+			event := purgeProgress(keysFetched, keysProcessed, keysMatched, 0, total)
+			event.Failed, event.FailedByClass = failures.Failed, failures.ByClass
+			tracker.report(event)
+		},
+	})
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to find keys with value '%s': %w", searchValue, err)
 	}
+	if failures.Failed > 0 {
+		fmt.Printf("Warning: skipped %d of %d keys whose metadata could not be read (%s); matching keys among them were not purged\n",
+			failures.Failed, failures.Checked, failures.Classes())
+	}
 
 	// Extract just the key names for deletion
 	keyNames := make([]string, len(matchedKeys))
@@ -1038,11 +1052,17 @@ func SmartPurgeByValue(client *api.Client, accountID, namespaceID, searchValue s
 		return 0, nil
 	}
 
+	// Deleting a split value deletes its chunks too
+	keyNames, err = WithChunkKeys(client, accountID, namespaceID, keyNames, matchedKeys)
+	if err != nil {
+		return 0, err
+	}
+
 	// Purge the keys in batches for efficiency
 	totalDeleted := 0
-	batchSize := 1000 // Cloudflare API limit
+	batchSize := common.CurrentPolicy().BatchSize
 
-	// Process keys in batches of 1000
+	// Process keys in batches of the policy's batch size
 	for i := 0; i < len(keyNames); i += batchSize {
 		end := i + batchSize
 		if end > len(keyNames) {
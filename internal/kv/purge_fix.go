@@ -2,9 +2,9 @@ package kv
 
 import (
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"sync"
 	"sync/atomic"
@@ -14,9 +14,9 @@ import (
 // StreamingPurgeByTagFixed performs a streaming purge of keys with a specific tag value
 // This is much more efficient for large namespaces as it processes in chunks
 // This version fixes race conditions using atomic operations and proper synchronization
-func StreamingPurgeByTagFixed(client *api.Client, accountID, namespaceID, tagField, tagValue string,
+func StreamingPurgeByTagFixed(client *api.Client, accountID, namespaceID, prefix, tagField, tagValue string,
 	chunkSize int, concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -30,20 +30,18 @@ func StreamingPurgeByTagFixed(client *api.Client, accountID, namespaceID, tagFie
 	if chunkSize <= 0 {
 		chunkSize = 100 // Default chunk size
 	}
-	if concurrency <= 0 {
-		concurrency = 20 // Default concurrency
-	}
-	if concurrency > 50 {
-		concurrency = 50 // Cap maximum concurrency
-	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
-	// Simple progress callback if none provided
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysDeleted, total int) {}
+	var totalMatched int32 // Use atomic counter for thread safety
+
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, int(atomic.LoadInt32(&totalMatched)), keysDeleted, total))
 	}
 
 	// First, list all keys (we need this to get the total count)
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, total)
 	})
 	if err != nil {
@@ -93,6 +91,7 @@ func StreamingPurgeByTagFixed(client *api.Client, accountID, namespaceID, tagFie
 			matchedKeysMutex.Lock()
 			allMatchedKeys = append(allMatchedKeys, matchedKeys...)
 			matchedKeysMutex.Unlock()
+			atomic.AddInt32(&totalMatched, int32(len(matchedKeys)))
 		}
 
 		// If we've reached a significant number of keys to delete, batch delete them
@@ -165,9 +164,10 @@ func StreamingPurgeByTagFixed(client *api.Client, accountID, namespaceID, tagFie
 // PurgeByMetadataOnlyFixed uses a metadata-first approach for better performance with fixed concurrency
 // It only checks metadata and doesn't look at values at all
 // This version fixes race conditions using atomic operations and proper synchronization
-func PurgeByMetadataOnlyFixed(client *api.Client, accountID, namespaceID, metadataField, metadataValue string,
+// Only keys under prefix are listed and considered; an empty prefix covers the namespace
+func PurgeByMetadataOnlyFixed(client *api.Client, accountID, namespaceID, prefix, metadataField, metadataValue string,
 	chunkSize int, concurrency int, dryRun bool,
-	progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)) (int, error) {
+	progress ProgressFunc) (int, error) {
 
 	if accountID == "" {
 		return 0, fmt.Errorf("account ID is required")
@@ -181,20 +181,16 @@ func PurgeByMetadataOnlyFixed(client *api.Client, accountID, namespaceID, metada
 	if chunkSize <= 0 {
 		chunkSize = 1000 // Use larger chunks for better performance
 	}
-	if concurrency <= 0 {
-		concurrency = 20 // Use higher concurrency for better performance
-	}
-	if concurrency > 50 {
-		concurrency = 50 // Cap maximum concurrency
-	}
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 20)
 
-	// Default progress callback
-	if progressCallback == nil {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {}
+	// Report the counts as progress events with rates and time remaining
+	tracker := newProgressTracker(progress)
+	progressCallback := func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
+		tracker.report(purgeProgress(keysFetched, keysProcessed, keysMatched, keysDeleted, total))
 	}
 
 	// First, list all keys (we need this to get the total count)
-	keys, err := ListAllKeys(client, accountID, namespaceID, func(fetched, total int) {
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: prefix}, func(fetched, total int) {
 		progressCallback(fetched, 0, 0, 0, total)
 	})
 	if err != nil {
@@ -291,10 +287,16 @@ func PurgeByMetadataOnlyFixed(client *api.Client, accountID, namespaceID, metada
 		return len(allMatchedKeys), nil
 	}
 
+	// Deleting a split value deletes its chunks too
+	allMatchedKeys, err = WithChunkKeys(client, accountID, namespaceID, allMatchedKeys, keys)
+	if err != nil {
+		return 0, err
+	}
+
 	// Delete matching keys in batches
 	if len(allMatchedKeys) > 0 {
-		// Use batch size of 1000 for deletions (Cloudflare API limit)
-		batchSize := 1000
+		// Delete in batches of the policy's batch size
+		batchSize := common.CurrentPolicy().BatchSize
 
 		for i := 0; i < len(allMatchedKeys); i += batchSize {
 			end := i + batchSize
@@ -338,10 +340,9 @@ func processMetadataOnlyChunkFixed(client *api.Client, accountID, namespaceID st
 		// First check if key already has metadata from the list response
 		if key.Metadata != nil {
 			// Check if metadata contains our field
-			if fieldValue, ok := (*key.Metadata)[metadataField]; ok {
+			if fieldValue, ok := common.LookupMetadataField(*key.Metadata, metadataField); ok {
 				// We found the field in metadata!
-				fieldStr, isString := fieldValue.(string)
-				if isString && (metadataValue == "" || fieldStr == metadataValue) {
+				if common.MetadataValueMatches(fieldValue, metadataValue) {
 					mu.Lock()
 					matchedKeys = append(matchedKeys, key.Key)
 					mu.Unlock()
@@ -360,23 +361,16 @@ func processMetadataOnlyChunkFixed(client *api.Client, accountID, namespaceID st
 			accountID, namespaceID, encodedKey)
 
 		// Get metadata
-		metadataResp, metadataErr := client.Request(http.MethodGet, metadataPath, nil, nil)
+		metadataResp, metadataErr := requestMetadata(client, metadataPath)
 
 		// If we got metadata and it contains our field, check it
 		if metadataErr == nil {
-			var metadataResponse struct {
-				Success bool                   `json:"success"`
-				Result  map[string]interface{} `json:"result"`
-			}
-
-			if err := json.Unmarshal(metadataResp, &metadataResponse); err == nil &&
-				metadataResponse.Success && metadataResponse.Result != nil {
+			if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp); err == nil && metadataResponse.Result != nil {
 
 				// Check if metadata has our field
-				if fieldValue, ok := metadataResponse.Result[metadataField]; ok {
+				if fieldValue, ok := common.LookupMetadataField(metadataResponse.Result, metadataField); ok {
 					// We found the field in metadata!
-					fieldStr, isString := fieldValue.(string)
-					if isString && (metadataValue == "" || fieldStr == metadataValue) {
+					if common.MetadataValueMatches(fieldValue, metadataValue) {
 						mu.Lock()
 						matchedKeys = append(matchedKeys, key.Key)
 						mu.Unlock()
@@ -426,10 +420,9 @@ func processKeyChunkOptimizedFixed(client *api.Client, accountID, namespaceID st
 				// First check if metadata is already in the list response
 				if key.Metadata != nil {
 					// Check if metadata has our tag field
-					if fieldValue, ok := (*key.Metadata)[tagField]; ok {
+					if fieldValue, ok := common.LookupMetadataField(*key.Metadata, tagField); ok {
 						// We found the field in metadata!
-						foundTagStr, isString := fieldValue.(string)
-						if isString && (tagValue == "" || foundTagStr == tagValue) {
+						if common.MetadataValueMatches(fieldValue, tagValue) {
 							matches = true
 						}
 
@@ -454,23 +447,16 @@ func processKeyChunkOptimizedFixed(client *api.Client, accountID, namespaceID st
 					accountID, namespaceID, encodedKey)
 
 				// Get metadata
-				metadataResp, metadataErr := client.Request(http.MethodGet, metadataPath, nil, nil)
+				metadataResp, metadataErr := requestMetadata(client, metadataPath)
 
 				// If we got metadata and it contains our tag field, check it
 				if metadataErr == nil {
-					var metadataResponse struct {
-						Success bool                   `json:"success"`
-						Result  map[string]interface{} `json:"result"`
-					}
-
-					if err := json.Unmarshal(metadataResp, &metadataResponse); err == nil &&
-						metadataResponse.Success && metadataResponse.Result != nil {
+					if metadataResponse, err := api.DecodeResponse[map[string]interface{}](metadataResp); err == nil && metadataResponse.Result != nil {
 
 						// Check if metadata has our tag field
-						if fieldValue, ok := metadataResponse.Result[tagField]; ok {
+						if fieldValue, ok := common.LookupMetadataField(metadataResponse.Result, tagField); ok {
 							// We found the field in metadata!
-							foundTagStr, isString := fieldValue.(string)
-							if isString && (tagValue == "" || foundTagStr == tagValue) {
+							if common.MetadataValueMatches(fieldValue, tagValue) {
 								matches = true
 							}
 
@@ -491,7 +477,7 @@ func processKeyChunkOptimizedFixed(client *api.Client, accountID, namespaceID st
 
 				// If we still didn't find metadata, fall back to getting the value
 				// Add a small delay to avoid rate limiting
-				time.Sleep(time.Duration(10) * time.Millisecond)
+				time.Sleep(common.CurrentPolicy().WorkerDelay)
 
 				// Get the value
 				value, err := GetValue(client, accountID, namespaceID, key.Key)
@@ -525,14 +511,8 @@ func processKeyChunkOptimizedFixed(client *api.Client, accountID, namespaceID st
 					continue
 				}
 
-				// Check if the tag field exists and matches
-				if foundTagValue, ok := valueMap[tagField]; ok {
-					// Convert tag value to string
-					if foundTagStr, ok := foundTagValue.(string); ok {
-						// Check if tag value matches (if tagValue is empty, match any tag)
-						matches = tagValue == "" || foundTagStr == tagValue
-					}
-				}
+				// Check if the tag field exists and matches (if tagValue is empty, match any tag)
+				matches = common.MetadataFieldMatches(valueMap, tagField, tagValue)
 
 				// Report result
 				resultChan <- struct {
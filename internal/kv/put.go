@@ -1,6 +1,7 @@
 package kv
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -46,22 +47,8 @@ func WriteValue(client *api.Client, accountID, namespaceID, key, value string, o
 		}
 	}
 
-	respBody, err := client.Request(http.MethodPut, path, query, []byte(value))
-	if err != nil {
-		return err
-	}
-
-	var apiResp api.APIResponse
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return fmt.Errorf("failed to parse API response: %w", err)
-	}
-
-	if !apiResp.Success {
-		errorStr := "API reported failure"
-		if len(apiResp.Errors) > 0 {
-			errorStr = apiResp.Errors[0].Message
-		}
-		return fmt.Errorf("failed to write value: %s", errorStr)
+	if _, err := api.RequestJSON[json.RawMessage](context.Background(), client, http.MethodPut, path, query, []byte(value)); err != nil {
+		return fmt.Errorf("failed to write value: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,205 @@
+package kv
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// ReplaceOptions configures a find-and-replace over the values of a namespace
+type ReplaceOptions struct {
+	Find        string // Text to find, or a regular expression with Regex
+	Replace     string // Replacement; with Regex it may reference groups as $1 or ${name}
+	Regex       bool
+	Prefix      string // Only keys with this prefix
+	Concurrency int    // Concurrent value reads (default 10)
+	BatchSize   int    // Keys per bulk write (default 1000)
+	SampleSize  int    // Changes kept in the result for a preview (default 5)
+	DryRun      bool   // Find the changes without writing them
+}
+
+// ReplaceChange is a value changed by a replacement
+type ReplaceChange struct {
+	Key     string `json:"key"`
+	Matches int    `json:"matches"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+}
+
+// ReplaceResult summarizes a find-and-replace
+type ReplaceResult struct {
+	Scanned int               `json:"scanned"`
+	Matched int               `json:"matched"` // Keys whose value contains a match
+	Updated []string          `json:"updated"`
+	Failed  map[string]string `json:"failed,omitempty"` // Key to error message
+	Samples []ReplaceChange   `json:"samples,omitempty"`
+}
+
+// ReplaceInValues applies a find-and-replace to every value in a namespace. Keys are listed
+// page by page; the values of each page are fetched concurrently, and the changed values
+// are written back with the bulk API, keeping each key's metadata and expiration. Only the
+// sample changes are held in memory, so large namespaces can be processed.
+//
+// Keys that expire within a minute cannot be written with their expiration and are
+// reported as failed. The progress callback is called after each page.
+func ReplaceInValues(client *api.Client, accountID, namespaceID string, options ReplaceOptions,
+	progressCallback func(scanned, matched, updated int)) (*ReplaceResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.Find == "" {
+		return nil, fmt.Errorf("find text is required")
+	}
+	if options.SampleSize <= 0 {
+		options.SampleSize = 5
+	}
+
+	replacer, err := newValueReplacer(options.Find, options.Replace, options.Regex)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &replaceHandler{
+		keyListingHandler: keyListingHandler{
+			client:      client,
+			accountID:   accountID,
+			namespaceID: namespaceID,
+			options:     &ListKeysOptions{Limit: 1000, Prefix: options.Prefix},
+		},
+		replacer:         replacer,
+		options:          options,
+		processed:        make(map[string]bool),
+		result:           &ReplaceResult{Updated: []string{}, Failed: make(map[string]string)},
+		progressCallback: progressCallback,
+	}
+
+	pagOptions := &common.PaginationOptions{
+		MaxRetries: common.CurrentPolicy().Retries,
+		Timeout:    120 * time.Second,
+		LogPrefix:  "Replace",
+	}
+	if _, err := common.ExecutePagination(handler, pagOptions); err != nil {
+		return handler.result, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	sort.Strings(handler.result.Updated)
+	return handler.result, nil
+}
+
+// valueReplacer applies a literal or regex replacement and counts the matches
+type valueReplacer func(value string) (string, int)
+
+// newValueReplacer builds the replacement function for find and replace
+func newValueReplacer(find, replace string, regex bool) (valueReplacer, error) {
+	if !regex {
+		return func(value string) (string, int) {
+			n := strings.Count(value, find)
+			if n == 0 {
+				return value, 0
+			}
+			return strings.ReplaceAll(value, find, replace), n
+		}, nil
+	}
+
+	re, err := regexp.Compile(find)
+	if err != nil {
+		return nil, fmt.Errorf("invalid find regex '%s': %w", find, err)
+	}
+	return func(value string) (string, int) {
+		n := len(re.FindAllStringIndex(value, -1))
+		if n == 0 {
+			return value, 0
+		}
+		return re.ReplaceAllString(value, replace), n
+	}, nil
+}
+
+// replaceHandler is a key listing handler that rewrites each page of keys as it arrives
+// instead of collecting them
+type replaceHandler struct {
+	keyListingHandler
+	replacer         valueReplacer
+	options          ReplaceOptions
+	processed        map[string]bool // Keys already handled, so a cursor restart doesn't replace twice
+	result           *ReplaceResult
+	progressCallback func(scanned, matched, updated int)
+}
+
+// ProcessItems fetches, rewrites and writes back the values of a page of keys
+func (h *replaceHandler) ProcessItems(items interface{}) error {
+	page, ok := items.([]KeyValuePair)
+	if !ok {
+		return fmt.Errorf("unexpected item type in key listing")
+	}
+
+	keys := make([]KeyValuePair, 0, len(page))
+	for _, key := range page {
+		if !h.processed[key.Key] {
+			h.processed[key.Key] = true
+			keys = append(keys, key)
+		}
+	}
+	h.result.Scanned += len(keys)
+
+	values, failed := readValues(h.client, h.accountID, h.namespaceID, keys, h.options.Concurrency, nil)
+	for key, msg := range failed {
+		h.result.Failed[key] = msg
+	}
+
+	minExpiration := time.Now().Unix() + MinExpirationTTL
+	var writes []BulkWriteItem
+	for i, key := range keys {
+		if _, ok := failed[key.Key]; ok {
+			continue
+		}
+
+		after, matches := h.replacer(values[i])
+		if matches == 0 || after == values[i] {
+			continue
+		}
+		h.result.Matched++
+		if len(h.result.Samples) < h.options.SampleSize {
+			h.result.Samples = append(h.result.Samples, ReplaceChange{
+				Key: key.Key, Matches: matches, Before: values[i], After: after,
+			})
+		}
+
+		if key.Expiration > 0 && key.Expiration < minExpiration {
+			h.result.Failed[key.Key] = "key expires within a minute and cannot be rewritten with its expiration"
+			continue
+		}
+
+		item := BulkWriteItem{Key: key.Key, Value: after, Expiration: key.Expiration}
+		if key.Metadata != nil {
+			item.Metadata = *key.Metadata
+		}
+		writes = append(writes, item)
+	}
+
+	if !h.options.DryRun && len(writes) > 0 {
+		written, rejected := writeValues(h.client, h.accountID, h.namespaceID, writes, h.options.BatchSize, nil)
+		h.result.Updated = append(h.result.Updated, written...)
+		for key, msg := range rejected {
+			h.result.Failed[key] = msg
+		}
+	}
+
+	if h.progressCallback != nil {
+		h.progressCallback(h.result.Scanned, h.result.Matched, len(h.result.Updated))
+	}
+	return nil
+}
+
+// PrepareRestart does nothing, since processed keys are always tracked
+func (h *replaceHandler) PrepareRestart() error {
+	return nil
+}
@@ -0,0 +1,231 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// ReprefixOptions configures moving the keys under one prefix to another
+type ReprefixOptions struct {
+	From         string          // Prefix of the keys to move
+	To           string          // Prefix that replaces it
+	Overwrite    OverwritePolicy // What to do when a new key name already exists (default skip)
+	DeleteSource bool            // Delete each source key once it is copied
+	Concurrency  int             // Concurrent value reads (default 10)
+	BatchSize    int             // Keys per bulk write and delete (default 1000)
+	DryRun       bool            // Find the keys without writing them
+}
+
+// ReprefixResult summarizes a reprefix. Keys are listed by their source name.
+type ReprefixResult struct {
+	Scanned int               `json:"scanned"`
+	Copied  []string          `json:"copied"`
+	Skipped []string          `json:"skipped,omitempty"` // The new key name already existed
+	Deleted []string          `json:"deleted,omitempty"`
+	Failed  map[string]string `json:"failed,omitempty"` // Key to error message
+	DryRun  bool              `json:"dry_run,omitempty"`
+}
+
+// ReprefixKey returns the name of a key with the from prefix replaced by to
+func ReprefixKey(key, from, to string) string {
+	return to + strings.TrimPrefix(key, from)
+}
+
+// ReprefixKeys copies every key under the From prefix to the same name under the To prefix,
+// keeping value, metadata and expiration, and with DeleteSource deletes the source keys that
+// were copied. Keys are handled in batches: the values of a batch are read concurrently,
+// written with the bulk API and, with DeleteSource, only then deleted, so an interrupted run
+// never loses a key and can be repeated to finish.
+//
+// Binary values, such as values compressed with --compress, are written base64-encoded so
+// they arrive byte for byte. A split value moves as one key: it is reassembled, written under
+// the new name, split again if it is still too large for KV, and its old chunks are deleted
+// with it.
+//
+// All source keys are listed before anything is written, and a move is refused when a new
+// key name is also a source key name, which can happen when one prefix starts with the
+// other. Keys that expire within a minute cannot be written with their expiration and are
+// reported as failed. The progress callback is called after each batch.
+func ReprefixKeys(client *api.Client, accountID, namespaceID string, options ReprefixOptions,
+	progressCallback func(processed, total int)) (*ReprefixResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.From == "" {
+		return nil, fmt.Errorf("source prefix is required")
+	}
+	if options.From == options.To {
+		return nil, fmt.Errorf("source and destination prefix are the same")
+	}
+	if options.Overwrite == "" {
+		options.Overwrite = OverwriteSkip
+	}
+	options.BatchSize = common.CurrentPolicy().Batch(options.BatchSize)
+
+	sourceKeys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.From}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys under '%s': %w", options.From, err)
+	}
+	// Chunks move with the value they belong to
+	sourceKeys = withoutChunks(sourceKeys)
+
+	result := &ReprefixResult{
+		Scanned: len(sourceKeys),
+		Copied:  []string{},
+		Failed:  make(map[string]string),
+		DryRun:  options.DryRun,
+	}
+
+	// New names must be valid and must not collide with the keys being moved
+	sources := make(map[string]bool, len(sourceKeys))
+	newNames := make([]string, len(sourceKeys))
+	for i, key := range sourceKeys {
+		sources[key.Key] = true
+		newNames[i] = ReprefixKey(key.Key, options.From, options.To)
+	}
+	for i, name := range newNames {
+		if sources[name] {
+			return nil, fmt.Errorf("key '%s' would be moved to '%s', which is also a key under '%s'; move to a prefix that does not overlap",
+				sourceKeys[i].Key, name, options.From)
+		}
+	}
+	if fatal := common.FatalKeyIssues(common.ValidateKVKeys(newNames)); len(fatal) > 0 {
+		return nil, fmt.Errorf("%d new key names are invalid, e.g. '%s' (%s)", len(fatal), fatal[0].Key, fatal[0].Problem)
+	}
+
+	// Check which new names already exist
+	existing := make(map[string]bool)
+	if options.Overwrite != OverwriteReplace && len(sourceKeys) > 0 {
+		destKeys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.To}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys under '%s': %w", options.To, err)
+		}
+		for _, key := range destKeys {
+			existing[key.Key] = true
+		}
+	}
+
+	var toMove []KeyValuePair
+	for i, key := range sourceKeys {
+		if existing[newNames[i]] {
+			if options.Overwrite == OverwriteFail {
+				return nil, fmt.Errorf("key '%s' already exists (use --overwrite skip or replace)", newNames[i])
+			}
+			result.Skipped = append(result.Skipped, key.Key)
+			continue
+		}
+		toMove = append(toMove, key)
+	}
+
+	if options.DryRun {
+		for _, key := range toMove {
+			result.Copied = append(result.Copied, key.Key)
+		}
+		return result, nil
+	}
+
+	for start := 0; start < len(toMove); start += options.BatchSize {
+		batch := toMove[start:min(start+options.BatchSize, len(toMove))]
+
+		values, failed := readValues(client, accountID, namespaceID, batch, options.Concurrency, nil)
+		for key, msg := range failed {
+			result.Failed[key] = msg
+		}
+
+		minExpiration := time.Now().Unix() + MinExpirationTTL
+		var writes []BulkWriteItem
+		sourceOf := make(map[string]string, len(batch))
+		for i, key := range batch {
+			if _, ok := failed[key.Key]; ok {
+				continue
+			}
+			if key.Expiration > 0 && key.Expiration < minExpiration {
+				result.Failed[key.Key] = "key expires within a minute and cannot be copied with its expiration"
+				continue
+			}
+
+			value := values[i]
+			var metadata map[string]interface{}
+			if key.Metadata != nil {
+				metadata = *key.Metadata
+			}
+			if IsChunked(metadata) {
+				assembled, err := ReadChunkedValue(client, accountID, namespaceID, key.Key, value)
+				if err != nil {
+					result.Failed[key.Key] = err.Error()
+					continue
+				}
+				value, metadata = assembled, withoutChunkMarker(metadata)
+			}
+
+			item := ValueItem(ReprefixKey(key.Key, options.From, options.To), value)
+			item.Expiration = key.Expiration
+			item.Metadata = metadata
+			writes = append(writes, item)
+			sourceOf[item.Key] = key.Key
+		}
+
+		// Replaced keys may hold split values whose chunks the new values leave unused
+		var stored map[string][]string
+		if options.Overwrite == OverwriteReplace && len(writes) > 0 {
+			names := make([]string, len(writes))
+			for i, item := range writes {
+				names[i] = item.Key
+			}
+			if stored, err = StoredChunkKeysOf(context.Background(), client, accountID, namespaceID, names, options.Concurrency); err != nil {
+				return result, err
+			}
+		}
+
+		// Values still too large for KV are split again under their new name
+		fitting, oversized := SplitOversizedItems(writes)
+		written, rejected := writeValues(client, accountID, namespaceID, fitting, options.BatchSize, nil)
+		for _, key := range written {
+			if err := DeleteStaleChunks(client, accountID, namespaceID, key, stored[key], 0); err != nil {
+				return result, err
+			}
+		}
+		for _, item := range oversized {
+			if _, err := WriteChunkedItem(client, accountID, namespaceID, item); err != nil {
+				rejected[item.Key] = err.Error()
+				continue
+			}
+			written = append(written, item.Key)
+		}
+		for key, msg := range rejected {
+			result.Failed[sourceOf[key]] = msg
+		}
+		copied := make([]string, len(written))
+		for i, key := range written {
+			copied[i] = sourceOf[key]
+		}
+		result.Copied = append(result.Copied, copied...)
+
+		// Only delete sources whose copy was written, along with the chunks of split ones
+		if options.DeleteSource && len(copied) > 0 {
+			deleting, err := WithChunkKeys(client, accountID, namespaceID, copied, batch)
+			if err != nil {
+				return result, fmt.Errorf("failed to find the chunks of copied source keys: %w", err)
+			}
+			if err := DeleteMultipleValuesInBatches(client, accountID, namespaceID, deleting, options.BatchSize, nil); err != nil {
+				return result, fmt.Errorf("failed to delete copied source keys: %w", err)
+			}
+			result.Deleted = append(result.Deleted, copied...)
+		}
+
+		if progressCallback != nil {
+			progressCallback(min(start+options.BatchSize, len(toMove)), len(toMove))
+		}
+	}
+
+	return result, nil
+}
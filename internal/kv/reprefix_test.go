@@ -0,0 +1,53 @@
+package kv
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReprefixKeysBinaryAndChunkedValues(t *testing.T) {
+	ns, client := newFakeNamespace(t)
+	ns.listMetadata = true
+	const account, namespace = "account", "namespace"
+
+	compressed, err := CompressValue(strings.Repeat("hello world ", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.values["old/gzip"] = compressed
+	ns.metadata["old/gzip"] = json.RawMessage(`{"content-encoding": "gzip"}`)
+	if _, err := WriteChunkedValue(client, account, namespace, "old/split", "0123456789", nil, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ReprefixKeys(client, account, namespace, ReprefixOptions{From: "old/", To: "new/", DeleteSource: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failed) > 0 {
+		t.Fatalf("failed keys: %v", result.Failed)
+	}
+	if got := strings.Join(result.Copied, ","); got != "old/gzip,old/split" {
+		t.Errorf("copied = %s, want old/gzip,old/split", got)
+	}
+
+	// The gzip value arrives byte for byte and still decompresses
+	if ns.values["new/gzip"] != compressed {
+		t.Error("gzip value was not copied byte for byte")
+	}
+	if value, err := decodeStoredValue(ns.values["new/gzip"], &KeyValueMetadata{ContentEncodingField: "gzip"}); err != nil || value != strings.Repeat("hello world ", 100) {
+		t.Errorf("moved gzip value decodes to %q, %v", value, err)
+	}
+	if got := string(ns.metadata["new/gzip"]); got != `{"content-encoding":"gzip"}` {
+		t.Errorf("moved gzip metadata = %s", got)
+	}
+
+	// The split value moved as one key, leaving no chunks of the old one behind
+	if got := ns.values["new/split"]; got != "0123456789" {
+		t.Errorf("moved split value = %q, want 0123456789", got)
+	}
+	if got := strings.Join(ns.keys(), ","); got != "new/gzip,new/split" {
+		t.Errorf("keys after move = %s, want new/gzip,new/split", got)
+	}
+}
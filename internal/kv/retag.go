@@ -0,0 +1,193 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// RetagOptions configures renaming a tag in the metadata of a namespace
+type RetagOptions struct {
+	TagField    string // Metadata field holding the tag (default cache-tag)
+	From        string // Tag to replace
+	To          string // New tag
+	Prefix      string // Only keys with this prefix
+	Concurrency int    // Concurrent value reads (default 10)
+	BatchSize   int    // Keys per bulk write (default 1000)
+	DryRun      bool   // Find the keys without writing them
+}
+
+// RetagResult summarizes a retag
+type RetagResult struct {
+	Scanned int               `json:"scanned"`
+	Matched []string          `json:"matched"` // Keys carrying the old tag
+	Updated []string          `json:"updated"`
+	Failed  map[string]string `json:"failed,omitempty"` // Key to error message
+}
+
+// RetagKeys replaces a tag in the metadata field of every key that carries it, where the
+// field either equals the tag or is a list containing it. Keys are listed page by page with
+// their metadata, so values are only read for matching keys, which are written back with
+// the bulk API keeping their value and expiration.
+//
+// Keys that expire within a minute cannot be written with their expiration and are
+// reported as failed. The progress callback is called after each page.
+func RetagKeys(client *api.Client, accountID, namespaceID string, options RetagOptions,
+	progressCallback func(scanned, matched, updated int)) (*RetagResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.From == "" || options.To == "" {
+		return nil, fmt.Errorf("old and new tag are required")
+	}
+	if options.From == options.To {
+		return nil, fmt.Errorf("old and new tag are the same")
+	}
+	if options.TagField == "" {
+		options.TagField = "cache-tag"
+	}
+
+	handler := &retagHandler{
+		keyListingHandler: keyListingHandler{
+			client:      client,
+			accountID:   accountID,
+			namespaceID: namespaceID,
+			options:     &ListKeysOptions{Limit: 1000, Prefix: options.Prefix},
+		},
+		options:          options,
+		processed:        make(map[string]bool),
+		result:           &RetagResult{Matched: []string{}, Updated: []string{}, Failed: make(map[string]string)},
+		progressCallback: progressCallback,
+	}
+
+	pagOptions := &common.PaginationOptions{
+		MaxRetries: common.CurrentPolicy().Retries,
+		Timeout:    120 * time.Second,
+		LogPrefix:  "Retag",
+	}
+	if _, err := common.ExecutePagination(handler, pagOptions); err != nil {
+		return handler.result, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	sort.Strings(handler.result.Matched)
+	sort.Strings(handler.result.Updated)
+	return handler.result, nil
+}
+
+// retagMetadata returns a copy of metadata with the tag replaced in field, or false if
+// the field doesn't carry the tag
+func retagMetadata(metadata *KeyValueMetadata, field, from, to string) (KeyValueMetadata, bool) {
+	if metadata == nil {
+		return nil, false
+	}
+
+	var replaced interface{}
+	switch value := (*metadata)[field].(type) {
+	case string:
+		if value != from {
+			return nil, false
+		}
+		replaced = to
+	case []interface{}:
+		tags := make([]interface{}, len(value))
+		found := false
+		for i, tag := range value {
+			if s, ok := tag.(string); ok && s == from {
+				tags[i] = to
+				found = true
+			} else {
+				tags[i] = tag
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		replaced = tags
+	default:
+		return nil, false
+	}
+
+	updated := make(KeyValueMetadata, len(*metadata))
+	for k, v := range *metadata {
+		updated[k] = v
+	}
+	updated[field] = replaced
+	return updated, true
+}
+
+// retagHandler is a key listing handler that retags each page of keys as it arrives
+// instead of collecting them
+type retagHandler struct {
+	keyListingHandler
+	options          RetagOptions
+	processed        map[string]bool // Keys already handled, so a cursor restart doesn't retag twice
+	result           *RetagResult
+	progressCallback func(scanned, matched, updated int)
+}
+
+// ProcessItems finds the keys of a page carrying the old tag and writes them back retagged
+func (h *retagHandler) ProcessItems(items interface{}) error {
+	page, ok := items.([]KeyValuePair)
+	if !ok {
+		return fmt.Errorf("unexpected item type in key listing")
+	}
+
+	var matched []KeyValuePair
+	var metadata []KeyValueMetadata
+	for _, key := range page {
+		if h.processed[key.Key] {
+			continue
+		}
+		h.processed[key.Key] = true
+		h.result.Scanned++
+
+		if updated, ok := retagMetadata(key.Metadata, h.options.TagField, h.options.From, h.options.To); ok {
+			matched = append(matched, key)
+			metadata = append(metadata, updated)
+			h.result.Matched = append(h.result.Matched, key.Key)
+		}
+	}
+
+	if !h.options.DryRun && len(matched) > 0 {
+		values, failed := readValues(h.client, h.accountID, h.namespaceID, matched, h.options.Concurrency, nil)
+		for key, msg := range failed {
+			h.result.Failed[key] = msg
+		}
+
+		minExpiration := time.Now().Unix() + MinExpirationTTL
+		var writes []BulkWriteItem
+		for i, key := range matched {
+			if _, ok := failed[key.Key]; ok {
+				continue
+			}
+			if key.Expiration > 0 && key.Expiration < minExpiration {
+				h.result.Failed[key.Key] = "key expires within a minute and cannot be rewritten with its expiration"
+				continue
+			}
+			writes = append(writes, BulkWriteItem{Key: key.Key, Value: values[i], Expiration: key.Expiration, Metadata: metadata[i]})
+		}
+
+		written, rejected := writeValues(h.client, h.accountID, h.namespaceID, writes, h.options.BatchSize, nil)
+		h.result.Updated = append(h.result.Updated, written...)
+		for key, msg := range rejected {
+			h.result.Failed[key] = msg
+		}
+	}
+
+	if h.progressCallback != nil {
+		h.progressCallback(h.result.Scanned, len(h.result.Matched), len(h.result.Updated))
+	}
+	return nil
+}
+
+// PrepareRestart does nothing, since processed keys are always tracked
+func (h *retagHandler) PrepareRestart() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package kv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// ValueItem returns a bulk write item for a value read from a namespace. The bulk API takes
+// JSON strings, which can't carry invalid UTF-8, so binary values such as gzip data are
+// base64-encoded.
+func ValueItem(key, value string) BulkWriteItem {
+	if utf8.ValidString(value) {
+		return BulkWriteItem{Key: key, Value: value}
+	}
+	return BulkWriteItem{Key: key, Value: base64.StdEncoding.EncodeToString([]byte(value)), Base64: true}
+}
+
+// readValues fetches the values of keys with a pool of concurrency workers. values[i] is the
+// value of keys[i]; keys whose value could not be read are returned in failed, with the
+// reason, and have an empty value. The progress callback is called after each read.
+func readValues(client *api.Client, accountID, namespaceID string, keys []KeyValuePair, concurrency int,
+	progressCallback func()) (values []string, failed map[string]string) {
+
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	values = make([]string, len(keys))
+	failed = make(map[string]string)
+	var mu sync.Mutex
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(keys)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				value, err := GetValue(client, accountID, namespaceID, keys[i].Key)
+
+				mu.Lock()
+				if err != nil {
+					failed[keys[i].Key] = fmt.Sprintf("failed to read value: %v", err)
+				} else {
+					values[i] = value
+				}
+				if progressCallback != nil {
+					progressCallback()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return values, failed
+}
+
+// writeValues writes items with the bulk API in batches of batchSize and returns the keys
+// that were written and the keys that were rejected, with the reason. A failed batch marks
+// all of its keys as rejected. The progress callback is called with the size of each batch.
+func writeValues(client *api.Client, accountID, namespaceID string, items []BulkWriteItem, batchSize int,
+	progressCallback func(written int)) (written []string, failed map[string]string) {
+
+	batchSize = common.CurrentPolicy().Batch(batchSize)
+
+	written = []string{}
+	failed = make(map[string]string)
+	for start := 0; start < len(items); start += batchSize {
+		batch := items[start:min(start+batchSize, len(items))]
+
+		rejected := make(map[string]string)
+		resp, err := WriteMultipleValuesWithResult(client, accountID, namespaceID, batch)
+		if err != nil {
+			for _, item := range batch {
+				rejected[item.Key] = fmt.Sprintf("failed to write value: %v", err)
+			}
+		} else {
+			for _, keyErr := range resp.Result.Errors {
+				rejected[keyErr.Key] = keyErr.Error
+			}
+		}
+
+		for _, item := range batch {
+			if msg, ok := rejected[item.Key]; ok {
+				failed[item.Key] = msg
+			} else {
+				written = append(written, item.Key)
+			}
+		}
+		if progressCallback != nil {
+			progressCallback(len(batch))
+		}
+	}
+
+	return written, failed
+}
@@ -0,0 +1,153 @@
+package kv
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// SampleOptions configures random sampling of the keys in a namespace
+type SampleOptions struct {
+	Prefix      string // Only keys with this prefix
+	Pattern     string // Only keys whose name matches this regular expression
+	Count       int    // Number of keys to sample (default 10)
+	Concurrency int    // Concurrent value reads (default 10)
+}
+
+// SampledKey is a randomly chosen key with its value
+type SampledKey struct {
+	Key        string            `json:"key"`
+	Value      string            `json:"value"`
+	Expiration int64             `json:"expiration,omitempty"`
+	Metadata   *KeyValueMetadata `json:"metadata,omitempty"`
+	Error      string            `json:"error,omitempty"` // Set when the value could not be read
+}
+
+// SampleResult holds the sampled keys and the number of keys they were drawn from
+type SampleResult struct {
+	Matched int          `json:"matched"`
+	Samples []SampledKey `json:"samples"`
+}
+
+// SampleKeys picks Count keys uniformly at random from the keys matching the prefix and
+// pattern, and fetches their values. Keys are listed page by page with reservoir sampling,
+// so only the sample is held in memory. Samples are returned in key order. The progress
+// callback is called after each page with the number of matching keys so far.
+func SampleKeys(client *api.Client, accountID, namespaceID string, options SampleOptions,
+	progressCallback func(matched int)) (*SampleResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.Count <= 0 {
+		options.Count = 10
+	}
+
+	var pattern *regexp.Regexp
+	if options.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(options.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", options.Pattern, err)
+		}
+	}
+
+	handler := &sampleHandler{
+		keyListingHandler: keyListingHandler{
+			client:      client,
+			accountID:   accountID,
+			namespaceID: namespaceID,
+			options:     &ListKeysOptions{Limit: 1000, Prefix: options.Prefix},
+		},
+		pattern:          pattern,
+		count:            options.Count,
+		rng:              rand.New(rand.NewSource(time.Now().UnixNano())),
+		processed:        make(map[string]bool),
+		progressCallback: progressCallback,
+	}
+
+	pagOptions := &common.PaginationOptions{
+		MaxRetries: common.CurrentPolicy().Retries,
+		Timeout:    120 * time.Second,
+		LogPrefix:  "Sample",
+	}
+	if _, err := common.ExecutePagination(handler, pagOptions); err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	keys := handler.reservoir
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+
+	values, failed := readValues(client, accountID, namespaceID, keys, options.Concurrency, nil)
+	result := &SampleResult{Matched: handler.matched, Samples: make([]SampledKey, len(keys))}
+	for i, key := range keys {
+		if _, ok := failed[key.Key]; !ok {
+			decoded, err := decodeStoredValue(values[i], key.Metadata)
+			if err != nil {
+				failed[key.Key] = err.Error()
+			}
+			values[i] = decoded
+		}
+		result.Samples[i] = SampledKey{
+			Key:        key.Key,
+			Value:      values[i],
+			Expiration: key.Expiration,
+			Metadata:   key.Metadata,
+			Error:      failed[key.Key],
+		}
+	}
+	return result, nil
+}
+
+// sampleHandler is a key listing handler that keeps a uniform random sample of the keys
+// instead of collecting them all
+type sampleHandler struct {
+	keyListingHandler
+	pattern          *regexp.Regexp
+	count            int
+	rng              *rand.Rand
+	matched          int
+	reservoir        []KeyValuePair
+	processed        map[string]bool // Keys already seen, so a cursor restart doesn't count them twice
+	progressCallback func(matched int)
+}
+
+// ProcessItems adds a page of keys to the reservoir
+func (h *sampleHandler) ProcessItems(items interface{}) error {
+	page, ok := items.([]KeyValuePair)
+	if !ok {
+		return fmt.Errorf("unexpected item type in key listing")
+	}
+
+	for _, key := range page {
+		if h.processed[key.Key] || (h.pattern != nil && !h.pattern.MatchString(key.Key)) {
+			continue
+		}
+		h.processed[key.Key] = true
+		h.matched++
+
+		// Reservoir sampling: the n-th key replaces a sampled key with probability count/n
+		if len(h.reservoir) < h.count {
+			h.reservoir = append(h.reservoir, key)
+		} else if j := h.rng.Intn(h.matched); j < h.count {
+			h.reservoir[j] = key
+		}
+	}
+
+	if h.progressCallback != nil {
+		h.progressCallback(h.matched)
+	}
+	return nil
+}
+
+// PrepareRestart does nothing, since seen keys are always tracked
+func (h *sampleHandler) PrepareRestart() error {
+	return nil
+}
@@ -0,0 +1,131 @@
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// searchErrorMinChecked is how many keys a value search checks before an error rate can abort
+// it, so a few early failures don't stop a long search
+const searchErrorMinChecked = 100
+
+// ErrSearchErrorRate is returned, wrapped, when a value search aborts because too many
+// metadata reads failed
+var ErrSearchErrorRate = errors.New("too many metadata reads failed")
+
+// SearchErrorStats counts the keys a value search checked and the ones it skipped because
+// their metadata couldn't be read, by error class. Skipped keys may have matched, so a
+// search with failures can report fewer matches than the namespace holds.
+type SearchErrorStats struct {
+	Checked int                      `json:"checked"`
+	Failed  int                      `json:"failed"`
+	ByClass map[ExportErrorClass]int `json:"by_class,omitempty"`
+}
+
+// Rate returns the share of checked keys that failed
+func (s SearchErrorStats) Rate() float64 {
+	if s.Checked == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(s.Checked)
+}
+
+// Classes formats the failures per class, most frequent first, e.g. "rate_limited: 10, server: 2"
+func (s SearchErrorStats) Classes() string {
+	classes := make([]ExportErrorClass, 0, len(s.ByClass))
+	for class := range s.ByClass {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if s.ByClass[classes[i]] != s.ByClass[classes[j]] {
+			return s.ByClass[classes[i]] > s.ByClass[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%s: %d", class, s.ByClass[class])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String describes the failures, e.g. "12 of 5000 keys failed (0.2%; rate_limited: 10, server: 2)"
+func (s SearchErrorStats) String() string {
+	if s.Failed == 0 {
+		return fmt.Sprintf("no failures in %d keys", s.Checked)
+	}
+	return fmt.Sprintf("%d of %d keys failed (%.1f%%; %s)", s.Failed, s.Checked, 100*s.Rate(), s.Classes())
+}
+
+// searchErrorCounter counts failures from concurrent search workers and decides when
+// their rate aborts the search
+type searchErrorCounter struct {
+	mu           sync.Mutex
+	stats        SearchErrorStats
+	failOnErrors bool
+	maxRate      float64
+	total        int
+	aborted      bool
+}
+
+// newSearchErrorCounter returns a counter for a search over total keys. With failOnErrors,
+// the search aborts once more than maxRate of the checked keys failed.
+func newSearchErrorCounter(total int, failOnErrors bool, maxRate float64) *searchErrorCounter {
+	return &searchErrorCounter{
+		stats:        SearchErrorStats{ByClass: make(map[ExportErrorClass]int)},
+		failOnErrors: failOnErrors,
+		maxRate:      maxRate,
+		total:        total,
+	}
+}
+
+// record counts a checked key, with the error of reading its metadata if that failed, and
+// returns true if the search must abort
+func (c *searchErrorCounter) record(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Checked++
+	if err != nil {
+		c.stats.Failed++
+		c.stats.ByClass[ClassifyExportError(err)]++
+	}
+
+	if c.failOnErrors && !c.aborted && c.stats.Failed > 0 && c.stats.Rate() > c.maxRate &&
+		(c.maxRate == 0 || c.stats.Checked >= min(searchErrorMinChecked, c.total)) {
+		c.aborted = true
+	}
+	return c.aborted
+}
+
+// stopped returns true once the search was aborted
+func (c *searchErrorCounter) stopped() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aborted
+}
+
+// snapshot returns a copy of the counts
+func (c *searchErrorCounter) snapshot() SearchErrorStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.ByClass = make(map[ExportErrorClass]int, len(c.stats.ByClass))
+	for class, n := range c.stats.ByClass {
+		stats.ByClass[class] = n
+	}
+	return stats
+}
+
+// err returns the error of an aborted search, or nil
+func (c *searchErrorCounter) err() error {
+	if !c.stopped() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, above the limit of %.1f%%", ErrSearchErrorRate, c.snapshot(), 100*c.maxRate)
+}
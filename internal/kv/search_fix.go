@@ -18,6 +18,7 @@ type EnhancedSearchOptions struct {
 	IncludeMetadata bool          // Include metadata with keys
 	BatchSize       int           // Batch size for bulk operations
 	Concurrency     int           // Number of concurrent operations
+	Prefix          string        // Only search keys with this prefix, filtered by the list API
 	Timeout         time.Duration // Overall timeout for the operation
 	Verbose         bool          // Enable verbose output
 	Debug           bool          // Enable debug output
@@ -58,7 +59,7 @@ func EnhancedStreamingFilterKeysByMetadata(
 	pagOptions := &common.PaginationOptions{
 		Debug:      options.Debug,
 		Verbose:    options.Verbose,
-		MaxRetries: 3,
+		MaxRetries: common.CurrentPolicy().Retries,
 		Timeout:    options.Timeout,
 		LogPrefix:  "Search",
 	}
@@ -70,6 +71,7 @@ func EnhancedStreamingFilterKeysByMetadata(
 		namespaceID:      namespaceID,
 		tagField:         tagField,
 		tagValue:         tagValue,
+		prefix:           options.Prefix,
 		includeMetadata:  options.IncludeMetadata,
 		batchSize:        options.BatchSize,
 		concurrency:      options.Concurrency,
@@ -103,6 +105,7 @@ type tagSearchHandler struct {
 	namespaceID      string
 	tagField         string
 	tagValue         string
+	prefix           string
 	includeMetadata  bool
 	batchSize        int
 	concurrency      int
@@ -120,6 +123,7 @@ func (h *tagSearchHandler) FetchPage(cursor string) (interface{}, string, bool,
 	listOptions := &ListKeysOptions{
 		Limit:  h.batchSize,
 		Cursor: cursor,
+		Prefix: h.prefix,
 		// Note: ListKeysOptions doesn't have an IncludeMetadata field,
 		// but ListKeysWithOptions will return metadata by default
 	}
@@ -309,7 +313,7 @@ func EnhancedSmartFindKeysWithValue(
 	pagOptions := &common.PaginationOptions{
 		Debug:      options.Debug,
 		Verbose:    options.Verbose,
-		MaxRetries: 3,
+		MaxRetries: common.CurrentPolicy().Retries,
 		Timeout:    options.Timeout,
 		LogPrefix:  "Deep Search",
 	}
@@ -320,6 +324,7 @@ func EnhancedSmartFindKeysWithValue(
 		accountID:        accountID,
 		namespaceID:      namespaceID,
 		searchValue:      searchValue,
+		prefix:           options.Prefix,
 		includeMetadata:  options.IncludeMetadata,
 		batchSize:        options.BatchSize,
 		concurrency:      options.Concurrency,
@@ -352,6 +357,7 @@ type valueSearchHandler struct {
 	accountID        string
 	namespaceID      string
 	searchValue      string
+	prefix           string
 	includeMetadata  bool
 	batchSize        int
 	concurrency      int
@@ -369,6 +375,7 @@ func (h *valueSearchHandler) FetchPage(cursor string) (interface{}, string, bool
 	listOptions := &ListKeysOptions{
 		Limit:  h.batchSize,
 		Cursor: cursor,
+		Prefix: h.prefix,
 		// Note: ListKeysOptions doesn't have an IncludeMetadata field,
 		// but ListKeysWithOptions will return metadata by default
 	}
@@ -0,0 +1,167 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// SearchPage is a window of search matches, found by scanning keys in name order
+// only until the window is full
+type SearchPage struct {
+	Keys    []KeyValuePair `json:"keys"`
+	Scanned int            `json:"scanned"`  // Keys checked against the criteria
+	HasMore bool           `json:"has_more"` // Scanning stopped with keys left to check, so more matches may exist
+	LastKey string         `json:"last_key"` // Last key returned, to continue from with AfterKey
+}
+
+// Limited returns true if the search options ask for a window of the matches
+// rather than all of them
+func (o SearchOptions) Limited() bool {
+	return o.Limit > 0 || o.Offset > 0 || o.AfterKey != ""
+}
+
+// SearchKeysPage searches a namespace page by page in key name order and stops as soon
+// as Offset+Limit matches are found, so a capped search of a large namespace only reads
+// the metadata of the keys it gets through. Keys up to AfterKey are listed but skipped
+// without any metadata requests; continuing from the LastKey of a previous page is
+// therefore much cheaper than a growing Offset.
+func SearchKeysPage(ctx context.Context, client *api.Client, accountID, namespaceID string, options SearchOptions) (*SearchPage, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.Limit < 0 || options.Offset < 0 {
+		return nil, fmt.Errorf("limit and offset cannot be negative")
+	}
+
+	nameFilter, err := NewNameFilter(options.Prefix, options.NameContains, options.NameRegex)
+	if err != nil {
+		return nil, err
+	}
+	if options.SearchValue == "" && options.TagField == "" && nameFilter == nil {
+		return nil, fmt.Errorf("search requires SearchValue, TagField, or a key name filter to be specified")
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	page := &SearchPage{Keys: []KeyValuePair{}}
+	matched := 0
+	cursor := ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := ListKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{
+			Limit:  1000,
+			Prefix: options.Prefix,
+			Cursor: cursor,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys: %w", err)
+		}
+
+		// Name and expiration criteria need no requests, so apply them first
+		candidates := make([]KeyValuePair, 0, len(result.Keys))
+		for _, key := range result.Keys {
+			if options.AfterKey != "" && key.Key <= options.AfterKey {
+				continue
+			}
+			if nameFilter.Match(key.Key) && options.Expiration.Match(key) {
+				candidates = append(candidates, key)
+			}
+		}
+
+		for start := 0; start < len(candidates); start += batchSize {
+			end := start + batchSize
+			if end > len(candidates) {
+				end = len(candidates)
+			}
+			batch := candidates[start:end]
+			matches := matchSearchBatch(client, accountID, namespaceID, batch, options)
+
+			for i, key := range batch {
+				page.Scanned++
+				if !matches[i] {
+					continue
+				}
+				matched++
+				if matched <= options.Offset {
+					continue
+				}
+
+				page.Keys = append(page.Keys, batch[i])
+				page.LastKey = key.Key
+				if options.Limit > 0 && len(page.Keys) >= options.Limit {
+					// Listed keys come back sorted, so anything after this one is unchecked
+					lastListed := result.Keys[len(result.Keys)-1].Key
+					page.HasMore = key.Key != lastListed || result.Cursor != ""
+					return page, nil
+				}
+			}
+		}
+
+		if result.Cursor == "" {
+			break
+		}
+		cursor = result.Cursor
+	}
+
+	return page, nil
+}
+
+// matchSearchBatch checks a batch of keys against the metadata criteria of a search,
+// fetching metadata concurrently for keys listed without it. Matched keys have their
+// metadata filled in. Keys whose metadata cannot be fetched do not match.
+func matchSearchBatch(client *api.Client, accountID, namespaceID string, batch []KeyValuePair, options SearchOptions) []bool {
+	matches := make([]bool, len(batch))
+	if options.SearchValue == "" && options.TagField == "" {
+		for i := range matches {
+			matches[i] = true
+		}
+		return matches
+	}
+
+	concurrency := options.Concurrency
+	concurrency = common.CurrentPolicy().Concurrency(concurrency, 10)
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for i := range batch {
+		if batch[i].Metadata == nil {
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				if metadata, err := GetMetadata(client, accountID, namespaceID, batch[i].Key); err == nil {
+					batch[i].Metadata = metadata
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+
+	for i, key := range batch {
+		if key.Metadata == nil {
+			continue
+		}
+		metadata := map[string]interface{}(*key.Metadata)
+		if options.SearchValue != "" {
+			matches[i] = SmartMetadataSearch(metadata, options.SearchValue)
+			continue
+		}
+		matches[i] = common.MetadataFieldMatches(metadata, options.TagField, options.TagValue)
+	}
+	return matches
+}
@@ -0,0 +1,198 @@
+package kv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// NameFilter matches keys by name only, so searching with it never needs
+// per-key metadata or value requests
+type NameFilter struct {
+	Prefix   string         // Server-side prefix passed to the list API
+	Contains string         // Substring the key name must contain
+	Regex    *regexp.Regexp // Pattern the key name must match
+}
+
+// NewNameFilter builds a name filter, returning nil if no criteria are given
+func NewNameFilter(prefix, contains, pattern string) (*NameFilter, error) {
+	if prefix == "" && contains == "" && pattern == "" {
+		return nil, nil
+	}
+
+	filter := &NameFilter{Prefix: prefix, Contains: contains}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name regex '%s': %w", pattern, err)
+		}
+		filter.Regex = re
+	}
+
+	return filter, nil
+}
+
+// Match returns true if the key name satisfies every criterion of the filter
+func (f *NameFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	if f.Prefix != "" && !strings.HasPrefix(name, f.Prefix) {
+		return false
+	}
+	if f.Contains != "" && !strings.Contains(name, f.Contains) {
+		return false
+	}
+	if f.Regex != nil && !f.Regex.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// FilterKeysByName returns the keys whose names match the filter
+func FilterKeysByName(keys []KeyValuePair, filter *NameFilter) []KeyValuePair {
+	if filter == nil {
+		return keys
+	}
+
+	matched := make([]KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		if filter.Match(key.Key) {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// KeyExclusion protects keys from a bulk operation by prefix, name pattern or exact name
+type KeyExclusion struct {
+	Prefixes []string
+	Regex    *regexp.Regexp
+	Keys     map[string]bool
+}
+
+// NewKeyExclusion builds a key exclusion, returning nil if no criteria are given
+func NewKeyExclusion(prefixes []string, pattern string, keys []string) (*KeyExclusion, error) {
+	if len(prefixes) == 0 && pattern == "" && len(keys) == 0 {
+		return nil, nil
+	}
+
+	exclusion := &KeyExclusion{Prefixes: prefixes, Keys: make(map[string]bool, len(keys))}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern '%s': %w", pattern, err)
+		}
+		exclusion.Regex = re
+	}
+	for _, key := range keys {
+		exclusion.Keys[key] = true
+	}
+
+	return exclusion, nil
+}
+
+// Excludes returns true if the key name matches any criterion of the exclusion
+func (e *KeyExclusion) Excludes(name string) bool {
+	if e == nil {
+		return false
+	}
+	if e.Keys[name] {
+		return true
+	}
+	for _, prefix := range e.Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return e.Regex != nil && e.Regex.MatchString(name)
+}
+
+// Apply returns the key names that are not excluded and the number that were
+func (e *KeyExclusion) Apply(names []string) ([]string, int) {
+	if e == nil {
+		return names, 0
+	}
+
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if !e.Excludes(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept, len(names) - len(kept)
+}
+
+// FindKeysByName pages through the key listing and keeps only the keys whose names
+// match the filter. Names are filtered page by page, so non-matching keys are never
+// accumulated and no metadata or values are fetched.
+func FindKeysByName(client *api.Client, accountID, namespaceID string, filter *NameFilter,
+	progressCallback func(fetched, matched int)) ([]KeyValuePair, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("name filter is required")
+	}
+
+	pagOptions := &common.PaginationOptions{
+		MaxRetries: 3,
+		Timeout:    120 * time.Second,
+		LogPrefix:  "Name search",
+	}
+
+	handler := &nameSearchHandler{
+		keyListingHandler: keyListingHandler{
+			client:      client,
+			accountID:   accountID,
+			namespaceID: namespaceID,
+			options:     &ListKeysOptions{Limit: 1000, Prefix: filter.Prefix},
+			allKeys:     []KeyValuePair{},
+		},
+		filter:           filter,
+		progressCallback: progressCallback,
+	}
+
+	result, err := common.ExecutePagination(handler, pagOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search key names: %w", err)
+	}
+
+	warnCursorRestarts(result)
+
+	return handler.allKeys, nil
+}
+
+// nameSearchHandler is a key listing handler that drops non-matching names as each page arrives
+type nameSearchHandler struct {
+	keyListingHandler
+	filter           *NameFilter
+	fetched          int
+	progressCallback func(fetched, matched int)
+}
+
+// ProcessItems filters a page of keys by name before collecting them
+func (h *nameSearchHandler) ProcessItems(items interface{}) error {
+	keys, ok := items.([]KeyValuePair)
+	if !ok {
+		return fmt.Errorf("unexpected item type in key name search")
+	}
+
+	h.fetched += len(keys)
+	if err := h.keyListingHandler.ProcessItems(FilterKeysByName(keys, h.filter)); err != nil {
+		return err
+	}
+
+	if h.progressCallback != nil {
+		h.progressCallback(h.fetched, len(h.allKeys))
+	}
+	return nil
+}
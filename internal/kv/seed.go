@@ -0,0 +1,146 @@
+package kv
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// seedAlphabet is the characters random seed values are made of
+const seedAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// SeedOptions configures generated test data
+type SeedOptions struct {
+	Count         int      // Number of keys to generate
+	Prefix        string   // Key name prefix; keys are named <prefix><index>, zero-padded
+	ValueSize     int      // Length of random values, when no ValueTemplate is set
+	ValueTemplate string   // Value template with {key}, {index} and {tag} placeholders
+	TagField      string   // Metadata field to write a tag into, empty for no tags
+	Tags          []string // Tags assigned to the keys in turn
+	Seed          int64    // Random seed; the same seed generates the same data
+	ExpirationTTL int64    // Expiration of each key in seconds, 0 for none
+	BatchSize     int      // Keys per bulk write
+	DryRun        bool     // Generate the keys without writing them
+}
+
+// SeedResult is the outcome of writing generated test data
+type SeedResult struct {
+	Generated int               `json:"generated"`
+	Written   int               `json:"written"`
+	First     string            `json:"first_key,omitempty"`
+	Last      string            `json:"last_key,omitempty"`
+	TagCounts map[string]int    `json:"tag_counts,omitempty"` // Keys per tag
+	Failed    map[string]string `json:"failed,omitempty"`     // Key to error message
+	DryRun    bool              `json:"dry_run,omitempty"`
+}
+
+// GenerateSeedItems generates deterministic test keys: the same options always give the
+// same key names, values and metadata. Each key's metadata records its index and, with a
+// tag field, one of the tags in turn.
+func GenerateSeedItems(options SeedOptions) ([]BulkWriteItem, error) {
+	if options.Count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+	if options.ValueSize < 0 {
+		return nil, fmt.Errorf("value size cannot be negative")
+	}
+	if options.TagField == "" && len(options.Tags) > 0 {
+		return nil, fmt.Errorf("tags require a tag field")
+	}
+	if options.ExpirationTTL != 0 && options.ExpirationTTL < 60 {
+		return nil, fmt.Errorf("expiration TTL must be at least 60 seconds")
+	}
+
+	width := len(strconv.Itoa(options.Count - 1))
+	random := rand.New(rand.NewSource(options.Seed))
+
+	items := make([]BulkWriteItem, options.Count)
+	for i := range items {
+		key := fmt.Sprintf("%s%0*d", options.Prefix, width, i)
+		tag := ""
+		if len(options.Tags) > 0 {
+			tag = options.Tags[i%len(options.Tags)]
+		}
+
+		var value string
+		if options.ValueTemplate != "" {
+			value = strings.NewReplacer("{key}", key, "{index}", strconv.Itoa(i), "{tag}", tag).Replace(options.ValueTemplate)
+		} else {
+			value = randomSeedValue(random, options.ValueSize)
+		}
+
+		metadata := map[string]interface{}{"seed_index": i}
+		if options.TagField != "" && tag != "" {
+			metadata[options.TagField] = tag
+		}
+
+		items[i] = BulkWriteItem{
+			Key:           key,
+			Value:         value,
+			ExpirationTTL: options.ExpirationTTL,
+			Metadata:      metadata,
+		}
+	}
+	return items, nil
+}
+
+// randomSeedValue returns size random alphanumeric characters
+func randomSeedValue(random *rand.Rand, size int) string {
+	value := make([]byte, size)
+	for i := range value {
+		value[i] = seedAlphabet[random.Intn(len(seedAlphabet))]
+	}
+	return string(value)
+}
+
+// SeedNamespace generates test data and writes it with the bulk API. The progress
+// callback is called after each batch with the number of keys written so far.
+func SeedNamespace(client *api.Client, accountID, namespaceID string, options SeedOptions,
+	progressCallback func(written, total int)) (*SeedResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+
+	items, err := GenerateSeedItems(options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SeedResult{
+		Generated: len(items),
+		First:     items[0].Key,
+		Last:      items[len(items)-1].Key,
+		DryRun:    options.DryRun,
+	}
+	if options.TagField != "" && len(options.Tags) > 0 {
+		result.TagCounts = make(map[string]int)
+		for _, item := range items {
+			if tag, ok := item.Metadata[options.TagField].(string); ok {
+				result.TagCounts[tag]++
+			}
+		}
+	}
+	if options.DryRun {
+		return result, nil
+	}
+
+	done := 0
+	written, failed := writeValues(client, accountID, namespaceID, items, options.BatchSize, func(batch int) {
+		done += batch
+		if progressCallback != nil {
+			progressCallback(done, len(items))
+		}
+	})
+	result.Written = len(written)
+	if len(failed) > 0 {
+		result.Failed = failed
+	}
+	return result, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
 )
 
 // KVService provides a unified interface for KV operations
@@ -83,6 +85,14 @@ type BulkDeleteOptions struct {
 	TagField        string
 	TagValue        string
 	SearchValue     string
+	Exclude         *KeyExclusion    // Keys never deleted, applied after the other filters
+	Expiration      ExpirationFilter // Only delete keys with, or without, an expiration
+	// BeforeDelete is called with the keys about to be deleted, e.g. to journal them.
+	// An error aborts the deletion.
+	BeforeDelete func(keys []string) error
+	// OnMatch is called with the matched keys before the dry run returns or anything is
+	// deleted, e.g. to save or check a plan. An error aborts the deletion.
+	OnMatch func(keys []string) error
 }
 
 // SearchOptions represents options for searching keys
@@ -90,9 +100,22 @@ type SearchOptions struct {
 	TagField        string
 	TagValue        string
 	SearchValue     string
+	Prefix          string // Only return keys with this prefix
+	NameContains    string // Key name must contain this substring
+	NameRegex       string // Key name must match this regex
 	IncludeMetadata bool
 	BatchSize       int
 	Concurrency     int
+	Expiration      ExpirationFilter // Only match keys with, or without, an expiration
+	Limit           int              // Stop once this many matches are found, 0 for all
+	Offset          int              // Skip this many matches before collecting
+	AfterKey        string           // Only consider keys sorted after this key name
+	// FailOnErrors aborts a value search once more than MaxErrorRate (0 to 1) of the checked
+	// keys couldn't be read; otherwise they are skipped and counted
+	FailOnErrors bool
+	MaxErrorRate float64
+	Progress     ProgressFunc           // Progress of a value search, with its failures
+	OnFailures   func(SearchErrorStats) // Called with the failures of a value search when it ends
 }
 
 // CloudflareKVService implements the KVService interface using Cloudflare API
@@ -226,7 +249,17 @@ func (s *CloudflareKVService) ListAll(ctx context.Context, accountID, namespaceI
 // Get gets a value for a key
 func (s *CloudflareKVService) Get(ctx context.Context, accountID, namespaceID, key string, options ServiceGetOptions) (*KeyValuePair, error) {
 	if options.IncludeMetadata {
-		return GetKeyWithMetadata(s.client, accountID, namespaceID, key)
+		pair, err := GetKeyWithMetadata(s.client, accountID, namespaceID, key)
+		if err != nil {
+			return nil, err
+		}
+		if pair.Value, err = assembleStoredValue(s.client, accountID, namespaceID, key, pair.Value, pair.Metadata); err != nil {
+			return nil, err
+		}
+		if pair.Value, err = decodeStoredValue(pair.Value, pair.Metadata); err != nil {
+			return nil, err
+		}
+		return pair, nil
 	}
 
 	// Just get the value without metadata
@@ -234,6 +267,9 @@ func (s *CloudflareKVService) Get(ctx context.Context, accountID, namespaceID, k
 	if err != nil {
 		return nil, err
 	}
+	if value, err = decodeValue(s.client, accountID, namespaceID, key, value); err != nil {
+		return nil, err
+	}
 
 	return &KeyValuePair{
 		Key:   key,
@@ -246,9 +282,16 @@ func (s *CloudflareKVService) Put(ctx context.Context, accountID, namespaceID, k
 	return WriteValue(s.client, accountID, namespaceID, key, value, &options)
 }
 
-// Delete deletes a value for a key
+// Delete deletes a value for a key, and the chunks of the value if it is split
 func (s *CloudflareKVService) Delete(ctx context.Context, accountID, namespaceID, key string) error {
-	return DeleteValue(s.client, accountID, namespaceID, key)
+	chunks, err := StoredChunkKeys(s.client, accountID, namespaceID, key)
+	if err != nil {
+		return err
+	}
+	if err := DeleteValue(s.client, accountID, namespaceID, key); err != nil {
+		return err
+	}
+	return DeleteStaleChunks(s.client, accountID, namespaceID, key, chunks, 0)
 }
 
 // Exists checks if a key exists
@@ -291,7 +334,7 @@ func (s *CloudflareKVService) BulkGet(ctx context.Context, accountID, namespaceI
 			metadataOpts.BatchSize = 100
 		}
 		if metadataOpts.Concurrency == 0 {
-			metadataOpts.Concurrency = 50
+			metadataOpts.Concurrency = common.CurrentPolicy().MaxConcurrency
 		}
 
 		metadataMap, _ := BatchFetchMetadataOptimized(ctx, s.client, accountID, namespaceID, existingKeys, metadataOpts)
@@ -305,6 +348,14 @@ func (s *CloudflareKVService) BulkGet(ctx context.Context, accountID, namespaceI
 			if metadata, ok := metadataMap[key]; ok {
 				kvp.Metadata = metadata
 			}
+			decoded, err := assembleStoredValue(s.client, accountID, namespaceID, key, value, kvp.Metadata)
+			if err == nil {
+				decoded, err = decodeStoredValue(decoded, kvp.Metadata)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("key '%s': %w", key, err)
+			}
+			kvp.Value = decoded
 			result = append(result, kvp)
 		}
 	} else {
@@ -315,6 +366,9 @@ func (s *CloudflareKVService) BulkGet(ctx context.Context, accountID, namespaceI
 				// Skip keys that don't exist
 				continue
 			}
+			if value, err = decodeValue(s.client, accountID, namespaceID, key, value); err != nil {
+				return nil, fmt.Errorf("key '%s': %w", key, err)
+			}
 			result = append(result, KeyValuePair{
 				Key:   key,
 				Value: value,
@@ -340,18 +394,19 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 	verbose := func(format string, args ...interface{}) {
 		// Print verbose information in verbose mode
 		if options.Verbose {
-			fmt.Printf("[VERBOSE] "+format+"\n", args...)
+			common.LogVerbose(format, args...)
 		}
 	}
 
 	debug := func(format string, args ...interface{}) {
 		// Only print debug information in debug mode
 		if options.Debug {
-			fmt.Printf("[DEBUG] "+format+"\n", args...)
+			common.LogDebug(format, args...)
 		}
 	}
 	// Handle filtering first to get an accurate count for dry run
 	var keysToDelete []string
+	var listed []KeyValuePair // Listed or matched keys, with the metadata that marks split values
 
 	// If keys are provided, use them directly
 	if len(keys) > 0 {
@@ -363,7 +418,9 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 		// 2. Non-empty prefix filtering
 		// 3. Empty prefix specified with --prefix ""
 		// 4. Pattern-based filtering
-		shouldListAllKeys := options.AllKeys || options.Prefix != "" || options.PrefixSpecified || options.Pattern != ""
+		// 5. Expiration-based filtering
+		shouldListAllKeys := options.AllKeys || options.Prefix != "" || options.PrefixSpecified || options.Pattern != "" ||
+			options.Expiration != AnyExpiration
 
 		if shouldListAllKeys {
 			debug("Finding keys with criteria: prefix='%s', pattern='%s', allKeys=%v",
@@ -383,17 +440,74 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 			verbose("Found %d keys matching criteria", len(allKeys))
 			debug("Matched keys count: %d, proceeding with deletion", len(allKeys))
 
+			// Narrow the listing by the name pattern
+			if options.Pattern != "" {
+				nameFilter, err := NewNameFilter("", "", options.Pattern)
+				if err != nil {
+					return 0, err
+				}
+				allKeys = FilterKeysByName(allKeys, nameFilter)
+				verbose("%d keys match pattern '%s'", len(allKeys), options.Pattern)
+			}
+
+			// Narrow the listing by expiration
+			if options.Expiration != AnyExpiration {
+				allKeys = FilterKeysByExpiration(allKeys, options.Expiration)
+				verbose("%d keys match the expiration filter", len(allKeys))
+			}
+
 			// Extract key names
 			keysToDelete = make([]string, len(allKeys))
 			for i, key := range allKeys {
 				keysToDelete[i] = key.Key
 			}
+			listed = allKeys
 		} else {
 			verbose("No keys or filtering criteria provided")
 			debug("Empty criteria, no keys to process")
 		}
 	}
 
+	// Exclusions, name patterns, expiration filters and the BeforeDelete and OnMatch hooks
+	// need the matched keys, so find tag and search matches before deleting
+	if (options.Exclude != nil || options.BeforeDelete != nil || options.OnMatch != nil || options.Expiration != AnyExpiration ||
+		options.Pattern != "") && (options.TagField != "" || options.SearchValue != "") {
+		matches, err := s.Search(ctx, accountID, namespaceID, SearchOptions{
+			TagField:    options.TagField,
+			TagValue:    options.TagValue,
+			SearchValue: options.SearchValue,
+			Prefix:      options.Prefix,
+			NameRegex:   options.Pattern,
+			Expiration:  options.Expiration,
+			BatchSize:   options.BatchSize,
+			Concurrency: options.Concurrency,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to find matching keys: %w", err)
+		}
+		keysToDelete = make([]string, len(matches))
+		for i, key := range matches {
+			keysToDelete[i] = key.Key
+		}
+		listed = matches
+		options.TagField, options.TagValue, options.SearchValue = "", "", ""
+	}
+
+	// Drop excluded keys
+	if options.Exclude != nil {
+		var excluded int
+		keysToDelete, excluded = options.Exclude.Apply(keysToDelete)
+		if excluded > 0 {
+			fmt.Printf("Excluded %d keys matching the exclusion filters\n", excluded)
+		}
+	}
+
+	if options.OnMatch != nil {
+		if err := options.OnMatch(keysToDelete); err != nil {
+			return 0, err
+		}
+	}
+
 	// If we have tag-based filtering or search, use the appropriate functions
 	if options.TagField != "" || options.SearchValue != "" {
 		verbose("Using advanced filtering with tag field '%s' or search value '%s'",
@@ -425,6 +539,24 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 		return 0, nil
 	}
 
+	// Deleting a split value deletes its chunks too. Keys given by name carry no metadata,
+	// so theirs is read to find the split ones.
+	var err error
+	if listed != nil {
+		keysToDelete, err = WithChunkKeys(s.client, accountID, namespaceID, keysToDelete, listed)
+	} else {
+		keysToDelete, err = withStoredChunkKeys(ctx, s.client, accountID, namespaceID, keysToDelete, options.Concurrency)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if options.BeforeDelete != nil {
+		if err := options.BeforeDelete(keysToDelete); err != nil {
+			return 0, err
+		}
+	}
+
 	verbose("Deleting %d keys", len(keysToDelete))
 	debug("Starting deletion process for %d keys", len(keysToDelete))
 
@@ -437,6 +569,9 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 			percent := float64(completed) / float64(total) * 100
 			verbose("Progress: %d/%d keys deleted (%.1f%%)", completed, total, percent)
 			debug("Batch deletion progress: %d/%d (%.1f%%)", completed, total, percent)
+			if quota, ok := common.CurrentRateLimitStatus(); ok {
+				verbose("API quota: %s", quota)
+			}
 		}
 	}
 
@@ -447,7 +582,8 @@ func (s *CloudflareKVService) BulkDelete(ctx context.Context, accountID, namespa
 		debug("Initializing concurrent deletion with %d workers, batch size %d", options.Concurrency, options.BatchSize)
 		successCount, errs := DeleteMultipleValuesConcurrently(s.client, accountID, namespaceID, keysToDelete, options.BatchSize, options.Concurrency, progressCallback)
 		if len(errs) > 0 {
-			return successCount, errs[0] // Return the first error encountered
+			// Keys rejected by the API are reported together; other failures by the first error
+			return successCount, mergeBulkDeleteErrors(errs)
 		}
 		return successCount, nil
 	} else {
@@ -481,33 +617,26 @@ func (s *CloudflareKVService) bulkDeleteWithAdvancedFiltering(ctx context.Contex
 	verbose := func(format string, args ...interface{}) {
 		// Print verbose information in verbose mode
 		if options.Verbose {
-			fmt.Printf("[VERBOSE] "+format+"\n", args...)
+			common.LogVerbose(format, args...)
 		}
 	}
 
 	debug := func(format string, args ...interface{}) {
 		// Only print debug information in debug mode
 		if options.Debug {
-			fmt.Printf("[DEBUG] "+format+"\n", args...)
+			common.LogDebug(format, args...)
 		}
 	}
 
 	// Define a progress callback for showing batch progress in verbose mode
-	var progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)
+	var progressCallback ProgressFunc
 
 	// Only create callback in verbose mode
 	if options.Verbose {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
-			// Show detailed progress information
-			if total > 0 {
-				fetchPercent := float64(keysFetched) / float64(total) * 100
-				procPercent := float64(keysProcessed) / float64(total) * 100
-				debug("Progress: %d/%d keys fetched (%.1f%%), %d/%d processed (%.1f%%), %d matched, %d deleted",
-					keysFetched, total, fetchPercent, keysProcessed, total, procPercent, keysMatched, keysDeleted)
-			} else {
-				debug("Progress: %d keys fetched, %d processed, %d matched, %d deleted",
-					keysFetched, keysProcessed, keysMatched, keysDeleted)
-			}
+		progressCallback = func(event ProgressEvent) {
+			// Show detailed progress information, with the rate and time remaining
+			debug("Progress: %s (%d fetched, %d matched, %d deleted, %s elapsed)",
+				event, event.Fetched, event.Matched, event.Deleted, event.Elapsed.Round(time.Second))
 		}
 	}
 
@@ -516,13 +645,13 @@ func (s *CloudflareKVService) bulkDeleteWithAdvancedFiltering(ctx context.Contex
 		verbose("Using smart purge by value '%s'", options.SearchValue)
 		debug("Starting smart purge operation with search value '%s'", options.SearchValue)
 		// Use smart purge by value
-		return SmartPurgeByValue(s.client, accountID, namespaceID, options.SearchValue,
+		return SmartPurgeByValue(s.client, accountID, namespaceID, options.Prefix, options.SearchValue,
 			options.BatchSize, options.Concurrency, options.DryRun, progressCallback)
 	} else if options.TagField != "" {
 		verbose("Using tag-based purge with field '%s', value '%s'", options.TagField, options.TagValue)
 		debug("Starting tag-based purge with metadata field '%s', value '%s'", options.TagField, options.TagValue)
 		// Use tag-based purge
-		return PurgeByMetadataOnly(s.client, accountID, namespaceID, options.TagField, options.TagValue,
+		return PurgeByMetadataOnly(s.client, accountID, namespaceID, options.Prefix, options.TagField, options.TagValue,
 			options.BatchSize, options.Concurrency, options.DryRun, progressCallback)
 	}
 
@@ -532,15 +661,59 @@ func (s *CloudflareKVService) bulkDeleteWithAdvancedFiltering(ctx context.Contex
 
 // Search searches for keys with specific criteria
 func (s *CloudflareKVService) Search(ctx context.Context, accountID, namespaceID string, options SearchOptions) ([]KeyValuePair, error) {
+	if options.Limited() {
+		page, err := SearchKeysPage(ctx, s.client, accountID, namespaceID, options)
+		if err != nil {
+			return nil, err
+		}
+		return page.Keys, nil
+	}
+
+	nameFilter, err := NewNameFilter(options.Prefix, options.NameContains, options.NameRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []KeyValuePair
 	if options.SearchValue != "" {
-		// Use smart search
-		return SmartFindKeysWithValue(s.client, accountID, namespaceID, options.SearchValue,
-			options.BatchSize, options.Concurrency, nil)
+		// Use smart search, counting keys whose metadata can't be read
+		tracker := newProgressTracker(options.Progress)
+		var failures SearchErrorStats
+		keys, failures, err = FindKeysWithValue(s.client, accountID, namespaceID, options.SearchValue, ValueSearchOptions{
+			Prefix:       options.Prefix,
+			ChunkSize:    options.BatchSize,
+			Concurrency:  options.Concurrency,
+			Names:        nameFilter,
+			Expiration:   options.Expiration,
+			FailOnErrors: options.FailOnErrors,
+			MaxErrorRate: options.MaxErrorRate,
+			Progress: func(keysFetched, keysProcessed, keysMatched, total int, failures SearchErrorStats) {
+				event := purgeProgress(keysFetched, keysProcessed, keysMatched, 0, total)
+				event.Failed, event.FailedByClass = failures.Failed, failures.ByClass
+				tracker.report(event)
+			},
+		})
+		if options.OnFailures != nil {
+			options.OnFailures(failures)
+		}
 	} else if options.TagField != "" {
 		// Use tag-based search
-		return StreamingFilterKeysByMetadata(s.client, accountID, namespaceID, options.TagField,
-			options.TagValue, options.BatchSize, options.Concurrency, nil)
+		keys, err = filterKeysByMetadata(s.client, accountID, namespaceID, nameFilter, options.Expiration, options.TagField,
+			options.TagValue, options.BatchSize, nil)
+	} else if nameFilter != nil {
+		// Names only: the cheapest strategy, filtering during pagination without metadata calls
+		keys, err = FindKeysByName(s.client, accountID, namespaceID, nameFilter, nil)
+		if err != nil {
+			return nil, err
+		}
+		return FilterKeysByExpiration(keys, options.Expiration), nil
+	} else {
+		return nil, fmt.Errorf("search requires SearchValue, TagField, or a key name filter to be specified")
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("search requires either SearchValue or TagField to be specified")
+	// Value and tag searches narrowed the keys by name and expiration before fetching metadata
+	return keys, nil
 }
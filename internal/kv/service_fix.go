@@ -3,6 +3,9 @@ package kv
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"cache-kv-purger/internal/common"
 )
 
 // Updated bulkDeleteWithAdvancedFiltering handles complex delete operations with filtering
@@ -12,33 +15,26 @@ func (s *CloudflareKVService) bulkDeleteWithAdvancedFilteringFixed(ctx context.C
 	verbose := func(format string, args ...interface{}) {
 		// Print verbose information in verbose mode
 		if options.Verbose {
-			fmt.Printf("[VERBOSE] "+format+"\n", args...)
+			common.LogVerbose(format, args...)
 		}
 	}
 
 	debug := func(format string, args ...interface{}) {
 		// Only print debug information in debug mode
 		if options.Debug {
-			fmt.Printf("[DEBUG] "+format+"\n", args...)
+			common.LogDebug(format, args...)
 		}
 	}
 
 	// Define a progress callback for showing batch progress in verbose mode
-	var progressCallback func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int)
+	var progressCallback ProgressFunc
 
 	// Only create callback in verbose mode
 	if options.Verbose {
-		progressCallback = func(keysFetched, keysProcessed, keysMatched, keysDeleted, total int) {
-			// Show detailed progress information
-			if total > 0 {
-				fetchPercent := float64(keysFetched) / float64(total) * 100
-				procPercent := float64(keysProcessed) / float64(total) * 100
-				debug("Progress: %d/%d keys fetched (%.1f%%), %d/%d processed (%.1f%%), %d matched, %d deleted",
-					keysFetched, total, fetchPercent, keysProcessed, total, procPercent, keysMatched, keysDeleted)
-			} else {
-				debug("Progress: %d keys fetched, %d processed, %d matched, %d deleted",
-					keysFetched, keysProcessed, keysMatched, keysDeleted)
-			}
+		progressCallback = func(event ProgressEvent) {
+			// Show detailed progress information, with the rate and time remaining
+			debug("Progress: %s (%d fetched, %d matched, %d deleted, %s elapsed)",
+				event, event.Fetched, event.Matched, event.Deleted, event.Elapsed.Round(time.Second))
 		}
 	}
 
@@ -47,13 +43,13 @@ func (s *CloudflareKVService) bulkDeleteWithAdvancedFilteringFixed(ctx context.C
 		verbose("Using smart purge by value '%s'", options.SearchValue)
 		debug("Starting smart purge operation with search value '%s'", options.SearchValue)
 		// Use smart purge by value
-		return SmartPurgeByValue(s.client, accountID, namespaceID, options.SearchValue,
+		return SmartPurgeByValue(s.client, accountID, namespaceID, options.Prefix, options.SearchValue,
 			options.BatchSize, options.Concurrency, options.DryRun, progressCallback)
 	} else if options.TagField != "" {
 		verbose("Using tag-based purge with field '%s', value '%s'", options.TagField, options.TagValue)
 		debug("Starting tag-based purge with metadata field '%s', value '%s'", options.TagField, options.TagValue)
 		// Use tag-based purge with fixed implementation
-		return PurgeByMetadataOnlyFixed(s.client, accountID, namespaceID, options.TagField, options.TagValue,
+		return PurgeByMetadataOnlyFixed(s.client, accountID, namespaceID, options.Prefix, options.TagField, options.TagValue,
 			options.BatchSize, options.Concurrency, options.DryRun, progressCallback)
 	}
 
@@ -67,14 +63,14 @@ func (s *CloudflareKVService) BulkDeleteFixed(ctx context.Context, accountID, na
 	verbose := func(format string, args ...interface{}) {
 		// Print verbose information in verbose mode
 		if options.Verbose {
-			fmt.Printf("[VERBOSE] "+format+"\n", args...)
+			common.LogVerbose(format, args...)
 		}
 	}
 
 	debug := func(format string, args ...interface{}) {
 		// Only print debug information in debug mode
 		if options.Debug {
-			fmt.Printf("[DEBUG] "+format+"\n", args...)
+			common.LogDebug(format, args...)
 		}
 	}
 	// Handle filtering first to get an accurate count for dry run
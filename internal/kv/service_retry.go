@@ -18,13 +18,7 @@ type RetryableKVService struct {
 // NewRetryableKVService creates a new KV service with retry capabilities
 func NewRetryableKVService(service KVService, config *common.RetryConfig) KVService {
 	if config == nil {
-		config = &common.RetryConfig{
-			MaxAttempts:  3,
-			InitialDelay: 500 * time.Millisecond,
-			MaxDelay:     10 * time.Second,
-			Multiplier:   2.0,
-			Jitter:       0.1,
-		}
+		config = common.CurrentPolicy().RetryConfig()
 	}
 
 	return &RetryableKVService{
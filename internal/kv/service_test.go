@@ -0,0 +1,46 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSearchNarrowsByNameBeforeMetadata(t *testing.T) {
+	ns, client := newFakeNamespace(t)
+	const account, namespace = "account", "namespace"
+	for _, key := range []string{"user-1", "user-2", "session-1", "session-2", "other"} {
+		ns.values[key] = "value"
+		ns.metadata[key] = json.RawMessage(`{"cache-tag": "blog"}`)
+	}
+
+	service := NewKVService(client)
+	tests := []struct {
+		name    string
+		options SearchOptions
+	}{
+		{"tag", SearchOptions{TagField: "cache-tag", TagValue: "blog", NameContains: "session"}},
+		{"value", SearchOptions{SearchValue: "blog", NameRegex: "^session-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns.metadataReads = nil
+			keys, err := service.Search(context.Background(), account, namespace, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(keyNamesOf(keys), ","); got != "session-1,session-2" {
+				t.Errorf("matched keys = %s, want session-1,session-2", got)
+			}
+
+			// Only the keys with matching names have their metadata read
+			reads := append([]string(nil), ns.metadataReads...)
+			sort.Strings(reads)
+			if got := strings.Join(reads, ","); got != "session-1,session-2" {
+				t.Errorf("metadata reads = %s, want session-1,session-2", got)
+			}
+		})
+	}
+}
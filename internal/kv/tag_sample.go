@@ -0,0 +1,107 @@
+package kv
+
+import (
+	"fmt"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// DefaultTagSampleScan is how many keys SampleTaggedKeys scans at most by default
+const DefaultTagSampleScan = 100000
+
+// TagSample lists some of the keys whose metadata carries a cache tag
+type TagSample struct {
+	Tag     string   `json:"tag"`
+	Keys    []string `json:"keys"`
+	HasMore bool     `json:"has_more"` // More keys carry the tag than were sampled
+}
+
+// SampleTaggedKeys scans a namespace for keys whose tagField metadata holds one of the
+// tags, and returns up to perTag key names per tag, in the order of the tags. A field
+// holds a tag when it equals it, lists it among comma-separated values, or is an array
+// containing it. Only the metadata returned by the key listing is used, so the scan
+// costs one request per 1000 keys. It stops once every tag has perTag keys or maxScan
+// keys were scanned (0 for DefaultTagSampleScan), and returns the number of keys scanned.
+func SampleTaggedKeys(client *api.Client, accountID, namespaceID, tagField string, tags []string, perTag, maxScan int) ([]TagSample, int, error) {
+	if accountID == "" {
+		return nil, 0, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, 0, fmt.Errorf("namespace ID is required")
+	}
+	if tagField == "" {
+		return nil, 0, fmt.Errorf("tag field is required")
+	}
+	if perTag <= 0 {
+		perTag = 5
+	}
+	if maxScan <= 0 {
+		maxScan = DefaultTagSampleScan
+	}
+
+	samples := make([]TagSample, len(tags))
+	index := make(map[string]int, len(tags))
+	for i, tag := range tags {
+		samples[i] = TagSample{Tag: tag, Keys: []string{}}
+		index[tag] = i
+	}
+	complete := 0
+
+	scanned := 0
+	cursor := ""
+	for scanned < maxScan && complete < len(tags) {
+		result, err := ListKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Limit: 1000, Cursor: cursor})
+		if err != nil {
+			return nil, scanned, fmt.Errorf("failed to list keys: %w", err)
+		}
+
+		for _, key := range result.Keys {
+			scanned++
+			if key.Metadata == nil {
+				continue
+			}
+			for _, tag := range metadataTagValues((*key.Metadata)[tagField]) {
+				i, ok := index[tag]
+				if !ok {
+					continue
+				}
+				switch {
+				case len(samples[i].Keys) < perTag:
+					samples[i].Keys = append(samples[i].Keys, key.Key)
+				case !samples[i].HasMore:
+					samples[i].HasMore = true
+					complete++
+				}
+			}
+		}
+
+		if result.Cursor == "" {
+			break
+		}
+		cursor = result.Cursor
+	}
+
+	return samples, scanned, nil
+}
+
+// metadataTagValues returns the tags held by a metadata field: the comma-separated
+// values of a string, or the string elements of an array
+func metadataTagValues(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
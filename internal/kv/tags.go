@@ -0,0 +1,139 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+	"cache-kv-purger/internal/config"
+)
+
+// TagCount is the number of keys carrying one tag value
+type TagCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// TagReport summarizes the values of a metadata tag field across a namespace
+type TagReport struct {
+	TagField       string     `json:"tag_field"`
+	TotalKeys      int        `json:"total_keys"`
+	TaggedKeys     int        `json:"tagged_keys"`
+	DistinctValues int        `json:"distinct_values"`
+	Tags           []TagCount `json:"tags"`
+}
+
+// TagReportOptions configures a tag report
+type TagReportOptions struct {
+	Prefix      string // Only scan keys with this prefix
+	MinCount    int    // Only report values carried by at least this many keys
+	Concurrency int    // Concurrent metadata requests for keys listed without metadata
+	Delimiter   string // Splits string values into several tags; empty counts each value whole
+}
+
+// BuildTagReport counts how many keys carry each distinct value of a metadata field.
+// Values are read like a tag extract rule for the field, so arrays and delimited strings
+// count each of their tags. It uses the metadata returned by the key listing first and
+// only requests metadata individually for keys that were listed without any.
+func BuildTagReport(client *api.Client, accountID, namespaceID, tagField string, options TagReportOptions,
+	progressCallback func(processed, total int)) (*TagReport, error) {
+
+	if tagField == "" {
+		return nil, fmt.Errorf("tag field is required")
+	}
+	options.Concurrency = common.CurrentPolicy().Concurrency(options.Concurrency, 20)
+
+	keys, err := ListAllKeysWithOptions(client, accountID, namespaceID, &ListKeysOptions{Prefix: options.Prefix}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	rules := []config.TagExtractRule{{Field: tagField, Delimiter: options.Delimiter}}
+	counts := make(map[string]int)
+	tagged := 0
+	processed := 0
+	var mu sync.Mutex
+
+	record := func(metadata *KeyValueMetadata) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		processed++
+		if metadata != nil {
+			if tags := common.ExtractTagsFromMetadata(*metadata, rules); len(tags) > 0 {
+				tagged++
+				for _, tag := range tags {
+					counts[tag]++
+				}
+			}
+		}
+		if progressCallback != nil {
+			progressCallback(processed, len(keys))
+		}
+	}
+
+	// Metadata from the listing needs no extra requests
+	var missing []string
+	for _, key := range keys {
+		if key.Metadata != nil {
+			record(key.Metadata)
+		} else {
+			missing = append(missing, key.Key)
+		}
+	}
+
+	// Fetch metadata for the remaining keys concurrently
+	var wg sync.WaitGroup
+	var firstErr error
+	sem := make(chan struct{}, options.Concurrency)
+
+	for _, key := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			metadata, err := GetMetadata(client, accountID, namespaceID, key)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to get metadata for key '%s': %w", key, err)
+				}
+				mu.Unlock()
+				return
+			}
+			record(metadata)
+		}(key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	report := &TagReport{
+		TagField:       tagField,
+		TotalKeys:      len(keys),
+		TaggedKeys:     tagged,
+		DistinctValues: len(counts),
+		Tags:           []TagCount{},
+	}
+	for value, count := range counts {
+		if count >= options.MinCount {
+			report.Tags = append(report.Tags, TagCount{Value: value, Count: count})
+		}
+	}
+
+	// Most common values first
+	sort.Slice(report.Tags, func(i, j int) bool {
+		if report.Tags[i].Count != report.Tags[j].Count {
+			return report.Tags[i].Count > report.Tags[j].Count
+		}
+		return report.Tags[i].Value < report.Tags[j].Value
+	})
+
+	return report, nil
+}
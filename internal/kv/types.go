@@ -21,18 +21,6 @@ type KeyName struct {
 	Name string `json:"name"`
 }
 
-// KeyValuesResponse represents a response containing multiple key-value pairs
-type KeyValuesResponse struct {
-	Success    bool        `json:"success"`
-	Errors     []api.Error `json:"errors,omitempty"`
-	Messages   []string    `json:"messages,omitempty"`
-	ResultInfo struct {
-		Cursor string `json:"cursor"`
-		Count  int    `json:"count"`
-	} `json:"result_info"`
-	Result []KeyValuePair `json:"result"`
-}
-
 // WriteOptions represents options for writing a value
 type WriteOptions struct {
 	Expiration    int64            `json:"expiration,omitempty"`     // Unix timestamp
@@ -73,6 +61,7 @@ type BulkWriteItem struct {
 	Expiration    int64                  `json:"expiration,omitempty"`
 	ExpirationTTL int64                  `json:"expiration_ttl,omitempty"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Base64        bool                   `json:"base64,omitempty"` // Value is base64-encoded binary data
 }
 
 // BulkWriteResult represents the result of a bulk write operation
@@ -0,0 +1,132 @@
+package kv
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"cache-kv-purger/internal/api"
+	"cache-kv-purger/internal/common"
+)
+
+// DefaultVerifySampleSize is the number of keys re-read when verifying a sample
+const DefaultVerifySampleSize = 100
+
+// VerifyOptions configures verification of written keys
+type VerifyOptions struct {
+	All         bool // Verify every key instead of a random sample
+	SampleSize  int  // Keys to verify when not verifying all (default 100)
+	Concurrency int  // Concurrent value requests
+}
+
+// VerifyResult reports how written keys compare with their source values
+type VerifyResult struct {
+	Checked    int      `json:"checked"`
+	Total      int      `json:"total"`
+	Mismatched []string `json:"mismatched,omitempty"`
+	Missing    []string `json:"missing,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// OK returns true if every checked key was found with the expected value
+func (r *VerifyResult) OK() bool {
+	return len(r.Mismatched) == 0 && len(r.Missing) == 0 && len(r.Errors) == 0
+}
+
+// HashValue returns the hex SHA-256 hash of a value
+func HashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashBulkWriteItems returns the value hashes of bulk write items by key
+func HashBulkWriteItems(items []BulkWriteItem) map[string]string {
+	hashes := make(map[string]string, len(items))
+	for _, item := range items {
+		value := item.Value
+		if item.Base64 {
+			if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+				value = string(decoded)
+			}
+		}
+		hashes[item.Key] = HashValue(value)
+	}
+	return hashes
+}
+
+// storedValueHash returns the SHA-256 hash of a value read from a namespace. A chunk
+// manifest records the hash of the value it was split from, so that hash is returned for it.
+func storedValueHash(value string) string {
+	if strings.HasPrefix(value, chunkManifestPrefix) {
+		if manifest, err := ParseChunkManifest(value); err == nil {
+			return manifest.SHA256
+		}
+	}
+	return HashValue(value)
+}
+
+// VerifyValues re-reads keys from a namespace and compares the SHA-256 hash of each value
+// with the expected hash. Either all keys or a random sample of them are checked.
+func VerifyValues(client *api.Client, accountID, namespaceID string, expected map[string]string,
+	options VerifyOptions) (*VerifyResult, error) {
+
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if namespaceID == "" {
+		return nil, fmt.Errorf("namespace ID is required")
+	}
+	if options.SampleSize <= 0 {
+		options.SampleSize = DefaultVerifySampleSize
+	}
+	options.Concurrency = common.CurrentPolicy().Concurrency(options.Concurrency, 10)
+
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	if !options.All && len(keys) > options.SampleSize {
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		keys = keys[:options.SampleSize]
+	}
+	sort.Strings(keys)
+
+	result := &VerifyResult{Checked: len(keys), Total: len(expected)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, options.Concurrency)
+
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			value, err := GetValue(client, accountID, namespaceID, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil && strings.Contains(err.Error(), "HTTP 404"):
+				result.Missing = append(result.Missing, key)
+			case err != nil:
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", key, err))
+			case storedValueHash(value) != expected[key]:
+				result.Mismatched = append(result.Mismatched, key)
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	sort.Strings(result.Mismatched)
+	sort.Strings(result.Missing)
+	sort.Strings(result.Errors)
+
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyChecksum checks the SHA-256 hash of an archive against its line in a checksums
+// file, which lists one "<hex hash>  <file name>" per line. The checksums file comes from
+// the same release and is not signed, so this catches corrupted or truncated downloads,
+// not a tampered release.
+func VerifyChecksum(checksums []byte, name string, data []byte) error {
+	var expected string
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			expected = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if expected == "" {
+		return fmt.Errorf("%s has no checksum for %s", ChecksumsAsset, name)
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, actual)
+	}
+	return nil
+}
+
+// ExtractBinary returns the binary from a release archive, a .tar.gz or a .zip as
+// given by the archive name
+func ExtractBinary(archiveName string, data []byte, binaryName string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		return extractFromZip(data, binaryName)
+	}
+	return extractFromTarGz(data, binaryName)
+}
+
+// extractFromTarGz returns the file named binaryName from a gzipped tar archive
+func extractFromTarGz(data []byte, binaryName string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return io.ReadAll(tr)
+		}
+	}
+	return nil, fmt.Errorf("archive has no %s binary", binaryName)
+}
+
+// extractFromZip returns the file named binaryName from a zip archive
+func extractFromZip(data []byte, binaryName string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != binaryName {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", binaryName, err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("archive has no %s binary", binaryName)
+}
+
+// ReplaceBinary atomically replaces the executable at exePath with binary, keeping its
+// permissions. The new binary is written next to the old one and renamed over it; the
+// old binary is first moved aside to exePath+".old", since a running executable cannot
+// be overwritten on Windows. The moved-aside file is removed when possible.
+func ReplaceBinary(exePath string, binary []byte) error {
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to read the current binary: %w", err)
+	}
+
+	dir := filepath.Dir(exePath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(exePath)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary next to %s: %w", exePath, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set permissions of the new binary: %w", err)
+	}
+
+	oldPath := exePath + ".old"
+	_ = os.Remove(oldPath)
+	if err := os.Rename(exePath, oldPath); err != nil {
+		return fmt.Errorf("failed to move the current binary aside: %w", err)
+	}
+	if err := os.Rename(tmpPath, exePath); err != nil {
+		// Put the old binary back so the installation keeps working
+		_ = os.Rename(oldPath, exePath)
+		return fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	_ = os.Remove(oldPath)
+	return nil
+}
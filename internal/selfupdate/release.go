@@ -0,0 +1,212 @@
+// Package selfupdate finds, verifies and installs release binaries published on GitHub.
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultRepository is the GitHub repository releases are downloaded from
+	DefaultRepository = "erfianugrah/cache-kv-purger"
+
+	// DefaultAPIURL is the base URL of the GitHub API
+	DefaultAPIURL = "https://api.github.com"
+
+	// ProjectName is the name release archives and the binary inside them start with
+	ProjectName = "cache-kv-purger"
+
+	// ChecksumsAsset is the release asset listing the SHA-256 hash of every archive
+	ChecksumsAsset = "checksums.txt"
+)
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a published GitHub release
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset returns the release asset with the name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Updater looks up releases of a repository
+type Updater struct {
+	HTTPClient *http.Client
+	APIURL     string // Base URL of the GitHub API, DefaultAPIURL if empty
+	Repository string // owner/name, DefaultRepository if empty
+}
+
+// Release returns the release with the tag, or the latest release if tag is empty
+func (u *Updater) Release(ctx context.Context, tag string) (*Release, error) {
+	apiURL := u.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	repository := u.Repository
+	if repository == "" {
+		repository = DefaultRepository
+	}
+
+	path := "/releases/latest"
+	if tag != "" {
+		path = "/releases/tags/" + NormalizeTag(tag)
+	}
+	url := strings.TrimRight(apiURL, "/") + "/repos/" + repository + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		if tag != "" {
+			return nil, fmt.Errorf("release %s not found in %s", NormalizeTag(tag), repository)
+		}
+		return nil, fmt.Errorf("no releases found in %s", repository)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to look up release: GitHub API returned %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// Download fetches a release asset into memory
+func (u *Updater) Download(ctx context.Context, asset Asset) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: server returned %s", asset.Name, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	return data, nil
+}
+
+// client returns the HTTP client of the updater
+func (u *Updater) client() *http.Client {
+	if u.HTTPClient != nil {
+		return u.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// ArchiveName returns the name of the release archive for a platform, following the
+// name template of the release configuration: e.g. cache-kv-purger_Linux_x86_64.tar.gz
+// or cache-kv-purger_Windows_arm64.zip
+func ArchiveName(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+
+	format := "tar.gz"
+	if goos == "windows" {
+		format = "zip"
+	}
+
+	osName := goos
+	if goos != "" {
+		osName = strings.ToUpper(goos[:1]) + goos[1:]
+	}
+	return fmt.Sprintf("%s_%s_%s.%s", ProjectName, osName, arch, format)
+}
+
+// NormalizeTag returns a release tag with its "v" prefix
+func NormalizeTag(tag string) string {
+	if tag == "" || strings.HasPrefix(tag, "v") {
+		return tag
+	}
+	return "v" + tag
+}
+
+// ParseVersion parses a version such as v1.2.3 or 1.2.3-rc1 into its numeric parts.
+// Pre-release and build suffixes are ignored.
+func ParseVersion(version string) ([3]int, error) {
+	var parts [3]int
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+
+	fields := strings.Split(trimmed, ".")
+	if len(fields) == 0 || len(fields) > 3 || fields[0] == "" {
+		return parts, fmt.Errorf("'%s' is not a release version", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("'%s' is not a release version", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// CompareVersions returns -1, 0 or 1 as version a is older than, the same as, or newer
+// than version b
+func CompareVersions(a, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1, nil
+		case va[i] > vb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
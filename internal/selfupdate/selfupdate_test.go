@@ -0,0 +1,168 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "cache-kv-purger_Linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "cache-kv-purger_Darwin_arm64.tar.gz"},
+		{"windows", "amd64", "cache-kv-purger_Windows_x86_64.zip"},
+		{"linux", "386", "cache-kv-purger_Linux_i386.tar.gz"},
+	}
+	for _, tt := range tests {
+		if got := ArchiveName(tt.goos, tt.goarch); got != tt.want {
+			t.Errorf("ArchiveName(%s, %s) = %s, want %s", tt.goos, tt.goarch, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"2.0.0", "v1.99.99", 1},
+		{"v1.2.3-rc1", "v1.2.3", 0},
+		{"v1.2", "v1.2.1", -1},
+	}
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("CompareVersions(%s, %s): %v", tt.a, tt.b, err)
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := CompareVersions("dev", "v1.0.0"); err == nil {
+		t.Error("expected a dev build version to be rejected")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("archive contents")
+	sum := sha256.Sum256(data)
+	checksums := []byte(fmt.Sprintf("%s  other.tar.gz\n%s  cache-kv-purger_Linux_x86_64.tar.gz\n",
+		hex.EncodeToString(make([]byte, 32)), hex.EncodeToString(sum[:])))
+
+	if err := VerifyChecksum(checksums, "cache-kv-purger_Linux_x86_64.tar.gz", data); err != nil {
+		t.Errorf("expected checksum to match, got %v", err)
+	}
+	if err := VerifyChecksum(checksums, "cache-kv-purger_Linux_x86_64.tar.gz", []byte("tampered")); err == nil {
+		t.Error("expected a tampered archive to fail")
+	}
+	if err := VerifyChecksum(checksums, "cache-kv-purger_Darwin_arm64.tar.gz", data); err == nil {
+		t.Error("expected an archive without a checksum to fail")
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho new\n")
+
+	var tgz bytes.Buffer
+	gz := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string][]byte{"README.md": []byte("readme"), "cache-kv-purger": binary} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write(content)
+	}
+	tw.Close()
+	gz.Close()
+
+	got, err := ExtractBinary("cache-kv-purger_Linux_x86_64.tar.gz", tgz.Bytes(), "cache-kv-purger")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("tar.gz: got %q, %v", got, err)
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("cache-kv-purger.exe")
+	w.Write(binary)
+	zw.Close()
+
+	got, err = ExtractBinary("cache-kv-purger_Windows_x86_64.zip", zipped.Bytes(), "cache-kv-purger.exe")
+	if err != nil || !bytes.Equal(got, binary) {
+		t.Errorf("zip: got %q, %v", got, err)
+	}
+
+	if _, err := ExtractBinary("cache-kv-purger_Linux_x86_64.tar.gz", tgz.Bytes(), "missing"); err == nil {
+		t.Error("expected a missing binary to fail")
+	}
+}
+
+func TestReplaceBinary(t *testing.T) {
+	exePath := filepath.Join(t.TempDir(), "cache-kv-purger")
+	if err := os.WriteFile(exePath, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReplaceBinary(exePath, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(exePath)
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected the new binary, got %q, %v", data, err)
+	}
+	info, _ := os.Stat(exePath)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected permissions 0755 to be kept, got %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(exePath + ".old"); !os.IsNotExist(err) {
+		t.Error("expected the old binary to be removed")
+	}
+}
+
+func TestUpdaterRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/latest":
+			fmt.Fprint(w, `{"tag_name":"v1.4.0","assets":[{"name":"checksums.txt","browser_download_url":"http://example/checksums.txt"}]}`)
+		case "/repos/owner/repo/releases/tags/v1.3.0":
+			fmt.Fprint(w, `{"tag_name":"v1.3.0","assets":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	updater := &Updater{HTTPClient: server.Client(), APIURL: server.URL, Repository: "owner/repo"}
+
+	release, err := updater.Release(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.TagName != "v1.4.0" {
+		t.Errorf("expected the latest release v1.4.0, got %s", release.TagName)
+	}
+	if _, ok := release.Asset(ChecksumsAsset); !ok {
+		t.Error("expected the checksums asset")
+	}
+
+	if release, err := updater.Release(context.Background(), "1.3.0"); err != nil || release.TagName != "v1.3.0" {
+		t.Errorf("expected release v1.3.0, got %v, %v", release, err)
+	}
+	if _, err := updater.Release(context.Background(), "v9.9.9"); err == nil {
+		t.Error("expected a missing release to fail")
+	}
+}
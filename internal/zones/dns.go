@@ -0,0 +1,101 @@
+package zones
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// dnsRecordsPerPage is the page size used when listing DNS records
+const dnsRecordsPerPage = 1000
+
+// DNSRecord represents a DNS record in a zone
+type DNSRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Proxied bool   `json:"proxied"`
+}
+
+// ListDNSRecords retrieves all DNS records of a zone, following pagination
+func ListDNSRecords(client *api.Client, zoneID string) ([]DNSRecord, error) {
+	if zoneID == "" {
+		return nil, fmt.Errorf("zone ID is required")
+	}
+
+	path := fmt.Sprintf("/zones/%s/dns_records", zoneID)
+	records, err := api.Paginate[DNSRecord](context.Background(), client, path, nil, dnsRecordsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DNS records: %w", err)
+	}
+
+	return records, nil
+}
+
+// ProxiedHostnames returns the unique hostnames of proxied A, AAAA and CNAME records.
+// Only proxied hostnames are served through the cache, and wildcard names are skipped
+// because they cannot be purged by host.
+func ProxiedHostnames(records []DNSRecord) []string {
+	seen := make(map[string]bool)
+	hostnames := make([]string, 0)
+
+	for _, record := range records {
+		switch record.Type {
+		case "A", "AAAA", "CNAME":
+		default:
+			continue
+		}
+		if !record.Proxied || strings.HasPrefix(record.Name, "*") {
+			continue
+		}
+
+		name := normalizeHostname(record.Name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		hostnames = append(hostnames, name)
+	}
+
+	sort.Strings(hostnames)
+	return hostnames
+}
+
+// ListProxiedHostnames returns the hostnames of a zone whose content is cached
+func ListProxiedHostnames(client *api.Client, zoneID string) ([]string, error) {
+	records, err := ListDNSRecords(client, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return ProxiedHostnames(records), nil
+}
+
+// ExcludeHostnames removes the excluded hostnames from a list, ignoring case and
+// trailing dots. It returns the remaining hostnames and the ones that were removed.
+func ExcludeHostnames(hostnames, excluded []string) ([]string, []string) {
+	skip := make(map[string]bool, len(excluded))
+	for _, host := range excluded {
+		skip[normalizeHostname(host)] = true
+	}
+
+	kept := make([]string, 0, len(hostnames))
+	removed := make([]string, 0)
+	for _, host := range hostnames {
+		if skip[normalizeHostname(host)] {
+			removed = append(removed, host)
+		} else {
+			kept = append(kept, host)
+		}
+	}
+
+	return kept, removed
+}
+
+// normalizeHostname lowercases a hostname and strips surrounding whitespace and a trailing dot
+func normalizeHostname(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
@@ -0,0 +1,36 @@
+package zones
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProxiedHostnames(t *testing.T) {
+	records := []DNSRecord{
+		{Type: "A", Name: "example.com", Proxied: true},
+		{Type: "AAAA", Name: "example.com", Proxied: true},
+		{Type: "CNAME", Name: "CDN.example.com", Proxied: true},
+		{Type: "A", Name: "mail.example.com", Proxied: false},
+		{Type: "A", Name: "*.example.com", Proxied: true},
+		{Type: "TXT", Name: "txt.example.com", Proxied: true},
+		{Type: "CNAME", Name: "api.example.com", Proxied: true},
+	}
+
+	got := ProxiedHostnames(records)
+	want := []string{"api.example.com", "cdn.example.com", "example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProxiedHostnames() = %v, want %v", got, want)
+	}
+}
+
+func TestExcludeHostnames(t *testing.T) {
+	hostnames := []string{"api.example.com", "cdn.example.com", "example.com"}
+
+	kept, removed := ExcludeHostnames(hostnames, []string{"CDN.example.com.", "other.example.com"})
+	if want := []string{"api.example.com", "example.com"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %v, want %v", kept, want)
+	}
+	if want := []string{"cdn.example.com"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+}
@@ -0,0 +1,64 @@
+package zones
+
+import (
+	"fmt"
+	"strings"
+
+	"cache-kv-purger/internal/api"
+)
+
+// enterprisePurgeTypes are the purge types that many plans only allow on Enterprise
+var enterprisePurgeTypes = map[string]string{
+	"tags":     "cache tag",
+	"prefixes": "prefix",
+	"hosts":    "hostname",
+}
+
+// UnsupportedPurgeError reports a purge type the zone's plan does not include
+type UnsupportedPurgeError struct {
+	ZoneName  string
+	PlanName  string
+	PurgeType string
+}
+
+// Error explains which plan is needed and what can be done instead
+func (e *UnsupportedPurgeError) Error() string {
+	return fmt.Sprintf("zone %s is on the %s plan; purging by %s requires Enterprise. "+
+		"Purge the affected URLs with 'cache purge files', pass --fallback-files with a JSON map of %s to URLs, "+
+		"or --skip-plan-check if the zone has the entitlement",
+		e.ZoneName, e.PlanName, enterprisePurgeTypes[e.PurgeType], e.PurgeType)
+}
+
+// IsEnterprisePlan returns true if the plan is an Enterprise plan
+func IsEnterprisePlan(plan *api.ZonePlan) bool {
+	if plan == nil {
+		return false
+	}
+	return strings.EqualFold(plan.LegacyID, "enterprise") ||
+		strings.Contains(strings.ToLower(plan.Name), "enterprise")
+}
+
+// CheckPurgeSupported returns an *UnsupportedPurgeError when the zone's plan does not
+// allow the purge type ("tags", "prefixes" or "hosts"). Zones whose plan is unknown are
+// allowed, so the API has the final say.
+func CheckPurgeSupported(zone api.Zone, purgeType string) error {
+	if _, restricted := enterprisePurgeTypes[purgeType]; !restricted {
+		return nil
+	}
+	if zone.Plan == nil || (zone.Plan.LegacyID == "" && zone.Plan.Name == "") {
+		return nil
+	}
+	if IsEnterprisePlan(zone.Plan) {
+		return nil
+	}
+
+	name := zone.Name
+	if name == "" {
+		name = zone.ID
+	}
+	planName := zone.Plan.Name
+	if planName == "" {
+		planName = zone.Plan.LegacyID
+	}
+	return &UnsupportedPurgeError{ZoneName: name, PlanName: planName, PurgeType: purgeType}
+}
@@ -0,0 +1,33 @@
+package zones
+
+import (
+	"errors"
+	"testing"
+
+	"cache-kv-purger/internal/api"
+)
+
+func TestCheckPurgeSupported(t *testing.T) {
+	pro := api.Zone{ID: "abc", Name: "example.com", Plan: &api.ZonePlan{Name: "Pro Website", LegacyID: "pro"}}
+	enterprise := api.Zone{Name: "example.org", Plan: &api.ZonePlan{Name: "Enterprise Website", LegacyID: "enterprise"}}
+	unknown := api.Zone{Name: "example.net"}
+
+	var unsupported *UnsupportedPurgeError
+	if err := CheckPurgeSupported(pro, "tags"); !errors.As(err, &unsupported) {
+		t.Errorf("expected tag purge on Pro to be unsupported, got %v", err)
+	} else if unsupported.ZoneName != "example.com" || unsupported.PlanName != "Pro Website" {
+		t.Errorf("unexpected error details: %+v", unsupported)
+	}
+
+	if err := CheckPurgeSupported(pro, "files"); err != nil {
+		t.Errorf("expected file purge on Pro to be supported, got %v", err)
+	}
+	for _, purgeType := range []string{"tags", "prefixes", "hosts"} {
+		if err := CheckPurgeSupported(enterprise, purgeType); err != nil {
+			t.Errorf("expected %s purge on Enterprise to be supported, got %v", purgeType, err)
+		}
+		if err := CheckPurgeSupported(unknown, purgeType); err != nil {
+			t.Errorf("expected %s purge with unknown plan to be allowed, got %v", purgeType, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package zones
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -22,7 +23,10 @@ type ZoneDetailsResponse struct {
 // ZoneListResponse is an alias for ZonesResponse for better naming consistency
 type ZoneListResponse = api.ZonesResponse
 
-// ListZones retrieves all zones for an account
+// zonesPerPage is the page size used when listing zones
+const zonesPerPage = 50
+
+// ListZones retrieves all zones for an account, following pagination
 func ListZones(client *api.Client, accountID string) (*ZoneListResponse, error) {
 	// Build query params
 	query := url.Values{}
@@ -30,25 +34,18 @@ func ListZones(client *api.Client, accountID string) (*ZoneListResponse, error)
 		query.Add("account.id", accountID)
 	}
 
-	// Make the request
-	path := "/zones"
-	respBody, err := client.Request(http.MethodGet, path, query, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the response using our generic parser
-	var zones []api.Zone
-	resp, err := api.ParsePaginatedResponse(respBody, &zones)
+	zones, err := api.Paginate[api.Zone](context.Background(), client, "/zones", query, zonesPerPage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list zones: %w", err)
 	}
 
 	// Build the response object
 	zonesResp := &ZoneListResponse{
-		PaginatedResponse: *resp,
-		Result:            zones,
+		Result: zones,
 	}
+	zonesResp.Success = true
+	zonesResp.ResultInfo.Count = len(zones)
+	zonesResp.ResultInfo.Total = len(zones)
 
 	return zonesResp, nil
 }
@@ -62,16 +59,7 @@ func GetZoneByName(client *api.Client, accountID, name string) (*api.Zone, error
 	}
 	query.Add("name", name)
 
-	// Make the request
-	path := "/zones"
-	respBody, err := client.Request(http.MethodGet, path, query, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the response using our generic parser
-	var zones []api.Zone
-	_, err = api.ParsePaginatedResponse(respBody, &zones)
+	zones, err := api.RequestJSON[[]api.Zone](context.Background(), client, http.MethodGet, "/zones", query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find zone: %w", err)
 	}
@@ -135,22 +123,16 @@ func isHexString(s string) bool {
 // GetZoneDetails retrieves details for a specific zone by its ID
 func GetZoneDetails(client *api.Client, zoneID string) (*ZoneDetailsResponse, error) {
 	path := fmt.Sprintf("/zones/%s", zoneID)
-	respBody, err := client.Request(http.MethodGet, path, nil, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var zone api.Zone
-	resp, err := api.ParseAPIResponse(respBody, &zone)
+	resp, err := api.RequestJSON[api.Zone](context.Background(), client, http.MethodGet, path, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get zone details: %w", err)
 	}
 
 	// Build the response object
 	detailsResp := &ZoneDetailsResponse{
-		APIResponse: *resp,
-		Result:      zone,
+		Result: resp,
 	}
+	detailsResp.Success = true
 
 	return detailsResp, nil
 }
@@ -190,7 +172,7 @@ func DetectZonesFromHosts(client *api.Client, accountID string, hosts []string)
 
 		for zoneName, zoneID := range zoneMap {
 			// Check if the host ends with the zone name (with a dot before or exact match)
-			if host == zoneName || strings.HasSuffix(host, "."+zoneName) {
+			if HostInZone(host, zoneName) {
 				// This is a matching zone, but we want the longest match
 				if len(zoneName) > len(longestMatch) {
 					longestMatch = zoneName
@@ -210,6 +192,29 @@ func DetectZonesFromHosts(client *api.Client, accountID string, hosts []string)
 	return hostZones, unknownHosts, nil
 }
 
+// HostInZone returns true if the host is the zone's name or one of its subdomains.
+// Case and a trailing dot are ignored.
+func HostInZone(host, zoneName string) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	zoneName = strings.TrimSuffix(strings.ToLower(zoneName), ".")
+	if zoneName == "" {
+		return false
+	}
+	return host == zoneName || strings.HasSuffix(host, "."+zoneName)
+}
+
+// HostsOutsideZone returns the hosts that are neither the zone's name nor one of its
+// subdomains, in the order given
+func HostsOutsideZone(zoneName string, hosts []string) []string {
+	var outside []string
+	for _, host := range hosts {
+		if !HostInZone(host, zoneName) {
+			outside = append(outside, host)
+		}
+	}
+	return outside
+}
+
 // GroupItemsByZone groups items by zone based on hostname mapping
 // itemsByHost is a map of hostname to the items (e.g., URLs) associated with that host
 // hostZones is a map of hostname to zone ID
@@ -0,0 +1,37 @@
+package zones
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHostInZone(t *testing.T) {
+	tests := []struct {
+		host string
+		zone string
+		want bool
+	}{
+		{"example.com", "example.com", true},
+		{"images.example.com", "example.com", true},
+		{"A.B.Example.COM.", "example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com.evil.net", "example.com", false},
+		{"example.org", "example.com", false},
+		{"example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := HostInZone(tt.host, tt.zone); got != tt.want {
+			t.Errorf("HostInZone(%q, %q) = %v, want %v", tt.host, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestHostsOutsideZone(t *testing.T) {
+	hosts := []string{"api.example.com", "cdn.example.net", "example.com", "notexample.com"}
+	got := HostsOutsideZone("example.com", hosts)
+	want := []string{"cdn.example.net", "notexample.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HostsOutsideZone() = %v, want %v", got, want)
+	}
+}